@@ -112,9 +112,12 @@ func decodeGoToE(c pdf.Cursor, dict pdf.Dict) (*GoToE, error) {
 		return nil, pdf.Error("GoToE action missing D entry")
 	}
 
-	f, err := pdf.Decode(c, dict["F"], file.ExtractSpecification)
-	if err != nil {
-		return nil, err
+	var f *file.Specification
+	if dict["F"] != nil {
+		f, err = pdf.Decode(c, dict["F"], file.ExtractSpecification)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	newWindow := NewWindowDefault
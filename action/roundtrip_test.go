@@ -111,6 +111,10 @@ var actionTestCases = []pdf.Action{
 		D: &destination.Fit{Page: pdf.Integer(0)},
 		T: &TargetNamedChild{Name: pdf.String("child.pdf"), Next: &TargetParent{}},
 	},
+	&GoToE{ // target in the current document
+		D: &destination.Fit{Page: pdf.Integer(0)},
+		T: &TargetNamedChild{Name: pdf.String("child.pdf")},
+	},
 
 	// GoToDp (PDF 2.0)
 	&GoToDp{DPart: pdf.Reference(1)},
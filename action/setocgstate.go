@@ -194,11 +194,11 @@ func decodeSetOCGState(c pdf.Cursor, dict pdf.Dict) (*SetOCGState, error) {
 	var state []OCGStateChange
 	cur := -1
 	for _, obj := range stateArray {
-		op, err := pdf.Optional(c.Name(obj))
+		resolved, err := pdf.Optional(c.Resolve(obj))
 		if err != nil {
 			return nil, err
 		}
-		if op != "" {
+		if op, _ := resolved.(pdf.Name); op != "" {
 			switch OCGOperation(op) {
 			case OCGOperationON, OCGOperationOFF, OCGOperationToggle:
 				state = append(state, OCGStateChange{Op: OCGOperation(op)})
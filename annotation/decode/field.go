@@ -481,7 +481,8 @@ func decodeExportValues(c pdf.Cursor, obj pdf.Object, out *[]string) error {
 // for both export and display) or a two-element [export, display] array. An
 // entry that is neither is skipped (ok is false).
 func decodeChoiceOption(c pdf.Cursor, el pdf.Object) (acroform.ChoiceOption, bool) {
-	if arr, err := pdf.Optional(c.Array(el)); err == nil && len(arr) == 2 {
+	resolved, _ := pdf.Optional(c.Resolve(el))
+	if arr, ok := resolved.(pdf.Array); ok && len(arr) == 2 {
 		export, ok1 := choiceOptionString(c, arr[0])
 		display, ok2 := choiceOptionString(c, arr[1])
 		if ok1 && ok2 {
@@ -0,0 +1,283 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package attachment
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/file"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+	"seehuhn.de/go/pdf/internal/rewrite/rewritetest"
+	"seehuhn.de/go/pdf/nametree"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+var modTime = time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func embeddedFile(name, data string, sum []byte) *file.Specification {
+	stm := &file.Stream{
+		MimeType: "text/plain",
+		ModDate:  modTime,
+		CheckSum: sum,
+		WriteData: func(w io.Writer) error {
+			_, err := io.WriteString(w, data)
+			return err
+		},
+	}
+	return &file.Specification{
+		FileName:      name,
+		EmbeddedFiles: map[string]*file.Stream{"F": stm},
+	}
+}
+
+func checksum(data string) []byte {
+	sum := md5.Sum([]byte(data))
+	return sum[:]
+}
+
+// makeSource writes a two-page document with embedded files in the
+// EmbeddedFiles name tree, in the catalog's AF array and in a file
+// attachment annotation on the second page.
+func makeSource(t *testing.T, extra map[pdf.Name]*file.Specification) *pdf.Reader {
+	t.Helper()
+
+	buf := memfile.New()
+	w, err := pdf.NewWriter(buf, pdf.V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rm := pdf.NewResourceManager(w)
+	embed := func(spec *file.Specification) pdf.Native {
+		obj, err := rm.Embed(spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj
+	}
+
+	report := embed(embeddedFile("docs/report.txt", "quarterly report", checksum("quarterly report")))
+	files := map[pdf.Name]pdf.Object{
+		"report": report,
+		"other":  embed(embeddedFile("report.txt", "another report", nil)),
+	}
+	for key, spec := range extra {
+		files[key] = embed(spec)
+	}
+	treeRef, err := nametree.WriteMap(w, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree := pagetree.NewWriter(w, rm)
+	page2 := w.Alloc()
+	annotRef := w.Alloc()
+	popupRef := w.Alloc()
+	err = w.Put(annotRef, pdf.Dict{
+		"Type":    pdf.Name("Annot"),
+		"Subtype": pdf.Name("FileAttachment"),
+		"Rect":    &pdf.Rectangle{LLx: 10, LLy: 10, URx: 30, URy: 30},
+		"FS":      embed(embeddedFile("scan.png", "not really a PNG", nil)),
+		"Popup":   popupRef,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Put(popupRef, pdf.Dict{
+		"Type":    pdf.Name("Annot"),
+		"Subtype": pdf.Name("Popup"),
+		"Rect":    &pdf.Rectangle{LLx: 40, LLy: 10, URx: 140, URy: 60},
+		"Parent":  annotRef,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ref := range []pdf.Reference{w.Alloc(), page2} {
+		dict := pdf.Dict{
+			"Type":     pdf.Name("Page"),
+			"MediaBox": &pdf.Rectangle{URx: 200, URy: 200},
+		}
+		if i == 1 {
+			dict["Annots"] = pdf.Array{annotRef, popupRef}
+		}
+		if err := tree.AppendPageDict(ref, dict); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cat := w.GetMeta().Catalog
+	cat.Pages = pagesRef
+	cat.Names = pdf.Dict{"EmbeddedFiles": treeRef}
+	cat.AF = pdf.Array{report}
+	if err := rm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(buf, int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func names(t *testing.T, r pdf.Getter) []string {
+	t.Helper()
+
+	files, err := List(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var res []string
+	for _, a := range files {
+		res = append(res, a.Name)
+	}
+	return res
+}
+
+func TestList(t *testing.T) {
+	r := makeSource(t, nil)
+	files, err := List(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type summary struct {
+		name  string
+		kinds []Kind
+	}
+	var got []summary
+	for _, a := range files {
+		s := summary{name: a.Name}
+		for _, loc := range a.Locations {
+			s.kinds = append(s.kinds, loc.Kind)
+		}
+		got = append(got, s)
+	}
+	want := []summary{
+		{"report.txt", []Kind{NameTree}},
+		{"report (2).txt", []Kind{NameTree, AssociatedFile}},
+		{"scan.png", []Kind{Annotation}},
+	}
+	if !slices.EqualFunc(got, want, func(a, b summary) bool {
+		return a.name == b.name && slices.Equal(a.kinds, b.kinds)
+	}) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestFS(t *testing.T) {
+	fsys, err := NewFS(makeSource(t, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(fsys, "report.txt", "report (2).txt", "scan.png"); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := fsys.ReadFile("report (2).txt")
+	if err != nil || string(data) != "quarterly report" {
+		t.Errorf("got %q, %v", data, err)
+	}
+	info, err := fs.Stat(fsys, "scan.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() != 16 || !info.ModTime().Equal(modTime) {
+		t.Errorf("got size %d, time %s", info.Size(), info.ModTime())
+	}
+}
+
+func TestChecksum(t *testing.T) {
+	bad := embeddedFile("bad.bin", "data", checksum("different data"))
+	fsys, err := NewFS(makeSource(t, map[pdf.Name]*file.Specification{"bad": bad}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = fsys.Open("bad.bin")
+	if !errors.Is(err, ErrChecksum) {
+		t.Errorf("got %v, want ErrChecksum", err)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := makeSource(t, nil)
+
+	buf := &bytes.Buffer{}
+	err := Write(buf, r, &Changes{
+		Add:    []*File{{Name: "scan.png", Data: []byte("new file"), MimeType: "image/png"}},
+		Remove: []string{"report (2).txt", "scan.png"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := pdf.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"report.txt", "scan.png"}
+	if got := names(t, out); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if af := out.GetMeta().Catalog.AF; af != nil {
+		t.Errorf("unexpected AF array %v", af)
+	}
+	_, page, err := pagetree.GetPage(out, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if page["Annots"] != nil {
+		t.Errorf("unexpected annotations %v", page["Annots"])
+	}
+
+	fsys, err := NewFS(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := fsys.ReadFile("scan.png")
+	if err != nil || string(data) != "new file" {
+		t.Errorf("got %q, %v", data, err)
+	}
+}
+
+func TestWriteCatalog(t *testing.T) {
+	r := rewritetest.Source(t)
+	res := rewritetest.Rewrite(t, r, func(w io.Writer, r pdf.Getter) error {
+		return Write(w, r, &Changes{
+			Add: []*File{{Name: "notes.txt", Data: []byte("notes")}},
+		})
+	})
+	rewritetest.CheckCatalog(t, res)
+	if got := names(t, res); !slices.Equal(got, []string{"notes.txt"}) {
+		t.Errorf("got %v, want [notes.txt]", got)
+	}
+}
@@ -0,0 +1,40 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package attachment gives access to the files embedded in a PDF document.
+//
+// PDF documents can carry embedded files in three places: in the
+// EmbeddedFiles name tree of the document, in file attachment annotations
+// on the pages, and in the associated files (AF) arrays of the document
+// catalog and of the pages.  [List] collects the embedded files from all
+// these places.  A file which is referenced from several places, for
+// example from the EmbeddedFiles name tree and from the AF array, is
+// listed once.  Each file is given a name which is unique within the
+// document, based on the file name in the file specification.  File
+// specifications which refer to external files are ignored.
+//
+// [NewFS] presents the embedded files as an [io/fs.FS] with a single,
+// flat directory.  The sizes and modification times are taken from the
+// parameters of the embedded file streams.  If an embedded file stream
+// carries an MD5 checksum, the file data is verified when the file is
+// opened, and [ErrChecksum] is returned on a mismatch.
+//
+// [Write] copies a document while adding and removing embedded files.
+// New files are added to the EmbeddedFiles name tree.  Removed files are
+// taken out of all places where they are referenced; file attachment
+// annotations for removed files are deleted, together with their pop-up
+// annotations.
+package attachment
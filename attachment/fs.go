@@ -0,0 +1,242 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package attachment
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+	"time"
+
+	"seehuhn.de/go/pdf"
+)
+
+var errNotDir = errors.New("not a directory")
+
+// FS presents the embedded files of a document as a file system.  All
+// files are in the root directory.  FS implements [fs.ReadDirFS],
+// [fs.ReadFileFS] and [fs.StatFS].
+type FS struct {
+	files  []*Attachment
+	byName map[string]*Attachment
+}
+
+var (
+	_ fs.ReadDirFS  = (*FS)(nil)
+	_ fs.ReadFileFS = (*FS)(nil)
+	_ fs.StatFS     = (*FS)(nil)
+)
+
+// NewFS returns a file system which contains the embedded files of r.
+func NewFS(r pdf.Getter) (*FS, error) {
+	files, err := List(r)
+	if err != nil {
+		return nil, err
+	}
+	f := &FS{
+		files:  files,
+		byName: make(map[string]*Attachment, len(files)),
+	}
+	for _, a := range files {
+		f.byName[a.Name] = a
+	}
+	return f, nil
+}
+
+// Attachments returns the embedded files, in the order given by [List].
+func (f *FS) Attachments() []*Attachment {
+	return slices.Clone(f.files)
+}
+
+// Open opens the named file.  If the file carries an MD5 checksum which
+// does not match the file data, an error wrapping [ErrChecksum] is
+// returned.
+func (f *FS) Open(name string) (fs.File, error) {
+	if name == "." {
+		return &dir{entries: f.entries()}, nil
+	}
+	a, err := f.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := a.ReadAll()
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return &openFile{
+		Reader: bytes.NewReader(data),
+		info:   &fileInfo{a: a, size: int64(len(data))},
+	}, nil
+}
+
+// ReadFile returns the contents of the named file.  The checksum is
+// verified as for [FS.Open].
+func (f *FS) ReadFile(name string) ([]byte, error) {
+	a, err := f.lookup("readfile", name)
+	if err != nil {
+		return nil, err
+	}
+	data, err := a.ReadAll()
+	if err != nil {
+		return nil, &fs.PathError{Op: "readfile", Path: name, Err: err}
+	}
+	return data, nil
+}
+
+// ReadDir returns the entries of the root directory, sorted by file name.
+func (f *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name == "." {
+		return f.entries(), nil
+	}
+	if _, err := f.lookup("readdir", name); err != nil {
+		return nil, err
+	}
+	return nil, &fs.PathError{Op: "readdir", Path: name, Err: errNotDir}
+}
+
+// Stat returns information about the named file.  If the size of the file
+// is not recorded in the embedded file stream, the file data is read to
+// determine the size.  The checksum is not verified.
+func (f *FS) Stat(name string) (fs.FileInfo, error) {
+	if name == "." {
+		return dirInfo{}, nil
+	}
+	a, err := f.lookup("stat", name)
+	if err != nil {
+		return nil, err
+	}
+	info := &fileInfo{a: a, size: a.Stream.Size}
+	if info.size <= 0 {
+		n, err := a.size()
+		if err != nil {
+			return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+		}
+		info.size = n
+	}
+	return info, nil
+}
+
+func (f *FS) lookup(op, name string) (*Attachment, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+	a := f.byName[name]
+	if a == nil {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+	return a, nil
+}
+
+func (f *FS) entries() []fs.DirEntry {
+	res := make([]fs.DirEntry, len(f.files))
+	for i, a := range f.files {
+		res[i] = &dirEntry{f: f, a: a}
+	}
+	slices.SortFunc(res, func(a, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+	return res
+}
+
+// size returns the length of the file data.
+func (a *Attachment) size() (int64, error) {
+	var w countingWriter
+	if err := a.Stream.WriteData(&w); err != nil {
+		return 0, err
+	}
+	return int64(w), nil
+}
+
+type countingWriter int64
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	*w += countingWriter(len(p))
+	return len(p), nil
+}
+
+// openFile is an open embedded file.
+type openFile struct {
+	*bytes.Reader
+	info *fileInfo
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *openFile) Close() error               { return nil }
+
+// fileInfo describes an embedded file.  Sys returns the [*Attachment].
+type fileInfo struct {
+	a    *Attachment
+	size int64
+}
+
+func (fi *fileInfo) Name() string       { return fi.a.Name }
+func (fi *fileInfo) Size() int64        { return fi.size }
+func (fi *fileInfo) Mode() fs.FileMode  { return 0o444 }
+func (fi *fileInfo) ModTime() time.Time { return fi.a.ModTime() }
+func (fi *fileInfo) IsDir() bool        { return false }
+func (fi *fileInfo) Sys() any           { return fi.a }
+
+// dirEntry is an entry of the root directory.
+type dirEntry struct {
+	f *FS
+	a *Attachment
+}
+
+func (e *dirEntry) Name() string               { return e.a.Name }
+func (e *dirEntry) IsDir() bool                { return false }
+func (e *dirEntry) Type() fs.FileMode          { return 0 }
+func (e *dirEntry) Info() (fs.FileInfo, error) { return e.f.Stat(e.a.Name) }
+
+// dir is the open root directory.
+type dir struct {
+	entries []fs.DirEntry
+	pos     int
+}
+
+func (d *dir) Stat() (fs.FileInfo, error) { return dirInfo{}, nil }
+func (d *dir) Close() error               { return nil }
+
+func (d *dir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: ".", Err: fs.ErrInvalid}
+}
+
+func (d *dir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.pos:]
+	if n <= 0 {
+		d.pos = len(d.entries)
+		return slices.Clone(rest), nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(rest))
+	d.pos += n
+	return slices.Clone(rest[:n]), nil
+}
+
+// dirInfo describes the root directory.
+type dirInfo struct{}
+
+func (dirInfo) Name() string       { return "." }
+func (dirInfo) Size() int64        { return 0 }
+func (dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0o555 }
+func (dirInfo) ModTime() time.Time { return time.Time{} }
+func (dirInfo) IsDir() bool        { return true }
+func (dirInfo) Sys() any           { return nil }
@@ -0,0 +1,311 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package attachment
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/file"
+	"seehuhn.de/go/pdf/nametree"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// ErrChecksum is returned when the data of an embedded file does not match
+// the MD5 checksum stored in the embedded file stream.
+var ErrChecksum = errors.New("checksum mismatch")
+
+// Kind describes where in the document an embedded file is referenced.
+type Kind int
+
+// These are the places where embedded files can be referenced.
+const (
+	// NameTree is used for files in the EmbeddedFiles name tree.
+	NameTree Kind = iota + 1
+
+	// Annotation is used for files in file attachment annotations.
+	Annotation
+
+	// AssociatedFile is used for files in the AF array of the document
+	// catalog or of a page.
+	AssociatedFile
+)
+
+func (k Kind) String() string {
+	switch k {
+	case NameTree:
+		return "EmbeddedFiles"
+	case Annotation:
+		return "annotation"
+	case AssociatedFile:
+		return "AF"
+	default:
+		return fmt.Sprintf("Kind(%d)", int(k))
+	}
+}
+
+// Location describes one place where an embedded file is referenced.
+type Location struct {
+	Kind Kind
+
+	// Page is the zero-based page number for annotations and for the AF
+	// arrays of pages, and -1 for the document level.
+	Page int
+
+	// Key is the key in the EmbeddedFiles name tree.  This is only used
+	// for [NameTree] locations.
+	Key pdf.Name
+
+	// index is the position in the Annots or AF array.
+	index int
+}
+
+// Attachment describes an embedded file.
+type Attachment struct {
+	// Name is the name of the file.  This is unique within the document,
+	// and is a valid name for [io/fs.FS].
+	Name string
+
+	// Spec is the file specification of the embedded file.
+	Spec *file.Specification
+
+	// Stream is the embedded file stream.
+	Stream *file.Stream
+
+	// Locations lists the places where the file is referenced.
+	Locations []Location
+}
+
+// ModTime returns the modification time of the file.  If this is not
+// known, the creation time is used.  The zero time is returned if neither
+// is known.
+func (a *Attachment) ModTime() time.Time {
+	if !a.Stream.ModDate.IsZero() {
+		return a.Stream.ModDate
+	}
+	return a.Stream.CreationDate
+}
+
+// ReadAll returns the contents of the file.  If the embedded file stream
+// carries an MD5 checksum which does not match the data, an error wrapping
+// [ErrChecksum] is returned.
+func (a *Attachment) ReadAll() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	if err := a.Stream.WriteData(buf); err != nil {
+		return nil, err
+	}
+	data := buf.Bytes()
+	if len(a.Stream.CheckSum) == md5.Size {
+		sum := md5.Sum(data)
+		if !bytes.Equal(sum[:], a.Stream.CheckSum) {
+			return nil, fmt.Errorf("%s: %w", a.Name, ErrChecksum)
+		}
+	}
+	return data, nil
+}
+
+// List returns the embedded files of a document.  The files from the
+// EmbeddedFiles name tree come first, in the order of the keys, followed by
+// the files from the document's AF array and the files referenced from the
+// pages.
+func List(r pdf.Getter) ([]*Attachment, error) {
+	l := &lister{
+		c:     pdf.NewCursor(r),
+		byRef: map[pdf.Reference]*Attachment{},
+		names: map[string]bool{},
+	}
+	cat := r.GetMeta().Catalog
+
+	names, err := l.c.Dict(cat.Names)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	if names != nil {
+		tree, err := nametree.ExtractInMemory(r, names["EmbeddedFiles"])
+		if pdf.IsReadError(err) {
+			return nil, err
+		}
+		if tree != nil {
+			for _, key := range slices.Sorted(maps.Keys(tree.Data)) {
+				loc := Location{Kind: NameTree, Page: -1, Key: key}
+				if err := l.add(tree.Data[key], loc, string(key)); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	if err := l.addAF(cat.AF, -1); err != nil {
+		return nil, err
+	}
+
+	pageNo := 0
+	it := pagetree.NewIterator(r)
+	for _, dict := range it.All() {
+		annots, err := l.c.Array(dict["Annots"])
+		if pdf.IsReadError(err) {
+			return nil, err
+		}
+		for i, obj := range annots {
+			annot, err := l.c.Dict(obj)
+			if pdf.IsReadError(err) {
+				return nil, err
+			}
+			if annot == nil {
+				continue
+			}
+			if tp, _ := l.c.Name(annot["Subtype"]); tp != "FileAttachment" {
+				continue
+			}
+			loc := Location{Kind: Annotation, Page: pageNo, index: i}
+			if err := l.add(annot["FS"], loc, ""); err != nil {
+				return nil, err
+			}
+		}
+		if err := l.addAF(dict["AF"], pageNo); err != nil {
+			return nil, err
+		}
+		pageNo++
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+
+	return l.files, nil
+}
+
+type lister struct {
+	c     pdf.Cursor
+	files []*Attachment
+	byRef map[pdf.Reference]*Attachment
+	names map[string]bool
+}
+
+// addAF adds the files from an AF array.
+func (l *lister) addAF(obj pdf.Object, pageNo int) error {
+	af, err := l.c.Array(obj)
+	if pdf.IsReadError(err) {
+		return err
+	}
+	for i, spec := range af {
+		loc := Location{Kind: AssociatedFile, Page: pageNo, index: i}
+		if err := l.add(spec, loc, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// add records a reference to the file specification obj.  The name is used
+// if the file specification does not give a file name.
+func (l *lister) add(obj pdf.Object, loc Location, name string) error {
+	ref, isRef := obj.(pdf.Reference)
+	if a := l.byRef[ref]; isRef && a != nil {
+		a.Locations = append(a.Locations, loc)
+		return nil
+	}
+
+	spec, err := file.ExtractSpecification(l.c, obj, false)
+	if pdf.IsReadError(err) {
+		return err
+	} else if err != nil || spec == nil {
+		return nil
+	}
+	stm := EmbeddedStream(spec)
+	if stm == nil {
+		return nil
+	}
+
+	if specName := FileName(spec); specName != "" {
+		name = specName
+	}
+	a := &Attachment{
+		Name:      l.uniqueName(name),
+		Spec:      spec,
+		Stream:    stm,
+		Locations: []Location{loc},
+	}
+	l.files = append(l.files, a)
+	if isRef {
+		l.byRef[ref] = a
+	}
+	return nil
+}
+
+// uniqueName converts a file name from a file specification into a name
+// which is valid for [io/fs.FS] and not yet used in the document.
+func (l *lister) uniqueName(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = path.Base(name)
+	if name == "." || name == ".." || name == "/" || !isValidName(name) {
+		name = "attachment"
+	}
+
+	res := name
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 2; l.names[res]; i++ {
+		res = fmt.Sprintf("%s (%d)%s", stem, i, ext)
+	}
+	l.names[res] = true
+	return res
+}
+
+// isValidName reports whether name can be used as a file name in the
+// root directory of an [io/fs.FS].
+func isValidName(name string) bool {
+	if strings.ContainsAny(name, "/\x00") {
+		return false
+	}
+	return strings.ToValidUTF8(name, "") == name
+}
+
+// FileName returns the name of the file described by spec.  The Unicode
+// file name is used, if present.
+func FileName(spec *file.Specification) string {
+	if spec.FileNameUnicode != "" {
+		return spec.FileNameUnicode
+	}
+	if spec.FileName != "" {
+		return spec.FileName
+	}
+	for _, s := range []pdf.String{spec.FileNameUnix, spec.FileNameMac, spec.FileNameDOS} {
+		if len(s) > 0 {
+			return string(s)
+		}
+	}
+	return ""
+}
+
+// EmbeddedStream returns the embedded file stream of a file specification,
+// or nil if the file is not embedded.
+func EmbeddedStream(spec *file.Specification) *file.Stream {
+	for _, key := range []string{"UF", "F", "Unix", "Mac", "DOS"} {
+		if stm := spec.EmbeddedFiles[key]; stm != nil && stm.WriteData != nil {
+			return stm
+		}
+	}
+	return nil
+}
@@ -0,0 +1,388 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package attachment
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"path"
+	"strings"
+	"time"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/file"
+	"seehuhn.de/go/pdf/internal/rewrite"
+	"seehuhn.de/go/pdf/nametree"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// File is a file to be embedded in a document.
+type File struct {
+	// Name is the file name.  This is also used as the key in the
+	// EmbeddedFiles name tree.
+	Name string
+
+	// Data is the contents of the file.
+	Data []byte
+
+	// MimeType (optional) is the MIME type of the file, for example
+	// "application/pdf".
+	MimeType string
+
+	// ModDate (optional) is the modification time of the file.
+	ModDate time.Time
+
+	// Description (optional) is shown to the user as the description of
+	// the file.
+	Description string
+}
+
+// Changes describes the edits made by [Write].
+type Changes struct {
+	// Add lists files to add to the EmbeddedFiles name tree.
+	Add []*File
+
+	// Remove lists the names of embedded files to remove, as given by
+	// [List].
+	Remove []string
+}
+
+// Write reads the document from r, applies the changes, and writes the
+// result to w.
+func Write(w io.Writer, r pdf.Getter, ch *Changes) error {
+	if ch == nil {
+		ch = &Changes{}
+	}
+	for _, f := range ch.Add {
+		if f.Name == "" {
+			return errors.New("missing file name")
+		}
+	}
+
+	files, err := List(r)
+	if err != nil {
+		return err
+	}
+	byName := make(map[string]*Attachment, len(files))
+	for _, a := range files {
+		byName[a.Name] = a
+	}
+	rem := &removals{
+		keys:   map[pdf.Name]bool{},
+		af:     map[int]map[int]bool{},
+		annots: map[int]map[int]bool{},
+	}
+	for _, name := range ch.Remove {
+		a := byName[name]
+		if a == nil {
+			return fmt.Errorf("%s: no such attachment", name)
+		}
+		for _, loc := range a.Locations {
+			rem.add(loc)
+		}
+	}
+
+	refs, dicts, err := rewrite.ReadPages(r)
+	if err != nil {
+		return err
+	}
+
+	version := pdf.GetVersion(r)
+	if len(ch.Add) > 0 {
+		version = max(version, pdf.V1_3)
+	}
+	metaIn := r.GetMeta()
+	out, err := rewrite.NewWriter(w, r, version)
+	if err != nil {
+		return err
+	}
+	rm := pdf.NewResourceManager(out)
+	wr := &writer{
+		x:    pdf.NewExtractor(r),
+		out:  out,
+		rm:   rm,
+		copy: pdf.NewCopier(out, r),
+		rem:  rem,
+	}
+
+	newRefs := rewrite.RedirectPages(out, wr.copy, refs)
+
+	tree := pagetree.NewWriter(out, rm)
+	for i, dict := range dicts {
+		newDict, err := wr.copyPage(i, dict)
+		if err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+		if err := tree.AppendPageDict(newRefs[i], newDict); err != nil {
+			return err
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		return err
+	}
+
+	meta := out.GetMeta()
+	meta.Info = metaIn.Info
+	if err := wr.copyCatalog(meta.Catalog, metaIn.Catalog, ch.Add); err != nil {
+		return err
+	}
+	meta.Catalog.Pages = pagesRef
+
+	if err := rm.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// removals lists the references to removed files.
+type removals struct {
+	keys   map[pdf.Name]bool
+	af     map[int]map[int]bool // page number (-1 for the catalog) -> index
+	annots map[int]map[int]bool // page number -> index
+}
+
+func (rem *removals) add(loc Location) {
+	switch loc.Kind {
+	case NameTree:
+		rem.keys[loc.Key] = true
+	case AssociatedFile:
+		addIndex(rem.af, loc.Page, loc.index)
+	case Annotation:
+		addIndex(rem.annots, loc.Page, loc.index)
+	}
+}
+
+func addIndex(m map[int]map[int]bool, pageNo, idx int) {
+	if m[pageNo] == nil {
+		m[pageNo] = map[int]bool{}
+	}
+	m[pageNo][idx] = true
+}
+
+type writer struct {
+	x    *pdf.Extractor
+	out  *pdf.Writer
+	rm   *pdf.ResourceManager
+	copy *pdf.Copier
+	rem  *removals
+}
+
+// copyPage copies a page dictionary, leaving out the removed annotations
+// and associated files.
+func (wr *writer) copyPage(pageNo int, src pdf.Dict) (pdf.Dict, error) {
+	dropAnnots := wr.rem.annots[pageNo]
+	dropAF := wr.rem.af[pageNo]
+	if dropAnnots == nil && dropAF == nil {
+		return wr.copy.CopyDict(src)
+	}
+
+	cur := pdf.CursorAt(wr.x, nil)
+	dict := maps.Clone(src)
+	if dropAnnots != nil {
+		annots, err := cur.Array(src["Annots"])
+		if err != nil {
+			return nil, err
+		}
+
+		// Pop-up annotations of removed annotations are removed as well.
+		popups := map[pdf.Reference]bool{}
+		for i := range dropAnnots {
+			if i >= len(annots) {
+				continue
+			}
+			annot, err := cur.Dict(annots[i])
+			if pdf.IsReadError(err) {
+				return nil, err
+			}
+			if ref, ok := annot["Popup"].(pdf.Reference); ok {
+				popups[ref] = true
+			}
+		}
+		var keep pdf.Array
+		for i, obj := range annots {
+			if ref, ok := obj.(pdf.Reference); dropAnnots[i] || ok && popups[ref] {
+				continue
+			}
+			keep = append(keep, obj)
+		}
+		setOrDelete(dict, "Annots", keep)
+	}
+	if dropAF != nil {
+		af, err := wr.filterAF(src["AF"], dropAF)
+		if err != nil {
+			return nil, err
+		}
+		setOrDelete(dict, "AF", af)
+	}
+	return wr.copy.CopyDict(dict)
+}
+
+// filterAF returns the elements of an AF array which are not removed.
+func (wr *writer) filterAF(obj pdf.Object, drop map[int]bool) (pdf.Array, error) {
+	af, err := pdf.CursorAt(wr.x, nil).Array(obj)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	var keep pdf.Array
+	for i, spec := range af {
+		if !drop[i] {
+			keep = append(keep, spec)
+		}
+	}
+	return keep, nil
+}
+
+func setOrDelete(dict pdf.Dict, key pdf.Name, arr pdf.Array) {
+	if len(arr) > 0 {
+		dict[key] = arr
+	} else {
+		delete(dict, key)
+	}
+}
+
+// copyCatalog copies the document-level entries of the catalog, with the
+// changes applied to the EmbeddedFiles name tree and the AF array.
+func (wr *writer) copyCatalog(dst, src *pdf.Catalog, add []*File) error {
+	err := rewrite.CopyCatalog(wr.out, wr.copy, src, "AF", "Names")
+	if err != nil {
+		return err
+	}
+
+	af, err := wr.filterAF(src.AF, wr.rem.af[-1])
+	if err != nil {
+		return err
+	}
+	if len(af) > 0 {
+		copied, err := wr.copy.Copy(af)
+		if err != nil {
+			return err
+		}
+		dst.AF = copied
+	}
+
+	names, err := wr.names(src.Names, add)
+	if err != nil {
+		return err
+	}
+	dst.Names = names
+	return nil
+}
+
+// names returns the name dictionary of the output document, with the
+// changes applied to the EmbeddedFiles name tree.
+func (wr *writer) names(obj pdf.Object, add []*File) (pdf.Object, error) {
+	dictIn, err := pdf.CursorAt(wr.x, nil).Dict(obj)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	if len(add) == 0 && len(wr.rem.keys) == 0 {
+		if dictIn == nil {
+			return nil, nil
+		}
+		return wr.copy.Copy(obj.AsPDF(wr.out.GetOptions()))
+	}
+
+	files := map[pdf.Name]pdf.Object{}
+	if dictIn != nil {
+		tree, err := nametree.ExtractInMemory(wr.x.R, dictIn["EmbeddedFiles"])
+		if pdf.IsReadError(err) {
+			return nil, err
+		}
+		if tree != nil {
+			for key, val := range tree.Data {
+				if wr.rem.keys[key] {
+					continue
+				}
+				nv, ok := val.(pdf.Native)
+				if !ok || nv == nil {
+					continue
+				}
+				copied, err := wr.copy.Copy(nv)
+				if err != nil {
+					return nil, err
+				}
+				files[key] = copied
+			}
+		}
+	}
+	for _, f := range add {
+		spec, err := wr.rm.Embed(wr.specification(f))
+		if err != nil {
+			return nil, err
+		}
+		files[uniqueKey(files, f.Name)] = spec
+	}
+
+	rest := maps.Clone(dictIn)
+	delete(rest, "EmbeddedFiles")
+	dict, err := wr.copy.CopyDict(rest)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) > 0 {
+		treeRef, err := nametree.WriteMap(wr.out, files)
+		if err != nil {
+			return nil, err
+		}
+		dict["EmbeddedFiles"] = treeRef
+	}
+	if len(dict) == 0 {
+		return nil, nil
+	}
+	return dict, nil
+}
+
+// specification returns the file specification for a new embedded file.
+func (wr *writer) specification(f *File) *file.Specification {
+	sum := md5.Sum(f.Data)
+	stm := &file.Stream{
+		MimeType: f.MimeType,
+		Size:     int64(len(f.Data)),
+		ModDate:  f.ModDate,
+		CheckSum: sum[:],
+		WriteData: func(w io.Writer) error {
+			_, err := w.Write(f.Data)
+			return err
+		},
+	}
+	spec := &file.Specification{
+		FileName:      f.Name,
+		Description:   f.Description,
+		EmbeddedFiles: map[string]*file.Stream{"F": stm},
+	}
+	if pdf.GetVersion(wr.out) >= pdf.V1_7 {
+		spec.FileNameUnicode = f.Name
+		spec.EmbeddedFiles["UF"] = stm
+	}
+	return spec
+}
+
+// uniqueKey returns a name tree key based on name which is not yet used in
+// files.
+func uniqueKey(files map[pdf.Name]pdf.Object, name string) pdf.Name {
+	key := pdf.Name(name)
+	ext := path.Ext(name)
+	stem := strings.TrimSuffix(name, ext)
+	for i := 2; files[key] != nil; i++ {
+		key = pdf.Name(fmt.Sprintf("%s (%d)%s", stem, i, ext))
+	}
+	return key
+}
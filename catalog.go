@@ -145,10 +145,14 @@ type Catalog struct {
 	NeedsRendering bool
 
 	// DSS (optional, PDF 2.0) contains document-wide security information.
+	// PAdES (ETSI EN 319 142-1) also allows this entry in PDF 1.7 files.
 	DSS Object
 
 	// AF (optional, PDF 2.0) contains an array of file specification
 	// dictionaries denoting the associated files for this PDF document.
+	//
+	// PDF/A-3 (ISO 19005-3) also uses this entry in PDF 1.7 files, so it
+	// is accepted from PDF 1.7 onwards.
 	AF Object
 
 	// DPartRoot (optional, PDF 2.0) describes the document parts hierarchy for
@@ -472,14 +476,14 @@ func (c *Catalog) Encode(rm *ResourceManager) (Native, error) {
 	}
 
 	if c.DSS != nil {
-		if err := CheckVersion(out, "Catalog DSS entry", V2_0); err != nil {
+		if err := CheckVersion(out, "Catalog DSS entry", V1_7); err != nil {
 			return nil, err
 		}
 		dict["DSS"] = c.DSS
 	}
 
 	if c.AF != nil {
-		if err := CheckVersion(out, "Catalog AF entry", V2_0); err != nil {
+		if err := CheckVersion(out, "Catalog AF entry", V1_7); err != nil {
 			return nil, err
 		}
 		dict["AF"] = c.AF
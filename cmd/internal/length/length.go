@@ -0,0 +1,53 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package length parses lengths given on the command line.
+package length
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Parse parses a length and returns its value in PDF points.  The number
+// can be followed by one of the units "mm", "cm", "in" or "pt".  Without a
+// unit, the value is in points.
+func Parse(spec string) (float64, error) {
+	units := []struct {
+		suffix string
+		factor float64
+	}{
+		{"mm", 72 / 25.4},
+		{"cm", 72 / 2.54},
+		{"in", 72},
+		{"pt", 1},
+	}
+	factor := 1.0
+	s := strings.TrimSpace(spec)
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			factor = u.factor
+			break
+		}
+	}
+	x, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid length %q", spec)
+	}
+	return x * factor, nil
+}
@@ -0,0 +1,49 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package length
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		in   string
+		want float64
+	}{
+		{"12", 12},
+		{"12pt", 12},
+		{" 1in ", 72},
+		{"2.54 cm", 72},
+		{"25.4mm", 72},
+		{"-3", -3},
+	} {
+		got, err := Parse(test.in)
+		if err != nil {
+			t.Errorf("%q: %v", test.in, err)
+		} else if math.Abs(got-test.want) > 1e-9 {
+			t.Errorf("%q: got %g, want %g", test.in, got, test.want)
+		}
+	}
+
+	for _, in := range []string{"", "mm", "1km", "one inch"} {
+		if _, err := Parse(in); err == nil {
+			t.Errorf("%q: missing error", in)
+		}
+	}
+}
@@ -0,0 +1,280 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Attach lists, extracts, adds and removes the files embedded in a PDF file.
+//
+// Without options, the embedded files are listed, together with their
+// sizes, modification times and the state of their MD5 checksums.  With
+// -x, the embedded files are extracted; files with a wrong checksum are
+// not written.  With -a and -d, files are added to or removed from the
+// document, and the result is written to the file given by -o.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/attachment"
+	"seehuhn.de/go/pdf/cmd/internal/buildinfo"
+)
+
+// listFlag is a flag which can be given several times.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+var (
+	extract   = flag.Bool("x", false, "extract embedded files")
+	dir       = flag.String("C", ".", "`directory` for extracted files")
+	out       = flag.String("o", "out.pdf", "output file name when adding or removing files")
+	force     = flag.Bool("f", false, "overwrite output files if they exist")
+	desc      = flag.String("desc", "", "`description` for the added files")
+	passwdArg = flag.String("p", "", "PDF password")
+
+	add    listFlag
+	remove listFlag
+)
+
+func main() {
+	flag.Var(&add, "a", "add `file` to the document (can be repeated)")
+	flag.Var(&remove, "d", "remove the embedded file `name` (can be repeated)")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "pdf-attach — list, extract, add and remove embedded files\n")
+		fmt.Fprintf(os.Stderr, "%s\n\n", buildinfo.Short("pdf-attach"))
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  pdf-attach [options] <input.pdf>\n")
+		fmt.Fprintf(os.Stderr, "  pdf-attach -x [options] <input.pdf> [name...]\n")
+		fmt.Fprintf(os.Stderr, "  pdf-attach [-a file]... [-d name]... [options] <input.pdf>\n\n")
+		fmt.Fprintf(os.Stderr, "Arguments:\n")
+		fmt.Fprintf(os.Stderr, "  input.pdf   PDF file to inspect or modify\n")
+		fmt.Fprintf(os.Stderr, "  name        embedded file to extract (default all)\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nEmbedded files are named as in the listing.  Files from the\n")
+		fmt.Fprintf(os.Stderr, "EmbeddedFiles name tree, from file attachment annotations and from\n")
+		fmt.Fprintf(os.Stderr, "associated file (AF) arrays are included.\n")
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  pdf-attach in.pdf\n")
+		fmt.Fprintf(os.Stderr, "  pdf-attach -x -C attachments in.pdf\n")
+		fmt.Fprintf(os.Stderr, "  pdf-attach -a data.csv -d old.csv -o out.pdf in.pdf\n")
+	}
+	flag.Parse()
+
+	modify := len(add) > 0 || len(remove) > 0
+	if flag.NArg() < 1 || flag.NArg() > 1 && !*extract || *extract && modify {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var err error
+	switch {
+	case *extract:
+		err = extractFiles(flag.Arg(0), flag.Args()[1:])
+	case modify:
+		err = modifyFile(flag.Arg(0))
+	default:
+		err = listFiles(flag.Arg(0))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func openPDF(fname string) (*pdf.Reader, error) {
+	var opt *pdf.ReaderOptions
+	if *passwdArg != "" {
+		opt = &pdf.ReaderOptions{
+			Password: *passwdArg,
+		}
+	}
+	return pdf.Open(fname, opt)
+}
+
+func listFiles(fname string) error {
+	r, err := openPDF(fname)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	fsys, err := attachment.NewFS(r)
+	if err != nil {
+		return err
+	}
+	for _, a := range fsys.Attachments() {
+		info, err := fsys.Stat(a.Name)
+		if err != nil {
+			return err
+		}
+
+		check := "-"
+		if len(a.Stream.CheckSum) > 0 {
+			_, err := a.ReadAll()
+			switch {
+			case err == nil:
+				check = "ok"
+			case errors.Is(err, attachment.ErrChecksum):
+				check = "BAD"
+			default:
+				return err
+			}
+		}
+
+		modTime := "-"
+		if t := info.ModTime(); !t.IsZero() {
+			modTime = t.Format("2006-01-02 15:04")
+		}
+
+		var where []string
+		for _, loc := range a.Locations {
+			if loc.Page >= 0 {
+				where = append(where, fmt.Sprintf("%s:p%d", loc.Kind, loc.Page+1))
+			} else {
+				where = append(where, loc.Kind.String())
+			}
+		}
+
+		fmt.Printf("%10d  %-16s  %-3s  %-30s  %s\n",
+			info.Size(), modTime, check, strings.Join(where, ","), a.Name)
+		if a.Spec.Description != "" {
+			fmt.Printf("%67s%s\n", "", a.Spec.Description)
+		}
+	}
+	return nil
+}
+
+func extractFiles(fname string, names []string) error {
+	r, err := openPDF(fname)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	fsys, err := attachment.NewFS(r)
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		for _, a := range fsys.Attachments() {
+			names = append(names, a.Name)
+		}
+	}
+
+	var failed bool
+	for _, name := range names {
+		if err := extractFile(fsys, name); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+	if failed {
+		return errors.New("some files could not be extracted")
+	}
+	return nil
+}
+
+func extractFile(fsys *attachment.FS, name string) error {
+	data, err := fsys.ReadFile(name)
+	if err != nil {
+		return err
+	}
+	info, err := fsys.Stat(name)
+	if err != nil {
+		return err
+	}
+
+	outName := filepath.Join(*dir, name)
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !*force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+	fd, err := os.OpenFile(outName, flags, 0o666)
+	if os.IsExist(err) {
+		return fmt.Errorf("output file %q already exists (use -f to overwrite)", outName)
+	} else if err != nil {
+		return err
+	}
+	if _, err := fd.Write(data); err != nil {
+		fd.Close()
+		return err
+	}
+	if err := fd.Close(); err != nil {
+		return err
+	}
+	if t := info.ModTime(); !t.IsZero() {
+		return os.Chtimes(outName, t, t)
+	}
+	return nil
+}
+
+func modifyFile(fname string) error {
+	changes := &attachment.Changes{Remove: remove}
+	for _, name := range add {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		info, err := os.Stat(name)
+		if err != nil {
+			return err
+		}
+		mimeType, _, _ := strings.Cut(mime.TypeByExtension(filepath.Ext(name)), ";")
+		changes.Add = append(changes.Add, &attachment.File{
+			Name:        filepath.Base(name),
+			Data:        data,
+			MimeType:    mimeType,
+			ModDate:     info.ModTime(),
+			Description: *desc,
+		})
+	}
+
+	r, err := openPDF(fname)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !*force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+	fd, err := os.OpenFile(*out, flags, 0o666)
+	if os.IsExist(err) {
+		return fmt.Errorf("output file %q already exists (use -f to overwrite)", *out)
+	} else if err != nil {
+		return err
+	}
+
+	err = attachment.Write(fd, r, changes)
+	if err != nil {
+		fd.Close()
+		os.Remove(*out)
+		return err
+	}
+	return fd.Close()
+}
@@ -26,7 +26,9 @@ import (
 	"seehuhn.de/go/pdf"
 	"seehuhn.de/go/pdf/action"
 	"seehuhn.de/go/pdf/destination"
+	"seehuhn.de/go/pdf/nametree"
 	"seehuhn.de/go/pdf/outline"
+	"seehuhn.de/go/pdf/pagelabel"
 	"seehuhn.de/go/pdf/pagetree"
 )
 
@@ -41,6 +43,16 @@ type Concat struct {
 
 	children []*Child
 
+	// FlatOutline, if set, places the outline items of every input at the
+	// top level of the merged outline, instead of below a new item for each
+	// input file.
+	FlatOutline bool
+
+	// merged named destinations and page labels
+	dests     map[pdf.Name]pdf.Object // named destinations of all inputs, after renaming
+	labels    []pagelabel.Entry       // labelling ranges of all inputs, in page order
+	hasLabels bool                    // whether any input has page labels
+
 	// merged interactive form
 	formFields          []pdf.Reference   // root fields of every input's form, in order
 	usedNames           map[string]bool   // root field partial names already taken
@@ -97,15 +109,46 @@ func (c *Concat) Close() error {
 
 	outlineTree := &outline.Outline{}
 	for _, child := range c.children {
+		if c.FlatOutline {
+			outlineTree.Items = append(outlineTree.Items, child.Outline...)
+			continue
+		}
 		entry := outlineTree.AddItem(child.Title)
 		entry.Destination = &destination.Fit{Page: child.FirstPage}
 		entry.Children = child.Outline
 	}
-	outlineRef, err := c.rm.Store(outlineTree)
-	if err != nil {
-		return err
+	if len(outlineTree.Items) > 0 {
+		outlineRef, err := c.rm.Store(outlineTree)
+		if err != nil {
+			return err
+		}
+		meta.Catalog.Outlines = outlineRef
+	}
+
+	// merged named destinations; the name tree needs PDF 1.2, older
+	// versions use the /Dests dictionary in the catalog
+	if len(c.dests) > 0 {
+		if c.v >= pdf.V1_2 {
+			destsRef, err := nametree.WriteMap(c.w, c.dests)
+			if err != nil {
+				return err
+			}
+			meta.Catalog.Names = pdf.Dict{"Dests": destsRef}
+		} else {
+			meta.Catalog.Dests = pdf.Dict(c.dests)
+		}
+	}
+
+	if c.hasLabels {
+		labels, err := pagelabel.New(c.labels...)
+		if err != nil {
+			return err
+		}
+		meta.Catalog.PageLabels, err = c.rm.Embed(labels)
+		if err != nil {
+			return err
+		}
 	}
-	c.rm.Out.GetMeta().Catalog.Outlines = outlineRef
 
 	// merged interactive form
 	if len(c.formFields) > 0 {
@@ -177,8 +220,31 @@ func (c *Concat) Append(fname string) error {
 		Title: title,
 	}
 
-	var copyError error
-	for oldRef, dict := range pagetree.NewIterator(r).All() {
+	var refs []pdf.Reference
+	var dicts []pdf.Dict
+	it := pagetree.NewIterator(r)
+	for ref, dict := range it.All() {
+		refs = append(refs, ref)
+		dicts = append(dicts, dict)
+	}
+	if it.Err != nil {
+		return it.Err
+	}
+
+	// Named destinations which clash with those of earlier inputs are
+	// renamed.  Links which use these names are rewritten before the pages
+	// are copied.
+	dests, rename, err := c.prepareDests(r)
+	if err != nil {
+		return err
+	}
+	links, err := c.prepareLinks(r, copy, dicts, rename)
+	if err != nil {
+		return err
+	}
+
+	firstPage := c.numPages
+	for i, oldRef := range refs {
 		newRef := c.w.Alloc()
 
 		// Since we rebuild the page tree, we can't use `copy` to copy the page
@@ -186,15 +252,13 @@ func (c *Concat) Append(fname string) error {
 		// constructed page dict to the old one.
 		copy.Redirect(oldRef, newRef)
 
-		newDict, err := copy.CopyDict(dict)
+		newDict, err := copy.CopyDict(dicts[i])
 		if err != nil {
-			copyError = err
-			break
+			return err
 		}
 		err = c.pages.AppendPageDict(newRef, newDict)
 		if err != nil {
-			copyError = err
-			break
+			return err
 		}
 
 		if child.FirstPage == 0 {
@@ -203,9 +267,6 @@ func (c *Concat) Append(fname string) error {
 
 		c.numPages++
 	}
-	if copyError != nil {
-		return copyError
-	}
 
 	// write the (renamed) root field dictionaries now that their widgets,
 	// copied with the pages, exist and reference the redirected field objects
@@ -213,8 +274,20 @@ func (c *Concat) Append(fname string) error {
 		return err
 	}
 
+	if err := c.finishLinks(copy, links); err != nil {
+		return err
+	}
+	if err := c.finishDests(copy, dests, rename); err != nil {
+		return err
+	}
+	if len(refs) > 0 {
+		if err := c.appendLabels(r, firstPage); err != nil {
+			return err
+		}
+	}
+
 	if outlineTree != nil {
-		items, err := c.CopyOutlineItems(copy, outlineTree.Items)
+		items, err := c.CopyOutlineItems(copy, outlineTree.Items, rename)
 		if err != nil {
 			return err
 		}
@@ -458,10 +531,11 @@ func (c *Concat) uniqueName(name string) string {
 }
 
 // CopyOutlineItems copies outline items from the source file to the target file.
-func (c *Concat) CopyOutlineItems(cp *pdf.Copier, in []*outline.Item) ([]*outline.Item, error) {
+// Named destinations are renamed as given by the rename map.
+func (c *Concat) CopyOutlineItems(cp *pdf.Copier, in []*outline.Item, rename map[pdf.Name]pdf.Name) ([]*outline.Item, error) {
 	out := make([]*outline.Item, len(in))
 	for i, child := range in {
-		cc, err := c.CopyOutlineItems(cp, child.Children)
+		cc, err := c.CopyOutlineItems(cp, child.Children, rename)
 		if err != nil {
 			return nil, err
 		}
@@ -477,7 +551,7 @@ func (c *Concat) CopyOutlineItems(cp *pdf.Copier, in []*outline.Item) ([]*outlin
 
 		// copy destination with page reference translation
 		if child.Destination != nil {
-			entry.Destination, err = copyDestination(cp, c.rm, child.Destination)
+			entry.Destination, err = copyDestination(cp, c.rm, child.Destination, rename)
 			if err != nil {
 				return nil, err
 			}
@@ -485,7 +559,7 @@ func (c *Concat) CopyOutlineItems(cp *pdf.Copier, in []*outline.Item) ([]*outlin
 
 		// copy action with page reference translation
 		if child.Action != nil {
-			entry.Action, err = copyAction(cp, c.rm, child.Action)
+			entry.Action, err = copyAction(cp, c.rm, child.Action, rename)
 			if err != nil {
 				return nil, err
 			}
@@ -496,10 +570,14 @@ func (c *Concat) CopyOutlineItems(cp *pdf.Copier, in []*outline.Item) ([]*outlin
 	return out, nil
 }
 
-// copyDestination copies a destination, translating page references.
+// copyDestination copies a destination, translating page references and
+// renaming named destinations.
 func copyDestination(cp *pdf.Copier, rm *pdf.ResourceManager,
-	dest destination.Destination) (destination.Destination, error) {
-	if _, ok := dest.(*destination.Named); ok {
+	dest destination.Destination, rename map[pdf.Name]pdf.Name) (destination.Destination, error) {
+	if named, ok := dest.(*destination.Named); ok {
+		if newName, ok := rename[pdf.Name(named.Name)]; ok {
+			return &destination.Named{Name: pdf.String(newName)}, nil
+		}
 		return dest, nil
 	}
 	encoded, err := dest.Encode(rm)
@@ -513,9 +591,19 @@ func copyDestination(cp *pdf.Copier, rm *pdf.ResourceManager,
 	return pdf.Decode(pdf.NewCursor(rm.Out), copied, destination.Decode)
 }
 
-// copyAction copies an action, translating page references.
+// copyAction copies an action, translating page references.  The named
+// destination of a go-to action is renamed as given by the rename map.
 func copyAction(cp *pdf.Copier, rm *pdf.ResourceManager,
-	act pdf.Action) (pdf.Action, error) {
+	act pdf.Action, rename map[pdf.Name]pdf.Name) (pdf.Action, error) {
+	if goTo, ok := act.(*action.GoTo); ok {
+		if named, ok := goTo.Dest.(*destination.Named); ok {
+			if newName, ok := rename[pdf.Name(named.Name)]; ok {
+				renamed := *goTo
+				renamed.Dest = &destination.Named{Name: pdf.String(newName)}
+				act = &renamed
+			}
+		}
+	}
 	encoded, err := act.Encode(rm)
 	if err != nil {
 		return nil, err
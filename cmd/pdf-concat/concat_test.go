@@ -17,6 +17,7 @@
 package main
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
@@ -24,6 +25,9 @@ import (
 	"seehuhn.de/go/pdf/acroform"
 	"seehuhn.de/go/pdf/annotation"
 	"seehuhn.de/go/pdf/document"
+	"seehuhn.de/go/pdf/nametree"
+	"seehuhn.de/go/pdf/pagelabel"
+	"seehuhn.de/go/pdf/pagetree"
 )
 
 // makeFormPDF writes a one-page PDF with a single text field named fieldName,
@@ -129,3 +133,208 @@ func TestRewriteDANoRename(t *testing.T) {
 		t.Errorf("rewriteDA with no renames = %q, want unchanged", got)
 	}
 }
+
+// makeLinkedPDF writes a two-page PDF with page labels "<prefix>1" and
+// "<prefix>2", a named destination "intro" for the first page, an outline
+// item using this name, and two links on the second page which use it too.
+// The second link is a direct object in the /Annots array, and uses a go-to
+// action.
+func makeLinkedPDF(t *testing.T, path, prefix string) {
+	t.Helper()
+
+	fd, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fd.Close()
+	w, err := pdf.NewWriter(fd, pdf.V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rm := pdf.NewResourceManager(w)
+
+	page1 := w.Alloc()
+	page2 := w.Alloc()
+	link := w.Alloc()
+	outlineRef := w.Alloc()
+	item := w.Alloc()
+	objs := map[pdf.Reference]pdf.Object{
+		link: pdf.Dict{
+			"Type":    pdf.Name("Annot"),
+			"Subtype": pdf.Name("Link"),
+			"Rect":    &pdf.Rectangle{LLx: 72, LLy: 72, URx: 144, URy: 90},
+			"Dest":    pdf.String("intro"),
+		},
+		outlineRef: pdf.Dict{"First": item, "Last": item, "Count": pdf.Integer(1)},
+		item: pdf.Dict{
+			"Title":  pdf.TextString("Introduction"),
+			"Parent": outlineRef,
+			"Dest":   pdf.String("intro"),
+		},
+	}
+	for ref, obj := range objs {
+		if err := w.Put(ref, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inline := pdf.Dict{
+		"Type":    pdf.Name("Annot"),
+		"Subtype": pdf.Name("Link"),
+		"Rect":    &pdf.Rectangle{LLx: 72, LLy: 108, URx: 144, URy: 126},
+		"A":       pdf.Dict{"S": pdf.Name("GoTo"), "D": pdf.String("intro")},
+	}
+
+	tree := pagetree.NewWriter(w, rm)
+	for _, p := range []struct {
+		ref  pdf.Reference
+		dict pdf.Dict
+	}{
+		{page1, pdf.Dict{"Type": pdf.Name("Page")}},
+		{page2, pdf.Dict{"Type": pdf.Name("Page"), "Annots": pdf.Array{link, inline}}},
+	} {
+		p.dict["MediaBox"] = &pdf.Rectangle{URx: 200, URy: 200}
+		if err := tree.AppendPageDict(p.ref, p.dict); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dests, err := nametree.WriteMap(w, map[pdf.Name]pdf.Object{
+		"intro": pdf.Array{page1, pdf.Name("Fit")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels, err := pagelabel.New(pagelabel.Entry{
+		Range: pagelabel.Range{Style: pagelabel.Decimal, Prefix: prefix},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	labelsObj, err := rm.Embed(labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cat := w.GetMeta().Catalog
+	cat.Pages = pagesRef
+	cat.Outlines = outlineRef
+	cat.Names = pdf.Dict{"Dests": dests}
+	cat.PageLabels = labelsObj
+	if err := rm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestConcatMergesNavigation concatenates two documents which both use the
+// named destination "intro", and checks that the second one is renamed in the
+// name tree, in the outline and in both links, and that the page labels are
+// concatenated.
+func TestConcatMergesNavigation(t *testing.T) {
+	dir := t.TempDir()
+	in1 := filepath.Join(dir, "a.pdf")
+	in2 := filepath.Join(dir, "b.pdf")
+	out := filepath.Join(dir, "out.pdf")
+	makeLinkedPDF(t, in1, "A-")
+	makeLinkedPDF(t, in2, "B-")
+
+	c, err := NewConcat(out, pdf.V1_7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.FlatOutline = true
+	if err := c.Append(in1); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Append(in2); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.Open(out, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	cur := pdf.NewCursor(r)
+	pages, err := pagetree.FindPages(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 4 {
+		t.Fatalf("got %d pages, want 4", len(pages))
+	}
+
+	// named destinations
+	names, _ := cur.Dict(r.GetMeta().Catalog.Names)
+	tree, err := nametree.ExtractInMemory(r, names["Dests"])
+	if err != nil || tree == nil {
+		t.Fatalf("no named destinations: %v", err)
+	}
+	wantDests := map[pdf.Name]pdf.Reference{"intro": pages[0], "intro_2": pages[2]}
+	for name, want := range wantDests {
+		val, err := tree.Lookup(name)
+		if err != nil {
+			t.Errorf("destination %q: %v", name, err)
+			continue
+		}
+		dest, _ := cur.Array(val)
+		if len(dest) == 0 || dest[0] != want {
+			t.Errorf("destination %q = %v, want page %v", name, dest, want)
+		}
+	}
+
+	// outline items, at the top level since FlatOutline is set
+	outlines, _ := cur.Dict(r.GetMeta().Catalog.Outlines)
+	var itemDests []string
+	for item, _ := cur.Dict(outlines["First"]); item != nil; item, _ = cur.Dict(item["Next"]) {
+		dest, _ := cur.String(item["Dest"])
+		itemDests = append(itemDests, string(dest))
+	}
+	if len(itemDests) != 2 || itemDests[0] != "intro" || itemDests[1] != "intro_2" {
+		t.Errorf("outline destinations = %q, want [intro intro_2]", itemDests)
+	}
+
+	// links
+	for i, want := range []string{"intro", "intro_2"} {
+		_, dict, err := pagetree.GetPage(r, 2*i+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		annots, _ := cur.Array(dict["Annots"])
+		if len(annots) != 2 {
+			t.Fatalf("page %d: %d annotations, want 2", 2*i+2, len(annots))
+		}
+		annot, _ := cur.Dict(annots[0])
+		if dest, _ := cur.String(annot["Dest"]); string(dest) != want {
+			t.Errorf("page %d: link to %q, want %q", 2*i+2, dest, want)
+		}
+		if _, isRef := annots[1].(pdf.Reference); isRef {
+			t.Errorf("page %d: inline link became indirect", 2*i+2)
+		}
+		inline, _ := cur.Dict(annots[1])
+		act, _ := cur.Dict(inline["A"])
+		if dest, _ := cur.String(act["D"]); string(dest) != want {
+			t.Errorf("page %d: inline link to %q, want %q", 2*i+2, dest, want)
+		}
+	}
+
+	// page labels
+	labels, err := pagelabel.Extract(r, r.GetMeta().Catalog.PageLabels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"A-1", "A-2", "B-1", "B-2"} {
+		if got := labels.Format(i); got != want {
+			t.Errorf("page %d: label %q, want %q", i+1, got, want)
+		}
+	}
+}
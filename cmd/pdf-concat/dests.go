@@ -0,0 +1,248 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/nametree"
+	"seehuhn.de/go/pdf/pagelabel"
+)
+
+// preparedLink records a source annotation whose named destination has been
+// renamed. The source reference is redirected to newRef, so that the page
+// copied afterwards points to it, and finishLinks writes the modified
+// dictionary there.
+type preparedLink struct {
+	newRef pdf.Reference
+	dict   pdf.Dict
+}
+
+// prepareDests reads one input's named destinations, from both the catalog's
+// /Dests dictionary and the /Dests name tree. A name already taken by an
+// earlier input is given a fresh "_N" suffix; the returned map lists these
+// renames. The destinations themselves point to pages of the input, so they
+// are copied by finishDests, after the pages.
+func (c *Concat) prepareDests(r pdf.Getter) (map[pdf.Name]pdf.Object, map[pdf.Name]pdf.Name, error) {
+	cur := pdf.NewCursor(r)
+	cat := r.GetMeta().Catalog
+
+	dests := map[pdf.Name]pdf.Object{}
+	if dict, _ := cur.Dict(cat.Dests); dict != nil {
+		maps.Copy(dests, dict)
+	}
+	if names, _ := cur.Dict(cat.Names); names != nil {
+		tree, err := pdf.Optional(nametree.ExtractInMemory(r, names["Dests"]))
+		if err != nil {
+			return nil, nil, err
+		}
+		for name, val := range tree.All() {
+			dests[name] = val
+		}
+	}
+	if len(dests) == 0 {
+		return nil, nil, nil
+	}
+
+	// a fresh name must avoid the names of earlier inputs, the names this
+	// input uses itself, and the fresh names picked so far
+	fresh := map[pdf.Name]bool{}
+	taken := func(name pdf.Name) bool {
+		_, earlier := c.dests[name]
+		_, own := dests[name]
+		return earlier || own || fresh[name]
+	}
+	var rename map[pdf.Name]pdf.Name
+	for _, name := range slices.Sorted(maps.Keys(dests)) {
+		if _, clash := c.dests[name]; !clash {
+			continue
+		}
+		if rename == nil {
+			rename = map[pdf.Name]pdf.Name{}
+		}
+		for i := 2; ; i++ {
+			cand := pdf.Name(fmt.Sprintf("%s_%d", name, i))
+			if !taken(cand) {
+				rename[name] = cand
+				fresh[cand] = true
+				break
+			}
+		}
+	}
+	return dests, rename, nil
+}
+
+// finishDests copies one input's named destinations, now that the pages they
+// point to have been copied, and adds them to the merged name space under
+// their (possibly renamed) names.
+func (c *Concat) finishDests(cp *pdf.Copier, dests map[pdf.Name]pdf.Object, rename map[pdf.Name]pdf.Name) error {
+	if c.dests == nil && len(dests) > 0 {
+		c.dests = map[pdf.Name]pdf.Object{}
+	}
+	for _, name := range slices.Sorted(maps.Keys(dests)) {
+		val := dests[name]
+		var copied pdf.Native
+		if val != nil {
+			var err error
+			copied, err = cp.Copy(val.AsPDF(c.w.GetOptions()))
+			if err != nil {
+				return err
+			}
+		}
+		if newName, ok := rename[name]; ok {
+			name = newName
+		}
+		c.dests[name] = copied
+	}
+	return nil
+}
+
+// prepareLinks finds the annotations on one input's pages whose destination,
+// or go-to action, uses a renamed named destination. Each such annotation
+// which is an indirect object is redirected to a fresh reference, and must be
+// written by finishLinks after the pages have been copied. Annotations which
+// are direct objects are replaced in the /Annots array of the page
+// dictionary in pages, so that they are renamed when the page is copied.
+func (c *Concat) prepareLinks(r pdf.Getter, cp *pdf.Copier, pages []pdf.Dict, rename map[pdf.Name]pdf.Name) ([]preparedLink, error) {
+	if len(rename) == 0 {
+		return nil, nil
+	}
+
+	cur := pdf.NewCursor(r)
+	var links []preparedLink
+	for i, pageDict := range pages {
+		annots, _ := cur.Array(pageDict["Annots"])
+		var newAnnots pdf.Array
+		for j, el := range annots {
+			annot, _ := cur.Dict(el)
+			if annot == nil {
+				continue
+			}
+			dict := renameTargets(cur, annot, rename)
+			if dict == nil {
+				continue
+			}
+			if ref, ok := el.(pdf.Reference); ok {
+				newRef := c.w.Alloc()
+				cp.Redirect(ref, newRef)
+				links = append(links, preparedLink{newRef: newRef, dict: dict})
+				continue
+			}
+			if newAnnots == nil {
+				newAnnots = slices.Clone(annots)
+			}
+			newAnnots[j] = dict
+		}
+		if newAnnots != nil {
+			pageDict = maps.Clone(pageDict)
+			pageDict["Annots"] = newAnnots
+			pages[i] = pageDict
+		}
+	}
+	return links, nil
+}
+
+// finishLinks writes the annotations prepared by prepareLinks.
+func (c *Concat) finishLinks(cp *pdf.Copier, links []preparedLink) error {
+	for _, l := range links {
+		dict, err := cp.CopyDict(l.dict)
+		if err != nil {
+			return err
+		}
+		if err := c.w.Put(l.newRef, dict); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renameTargets returns a copy of an annotation dictionary with the named
+// destinations in /Dest, and in the /D entry of a go-to action in /A,
+// renamed. If nothing needs renaming, nil is returned.
+func renameTargets(cur pdf.Cursor, annot pdf.Dict, rename map[pdf.Name]pdf.Name) pdf.Dict {
+	var res pdf.Dict
+	if dest, ok := renameDest(cur, annot["Dest"], rename); ok {
+		res = maps.Clone(annot)
+		res["Dest"] = dest
+	}
+
+	act, _ := cur.Dict(annot["A"])
+	if tp, _ := cur.Name(act["S"]); tp == "GoTo" {
+		if dest, ok := renameDest(cur, act["D"], rename); ok {
+			if res == nil {
+				res = maps.Clone(annot)
+			}
+			act = maps.Clone(act)
+			act["D"] = dest
+			res["A"] = act
+		}
+	}
+	return res
+}
+
+// renameDest returns the new name for a named destination, keeping the form
+// (name or string) of the original. The second return value is false if obj
+// is not a renamed destination.
+func renameDest(cur pdf.Cursor, obj pdf.Object, rename map[pdf.Name]pdf.Name) (pdf.Object, bool) {
+	resolved, _ := cur.Resolve(obj)
+	switch x := resolved.(type) {
+	case pdf.Name:
+		if newName, ok := rename[x]; ok {
+			return newName, true
+		}
+	case pdf.String:
+		if newName, ok := rename[pdf.Name(x)]; ok {
+			return pdf.String(newName), true
+		}
+	}
+	return nil, false
+}
+
+// appendLabels adds one input's page labels, shifted to the input's first
+// page in the output. An input without page labels is numbered 1, 2, ...
+func (c *Concat) appendLabels(r pdf.Getter, firstPage int) error {
+	var labels *pagelabel.Labels
+	if obj := r.GetMeta().Catalog.PageLabels; obj != nil {
+		var err error
+		labels, err = pdf.Optional(pagelabel.Extract(r, obj))
+		if err != nil {
+			return err
+		}
+	}
+	if labels == nil {
+		c.labels = append(c.labels, pagelabel.Entry{
+			FirstPage: firstPage,
+			Range:     pagelabel.Range{Style: pagelabel.Decimal, Start: 1},
+		})
+		return nil
+	}
+
+	c.hasLabels = true
+	numPages := c.numPages - firstPage
+	for _, e := range labels.Ranges {
+		// ranges past the input's last page would clash with the next input
+		if e.FirstPage >= numPages {
+			break
+		}
+		e.FirstPage += firstPage
+		c.labels = append(c.labels, e)
+	}
+	return nil
+}
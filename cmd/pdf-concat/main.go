@@ -16,24 +16,32 @@
 
 // Concat concatenates PDF files.
 //
-// This is a simplistic implementation which copies the page contents
-// and the document outlines, but ignores all other document structure
-// and document-level meta information.
+// The page contents, the document outlines, the named destinations, the
+// page labels and the interactive forms of the input files are merged.
+// Other document structure and document-level meta information is
+// ignored.
+//
+// With -merge-fonts, subsets of the same font which are embedded by
+// several input files are combined into one font.
 package main
 
 import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 
 	"seehuhn.de/go/pdf"
 	"seehuhn.de/go/pdf/cmd/internal/buildinfo"
 	"seehuhn.de/go/pdf/cmd/internal/profile"
+	"seehuhn.de/go/pdf/fontmerge"
 )
 
 var (
 	out        = flag.String("o", "out.pdf", "output file name")
 	force      = flag.Bool("f", false, "overwrite output file if it exists")
+	flat       = flag.Bool("flat", false, "merge the outlines without adding an item for each input file")
+	mergeFonts = flag.Bool("merge-fonts", false, "merge the subsets of fonts which occur in several input files")
 	cpuprofile = flag.String("cpuprofile", "", "write cpu profile to `file`")
 	memprofile = flag.String("memprofile", "", "write memory profile to `file`")
 )
@@ -51,6 +59,8 @@ func main() {
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  pdf-concat -o combined.pdf a.pdf b.pdf c.pdf\n")
 		fmt.Fprintf(os.Stderr, "  pdf-concat -f -o out.pdf *.pdf\n")
+		fmt.Fprintf(os.Stderr, "  pdf-concat -flat -o reader.pdf ch1.pdf ch2.pdf\n")
+		fmt.Fprintf(os.Stderr, "  pdf-concat -merge-fonts -o invoices.pdf invoice-*.pdf\n")
 	}
 	flag.Parse()
 
@@ -79,7 +89,43 @@ func run() error {
 		}
 	}
 
-	return concatFiles(*out, flag.Args())
+	if !*mergeFonts {
+		return concatFiles(*out, flag.Args())
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(*out), ".pdf-concat-*.pdf")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpName)
+
+	if err := concatFiles(tmpName, flag.Args()); err != nil {
+		return err
+	}
+	return mergeFontFiles(*out, tmpName)
+}
+
+// mergeFontFiles merges the font subsets in the file in and writes the
+// result to out.
+func mergeFontFiles(out, in string) error {
+	r, err := pdf.Open(in, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	fd, err := os.Create(out)
+	if err != nil {
+		return err
+	}
+	_, err = fontmerge.Write(fd, r)
+	if err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
 }
 
 func concatFiles(out string, in []string) error {
@@ -98,6 +144,7 @@ func concatFiles(out string, in []string) error {
 	if err != nil {
 		return err
 	}
+	c.FlatOutline = *flat
 
 	for _, fname := range in {
 		err = c.Append(fname)
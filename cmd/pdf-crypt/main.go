@@ -0,0 +1,165 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Crypt changes the passwords, permissions and encryption algorithm of a
+// PDF file.
+//
+// The input file is decrypted using the password given by -p, which can be
+// either the user or the owner password.  The output is encrypted using the
+// new passwords given by -user and -owner.  If neither of these is given,
+// the output is not encrypted.  The object structure of the document, the
+// file identifiers and the XMP metadata are preserved.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/cmd/internal/buildinfo"
+)
+
+var (
+	out       = flag.String("o", "out.pdf", "output file name")
+	force     = flag.Bool("f", false, "overwrite the output file if it exists")
+	passwdArg = flag.String("p", "", "password of the input file (user or owner)")
+	userPW    = flag.String("user", "", "new user `password`")
+	ownerPW   = flag.String("owner", "", "new owner `password`")
+	permArg   = flag.String("perm", "all", "comma-separated `list` of user permissions")
+	algArg    = flag.String("alg", "", "encryption `algorithm`: rc4-128, aes-128 or aes-256 (default depends on the PDF version)")
+	plainMeta = flag.Bool("plaintext-metadata", false, "leave the XMP metadata unencrypted")
+)
+
+// permNames maps the names accepted by -perm to permission bits.
+var permNames = map[string]pdf.Perm{
+	"copy":           pdf.PermCopy,
+	"print-degraded": pdf.PermPrintDegraded,
+	"print":          pdf.PermPrint | pdf.PermPrintDegraded,
+	"forms":          pdf.PermForms,
+	"annotate":       pdf.PermAnnotate | pdf.PermForms,
+	"assemble":       pdf.PermAssemble,
+	"modify":         pdf.PermModify | pdf.PermAssemble,
+	"all":            pdf.PermAll,
+	"none":           0,
+}
+
+// algorithms maps the names accepted by -alg to encryption algorithms.
+var algorithms = map[string]*pdf.Encryption{
+	"rc4-128": {Cipher: "RC4", KeyLength: 128},
+	"aes-128": {Cipher: "AES", KeyLength: 128},
+	"aes-256": {Cipher: "AES", KeyLength: 256},
+}
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "pdf-crypt — change the encryption of a PDF file\n")
+		fmt.Fprintf(os.Stderr, "%s\n\n", buildinfo.Short("pdf-crypt"))
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  pdf-crypt [options] <input.pdf>\n\n")
+		fmt.Fprintf(os.Stderr, "Arguments:\n")
+		fmt.Fprintf(os.Stderr, "  input.pdf   PDF file to re-encrypt\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nIf neither -user nor -owner is given, the output is not encrypted.\n")
+		fmt.Fprintf(os.Stderr, "Permissions are copy, print-degraded, print, forms, annotate,\n")
+		fmt.Fprintf(os.Stderr, "assemble, modify, all and none.  AES-256 requires PDF 2.0 and\n")
+		fmt.Fprintf(os.Stderr, "AES-128 requires PDF 1.6; the PDF version is raised if needed.\n")
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  pdf-crypt -p secret -o plain.pdf in.pdf\n")
+		fmt.Fprintf(os.Stderr, "  pdf-crypt -owner admin -perm print,copy -alg aes-256 in.pdf\n")
+		fmt.Fprintf(os.Stderr, "  pdf-crypt -user u -owner o -plaintext-metadata -o out.pdf in.pdf\n")
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	err := run(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(fname string) error {
+	perm, err := parsePerm(*permArg)
+	if err != nil {
+		return err
+	}
+	opt := &pdf.ReencryptOptions{
+		UserPassword:      *userPW,
+		OwnerPassword:     *ownerPW,
+		UserPermissions:   perm,
+		PlaintextMetadata: *plainMeta,
+	}
+	if *algArg != "" {
+		alg, ok := algorithms[strings.ToLower(*algArg)]
+		if !ok {
+			return fmt.Errorf("unknown encryption algorithm %q", *algArg)
+		}
+		opt.Encryption = alg
+	}
+
+	var ropt *pdf.ReaderOptions
+	if *passwdArg != "" {
+		ropt = &pdf.ReaderOptions{
+			Password: *passwdArg,
+		}
+	}
+	r, err := pdf.Open(fname, ropt)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !*force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+	fd, err := os.OpenFile(*out, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	err = pdf.Reencrypt(fd, r, opt)
+	if err != nil {
+		fd.Close()
+		os.Remove(*out)
+		return err
+	}
+	return fd.Close()
+}
+
+// parsePerm converts a comma-separated list of permission names into a
+// permission set.
+func parsePerm(s string) (pdf.Perm, error) {
+	var perm pdf.Perm
+	for name := range strings.SplitSeq(s, ",") {
+		name = strings.TrimSpace(strings.ToLower(name))
+		if name == "" {
+			continue
+		}
+		p, ok := permNames[name]
+		if !ok {
+			return 0, fmt.Errorf("unknown permission %q", name)
+		}
+		perm |= p
+	}
+	return perm, nil
+}
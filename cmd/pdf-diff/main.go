@@ -0,0 +1,123 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Diff compares two PDF files.
+//
+// The pages of the two files are matched, and the page boxes, resources,
+// fonts, annotations, content streams and text of matching pages are
+// compared.  The fields of the interactive forms are compared as well.
+// Differences in the file structure which do not change the document,
+// like the numbering of objects or the compression of streams, are
+// ignored.
+//
+// Like diff(1), the exit status is 0 if no differences were found, 1 if
+// the files differ, and 2 if an error occurred.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/cmd/internal/buildinfo"
+	"seehuhn.de/go/pdf/pdfdiff"
+)
+
+var (
+	jsonArg   = flag.Bool("json", false, "write the report as JSON")
+	noContent = flag.Bool("no-content", false, "do not compare content stream operators")
+	noText    = flag.Bool("no-text", false, "do not compare the text on the pages")
+	maxLines  = flag.Int("max-lines", 40, "maximum number of `lines` shown per content or text diff (0 for no limit)")
+	passwdA   = flag.String("pa", "", "password for the first PDF file")
+	passwdB   = flag.String("pb", "", "password for the second PDF file")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "pdf-diff — compare two PDF files\n")
+		fmt.Fprintf(os.Stderr, "%s\n\n", buildinfo.Short("pdf-diff"))
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  pdf-diff [options] <a.pdf> <b.pdf>\n\n")
+		fmt.Fprintf(os.Stderr, "Arguments:\n")
+		fmt.Fprintf(os.Stderr, "  a.pdf, b.pdf   PDF files to compare\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nThe exit status is 0 if the files are equivalent, 1 if they differ\n")
+		fmt.Fprintf(os.Stderr, "and 2 if an error occurred.\n")
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  pdf-diff old.pdf new.pdf\n")
+		fmt.Fprintf(os.Stderr, "  pdf-diff -no-content -json old.pdf new.pdf >report.json\n")
+	}
+	flag.Parse()
+
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	equal, err := run(flag.Arg(0), flag.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if !equal {
+		os.Exit(1)
+	}
+}
+
+func run(fnameA, fnameB string) (bool, error) {
+	a, err := openPDF(fnameA, *passwdA)
+	if err != nil {
+		return false, err
+	}
+	defer a.Close()
+	b, err := openPDF(fnameB, *passwdB)
+	if err != nil {
+		return false, err
+	}
+	defer b.Close()
+
+	rep, err := pdfdiff.Compare(a, b, &pdfdiff.Options{
+		IgnoreContent: *noContent,
+		IgnoreText:    *noText,
+		MaxLines:      *maxLines,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if *jsonArg {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(rep)
+	} else {
+		err = rep.WriteText(os.Stdout)
+	}
+	if err != nil {
+		return false, err
+	}
+	return rep.Equal(), nil
+}
+
+func openPDF(fname, passwd string) (*pdf.Reader, error) {
+	var opt *pdf.ReaderOptions
+	if passwd != "" {
+		opt = &pdf.ReaderOptions{Password: passwd}
+	}
+	return pdf.Open(fname, opt)
+}
@@ -0,0 +1,131 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package images implements the image extraction mode of pdf-extract.
+// It lists image placements and saves images in their native formats.
+package images
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/image/imageextract"
+)
+
+// List writes one line for every image placement on the given pages to w.
+// Each line shows the page number, the image object (or the index of the
+// inline image), the kind of content stream, the size in pixels, the
+// effective resolution and the transformation matrix.
+func List(doc pdf.Getter, pages []int, w io.Writer) error {
+	count := 0
+	err := imageextract.Walk(doc, &imageextract.Options{Pages: pages}, func(img *imageextract.Image) error {
+		count++
+		_, err := fmt.Fprintf(w, "page %d  %-10s %-10s %5dx%-5d %4.0fx%-4.0f dpi  %s\n",
+			img.Page+1, name(img), img.Source,
+			img.Width, img.Height, img.DPIX, img.DPIY,
+			formatMatrix(img))
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		fmt.Fprintln(w, "No images found.")
+	}
+	return nil
+}
+
+// Save writes every image on the given pages into the directory dir,
+// creating the directory if needed.  Each image XObject is written once,
+// even if it is placed several times.  Images are written in their native
+// formats, with bilevel images written in the format given by bilevel.
+// Existing files are only overwritten if force is set.
+//
+// The function returns the number of files written.
+func Save(doc pdf.Getter, pages []int, dir string, bilevel imageextract.Format, force bool) (int, error) {
+	if err := os.MkdirAll(dir, 0o777); err != nil {
+		return 0, err
+	}
+
+	seen := make(map[pdf.Reference]bool)
+	count := 0
+	err := imageextract.Walk(doc, &imageextract.Options{Pages: pages}, func(img *imageextract.Image) error {
+		if img.Ref != 0 {
+			if seen[img.Ref] {
+				return nil
+			}
+			seen[img.Ref] = true
+		}
+
+		format := img.NativeFormat(bilevel)
+		fileName := filepath.Join(dir, FileName(img)+format.Ext())
+		if err := saveOne(img, format, fileName, force); err != nil {
+			return fmt.Errorf("page %d, %s: %w", img.Page+1, name(img), err)
+		}
+		count++
+		return nil
+	})
+	return count, err
+}
+
+// FileName returns the base name, without extension, used for an image.
+// Image XObjects are named after their object number, inline images after
+// the page and their position on the page.
+func FileName(img *imageextract.Image) string {
+	if img.Ref != 0 {
+		return fmt.Sprintf("img-%d", img.Ref.Number())
+	}
+	return fmt.Sprintf("img-p%d-%d", img.Page+1, img.Inline)
+}
+
+func saveOne(img *imageextract.Image, format imageextract.Format, fileName string, force bool) error {
+	flags := os.O_WRONLY | os.O_CREATE
+	if force {
+		flags |= os.O_TRUNC
+	} else {
+		flags |= os.O_EXCL
+	}
+	fd, err := os.OpenFile(fileName, flags, 0o666)
+	if err != nil {
+		if os.IsExist(err) {
+			return fmt.Errorf("file %s already exists (use -f to overwrite)", fileName)
+		}
+		return err
+	}
+
+	err = img.Write(fd, format)
+	if err != nil {
+		fd.Close()
+		os.Remove(fileName)
+		return err
+	}
+	return fd.Close()
+}
+
+func name(img *imageextract.Image) string {
+	if img.Ref != 0 {
+		return img.Ref.String()
+	}
+	return fmt.Sprintf("inline#%d", img.Inline)
+}
+
+func formatMatrix(img *imageextract.Image) string {
+	m := img.CTM
+	return fmt.Sprintf("[%.4g %.4g %.4g %.4g %.4g %.4g]", m[0], m[1], m[2], m[3], m[4], m[5])
+}
@@ -0,0 +1,123 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package images_test
+
+import (
+	"bytes"
+	goimage "image"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"seehuhn.de/go/geom/matrix"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/cmd/pdf-extract/images"
+	"seehuhn.de/go/pdf/document"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/image"
+	"seehuhn.de/go/pdf/graphics/image/imageextract"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+)
+
+// makeDoc returns a one-page document which shows the same image twice and
+// an inline stencil mask once.
+func makeDoc(t *testing.T) *pdf.Reader {
+	t.Helper()
+
+	buf := memfile.New()
+	doc, err := document.WriteMultiPage(buf, document.A4, pdf.V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := doc.AddPage()
+	img := image.FromImage(goimage.NewGray(goimage.Rect(0, 0, 10, 10)), color.SpaceDeviceGray, 8)
+	for _, x := range []float64{100, 300} {
+		p.PushGraphicsState()
+		p.Transform(matrix.Matrix{72, 0, 0, 72, x, 100})
+		p.DrawXObject(img)
+		p.PopGraphicsState()
+	}
+	p.DrawInlineImageRaw(pdf.Dict{
+		"W":   pdf.Integer(8),
+		"H":   pdf.Integer(1),
+		"IM":  pdf.Boolean(true),
+		"BPC": pdf.Integer(1),
+	}, []byte{0x55})
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(bytes.NewReader(buf.Data), int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestList(t *testing.T) {
+	r := makeDoc(t)
+
+	var out bytes.Buffer
+	if err := images.List(r, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3:\n%s", len(lines), out.String())
+	}
+	if !strings.HasPrefix(lines[0], "page 1 ") || !strings.Contains(lines[0], "10x10") {
+		t.Errorf("unexpected first line %q", lines[0])
+	}
+	if !strings.Contains(lines[2], "inline#1") {
+		t.Errorf("unexpected last line %q", lines[2])
+	}
+}
+
+func TestSave(t *testing.T) {
+	r := makeDoc(t)
+	dir := t.TempDir()
+
+	n, err := images.Save(r, nil, dir, imageextract.FormatPBM, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("wrote %d files, want 2", n)
+	}
+
+	pbm, err := os.ReadFile(filepath.Join(dir, "img-p1-1.pbm"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "P4\n8 1\n\xaa"; string(pbm) != want {
+		t.Errorf("got %q, want %q", pbm, want)
+	}
+
+	// a second run must not overwrite existing files
+	if _, err := images.Save(r, nil, dir, imageextract.FormatPBM, false); err == nil {
+		t.Error("existing files were overwritten")
+	}
+	if _, err := images.Save(r, nil, dir, imageextract.FormatPBM, true); err != nil {
+		t.Errorf("forced overwrite failed: %v", err)
+	}
+}
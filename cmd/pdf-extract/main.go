@@ -28,11 +28,13 @@ import (
 	"strings"
 
 	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/image/imageextract"
 	"seehuhn.de/go/pdf/pagetree"
 
 	"seehuhn.de/go/pdf/cmd/internal/buildinfo"
 	"seehuhn.de/go/pdf/cmd/internal/profile"
 	"seehuhn.de/go/pdf/cmd/pdf-extract/forms"
+	"seehuhn.de/go/pdf/cmd/pdf-extract/images"
 	"seehuhn.de/go/pdf/cmd/pdf-extract/sections"
 	"seehuhn.de/go/pdf/cmd/pdf-extract/text"
 )
@@ -43,6 +45,7 @@ type config struct {
 	force           bool
 	showNextSection bool
 	showPageNumbers bool
+	bilevel         string
 }
 
 // PageSet represents a set of pages with coordinate bounds.
@@ -340,6 +343,31 @@ func getOutputProcessor(filename, explicitType string, cfg config) (OutputProces
 	}
 }
 
+// isImageOutput reports whether images should be extracted.  This is the
+// case if requested explicitly, or if the output name ends in a path
+// separator.
+func isImageOutput(outputFile, explicitType string) bool {
+	if explicitType != "" {
+		return explicitType == "images"
+	}
+	return strings.HasSuffix(outputFile, "/") || strings.HasSuffix(outputFile, string(filepath.Separator))
+}
+
+// parseBilevelFormat converts the value of the -bilevel flag into an image
+// format.
+func parseBilevelFormat(s string) (imageextract.Format, error) {
+	switch strings.ToLower(s) {
+	case "png":
+		return imageextract.FormatPNG, nil
+	case "tif", "tiff":
+		return imageextract.FormatTIFF, nil
+	case "pbm":
+		return imageextract.FormatPBM, nil
+	default:
+		return 0, fmt.Errorf("unsupported bilevel format: %s (supported: png, tiff, pbm)", s)
+	}
+}
+
 // openOutputFile opens the output file for writing. If outputFile is "-",
 // os.Stdout is returned. Otherwise, the file is opened with overwrite
 // protection unless forceOverwrite is set.
@@ -370,20 +398,22 @@ func main() {
 	memprofile := flag.String("memprofile", "", "write memory profile to `file`")
 
 	var cfg config
-	flag.StringVar(&cfg.outputType, "type", "", "output type (pdf or txt), overrides file extension")
+	flag.StringVar(&cfg.outputType, "type", "", "output type (pdf, txt or images), overrides file extension")
 	flag.BoolVar(&cfg.force, "f", false, "overwrite output file if it exists")
 	flag.BoolVar(&cfg.showNextSection, "show-next-section", false, "show the name of the next section after processing")
 	flag.BoolVar(&cfg.showPageNumbers, "P", false, "show page numbers in text output")
+	flag.StringVar(&cfg.bilevel, "bilevel", "png", "file format for bilevel images (png, tiff or pbm)")
 	help := flag.Bool("help", false, "show help information")
 
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "pdf-extract \u2014 extract pages or text from a PDF file\n")
+		fmt.Fprintf(os.Stderr, "pdf-extract \u2014 extract pages, text or images from a PDF file\n")
 		fmt.Fprintf(os.Stderr, "%s\n\n", buildinfo.Short("pdf-extract"))
 		fmt.Fprintf(os.Stderr, "Usage:\n")
 		fmt.Fprintf(os.Stderr, "  pdf-extract [options] <file.pdf> [region...] [to <output>]\n\n")
 		fmt.Fprintf(os.Stderr, "Arguments:\n")
 		fmt.Fprintf(os.Stderr, "  file.pdf   PDF file to extract from\n")
-		fmt.Fprintf(os.Stderr, "  output     output file (.pdf or .txt), or - for stdout\n\n")
+		fmt.Fprintf(os.Stderr, "  output     output file (.pdf or .txt), - for stdout,\n")
+		fmt.Fprintf(os.Stderr, "             or a directory ending in / for images\n\n")
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\nRegion types:\n")
@@ -397,6 +427,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  sections     list all sections in document\n")
 		fmt.Fprintf(os.Stderr, "  pages        show total page count\n")
 		fmt.Fprintf(os.Stderr, "  form         list interactive form fields and values\n")
+		fmt.Fprintf(os.Stderr, "  images       list all image placements with size and resolution\n")
 		fmt.Fprintf(os.Stderr, "\nExamples:\n")
 		fmt.Fprintf(os.Stderr, "  pdf-extract doc.pdf page 1 to page1.pdf\n")
 		fmt.Fprintf(os.Stderr, "  pdf-extract doc.pdf section \"Intro\" to intro.txt\n")
@@ -404,6 +435,7 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  pdf-extract -type txt doc.pdf section \"Intro\" xrange 100-500 to -\n")
 		fmt.Fprintf(os.Stderr, "  pdf-extract doc.pdf sections\n")
 		fmt.Fprintf(os.Stderr, "  pdf-extract doc.pdf pages\n")
+		fmt.Fprintf(os.Stderr, "  pdf-extract doc.pdf pages 2-5 to img/\n")
 	}
 
 	flag.Parse()
@@ -508,6 +540,11 @@ func run(cfg config, cpuprofile, memprofile string) error {
 				return fmt.Errorf("failed to list form fields: %w", err)
 			}
 			return nil
+		case "image", "images":
+			if err := images.List(doc, nil, os.Stdout); err != nil {
+				return fmt.Errorf("failed to list images: %w", err)
+			}
+			return nil
 		}
 	}
 
@@ -545,7 +582,20 @@ func run(cfg config, cpuprofile, memprofile string) error {
 	}
 
 	// handle output
-	if outputFile != "" {
+	if outputFile != "" && isImageOutput(outputFile, cfg.outputType) {
+		bilevel, err := parseBilevelFormat(cfg.bilevel)
+		if err != nil {
+			return err
+		}
+		if len(currentPages.Pages) == 0 {
+			return fmt.Errorf("no pages selected for image extraction")
+		}
+		n, err := images.Save(doc, currentPages.SortedPages(), outputFile, bilevel, cfg.force)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "extracted %d images to %s\n", n, outputFile)
+	} else if outputFile != "" {
 		processor, err := getOutputProcessor(outputFile, cfg.outputType, cfg)
 		if err != nil {
 			return err
@@ -0,0 +1,246 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Impose arranges the pages of a PDF file on larger sheets, for n-up
+// handouts, saddle-stitched booklets and step-and-repeat labels.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/cmd/internal/buildinfo"
+	"seehuhn.de/go/pdf/cmd/internal/length"
+	"seehuhn.de/go/pdf/cmd/internal/profile"
+	"seehuhn.de/go/pdf/imposition"
+)
+
+var (
+	out        = flag.String("o", "out.pdf", "output file name")
+	force      = flag.Bool("f", false, "overwrite output file if it exists")
+	layout     = flag.String("layout", "nup", "page layout (nup, booklet or repeat)")
+	gridSpec   = flag.String("grid", "2x1", "grid of cells per sheet, as `COLSxROWS`")
+	sheetSpec  = flag.String("sheet", "", "sheet size: a3, a4, a5, letter, legal, tabloid (append r for landscape), or `WxH`")
+	margin     = flag.String("margin", "0", "blank space at the sheet edges")
+	gutter     = flag.String("gutter", "0", "space between cells")
+	creep      = flag.String("creep", "0", "total creep compensation for booklets")
+	rotate     = flag.Int("rotate", 0, "rotate pages clockwise by `degrees` (multiple of 90)")
+	scale      = flag.Float64("scale", 0, "scale factor for the pages (0 scales to fit)")
+	pageSpec   = flag.String("pages", "", "pages to use, e.g. `1-4,7` (default all)")
+	noAnnots   = flag.Bool("no-annots", false, "do not copy annotations")
+	cpuprofile = flag.String("cpuprofile", "", "write cpu profile to `file`")
+	memprofile = flag.String("memprofile", "", "write memory profile to `file`")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "pdf-impose \u2014 arrange PDF pages on sheets for printing\n")
+		fmt.Fprintf(os.Stderr, "%s\n\n", buildinfo.Short("pdf-impose"))
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  pdf-impose [options] <input.pdf>\n\n")
+		fmt.Fprintf(os.Stderr, "Arguments:\n")
+		fmt.Fprintf(os.Stderr, "  input.pdf   PDF file with the pages to impose\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nLengths are given in PDF points, or with a unit suffix (mm, cm, in, pt).\n")
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  pdf-impose -sheet a4r -o handout.pdf slides.pdf\n")
+		fmt.Fprintf(os.Stderr, "  pdf-impose -layout booklet -sheet a4r -creep 1mm -o booklet.pdf a5.pdf\n")
+		fmt.Fprintf(os.Stderr, "  pdf-impose -layout repeat -grid 3x8 -sheet a4 -margin 5mm -o labels.pdf label.pdf\n")
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if err := run(flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(inName string) error {
+	stop, err := profile.Start(*cpuprofile, *memprofile)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	opts, err := getOptions()
+	if err != nil {
+		return err
+	}
+
+	r, err := pdf.Open(inName, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !*force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+	fd, err := os.OpenFile(*out, flags, 0o666)
+	if os.IsExist(err) {
+		return fmt.Errorf("output file %q already exists (use -f to overwrite)", *out)
+	} else if err != nil {
+		return err
+	}
+
+	err = imposition.Write(fd, r, opts)
+	if err != nil {
+		fd.Close()
+		os.Remove(*out)
+		return err
+	}
+	return fd.Close()
+}
+
+// getOptions converts the command line flags into imposition options.
+func getOptions() (*imposition.Options, error) {
+	opts := &imposition.Options{
+		Rotate:          *rotate,
+		Scale:           *scale,
+		DropAnnotations: *noAnnots,
+	}
+
+	switch *layout {
+	case "nup", "n-up":
+		opts.Layout = imposition.NUp
+	case "booklet":
+		opts.Layout = imposition.Booklet
+	case "repeat", "step-and-repeat":
+		opts.Layout = imposition.StepAndRepeat
+	default:
+		return nil, fmt.Errorf("unknown layout %q (supported: nup, booklet, repeat)", *layout)
+	}
+
+	if _, err := fmt.Sscanf(*gridSpec, "%dx%d", &opts.Cols, &opts.Rows); err != nil || opts.Cols < 1 || opts.Rows < 1 {
+		return nil, fmt.Errorf("invalid grid %q (expected COLSxROWS)", *gridSpec)
+	}
+
+	if *sheetSpec != "" {
+		sheet, err := parseSheet(*sheetSpec)
+		if err != nil {
+			return nil, err
+		}
+		opts.Sheet = sheet
+	}
+
+	for _, l := range []struct {
+		name string
+		spec string
+		val  *float64
+	}{
+		{"margin", *margin, &opts.Margin},
+		{"gutter", *gutter, &opts.Gutter},
+		{"creep", *creep, &opts.Creep},
+	} {
+		x, err := length.Parse(l.spec)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", l.name, err)
+		}
+		*l.val = x
+	}
+
+	if *pageSpec != "" {
+		pages, err := parsePages(*pageSpec)
+		if err != nil {
+			return nil, err
+		}
+		opts.Pages = pages
+	}
+
+	return opts, nil
+}
+
+// paperSizes lists the named sheet sizes, in portrait orientation.
+var paperSizes = map[string][2]float64{
+	"a3":      {841.890, 1190.551},
+	"a4":      {595.276, 841.890},
+	"a5":      {420.945, 595.276},
+	"letter":  {612, 792},
+	"legal":   {612, 1008},
+	"tabloid": {792, 1224},
+}
+
+// parseSheet parses a sheet size, either a paper name or WxH.  A trailing
+// "r" selects landscape orientation.
+func parseSheet(spec string) (*pdf.Rectangle, error) {
+	s := strings.ToLower(spec)
+	landscape := false
+	if name, ok := strings.CutSuffix(s, "r"); ok {
+		if _, known := paperSizes[name]; known {
+			s, landscape = name, true
+		}
+	}
+
+	var w, h float64
+	if size, ok := paperSizes[s]; ok {
+		w, h = size[0], size[1]
+	} else {
+		parts := strings.Split(s, "x")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid sheet size %q", spec)
+		}
+		var err error
+		if w, err = length.Parse(parts[0]); err != nil {
+			return nil, fmt.Errorf("invalid sheet size %q: %w", spec, err)
+		}
+		if h, err = length.Parse(parts[1]); err != nil {
+			return nil, fmt.Errorf("invalid sheet size %q: %w", spec, err)
+		}
+	}
+	if landscape {
+		w, h = h, w
+	}
+	if !(w > 0 && h > 0) {
+		return nil, fmt.Errorf("invalid sheet size %q", spec)
+	}
+	return &pdf.Rectangle{URx: w, URy: h}, nil
+}
+
+// parsePages parses a comma-separated list of one-based page numbers and
+// page ranges, and returns the zero-based page indices.
+func parsePages(spec string) ([]int, error) {
+	var pages []int
+	for part := range strings.SplitSeq(spec, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, "-")
+		a, err := strconv.Atoi(first)
+		if err != nil || a < 1 {
+			return nil, fmt.Errorf("invalid page specification %q", part)
+		}
+		b := a
+		if isRange {
+			b, err = strconv.Atoi(last)
+			if err != nil || b < a {
+				return nil, fmt.Errorf("invalid page specification %q", part)
+			}
+		}
+		for i := a; i <= b; i++ {
+			pages = append(pages, i-1)
+		}
+	}
+	return pages, nil
+}
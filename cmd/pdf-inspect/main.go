@@ -21,13 +21,18 @@ import (
 	"fmt"
 	"os"
 
+	"seehuhn.de/go/pdf"
 	"seehuhn.de/go/pdf/cmd/internal/buildinfo"
 	"seehuhn.de/go/pdf/cmd/internal/profile"
 	"seehuhn.de/go/pdf/cmd/pdf-inspect/traverse"
+	"seehuhn.de/go/pdf/pdfjson"
 )
 
 var (
 	passwdArg  = flag.String("p", "", "PDF password")
+	shellArg   = flag.Bool("i", false, "start an interactive shell")
+	jsonArg    = flag.Bool("json", false, "write all objects of the file as JSON")
+	dataArg    = flag.String("data", "raw", "stream data for -json: `mode` raw, decoded or none")
 	cpuprofile = flag.String("cpuprofile", "", "write cpu profile to `file`")
 	memprofile = flag.String("memprofile", "", "write memory profile to `file`")
 )
@@ -37,7 +42,9 @@ func main() {
 		fmt.Fprintf(os.Stderr, "pdf-inspect \u2014 inspect PDF file structure\n")
 		fmt.Fprintf(os.Stderr, "%s\n\n", buildinfo.Short("pdf-inspect"))
 		fmt.Fprintf(os.Stderr, "Usage:\n")
-		fmt.Fprintf(os.Stderr, "  pdf-inspect [options] <file.pdf> [path...]\n\n")
+		fmt.Fprintf(os.Stderr, "  pdf-inspect [options] <file.pdf> [path...]\n")
+		fmt.Fprintf(os.Stderr, "  pdf-inspect -i [options] <file.pdf>\n")
+		fmt.Fprintf(os.Stderr, "  pdf-inspect -json [options] <file.pdf>\n\n")
 		fmt.Fprintf(os.Stderr, "Arguments:\n")
 		fmt.Fprintf(os.Stderr, "  file.pdf   PDF file to inspect\n")
 		fmt.Fprintf(os.Stderr, "  path       sequence of selectors to navigate the document structure\n\n")
@@ -47,10 +54,13 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  pdf-inspect file.pdf\n")
 		fmt.Fprintf(os.Stderr, "  pdf-inspect file.pdf Pages 1\n")
 		fmt.Fprintf(os.Stderr, "  pdf-inspect -p secret file.pdf Pages 1 @contents\n")
+		fmt.Fprintf(os.Stderr, "  pdf-inspect file.pdf Pages 1 @ops\n")
+		fmt.Fprintf(os.Stderr, "  pdf-inspect -i file.pdf\n")
+		fmt.Fprintf(os.Stderr, "  pdf-inspect -json -data decoded file.pdf >file.json\n")
 	}
 	flag.Parse()
 
-	if flag.NArg() == 0 {
+	if flag.NArg() == 0 || (*jsonArg || *shellArg) && flag.NArg() > 1 {
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -68,9 +78,45 @@ func run() error {
 	}
 	defer stop()
 
+	if *jsonArg {
+		return writeJSON(flag.Arg(0))
+	}
+	if *shellArg {
+		var passwords []string
+		if *passwdArg != "" {
+			passwords = append(passwords, *passwdArg)
+		}
+		return runShell(flag.Arg(0), passwords...)
+	}
 	return showObject(flag.Args()...)
 }
 
+func writeJSON(fname string) error {
+	opt := &pdfjson.Options{}
+	switch *dataArg {
+	case "raw":
+		opt.Data = pdfjson.DataRaw
+	case "decoded":
+		opt.Data = pdfjson.DataDecoded
+	case "none":
+		opt.Data = pdfjson.DataNone
+	default:
+		return fmt.Errorf("invalid stream data mode %q", *dataArg)
+	}
+
+	var ropt *pdf.ReaderOptions
+	if *passwdArg != "" {
+		ropt = &pdf.ReaderOptions{Password: *passwdArg}
+	}
+	r, err := pdf.Open(fname, ropt)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	return pdfjson.Encode(os.Stdout, r, opt)
+}
+
 func showObject(args ...string) error {
 	passwords := []string{}
 	if *passwdArg != "" {
@@ -84,20 +130,9 @@ func showObject(args ...string) error {
 	defer cleanup()
 
 	for _, key := range args[1:] {
-		steps := obj.Next()
-		found := false
-		for _, step := range steps {
-			if step.Match.MatchString(key) {
-				obj, err = step.Next(key)
-				if err != nil {
-					return err
-				}
-				found = true
-				break
-			}
-		}
-		if !found {
-			return fmt.Errorf("no match for key %q", key)
+		obj, err = step(obj, key)
+		if err != nil {
+			return err
 		}
 	}
 	err = obj.Show()
@@ -0,0 +1,501 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"golang.org/x/term"
+
+	"seehuhn.de/go/pdf/cmd/pdf-inspect/traverse"
+)
+
+// maxFindResults limits the number of matches shown by the find command.
+const maxFindResults = 100
+
+// location is one element of the path from the file level to the current
+// object.
+type location struct {
+	key string
+	ctx traverse.Context
+}
+
+// shell implements the interactive mode of pdf-inspect.
+type shell struct {
+	name    string
+	root    traverse.Context
+	cwd     []location
+	prev    [][]location
+	history []string
+
+	// out is used to show completion candidates while a line is being
+	// edited.  Nil if stdin is not a terminal.
+	out io.Writer
+}
+
+type command struct {
+	name string
+	args string
+	desc string
+	run  func(s *shell, args []string) error
+}
+
+var commands []*command
+
+func init() {
+	commands = []*command{
+		{"cd", "[path]", "go to path, or to the file level if no path is given", (*shell).cd},
+		{"ls", "[path]", "list the selectors available at path", (*shell).ls},
+		{"cat", "[path]", "show the object at path", (*shell).cat},
+		{"pwd", "", "show the current path", (*shell).pwd},
+		{"back", "", "return to the previous location", (*shell).back},
+		{"history", "", "list the commands entered so far", (*shell).showHistory},
+		{"decode", "[path]", "show the decoded data of a stream", withSuffix("@dump")},
+		{"ops", "[path]", "show a content stream one operator per line", withSuffix("@ops")},
+		{"font", "[path]", "show information about a font dictionary", withSuffix("@font")},
+		{"find", "text", "search all objects for keys and values containing text", (*shell).find},
+		{"help", "", "show this list of commands", (*shell).help},
+		{"quit", "", "leave the shell", nil},
+	}
+}
+
+func findCommand(name string) *command {
+	switch name {
+	case "show":
+		name = "cat"
+	case "exit":
+		name = "quit"
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// runShell reads commands from stdin until end of input or until the
+// quit command is given.
+func runShell(fname string, passwords ...string) error {
+	root, cleanup, err := traverse.Root(fname, passwords...)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	s := &shell{
+		name: filepath.Base(fname),
+		root: root,
+	}
+
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			if !s.execute(scanner.Text()) {
+				return nil
+			}
+		}
+		return scanner.Err()
+	}
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, "")
+	t.AutoCompleteCallback = s.complete
+	s.out = t
+	fmt.Println("type \"help\" for a list of commands")
+	for {
+		t.SetPrompt(s.prompt())
+
+		// The terminal is in raw mode only while a line is edited, so that
+		// the output of the commands can be written to stdout directly.
+		state, err := term.MakeRaw(fd)
+		if err != nil {
+			return err
+		}
+		line, err := t.ReadLine()
+		term.Restore(fd, state)
+		if err == io.EOF {
+			fmt.Println()
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if !s.execute(line) {
+			return nil
+		}
+	}
+}
+
+// execute runs a single command line.  The return value is false if the
+// shell should exit.
+func (s *shell) execute(line string) bool {
+	words, err := splitWords(line)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return true
+	}
+	if len(words) == 0 {
+		return true
+	}
+	s.history = append(s.history, line)
+
+	cmd := findCommand(words[0])
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "unknown command %q, type \"help\" for a list of commands\n", words[0])
+		return true
+	}
+	if cmd.run == nil {
+		return false
+	}
+	err = cmd.run(s, words[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+	}
+	return true
+}
+
+func (s *shell) prompt() string {
+	return s.name + ":" + formatPath(s.cwd) + "> "
+}
+
+// current returns the context at the end of path.
+func (s *shell) current(path []location) traverse.Context {
+	if len(path) == 0 {
+		return s.root
+	}
+	return path[len(path)-1].ctx
+}
+
+// resolve interprets args as a path relative to the current location.
+// The special selectors "/", "." and ".." denote the file level, the
+// current location and the parent location, respectively.
+func (s *shell) resolve(args []string) ([]location, error) {
+	path := slices.Clone(s.cwd)
+	for _, key := range args {
+		switch key {
+		case "/":
+			path = path[:0]
+		case ".":
+			// pass
+		case "..":
+			if len(path) > 0 {
+				path = path[:len(path)-1]
+			}
+		default:
+			next, err := step(s.current(path), key)
+			if err != nil {
+				return nil, err
+			}
+			path = append(path, location{key: key, ctx: next})
+		}
+	}
+	return path, nil
+}
+
+func (s *shell) cd(args []string) error {
+	if len(args) == 1 && args[0] == "-" {
+		return s.back(nil)
+	}
+	path, err := s.resolve(args)
+	if err != nil {
+		return err
+	}
+	if len(args) == 0 {
+		path = nil
+	}
+	s.prev = append(s.prev, s.cwd)
+	s.cwd = path
+	return nil
+}
+
+func (s *shell) back(args []string) error {
+	if len(s.prev) == 0 {
+		return errors.New("no previous location")
+	}
+	s.cwd = s.prev[len(s.prev)-1]
+	s.prev = s.prev[:len(s.prev)-1]
+	return nil
+}
+
+func (s *shell) pwd(args []string) error {
+	fmt.Println(formatPath(s.cwd))
+	return nil
+}
+
+func (s *shell) ls(args []string) error {
+	path, err := s.resolve(args)
+	if err != nil {
+		return err
+	}
+	ctx := s.current(path)
+
+	steps := ctx.Next()
+	if len(steps) == 0 {
+		fmt.Println("no selectors available")
+		return nil
+	}
+	var descs strings.Builder
+	for _, step := range steps {
+		fmt.Printf("  • %s\n", step.Desc)
+		descs.WriteString(step.Desc)
+	}
+
+	// list the keys which are not already shown as keywords
+	var keys []string
+	for _, key := range traverse.Keys(ctx) {
+		if !strings.Contains(descs.String(), "`"+key+"`") {
+			keys = append(keys, quoteWord(key))
+		}
+	}
+	if len(keys) > 0 {
+		fmt.Println()
+		printColumns(os.Stdout, keys)
+	}
+	return nil
+}
+
+func (s *shell) cat(args []string) error {
+	path, err := s.resolve(args)
+	if err != nil {
+		return err
+	}
+	return s.current(path).Show()
+}
+
+// withSuffix returns a command which shows the object obtained by
+// appending sel to the path given on the command line.
+func withSuffix(sel string) func(s *shell, args []string) error {
+	return func(s *shell, args []string) error {
+		return s.cat(append(slices.Clip(args), sel))
+	}
+}
+
+func (s *shell) find(args []string) error {
+	if len(args) == 0 {
+		return errors.New("missing search text")
+	}
+	matches, err := traverse.Find(s.root, strings.Join(args, " "), maxFindResults+1)
+	if err != nil {
+		return err
+	}
+	if len(matches) == 0 {
+		fmt.Println("no matches")
+		return nil
+	}
+	for i, m := range matches {
+		if i == maxFindResults {
+			fmt.Printf("... (showing the first %d matches)\n", maxFindResults)
+			break
+		}
+		var words []string
+		for _, key := range m.Path {
+			words = append(words, quoteWord(key))
+		}
+		fmt.Printf("%s: %s %s\n", strings.Join(words, " "), m.Key, m.Value)
+	}
+	return nil
+}
+
+func (s *shell) showHistory(args []string) error {
+	for i, line := range s.history {
+		fmt.Printf("%4d  %s\n", i+1, line)
+	}
+	return nil
+}
+
+func (s *shell) help(args []string) error {
+	for _, cmd := range commands {
+		usage := cmd.name
+		if cmd.args != "" {
+			usage += " " + cmd.args
+		}
+		fmt.Printf("  %-16s %s\n", usage, cmd.desc)
+	}
+	fmt.Println()
+	fmt.Println("A path is a sequence of selectors, as on the command line.")
+	fmt.Println("Use \"/\" for the file level and \"..\" for the parent object.")
+	fmt.Println("Selectors containing spaces can be enclosed in double quotes.")
+	fmt.Println("Press TAB to complete commands and selectors.")
+	return nil
+}
+
+// complete implements tab completion for the terminal.
+func (s *shell) complete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' {
+		return "", 0, false
+	}
+
+	head := line[:pos]
+	words, err := splitWords(head)
+	if err != nil {
+		// do not try to complete inside quotes
+		return "", 0, false
+	}
+	if len(words) == 0 || strings.HasSuffix(head, " ") {
+		words = append(words, "")
+	}
+	word := words[len(words)-1]
+
+	var candidates []string
+	if len(words) == 1 {
+		for _, cmd := range commands {
+			candidates = append(candidates, cmd.name)
+		}
+	} else {
+		if words[0] == "find" {
+			return "", 0, false
+		}
+		path, err := s.resolve(words[1 : len(words)-1])
+		if err != nil {
+			return "", 0, false
+		}
+		candidates = append(traverse.Keys(s.current(path)), "..")
+	}
+
+	var matches []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) && !slices.Contains(matches, c) {
+			matches = append(matches, c)
+		}
+	}
+
+	var completion string
+	switch len(matches) {
+	case 0:
+		return "", 0, false
+	case 1:
+		completion = quoteWord(matches[0]) + " "
+	default:
+		completion = commonPrefix(matches)
+		if strings.ContainsAny(completion, " \"") {
+			completion = word
+		}
+		if completion == word && s.out != nil {
+			printColumns(s.out, matches)
+		}
+	}
+
+	start := pos - len(word)
+	newLine := line[:start] + completion + line[pos:]
+	return newLine, start + len(completion), true
+}
+
+// step goes from ctx to the next object selected by key.
+func step(ctx traverse.Context, key string) (traverse.Context, error) {
+	for _, step := range ctx.Next() {
+		if step.Match.MatchString(key) {
+			return step.Next(key)
+		}
+	}
+	return nil, fmt.Errorf("no match for key %q", key)
+}
+
+func formatPath(path []location) string {
+	if len(path) == 0 {
+		return "/"
+	}
+	var words []string
+	for _, loc := range path {
+		words = append(words, quoteWord(loc.key))
+	}
+	return strings.Join(words, " ")
+}
+
+// splitWords splits a command line into words.  Words are separated by
+// white space, and double quotes can be used to include spaces in a word.
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	inQuotes := false
+	for _, r := range line {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			inWord = true
+		case !inQuotes && (r == ' ' || r == '\t'):
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if inQuotes {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// quoteWord encloses a word in double quotes if it contains white space.
+func quoteWord(word string) string {
+	if word == "" || strings.ContainsAny(word, " \t") {
+		return `"` + word + `"`
+	}
+	return word
+}
+
+func commonPrefix(words []string) string {
+	prefix := words[0]
+	for _, w := range words[1:] {
+		n := 0
+		for n < len(prefix) && n < len(w) && prefix[n] == w[n] {
+			n++
+		}
+		prefix = prefix[:n]
+	}
+	return prefix
+}
+
+// printColumns writes the words in columns, using at most 78 characters
+// per line.
+func printColumns(w io.Writer, words []string) {
+	width := 0
+	for _, word := range words {
+		width = max(width, len(word))
+	}
+	width += 2
+	perLine := max(78/width, 1)
+
+	var buf strings.Builder
+	for i, word := range words {
+		if i%perLine == perLine-1 || i == len(words)-1 {
+			buf.WriteString(word)
+			buf.WriteString("\n")
+		} else {
+			fmt.Fprintf(&buf, "%-*s", width, word)
+		}
+	}
+	io.WriteString(w, buf.String())
+}
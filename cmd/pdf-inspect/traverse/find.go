@@ -0,0 +1,147 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package traverse
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"seehuhn.de/go/pdf"
+)
+
+// Match is an occurrence of a search term, found by [Find].
+type Match struct {
+	// Path is the sequence of selectors which leads from the file level to
+	// the dictionary or array which contains the match.  The first element
+	// is the object number.
+	Path []string
+
+	// Key is the dictionary key or the array index of the match.
+	Key string
+
+	// Value is a short description of the value.
+	Value string
+}
+
+// Find searches all indirect objects of the file for dictionary keys and
+// values which contain query, ignoring case.  References are not followed;
+// each indirect object is searched on its own.  At most limit matches are
+// returned, if limit is positive.  The argument root must be the context
+// returned by [Root].
+func Find(root Context, query string, limit int) ([]Match, error) {
+	fc, ok := root.(*fileCtx)
+	if !ok {
+		return nil, errors.New("search needs the file-level context")
+	}
+	r, ok := fc.r.(*pdf.Reader)
+	if !ok {
+		return nil, errors.New("search not supported for this file")
+	}
+
+	s := &searcher{
+		ctx:   &objectCtx{r: r},
+		query: strings.ToLower(query),
+		limit: limit,
+	}
+	for ref, _ := range r.Objects() {
+		obj, err := r.Get(ref, true)
+		if pdf.IsReadError(err) {
+			return s.matches, err
+		} else if err != nil {
+			continue
+		}
+		sel := strconv.FormatUint(uint64(ref.Number()), 10)
+		if gen := ref.Generation(); gen != 0 {
+			sel += "." + strconv.FormatUint(uint64(gen), 10)
+		}
+		if !s.search([]string{sel}, obj) {
+			break
+		}
+	}
+	return s.matches, nil
+}
+
+type searcher struct {
+	ctx     *objectCtx
+	query   string
+	limit   int
+	matches []Match
+}
+
+// search looks for matches in obj.  It returns false when the limit has
+// been reached.
+func (s *searcher) search(path []string, obj pdf.Object) bool {
+	switch x := obj.(type) {
+	case *pdf.Stream:
+		return s.search(path, x.Dict)
+	case pdf.Dict:
+		for _, key := range dictKeys(x) {
+			val := x[key]
+			if s.contains(string(key)) || s.valueMatches(val) {
+				if !s.add(path, "/"+string(key), val) {
+					return false
+				}
+			}
+			if !s.search(append(slices.Clip(path), string(key)), val) {
+				return false
+			}
+		}
+	case pdf.Array:
+		for i, val := range x {
+			key := strconv.Itoa(i)
+			if s.valueMatches(val) {
+				if !s.add(path, key, val) {
+					return false
+				}
+			}
+			if !s.search(append(slices.Clip(path), key), val) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (s *searcher) add(path []string, key string, val pdf.Object) bool {
+	desc, err := s.ctx.explainSingleLine(val)
+	if err != nil {
+		desc = "???"
+	}
+	s.matches = append(s.matches, Match{Path: slices.Clone(path), Key: key, Value: desc})
+	return s.limit <= 0 || len(s.matches) < s.limit
+}
+
+// valueMatches reports whether a simple value contains the query.
+func (s *searcher) valueMatches(obj pdf.Object) bool {
+	switch x := obj.(type) {
+	case pdf.Name:
+		return s.contains(string(x))
+	case pdf.String:
+		return s.contains(string(x.AsTextString()))
+	case pdf.Integer, pdf.Real, pdf.Reference:
+		return s.contains(fmt.Sprint(x))
+	default:
+		return false
+	}
+}
+
+func (s *searcher) contains(text string) bool {
+	return strings.Contains(strings.ToLower(text), s.query)
+}
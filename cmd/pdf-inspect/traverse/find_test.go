@@ -0,0 +1,141 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package traverse
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"seehuhn.de/go/pdf"
+)
+
+func writeTestFile(t *testing.T) string {
+	t.Helper()
+
+	fname := filepath.Join(t.TempDir(), "test.pdf")
+	w, err := pdf.Create(fname, pdf.V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pagesRef := w.Alloc()
+	pageRef := w.Alloc()
+	err = w.Put(pagesRef, pdf.Dict{
+		"Type":  pdf.Name("Pages"),
+		"Kids":  pdf.Array{pageRef},
+		"Count": pdf.Integer(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Put(pageRef, pdf.Dict{
+		"Type":     pdf.Name("Page"),
+		"Parent":   pagesRef,
+		"MediaBox": pdf.Array{pdf.Integer(0), pdf.Integer(0), pdf.Integer(200), pdf.Integer(100)},
+		"Rotate":   pdf.Integer(90),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.GetMeta().Catalog.Pages = pagesRef
+	w.GetMeta().Info = &pdf.Info{Title: "Find Me Please"}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return fname
+}
+
+func TestKeys(t *testing.T) {
+	root, cleanup, err := Root(writeTestFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	keys := Keys(root)
+	for _, want := range []string{"Pages", "Type", "meta", "catalog"} {
+		if !slices.Contains(keys, want) {
+			t.Errorf("file keys %q: missing %q", keys, want)
+		}
+	}
+
+	pages, err := navigateContext(root, "Pages")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys = Keys(pages)
+	for _, want := range []string{"Kids", "Count", "1"} {
+		if !slices.Contains(keys, want) {
+			t.Errorf("pages keys %q: missing %q", keys, want)
+		}
+	}
+
+	kids, err := navigateContext(pages, "Kids")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys = Keys(kids)
+	if !slices.Contains(keys, "0") || slices.Contains(keys, "1") {
+		t.Errorf("array keys %q: expected index 0 only", keys)
+	}
+}
+
+func TestFind(t *testing.T) {
+	root, cleanup, err := Root(writeTestFile(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cleanup()
+
+	// key match
+	matches, err := Find(root, "rotate", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Key != "/Rotate" || matches[0].Value != "90" {
+		t.Errorf("rotate: got %v", matches)
+	}
+
+	// text string value match
+	matches, err = Find(root, "find me", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Key != "/Title" {
+		t.Errorf("find me: got %v", matches)
+	}
+
+	// nested array element
+	matches, err = Find(root, "200", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Key != "2" ||
+		!slices.Equal(matches[0].Path[1:], []string{"MediaBox"}) {
+		t.Errorf("200: got %v", matches)
+	}
+
+	// limit
+	matches, err = Find(root, "e", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 2 {
+		t.Errorf("limit: got %d matches", len(matches))
+	}
+}
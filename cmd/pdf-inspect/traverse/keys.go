@@ -0,0 +1,102 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package traverse
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// maxKeys limits the number of array indices and page numbers returned by
+// [Keys].
+const maxKeys = 1000
+
+// keyLister is implemented by contexts which can list the concrete
+// selectors accepted by their steps.
+type keyLister interface {
+	keys() []string
+}
+
+var keywordRegexp = regexp.MustCompile("`([^`]+)`")
+
+// Keys returns the selectors which can be used in the context c.  This
+// includes the keywords shown in the step descriptions, and dictionary
+// keys, array indices and page numbers where applicable.  The result is
+// used for completion in the interactive shell.
+func Keys(c Context) []string {
+	var res []string
+	for _, step := range c.Next() {
+		for _, m := range keywordRegexp.FindAllStringSubmatch(step.Desc, -1) {
+			res = append(res, m[1])
+		}
+	}
+	if kl, ok := c.(keyLister); ok {
+		res = append(res, kl.keys()...)
+	}
+	return res
+}
+
+func (c *fileCtx) keys() []string {
+	cat, err := pdf.NewCursor(c.r).Dict(c.r.GetMeta().Trailer["Root"])
+	if err != nil {
+		return nil
+	}
+	return dictKeyStrings(cat)
+}
+
+func (c *objectCtx) keys() []string {
+	switch x := c.obj.(type) {
+	case pdf.Dict:
+		res := dictKeyStrings(x)
+		if tp, _ := x["Type"].(pdf.Name); tp == "Pages" && c.r != nil {
+			n, err := pagetree.NumPages(c.r)
+			if err == nil {
+				res = append(res, numbers(1, n)...)
+			}
+		}
+		return res
+	case pdf.Array:
+		return numbers(0, len(x)-1)
+	case *pdf.Stream:
+		return dictKeyStrings(x.Dict)
+	default:
+		return nil
+	}
+}
+
+func dictKeyStrings(dict pdf.Dict) []string {
+	var res []string
+	for _, key := range slices.Sorted(maps.Keys(dict)) {
+		res = append(res, string(key))
+	}
+	return res
+}
+
+// numbers returns the decimal representations of the integers from first
+// to last, but at most maxKeys of them.
+func numbers(first, last int) []string {
+	var res []string
+	for i := first; i <= last && len(res) < maxKeys; i++ {
+		res = append(res, strconv.Itoa(i))
+	}
+	return res
+}
@@ -63,6 +63,15 @@ func (c *objectCtx) Next() []Step {
 						}, nil
 					},
 				})
+				steps = append(steps, Step{
+					Match: regexp.MustCompile(`^@ops$`),
+					Desc:  "`@ops`",
+					Next: func(key string) (Context, error) {
+						return &opsCtx{open: func() (io.ReadCloser, error) {
+							return pagetree.ContentStream(c.r, x)
+						}}, nil
+					},
+				})
 			}
 		}
 
@@ -187,6 +196,26 @@ func (c *objectCtx) Next() []Step {
 				return &rawStreamCtx{r: decoded}, nil
 			},
 		})
+		open := func() (io.ReadCloser, error) {
+			if c.r == nil {
+				return nil, errors.New("reader is nil, cannot decode stream")
+			}
+			return pdf.NewCursor(c.r).StreamReader(x)
+		}
+		steps = append(steps, Step{
+			Match: regexp.MustCompile(`^@dump$`),
+			Desc:  "`@dump`",
+			Next: func(key string) (Context, error) {
+				return &dumpCtx{open: open}, nil
+			},
+		})
+		steps = append(steps, Step{
+			Match: regexp.MustCompile(`^@ops$`),
+			Desc:  "`@ops`",
+			Next: func(key string) (Context, error) {
+				return &opsCtx{open: open}, nil
+			},
+		})
 		steps = append(steps, Step{
 			Match: regexp.MustCompile(`^dict$`),
 			Desc:  "`dict`",
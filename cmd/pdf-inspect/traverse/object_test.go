@@ -257,14 +257,14 @@ func TestObjectCtxKeys(t *testing.T) {
 					"Subtype": pdf.Name("Image"),
 				},
 			},
-			expected: []string{"`@encoded`", "`@raw`", "`dict`", "stream dict keys"},
+			expected: []string{"`@encoded`", "`@raw`", "`@dump`", "`@ops`", "`dict`", "stream dict keys"},
 		},
 		{
 			name: "stream with empty dict",
 			obj: &pdf.Stream{
 				Dict: pdf.Dict{},
 			},
-			expected: []string{"`@encoded`", "`@raw`", "`dict`"},
+			expected: []string{"`@encoded`", "`@raw`", "`@dump`", "`@ops`", "`dict`"},
 		},
 		{
 			name:     "scalar type",
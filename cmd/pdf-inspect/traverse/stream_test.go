@@ -294,7 +294,7 @@ func TestObjectCtxKeysWithSpecialActions(t *testing.T) {
 					"Subtype": pdf.Name("Image"),
 				},
 			},
-			expected: []string{"`@encoded`", "`@raw`", "`dict`", "stream dict keys"},
+			expected: []string{"`@encoded`", "`@raw`", "`@dump`", "`@ops`", "`dict`", "stream dict keys"},
 		},
 		{
 			name: "page dict with @contents",
@@ -304,7 +304,7 @@ func TestObjectCtxKeysWithSpecialActions(t *testing.T) {
 				"Contents": pdf.NewReference(2, 0),
 				"MediaBox": pdf.Array{pdf.Integer(0), pdf.Integer(0), pdf.Integer(612), pdf.Integer(792)},
 			},
-			expected: []string{"`@contents`", "dict keys (with optional /)"},
+			expected: []string{"`@contents`", "`@ops`", "dict keys (with optional /)"},
 		},
 		{
 			name: "pages dict with page numbers",
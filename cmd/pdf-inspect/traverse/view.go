@@ -0,0 +1,146 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package traverse
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/content"
+)
+
+// maxDumpBytes is the maximum number of bytes shown in a hex dump.
+const maxDumpBytes = 4096
+
+// opsCtx shows a content stream, one operator per line.  The lines are
+// indented according to the nesting of q/Q, BT/ET, marked-content and
+// compatibility sections.
+type opsCtx struct {
+	open func() (io.ReadCloser, error)
+}
+
+func (c *opsCtx) Next() []Step {
+	return nil
+}
+
+func (c *opsCtx) Show() error {
+	it := content.NewScanner(c.open).NewIter()
+	depth := 0
+	count := 0
+	for name, args := range it.All() {
+		switch name {
+		case content.OpPopGraphicsState, content.OpTextEnd, content.OpEndMarkedContent, content.OpEndCompatibility:
+			depth = max(depth-1, 0)
+		}
+
+		var b strings.Builder
+		b.WriteString(strings.Repeat("  ", depth))
+		for _, arg := range args {
+			b.WriteString(formatArg(arg))
+			b.WriteByte(' ')
+		}
+		b.WriteString(string(name))
+		fmt.Println(b.String())
+		count++
+
+		switch name {
+		case content.OpPushGraphicsState, content.OpTextBegin, content.OpBeginMarkedContent,
+			content.OpBeginMarkedContentWithProperties, content.OpBeginCompatibility:
+			depth++
+		}
+	}
+	if err := it.Err(); err != nil {
+		return err
+	}
+	if count == 0 {
+		fmt.Println("empty content stream")
+	}
+	return nil
+}
+
+// formatArg formats an operator argument.  Long strings, like the data of
+// inline images, are abbreviated.
+func formatArg(obj pdf.Object) string {
+	if s, ok := obj.(pdf.String); ok && len(s) > 64 {
+		return fmt.Sprintf("<%d bytes>", len(s))
+	}
+	buf := &bytes.Buffer{}
+	err := pdf.Format(buf, 0, obj)
+	if err != nil {
+		return "???"
+	}
+	return buf.String()
+}
+
+// dumpCtx shows decoded stream data.  Text is shown as it is, binary data
+// is shown as a hex dump.
+type dumpCtx struct {
+	open func() (io.ReadCloser, error)
+}
+
+func (c *dumpCtx) Next() []Step {
+	return nil
+}
+
+func (c *dumpCtx) Show() error {
+	r, err := c.open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	buf := make([]byte, maxDumpBytes)
+	n, err := io.ReadFull(r, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return err
+	}
+	buf = buf[:n]
+	if n == 0 {
+		fmt.Println("empty stream")
+		return nil
+	}
+
+	if !mostlyBinary(buf[:min(n, 512)]) {
+		_, err := os.Stdout.Write(buf)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(os.Stdout, r)
+		return err
+	}
+
+	d := hex.Dumper(os.Stdout)
+	if _, err := d.Write(buf); err != nil {
+		return err
+	}
+	if err := d.Close(); err != nil {
+		return err
+	}
+	rest, err := io.Copy(io.Discard, r)
+	if err != nil {
+		return err
+	}
+	if rest > 0 {
+		fmt.Printf("... %d more bytes\n", rest)
+	}
+	return nil
+}
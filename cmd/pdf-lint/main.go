@@ -0,0 +1,129 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Lint checks PDF files for problems.
+//
+// Each file is checked for damage found while opening it, for objects which
+// can not be read or decoded, for entries which are not allowed in the PDF
+// version of the file, and for invalid operators and missing resources in
+// content streams.  Errors and warnings are listed together with their
+// location in the file: the page number, the object number and the path of
+// dictionary keys and array indices inside the object.  Minor violations
+// which the decoders repair, such as entries of the wrong type, are reported
+// as warnings.
+//
+// The exit status is 0 if no errors were found, 1 if at least one file
+// contains errors, and 2 if a file could not be read.  Warnings do not
+// change the exit status.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/cmd/internal/buildinfo"
+	"seehuhn.de/go/pdf/pdflint"
+)
+
+var (
+	passwdArg = flag.String("p", "", "PDF password")
+	quietArg  = flag.Bool("q", false, "only report errors, not warnings")
+	noContent = flag.Bool("no-content", false, "do not check content streams")
+	maxArg    = flag.Int("max", 0, "stop after `n` problems per file (0 for no limit)")
+)
+
+var errFound = errors.New("errors found")
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "pdf-lint — check PDF files for problems\n")
+		fmt.Fprintf(os.Stderr, "%s\n\n", buildinfo.Short("pdf-lint"))
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  pdf-lint [options] <file.pdf>...\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nFiles are checked for damage, for objects which can not be read or decoded,\n")
+		fmt.Fprintf(os.Stderr, "for entries which need a later PDF version, and for invalid operators and\n")
+		fmt.Fprintf(os.Stderr, "missing resources in content streams.  Minor violations which are repaired\n")
+		fmt.Fprintf(os.Stderr, "while reading are reported as warnings.\n")
+		fmt.Fprintf(os.Stderr, "\nThe exit status is 0 if no errors were found, 1 if errors were found\n")
+		fmt.Fprintf(os.Stderr, "and 2 if a file could not be read.\n")
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  pdf-lint file.pdf\n")
+		fmt.Fprintf(os.Stderr, "  pdf-lint -q -no-content *.pdf\n")
+	}
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	status := 0
+	for _, fname := range flag.Args() {
+		err := lint(fname, flag.NArg() > 1)
+		if errors.Is(err, errFound) {
+			status = max(status, 1)
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", fname, err)
+			status = 2
+		}
+	}
+	os.Exit(status)
+}
+
+// lint checks a single file and prints the problems found.  If showName is
+// true, each line is prefixed with the file name.
+func lint(fname string, showName bool) error {
+	opt := &pdf.ReaderOptions{
+		Password:      *passwdArg,
+		ErrorHandling: pdf.ErrorHandlingReport,
+	}
+	r, err := pdf.Open(fname, opt)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	problems, err := pdflint.Check(r, &pdflint.Options{
+		SkipContent: *noContent,
+		MaxProblems: *maxArg,
+	})
+
+	hasErrors := false
+	for _, p := range problems {
+		if p.Severity == pdflint.Error {
+			hasErrors = true
+		} else if *quietArg {
+			continue
+		}
+		if showName {
+			fmt.Print(fname, ": ")
+		}
+		fmt.Println(p)
+	}
+
+	if err != nil {
+		return err
+	}
+	if hasErrors {
+		return errFound
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Paginate stamps running headers, footers, page numbers and Bates numbers
+// onto the pages of a PDF file.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/cmd/internal/buildinfo"
+	"seehuhn.de/go/pdf/cmd/internal/length"
+	"seehuhn.de/go/pdf/cmd/internal/profile"
+	"seehuhn.de/go/pdf/pagination"
+)
+
+var (
+	out         = flag.String("o", "out.pdf", "output file name")
+	force       = flag.Bool("f", false, "overwrite output file if it exists")
+	headerLeft  = flag.String("header-left", "", "`text` at the top left of each page")
+	header      = flag.String("header", "", "`text` at the top centre of each page")
+	headerRight = flag.String("header-right", "", "`text` at the top right of each page")
+	footerLeft  = flag.String("footer-left", "", "`text` at the bottom left of each page")
+	footer      = flag.String("footer", "", "`text` at the bottom centre of each page")
+	footerRight = flag.String("footer-right", "", "`text` at the bottom right of each page")
+	fontSize    = flag.Float64("size", 9, "font size in points")
+	margin      = flag.String("margin", "24pt", "distance between the text and the page edge")
+	dateFormat  = flag.String("date-format", "2006-01-02", "Go time `layout` for {date}")
+	batesPrefix = flag.String("bates-prefix", "", "text before the Bates number")
+	batesSuffix = flag.String("bates-suffix", "", "text after the Bates number")
+	batesStart  = flag.Int("bates-start", 1, "Bates number of the first page")
+	batesDigits = flag.Int("bates-digits", 6, "minimal number of digits of a Bates number")
+	cpuprofile  = flag.String("cpuprofile", "", "write cpu profile to `file`")
+	memprofile  = flag.String("memprofile", "", "write memory profile to `file`")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "pdf-paginate \u2014 stamp headers, footers and page numbers onto PDF pages\n")
+		fmt.Fprintf(os.Stderr, "%s\n\n", buildinfo.Short("pdf-paginate"))
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  pdf-paginate [options] <input.pdf>\n\n")
+		fmt.Fprintf(os.Stderr, "Arguments:\n")
+		fmt.Fprintf(os.Stderr, "  input.pdf   PDF file to stamp\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nThe texts may contain the placeholders {page}, {pages}, {label}, {date}\n")
+		fmt.Fprintf(os.Stderr, "and {bates}.  Lengths are given in PDF points, or with a unit suffix\n")
+		fmt.Fprintf(os.Stderr, "(mm, cm, in, pt).\n")
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  pdf-paginate -footer \"Page {page} of {pages}\" -o out.pdf in.pdf\n")
+		fmt.Fprintf(os.Stderr, "  pdf-paginate -footer-right \"{bates}\" -bates-prefix ACME -bates-start 1001 -o out.pdf in.pdf\n")
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if err := run(flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(inName string) error {
+	stop, err := profile.Start(*cpuprofile, *memprofile)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	opts, err := getOptions()
+	if err != nil {
+		return err
+	}
+
+	r, err := pdf.Open(inName, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !*force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+	fd, err := os.OpenFile(*out, flags, 0o666)
+	if os.IsExist(err) {
+		return fmt.Errorf("output file %q already exists (use -f to overwrite)", *out)
+	} else if err != nil {
+		return err
+	}
+
+	err = pagination.Write(fd, r, opts)
+	if err != nil {
+		fd.Close()
+		os.Remove(*out)
+		return err
+	}
+	return fd.Close()
+}
+
+// getOptions converts the command line flags into pagination options.
+func getOptions() (*pagination.Options, error) {
+	opts := &pagination.Options{
+		FontSize:    *fontSize,
+		DateFormat:  *dateFormat,
+		BatesPrefix: *batesPrefix,
+		BatesSuffix: *batesSuffix,
+		BatesStart:  *batesStart,
+		BatesDigits: *batesDigits,
+	}
+
+	for _, f := range []struct {
+		pos  pagination.Position
+		text string
+	}{
+		{pagination.TopLeft, *headerLeft},
+		{pagination.TopCenter, *header},
+		{pagination.TopRight, *headerRight},
+		{pagination.BottomLeft, *footerLeft},
+		{pagination.BottomCenter, *footer},
+		{pagination.BottomRight, *footerRight},
+	} {
+		if f.text != "" {
+			opts.Fields = append(opts.Fields, pagination.Field{Position: f.pos, Text: f.text})
+		}
+	}
+	if len(opts.Fields) == 0 {
+		return nil, fmt.Errorf("no header or footer text given")
+	}
+
+	m, err := length.Parse(*margin)
+	if err != nil {
+		return nil, fmt.Errorf("invalid margin: %w", err)
+	}
+	opts.Margin = m
+
+	return opts, nil
+}
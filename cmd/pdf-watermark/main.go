@@ -0,0 +1,264 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Watermark places a text, an image or a page of another PDF file onto the
+// pages of a PDF file.
+package main
+
+import (
+	"flag"
+	"fmt"
+	goimage "image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"strconv"
+	"strings"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/cmd/internal/buildinfo"
+	"seehuhn.de/go/pdf/cmd/internal/length"
+	"seehuhn.de/go/pdf/cmd/internal/profile"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/image"
+	"seehuhn.de/go/pdf/watermark"
+)
+
+var (
+	out        = flag.String("o", "out.pdf", "output file name")
+	force      = flag.Bool("f", false, "overwrite output file if it exists")
+	text       = flag.String("text", "", "watermark `text`")
+	imageFile  = flag.String("image", "", "PNG or JPEG `file` to use as the watermark")
+	pdfFile    = flag.String("pdf", "", "PDF `file` whose first page is used as the watermark")
+	placement  = flag.String("placement", "behind", "placement (behind, front or annotation)")
+	position   = flag.String("position", "center", "position on the page (center, top, bottom, left, right, top-left, top-right, bottom-left, bottom-right)")
+	angle      = flag.Float64("angle", 0, "rotation in `degrees` counterclockwise")
+	diagonal   = flag.Bool("diagonal", false, "rotate along the page diagonal")
+	size       = flag.String("size", "0", "font size for text, or width for graphics (0 scales to fit)")
+	scale      = flag.Float64("scale", 0.8, "fraction of the page covered if -size is 0")
+	margin     = flag.String("margin", "0", "distance from the page edge")
+	opacity    = flag.Float64("opacity", 1, "opacity, from 0 (invisible) to 1 (opaque)")
+	gray       = flag.Float64("gray", 0.5, "gray level of the text, from 0 (black) to 1 (white)")
+	layer      = flag.String("layer", "", "put the watermark in an optional content group of this `name`")
+	pageSpec   = flag.String("pages", "", "pages to mark, e.g. `1-4,7` (default all)")
+	cpuprofile = flag.String("cpuprofile", "", "write cpu profile to `file`")
+	memprofile = flag.String("memprofile", "", "write memory profile to `file`")
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "pdf-watermark \u2014 add a watermark to the pages of a PDF file\n")
+		fmt.Fprintf(os.Stderr, "%s\n\n", buildinfo.Short("pdf-watermark"))
+		fmt.Fprintf(os.Stderr, "Usage:\n")
+		fmt.Fprintf(os.Stderr, "  pdf-watermark [options] <input.pdf>\n\n")
+		fmt.Fprintf(os.Stderr, "Arguments:\n")
+		fmt.Fprintf(os.Stderr, "  input.pdf   PDF file to add the watermark to\n\n")
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\nExactly one of -text, -image and -pdf must be given.  Lengths are given\n")
+		fmt.Fprintf(os.Stderr, "in PDF points, or with a unit suffix (mm, cm, in, pt).\n")
+		fmt.Fprintf(os.Stderr, "\nExamples:\n")
+		fmt.Fprintf(os.Stderr, "  pdf-watermark -text CONFIDENTIAL -diagonal -opacity 0.3 -o out.pdf in.pdf\n")
+		fmt.Fprintf(os.Stderr, "  pdf-watermark -image logo.png -size 2cm -position top-right -margin 1cm -placement front -o out.pdf in.pdf\n")
+	}
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	if err := run(flag.Arg(0)); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func run(inName string) error {
+	stop, err := profile.Start(*cpuprofile, *memprofile)
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	opts, closeFn, err := getOptions()
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	r, err := pdf.Open(inName, nil)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !*force {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	}
+	fd, err := os.OpenFile(*out, flags, 0o666)
+	if os.IsExist(err) {
+		return fmt.Errorf("output file %q already exists (use -f to overwrite)", *out)
+	} else if err != nil {
+		return err
+	}
+
+	err = watermark.Write(fd, r, opts)
+	if err != nil {
+		fd.Close()
+		os.Remove(*out)
+		return err
+	}
+	return fd.Close()
+}
+
+// getOptions converts the command line flags into watermark options.  The
+// returned function closes the PDF file used for the watermark, if any.
+func getOptions() (*watermark.Options, func(), error) {
+	closeFn := func() {}
+
+	if *opacity < 0 || *opacity > 1 {
+		return nil, closeFn, fmt.Errorf("invalid opacity %g", *opacity)
+	}
+	opts := &watermark.Options{
+		Text:         *text,
+		Color:        color.DeviceGray(*gray),
+		Angle:        *angle,
+		Diagonal:     *diagonal,
+		Scale:        *scale,
+		Transparency: 1 - *opacity,
+		Layer:        *layer,
+	}
+
+	switch *placement {
+	case "behind":
+		opts.Placement = watermark.Behind
+	case "front":
+		opts.Placement = watermark.InFront
+	case "annotation":
+		opts.Placement = watermark.AsAnnotation
+	default:
+		return nil, closeFn, fmt.Errorf("unknown placement %q (supported: behind, front, annotation)", *placement)
+	}
+
+	positions := map[string]watermark.Position{
+		"center":       watermark.Center,
+		"top":          watermark.Top,
+		"bottom":       watermark.Bottom,
+		"left":         watermark.Left,
+		"right":        watermark.Right,
+		"top-left":     watermark.TopLeft,
+		"top-right":    watermark.TopRight,
+		"bottom-left":  watermark.BottomLeft,
+		"bottom-right": watermark.BottomRight,
+	}
+	pos, ok := positions[*position]
+	if !ok {
+		return nil, closeFn, fmt.Errorf("unknown position %q", *position)
+	}
+	opts.Position = pos
+
+	for _, l := range []struct {
+		name string
+		spec string
+		val  *float64
+	}{
+		{"size", *size, &opts.Size},
+		{"margin", *margin, &opts.Margin},
+	} {
+		x, err := length.Parse(l.spec)
+		if err != nil {
+			return nil, closeFn, fmt.Errorf("invalid %s: %w", l.name, err)
+		}
+		*l.val = x
+	}
+
+	if *pageSpec != "" {
+		pages, err := parsePages(*pageSpec)
+		if err != nil {
+			return nil, closeFn, err
+		}
+		opts.Pages = pages
+	}
+
+	n := 0
+	for _, s := range []string{*text, *imageFile, *pdfFile} {
+		if s != "" {
+			n++
+		}
+	}
+	if n != 1 {
+		return nil, closeFn, fmt.Errorf("exactly one of -text, -image and -pdf must be given")
+	}
+
+	switch {
+	case *imageFile != "":
+		fd, err := os.Open(*imageFile)
+		if err != nil {
+			return nil, closeFn, err
+		}
+		img, _, err := goimage.Decode(fd)
+		fd.Close()
+		if err != nil {
+			return nil, closeFn, fmt.Errorf("%s: %w", *imageFile, err)
+		}
+		dict := image.FromImage(img, color.SpaceDeviceRGB, 8)
+		if o, ok := img.(interface{ Opaque() bool }); ok && !o.Opaque() {
+			dict.SMask = image.FromImageAlpha(img, 8)
+		}
+		opts.Graphic = dict
+
+	case *pdfFile != "":
+		src, err := pdf.Open(*pdfFile, nil)
+		if err != nil {
+			return nil, closeFn, err
+		}
+		closeFn = func() { src.Close() }
+		f, err := watermark.FromPage(src, 0)
+		if err != nil {
+			return nil, closeFn, fmt.Errorf("%s: %w", *pdfFile, err)
+		}
+		opts.Graphic = f
+	}
+
+	return opts, closeFn, nil
+}
+
+// parsePages parses a comma-separated list of one-based page numbers and
+// page ranges, and returns the zero-based page indices.
+func parsePages(spec string) ([]int, error) {
+	var pages []int
+	for part := range strings.SplitSeq(spec, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, "-")
+		a, err := strconv.Atoi(first)
+		if err != nil || a < 1 {
+			return nil, fmt.Errorf("invalid page specification %q", part)
+		}
+		b := a
+		if isRange {
+			b, err = strconv.Atoi(last)
+			if err != nil || b < a {
+				return nil, fmt.Errorf("invalid page specification %q", part)
+			}
+		}
+		for i := a; i <= b; i++ {
+			pages = append(pages, i-1)
+		}
+	}
+	return pages, nil
+}
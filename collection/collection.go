@@ -0,0 +1,475 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collection
+
+import (
+	"errors"
+	"fmt"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/optional"
+)
+
+// PDF 2.0 sections: 7.11.6 12.3.6
+
+// Collection represents a collection dictionary.  A document whose catalog
+// contains a collection dictionary is presented as a portable collection
+// (a "PDF portfolio") of the files in its EmbeddedFiles name tree.
+//
+// The entries marked as PDF 2.0 were introduced by Adobe's extension level
+// 3 to PDF 1.7, and are accepted in PDF 1.7 files.
+type Collection struct {
+	// Schema (optional) describes the fields shown for the files of the
+	// collection.  The keys are the field names used in the collection
+	// item dictionaries.
+	Schema map[pdf.Name]*Field
+
+	// InitialDocument (optional) is the key in the EmbeddedFiles name tree
+	// of the document which is initially presented.  If this is empty, the
+	// document containing the collection is presented.
+	//
+	// This corresponds to the /D entry in the PDF dictionary.
+	InitialDocument string
+
+	// View (optional) specifies how the collection is presented initially.
+	//
+	// When writing collections, an empty name can be used as a shorthand
+	// for [ViewDetails].
+	View View
+
+	// Navigator (PDF 2.0; required if View is [ViewCustom]) describes the
+	// layouts a navigator may use to present the collection.
+	Navigator *Navigator
+
+	// Colors (optional, PDF 2.0) specifies the colours used by an
+	// interactive PDF processor to present the collection.
+	Colors *Colors
+
+	// Sort (optional) specifies the order of the files in the user
+	// interface.
+	Sort *Sort
+
+	// Folders (optional, PDF 2.0) is the root of the folder hierarchy of
+	// the collection.  Files are placed in folders by prefixing their keys
+	// in the EmbeddedFiles name tree with the folder ID, see [FileKey].
+	Folders *Folder
+
+	// Split (optional, PDF 2.0) specifies the orientation of the splitter
+	// bar in the details and tile views.
+	Split *Split
+}
+
+// View specifies the initial presentation of a collection.
+type View pdf.Name
+
+// These are the values for [Collection.View].
+const (
+	// ViewDetails shows the files in a multi-column list.
+	ViewDetails View = "D"
+
+	// ViewTile shows the files as icons, with information from the
+	// schema.
+	ViewTile View = "T"
+
+	// ViewHidden initially hides the collection user interface.
+	ViewHidden View = "H"
+
+	// ViewCustom (PDF 2.0) presents the collection using the navigator.
+	ViewCustom View = "C"
+)
+
+// Colors specifies the colours used to present a collection.  All colours
+// are given as RGB values in the range 0 to 1.  Nil entries are left to the
+// PDF processor.
+type Colors struct {
+	Background     *RGB
+	CardBackground *RGB
+	CardBorder     *RGB
+	PrimaryText    *RGB
+	SecondaryText  *RGB
+}
+
+// RGB is a colour in the DeviceRGB colour space.
+type RGB [3]float64
+
+// Sort specifies the order of the files in a collection.
+type Sort struct {
+	// Fields lists the schema fields to sort by, in order of precedence.
+	//
+	// This corresponds to the /S entry in the PDF dictionary.
+	Fields []pdf.Name
+
+	// Ascending specifies the sort direction for each entry of Fields.
+	// If Ascending is shorter than Fields, the remaining fields are sorted
+	// in ascending order.
+	//
+	// This corresponds to the /A entry in the PDF dictionary.
+	Ascending []bool
+}
+
+// Split specifies the splitter bar of a collection.
+type Split struct {
+	// Direction is the orientation of the splitter bar.
+	//
+	// When writing collections, an empty name can be used as a shorthand
+	// for [SplitNone].
+	Direction SplitDirection
+
+	// Position (optional) is the initial position of the splitter bar, as a
+	// percentage of the available window area.
+	Position optional.Float64
+}
+
+// SplitDirection is the orientation of the splitter bar.
+type SplitDirection pdf.Name
+
+// These are the values for [Split.Direction].
+const (
+	SplitHorizontal SplitDirection = "H"
+	SplitVertical   SplitDirection = "V"
+	SplitNone       SplitDirection = "N"
+)
+
+var _ pdf.Embedder = (*Collection)(nil)
+
+// ExtractCollection extracts a collection dictionary from a PDF object.
+func ExtractCollection(c pdf.Cursor, obj pdf.Object, _ bool) (*Collection, error) {
+	dict, err := c.DictTyped(obj, "Collection")
+	if err != nil {
+		return nil, err
+	} else if dict == nil {
+		return nil, pdf.Error("missing collection dictionary")
+	}
+
+	coll := &Collection{}
+
+	schema, err := pdf.Optional(c.DictTyped(dict["Schema"], "CollectionSchema"))
+	if err != nil {
+		return nil, err
+	}
+	for key, val := range schema {
+		if key == "Type" {
+			continue
+		}
+		field, err := pdf.Optional(extractField(c, val))
+		if err != nil {
+			return nil, err
+		} else if field != nil {
+			if coll.Schema == nil {
+				coll.Schema = make(map[pdf.Name]*Field)
+			}
+			coll.Schema[key] = field
+		}
+	}
+
+	if d, err := pdf.Optional(c.String(dict["D"])); err != nil {
+		return nil, err
+	} else {
+		coll.InitialDocument = string(d)
+	}
+
+	if view, err := pdf.Optional(c.Name(dict["View"])); err != nil {
+		return nil, err
+	} else {
+		coll.View = View(view)
+	}
+	if coll.View == "" {
+		coll.View = ViewDetails
+	}
+
+	if dict["Navigator"] != nil {
+		nav, err := pdf.Optional(extractNavigator(c, dict["Navigator"]))
+		if err != nil {
+			return nil, err
+		}
+		coll.Navigator = nav
+	}
+	if coll.View == ViewCustom && coll.Navigator == nil {
+		coll.View = ViewDetails
+	}
+
+	if colors, err := pdf.Optional(c.Dict(dict["Colors"])); err != nil {
+		return nil, err
+	} else if colors != nil {
+		coll.Colors = &Colors{
+			Background:     extractRGB(c, colors["Background"]),
+			CardBackground: extractRGB(c, colors["CardBackground"]),
+			CardBorder:     extractRGB(c, colors["CardBorder"]),
+			PrimaryText:    extractRGB(c, colors["PrimaryText"]),
+			SecondaryText:  extractRGB(c, colors["SecondaryText"]),
+		}
+	}
+
+	if sortDict, err := pdf.Optional(c.DictTyped(dict["Sort"], "CollectionSort")); err != nil {
+		return nil, err
+	} else if sortDict != nil {
+		s, err := extractSort(c, sortDict)
+		if err != nil {
+			return nil, err
+		}
+		coll.Sort = s
+	}
+
+	if dict["Folders"] != nil {
+		root, err := pdf.Optional(ExtractFolder(c, dict["Folders"], false))
+		if err != nil {
+			return nil, err
+		}
+		coll.Folders = root
+	}
+
+	if split, err := pdf.Optional(c.DictTyped(dict["Split"], "CollectionSplit")); err != nil {
+		return nil, err
+	} else if split != nil {
+		s := &Split{Direction: SplitNone}
+		if dir, err := pdf.Optional(c.Name(split["Direction"])); err != nil {
+			return nil, err
+		} else if dir == "H" || dir == "V" {
+			s.Direction = SplitDirection(dir)
+		}
+		if pos, err := pdf.Optional(c.Number(split["Position"])); err != nil {
+			return nil, err
+		} else if split["Position"] != nil && pos >= 0 && pos <= 100 {
+			s.Position.Set(float64(pos))
+		}
+		coll.Split = s
+	}
+
+	return coll, nil
+}
+
+// extractRGB reads an RGB colour array.  Malformed values are ignored.
+func extractRGB(c pdf.Cursor, obj pdf.Object) *RGB {
+	arr, _ := c.Array(obj)
+	if len(arr) != 3 {
+		return nil
+	}
+	var rgb RGB
+	for i, val := range arr {
+		x, err := c.Number(val)
+		if err != nil || x < 0 || x > 1 {
+			return nil
+		}
+		rgb[i] = float64(x)
+	}
+	return &rgb
+}
+
+// extractSort reads a collection sort dictionary.
+func extractSort(c pdf.Cursor, dict pdf.Dict) (*Sort, error) {
+	s := &Sort{}
+
+	obj, err := c.Resolve(dict["S"])
+	if err != nil {
+		return nil, err
+	}
+	switch obj := obj.(type) {
+	case pdf.Name:
+		s.Fields = []pdf.Name{obj}
+	case pdf.Array:
+		for _, elem := range obj {
+			if name, err := pdf.Optional(c.Name(elem)); err != nil {
+				return nil, err
+			} else if name != "" {
+				s.Fields = append(s.Fields, name)
+			}
+		}
+	}
+	if len(s.Fields) == 0 {
+		return nil, pdf.Error("collection sort dictionary without fields")
+	}
+
+	obj, err = c.Resolve(dict["A"])
+	if err != nil {
+		return nil, err
+	}
+	switch obj := obj.(type) {
+	case pdf.Boolean:
+		s.Ascending = []bool{bool(obj)}
+	case pdf.Array:
+		for _, elem := range obj[:min(len(obj), len(s.Fields))] {
+			b, _ := c.Boolean(elem)
+			s.Ascending = append(s.Ascending, bool(b))
+		}
+	}
+
+	return s, nil
+}
+
+// Embed converts the collection dictionary to a PDF object.
+// This implements the [pdf.Embedder] interface.
+func (coll *Collection) Embed(rm *pdf.EmbedHelper) (pdf.Native, error) {
+	if err := pdf.CheckVersion(rm.Out(), "collection dictionary", pdf.V1_7); err != nil {
+		return nil, err
+	}
+
+	dict := pdf.Dict{}
+	if rm.Out().GetOptions().HasAny(pdf.OptDictTypes) {
+		dict["Type"] = pdf.Name("Collection")
+	}
+
+	if len(coll.Schema) > 0 {
+		schema := pdf.Dict{}
+		if rm.Out().GetOptions().HasAny(pdf.OptDictTypes) {
+			schema["Type"] = pdf.Name("CollectionSchema")
+		}
+		for key, field := range coll.Schema {
+			if key == "Type" {
+				return nil, errors.New("collection schema cannot contain 'Type' key")
+			}
+			if field == nil {
+				continue
+			}
+			obj, err := field.embed(rm)
+			if err != nil {
+				return nil, fmt.Errorf("schema field %q: %w", key, err)
+			}
+			schema[key] = obj
+		}
+		dict["Schema"] = schema
+	}
+
+	if coll.InitialDocument != "" {
+		dict["D"] = pdf.String(coll.InitialDocument)
+	}
+
+	switch coll.View {
+	case "", ViewDetails:
+		// default value
+	case ViewTile, ViewHidden:
+		dict["View"] = pdf.Name(coll.View)
+	case ViewCustom:
+		if coll.Navigator == nil {
+			return nil, errors.New("custom collection view requires a navigator")
+		}
+		dict["View"] = pdf.Name(coll.View)
+	default:
+		return nil, fmt.Errorf("invalid collection view %q", coll.View)
+	}
+
+	if coll.Navigator != nil {
+		nav, err := rm.Embed(coll.Navigator)
+		if err != nil {
+			return nil, err
+		}
+		dict["Navigator"] = nav
+	}
+
+	if coll.Colors != nil {
+		colors := pdf.Dict{}
+		for _, entry := range []struct {
+			key pdf.Name
+			rgb *RGB
+		}{
+			{"Background", coll.Colors.Background},
+			{"CardBackground", coll.Colors.CardBackground},
+			{"CardBorder", coll.Colors.CardBorder},
+			{"PrimaryText", coll.Colors.PrimaryText},
+			{"SecondaryText", coll.Colors.SecondaryText},
+		} {
+			if entry.rgb == nil {
+				continue
+			}
+			arr := make(pdf.Array, 3)
+			for i, x := range entry.rgb {
+				if x < 0 || x > 1 {
+					return nil, fmt.Errorf("invalid %s colour %v", entry.key, *entry.rgb)
+				}
+				arr[i] = pdf.Number(x)
+			}
+			colors[entry.key] = arr
+		}
+		if len(colors) > 0 {
+			dict["Colors"] = colors
+		}
+	}
+
+	if coll.Sort != nil {
+		s, err := coll.Sort.embed(rm)
+		if err != nil {
+			return nil, err
+		}
+		dict["Sort"] = s
+	}
+
+	if coll.Folders != nil {
+		root, err := rm.Embed(coll.Folders)
+		if err != nil {
+			return nil, err
+		}
+		dict["Folders"] = root
+	}
+
+	if coll.Split != nil {
+		split := pdf.Dict{}
+		if rm.Out().GetOptions().HasAny(pdf.OptDictTypes) {
+			split["Type"] = pdf.Name("CollectionSplit")
+		}
+		switch coll.Split.Direction {
+		case "", SplitNone:
+			split["Direction"] = pdf.Name(SplitNone)
+		case SplitHorizontal, SplitVertical:
+			split["Direction"] = pdf.Name(coll.Split.Direction)
+		default:
+			return nil, fmt.Errorf("invalid split direction %q", coll.Split.Direction)
+		}
+		if pos, ok := coll.Split.Position.Get(); ok {
+			if pos < 0 || pos > 100 {
+				return nil, fmt.Errorf("invalid split position %g", pos)
+			}
+			split["Position"] = pdf.Number(pos)
+		}
+		dict["Split"] = split
+	}
+
+	return dict, nil
+}
+
+// embed converts the sort dictionary to a PDF object.
+func (s *Sort) embed(rm *pdf.EmbedHelper) (pdf.Dict, error) {
+	if len(s.Fields) == 0 {
+		return nil, errors.New("collection sort dictionary without fields")
+	}
+	if len(s.Ascending) > len(s.Fields) {
+		return nil, errors.New("too many sort directions")
+	}
+
+	dict := pdf.Dict{}
+	if rm.Out().GetOptions().HasAny(pdf.OptDictTypes) {
+		dict["Type"] = pdf.Name("CollectionSort")
+	}
+	if len(s.Fields) == 1 {
+		dict["S"] = s.Fields[0]
+	} else {
+		arr := make(pdf.Array, len(s.Fields))
+		for i, name := range s.Fields {
+			arr[i] = name
+		}
+		dict["S"] = arr
+	}
+	switch {
+	case len(s.Ascending) == 1:
+		dict["A"] = pdf.Boolean(s.Ascending[0])
+	case len(s.Ascending) > 1:
+		arr := make(pdf.Array, len(s.Ascending))
+		for i, b := range s.Ascending {
+			arr[i] = pdf.Boolean(b)
+		}
+		dict["A"] = arr
+	}
+	return dict, nil
+}
@@ -0,0 +1,204 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+	"seehuhn.de/go/pdf/optional"
+)
+
+var collectionTestCases = []struct {
+	name    string
+	version pdf.Version
+	coll    *Collection
+}{
+	{
+		name:    "minimal",
+		version: pdf.V1_7,
+		coll:    &Collection{View: ViewDetails},
+	},
+	{
+		name:    "details_view",
+		version: pdf.V1_7,
+		coll: &Collection{
+			Schema: map[pdf.Name]*Field{
+				"FileName": {Type: FieldFileName, Name: "Name", Order: optional.New(1)},
+				"Desc":     {Type: FieldDescription, Name: "Description", Order: optional.New(2)},
+				"Court":    {Type: FieldString, Name: "Court", Editable: true},
+				"Filed":    {Type: FieldDate, Name: "Date filed", Hidden: true},
+			},
+			InitialDocument: "summary.pdf",
+			View:            ViewDetails,
+			Sort: &Sort{
+				Fields:    []pdf.Name{"Filed", "FileName"},
+				Ascending: []bool{false, true},
+			},
+		},
+	},
+	{
+		name:    "folders",
+		version: pdf.V1_7,
+		coll: &Collection{
+			View: ViewTile,
+			Folders: &Folder{
+				ID:   0,
+				Name: "Case 2026-17",
+				Children: []*Folder{
+					{
+						ID:           1,
+						Name:         "Evidence",
+						Description:  "exhibits submitted by both parties",
+						CreationDate: time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC),
+						Children: []*Folder{
+							{ID: 3, Name: "Photos"},
+						},
+					},
+					{
+						ID:      2,
+						Name:    "Correspondence",
+						ModDate: time.Date(2026, 4, 2, 17, 30, 0, 0, time.UTC),
+					},
+				},
+			},
+		},
+	},
+	{
+		name:    "navigator_and_colors",
+		version: pdf.V1_7,
+		coll: &Collection{
+			View: ViewCustom,
+			Navigator: &Navigator{
+				Layout: []Layout{LayoutTree, LayoutDetails},
+			},
+			Colors: &Colors{
+				Background:  &RGB{1, 1, 1},
+				CardBorder:  &RGB{0.5, 0.5, 0.5},
+				PrimaryText: &RGB{0, 0, 0.25},
+			},
+			Split: &Split{
+				Direction: SplitVertical,
+				Position:  optional.NewFloat64(30),
+			},
+		},
+	},
+	{
+		name:    "pdf_2_0",
+		version: pdf.V2_0,
+		coll: &Collection{
+			Schema: map[pdf.Name]*Field{
+				"Size":   {Type: FieldSize, Name: "Size"},
+				"Packed": {Type: FieldCompressedSize, Name: "Compressed size"},
+			},
+			View:  ViewDetails,
+			Split: &Split{Direction: SplitNone},
+		},
+	},
+}
+
+func TestCollectionRoundTrip(t *testing.T) {
+	for _, tc := range collectionTestCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, _ := memfile.NewPDFWriter(tc.version, nil)
+			rm := pdf.NewResourceManager(w)
+
+			obj, err := rm.Embed(tc.coll)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = rm.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			w.GetMeta().Trailer["Quir:E"] = obj
+			err = w.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			x := pdf.NewExtractor(w)
+			coll, err := pdf.Decode(pdf.CursorAt(x, nil), w.GetMeta().Trailer["Quir:E"], ExtractCollection)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if diff := cmp.Diff(tc.coll, coll); diff != "" {
+				t.Errorf("round trip failed (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestFolderValidation(t *testing.T) {
+	cases := []struct {
+		name string
+		root *Folder
+	}{
+		{
+			name: "duplicate_id",
+			root: &Folder{ID: 0, Name: "root", Children: []*Folder{
+				{ID: 1, Name: "a"},
+				{ID: 1, Name: "b"},
+			}},
+		},
+		{
+			name: "duplicate_name",
+			root: &Folder{ID: 0, Name: "root", Children: []*Folder{
+				{ID: 1, Name: "a"},
+				{ID: 2, Name: "a"},
+			}},
+		},
+		{
+			name: "negative_id",
+			root: &Folder{ID: -1, Name: "root"},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, _ := memfile.NewPDFWriter(pdf.V1_7, nil)
+			rm := pdf.NewResourceManager(w)
+			_, err := rm.Embed(&Collection{Folders: tc.root})
+			if err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestFileKey(t *testing.T) {
+	key := FileKey(17, "report.pdf")
+	if key != "<17>report.pdf" {
+		t.Errorf("unexpected key %q", key)
+	}
+
+	id, name, ok := SplitKey(key)
+	if !ok || id != 17 || name != "report.pdf" {
+		t.Errorf("SplitKey(%q) = %d, %q, %t", key, id, name, ok)
+	}
+
+	for _, key := range []pdf.Name{"report.pdf", "<x>report.pdf", "<12report.pdf"} {
+		_, name, ok := SplitKey(key)
+		if ok || name != string(key) {
+			t.Errorf("SplitKey(%q) = %q, %t", key, name, ok)
+		}
+	}
+}
@@ -0,0 +1,343 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collection
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/image/thumbnail"
+)
+
+// Folder represents a folder dictionary of a portable collection.
+//
+// Files are placed into folders through their keys in the EmbeddedFiles
+// name tree: the key of a file in a folder starts with the folder ID in
+// angle brackets, see [FileKey] and [SplitKey].  Files without such a
+// prefix are in the root folder.
+type Folder struct {
+	// ID is the identifier of the folder.  IDs are non-negative and must
+	// be unique within the collection.
+	ID int
+
+	// Name is the name of the folder, as shown in the user interface.  The
+	// names of the children of a folder must be distinct.
+	Name string
+
+	// Description (optional) is a description of the folder.
+	//
+	// This corresponds to the /Desc entry in the PDF dictionary.
+	Description string
+
+	// CreationDate (optional) is the time the folder was created.
+	CreationDate time.Time
+
+	// ModDate (optional) is the time the folder was last modified.
+	ModDate time.Time
+
+	// Item (optional) holds the data for the collection schema fields.
+	//
+	// This corresponds to the /CI entry in the PDF dictionary.
+	Item *ItemDict
+
+	// Thumbnail (optional) is a thumbnail image for the folder.
+	//
+	// This corresponds to the /Thumb entry in the PDF dictionary.
+	Thumbnail *thumbnail.Thumbnail
+
+	// Children lists the subfolders of the folder.
+	//
+	// This corresponds to the /Child entry in the PDF dictionary, and to
+	// the /Next entries of the children.
+	Children []*Folder
+}
+
+// maxFolderDepth limits the nesting depth of folders when reading a folder
+// hierarchy.
+const maxFolderDepth = 64
+
+var _ pdf.Embedder = (*Folder)(nil)
+
+// ExtractFolder reads a folder hierarchy from a PDF file.  The argument
+// obj must refer to the root folder.  Folders which are reachable in more
+// than one way are only included once.
+func ExtractFolder(c pdf.Cursor, obj pdf.Object, _ bool) (*Folder, error) {
+	seen := map[pdf.Reference]bool{}
+	return extractFolder(c, obj, seen, 0)
+}
+
+func extractFolder(c pdf.Cursor, obj pdf.Object, seen map[pdf.Reference]bool, depth int) (*Folder, error) {
+	if ref, ok := obj.(pdf.Reference); ok {
+		seen[ref] = true
+	}
+
+	dict, err := c.DictTyped(obj, "Folder")
+	if err != nil {
+		return nil, err
+	} else if dict == nil {
+		return nil, pdf.Error("missing folder dictionary")
+	}
+
+	f := &Folder{}
+	id, err := c.Integer(dict["ID"])
+	if err != nil {
+		return nil, err
+	} else if id < 0 || id > math.MaxInt32 {
+		return nil, pdf.Errorf("invalid folder ID %d", id)
+	}
+	f.ID = int(id)
+
+	if name, err := pdf.Optional(c.TextString(dict["Name"])); err != nil {
+		return nil, err
+	} else {
+		f.Name = string(name)
+	}
+	if desc, err := pdf.Optional(c.TextString(dict["Desc"])); err != nil {
+		return nil, err
+	} else {
+		f.Description = string(desc)
+	}
+	if date, err := pdf.Optional(c.Date(dict["CreationDate"])); err != nil {
+		return nil, err
+	} else {
+		f.CreationDate = time.Time(date)
+	}
+	if date, err := pdf.Optional(c.Date(dict["ModDate"])); err != nil {
+		return nil, err
+	} else {
+		f.ModDate = time.Time(date)
+	}
+	if dict["CI"] != nil {
+		item, err := pdf.Optional(pdf.Decode(c, dict["CI"], ExtractItemDict))
+		if err != nil {
+			return nil, err
+		}
+		f.Item = item
+	}
+	if dict["Thumb"] != nil {
+		thumb, err := pdf.Optional(pdf.Decode(c, dict["Thumb"], thumbnail.ExtractThumbnail))
+		if err != nil {
+			return nil, err
+		}
+		f.Thumbnail = thumb
+	}
+
+	if depth >= maxFolderDepth {
+		return f, nil
+	}
+	next := dict["Child"]
+	for next != nil {
+		ref, ok := next.(pdf.Reference)
+		if !ok || seen[ref] {
+			break
+		}
+		child, err := pdf.Optional(extractFolder(c, ref, seen, depth+1))
+		if err != nil {
+			return nil, err
+		}
+		if child != nil {
+			f.Children = append(f.Children, child)
+		}
+
+		childDict, err := pdf.Optional(c.Dict(ref))
+		if err != nil {
+			return nil, err
+		}
+		next = childDict["Next"]
+	}
+
+	return f, nil
+}
+
+// Embed writes the folder hierarchy rooted at f to the PDF file.
+// This implements the [pdf.Embedder] interface.
+func (f *Folder) Embed(rm *pdf.EmbedHelper) (pdf.Native, error) {
+	if err := pdf.CheckVersion(rm.Out(), "collection folders", pdf.V1_7); err != nil {
+		return nil, err
+	}
+
+	// Check the hierarchy and allocate references for all folders.
+	refs := map[*Folder]pdf.Reference{}
+	var ids []int
+	var check func(f *Folder, depth int) error
+	check = func(f *Folder, depth int) error {
+		if refs[f] != 0 {
+			return errors.New("folder appears twice in the hierarchy")
+		}
+		if depth > maxFolderDepth {
+			return errors.New("folders nested too deeply")
+		}
+		if f.ID < 0 || f.ID > math.MaxInt32 {
+			return fmt.Errorf("invalid folder ID %d", f.ID)
+		}
+		if f.Name == "" && depth > 0 {
+			return fmt.Errorf("folder %d has no name", f.ID)
+		}
+		refs[f] = rm.Alloc()
+		ids = append(ids, f.ID)
+
+		names := map[string]bool{}
+		for _, child := range f.Children {
+			if child == nil {
+				return fmt.Errorf("folder %d has a nil child", f.ID)
+			}
+			if names[child.Name] {
+				return fmt.Errorf("duplicate folder name %q", child.Name)
+			}
+			names[child.Name] = true
+			if err := check(child, depth+1); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := check(f, 0); err != nil {
+		return nil, err
+	}
+	slices.Sort(ids)
+	for i := 1; i < len(ids); i++ {
+		if ids[i] == ids[i-1] {
+			return nil, fmt.Errorf("duplicate folder ID %d", ids[i])
+		}
+	}
+
+	var write func(f, parent *Folder, next pdf.Object) error
+	write = func(f, parent *Folder, next pdf.Object) error {
+		dict := pdf.Dict{
+			"ID":   pdf.Integer(f.ID),
+			"Name": pdf.TextString(f.Name),
+		}
+		if rm.Out().GetOptions().HasAny(pdf.OptDictTypes) {
+			dict["Type"] = pdf.Name("Folder")
+		}
+		if parent != nil {
+			dict["Parent"] = refs[parent]
+		} else {
+			dict["Free"] = freeIDs(ids)
+		}
+		if len(f.Children) > 0 {
+			dict["Child"] = refs[f.Children[0]]
+		}
+		if next != nil {
+			dict["Next"] = next
+		}
+		if f.Description != "" {
+			dict["Desc"] = pdf.TextString(f.Description)
+		}
+		if !f.CreationDate.IsZero() {
+			dict["CreationDate"] = pdf.Date(f.CreationDate)
+		}
+		if !f.ModDate.IsZero() {
+			dict["ModDate"] = pdf.Date(f.ModDate)
+		}
+		if f.Item != nil {
+			item, err := rm.Embed(f.Item)
+			if err != nil {
+				return err
+			}
+			dict["CI"] = item
+		}
+		if f.Thumbnail != nil {
+			thumb, err := rm.Embed(f.Thumbnail)
+			if err != nil {
+				return err
+			}
+			dict["Thumb"] = thumb
+		}
+		if err := rm.Out().Put(refs[f], dict); err != nil {
+			return err
+		}
+
+		for i, child := range f.Children {
+			var childNext pdf.Object
+			if i+1 < len(f.Children) {
+				childNext = refs[f.Children[i+1]]
+			}
+			if err := write(child, f, childNext); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := write(f, nil, nil); err != nil {
+		return nil, err
+	}
+
+	return refs[f], nil
+}
+
+// freeIDs returns the ranges of unused folder IDs, as pairs of integers
+// for the /Free entry of the root folder.  The argument must be sorted.
+func freeIDs(used []int) pdf.Array {
+	var res pdf.Array
+	next := 0
+	for _, id := range used {
+		if id > next {
+			res = append(res, pdf.Integer(next), pdf.Integer(id-1))
+		}
+		next = id + 1
+	}
+	if next <= math.MaxInt32 {
+		res = append(res, pdf.Integer(next), pdf.Integer(math.MaxInt32))
+	}
+	return res
+}
+
+// Find returns the folder with the given ID in the hierarchy rooted at f,
+// or nil if there is no such folder.
+func (f *Folder) Find(id int) *Folder {
+	if f.ID == id {
+		return f
+	}
+	for _, child := range f.Children {
+		if res := child.Find(id); res != nil {
+			return res
+		}
+	}
+	return nil
+}
+
+// FileKey returns the key for a file in the EmbeddedFiles name tree, which
+// places the file in the folder with the given ID.
+func FileKey(folderID int, name string) pdf.Name {
+	return pdf.Name("<" + strconv.Itoa(folderID) + ">" + name)
+}
+
+// SplitKey splits a key from the EmbeddedFiles name tree into the folder
+// ID and the file name.  If the key has no folder prefix, ok is false and
+// name equals key.
+func SplitKey(key pdf.Name) (folderID int, name string, ok bool) {
+	s := string(key)
+	if !strings.HasPrefix(s, "<") {
+		return 0, s, false
+	}
+	idStr, rest, found := strings.Cut(s[1:], ">")
+	if !found {
+		return 0, s, false
+	}
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id < 0 {
+		return 0, s, false
+	}
+	return id, rest, true
+}
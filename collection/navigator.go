@@ -0,0 +1,108 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collection
+
+import (
+	"errors"
+	"fmt"
+
+	"seehuhn.de/go/pdf"
+)
+
+// Navigator represents a navigator dictionary.  A navigator lists the
+// layouts which a PDF processor may use to present a collection.
+type Navigator struct {
+	// Layout lists the supported layouts, in order of preference.
+	Layout []Layout
+}
+
+// Layout is a navigator layout.
+type Layout pdf.Name
+
+// These are the layouts defined in PDF 2.0.
+const (
+	LayoutDetails   Layout = "D"
+	LayoutTile      Layout = "T"
+	LayoutHidden    Layout = "H"
+	LayoutFilmStrip Layout = "FilmStrip"
+	LayoutFreeForm  Layout = "FreeForm"
+	LayoutLinear    Layout = "Linear"
+	LayoutTree      Layout = "Tree"
+)
+
+var _ pdf.Embedder = (*Navigator)(nil)
+
+// extractNavigator reads a navigator dictionary.
+func extractNavigator(c pdf.Cursor, obj pdf.Object) (*Navigator, error) {
+	dict, err := c.DictTyped(obj, "Navigator")
+	if err != nil {
+		return nil, err
+	} else if dict == nil {
+		return nil, pdf.Error("missing navigator dictionary")
+	}
+
+	nav := &Navigator{}
+	layout, err := c.Resolve(dict["Layout"])
+	if err != nil {
+		return nil, err
+	}
+	switch layout := layout.(type) {
+	case pdf.Name:
+		nav.Layout = []Layout{Layout(layout)}
+	case pdf.Array:
+		for _, elem := range layout {
+			if name, err := pdf.Optional(c.Name(elem)); err != nil {
+				return nil, err
+			} else if name != "" {
+				nav.Layout = append(nav.Layout, Layout(name))
+			}
+		}
+	}
+	if len(nav.Layout) == 0 {
+		return nil, pdf.Error("navigator without layout")
+	}
+	return nav, nil
+}
+
+// Embed converts the navigator to a PDF dictionary.
+// This implements the [pdf.Embedder] interface.
+func (nav *Navigator) Embed(rm *pdf.EmbedHelper) (pdf.Native, error) {
+	if len(nav.Layout) == 0 {
+		return nil, errors.New("navigator without layout")
+	}
+
+	dict := pdf.Dict{}
+	if rm.Out().GetOptions().HasAny(pdf.OptDictTypes) {
+		dict["Type"] = pdf.Name("Navigator")
+	}
+	arr := make(pdf.Array, len(nav.Layout))
+	for i, l := range nav.Layout {
+		switch l {
+		case LayoutDetails, LayoutTile, LayoutHidden, LayoutFilmStrip,
+			LayoutFreeForm, LayoutLinear, LayoutTree:
+			arr[i] = pdf.Name(l)
+		default:
+			return nil, fmt.Errorf("invalid navigator layout %q", l)
+		}
+	}
+	if len(arr) == 1 {
+		dict["Layout"] = arr[0]
+	} else {
+		dict["Layout"] = arr
+	}
+	return dict, nil
+}
@@ -0,0 +1,167 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package collection
+
+import (
+	"errors"
+	"fmt"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/optional"
+)
+
+// Field represents a collection field dictionary.  Fields describe the
+// columns of the details view of a collection.
+type Field struct {
+	// Type specifies where the field value is taken from.
+	//
+	// This corresponds to the /Subtype entry in the PDF dictionary.
+	Type FieldType
+
+	// Name is the name of the field, as shown in the user interface.
+	//
+	// This corresponds to the /N entry in the PDF dictionary.
+	Name string
+
+	// Order (optional) is the relative order of the field in the user
+	// interface.  Fields are sorted in ascending order.
+	//
+	// This corresponds to the /O entry in the PDF dictionary.
+	Order optional.Value[int]
+
+	// Hidden indicates that the field is initially not shown.
+	//
+	// This corresponds to the /V entry in the PDF dictionary.
+	Hidden bool
+
+	// Editable indicates that a PDF processor may offer to edit the field
+	// value.
+	//
+	// This corresponds to the /E entry in the PDF dictionary.
+	Editable bool
+}
+
+// FieldType specifies the source of the data for a collection field.
+type FieldType pdf.Name
+
+// These are the possible values for [Field.Type].  For [FieldString],
+// [FieldDate] and [FieldNumber], the data is taken from the collection item
+// dictionary of the file.  The remaining types use information from the file
+// specification and the embedded file stream.
+const (
+	FieldString         FieldType = "S"
+	FieldDate           FieldType = "D"
+	FieldNumber         FieldType = "N"
+	FieldFileName       FieldType = "F"
+	FieldDescription    FieldType = "Desc"
+	FieldModDate        FieldType = "ModDate"
+	FieldCreationDate   FieldType = "CreationDate"
+	FieldSize           FieldType = "Size"
+	FieldCompressedSize FieldType = "CompressedSize" // PDF 2.0
+)
+
+func (t FieldType) isValid() bool {
+	switch t {
+	case FieldString, FieldDate, FieldNumber, FieldFileName, FieldDescription,
+		FieldModDate, FieldCreationDate, FieldSize, FieldCompressedSize:
+		return true
+	default:
+		return false
+	}
+}
+
+// extractField reads a collection field dictionary.
+func extractField(c pdf.Cursor, obj pdf.Object) (*Field, error) {
+	dict, err := c.DictTyped(obj, "CollectionField")
+	if err != nil {
+		return nil, err
+	} else if dict == nil {
+		return nil, pdf.Error("missing collection field dictionary")
+	}
+
+	subtype, err := pdf.Optional(c.Name(dict["Subtype"]))
+	if err != nil {
+		return nil, err
+	}
+	field := &Field{Type: FieldType(subtype)}
+	if !field.Type.isValid() {
+		return nil, pdf.Errorf("invalid collection field subtype %q", subtype)
+	}
+
+	if name, err := pdf.Optional(c.TextString(dict["N"])); err != nil {
+		return nil, err
+	} else {
+		field.Name = string(name)
+	}
+
+	if dict["O"] != nil {
+		if order, err := pdf.Optional(c.Integer(dict["O"])); err != nil {
+			return nil, err
+		} else {
+			field.Order.Set(int(order))
+		}
+	}
+
+	if dict["V"] != nil {
+		if visible, err := pdf.Optional(c.Boolean(dict["V"])); err != nil {
+			return nil, err
+		} else {
+			field.Hidden = !bool(visible)
+		}
+	}
+
+	if editable, err := pdf.Optional(c.Boolean(dict["E"])); err != nil {
+		return nil, err
+	} else {
+		field.Editable = bool(editable)
+	}
+
+	return field, nil
+}
+
+// embed converts the field to a PDF dictionary.
+func (f *Field) embed(rm *pdf.EmbedHelper) (pdf.Dict, error) {
+	if !f.Type.isValid() {
+		return nil, fmt.Errorf("invalid field type %q", f.Type)
+	}
+	if f.Type == FieldCompressedSize {
+		if err := pdf.CheckVersion(rm.Out(), "CompressedSize collection field", pdf.V2_0); err != nil {
+			return nil, err
+		}
+	}
+	if f.Name == "" {
+		return nil, errors.New("missing field name")
+	}
+
+	dict := pdf.Dict{
+		"Subtype": pdf.Name(f.Type),
+		"N":       pdf.TextString(f.Name),
+	}
+	if rm.Out().GetOptions().HasAny(pdf.OptDictTypes) {
+		dict["Type"] = pdf.Name("CollectionField")
+	}
+	if order, ok := f.Order.Get(); ok {
+		dict["O"] = pdf.Integer(order)
+	}
+	if f.Hidden {
+		dict["V"] = pdf.Boolean(false)
+	}
+	if f.Editable {
+		dict["E"] = pdf.Boolean(true)
+	}
+	return dict, nil
+}
@@ -0,0 +1,228 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package colorconv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"seehuhn.de/go/icc"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/internal/recolor"
+	"seehuhn.de/go/pdf/internal/rewrite"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// Target selects the colour space colours are converted into.
+type Target int
+
+const (
+	// Gray converts all colours to DeviceGray.
+	Gray Target = iota
+
+	// SRGB converts all colours to DeviceRGB, using sRGB values.
+	SRGB
+
+	// CMYK converts all colours to DeviceCMYK.
+	CMYK
+)
+
+func (t Target) String() string {
+	switch t {
+	case Gray:
+		return "gray"
+	case SRGB:
+		return "sRGB"
+	case CMYK:
+		return "CMYK"
+	}
+	return fmt.Sprintf("Target(%d)", int(t))
+}
+
+// maxProfileSize bounds the size of an output intent ICC profile.
+const maxProfileSize = 16 << 20
+
+// Options control the colour conversion.
+type Options struct {
+	// Target is the colour space colours are converted into.
+	Target Target
+
+	// Profile (optional) is the ICC profile used for CMYK output.  This must
+	// be a CMYK output profile.  If this is nil, the destination profile of
+	// the document's output intent is used if it is a CMYK profile, and a
+	// built-in profile otherwise.  Profile is ignored for other targets.
+	Profile *icc.Profile
+
+	// PreserveBlack maps pure black to pure black of the target space.  For
+	// CMYK output, this paints black text and lines with black ink only,
+	// instead of a mix of all four inks.
+	PreserveBlack bool
+
+	// KeepSpots leaves Separation and DeviceN colours with spot colorants
+	// unchanged.
+	KeepSpots bool
+}
+
+// catalogKeys lists the catalog entries copied to the output.  The pages
+// are converted one-to-one, so that references to the pages, for example in
+// outlines and destinations, remain valid.
+var catalogKeys = []pdf.Name{
+	"PageLabels", "Names", "Dests", "ViewerPreferences", "PageLayout",
+	"PageMode", "Outlines", "Threads", "OpenAction", "AcroForm",
+	"StructTreeRoot", "MarkInfo", "Lang", "OutputIntents",
+}
+
+// Write reads the document from r, converts all colours as described by
+// opts, and writes the result to w.  A nil *Options converts to gray.
+func Write(w io.Writer, r pdf.Getter, opts *Options) error {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+
+	m := &mapper{
+		preserveBlack: o.PreserveBlack,
+		keepSpots:     o.KeepSpots,
+	}
+	switch o.Target {
+	case Gray:
+		m.target = color.SpaceDeviceGray
+	case SRGB:
+		m.target = color.SpaceDeviceRGB
+	case CMYK:
+		m.target = color.SpaceDeviceCMYK
+		profile := o.Profile
+		if profile == nil {
+			profile = outputIntentProfile(r)
+		}
+		if profile != nil {
+			t, err := cmykTransform(profile)
+			if err != nil {
+				return err
+			}
+			m.profile = t
+		}
+	default:
+		return fmt.Errorf("invalid colour conversion target %d", int(o.Target))
+	}
+
+	refs, dicts, err := rewrite.ReadPages(r)
+	if err != nil {
+		return err
+	}
+
+	// soft masks for converted colour key masks need PDF 1.4
+	version := max(pdf.GetVersion(r), pdf.V1_4)
+	out, err := rewrite.NewWriter(w, r, version)
+	if err != nil {
+		return err
+	}
+	rm := pdf.NewResourceManager(out)
+
+	c := &converter{
+		x:    pdf.NewExtractor(r),
+		out:  out,
+		copy: pdf.NewCopier(out, r),
+		m:    m,
+	}
+	c.rw = recolor.New(c.x, rm, c.copy, m)
+
+	newRefs := rewrite.RedirectPages(out, c.copy, refs)
+
+	// Annotations are referenced from elsewhere in the document, for
+	// example from interactive form fields.  Allocate the new references
+	// first, so that copying these references picks up the converted
+	// annotations.
+	annots := make([][]annotRef, len(dicts))
+	for i, dict := range dicts {
+		annots[i], err = c.allocAnnotations(dict["Annots"])
+		if err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+	}
+
+	tree := pagetree.NewWriter(out, rm)
+	for i, dict := range dicts {
+		newDict, err := c.convertPage(dict, annots[i])
+		if err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+		if err := tree.AppendPageDict(newRefs[i], newDict); err != nil {
+			return err
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		return err
+	}
+
+	metaIn := r.GetMeta()
+	meta := out.GetMeta()
+	meta.Info = metaIn.Info
+	if err := rewrite.CopyCatalog(out, c.copy, metaIn.Catalog); err != nil {
+		return err
+	}
+	meta.Catalog.Pages = pagesRef
+
+	if err := rm.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// outputIntentProfile returns the destination profile of the first output
+// intent of the document which has a CMYK profile, or nil if there is none.
+func outputIntentProfile(r pdf.Getter) *icc.Profile {
+	cur := pdf.NewCursor(r)
+	intents, _ := cur.Array(r.GetMeta().Catalog.OutputIntents)
+	for _, obj := range intents {
+		intent, _ := cur.Dict(obj)
+		stm, _ := cur.Stream(intent["DestOutputProfile"])
+		if stm == nil {
+			continue
+		}
+		data, err := pdf.ReadAll(r, nil, stm, maxProfileSize)
+		if err != nil {
+			continue
+		}
+		profile, err := icc.Decode(data)
+		if err != nil || profile.ColorSpace != icc.CMYKSpace {
+			continue
+		}
+		return profile
+	}
+	return nil
+}
+
+// cmykTransform returns the transformation from XYZ values to the device
+// values of a CMYK output profile.
+func cmykTransform(profile *icc.Profile) (*icc.Transform, error) {
+	if profile.ColorSpace != icc.CMYKSpace {
+		return nil, errors.New("not a CMYK profile")
+	}
+	t, err := icc.NewTransform(profile, icc.Perceptual)
+	if err != nil {
+		return nil, err
+	}
+	if !t.CanFromXYZ() {
+		return nil, errors.New("CMYK profile cannot be used for output")
+	}
+	return t, nil
+}
@@ -0,0 +1,303 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package colorconv
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"seehuhn.de/go/icc"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/function"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+	"seehuhn.de/go/pdf/internal/rewrite/rewritetest"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// makeSource writes a one-page document which paints in red, black and the
+// spot colour Gold, and which has a square annotation with a blue
+// appearance.  If withIntent is set, the document has an output intent with
+// the built-in CMYK profile.
+func makeSource(t *testing.T, withIntent bool) *pdf.Reader {
+	t.Helper()
+
+	w, buf := memfile.NewPDFWriter(pdf.V1_7, nil)
+	rm := pdf.NewResourceManager(w)
+
+	gold, err := color.Separation("Gold", color.SpaceDeviceCMYK, &function.Type2{
+		XMin: 0,
+		XMax: 1,
+		C0:   []float64{0, 0, 0, 0},
+		C1:   []float64{0, 0.2, 0.8, 0.1},
+		N:    1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	goldObj, err := rm.Embed(gold)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeStream := func(dict pdf.Dict, body string) pdf.Reference {
+		ref := w.Alloc()
+		stm, err := w.OpenStream(ref, dict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stm.Write([]byte(body)); err != nil {
+			t.Fatal(err)
+		}
+		if err := stm.Close(); err != nil {
+			t.Fatal(err)
+		}
+		return ref
+	}
+
+	contentRef := writeStream(nil,
+		"1 0 0 rg 0 0 10 10 re f\n0 0 0 rg 10 0 10 10 re f\n/CS0 cs 1 sc 20 0 10 10 re f\n")
+	apRef := writeStream(pdf.Dict{
+		"Type":    pdf.Name("XObject"),
+		"Subtype": pdf.Name("Form"),
+		"BBox":    &pdf.Rectangle{URx: 10, URy: 10},
+	}, "0 0 1 RG 0 0 10 10 re S\n")
+
+	pageRef := w.Alloc()
+	annotRef := w.Alloc()
+	err = w.Put(annotRef, pdf.Dict{
+		"Type":    pdf.Name("Annot"),
+		"Subtype": pdf.Name("Square"),
+		"Rect":    &pdf.Rectangle{URx: 10, URy: 10},
+		"C":       pdf.Array{pdf.Integer(0), pdf.Integer(0), pdf.Integer(1)},
+		"P":       pageRef,
+		"AP":      pdf.Dict{"N": apRef},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tree := pagetree.NewWriter(w, rm)
+	err = tree.AppendPageDict(pageRef, pdf.Dict{
+		"Type":     pdf.Name("Page"),
+		"MediaBox": &pdf.Rectangle{URx: 100, URy: 100},
+		"Resources": pdf.Dict{
+			"ColorSpace": pdf.Dict{"CS0": goldObj},
+		},
+		"Contents": contentRef,
+		"Annots":   pdf.Array{annotRef},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := tree.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.GetMeta().Catalog.Pages = ref
+
+	if withIntent {
+		profileRef := writeStream(pdf.Dict{"N": pdf.Integer(4)}, string(icc.CMYKProfile))
+		w.GetMeta().Catalog.OutputIntents = pdf.Array{
+			pdf.Dict{
+				"Type":                      pdf.Name("OutputIntent"),
+				"S":                         pdf.Name("GTS_PDFX"),
+				"OutputConditionIdentifier": pdf.String("Custom"),
+				"DestOutputProfile":         profileRef,
+			},
+		}
+	}
+
+	if err := rm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(bytes.NewReader(buf.Data), int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// convert runs Write and returns the reader for the result, together with
+// the first page dictionary.
+func convert(t *testing.T, r *pdf.Reader, opts *Options) (*pdf.Reader, pdf.Dict) {
+	t.Helper()
+
+	var out bytes.Buffer
+	if err := Write(&out, r, opts); err != nil {
+		t.Fatal(err)
+	}
+	rr, err := pdf.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dict := range pagetree.NewIterator(rr).All() {
+		return rr, dict
+	}
+	t.Fatal("no pages")
+	return nil, nil
+}
+
+func TestGray(t *testing.T) {
+	r := makeSource(t, false)
+	rr, page := convert(t, r, &Options{Target: Gray})
+
+	ops := colorOps(t, rr, page["Contents"])
+	for _, op := range ops {
+		if op != "g" && op != "G" {
+			t.Errorf("unexpected colour operator %q", op)
+		}
+	}
+
+	cur := pdf.NewCursor(rr)
+	annots, err := cur.Array(page["Annots"])
+	if err != nil || len(annots) != 1 {
+		t.Fatalf("got annotations %v, %v", annots, err)
+	}
+	annot, err := cur.Dict(annots[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c, _ := cur.Array(annot["C"]); len(c) != 1 {
+		t.Errorf("annotation colour %v, want a gray value", c)
+	}
+	if p, _ := annot["P"].(pdf.Reference); p == 0 {
+		t.Error("annotation lost its page reference")
+	}
+	ap, _ := cur.Dict(annot["AP"])
+	if ops := colorOps(t, rr, ap["N"]); !slices.Equal(ops, []content.OpName{"G"}) {
+		t.Errorf("appearance uses colour operators %q, want [G]", ops)
+	}
+}
+
+func TestCMYK(t *testing.T) {
+	r := makeSource(t, true)
+	rr, page := convert(t, r, &Options{
+		Target:        CMYK,
+		PreserveBlack: true,
+		KeepSpots:     true,
+	})
+
+	ops, args := colorOpsArgs(t, rr, page["Contents"])
+	// the page starts by setting the converted initial colours
+	want := []content.OpName{"k", "K", "k", "k", "cs", "sc"}
+	if !slices.Equal(ops, want) {
+		t.Fatalf("got colour operators %q, want %q", ops, want)
+	}
+	if black := args[3]; black != "0 0 0 1" {
+		t.Errorf("black converted to %q, want pure black ink", black)
+	}
+	if spot := args[5]; spot != "1" {
+		t.Errorf("spot colour tint %q, want 1", spot)
+	}
+
+	meta := rr.GetMeta()
+	if meta.Catalog.OutputIntents == nil {
+		t.Error("output intents not copied")
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	r := rewritetest.Source(t)
+	res := rewritetest.Rewrite(t, r, func(w io.Writer, r pdf.Getter) error {
+		return Write(w, r, &Options{Target: Gray})
+	})
+	rewritetest.CheckCatalog(t, res)
+}
+
+func TestOutputIntentProfile(t *testing.T) {
+	if p := outputIntentProfile(makeSource(t, false)); p != nil {
+		t.Error("found a profile in a document without output intents")
+	}
+	p := outputIntentProfile(makeSource(t, true))
+	if p == nil {
+		t.Fatal("output intent profile not found")
+	}
+	if _, err := cmykTransform(p); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIsBlack(t *testing.T) {
+	for _, test := range []struct {
+		space  color.Space
+		values []float64
+		want   bool
+	}{
+		{color.SpaceDeviceGray, []float64{0}, true},
+		{color.SpaceDeviceGray, []float64{0.1}, false},
+		{color.SpaceDeviceRGB, []float64{0, 0, 0}, true},
+		{color.SpaceDeviceCMYK, []float64{0, 0, 0, 1}, true},
+		{color.SpaceDeviceCMYK, []float64{0.5, 0.5, 0.5, 1}, false},
+	} {
+		if got := isBlack(test.space, test.values); got != test.want {
+			t.Errorf("isBlack(%s, %v) = %t, want %t",
+				test.space.Family(), test.values, got, test.want)
+		}
+	}
+}
+
+// colorOps returns the colour operators of a content stream.
+func colorOps(t *testing.T, r *pdf.Reader, obj pdf.Object) []content.OpName {
+	t.Helper()
+	ops, _ := colorOpsArgs(t, r, obj)
+	return ops
+}
+
+// colorOpsArgs returns the colour operators of a content stream, together
+// with their formatted operands.
+func colorOpsArgs(t *testing.T, r *pdf.Reader, obj pdf.Object) ([]content.OpName, []string) {
+	t.Helper()
+	stm, err := pdf.NewCursor(r).Stream(obj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := pdf.ReadAll(r, nil, stm, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	open := func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(data)), nil }
+	it := content.NewScanner(open).NewIter()
+	var ops []content.OpName
+	var args []string
+	for name, a := range it.All() {
+		switch name {
+		case content.OpSetFillGray, content.OpSetStrokeGray,
+			content.OpSetFillRGB, content.OpSetStrokeRGB,
+			content.OpSetFillCMYK, content.OpSetStrokeCMYK,
+			content.OpSetFillColorSpace, content.OpSetStrokeColorSpace,
+			content.OpSetFillColor, content.OpSetStrokeColor,
+			content.OpSetFillColorN, content.OpSetStrokeColorN:
+			var buf bytes.Buffer
+			_ = content.Operator{Name: name, Args: a}.Format(&buf)
+			s := strings.TrimSpace(buf.String())
+			ops = append(ops, name)
+			args = append(args, strings.TrimSpace(strings.TrimSuffix(s, string(name))))
+		}
+	}
+	return ops, args
+}
@@ -0,0 +1,41 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package colorconv converts all colours of a PDF document to a single
+// colour space.
+//
+// [Write] copies a document and converts every colour on the way: colours
+// set in content streams, images (including inline images), shadings,
+// coloured tiling patterns, shading patterns, form XObjects, the glyphs of
+// Type 3 fonts, and the appearance streams of annotations.  The target is
+// DeviceGray, DeviceRGB with sRGB values, or DeviceCMYK.
+//
+// Colours are converted using their CIE XYZ values, as computed by the
+// colour spaces in [seehuhn.de/go/pdf/graphics/color].  This covers
+// DeviceRGB, CalRGB, Lab, ICC-based and Indexed colour spaces, and
+// Separation and DeviceN colour spaces via their alternate space and tint
+// transform.  For CMYK output, XYZ values are converted to CMYK using an ICC
+// profile: the one given in the options, the CMYK destination profile of
+// the document's output intent, or a built-in profile for US web offset
+// printing, in this order.
+//
+// Colours already in the target space are left unchanged.  Optionally, pure
+// black is mapped to pure black of the target space (for CMYK, black ink
+// only), which keeps black text sharp, and spot colours are left intact.
+//
+// Soft masks, which describe opacity rather than colour, and JPEG 2000
+// images are copied unchanged.
+package colorconv
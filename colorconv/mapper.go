@@ -0,0 +1,123 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package colorconv
+
+import (
+	"seehuhn.de/go/icc"
+
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/internal/colorants"
+)
+
+// mapper converts colours to the target colour space.  It implements
+// [recolor.Mapper].
+type mapper struct {
+	target color.Space
+
+	// profile converts XYZ values to CMYK.  If this is nil, the built-in
+	// CMYK profile of the color package is used.
+	profile *icc.Transform
+
+	preserveBlack bool
+	keepSpots     bool
+
+	ws icc.Workspace
+}
+
+func (m *mapper) Target() color.Space {
+	return m.target
+}
+
+// Keep reports whether colours in the given colour space are left
+// unchanged.  These are the colours already in the target space and, if
+// requested, spot colours.
+func (m *mapper) Keep(space color.Space) bool {
+	if space.Family() == m.target.Family() {
+		return true
+	}
+	if !m.keepSpots {
+		return false
+	}
+	switch s := space.(type) {
+	case *color.SpaceSeparation:
+		return colorants.IsSpot(s.Colorant)
+	case *color.SpaceDeviceN:
+		for _, name := range s.Colorants {
+			if colorants.IsSpot(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m *mapper) Convert(space color.Space, values []float64, dst []float64) {
+	if m.preserveBlack && isBlack(space, values) {
+		clear(dst)
+		if m.target.Family() == color.FamilyDeviceCMYK {
+			dst[3] = 1
+		}
+		return
+	}
+
+	// gray values go on the black plate only
+	if m.target.Family() == color.FamilyDeviceCMYK && space.Family() == color.FamilyDeviceGray {
+		dst[0], dst[1], dst[2] = 0, 0, 0
+		dst[3] = 1 - values[0]
+		return
+	}
+
+	X, Y, Z := space.ToXYZ(values, &m.ws)
+	switch m.target.Family() {
+	case color.FamilyDeviceGray:
+		color.SpaceDeviceGray.FromXYZ(X, Y, Z, dst, &m.ws)
+	case color.FamilyDeviceRGB:
+		color.SpaceDeviceRGB.FromXYZ(X, Y, Z, dst, &m.ws)
+	default:
+		if m.profile != nil {
+			m.profile.FromXYZ(X, Y, Z, dst, &m.ws)
+		} else {
+			color.SpaceDeviceCMYK.FromXYZ(X, Y, Z, dst, &m.ws)
+		}
+	}
+}
+
+// isBlack reports whether a colour is pure black in a device colour space,
+// or in an ICC-based colour space with the same number of components.
+func isBlack(space color.Space, values []float64) bool {
+	family := space.Family()
+	if family == color.FamilyICCBased {
+		switch space.Channels() {
+		case 1:
+			family = color.FamilyDeviceGray
+		case 3:
+			family = color.FamilyDeviceRGB
+		case 4:
+			family = color.FamilyDeviceCMYK
+		}
+	}
+
+	switch family {
+	case color.FamilyDeviceGray:
+		return values[0] == 0
+	case color.FamilyDeviceRGB:
+		return values[0] == 0 && values[1] == 0 && values[2] == 0
+	case color.FamilyDeviceCMYK:
+		return values[0] == 0 && values[1] == 0 && values[2] == 0 && values[3] == 1
+	}
+	return false
+}
@@ -0,0 +1,269 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package colorconv
+
+import (
+	"maps"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/internal/recolor"
+)
+
+type converter struct {
+	x    *pdf.Extractor
+	out  *pdf.Writer
+	copy *pdf.Copier
+	rw   *recolor.Rewriter
+	m    *mapper
+}
+
+// annotRef describes an annotation of a source page, together with the
+// reference of its converted copy.  For direct annotation dictionaries, ref
+// is zero.
+type annotRef struct {
+	src pdf.Object
+	ref pdf.Reference
+}
+
+// convertedKeys lists the page dictionary entries which are converted,
+// rather than copied.  Thumbnails show the unconverted page and are
+// dropped.
+var convertedKeys = []pdf.Name{"Contents", "Resources", "Group", "Annots", "Thumb"}
+
+// convertPage converts the content of a page and returns the new page
+// dictionary.
+func (c *converter) convertPage(src pdf.Dict, annots []annotRef) (pdf.Dict, error) {
+	contents, res, err := c.rw.Page(src["Contents"], src["Resources"])
+	if err != nil {
+		return nil, err
+	}
+	group, err := c.rw.Group(src["Group"])
+	if err != nil {
+		return nil, err
+	}
+
+	rest := maps.Clone(src)
+	for _, key := range convertedKeys {
+		delete(rest, key)
+	}
+	dict, err := c.copy.CopyDict(rest)
+	if err != nil {
+		return nil, err
+	}
+	dict["Contents"] = contents
+	if res != nil {
+		dict["Resources"] = res
+	} else {
+		dict["Resources"] = pdf.Dict{}
+	}
+	if group != nil {
+		dict["Group"] = group
+	}
+
+	if len(annots) > 0 {
+		arr := make(pdf.Array, 0, len(annots))
+		for _, a := range annots {
+			obj, err := c.convertAnnotation(a)
+			if err != nil {
+				return nil, err
+			}
+			if obj != nil {
+				arr = append(arr, obj)
+			}
+		}
+		dict["Annots"] = arr
+	}
+	return dict, nil
+}
+
+// allocAnnotations allocates references for the converted copies of the
+// annotations of a page.
+func (c *converter) allocAnnotations(obj pdf.Object) ([]annotRef, error) {
+	annots, err := pdf.CursorAt(c.x, nil).Array(obj)
+	if err != nil {
+		if pdf.IsReadError(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	res := make([]annotRef, 0, len(annots))
+	for _, a := range annots {
+		entry := annotRef{src: a}
+		if ref, isRef := a.(pdf.Reference); isRef {
+			entry.ref = c.out.Alloc()
+			c.copy.Redirect(ref, entry.ref)
+		}
+		res = append(res, entry)
+	}
+	return res, nil
+}
+
+// colorKeys lists the annotation dictionary entries which hold colours, as
+// arrays of 0, 1, 3 or 4 components.
+var colorKeys = []pdf.Name{"C", "IC"}
+
+// convertAnnotation writes the converted copy of an annotation.  The
+// appearance streams and the colour entries are converted; everything else
+// is copied.
+func (c *converter) convertAnnotation(a annotRef) (pdf.Object, error) {
+	cur := pdf.CursorAt(c.x, nil)
+	src, err := cur.Dict(a.src)
+	if err != nil || src == nil {
+		if pdf.IsReadError(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	rest := maps.Clone(src)
+	delete(rest, "AP")
+	delete(rest, "MK")
+	for _, key := range colorKeys {
+		delete(rest, key)
+	}
+	dict, err := c.copy.CopyDict(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	if ap, err := cur.Dict(src["AP"]); err == nil && ap != nil {
+		newAP := pdf.Dict{}
+		for _, key := range ap.SortedKeys() {
+			conv, err := c.appearance(ap[key])
+			if err != nil {
+				return nil, err
+			}
+			if conv != nil {
+				newAP[key] = conv
+			}
+		}
+		dict["AP"] = newAP
+	} else if pdf.IsReadError(err) {
+		return nil, err
+	}
+
+	for _, key := range colorKeys {
+		if conv := c.colorArray(src[key]); conv != nil {
+			dict[key] = conv
+		}
+	}
+
+	// the appearance characteristics of widgets hold border and background
+	// colours
+	if mk, err := cur.Dict(src["MK"]); err == nil && mk != nil {
+		rest := maps.Clone(mk)
+		delete(rest, "BC")
+		delete(rest, "BG")
+		newMK, err := c.copy.CopyDict(rest)
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range []pdf.Name{"BC", "BG"} {
+			if conv := c.colorArray(mk[key]); conv != nil {
+				newMK[key] = conv
+			}
+		}
+		dict["MK"] = newMK
+	} else if pdf.IsReadError(err) {
+		return nil, err
+	}
+
+	if a.ref == 0 {
+		return dict, nil
+	}
+	if err := c.out.Put(a.ref, dict); err != nil {
+		return nil, err
+	}
+	return a.ref, nil
+}
+
+// appearance converts one entry of an appearance dictionary.  This is either
+// a single appearance stream, or a dictionary which maps appearance states
+// to appearance streams.
+func (c *converter) appearance(obj pdf.Object) (pdf.Object, error) {
+	cur := pdf.CursorAt(c.x, nil)
+	resolved, err := cur.Resolve(obj)
+	if err != nil {
+		if pdf.IsReadError(err) {
+			return nil, err
+		}
+		return nil, nil
+	}
+	switch v := resolved.(type) {
+	case *pdf.Stream:
+		return c.rw.Form(obj)
+	case pdf.Dict:
+		res := pdf.Dict{}
+		for _, state := range v.SortedKeys() {
+			conv, err := c.rw.Form(v[state])
+			if err != nil {
+				return nil, err
+			}
+			if conv != nil {
+				res[state] = conv
+			}
+		}
+		return res, nil
+	}
+	return nil, nil
+}
+
+// colorArray converts a colour given as an array of 0, 1, 3 or 4 numbers,
+// which select DeviceGray, DeviceRGB or DeviceCMYK.  An empty array, which
+// means transparent, is kept.  The result is nil if obj is not a valid
+// colour array.
+func (c *converter) colorArray(obj pdf.Object) pdf.Object {
+	cur := pdf.CursorAt(c.x, nil)
+	a, err := cur.Array(obj)
+	if err != nil || a == nil {
+		return nil
+	}
+	values := make([]float64, len(a))
+	for i, v := range a {
+		x, err := cur.Number(v)
+		if err != nil {
+			return nil
+		}
+		values[i] = x
+	}
+
+	var space color.Space
+	switch len(values) {
+	case 0:
+		return pdf.Array{}
+	case 1:
+		space = color.SpaceDeviceGray
+	case 3:
+		space = color.SpaceDeviceRGB
+	case 4:
+		space = color.SpaceDeviceCMYK
+	default:
+		return nil
+	}
+
+	if !c.m.Keep(space) {
+		dst := make([]float64, c.m.target.Channels())
+		c.m.Convert(space, values, dst)
+		values = dst
+	}
+	res := make(pdf.Array, len(values))
+	for i, v := range values {
+		res[i] = pdf.Number(pdf.Round(max(0, min(1, v)), 4))
+	}
+	return res
+}
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"slices"
 
 	"seehuhn.de/go/membudget"
 	"seehuhn.de/go/pdf/internal/limits"
@@ -89,12 +90,12 @@ func getIntegerNoObjStm(r Getter, obj Object) (Integer, error) {
 //
 // Each call creates a fresh reader, so streams can be read multiple times.
 //
-// Streams whose filter chain begins with an explicit /Crypt entry naming a
-// CF other than /Identity (e.g. /StdCF, or a custom /CF entry) cannot be
-// decrypted yet: the function returns an error rather than silently
-// emitting ciphertext.  Encoding/decoding such streams is Phase 2 work.
+// If the filter chain begins with an explicit /Crypt entry, the data is
+// decrypted using the crypt filter named there.  The Crypt entry remains in
+// the stream dictionary, so callers which decode the remaining filters must
+// skip it.
 func RawStreamReader(r Getter, x *Stream) (io.ReadCloser, error) {
-	recipe, err := streamCryptRecipe(r, x)
+	recipe, cf, err := streamCryptRecipe(r, x)
 	if err != nil {
 		return nil, err
 	}
@@ -102,13 +103,8 @@ func RawStreamReader(r Getter, x *Stream) (io.ReadCloser, error) {
 	switch recipe {
 	case cryptNone, cryptIdentity:
 		return out, nil
-	case cryptDefault:
-		v := GetVersion(r)
-		budget := membudget.New(limits.StreamBudget(x.length))
-		return x.crypt.Decode(v, out, budget)
-	case cryptUnsupportedCF:
-		return nil, errors.New(
-			"RawStreamReader: stream uses non-Identity /Crypt filter; not yet supported")
+	case cryptDefault, cryptExplicit:
+		return x.crypt.decodeWith(cf, out)
 	}
 	panic("unreachable")
 }
@@ -125,49 +121,60 @@ const (
 	cryptNone cryptRecipe = iota
 
 	// cryptDefault: the document is encrypted and the stream uses the
-	// default StmF crypt filter with per-object Algorithm 1 key
-	// derivation.  Post-decryption bytes require [filterCrypt.Decode].
+	// default crypt filter (StmF, or EFF for embedded file streams) with
+	// per-object Algorithm 1 key derivation.  Post-decryption bytes
+	// require [filterCrypt] decryption.
 	cryptDefault
 
-	// cryptIdentity: the document is encrypted but the stream's filter
-	// chain declares /Crypt /Identity at position 0, overriding the
-	// default StmF.  On-disk bytes are already plaintext.
+	// cryptIdentity: the document is encrypted but the stream is stored
+	// in plain text, either because the stream's filter chain declares
+	// /Crypt /Identity at position 0, or because the default crypt filter
+	// for the stream is /Identity.  On-disk bytes are already plaintext.
 	cryptIdentity
 
-	// cryptUnsupportedCF: the stream's filter chain declares /Crypt
-	// /StdCF or a named CF at position 0.  The bytes are encrypted
-	// with that CF's recipe (no per-object Algorithm 1), which the
-	// library does not yet implement.
-	cryptUnsupportedCF
+	// cryptExplicit: the stream's filter chain declares /Crypt /StdCF or
+	// a named CF at position 0.  The bytes are encrypted with that CF,
+	// using per-object Algorithm 1 key derivation.
+	cryptExplicit
 )
 
-// streamCryptRecipe classifies how the encryption layer applies to x.
-// It is shared between [RawStreamReader] and [Copier] so that both
-// paths agree on which streams require decryption and which the
-// library cannot yet handle.
-func streamCryptRecipe(r Getter, x *Stream) (cryptRecipe, error) {
+// streamCryptRecipe classifies how the encryption layer applies to x, and
+// returns the crypt filter needed to decrypt the stream data.
+// It is shared between [RawStreamReader], [DecodeStream] and [Copier] so
+// that all paths agree on which streams require decryption.
+func streamCryptRecipe(r Getter, x *Stream) (cryptRecipe, *cryptFilter, error) {
 	if x.crypt == nil {
-		return cryptNone, nil
+		return cryptNone, nil, nil
 	}
 	// cheap probe: most streams have no /Crypt at filter position 0
 	startsWithCrypt, err := filterChainStartsWithCrypt(r, x.Dict["Filter"])
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
-	if !startsWithCrypt {
-		return cryptDefault, nil
+	var explicit CryptFilter
+	if startsWithCrypt {
+		filters, err := GetFilters(r, nil, x.Dict)
+		if err != nil {
+			return 0, nil, err
+		}
+		explicit = filters[0].(CryptFilter)
 	}
-	filters, err := GetFilters(r, nil, x.Dict)
+	tp, err := Resolve(r, x.Dict["Type"])
 	if err != nil {
-		return 0, err
+		return 0, nil, err
 	}
-	switch filters[0].(type) {
-	case FilterCryptIdentity:
-		return cryptIdentity, nil
-	case CryptFilter:
-		return cryptUnsupportedCF, nil
+	cf, err := x.crypt.enc.streamFilter(explicit, tp == Name("EmbeddedFile"))
+	if err != nil {
+		return 0, nil, &MalformedFileError{Err: err}
+	}
+	switch {
+	case cf == nil:
+		return cryptIdentity, nil, nil
+	case explicit != nil:
+		return cryptExplicit, cf, nil
+	default:
+		return cryptDefault, cf, nil
 	}
-	return cryptDefault, nil
 }
 
 // filterChainStartsWithCrypt reports whether the resolved /Filter entry
@@ -199,6 +206,42 @@ func filterChainStartsWithCrypt(r Getter, filter Object) (bool, error) {
 	return false, nil
 }
 
+// DropCryptFilter removes a leading Crypt filter from the /Filter and
+// /DecodeParms entries of the stream dictionary dict.  This is used after
+// the stream data has been decrypted.  Indirect /Filter and /DecodeParms
+// entries are resolved through r; the modified entries are stored as
+// direct objects in dict.  If the filter chain does not start with
+// Crypt, dict is left unchanged.
+func DropCryptFilter(r Getter, dict Dict) error {
+	isCrypt, err := filterChainStartsWithCrypt(r, dict["Filter"])
+	if err != nil || !isCrypt {
+		return err
+	}
+
+	filter, err := Resolve(r, dict["Filter"])
+	if err != nil {
+		return err
+	}
+	filters, ok := filter.(Array)
+	if !ok || len(filters) <= 1 {
+		delete(dict, "Filter")
+		delete(dict, "DecodeParms")
+		return nil
+	}
+	dict["Filter"] = slices.Clone(filters[1:])
+
+	parms, err := Resolve(r, dict["DecodeParms"])
+	if err != nil {
+		return err
+	}
+	if parms, ok := parms.(Array); ok && len(parms) > 1 {
+		dict["DecodeParms"] = slices.Clone(parms[1:])
+	} else {
+		delete(dict, "DecodeParms")
+	}
+	return nil
+}
+
 // DecodeStream returns a reader for the decoded stream data. If numFilters is
 // non-zero, only the first numFilters filters are decoded.
 //
@@ -228,20 +271,22 @@ func DecodeStream(r Getter, path *CycleCheck, x *Stream) (io.ReadCloser, error)
 	// per-decode working-memory budget, sized to the raw stream length
 	budget := membudget.New(limits.StreamBudget(x.length))
 
-	// Skip the document-level decryption when the stream's filter chain
-	// declares its own per-stream Crypt filter at position 0.  Per PDF
-	// spec §7.4.10, an explicit /Crypt entry overrides the document's
-	// default StmF and skips the per-object Algorithm 1 key derivation;
-	// the [CryptFilter] variant at position 0 is responsible for
-	// decryption (a pass-through for [FilterCryptIdentity]).
-	applyCrypt := x.crypt != nil
-	if applyCrypt && len(filters) > 0 {
+	// Per PDF spec §7.4.10, an explicit /Crypt entry at position 0 of the
+	// filter chain overrides the document's default crypt filter.  The
+	// entry only names the crypt filter, the decryption is done here.  In
+	// unencrypted documents, the Crypt entry is left in place, so that
+	// anything other than /Identity causes a decoding error.
+	recipe, cf, err := streamCryptRecipe(r, x)
+	if err != nil {
+		return nil, err
+	}
+	if recipe != cryptNone && len(filters) > 0 {
 		if _, ok := filters[0].(CryptFilter); ok {
-			applyCrypt = false
+			filters = filters[1:]
 		}
 	}
-	if applyCrypt {
-		out, err = x.crypt.Decode(v, out, budget)
+	if recipe == cryptDefault || recipe == cryptExplicit {
+		out, err = x.crypt.decodeWith(cf, out)
 		if err != nil {
 			return nil, src.promote(err)
 		}
@@ -20,6 +20,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	"seehuhn.de/go/pdf"
 	"seehuhn.de/go/pdf/internal/debug/memfile"
 )
@@ -148,3 +149,79 @@ func TestGetFiltersChainTooLong(t *testing.T) {
 		t.Fatal("expected error for oversized filter chain, got nil")
 	}
 }
+
+// TestDropCryptFilter checks that a leading Crypt filter is removed from
+// direct and indirect /Filter and /DecodeParms entries.
+func TestDropCryptFilter(t *testing.T) {
+	w, _ := memfile.NewPDFWriter(pdf.V2_0, nil)
+	filterRef := w.Alloc()
+	if err := w.Put(filterRef, pdf.Array{pdf.Name("Crypt"), pdf.Name("FlateDecode")}); err != nil {
+		t.Fatal(err)
+	}
+	parmsRef := w.Alloc()
+	if err := w.Put(parmsRef, pdf.Array{pdf.Dict{"Name": pdf.Name("StdCF")}, pdf.Dict{"Predictor": pdf.Integer(12)}}); err != nil {
+		t.Fatal(err)
+	}
+	cryptRef := w.Alloc()
+	if err := w.Put(cryptRef, pdf.Array{pdf.Name("Crypt")}); err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		name string
+		in   pdf.Dict
+		want pdf.Dict
+	}
+	cases := []testCase{
+		{
+			name: "direct",
+			in: pdf.Dict{
+				"Filter":      pdf.Array{pdf.Name("Crypt"), pdf.Name("FlateDecode")},
+				"DecodeParms": pdf.Array{pdf.Dict{"Name": pdf.Name("StdCF")}, nil},
+			},
+			want: pdf.Dict{
+				"Filter":      pdf.Array{pdf.Name("FlateDecode")},
+				"DecodeParms": pdf.Array{nil},
+			},
+		},
+		{
+			name: "indirect filter",
+			in:   pdf.Dict{"Filter": filterRef},
+			want: pdf.Dict{"Filter": pdf.Array{pdf.Name("FlateDecode")}},
+		},
+		{
+			name: "indirect filter and parameters",
+			in:   pdf.Dict{"Filter": filterRef, "DecodeParms": parmsRef},
+			want: pdf.Dict{
+				"Filter":      pdf.Array{pdf.Name("FlateDecode")},
+				"DecodeParms": pdf.Array{pdf.Dict{"Predictor": pdf.Integer(12)}},
+			},
+		},
+		{
+			name: "indirect crypt only",
+			in:   pdf.Dict{"Filter": cryptRef, "DecodeParms": pdf.Array{nil}},
+			want: pdf.Dict{},
+		},
+		{
+			name: "single name",
+			in:   pdf.Dict{"Filter": pdf.Name("Crypt"), "DecodeParms": pdf.Dict{}},
+			want: pdf.Dict{},
+		},
+		{
+			name: "no crypt filter",
+			in:   pdf.Dict{"Filter": parmsRef},
+			want: pdf.Dict{"Filter": parmsRef},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dict := tc.in
+			if err := pdf.DropCryptFilter(w, dict); err != nil {
+				t.Fatal(err)
+			}
+			if d := cmp.Diff(tc.want, dict); d != "" {
+				t.Errorf("unexpected dict (-want +got):\n%s", d)
+			}
+		})
+	}
+}
@@ -18,7 +18,6 @@ package pdf
 
 import (
 	"bytes"
-	"errors"
 	"io"
 )
 
@@ -67,18 +66,19 @@ func (c *Copier) Copy(obj Native) (Native, error) {
 		// Decide whether the source's on-disk bytes need decryption
 		// before being installed in the destination.  For /Identity
 		// (and unencrypted sources) the bytes are already plaintext
-		// and can be reused in place; for the default StmF recipe we
-		// must strip the source encryption so the destination writer
-		// can apply its own; non-Identity /Crypt CFs are not yet
-		// implemented.
-		recipe, err := streamCryptRecipe(c.r, x)
+		// and can be reused in place; otherwise we must strip the
+		// source encryption so the destination writer can apply its
+		// own.  An explicit /Crypt entry names a crypt filter of the
+		// source document, so it is removed from the copy and the
+		// destination's default applies.
+		recipe, _, err := streamCryptRecipe(c.r, x)
 		if err != nil {
 			return nil, err
 		}
 		switch recipe {
 		case cryptNone, cryptIdentity:
 			// reuse x.data verbatim
-		case cryptDefault:
+		case cryptDefault, cryptExplicit:
 			rc, err := RawStreamReader(c.r, x)
 			if err != nil {
 				return nil, err
@@ -93,9 +93,11 @@ func (c *Copier) Copy(obj Native) (Native, error) {
 			res.data = bytes.NewReader(raw)
 			res.start = 0
 			res.length = int64(len(raw))
-		case cryptUnsupportedCF:
-			return nil, errors.New(
-				"copying streams with non-Identity /Crypt filter is not yet supported")
+			if recipe == cryptExplicit {
+				if err := DropCryptFilter(c.r, res.Dict); err != nil {
+					return nil, err
+				}
+			}
 		}
 		return res, nil
 	case Reference:
@@ -41,6 +41,11 @@ type encryptInfo struct {
 	strF *cryptFilter // strings
 	stmF *cryptFilter // streams
 	efF  *cryptFilter // embedded files
+
+	// cf holds the named crypt filters from the /CF dictionary (V=4 and
+	// V=5 only).  Non-nil slots above point into this map; nil slots
+	// stand for /Identity.
+	cf map[Name]*cryptFilter
 }
 
 // publicInfo returns the user-facing description of this encryption
@@ -52,10 +57,9 @@ func (enc *encryptInfo) publicInfo() *Encryption {
 	// Pick the first non-nil slot as the canonical cipher.  Per spec
 	// (ISO 32000-2 Table 21), StmF/StrF/EFF may individually reference
 	// different crypt filters or default to /Identity, so any slot may
-	// legitimately be nil.  In files we produce all three point to the
-	// same *cryptFilter, and in files we accept every non-nil slot is
-	// built from the single /StdCF entry (the parser rejects other
-	// named filters), so cipher and length agree across non-nil slots.
+	// legitimately be nil.  All non-Identity filters of a document share
+	// the file encryption key, so the key length agrees across slots;
+	// only the cipher of a V=4 file can differ between slots.
 	cf := enc.stmF
 	if cf == nil {
 		cf = enc.strF
@@ -76,18 +80,20 @@ func (enc *encryptInfo) publicInfo() *Encryption {
 	return pub
 }
 
-// filterCrypt is the internal default-StmF stream encryption layer.
+// filterCrypt is the internal stream encryption layer.
 // Unlike regular filters which are stored in the PDF file's stream dictionary,
 // this filter is applied transparently based on the document's encryption
-// settings, using the document's StmF crypt filter and Algorithm 1
-// (per-object key derivation, PDF spec §7.6.3).
+// settings, using Algorithm 1 (per-object key derivation, PDF spec §7.6.3).
+// Encode and Decode use the document's StmF crypt filter; [streamCryptRecipe]
+// selects a different crypt filter for embedded file streams (EFF) and for
+// streams with an explicit /Crypt entry.
 //
-// It is unrelated to the public [CryptFilter] interface (and its variants
+// It is distinct from the public [CryptFilter] interface (and its variants
 // FilterCryptIdentity, FilterCryptStandard, FilterCryptNamed), which
 // represent the explicit /Crypt entries in a stream's /Filter array
-// described by PDF spec §7.4.10.  When such an entry is present at
-// position 0, the filterCrypt layer is skipped because the CryptFilter
-// variant is responsible for the stream's encryption recipe.
+// described by PDF spec §7.4.10.  Such an entry only names the crypt filter
+// to use; the key is held by the document, so the decryption itself is
+// still performed by filterCrypt.
 //
 // The raw (possibly encrypted) stream data stays in the Stream object.
 // Decryption happens on-the-fly when the stream is read through DecodeStream,
@@ -110,7 +116,12 @@ func (f *filterCrypt) Encode(_ Version, w io.WriteCloser) (io.WriteCloser, error
 
 // Decode implements the [Filter] interface.
 func (f *filterCrypt) Decode(_ Version, r io.Reader, _ *membudget.Budget) (io.ReadCloser, error) {
-	decrypted, err := f.enc.DecryptStream(f.ref, r)
+	return f.decodeWith(f.enc.stmF, r)
+}
+
+// decodeWith decrypts the stream data using the crypt filter cf.
+func (f *filterCrypt) decodeWith(cf *cryptFilter, r io.Reader) (io.ReadCloser, error) {
+	decrypted, err := f.enc.decryptStream(cf, f.ref, r)
 	if err != nil {
 		return nil, err
 	}
@@ -189,12 +200,33 @@ func (r *Reader) parseEncryptDict(encObj Object, password string) (*encryptInfo,
 		// filters.  As with /CF above, a malformed selector is ignored and a
 		// genuine read error propagated; an absent selector then leaves the
 		// default (Identity for StmF/StrF, StmF for EFF).
+		//
+		// All usable /CF entries are collected, so that streams can select
+		// any of them through an explicit /Crypt filter.  Broken entries are
+		// skipped here and only reported when one of the selectors refers
+		// to them.
+		res.cf = make(map[Name]*cryptFilter)
+		for name := range CF {
+			if name == "Identity" {
+				continue
+			}
+			if cf, err := getCryptFilter(name, CF); err == nil {
+				res.cf[name] = cf
+			}
+		}
+		lookup := func(name Name) (*cryptFilter, error) {
+			if cf, ok := res.cf[name]; ok {
+				return cf, nil
+			}
+			return getCryptFilter(name, CF)
+		}
+
 		stmFName, err := Optional(c.Name(enc["StmF"]))
 		if err != nil {
 			return nil, 0, err
 		}
 		if stmFName != "" {
-			cf, err := getCryptFilter(stmFName, CF)
+			cf, err := lookup(stmFName)
 			if err != nil {
 				return nil, 0, Wrap(err, "StmF")
 			}
@@ -205,7 +237,7 @@ func (r *Reader) parseEncryptDict(encObj Object, password string) (*encryptInfo,
 			return nil, 0, err
 		}
 		if strFName != "" {
-			cf, err := getCryptFilter(strFName, CF)
+			cf, err := lookup(strFName)
 			if err != nil {
 				return nil, 0, Wrap(err, "StrF")
 			}
@@ -217,7 +249,7 @@ func (r *Reader) parseEncryptDict(encObj Object, password string) (*encryptInfo,
 			return nil, 0, err
 		}
 		if effName != "" {
-			cf, err := getCryptFilter(effName, CF)
+			cf, err := lookup(effName)
 			if err != nil {
 				return nil, 0, Wrap(err, "EFF")
 			}
@@ -253,6 +285,14 @@ func (r *Reader) parseEncryptDict(encObj Object, password string) (*encryptInfo,
 	if err != nil && password != "" {
 		perm, err = res.sec.authenticate(password)
 	}
+	if err != nil && password == "" && res.strF == nil && res.stmF == nil &&
+		res.efF != nil && res.efF.EFOpen {
+		// Only embedded files are encrypted, and the password is only
+		// requested when one of these is opened (/AuthEvent /EFOpen).
+		// Decrypting an embedded file then fails with an
+		// AuthenticationError.
+		return res, stdSecPToPerm(res.sec.R, res.sec.P), nil
+	}
 	if err != nil {
 		return nil, 0, err
 	}
@@ -265,6 +305,15 @@ func (enc *encryptInfo) AsDict(version Version) (Dict, error) {
 		"Filter": Name("Standard"),
 	}
 
+	if enc.cf != nil {
+		err := enc.cryptFiltersAsDict(dict, version)
+		if err != nil {
+			return nil, err
+		}
+		enc.addSecHandlerEntries(dict)
+		return dict, nil
+	}
+
 	length := -1
 	var cipher cipherType
 	for _, cf := range []*cryptFilter{enc.stmF, enc.strF, enc.efF} {
@@ -323,6 +372,176 @@ func (enc *encryptInfo) AsDict(version Version) (Dict, error) {
 		return nil, errors.New("no supported encryption scheme found")
 	}
 
+	enc.addSecHandlerEntries(dict)
+	return dict, nil
+}
+
+// cryptFiltersAsDict adds the entries for a V=4 or V=5 encryption
+// dictionary, which describes the encryption using crypt filters.
+func (enc *encryptInfo) cryptFiltersAsDict(dict Dict, version Version) error {
+	var V int
+	switch {
+	case enc.sec.R == 4 && version >= V1_6:
+		V = 4
+	case enc.sec.R >= 5 && version >= V2_0:
+		V = 5
+	default:
+		return errors.New("no supported encryption scheme found")
+	}
+
+	// See the comment in AsDict about /Length being given in bits.
+	CF := Dict{}
+	for name, cf := range enc.cf {
+		var cfm Name
+		switch {
+		case V == 4 && cf.Cipher == cipherRC4 && cf.Length == 128:
+			cfm = "V2"
+		case V == 4 && cf.Cipher == cipherAES && cf.Length == 128:
+			cfm = "AESV2"
+		case V == 5 && cf.Cipher == cipherAES && cf.Length == 256:
+			cfm = "AESV3"
+		default:
+			return fmt.Errorf("crypt filter %s: unsupported cipher %s for V=%d",
+				name, cf, V)
+		}
+		cfDict := Dict{"CFM": cfm, "Length": Integer(cf.Length)}
+		if cf.EFOpen {
+			cfDict["AuthEvent"] = Name("EFOpen")
+		}
+		CF[name] = cfDict
+	}
+
+	stmF, err := enc.filterName(enc.stmF)
+	if err != nil {
+		return err
+	}
+	strF, err := enc.filterName(enc.strF)
+	if err != nil {
+		return err
+	}
+
+	dict["V"] = Integer(V)
+	dict["StmF"] = stmF
+	dict["StrF"] = strF
+	if enc.efF != enc.stmF {
+		eff, err := enc.filterName(enc.efF)
+		if err != nil {
+			return err
+		}
+		dict["EFF"] = eff
+	}
+	if V == 5 {
+		dict["Length"] = Integer(256)
+	}
+	if len(CF) > 0 {
+		dict["CF"] = CF
+	}
+	return nil
+}
+
+// filterName returns the name under which cf is listed in the /CF
+// dictionary.  A nil crypt filter corresponds to /Identity.
+func (enc *encryptInfo) filterName(cf *cryptFilter) (Name, error) {
+	if cf == nil {
+		return "Identity", nil
+	}
+	for name, x := range enc.cf {
+		if x == cf {
+			return name, nil
+		}
+	}
+	return "", errors.New("crypt filter missing from CF dictionary")
+}
+
+// namedFilter returns the crypt filter with the given name.
+// The result is nil for /Identity.
+func (enc *encryptInfo) namedFilter(name Name) (*cryptFilter, error) {
+	if name == "Identity" {
+		return nil, nil
+	}
+	cf, ok := enc.cf[name]
+	if !ok {
+		return nil, errors.New("unknown crypt filter " + string(name))
+	}
+	return cf, nil
+}
+
+// streamFilter returns the crypt filter used for a stream.  An explicit
+// /Crypt entry at position 0 of the stream's filter chain takes precedence.
+// Otherwise, embedded file streams use /EFF and all other streams use /StmF.
+// The result is nil if the stream data is not encrypted.
+func (enc *encryptInfo) streamFilter(explicit CryptFilter, embeddedFile bool) (*cryptFilter, error) {
+	switch f := explicit.(type) {
+	case FilterCryptIdentity:
+		return nil, nil
+	case FilterCryptStandard:
+		return enc.namedFilter("StdCF")
+	case FilterCryptNamed:
+		return enc.namedFilter(f.Name)
+	}
+	if embeddedFile {
+		return enc.efF, nil
+	}
+	return enc.stmF, nil
+}
+
+// setCryptFilters installs the crypt filter configuration opt.  The
+// crypt filter /StdCF must already be present in enc.cf.
+func (enc *encryptInfo) setCryptFilters(opt *CryptFilters) error {
+	std := enc.cf["StdCF"]
+	for name, method := range opt.Named {
+		if name == "" || name == "Identity" {
+			return fmt.Errorf("invalid crypt filter name %q", name)
+		}
+		cf := &cryptFilter{
+			Cipher: std.Cipher,
+			Length: std.Length,
+		}
+		if method != nil {
+			switch method.Cipher {
+			case "":
+				// use the default cipher
+			case "AES":
+				cf.Cipher = cipherAES
+			case "RC4":
+				if std.Length != 128 {
+					return fmt.Errorf("crypt filter %s: RC4 not supported for %d-bit keys",
+						name, std.Length)
+				}
+				cf.Cipher = cipherRC4
+			default:
+				return fmt.Errorf("crypt filter %s: unknown cipher %q", name, method.Cipher)
+			}
+			cf.EFOpen = method.EmbeddedFileOpen
+		}
+		enc.cf[name] = cf
+	}
+
+	slot := func(name Name) (*cryptFilter, error) {
+		if name == "" {
+			return enc.cf["StdCF"], nil
+		}
+		return enc.namedFilter(name)
+	}
+	var err error
+	enc.stmF, err = slot(opt.StmF)
+	if err != nil {
+		return err
+	}
+	enc.strF, err = slot(opt.StrF)
+	if err != nil {
+		return err
+	}
+	enc.efF, err = slot(opt.EFF)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// addSecHandlerEntries adds the entries of the standard security handler
+// to an encryption dictionary.
+func (enc *encryptInfo) addSecHandlerEntries(dict Dict) {
 	sec := enc.sec
 	dict["R"] = Integer(sec.R)
 	dict["O"] = String(sec.O)
@@ -336,8 +555,6 @@ func (enc *encryptInfo) AsDict(version Version) (Dict, error) {
 		dict["UE"] = String(sec.UE)
 		dict["Perms"] = String(sec.Perms)
 	}
-
-	return dict, nil
 }
 
 // EncryptBytes encrypts the bytes in buf using Algorithm 1 in the PDF spec.
@@ -458,8 +675,14 @@ func unpadPKCS7(buf []byte) ([]byte, error) {
 	return buf[:n-int(padByte)], nil
 }
 
+// EncryptStream encrypts stream data using the document's StmF crypt filter.
 func (enc *encryptInfo) EncryptStream(ref Reference, w io.WriteCloser) (io.WriteCloser, error) {
-	cf := enc.stmF
+	return enc.encryptStream(enc.stmF, ref, w)
+}
+
+// encryptStream encrypts stream data using the crypt filter cf.
+// If cf is nil, the data is written unchanged.
+func (enc *encryptInfo) encryptStream(cf *cryptFilter, ref Reference, w io.WriteCloser) (io.WriteCloser, error) {
 	if cf == nil {
 		return w, nil
 	}
@@ -500,8 +723,14 @@ func (enc *encryptInfo) EncryptStream(ref Reference, w io.WriteCloser) (io.Write
 	}
 }
 
+// DecryptStream decrypts stream data using the document's StmF crypt filter.
 func (enc *encryptInfo) DecryptStream(ref Reference, r io.Reader) (io.Reader, error) {
-	cf := enc.stmF
+	return enc.decryptStream(enc.stmF, ref, r)
+}
+
+// decryptStream decrypts stream data using the crypt filter cf.
+// If cf is nil, the data is returned unchanged.
+func (enc *encryptInfo) decryptStream(cf *cryptFilter, ref Reference, r io.Reader) (io.Reader, error) {
 	if cf == nil {
 		return r, nil
 	}
@@ -1458,6 +1687,10 @@ type cryptFilter struct {
 
 	// Length is the key length in bits.
 	Length int
+
+	// EFOpen is set for /AuthEvent /EFOpen, i.e. if the password is only
+	// requested when an embedded file encrypted with this filter is opened.
+	EFOpen bool
 }
 
 func (cf *cryptFilter) String() string {
@@ -1468,9 +1701,6 @@ func getCryptFilter(cryptFilterName Name, CF Dict) (*cryptFilter, error) {
 	if cryptFilterName == "Identity" {
 		return nil, nil
 	}
-	if cryptFilterName != "StdCF" {
-		return nil, errors.New("unknown crypt filter " + string(cryptFilterName))
-	}
 	if CF == nil {
 		return nil, errors.New("missing CF dictionary")
 	}
@@ -1494,6 +1724,7 @@ func getCryptFilter(cryptFilterName Name, CF Dict) (*cryptFilter, error) {
 	default:
 		return nil, errors.New("unknown cipher")
 	}
+	res.EFOpen = cfDict["AuthEvent"] == Name("EFOpen")
 	return res, nil
 }
 
@@ -1506,11 +1737,11 @@ const (
 	cipherUnknown cipherType = iota
 
 	// cipherRC4 indicates that RC4 encryption is used.  This corresponds to
-	// the StdCF crypt filter with a CFM value of V2 in the PDF specification.
+	// crypt filters with a CFM value of V2 in the PDF specification.
 	cipherRC4
 
 	// cipherAES indicates that AES encryption in CBC mode is used.  This
-	// corresponds to the StdCF crypt filter with a CFM value of AESV2 or
+	// corresponds to crypt filters with a CFM value of AESV2 or
 	// AESV3.
 	cipherAES
 )
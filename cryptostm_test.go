@@ -19,6 +19,7 @@ package pdf
 import (
 	"bytes"
 	"compress/zlib"
+	"errors"
 	"io"
 	"testing"
 
@@ -648,12 +649,11 @@ func TestCopyEncryptedStreamWithIdentityCrypt(t *testing.T) {
 	}
 }
 
-// TestRawStreamReaderRejectsNonIdentityCrypt verifies that RawStreamReader
-// refuses to emit ciphertext for streams whose /Filter chain begins with a
-// non-Identity Crypt entry: until the library can decrypt /StdCF and named
-// CFs, returning the raw encrypted bytes would silently corrupt any caller
-// that copies them into a destination with different keys.
-func TestRawStreamReaderRejectsNonIdentityCrypt(t *testing.T) {
+// TestRawStreamReaderExplicitCrypt verifies that RawStreamReader decrypts
+// streams whose /Filter chain begins with a non-Identity Crypt entry using
+// the named crypt filter, and refuses to emit ciphertext for crypt filters
+// which are not defined in the document.
+func TestRawStreamReaderExplicitCrypt(t *testing.T) {
 	// Build an encrypted PDF containing a normal Flate-compressed stream.
 	buf := &bytes.Buffer{}
 	w, err := NewWriter(buf, V1_6, &WriterOptions{
@@ -691,26 +691,39 @@ func TestRawStreamReaderRejectsNonIdentityCrypt(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	want, err := RawStreamReader(r, stream)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantData, err := io.ReadAll(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
 	// Mutate the dict to claim /Crypt /StdCF at filter position 0.  The
-	// on-disk bytes are not actually /StdCF-encrypted, but RawStreamReader
-	// must refuse without inspecting them.
-	for _, tc := range []struct {
-		name        string
-		decodeParms Object
-	}{
-		{"StdCF", Array{Dict{"Name": Name("StdCF")}, nil}},
-		{"NamedCF", Array{Dict{"Name": Name("MyCF")}, nil}},
-	} {
-		t.Run(tc.name, func(t *testing.T) {
-			stream.Dict["Filter"] = Array{Name("Crypt"), Name("FlateDecode")}
-			stream.Dict["DecodeParms"] = tc.decodeParms
-			if _, err := RawStreamReader(r, stream); err == nil {
-				t.Errorf("RawStreamReader: expected error for non-Identity Crypt, got nil")
-			}
-		})
+	// default StmF of the document is /StdCF, so the on-disk bytes are
+	// correctly encrypted for this.
+	stream.Dict["Filter"] = Array{Name("Crypt"), Name("FlateDecode")}
+	stream.Dict["DecodeParms"] = Array{Dict{"Name": Name("StdCF")}, nil}
+	rc, err := RawStreamReader(r, stream)
+	if err != nil {
+		t.Fatalf("RawStreamReader: unexpected error for /Crypt /StdCF: %v", err)
+	}
+	gotData, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(gotData, wantData) {
+		t.Error("RawStreamReader: /Crypt /StdCF data differs from default StmF data")
 	}
 
-	// Sanity check: the unmutated /Crypt /Identity case must still succeed.
+	// A crypt filter which is not listed in /CF must be rejected.
+	stream.Dict["DecodeParms"] = Array{Dict{"Name": Name("MyCF")}, nil}
+	if _, err := RawStreamReader(r, stream); err == nil {
+		t.Errorf("RawStreamReader: expected error for undefined crypt filter, got nil")
+	}
+
+	// Sanity check: the /Crypt /Identity case must still succeed.
 	stream.Dict["Filter"] = Array{Name("Crypt"), Name("FlateDecode")}
 	stream.Dict["DecodeParms"] = nil // /Identity is the default
 	if _, err := RawStreamReader(r, stream); err != nil {
@@ -782,3 +795,224 @@ func TestFilterCryptInfo(t *testing.T) {
 		t.Errorf("Info returned non-nil dict: %v", dict)
 	}
 }
+
+// writeCryptFilterTestFile writes an encrypted file with a content stream,
+// an embedded file stream, and a stream which selects its crypt filter
+// explicitly.  The references of the three streams are returned.
+func writeCryptFilterTestFile(t *testing.T, v Version, cf *CryptFilters, explicit CryptFilter) ([]byte, []Reference) {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, v, &WriterOptions{
+		UserPassword:  "secret",
+		OwnerPassword: "owner",
+		CryptFilters:  cf,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contents := w.Alloc()
+	attachment := w.Alloc()
+	other := w.Alloc()
+	streams := []struct {
+		ref     Reference
+		dict    Dict
+		filters []Filter
+		data    string
+	}{
+		{contents, nil, nil, "visible page content"},
+		{attachment, Dict{"Type": Name("EmbeddedFile")}, nil, "secret attachment"},
+		{other, nil, []Filter{explicit, FilterFlate{}}, "explicitly encrypted"},
+	}
+	for _, s := range streams {
+		stm, err := w.OpenStream(s.ref, s.dict, s.filters...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stm.Write([]byte(s.data)); err != nil {
+			t.Fatal(err)
+		}
+		if err := stm.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := addPage(w, Name("Contents"), contents, Name("Extra"), Array{attachment, other}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), []Reference{contents, attachment, other}
+}
+
+func readStreamData(r Getter, ref Reference) (string, error) {
+	stm, err := NewCursor(r).Stream(ref)
+	if err != nil {
+		return "", err
+	}
+	rc, err := DecodeStream(r, nil, stm)
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	return string(data), err
+}
+
+// TestEmbeddedFilesOnlyEncryption checks that a document where only the
+// embedded files are encrypted can be read without a password, while the
+// embedded files require the password.
+func TestEmbeddedFilesOnlyEncryption(t *testing.T) {
+	for _, v := range []Version{V1_6, V2_0} {
+		t.Run(v.String(), func(t *testing.T) {
+			cf := &CryptFilters{
+				Named: map[Name]*CryptMethod{
+					"EFCF": {EmbeddedFileOpen: true},
+				},
+				StmF: "Identity",
+				StrF: "Identity",
+				EFF:  "EFCF",
+			}
+			data, refs := writeCryptFilterTestFile(t, v, cf, FilterCryptStandard{})
+
+			if !bytes.Contains(data, []byte("visible page content")) {
+				t.Error("page content is encrypted")
+			}
+			if bytes.Contains(data, []byte("secret attachment")) {
+				t.Error("embedded file is not encrypted")
+			}
+
+			// without a password, only the page content can be read
+			r, err := NewReader(bytes.NewReader(data), int64(len(data)), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := readStreamData(r, refs[0])
+			if err != nil || got != "visible page content" {
+				t.Errorf("contents: got %q, %v", got, err)
+			}
+			_, err = readStreamData(r, refs[1])
+			var authErr *AuthenticationError
+			if !errors.As(err, &authErr) {
+				t.Errorf("embedded file: expected AuthenticationError, got %v", err)
+			}
+
+			// with the password, everything can be read
+			r, err = NewReader(bytes.NewReader(data), int64(len(data)),
+				&ReaderOptions{Password: "secret"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := []string{"visible page content", "secret attachment", "explicitly encrypted"}
+			for i, ref := range refs {
+				got, err := readStreamData(r, ref)
+				if err != nil || got != want[i] {
+					t.Errorf("stream %d: got %q, %v", i, got, err)
+				}
+			}
+			if !r.enc.efF.EFOpen {
+				t.Error("/AuthEvent /EFOpen was lost")
+			}
+		})
+	}
+}
+
+// TestNamedCryptFilters checks documents which use crypt filters with
+// different ciphers, and that such streams survive copying to an
+// unencrypted document.
+func TestNamedCryptFilters(t *testing.T) {
+	cf := &CryptFilters{
+		Named: map[Name]*CryptMethod{
+			"RC4CF": {Cipher: "RC4"},
+		},
+		EFF: "RC4CF",
+	}
+	data, refs := writeCryptFilterTestFile(t, V1_7, cf, FilterCryptNamed{Name: "RC4CF"})
+
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)),
+		&ReaderOptions{Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.enc.stmF.Cipher != cipherAES || r.enc.efF.Cipher != cipherRC4 {
+		t.Errorf("wrong ciphers: StmF=%s, EFF=%s", r.enc.stmF, r.enc.efF)
+	}
+	encDict, err := r.enc.AsDict(V1_7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if encDict["EFF"] != Name("RC4CF") || encDict["StmF"] != Name("StdCF") {
+		t.Errorf("wrong crypt filter selection: %s", AsString(encDict))
+	}
+
+	want := []string{"visible page content", "secret attachment", "explicitly encrypted"}
+	for i, ref := range refs {
+		got, err := readStreamData(r, ref)
+		if err != nil || got != want[i] {
+			t.Errorf("stream %d: got %q, %v", i, got, err)
+		}
+	}
+
+	// copy the streams into an unencrypted document
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	copier := NewCopier(w, r)
+	var copied []Reference
+	for _, ref := range refs {
+		newRef, err := copier.CopyReference(ref)
+		if err != nil {
+			t.Fatal(err)
+		}
+		copied = append(copied, newRef)
+	}
+	if err := addPage(w, Name("Contents"), copied[0], Name("Extra"), Array{copied[1], copied[2]}); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r2, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ref := range copied {
+		got, err := readStreamData(r2, ref)
+		if err != nil || got != want[i] {
+			t.Errorf("copied stream %d: got %q, %v", i, got, err)
+		}
+	}
+}
+
+// TestCryptFilterErrors checks that invalid crypt filter configurations
+// are rejected.
+func TestCryptFilterErrors(t *testing.T) {
+	opt := &WriterOptions{
+		UserPassword: "secret",
+		CryptFilters: &CryptFilters{StmF: "Missing"},
+	}
+	if _, err := NewWriter(&bytes.Buffer{}, V1_7, opt); err == nil {
+		t.Error("undefined StmF: expected error")
+	}
+
+	opt.CryptFilters = &CryptFilters{Named: map[Name]*CryptMethod{"X": {Cipher: "RC4"}}}
+	if _, err := NewWriter(&bytes.Buffer{}, V2_0, opt); err == nil {
+		t.Error("RC4 for PDF 2.0: expected error")
+	}
+
+	opt.CryptFilters = &CryptFilters{}
+	if _, err := NewWriter(&bytes.Buffer{}, V1_4, opt); err == nil {
+		t.Error("crypt filters for PDF 1.4: expected error")
+	}
+
+	w, err := NewWriter(&bytes.Buffer{}, V1_7, &WriterOptions{UserPassword: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.OpenStream(w.Alloc(), nil, FilterCryptNamed{Name: "Missing"}); err == nil {
+		t.Error("undefined crypt filter: expected error")
+	}
+}
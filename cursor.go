@@ -17,6 +17,7 @@
 package pdf
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -47,7 +48,21 @@ func NewCursor(r Getter) Cursor {
 // cycle-detection path.
 func (c Cursor) resolve(obj Object) (Native, error) {
 	n, _, err := resolvePath(c.x.R, c.path, obj, true)
-	return n, err
+	return n, c.mark(err)
+}
+
+// mark prepares a malformed-file error so that [Optional] passes it to the
+// Repaired callback of the extractor, in case the error is skipped.  Other
+// errors, and all errors when no callback is set, are returned unchanged.
+func (c Cursor) mark(err error) error {
+	if err == nil || c.x.Repaired == nil || !IsMalformed(err) {
+		return err
+	}
+	var r *repairableError
+	if errors.As(err, &r) {
+		return err
+	}
+	return &repairableError{err: err, report: c.x.Repaired}
 }
 
 // Resolve follows indirect references starting from obj and returns the
@@ -66,7 +81,8 @@ func cursorCast[T Native](c Cursor, obj Object) (T, error) {
 		var zero T
 		return zero, err
 	}
-	return as[T](resolved)
+	res, err := as[T](resolved)
+	return res, c.mark(err)
 }
 
 // Integer resolves any indirect reference and returns the object as an Integer.
@@ -77,7 +93,8 @@ func (c Cursor) Integer(obj Object) (Integer, error) {
 	if err != nil {
 		return 0, err
 	}
-	return asInteger(resolved)
+	res, err := asInteger(resolved)
+	return res, c.mark(err)
 }
 
 // Number resolves any indirect reference and returns the object as a float64.
@@ -88,7 +105,7 @@ func (c Cursor) Number(obj Object) (float64, error) {
 		return 0, err
 	}
 	n, err := asNumber(resolved)
-	return float64(n), err
+	return float64(n), c.mark(err)
 }
 
 // Array resolves any indirect reference and returns the object as an Array.
@@ -143,9 +160,9 @@ func (c Cursor) CheckDictType(dict Dict, wantType Name) error {
 		return err
 	}
 	if haveType != wantType && haveType != "" {
-		return &MalformedFileError{
+		return c.mark(&MalformedFileError{
 			Err: fmt.Errorf("expected dict type %q, got %q", wantType, haveType),
-		}
+		})
 	}
 	return nil
 }
@@ -210,9 +227,9 @@ func (c Cursor) Matrix(obj Object) (matrix.Matrix, error) {
 		return matrix.Matrix{}, Wrap(err, "Matrix")
 	}
 	if len(a) != 6 {
-		return matrix.Matrix{}, &MalformedFileError{
+		return matrix.Matrix{}, c.mark(&MalformedFileError{
 			Err: fmt.Errorf("expected 6 numbers, got %d", len(a)),
-		}
+		})
 	}
 	var m matrix.Matrix
 	copy(m[:], a)
@@ -226,7 +243,8 @@ func (c Cursor) Date(obj Object) (Date, error) {
 	if err != nil {
 		return zero, err
 	}
-	return s.AsDate()
+	d, err := s.AsDate()
+	return d, c.mark(err)
 }
 
 // TextString resolves any indirect reference and returns the object as a
@@ -306,20 +324,23 @@ func Decode[T any](c Cursor, obj Object, decode func(Cursor, Object, bool) (T, e
 		var err error
 		path, err = path.step(ref)
 		if err != nil {
-			return zero, err
+			return zero, c.mark(err)
 		}
 		refs = append(refs, ref)
 
 		obj, err = x.R.Get(ref, true)
 		if err != nil {
-			return zero, err
+			return zero, c.mark(err)
 		}
 	}
 
 	isDirect := len(refs) == 0
 	res, err := decode(Cursor{x: x, path: path}, obj, isDirect)
-	if err != nil {
+	if err != nil && obj == nil {
+		// an absent object is not a repair, even if the decoder rejects it
 		return zero, err
+	} else if err != nil {
+		return zero, c.mark(err)
 	}
 
 	// publish under all refs; adopt a concurrent decoder's result on a race so
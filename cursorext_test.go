@@ -128,3 +128,64 @@ func TestCursorVersion(t *testing.T) {
 		t.Errorf("Version: got %v, want %v", v, pdf.V1_7)
 	}
 }
+
+// TestExtractorRepaired checks that the malformed values skipped by
+// pdf.Optional are passed to the Repaired callback of the extractor.
+func TestExtractorRepaired(t *testing.T) {
+	w, _ := memfile.NewPDFWriter(pdf.V2_0, nil)
+	ref := w.Alloc()
+	err := w.Put(ref, pdf.Dict{
+		"Skipped": pdf.Integer(1),
+		"Valid":   pdf.Name("ok"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		skipped, valid pdf.Name
+	}
+	decode := func(c pdf.Cursor, obj pdf.Object, _ bool) (*result, error) {
+		dict, err := c.Dict(obj)
+		if err != nil {
+			return nil, err
+		}
+		res := &result{}
+		res.skipped, err = pdf.Optional(c.Name(dict["Skipped"]))
+		if err != nil {
+			return nil, err
+		}
+		res.valid, err = pdf.Optional(c.Name(dict["Valid"]))
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
+	}
+
+	var repaired []error
+	x := pdf.NewExtractor(w)
+	x.Repaired = func(err error) {
+		repaired = append(repaired, err)
+	}
+	c := pdf.CursorAt(x, nil)
+	res, err := pdf.Decode(c, ref, decode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.skipped != "" || res.valid != "ok" {
+		t.Errorf("wrong result %+v", res)
+	}
+	if len(repaired) != 1 || !pdf.IsMalformed(repaired[0]) {
+		t.Errorf("got repairs %v, want one malformed-file error", repaired)
+	}
+
+	// errors which are returned to the caller are not repairs
+	repaired = nil
+	_, err = c.Name(pdf.Integer(2))
+	if !pdf.IsMalformed(err) {
+		t.Errorf("expected malformed-file error, got %v", err)
+	}
+	if len(repaired) != 0 {
+		t.Errorf("unexpected repairs %v", repaired)
+	}
+}
@@ -98,9 +98,16 @@ func (err *MalformedFileError) Unwrap() error {
 // Optional zeros out any [MalformedFileError] but returns all other errors.
 // The check uses [errors.As] so wrapped malformed errors are recognised,
 // consistent with [IsMalformed].
+//
+// If err was returned by a [Cursor] whose [Extractor] has a Repaired
+// callback, the skipped error is passed to this callback.
 func Optional[T any](value T, err error) (T, error) {
 	var zero T
 	if IsMalformed(err) {
+		var r *repairableError
+		if errors.As(err, &r) {
+			r.report(r.err)
+		}
 		return zero, nil
 	} else if err != nil {
 		return zero, err
@@ -108,6 +115,21 @@ func Optional[T any](value T, err error) (T, error) {
 	return value, nil
 }
 
+// repairableError marks a malformed-file error which was found while
+// decoding through an [Extractor] with a Repaired callback.
+type repairableError struct {
+	err    error
+	report func(error)
+}
+
+func (err *repairableError) Error() string {
+	return err.err.Error()
+}
+
+func (err *repairableError) Unwrap() error {
+	return err.err
+}
+
 // Wrap wraps an error with a location.
 // If the error wraps a [MalformedFileError], the location is appended to the
 // list of locations on that inner error (and the outer wrapping is preserved).
@@ -153,10 +175,12 @@ type VersionError struct {
 	Latest    Version // zero means no upper bound
 }
 
-// CheckVersion checks whether the PDF file being written has version
-// minVersion or later.  If the version is new enough, nil is returned.
-// Otherwise a [VersionError] for the given operation is returned.
-func CheckVersion(pdf *Writer, operation string, minVersion Version) error {
+// CheckVersion checks whether the PDF file has version minVersion or later.
+// The argument pdf is normally the [Writer] of the file being written, but
+// a [Reader] can be used to validate existing files.  If the version is new
+// enough, nil is returned.  Otherwise a [VersionError] for the given
+// operation is returned.
+func CheckVersion(pdf interface{ GetMeta() *MetaInfo }, operation string, minVersion Version) error {
 	if pdf.GetMeta().Version >= minVersion {
 		return nil
 	}
@@ -166,11 +190,11 @@ func CheckVersion(pdf *Writer, operation string, minVersion Version) error {
 	}
 }
 
-// CheckVersionAtMost checks whether the PDF file being written has
-// version maxVersion or earlier.  If the version is old enough, nil is
-// returned.  Otherwise a [VersionError] for the given operation is
-// returned.
-func CheckVersionAtMost(pdf *Writer, operation string, maxVersion Version) error {
+// CheckVersionAtMost checks whether the PDF file has version maxVersion or
+// earlier.  As for [CheckVersion], pdf can be a [Writer] or a [Reader].
+// If the version is old enough, nil is returned.  Otherwise a
+// [VersionError] for the given operation is returned.
+func CheckVersionAtMost(pdf interface{ GetMeta() *MetaInfo }, operation string, maxVersion Version) error {
 	if pdf.GetMeta().Version <= maxVersion {
 		return nil
 	}
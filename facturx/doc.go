@@ -0,0 +1,40 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package facturx embeds and extracts the XML part of hybrid electronic
+// invoices, following the Factur-X and ZUGFeRD specifications.
+//
+// A hybrid invoice is a PDF/A-3 document which shows the invoice to human
+// readers, and which carries the same invoice in machine-readable form as
+// an embedded XML file.  The XML file is linked to the document by the
+// associated files (AF) array of the document catalog, is listed in the
+// EmbeddedFiles name tree, and is declared in the XMP metadata of the
+// document using the fx: extension schema.
+//
+// [Attach] copies a document and adds the XML invoice, together with the
+// file specification, the AF linkage and the XMP properties.  The caller
+// is responsible for the remaining PDF/A-3 requirements, for example
+// embedded fonts and output intents.  If the source document claims
+// conformance to PDF/A-1 or PDF/A-2, the claim is changed to PDF/A-3,
+// since only PDF/A-3 allows embedded XML files.
+//
+// [Extract] locates the invoice XML in a received document.  Besides
+// Factur-X and ZUGFeRD 2, the older ZUGFeRD 1 metadata is recognised.
+// If the XMP metadata is missing, the embedded files are searched for a
+// known file name or for a known XML root element.  The profile of the
+// invoice is taken from the XMP metadata or, failing that, from the
+// guideline identifier in the XML data.
+package facturx
@@ -0,0 +1,255 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package facturx
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/attachment"
+	"seehuhn.de/go/pdf/file"
+	"seehuhn.de/go/pdf/nametree"
+)
+
+// ErrNoInvoice is returned by [Extract] if the document does not contain an
+// embedded invoice.
+var ErrNoInvoice = errors.New("no embedded invoice found")
+
+// knownFileNames lists the file names used for embedded invoices by the
+// different versions of Factur-X, ZUGFeRD and XRechnung.
+var knownFileNames = []string{
+	"factur-x.xml",
+	"zugferd-invoice.xml",
+	"ZUGFeRD-invoice.xml",
+	"xrechnung.xml",
+}
+
+// XML namespaces of the invoice root elements.
+const (
+	nsCII      = "urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"
+	nsCIIFeRD  = "urn:ferd:CrossIndustryDocument:invoice:1p0"
+	nsUBL      = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	nsUBLNote  = "urn:oasis:names:specification:ubl:schema:xsd:CreditNote-2"
+	guidelineP = "GuidelineSpecifiedDocumentContextParameter"
+)
+
+// Extract locates the invoice XML embedded in the document r.  If no
+// invoice is found, [ErrNoInvoice] is returned.
+func Extract(r pdf.Getter) (*Invoice, error) {
+	meta := r.GetMeta()
+	inv := readMetadata(meta.Catalog.Metadata)
+
+	specs, err := embeddedFiles(r, meta.Catalog)
+	if err != nil {
+		return nil, err
+	}
+
+	// Candidates are tried in order: the file declared in the XMP
+	// metadata, files with one of the known names, and finally all other
+	// embedded files.
+	var order []*file.Specification
+	if inv.FileName != "" {
+		for _, spec := range specs {
+			if attachment.FileName(spec) == inv.FileName {
+				order = append(order, spec)
+			}
+		}
+	}
+	for _, name := range knownFileNames {
+		for _, spec := range specs {
+			if strings.EqualFold(attachment.FileName(spec), name) {
+				order = append(order, spec)
+			}
+		}
+	}
+	order = append(order, specs...)
+
+	for _, spec := range order {
+		data, err := readFile(spec)
+		if pdf.IsReadError(err) {
+			return nil, err
+		} else if data == nil {
+			continue
+		}
+		syntax, guideline, err := inspectXML(data)
+		if err != nil || syntax == UnknownSyntax {
+			continue
+		}
+
+		res := *inv
+		res.Data = data
+		res.Syntax = syntax
+		res.FileName = attachment.FileName(spec)
+		res.Relationship = spec.AFRelationship
+		res.Description = spec.Description
+		if res.Profile == "" {
+			res.Profile = profileFromGuideline(guideline)
+		}
+		if stm := attachment.EmbeddedStream(spec); stm != nil {
+			res.ModDate = stm.ModDate
+		}
+		return &res, nil
+	}
+	return nil, ErrNoInvoice
+}
+
+// embeddedFiles returns the file specifications of the associated files of
+// the document, followed by those in the EmbeddedFiles name tree.  Each file
+// specification is listed once.
+func embeddedFiles(r pdf.Getter, cat *pdf.Catalog) ([]*file.Specification, error) {
+	cur := pdf.NewCursor(r)
+
+	var objs []pdf.Object
+	af, err := cur.Array(cat.AF)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	objs = append(objs, af...)
+
+	names, err := cur.Dict(cat.Names)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	if names != nil {
+		tree, err := nametree.ExtractInMemory(r, names["EmbeddedFiles"])
+		if pdf.IsReadError(err) {
+			return nil, err
+		}
+		if tree != nil {
+			for _, key := range slices.Sorted(maps.Keys(tree.Data)) {
+				objs = append(objs, tree.Data[key])
+			}
+		}
+	}
+
+	var res []*file.Specification
+	seen := map[pdf.Reference]bool{}
+	for _, obj := range objs {
+		if ref, ok := obj.(pdf.Reference); ok {
+			if seen[ref] {
+				continue
+			}
+			seen[ref] = true
+		}
+		spec, err := file.ExtractSpecification(cur, obj, false)
+		if pdf.IsReadError(err) {
+			return nil, err
+		} else if err != nil || spec == nil {
+			continue
+		}
+		res = append(res, spec)
+	}
+	return res, nil
+}
+
+// readFile returns the contents of an embedded file, or nil if the file is
+// not embedded.
+func readFile(spec *file.Specification) ([]byte, error) {
+	stm := attachment.EmbeddedStream(spec)
+	if stm == nil {
+		return nil, nil
+	}
+	buf := &bytes.Buffer{}
+	if err := stm.WriteData(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// inspectXML determines the syntax of an XML invoice, and returns the
+// guideline identifier, which specifies the profile.
+func inspectXML(data []byte) (Syntax, string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+
+	syntax := UnknownSyntax
+	var stack []string
+	var text strings.Builder
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return syntax, "", nil
+		} else if err != nil {
+			return UnknownSyntax, "", err
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if len(stack) == 0 {
+				switch {
+				case tok.Name.Space == nsCII && tok.Name.Local == "CrossIndustryInvoice",
+					tok.Name.Space == nsCIIFeRD && tok.Name.Local == "CrossIndustryDocument":
+					syntax = CII
+				case tok.Name.Space == nsUBL && tok.Name.Local == "Invoice",
+					tok.Name.Space == nsUBLNote && tok.Name.Local == "CreditNote":
+					syntax = UBL
+				default:
+					return UnknownSyntax, "", nil
+				}
+			}
+			stack = append(stack, tok.Name.Local)
+			text.Reset()
+		case xml.CharData:
+			text.Write(tok)
+		case xml.EndElement:
+			n := len(stack)
+			if n == 0 {
+				continue
+			}
+			isGuideline := false
+			switch syntax {
+			case CII:
+				isGuideline = n >= 2 && stack[n-1] == "ID" && stack[n-2] == guidelineP
+			case UBL:
+				isGuideline = n == 2 && stack[n-1] == "CustomizationID"
+			}
+			if isGuideline {
+				return syntax, strings.TrimSpace(text.String()), nil
+			}
+			stack = stack[:n-1]
+		}
+	}
+}
+
+// profileFromGuideline maps a guideline identifier to a profile.  This
+// covers the identifiers of Factur-X, of ZUGFeRD 1 and 2, and of XRechnung.
+// The empty string is returned for unknown identifiers.
+func profileFromGuideline(id string) Profile {
+	lower := strings.ToLower(id)
+	switch {
+	case strings.Contains(lower, "xrechnung"):
+		return XRechnung
+	case lower == "urn:cen.eu:en16931:2017":
+		return EN16931
+	case strings.HasSuffix(lower, ":minimum"):
+		return Minimum
+	case strings.HasSuffix(lower, ":basicwl"):
+		return BasicWL
+	case strings.HasSuffix(lower, ":basic"):
+		return Basic
+	case strings.HasSuffix(lower, ":comfort"):
+		return Comfort
+	case strings.HasSuffix(lower, ":extended"):
+		return Extended
+	}
+	return ""
+}
@@ -0,0 +1,408 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package facturx
+
+import (
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"strings"
+	"time"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/attachment"
+	"seehuhn.de/go/pdf/file"
+	"seehuhn.de/go/pdf/internal/rewrite"
+	"seehuhn.de/go/pdf/nametree"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// Profile is the conformance level of an invoice.  The values are the ones
+// used for the fx:ConformanceLevel XMP property.
+type Profile string
+
+// These are the profiles defined by Factur-X 1.0 and ZUGFeRD 2.
+const (
+	Minimum   Profile = "MINIMUM"
+	BasicWL   Profile = "BASIC WL"
+	Basic     Profile = "BASIC"
+	EN16931   Profile = "EN 16931"
+	Extended  Profile = "EXTENDED"
+	XRechnung Profile = "XRECHNUNG"
+)
+
+// Comfort is the ZUGFeRD 1 profile which corresponds to [EN16931].  It
+// is only returned by [Extract].
+const Comfort Profile = "COMFORT"
+
+// Standard identifies the specification an embedded invoice follows.
+type Standard int
+
+// These are the standards recognised by [Extract].
+const (
+	// UnknownStandard is used if the XMP metadata does not describe the
+	// invoice.
+	UnknownStandard Standard = iota
+
+	// FacturX is used for Factur-X, and for ZUGFeRD 2.1 and later.
+	FacturX
+
+	// ZUGFeRD2 is used for ZUGFeRD 2.0.
+	ZUGFeRD2
+
+	// ZUGFeRD1 is used for ZUGFeRD 1.
+	ZUGFeRD1
+)
+
+func (s Standard) String() string {
+	switch s {
+	case FacturX:
+		return "Factur-X"
+	case ZUGFeRD2:
+		return "ZUGFeRD 2.0"
+	case ZUGFeRD1:
+		return "ZUGFeRD 1"
+	default:
+		return "unknown"
+	}
+}
+
+// Syntax is the XML syntax of an invoice.
+type Syntax int
+
+// These are the invoice syntaxes recognised by this package.
+const (
+	UnknownSyntax Syntax = iota
+
+	// CII is the UN/CEFACT Cross Industry Invoice syntax, used by
+	// Factur-X and ZUGFeRD.  The older CrossIndustryDocument format of
+	// ZUGFeRD 1 is also reported as CII.
+	CII
+
+	// UBL is the OASIS Universal Business Language syntax, which can be
+	// used for XRechnung invoices.
+	UBL
+)
+
+func (s Syntax) String() string {
+	switch s {
+	case CII:
+		return "CII"
+	case UBL:
+		return "UBL"
+	default:
+		return "unknown"
+	}
+}
+
+// Invoice describes an XML invoice embedded in a PDF document.
+type Invoice struct {
+	// Data is the XML invoice.
+	Data []byte
+
+	// Profile is the conformance level of the invoice.  When attaching an
+	// invoice, this can be left empty to take the profile from the
+	// guideline identifier in Data.
+	Profile Profile
+
+	// FileName is the name of the embedded file.  When attaching an
+	// invoice, this defaults to "xrechnung.xml" for the XRechnung profile
+	// and to "factur-x.xml" otherwise.
+	FileName string
+
+	// DocumentType is the type of the document, for example "INVOICE" or
+	// "ORDER".  When attaching an invoice, this defaults to "INVOICE".
+	DocumentType string
+
+	// Version is the version of the Factur-X XMP schema.  When attaching an
+	// invoice, this defaults to "1.0".
+	Version string
+
+	// Relationship is the relationship between the document and the XML
+	// file.  When attaching an invoice, this defaults to
+	// [file.RelationshipData] for the MINIMUM and BASIC WL profiles, which
+	// do not contain the full invoice, and to
+	// [file.RelationshipAlternative] otherwise.
+	Relationship file.Relationship
+
+	// Description (optional) is shown to the user as the description of
+	// the embedded file.
+	Description string
+
+	// ModDate (optional) is the modification date of the embedded file.
+	ModDate time.Time
+
+	// Standard is the specification declared in the XMP metadata.  This is
+	// set by [Extract] and ignored by [Attach].
+	Standard Standard
+
+	// Syntax is the XML syntax of Data.  This is set by [Extract] and
+	// ignored by [Attach].
+	Syntax Syntax
+}
+
+// Attach reads the document from r, embeds the invoice, and writes the
+// result to w.  The output uses at least PDF 1.7.  An existing embedded
+// file with the same name is replaced.
+func Attach(w io.Writer, r pdf.Getter, inv *Invoice) error {
+	if inv == nil || len(inv.Data) == 0 {
+		return errors.New("missing invoice data")
+	}
+	syntax, guideline, err := inspectXML(inv.Data)
+	if err != nil {
+		return err
+	}
+	if syntax == UnknownSyntax {
+		return errors.New("invoice data is not a CII or UBL invoice")
+	}
+
+	a := *inv
+	if a.Profile == "" {
+		a.Profile = profileFromGuideline(guideline)
+		if a.Profile == "" {
+			return fmt.Errorf("unknown invoice guideline %q", guideline)
+		}
+	}
+	if a.FileName == "" {
+		a.FileName = "factur-x.xml"
+		if a.Profile == XRechnung {
+			a.FileName = "xrechnung.xml"
+		}
+	}
+	if a.DocumentType == "" {
+		a.DocumentType = "INVOICE"
+	}
+	if a.Version == "" {
+		a.Version = "1.0"
+	}
+	if a.Relationship == "" {
+		a.Relationship = file.RelationshipAlternative
+		if a.Profile == Minimum || a.Profile == BasicWL {
+			a.Relationship = file.RelationshipData
+		}
+	}
+
+	refs, dicts, err := rewrite.ReadPages(r)
+	if err != nil {
+		return err
+	}
+
+	metaIn := r.GetMeta()
+	metadata, err := updateMetadata(metaIn.Catalog.Metadata, &a)
+	if err != nil {
+		return err
+	}
+	out, err := pdf.NewWriter(w, max(pdf.GetVersion(r), pdf.V1_7), &pdf.WriterOptions{
+		DocumentMetadata: metadata,
+	})
+	if err != nil {
+		return err
+	}
+	rm := pdf.NewResourceManager(out)
+	at := &attacher{
+		x:    pdf.NewExtractor(r),
+		out:  out,
+		rm:   rm,
+		copy: pdf.NewCopier(out, r),
+		inv:  &a,
+	}
+
+	newRefs := rewrite.RedirectPages(out, at.copy, refs)
+
+	tree := pagetree.NewWriter(out, rm)
+	for i, dict := range dicts {
+		newDict, err := at.copy.CopyDict(dict)
+		if err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+		if err := tree.AppendPageDict(newRefs[i], newDict); err != nil {
+			return err
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		return err
+	}
+
+	spec, err := rm.Embed(at.specification())
+	if err != nil {
+		return err
+	}
+
+	meta := out.GetMeta()
+	meta.Info = metaIn.Info
+	if err := at.copyCatalog(meta.Catalog, metaIn.Catalog, spec); err != nil {
+		return err
+	}
+	meta.Catalog.Pages = pagesRef
+
+	if err := rm.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+type attacher struct {
+	x    *pdf.Extractor
+	out  *pdf.Writer
+	rm   *pdf.ResourceManager
+	copy *pdf.Copier
+	inv  *Invoice
+}
+
+// specification returns the file specification for the embedded invoice.
+func (at *attacher) specification() *file.Specification {
+	inv := at.inv
+	sum := md5.Sum(inv.Data)
+	stream := &file.Stream{
+		MimeType: "text/xml",
+		Size:     int64(len(inv.Data)),
+		ModDate:  inv.ModDate,
+		CheckSum: sum[:],
+		WriteData: func(w io.Writer) error {
+			_, err := w.Write(inv.Data)
+			return err
+		},
+	}
+	desc := inv.Description
+	if desc == "" {
+		desc = "Factur-X/ZUGFeRD invoice"
+		if inv.Profile == XRechnung {
+			desc = "XRechnung invoice"
+		}
+	}
+	return &file.Specification{
+		FileName:        inv.FileName,
+		FileNameUnicode: inv.FileName,
+		Description:     desc,
+		AFRelationship:  inv.Relationship,
+		EmbeddedFiles: map[string]*file.Stream{
+			"F":  stream,
+			"UF": stream,
+		},
+	}
+}
+
+// copyCatalog copies the document-level entries of the catalog, and adds
+// the invoice to the associated files and the embedded files.  The XMP
+// metadata is set up when the writer is created.
+func (at *attacher) copyCatalog(dst, src *pdf.Catalog, spec pdf.Native) error {
+	err := rewrite.CopyCatalog(at.out, at.copy, src, "AF", "Names")
+	if err != nil {
+		return err
+	}
+
+	af, err := at.associatedFiles(src.AF, spec)
+	if err != nil {
+		return err
+	}
+	dst.AF = af
+
+	names, err := at.names(src.Names, spec)
+	if err != nil {
+		return err
+	}
+	dst.Names = names
+	return nil
+}
+
+// associatedFiles returns the AF array of the output document: the
+// associated files of the source document, followed by the invoice.
+func (at *attacher) associatedFiles(obj pdf.Object, spec pdf.Native) (pdf.Array, error) {
+	cur := pdf.CursorAt(at.x, nil)
+	arr, err := cur.Array(obj)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+
+	var res pdf.Array
+	for _, elem := range arr {
+		if at.replaced(elem) {
+			continue
+		}
+		nv, ok := elem.(pdf.Native)
+		if !ok || nv == nil {
+			continue
+		}
+		copied, err := at.copy.Copy(nv)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, copied)
+	}
+	return append(res, spec), nil
+}
+
+// names returns the name dictionary of the output document, with the
+// invoice added to the EmbeddedFiles name tree.
+func (at *attacher) names(obj pdf.Object, spec pdf.Native) (pdf.Object, error) {
+	cur := pdf.CursorAt(at.x, nil)
+	dictIn, err := cur.Dict(obj)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+
+	files := map[pdf.Name]pdf.Object{}
+	if dictIn != nil {
+		tree, err := nametree.ExtractInMemory(at.x.R, dictIn["EmbeddedFiles"])
+		if pdf.IsReadError(err) {
+			return nil, err
+		}
+		if tree != nil {
+			for key, val := range tree.Data {
+				if strings.EqualFold(string(key), at.inv.FileName) || at.replaced(val) {
+					continue
+				}
+				nv, ok := val.(pdf.Native)
+				if !ok || nv == nil {
+					continue
+				}
+				copied, err := at.copy.Copy(nv)
+				if err != nil {
+					return nil, err
+				}
+				files[key] = copied
+			}
+		}
+	}
+	files[pdf.Name(at.inv.FileName)] = spec
+	treeRef, err := nametree.WriteMap(at.out, files)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := maps.Clone(dictIn)
+	delete(rest, "EmbeddedFiles")
+	dict, err := at.copy.CopyDict(rest)
+	if err != nil {
+		return nil, err
+	}
+	dict["EmbeddedFiles"] = treeRef
+	return dict, nil
+}
+
+// replaced reports whether obj is a file specification for a file with the
+// name of the new invoice.  Such files are dropped from the output.
+func (at *attacher) replaced(obj pdf.Object) bool {
+	spec, err := file.ExtractSpecification(pdf.CursorAt(at.x, nil), obj, false)
+	if err != nil || spec == nil {
+		return false
+	}
+	return strings.EqualFold(attachment.FileName(spec), at.inv.FileName)
+}
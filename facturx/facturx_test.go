@@ -0,0 +1,233 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package facturx
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"golang.org/x/text/language"
+
+	"seehuhn.de/go/xmp"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/document"
+	"seehuhn.de/go/pdf/file"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+	"seehuhn.de/go/pdf/internal/rewrite/rewritetest"
+	"seehuhn.de/go/pdf/nametree"
+)
+
+const testCII = `<?xml version="1.0" encoding="UTF-8"?>
+<rsm:CrossIndustryInvoice xmlns:rsm="urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"
+    xmlns:ram="urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100">
+  <rsm:ExchangedDocumentContext>
+    <ram:GuidelineSpecifiedDocumentContextParameter>
+      <ram:ID>urn:cen.eu:en16931:2017</ram:ID>
+    </ram:GuidelineSpecifiedDocumentContextParameter>
+  </rsm:ExchangedDocumentContext>
+  <rsm:ExchangedDocument>
+    <ram:ID>INV-0001</ram:ID>
+  </rsm:ExchangedDocument>
+</rsm:CrossIndustryInvoice>
+`
+
+const testUBL = `<?xml version="1.0" encoding="UTF-8"?>
+<Invoice xmlns="urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+    xmlns:cbc="urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2">
+  <cbc:CustomizationID>urn:cen.eu:en16931:2017#compliant#urn:xeinkauf.de:kosit:xrechnung_3.0</cbc:CustomizationID>
+  <cbc:ID>INV-0002</cbc:ID>
+</Invoice>
+`
+
+// makeSource writes a one-page document which claims PDF/A-2b conformance.
+func makeSource(t *testing.T) *pdf.Reader {
+	t.Helper()
+
+	packet := xmp.NewPacket()
+	err := packet.Set(&xmp.PDFAID{Part: xmp.NewInteger(2), Conformance: xmp.NewText("B")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	opt := &pdf.WriterOptions{
+		DocumentMetadata: &pdf.MetadataStream{Data: packet},
+	}
+
+	buf := memfile.New()
+	doc, err := document.WriteSinglePage(buf, &pdf.Rectangle{URx: 200, URy: 200}, pdf.V1_7, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(buf, int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func attach(t *testing.T, r pdf.Getter, inv *Invoice) *pdf.Reader {
+	t.Helper()
+
+	out := &bytes.Buffer{}
+	if err := Attach(out, r, inv); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	res, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestRoundTrip(t *testing.T) {
+	r := attach(t, makeSource(t), &Invoice{Data: []byte(testCII)})
+	meta := r.GetMeta()
+
+	// check the AF linkage
+	cur := pdf.NewCursor(r)
+	af, err := cur.Array(meta.Catalog.AF)
+	if err != nil || len(af) != 1 {
+		t.Fatalf("AF = %v, %v", af, err)
+	}
+	spec, err := file.ExtractSpecification(cur, af[0], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spec.FileName != "factur-x.xml" || spec.AFRelationship != file.RelationshipAlternative {
+		t.Errorf("spec = %q, %q", spec.FileName, spec.AFRelationship)
+	}
+	stm := spec.EmbeddedFiles["F"]
+	if stm == nil || stm.MimeType != "text/xml" || stm.Size != int64(len(testCII)) {
+		t.Errorf("embedded stream = %+v", stm)
+	}
+
+	// check the EmbeddedFiles name tree
+	names, err := cur.Dict(meta.Catalog.Names)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, err := nametree.ExtractInMemory(r, names["EmbeddedFiles"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree == nil || tree.Data["factur-x.xml"] != af[0] {
+		t.Errorf("EmbeddedFiles = %v", tree)
+	}
+
+	// check the XMP metadata
+	packet := meta.Catalog.Metadata.Data
+	level, err := xmp.PacketGetValue[xmp.Text](packet, nsFacturX, "ConformanceLevel")
+	if err != nil || level.V != "EN 16931" {
+		t.Errorf("ConformanceLevel = %q, %v", level.V, err)
+	}
+	var id xmp.PDFAID
+	if err := packet.Get(&id); err != nil || id.Part.V != 3 || id.Conformance.V != "B" {
+		t.Errorf("pdfaid = %d%s, %v", id.Part.V, id.Conformance.V, err)
+	}
+
+	inv, err := Extract(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(inv.Data) != testCII {
+		t.Error("invoice data differs")
+	}
+	if inv.Standard != FacturX || inv.Syntax != CII || inv.Profile != EN16931 ||
+		inv.DocumentType != "INVOICE" || inv.Version != "1.0" {
+		t.Errorf("invoice = %v %v %q %q %q",
+			inv.Standard, inv.Syntax, inv.Profile, inv.DocumentType, inv.Version)
+	}
+}
+
+// TestReplace checks that attaching a second invoice replaces the first
+// one, and that the extension schema is declared only once.
+func TestReplace(t *testing.T) {
+	r := attach(t, makeSource(t), &Invoice{Data: []byte(testCII), FileName: "xrechnung.xml"})
+	r = attach(t, r, &Invoice{Data: []byte(testUBL)})
+
+	meta := r.GetMeta()
+	af, err := pdf.NewCursor(r).Array(meta.Catalog.AF)
+	if err != nil || len(af) != 1 {
+		t.Fatalf("AF = %v, %v", af, err)
+	}
+	schemas := meta.Catalog.Metadata.Data.Properties[extensionSchemas]
+	if arr, ok := schemas.(xmp.RawArray); !ok || len(arr.Value) != 1 {
+		t.Errorf("extension schemas = %v", schemas)
+	}
+
+	inv, err := Extract(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(inv.Data) != testUBL || inv.FileName != "xrechnung.xml" ||
+		inv.Syntax != UBL || inv.Profile != XRechnung {
+		t.Errorf("invoice = %q %v %q", inv.FileName, inv.Syntax, inv.Profile)
+	}
+}
+
+// TestCatalog checks that the other document-level data survives
+// attaching an invoice.
+func TestCatalog(t *testing.T) {
+	r := attach(t, rewritetest.Source(t), &Invoice{Data: []byte(testCII)})
+	cat := r.GetMeta().Catalog
+
+	dc := &xmp.DublinCore{}
+	if err := cat.Metadata.Data.Get(dc); err != nil || dc.Title.Default.V != rewritetest.Title {
+		t.Errorf("title = %q, %v", dc.Title.Default.V, err)
+	}
+	if cat.Lang != language.German || cat.AA == nil || cat.Extensions == nil || cat.Outlines == 0 {
+		t.Errorf("catalog entries lost: %+v", cat)
+	}
+	af, err := pdf.NewCursor(r).Array(cat.AF)
+	if err != nil || len(af) != 2 {
+		t.Errorf("AF = %v, %v", af, err)
+	}
+}
+
+func TestNoInvoice(t *testing.T) {
+	_, err := Extract(makeSource(t))
+	if !errors.Is(err, ErrNoInvoice) {
+		t.Errorf("got %v, want ErrNoInvoice", err)
+	}
+}
+
+func TestProfileFromGuideline(t *testing.T) {
+	cases := []struct {
+		id   string
+		want Profile
+	}{
+		{"urn:factur-x.eu:1p0:minimum", Minimum},
+		{"urn:factur-x.eu:1p0:basicwl", BasicWL},
+		{"urn:cen.eu:en16931:2017#compliant#urn:factur-x.eu:1p0:basic", Basic},
+		{"urn:cen.eu:en16931:2017", EN16931},
+		{"urn:cen.eu:en16931:2017#conformant#urn:factur-x.eu:1p0:extended", Extended},
+		{"urn:cen.eu:en16931:2017#compliant#urn:xeinkauf.de:kosit:xrechnung_3.0", XRechnung},
+		{"urn:ferd:CrossIndustryDocument:invoice:1p0:comfort", Comfort},
+		{"urn:example:unknown", ""},
+	}
+	for _, c := range cases {
+		if got := profileFromGuideline(c.id); got != c.want {
+			t.Errorf("%s: got %q, want %q", c.id, got, c.want)
+		}
+	}
+}
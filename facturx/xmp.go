@@ -0,0 +1,189 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package facturx
+
+import (
+	"encoding/xml"
+	"maps"
+
+	"seehuhn.de/go/xmp"
+
+	"seehuhn.de/go/pdf"
+)
+
+// XMP namespaces for the invoice properties.
+const (
+	nsFacturX  = "urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#"
+	nsZUGFeRD2 = "urn:zugferd:pdfa:CrossIndustryDocument:invoice:2p0#"
+	nsZUGFeRD1 = "urn:ferd:pdfa:CrossIndustryDocument:invoice:1p0#"
+)
+
+// extensionSchemas is the XMP property which declares PDF/A extension
+// schemas.
+var extensionSchemas = xml.Name{Space: nsExtension, Local: "schemas"}
+
+// XMP namespaces for PDF/A extension schema descriptions.
+const (
+	nsExtension = "http://www.aiim.org/pdfa/ns/extension/"
+	nsSchema    = "http://www.aiim.org/pdfa/ns/schema#"
+	nsProperty  = "http://www.aiim.org/pdfa/ns/property#"
+)
+
+// invoiceNamespaces lists the XMP namespaces of the invoice properties,
+// in the order in which they are tried by [Extract].
+var invoiceNamespaces = []struct {
+	ns       string
+	standard Standard
+}{
+	{nsFacturX, FacturX},
+	{nsZUGFeRD2, ZUGFeRD2},
+	{nsZUGFeRD1, ZUGFeRD1},
+}
+
+// fxProperties describes the properties of the fx: schema, for the PDF/A
+// extension schema declaration.
+var fxProperties = []struct {
+	name, description string
+}{
+	{"DocumentFileName", "The name of the embedded XML document"},
+	{"DocumentType", "The type of the hybrid document in capital letters, e.g. INVOICE or ORDER"},
+	{"Version", "The actual version of the standard applying to the embedded XML document"},
+	{"ConformanceLevel", "The conformance level of the embedded XML document"},
+}
+
+// updateMetadata returns the document metadata with the invoice properties
+// added.  The source metadata is not modified.
+func updateMetadata(src *pdf.MetadataStream, inv *Invoice) (*pdf.MetadataStream, error) {
+	p := xmp.NewPacket()
+	if src != nil && src.Data != nil {
+		maps.Copy(p.Properties, src.Data.Properties)
+		p.About = src.Data.About
+	}
+
+	// Drop the properties of earlier invoices, which may use one of the
+	// older namespaces.
+	for name := range p.Properties {
+		for _, ns := range invoiceNamespaces {
+			if name.Space == ns.ns {
+				delete(p.Properties, name)
+			}
+		}
+	}
+
+	// The prefixes are not preserved when a packet is read, and PDF/A
+	// validators expect the customary ones.
+	p.RegisterPrefix(nsFacturX, "fx")
+	p.RegisterPrefix(xmp.NSPDFAID, "pdfaid")
+	p.RegisterPrefix(nsExtension, "pdfaExtension")
+	p.RegisterPrefix(nsSchema, "pdfaSchema")
+	p.RegisterPrefix(nsProperty, "pdfaProperty")
+	for _, prop := range []struct {
+		name, value string
+	}{
+		{"DocumentFileName", inv.FileName},
+		{"DocumentType", inv.DocumentType},
+		{"Version", inv.Version},
+		{"ConformanceLevel", string(inv.Profile)},
+	} {
+		if err := p.SetValue(nsFacturX, prop.name, xmp.NewText(prop.value)); err != nil {
+			return nil, err
+		}
+	}
+
+	addExtensionSchema(p)
+
+	// Embedded files are only allowed in PDF/A-3 and later.
+	var id xmp.PDFAID
+	if err := p.Get(&id); err == nil && (id.Part.V == 1 || id.Part.V == 2) {
+		id.Part = xmp.NewInteger(3)
+		if err := p.Set(&id); err != nil {
+			return nil, err
+		}
+	}
+
+	return &pdf.MetadataStream{Data: p, Plaintext: true}, nil
+}
+
+// addExtensionSchema declares the fx: schema in the pdfaExtension:schemas
+// property, unless a declaration is already present.  PDF/A requires this
+// declaration for all schemas which are not predefined in XMP.
+func addExtensionSchema(p *xmp.Packet) {
+	schemas, _ := p.Properties[extensionSchemas].(xmp.RawArray)
+	nameURI := xml.Name{Space: nsSchema, Local: "namespaceURI"}
+	for _, elem := range schemas.Value {
+		s, ok := elem.(xmp.RawStruct)
+		if !ok {
+			continue
+		}
+		if uri, ok := s.Value[nameURI].(xmp.Text); ok && uri.V == nsFacturX {
+			return
+		}
+	}
+
+	props := xmp.RawArray{Kind: xmp.Ordered}
+	for _, prop := range fxProperties {
+		props.Value = append(props.Value, xmp.RawStruct{
+			Value: map[xml.Name]xmp.Raw{
+				{Space: nsProperty, Local: "name"}:        xmp.NewText(prop.name),
+				{Space: nsProperty, Local: "valueType"}:   xmp.NewText("Text"),
+				{Space: nsProperty, Local: "category"}:    xmp.NewText("external"),
+				{Space: nsProperty, Local: "description"}: xmp.NewText(prop.description),
+			},
+		})
+	}
+	schema := xmp.RawStruct{
+		Value: map[xml.Name]xmp.Raw{
+			{Space: nsSchema, Local: "schema"}:   xmp.NewText("Factur-X PDFA Extension Schema"),
+			nameURI:                              xmp.NewText(nsFacturX),
+			{Space: nsSchema, Local: "prefix"}:   xmp.NewText("fx"),
+			{Space: nsSchema, Local: "property"}: props,
+		},
+	}
+
+	schemas.Kind = xmp.Unordered
+	schemas.Value = append(schemas.Value, schema)
+	p.Properties[extensionSchemas] = schemas
+}
+
+// readMetadata returns the invoice properties from the document metadata.
+// The returned invoice has Standard set to [UnknownStandard] if the
+// metadata does not describe an invoice.
+func readMetadata(m *pdf.MetadataStream) *Invoice {
+	inv := &Invoice{}
+	if m == nil || m.Data == nil {
+		return inv
+	}
+	for _, ns := range invoiceNamespaces {
+		name, err := xmp.PacketGetValue[xmp.Text](m.Data, ns.ns, "DocumentFileName")
+		if err != nil {
+			continue
+		}
+		inv.Standard = ns.standard
+		inv.FileName = name.V
+		if v, err := xmp.PacketGetValue[xmp.Text](m.Data, ns.ns, "DocumentType"); err == nil {
+			inv.DocumentType = v.V
+		}
+		if v, err := xmp.PacketGetValue[xmp.Text](m.Data, ns.ns, "Version"); err == nil {
+			inv.Version = v.V
+		}
+		if v, err := xmp.PacketGetValue[xmp.Text](m.Data, ns.ns, "ConformanceLevel"); err == nil {
+			inv.Profile = Profile(v.V)
+		}
+		break
+	}
+	return inv
+}
@@ -72,7 +72,8 @@ type Specification struct {
 	FileNameUnix pdf.String
 
 	// AFRelationship (PDF 2.0) specifies the relationship between the
-	// referencing component and the associated file.
+	// referencing component and the associated file.  PDF/A-3 files use
+	// this entry from PDF 1.7 onwards.
 	//
 	// When writing file specifications, and empty name can be used as a
 	// shorthand for [RelationshipUnspecified].
@@ -289,12 +290,19 @@ func (spec *Specification) Embed(rm *pdf.EmbedHelper) (pdf.Native, error) {
 		}
 	}
 
-	if spec.Thumbnail != nil || spec.EncryptedPayload != nil || (spec.AFRelationship != "" && spec.AFRelationship != RelationshipUnspecified) {
+	if spec.Thumbnail != nil || spec.EncryptedPayload != nil {
 		if err := pdf.CheckVersion(rm.Out(), "file specification PDF 2.0 entries", pdf.V2_0); err != nil {
 			return nil, err
 		}
 	}
 
+	// PDF/A-3 (ISO 19005-3) uses AFRelationship in PDF 1.7 files.
+	if spec.AFRelationship != "" && spec.AFRelationship != RelationshipUnspecified {
+		if err := pdf.CheckVersion(rm.Out(), "file specification AFRelationship entry", pdf.V1_7); err != nil {
+			return nil, err
+		}
+	}
+
 	// Validate that F is present if DOS/Mac/Unix are all absent
 	if spec.FileName == "" && len(spec.FileNameDOS) == 0 && len(spec.FileNameMac) == 0 && len(spec.FileNameUnix) == 0 {
 		return nil, pdf.Errorf("file specification must have F entry if DOS, Mac, and Unix entries are all absent")
@@ -140,8 +140,10 @@ func (s *Stream) Embed(e *pdf.EmbedHelper) (pdf.Native, error) {
 		return nil, errors.New("CheckSum must be exactly 16 bytes when present")
 	}
 
+	// In encrypted documents, readers use the /Type entry to select the
+	// crypt filter for embedded files (/EFF).
 	dict := pdf.Dict{}
-	if e.Out().GetOptions().HasAny(pdf.OptDictTypes) {
+	if e.Out().GetOptions().HasAny(pdf.OptDictTypes) || e.Out().GetMeta().Encryption != nil {
 		dict["Type"] = pdf.Name("EmbeddedFile")
 	}
 
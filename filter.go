@@ -66,9 +66,9 @@ import (
 //
 // The Crypt filter is represented by three variants implementing
 // [CryptFilter]: [FilterCryptIdentity], [FilterCryptStandard], and
-// [FilterCryptNamed].  Of these, only [FilterCryptIdentity] is
-// implemented end-to-end; the other two round-trip the wire form but
-// do not yet support encoding or decoding.
+// [FilterCryptNamed].  Since the encryption key belongs to the document,
+// the latter two are only encoded and decoded by [Writer.OpenStream] and
+// [DecodeStream], not through the Filter interface.
 type Filter interface {
 	// Info returns the name and parameters of the filter,
 	// as they should be written to the PDF file.
@@ -829,7 +829,10 @@ func (f FilterJPX) Decode(_ Version, _ io.Reader, _ *membudget.Budget) (io.ReadC
 //
 // Per PDF spec §7.4.10, a Crypt filter must be the first entry in a
 // stream's /Filter array.  The library enforces this on read (via
-// [GetFilters]) and on write (via [Writer.OpenStream]).
+// [GetFilters]) and on write (via [Writer.OpenStream]).  A Crypt filter
+// overrides the document's default crypt filter for the stream;
+// [Writer.OpenStream] and [DecodeStream] apply the encryption, using the
+// crypt filters defined in the document's encryption dictionary.
 type CryptFilter interface {
 	Filter
 	isCryptFilter()
@@ -868,12 +871,11 @@ func (FilterCryptIdentity) Decode(_ Version, r io.Reader, _ *membudget.Budget) (
 }
 
 // FilterCryptStandard declares that a stream is encrypted using the
-// document's /StdCF crypt filter, with the file key used as-is (no
-// per-object Algorithm 1 key derivation, per PDF spec §7.4.10).
+// document's /StdCF crypt filter.  This is useful in documents where the
+// default crypt filter for streams is /Identity.
 //
-// Encoding and decoding via the filter interface are not yet supported;
-// the type exists so PDF files using /Crypt with /Name /StdCF survive a
-// read/write cycle.
+// The encryption is applied by [Writer.OpenStream] and [DecodeStream];
+// the Encode and Decode methods return an error.
 type FilterCryptStandard struct{}
 
 func (FilterCryptStandard) isCryptFilter() {}
@@ -888,25 +890,23 @@ func (FilterCryptStandard) Info(v Version) (Name, Dict, error) {
 
 // Encode implements the [Filter] interface.
 func (FilterCryptStandard) Encode(_ Version, _ io.WriteCloser) (io.WriteCloser, error) {
-	return nil, errors.New("FilterCryptStandard encoding not yet supported")
+	return nil, errors.New("FilterCryptStandard: encryption requires Writer.OpenStream")
 }
 
 // Decode implements the [Filter] interface.
 func (FilterCryptStandard) Decode(_ Version, _ io.Reader, _ *membudget.Budget) (io.ReadCloser, error) {
-	return asMalformedFilter(nil, errors.New("FilterCryptStandard decoding not yet supported"))
+	return asMalformedFilter(nil, errors.New("FilterCryptStandard: stream is not part of an encrypted document"))
 }
 
 // FilterCryptNamed declares that a stream is encrypted using a named
-// crypt filter from the document's /CF dictionary, with the file key
-// used as-is (no per-object Algorithm 1 key derivation, per PDF spec
-// §7.4.10).
+// crypt filter from the document's /CF dictionary.  When writing, the
+// crypt filter must be listed in [CryptFilters.Named].
 //
 // Name must not be empty, "Identity" (use [FilterCryptIdentity]
 // instead), or "StdCF" (use [FilterCryptStandard] instead).
 //
-// Encoding and decoding via the filter interface are not yet supported;
-// the type exists so PDF files using a named /Crypt filter survive a
-// read/write cycle.
+// The encryption is applied by [Writer.OpenStream] and [DecodeStream];
+// the Encode and Decode methods return an error.
 type FilterCryptNamed struct {
 	Name Name
 }
@@ -931,12 +931,12 @@ func (f FilterCryptNamed) Info(v Version) (Name, Dict, error) {
 
 // Encode implements the [Filter] interface.
 func (FilterCryptNamed) Encode(_ Version, _ io.WriteCloser) (io.WriteCloser, error) {
-	return nil, errors.New("FilterCryptNamed encoding not yet supported")
+	return nil, errors.New("FilterCryptNamed: encryption requires Writer.OpenStream")
 }
 
 // Decode implements the [Filter] interface.
 func (FilterCryptNamed) Decode(_ Version, _ io.Reader, _ *membudget.Budget) (io.ReadCloser, error) {
-	return asMalformedFilter(nil, errors.New("FilterCryptNamed decoding not yet supported"))
+	return asMalformedFilter(nil, errors.New("FilterCryptNamed: stream is not part of an encrypted document"))
 }
 
 // checkVersionV is the equivalent of [CheckVersion] for use
@@ -547,9 +547,9 @@ func TestCryptFilterPositionValidationOnWrite(t *testing.T) {
 	}
 }
 
-// TestFilterCryptStandardUnimplemented verifies that Encode/Decode return
-// clear errors for the not-yet-implemented variants.
-func TestFilterCryptStandardUnimplemented(t *testing.T) {
+// TestFilterCryptStandardStandalone verifies that Encode/Decode return
+// clear errors when used outside of Writer.OpenStream and DecodeStream.
+func TestFilterCryptStandardStandalone(t *testing.T) {
 	f := FilterCryptStandard{}
 	if _, err := f.Encode(V2_0, nil); err == nil {
 		t.Error("FilterCryptStandard.Encode: expected error, got nil")
@@ -559,9 +559,9 @@ func TestFilterCryptStandardUnimplemented(t *testing.T) {
 	}
 }
 
-// TestFilterCryptNamedUnimplemented verifies that Encode/Decode return
-// clear errors for FilterCryptNamed.
-func TestFilterCryptNamedUnimplemented(t *testing.T) {
+// TestFilterCryptNamedStandalone verifies that Encode/Decode return
+// clear errors for FilterCryptNamed outside of an encrypted document.
+func TestFilterCryptNamedStandalone(t *testing.T) {
 	f := FilterCryptNamed{Name: "MyCF"}
 	if _, err := f.Encode(V2_0, nil); err == nil {
 		t.Error("FilterCryptNamed.Encode: expected error, got nil")
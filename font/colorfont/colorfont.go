@@ -0,0 +1,217 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package colorfont
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"seehuhn.de/go/geom/rect"
+	"seehuhn.de/go/postscript/type1/names"
+
+	"seehuhn.de/go/sfnt"
+	"seehuhn.de/go/sfnt/glyph"
+	"seehuhn.de/go/sfnt/header"
+	"seehuhn.de/go/sfnt/parser"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/font/type3"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/content/builder"
+	"seehuhn.de/go/pdf/graphics/extgstate"
+)
+
+// Options controls how a colour font is converted.
+type Options struct {
+	// Palette selects the CPAL palette.  If the font has no palette
+	// with this index, the first palette is used.
+	Palette int
+
+	// Foreground is the colour for layers which the font draws in the
+	// text colour, and for glyphs which have no colour description.
+	// The default is black.
+	Foreground color.DeviceRGB
+}
+
+// OpenTypeFile loads a colour font from an OpenType or TrueType file
+// and converts it into a Type 3 font.
+func OpenTypeFile(fname string, opt *Options) (font.Layouter, error) {
+	data, err := os.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	return OpenType(data, opt)
+}
+
+// OpenType converts the colour font contained in an OpenType or TrueType
+// font file into a Type 3 font.
+func OpenType(data []byte, opt *Options) (font.Layouter, error) {
+	r := bytes.NewReader(data)
+	info, err := sfnt.Read(r, parser.NewBudget(int64(len(data))))
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := header.Read(r)
+	if err != nil {
+		return nil, err
+	}
+	if !dir.Has("COLR") {
+		return nil, errors.New("font has no COLR table")
+	}
+	colr, err := dir.ReadTableBytes(r, "COLR")
+	if err != nil {
+		return nil, err
+	}
+	var cpal []byte
+	if dir.Has("CPAL") {
+		cpal, err = dir.ReadTableBytes(r, "CPAL")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return New(info, colr, cpal, opt)
+}
+
+// New converts a colour font into a Type 3 font.
+// The arguments colr and cpal are the contents of the font's "COLR" and
+// "CPAL" tables.  If cpal is nil, all layers use the foreground colour.
+func New(info *sfnt.Font, colr, cpal []byte, opt *Options) (font.Layouter, error) {
+	F, err := Type3(info, colr, cpal, opt)
+	if err != nil {
+		return nil, err
+	}
+	return F.New()
+}
+
+// Type3 converts a colour font into a Type 3 font definition.
+// The arguments are the same as for [New].
+//
+// The Type 3 font has one glyph for each character in the font's
+// character map.  Glyph space units are the font design units.
+func Type3(info *sfnt.Font, colr, cpal []byte, opt *Options) (*type3.Font, error) {
+	if opt == nil {
+		opt = &Options{}
+	}
+
+	colorGlyphs, err := decodeCOLR(colr)
+	if err != nil {
+		return nil, err
+	}
+	var palette []paletteEntry
+	if cpal != nil {
+		palette, err = decodeCPAL(cpal, opt.Palette)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	cmap, err := info.CMapTable.GetBest()
+	if err != nil {
+		return nil, fmt.Errorf("colour font: %w", err)
+	}
+
+	fontBBox := info.FontBBox()
+	bbox := rect.Rect{
+		LLx: float64(fontBBox.LLx),
+		LLy: float64(fontBBox.LLy),
+		URx: float64(fontBBox.URx),
+		URy: float64(fontBBox.URy),
+	}
+
+	F := &type3.Font{
+		Glyphs: []*type3.Glyph{
+			{}, // .notdef
+		},
+		PostScriptName:     info.FontName,
+		FontMatrix:         info.FontMatrix,
+		FontFamily:         info.FamilyName,
+		FontStretch:        info.Width,
+		FontWeight:         info.Weight,
+		IsFixedPitch:       info.IsFixedPitch(),
+		IsSerif:            info.IsSerif,
+		IsScript:           info.IsScript,
+		ItalicAngle:        info.ItalicAngle,
+		Ascent:             float64(info.Ascent),
+		Descent:            float64(info.Descent),
+		Leading:            float64(info.Ascent - info.Descent + info.LineGap),
+		CapHeight:          float64(info.CapHeight),
+		XHeight:            float64(info.XHeight),
+		UnderlinePosition:  float64(info.UnderlinePosition),
+		UnderlineThickness: float64(info.UnderlineThickness),
+		AlwaysToUnicode:    true,
+	}
+
+	low, high := cmap.CodeRange()
+	for code := low; code <= high; code++ {
+		gid := cmap.Lookup(code)
+		if gid == 0 || int(gid) >= info.NumGlyphs() {
+			continue
+		}
+
+		r := &renderer{
+			b:       builder.New(content.Glyph, nil, pdf.V1_7),
+			info:    info,
+			glyphs:  colorGlyphs,
+			palette: palette,
+			fg:      opt.Foreground,
+			clip:    bbox,
+			active:  make(map[glyph.ID]bool),
+			alphaGS: make(map[float64]*extgstate.ExtGState),
+			blendGS: make(map[pdf.Name]*extgstate.ExtGState),
+		}
+		r.b.Type3ColoredGlyph(info.GlyphWidth(gid), 0)
+		if p, ok := colorGlyphs[gid]; ok {
+			r.active[gid] = true
+			r.draw(p)
+		} else if !info.Outlines.IsBlank(gid) {
+			r.b.SetFillColor(opt.Foreground)
+			r.path(info.Outlines.Path(gid).ToCubic())
+			r.b.Fill()
+		}
+		stream, err := r.b.Harvest()
+		if err != nil {
+			return nil, fmt.Errorf("glyph %d: %w", gid, err)
+		}
+
+		g := &type3.Glyph{
+			Name:    glyphName(code, info.FontName),
+			Content: stream,
+		}
+		if len(r.b.Resources.ExtGState) > 0 || len(r.b.Resources.Shading) > 0 {
+			g.Resources = r.b.Resources
+		}
+		F.Glyphs = append(F.Glyphs, g)
+	}
+
+	return F, nil
+}
+
+// glyphName returns a glyph name which maps back to the given character.
+func glyphName(r rune, fontName string) string {
+	name := names.FromUnicode(string(r))
+	if !slices.Equal([]rune(names.ToUnicode(name, fontName)), []rune{r}) {
+		name = fmt.Sprintf("u%04X", r)
+	}
+	return name
+}
@@ -0,0 +1,271 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package colorfont
+
+import (
+	"encoding/binary"
+	"math"
+	"slices"
+	"testing"
+
+	"seehuhn.de/go/geom/rect"
+
+	"seehuhn.de/go/sfnt/glyph"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font/dict"
+	"seehuhn.de/go/pdf/font/type3"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/content/builder"
+	"seehuhn.de/go/pdf/graphics/extract"
+	"seehuhn.de/go/pdf/graphics/shading"
+	"seehuhn.de/go/pdf/internal/debug/makefont"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+)
+
+// testTables returns COLR and CPAL tables where "A" has two version 0
+// layers, "B" is filled with a linear gradient and "C" is a shifted copy
+// of "A".
+func testTables(gidA, gidB, gidC glyph.ID) (colr, cpal []byte) {
+	be := binary.BigEndian
+
+	// palette: opaque red, half-transparent blue
+	cpal = be.AppendUint16(nil, 0)      // version
+	cpal = be.AppendUint16(cpal, 2)     // numPaletteEntries
+	cpal = be.AppendUint16(cpal, 1)     // numPalettes
+	cpal = be.AppendUint16(cpal, 2)     // numColorRecords
+	cpal = be.AppendUint32(cpal, 14)    // colorRecordsArrayOffset
+	cpal = be.AppendUint16(cpal, 0)     // colorRecordIndices[0]
+	cpal = append(cpal, 0, 0, 255, 255) // red, BGRA
+	cpal = append(cpal, 255, 0, 0, 128) // blue, BGRA
+
+	colr = be.AppendUint16(nil, 1)           // version
+	colr = be.AppendUint16(colr, 1)          // numBaseGlyphRecords
+	colr = be.AppendUint32(colr, 34)         // baseGlyphRecordsOffset
+	colr = be.AppendUint32(colr, 40)         // layerRecordsOffset
+	colr = be.AppendUint16(colr, 2)          // numLayerRecords
+	colr = be.AppendUint32(colr, 48)         // baseGlyphListOffset
+	colr = append(colr, make([]byte, 16)...) // layer, clip, var offsets
+
+	// version 0 glyph "A"
+	colr = be.AppendUint16(colr, uint16(gidA))
+	colr = be.AppendUint16(colr, 0)
+	colr = be.AppendUint16(colr, 2)
+	colr = be.AppendUint16(colr, uint16(gidA))
+	colr = be.AppendUint16(colr, 0)
+	colr = be.AppendUint16(colr, uint16(gidA))
+	colr = be.AppendUint16(colr, foregroundIndex)
+
+	// BaseGlyphList
+	colr = be.AppendUint32(colr, 2)
+	colr = be.AppendUint16(colr, uint16(gidB))
+	colr = be.AppendUint32(colr, 16)
+	colr = be.AppendUint16(colr, uint16(gidC))
+	colr = be.AppendUint32(colr, 16+6+16+15)
+
+	// "B": PaintGlyph -> PaintLinearGradient -> ColorLine
+	colr = append(colr, 10, 0, 0, 6)
+	colr = be.AppendUint16(colr, uint16(gidB))
+	colr = append(colr, 4, 0, 0, 16)
+	for _, x := range []int16{0, 0, 1000, 0, 0, 1000} {
+		colr = be.AppendUint16(colr, uint16(x))
+	}
+	colr = append(colr, extendPad)
+	colr = be.AppendUint16(colr, 2)
+	colr = append(colr, 0, 0, 0, 0, 0x40, 0)    // offset 0, red, alpha 1
+	colr = append(colr, 0x40, 0, 0, 1, 0x40, 0) // offset 1, blue, alpha 1
+
+	// "C": PaintTranslate -> PaintColrGlyph
+	colr = append(colr, 14, 0, 0, 8)
+	colr = be.AppendUint16(colr, 100)
+	colr = be.AppendUint16(colr, 0)
+	colr = append(colr, 11)
+	colr = be.AppendUint16(colr, uint16(gidA))
+
+	return colr, cpal
+}
+
+func TestType3(t *testing.T) {
+	info := makefont.TrueType()
+	cmap, err := info.CMapTable.GetBest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	gidA, gidB, gidC := cmap.Lookup('A'), cmap.Lookup('B'), cmap.Lookup('C')
+	colr, cpal := testTables(gidA, gidB, gidC)
+
+	F, err := Type3(info, colr, cpal, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	glyphs := make(map[string]*type3.Glyph)
+	for _, g := range F.Glyphs {
+		glyphs[g.Name] = g
+	}
+	ops := func(name string) []content.OpName {
+		g := glyphs[name]
+		if g == nil {
+			t.Fatalf("glyph %q not found", name)
+		}
+		var res []content.OpName
+		for _, op := range g.Content.(*content.Operators).Ops {
+			switch op.Name {
+			case content.OpMoveTo, content.OpLineTo, content.OpCurveTo, content.OpClosePath:
+				continue // the outline details depend on the font
+			}
+			res = append(res, op.Name)
+		}
+		return res
+	}
+
+	wantA := []content.OpName{"d0", "rg", "f", "rg", "f"}
+	if got := ops("A"); !slices.Equal(got, wantA) {
+		t.Errorf("A: got %v, want %v", got, wantA)
+	}
+	wantB := []content.OpName{"d0", "q", "W", "n", "q", "gs", "re", "W", "n", "sh", "Q", "Q"}
+	if got := ops("B"); !slices.Equal(got, wantB) {
+		t.Errorf("B: got %v, want %v", got, wantB)
+	}
+	wantC := []content.OpName{"d0", "q", "cm", "rg", "f", "rg", "f", "Q"}
+	if got := ops("C"); !slices.Equal(got, wantC) {
+		t.Errorf("C: got %v, want %v", got, wantC)
+	}
+	if glyphs["B"].Resources == nil || len(glyphs["B"].Resources.Shading) != 1 {
+		t.Error("B: missing shading resource")
+	}
+
+	// glyphs without a colour description use the foreground colour
+	if got := ops("D"); !slices.Equal(got, []content.OpName{"d0", "rg", "f"}) {
+		t.Errorf("D: got %v", got)
+	}
+}
+
+func TestEmbed(t *testing.T) {
+	info := makefont.TrueType()
+	cmap, err := info.CMapTable.GetBest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	colr, cpal := testTables(cmap.Lookup('A'), cmap.Lookup('B'), cmap.Lookup('C'))
+	F, err := New(info, colr, cpal, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, _ := memfile.NewPDFWriter(pdf.V1_7, nil)
+	rm := pdf.NewResourceManager(w)
+	ref, err := rm.Embed(F)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, g := range F.Layout(nil, 12, "ABC").Seq {
+		F.Encode(g.GID, g.Text)
+	}
+	if err := rm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	obj, err := extract.Dict(pdf.CursorAt(pdf.NewExtractor(w), nil), ref, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, ok := obj.(*dict.Type3)
+	if !ok {
+		t.Fatalf("wrong font dictionary type: %T", obj)
+	}
+	for _, name := range []pdf.Name{"A", "B", "C"} {
+		if d.CharProcs[name] == nil {
+			t.Errorf("missing glyph %q", name)
+		}
+	}
+	if d.ToUnicode == nil {
+		t.Error("missing ToUnicode map")
+	}
+}
+
+// TestSweep checks the colours of the function used to draw a sweep
+// gradient with a hard colour change halfway round.
+func TestSweep(t *testing.T) {
+	r := &renderer{
+		b:    builder.New(content.Glyph, nil, pdf.V1_7),
+		clip: rect.Rect{LLx: -10, LLy: -10, URx: 10, URy: 10},
+		palette: []paletteEntry{
+			{rgb: color.DeviceRGB{1, 0, 0}, alpha: 1},
+			{rgb: color.DeviceRGB{0, 0, 1}, alpha: 1},
+			{rgb: color.DeviceRGB{0, 1, 0}, alpha: 1},
+		},
+	}
+	r.b.Type3ColoredGlyph(0, 0)
+	r.draw(&paintSweep{
+		line: &colorLine{stops: []colorStop{
+			{0, colorIndex{0, 1}},
+			{0.5, colorIndex{1, 1}},
+			{0.5, colorIndex{2, 1}},
+			{1, colorIndex{0, 1}},
+		}},
+		start: 0,
+		end:   360,
+	})
+	if r.b.Err != nil {
+		t.Fatal(r.b.Err)
+	}
+
+	var F pdf.Function
+	for _, sh := range r.b.Resources.Shading {
+		F = sh.(*shading.Type1).F
+	}
+	cases := []struct {
+		x, y float64
+		want color.DeviceRGB
+	}{
+		{1, 0, color.DeviceRGB{1, 0, 0}},
+		{0, 1, color.DeviceRGB{0.5, 0, 0.5}},
+		{-1, 0.001, color.DeviceRGB{0, 0, 1}},
+		{-1, -0.001, color.DeviceRGB{0, 1, 0}},
+		{0, -1, color.DeviceRGB{0.5, 0.5, 0}},
+	}
+	out := make([]float64, 3)
+	for _, c := range cases {
+		F.Apply(out, c.x, c.y)
+		for i := range out {
+			if math.Abs(out[i]-c.want[i]) > 0.01 {
+				t.Errorf("(%g, %g): got %v, want %v", c.x, c.y, out, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestDecodeCPAL(t *testing.T) {
+	_, cpal := testTables(1, 2, 3)
+	entries, err := decodeCPAL(cpal, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].rgb[0] != 1 || entries[1].rgb[2] != 1 {
+		t.Errorf("wrong palette: %v", entries)
+	}
+	if entries[1].alpha != 128.0/255 {
+		t.Errorf("wrong alpha: %g", entries[1].alpha)
+	}
+
+	if _, err := decodeCPAL(cpal[:13], 0); err == nil {
+		t.Error("truncated table accepted")
+	}
+}
@@ -0,0 +1,561 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package colorfont
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/vec"
+
+	"seehuhn.de/go/sfnt/glyph"
+)
+
+// paint is a node in the paint graph of a colour glyph.
+// This is one of the paint* types below.
+type paint any
+
+// paintLayers paints its layers one after the other, bottom first.
+type paintLayers struct {
+	layers []paint
+}
+
+// paintSolid fills the current clip region with a palette colour.
+type paintSolid struct {
+	color colorIndex
+}
+
+// paintLinear fills the current clip region with a linear gradient.
+// The colour line runs from P0 to P1, the lines of constant colour
+// are parallel to P0P2.
+type paintLinear struct {
+	line       *colorLine
+	p0, p1, p2 vec.Vec2
+}
+
+// paintRadial fills the current clip region with a gradient between
+// two circles.
+type paintRadial struct {
+	line   *colorLine
+	c0, c1 vec.Vec2
+	r0, r1 float64
+}
+
+// paintSweep fills the current clip region with a gradient which varies
+// with the angle around a centre point.  Angles are in degrees,
+// counterclockwise from the positive x-axis.
+type paintSweep struct {
+	line       *colorLine
+	center     vec.Vec2
+	start, end float64
+}
+
+// paintGlyph clips to the outline of a glyph and then paints its child.
+type paintGlyph struct {
+	gid   glyph.ID
+	child paint
+}
+
+// paintColrGlyph paints the colour glyph with the given ID.
+type paintColrGlyph struct {
+	gid glyph.ID
+}
+
+// paintTransform paints its child with the transformation M applied.
+type paintTransform struct {
+	M     matrix.Matrix
+	child paint
+}
+
+// paintComposite combines the source and backdrop paints
+// using one of the COLR composite modes.
+type paintComposite struct {
+	mode     uint8
+	source   paint
+	backdrop paint
+}
+
+// colorIndex refers to a palette colour, with an additional alpha factor.
+type colorIndex struct {
+	index uint16
+	alpha float64
+}
+
+// colorLine describes the colours of a gradient.
+type colorLine struct {
+	extend uint8
+	stops  []colorStop
+}
+
+// colorStop is one stop of a colour line.
+type colorStop struct {
+	offset float64
+	color  colorIndex
+}
+
+// Values of the extend field in a colour line.
+const (
+	extendPad     = 0
+	extendRepeat  = 1
+	extendReflect = 2
+)
+
+// Values of the composite mode in a PaintComposite table.
+const (
+	compositeClear    = 0
+	compositeSrc      = 1
+	compositeDest     = 2
+	compositeDestOver = 4
+)
+
+// maxPaintDepth limits the nesting depth of paint graphs.
+const maxPaintDepth = 64
+
+// decodeCOLR reads the colour glyphs from a COLR table.
+// Both version 0 layer records and the version 1 paint graphs are supported.
+// Where a glyph has both, the version 1 description is used.
+//
+// Variation data is ignored; variable paints use their default values.
+func decodeCOLR(data []byte) (map[glyph.ID]paint, error) {
+	d := &colrDecoder{
+		data:   data,
+		memo:   make(map[int]paint),
+		active: make(map[int]bool),
+	}
+
+	version, err := d.u16(0)
+	if err != nil {
+		return nil, err
+	}
+	numBase, _ := d.u16(2)
+	baseOffset, _ := d.u32(4)
+	layerOffset, _ := d.u32(8)
+	numLayerRecords, err := d.u16(12)
+	if err != nil {
+		return nil, err
+	}
+
+	res := make(map[glyph.ID]paint)
+	for i := range int(numBase) {
+		rec := int(baseOffset) + 6*i
+		gid, _ := d.u16(rec)
+		first, _ := d.u16(rec + 2)
+		n, err := d.u16(rec + 4)
+		if err != nil {
+			return nil, err
+		}
+		if int(first)+int(n) > int(numLayerRecords) {
+			return nil, errMalformedCOLR
+		}
+		layers := make([]paint, n)
+		for j := range layers {
+			rec := int(layerOffset) + 4*(int(first)+j)
+			layerGID, _ := d.u16(rec)
+			index, err := d.u16(rec + 2)
+			if err != nil {
+				return nil, err
+			}
+			layers[j] = &paintGlyph{
+				gid:   glyph.ID(layerGID),
+				child: &paintSolid{color: colorIndex{index: index, alpha: 1}},
+			}
+		}
+		res[glyph.ID(gid)] = &paintLayers{layers: layers}
+	}
+
+	if version == 0 {
+		return res, nil
+	}
+
+	baseList, _ := d.u32(14)
+	layerList, err := d.u32(18)
+	if err != nil {
+		return nil, err
+	}
+	if layerList != 0 {
+		n, err := d.u32(int(layerList))
+		if err != nil {
+			return nil, err
+		}
+		d.layerList = int(layerList)
+		d.numLayers = int(n)
+	}
+	if baseList == 0 {
+		return res, nil
+	}
+	numRecords, err := d.u32(int(baseList))
+	if err != nil {
+		return nil, err
+	}
+	for i := range int(numRecords) {
+		rec := int(baseList) + 4 + 6*i
+		gid, _ := d.u16(rec)
+		offs, err := d.u32(rec + 2)
+		if err != nil {
+			return nil, err
+		}
+		p, err := d.paint(int(baseList)+int(offs), 0)
+		if err != nil {
+			return nil, err
+		}
+		res[glyph.ID(gid)] = p
+	}
+	return res, nil
+}
+
+// colrDecoder reads the paint graph of a COLR version 1 table.
+type colrDecoder struct {
+	data []byte
+
+	layerList int
+	numLayers int
+
+	memo   map[int]paint
+	active map[int]bool
+}
+
+// paint decodes the paint table at the given offset.
+// Paint tables may be shared between glyphs, so the results are memoized.
+func (d *colrDecoder) paint(pos, depth int) (paint, error) {
+	if p, ok := d.memo[pos]; ok {
+		return p, nil
+	}
+	if depth > maxPaintDepth || d.active[pos] {
+		return nil, errors.New("COLR: cyclic or too deeply nested paint graph")
+	}
+	d.active[pos] = true
+	defer delete(d.active, pos)
+
+	format, err := d.u8(pos)
+	if err != nil {
+		return nil, err
+	}
+	isVar := format >= 3 && format <= 9 && format%2 == 1 ||
+		format >= 13 && format <= 31 && format%2 == 1
+
+	// child decodes the paint referenced by the 24-bit offset at pos+delta.
+	child := func(delta int) (paint, error) {
+		offs, err := d.u24(pos + delta)
+		if err != nil {
+			return nil, err
+		}
+		return d.paint(pos+int(offs), depth+1)
+	}
+	// transformed decodes the child at pos+1 and applies M to it.
+	transformed := func(M matrix.Matrix) (paint, error) {
+		c, err := child(1)
+		if err != nil {
+			return nil, err
+		}
+		return &paintTransform{M: M, child: c}, nil
+	}
+	// around conjugates M with a translation to the centre stored at pos+delta.
+	around := func(M matrix.Matrix, delta int) matrix.Matrix {
+		cx := d.fword(pos + delta)
+		cy := d.fword(pos + delta + 2)
+		return matrix.Translate(-cx, -cy).Mul(M).Mul(matrix.Translate(cx, cy))
+	}
+
+	var p paint
+	switch format {
+	case 1: // PaintColrLayers
+		n, _ := d.u8(pos + 1)
+		first, err := d.u32(pos + 2)
+		if err != nil {
+			return nil, err
+		}
+		if int(first)+int(n) > d.numLayers {
+			return nil, errMalformedCOLR
+		}
+		layers := make([]paint, n)
+		for i := range layers {
+			offs, err := d.u32(d.layerList + 4 + 4*(int(first)+i))
+			if err != nil {
+				return nil, err
+			}
+			layers[i], err = d.paint(d.layerList+int(offs), depth+1)
+			if err != nil {
+				return nil, err
+			}
+		}
+		p = &paintLayers{layers: layers}
+
+	case 2, 3: // PaintSolid
+		if err := d.need(pos, 5); err != nil {
+			return nil, err
+		}
+		index, _ := d.u16(pos + 1)
+		p = &paintSolid{color: colorIndex{index: index, alpha: d.f2dot14(pos + 3)}}
+
+	case 4, 5: // PaintLinearGradient
+		line, err := d.colorLine(pos, isVar)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.need(pos+4, 12); err != nil {
+			return nil, err
+		}
+		p = &paintLinear{
+			line: line,
+			p0:   vec.Vec2{X: d.fword(pos + 4), Y: d.fword(pos + 6)},
+			p1:   vec.Vec2{X: d.fword(pos + 8), Y: d.fword(pos + 10)},
+			p2:   vec.Vec2{X: d.fword(pos + 12), Y: d.fword(pos + 14)},
+		}
+
+	case 6, 7: // PaintRadialGradient
+		line, err := d.colorLine(pos, isVar)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.need(pos+4, 12); err != nil {
+			return nil, err
+		}
+		p = &paintRadial{
+			line: line,
+			c0:   vec.Vec2{X: d.fword(pos + 4), Y: d.fword(pos + 6)},
+			r0:   d.ufword(pos + 8),
+			c1:   vec.Vec2{X: d.fword(pos + 10), Y: d.fword(pos + 12)},
+			r1:   d.ufword(pos + 14),
+		}
+
+	case 8, 9: // PaintSweepGradient
+		line, err := d.colorLine(pos, isVar)
+		if err != nil {
+			return nil, err
+		}
+		if err := d.need(pos+4, 8); err != nil {
+			return nil, err
+		}
+		p = &paintSweep{
+			line:   line,
+			center: vec.Vec2{X: d.fword(pos + 4), Y: d.fword(pos + 6)},
+			start:  180 * d.f2dot14(pos+8),
+			end:    180 * d.f2dot14(pos+10),
+		}
+
+	case 10: // PaintGlyph
+		gid, err := d.u16(pos + 4)
+		if err != nil {
+			return nil, err
+		}
+		c, err := child(1)
+		if err != nil {
+			return nil, err
+		}
+		p = &paintGlyph{gid: glyph.ID(gid), child: c}
+
+	case 11: // PaintColrGlyph
+		gid, err := d.u16(pos + 1)
+		if err != nil {
+			return nil, err
+		}
+		p = &paintColrGlyph{gid: glyph.ID(gid)}
+
+	case 12, 13: // PaintTransform
+		offs, err := d.u24(pos + 4)
+		if err != nil {
+			return nil, err
+		}
+		t := pos + int(offs)
+		if err := d.need(t, 24); err != nil {
+			return nil, err
+		}
+		var M matrix.Matrix
+		for i := range M {
+			M[i] = d.fixed(t + 4*i)
+		}
+		p, err = transformed(M)
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		if format < 14 || format > 32 {
+			return nil, errMalformedCOLR
+		}
+		if err := d.need(pos, formatSize[format]); err != nil {
+			return nil, err
+		}
+		var M matrix.Matrix
+		switch format {
+		case 14, 15: // PaintTranslate
+			M = matrix.Translate(d.fword(pos+4), d.fword(pos+6))
+		case 16, 17: // PaintScale
+			M = matrix.Scale(d.f2dot14(pos+4), d.f2dot14(pos+6))
+		case 18, 19: // PaintScaleAroundCenter
+			M = around(matrix.Scale(d.f2dot14(pos+4), d.f2dot14(pos+6)), 8)
+		case 20, 21: // PaintScaleUniform
+			s := d.f2dot14(pos + 4)
+			M = matrix.Scale(s, s)
+		case 22, 23: // PaintScaleUniformAroundCenter
+			s := d.f2dot14(pos + 4)
+			M = around(matrix.Scale(s, s), 6)
+		case 24, 25: // PaintRotate
+			M = matrix.RotateDeg(180 * d.f2dot14(pos+4))
+		case 26, 27: // PaintRotateAroundCenter
+			M = around(matrix.RotateDeg(180*d.f2dot14(pos+4)), 6)
+		case 28, 29: // PaintSkew
+			M = skew(d.f2dot14(pos+4), d.f2dot14(pos+6))
+		case 30, 31: // PaintSkewAroundCenter
+			M = around(skew(d.f2dot14(pos+4), d.f2dot14(pos+6)), 8)
+		case 32: // PaintComposite
+			src, err := child(1)
+			if err != nil {
+				return nil, err
+			}
+			backdrop, err := child(5)
+			if err != nil {
+				return nil, err
+			}
+			p = &paintComposite{
+				mode:     d.data[pos+4],
+				source:   src,
+				backdrop: backdrop,
+			}
+		}
+		if p == nil {
+			p, err = transformed(M)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	d.memo[pos] = p
+	return p, nil
+}
+
+// formatSize gives the minimum length of the paint tables which
+// only hold fixed-size fields.
+var formatSize = [33]int{
+	14: 8, 15: 8,
+	16: 8, 17: 8,
+	18: 12, 19: 12,
+	20: 6, 21: 6,
+	22: 10, 23: 10,
+	24: 6, 25: 6,
+	26: 10, 27: 10,
+	28: 8, 29: 8,
+	30: 12, 31: 12,
+	32: 8,
+}
+
+// skew returns the transformation for a PaintSkew table.
+// The angles are given in units of 180 degrees.
+func skew(xAngle, yAngle float64) matrix.Matrix {
+	return matrix.Matrix{
+		1, math.Tan(yAngle * math.Pi),
+		-math.Tan(xAngle * math.Pi), 1,
+		0, 0,
+	}
+}
+
+// colorLine decodes the colour line referenced by the gradient paint at pos.
+func (d *colrDecoder) colorLine(pos int, isVar bool) (*colorLine, error) {
+	offs, err := d.u24(pos + 1)
+	if err != nil {
+		return nil, err
+	}
+	pos += int(offs)
+
+	extend, _ := d.u8(pos)
+	n, err := d.u16(pos + 1)
+	if err != nil {
+		return nil, err
+	}
+	size := 6
+	if isVar {
+		size = 10
+	}
+	if err := d.need(pos+3, size*int(n)); err != nil {
+		return nil, err
+	}
+	line := &colorLine{
+		extend: extend,
+		stops:  make([]colorStop, n),
+	}
+	for i := range line.stops {
+		stop := pos + 3 + size*i
+		line.stops[i] = colorStop{
+			offset: d.f2dot14(stop),
+			color: colorIndex{
+				index: binary.BigEndian.Uint16(d.data[stop+2:]),
+				alpha: d.f2dot14(stop + 4),
+			},
+		}
+	}
+	return line, nil
+}
+
+func (d *colrDecoder) need(pos, n int) error {
+	if pos < 0 || n < 0 || pos+n > len(d.data) {
+		return errMalformedCOLR
+	}
+	return nil
+}
+
+func (d *colrDecoder) u8(pos int) (uint8, error) {
+	if err := d.need(pos, 1); err != nil {
+		return 0, err
+	}
+	return d.data[pos], nil
+}
+
+func (d *colrDecoder) u16(pos int) (uint16, error) {
+	if err := d.need(pos, 2); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint16(d.data[pos:]), nil
+}
+
+func (d *colrDecoder) u24(pos int) (uint32, error) {
+	if err := d.need(pos, 3); err != nil {
+		return 0, err
+	}
+	b := d.data[pos:]
+	return uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2]), nil
+}
+
+func (d *colrDecoder) u32(pos int) (uint32, error) {
+	if err := d.need(pos, 4); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint32(d.data[pos:]), nil
+}
+
+// The following readers are only used after the caller has checked
+// the bounds with need.
+
+func (d *colrDecoder) fword(pos int) float64 {
+	return float64(int16(binary.BigEndian.Uint16(d.data[pos:])))
+}
+
+func (d *colrDecoder) ufword(pos int) float64 {
+	return float64(binary.BigEndian.Uint16(d.data[pos:]))
+}
+
+func (d *colrDecoder) f2dot14(pos int) float64 {
+	return float64(int16(binary.BigEndian.Uint16(d.data[pos:]))) / (1 << 14)
+}
+
+func (d *colrDecoder) fixed(pos int) float64 {
+	return float64(int32(binary.BigEndian.Uint32(d.data[pos:]))) / (1 << 16)
+}
+
+var errMalformedCOLR = errors.New("malformed COLR table")
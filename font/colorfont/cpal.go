@@ -0,0 +1,73 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package colorfont
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"seehuhn.de/go/pdf/graphics/color"
+)
+
+// foregroundIndex is the palette index which COLR uses for the text colour.
+const foregroundIndex = 0xFFFF
+
+// paletteEntry is one colour of a CPAL palette.
+type paletteEntry struct {
+	rgb   color.DeviceRGB
+	alpha float64
+}
+
+// decodeCPAL returns the colours of the given palette from a CPAL table.
+// Palette indices which are out of range select palette 0.
+func decodeCPAL(data []byte, palette int) ([]paletteEntry, error) {
+	if len(data) < 12 {
+		return nil, errMalformedCPAL
+	}
+	numEntries := int(binary.BigEndian.Uint16(data[2:]))
+	numPalettes := int(binary.BigEndian.Uint16(data[4:]))
+	numRecords := int(binary.BigEndian.Uint16(data[6:]))
+	recordsOffset := int(binary.BigEndian.Uint32(data[8:]))
+	if numPalettes == 0 || len(data) < 12+2*numPalettes {
+		return nil, errMalformedCPAL
+	}
+	if palette < 0 || palette >= numPalettes {
+		palette = 0
+	}
+
+	first := int(binary.BigEndian.Uint16(data[12+2*palette:]))
+	if first+numEntries > numRecords || recordsOffset+4*numRecords > len(data) {
+		return nil, errMalformedCPAL
+	}
+
+	entries := make([]paletteEntry, numEntries)
+	for i := range entries {
+		rec := data[recordsOffset+4*(first+i):]
+		// colour records are stored in BGRA order
+		entries[i] = paletteEntry{
+			rgb: color.DeviceRGB{
+				float64(rec[2]) / 255,
+				float64(rec[1]) / 255,
+				float64(rec[0]) / 255,
+			},
+			alpha: float64(rec[3]) / 255,
+		}
+	}
+	return entries, nil
+}
+
+var errMalformedCPAL = errors.New("malformed CPAL table")
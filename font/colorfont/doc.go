@@ -0,0 +1,47 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package colorfont embeds OpenType colour fonts, such as emoji fonts,
+// as Type 3 fonts.
+//
+// PDF font formats have no notion of colour glyphs, so embedding a colour
+// font as a TrueType or OpenType font only shows the monochrome fallback
+// outlines.  This package reads the colour glyph descriptions from the
+// "COLR" and "CPAL" tables and converts every glyph into a Type 3 glyph
+// procedure which paints the coloured layers:
+//
+//   - COLR version 0 layers are filled with their palette colours.
+//   - COLR version 1 paint graphs are supported, including transformations
+//     and nested colour glyphs.  Linear and radial gradients become axial
+//     and radial shadings, sweep gradients become function-based shadings.
+//
+// Some COLR features have no direct PDF equivalent and are approximated:
+// shadings cannot vary the opacity, so a gradient uses the mean opacity of
+// its colour stops; radial and sweep gradients are always padded, rather
+// than repeated or reflected; composite modes other than "source over" and
+// the blend modes are drawn by painting the source and/or the backdrop.
+// Variation data is ignored.
+//
+// Glyphs which are only available as SVG documents, in the "SVG " table,
+// are not supported.  These glyphs are drawn using their outlines, in the
+// foreground colour.
+//
+// The glyph names of the Type 3 font are derived from the character codes,
+// so that the font has a ToUnicode map and text remains extractable.
+// Since Type 3 fonts are simple fonts, at most 256 different glyphs can be
+// used with one font instance, and glyph sequences which require OpenType
+// layout features, like flags or emoji ZWJ sequences, are not composed.
+package colorfont
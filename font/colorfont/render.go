@@ -0,0 +1,588 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package colorfont
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"seehuhn.de/go/geom/path"
+	"seehuhn.de/go/geom/rect"
+	"seehuhn.de/go/geom/vec"
+
+	"seehuhn.de/go/sfnt"
+	"seehuhn.de/go/sfnt/glyph"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/function"
+	"seehuhn.de/go/pdf/graphics"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/content/builder"
+	"seehuhn.de/go/pdf/graphics/extgstate"
+	"seehuhn.de/go/pdf/graphics/shading"
+)
+
+// maxPeriods limits the number of copies of a colour line which are used
+// to draw a repeating or reflecting linear gradient.
+const maxPeriods = 256
+
+// renderer draws the paint graph of one colour glyph.
+// All coordinates are in font design units.
+type renderer struct {
+	b *builder.Builder
+
+	info    *sfnt.Font
+	glyphs  map[glyph.ID]paint
+	palette []paletteEntry
+	fg      color.DeviceRGB
+
+	// clip is a bounding box for the current clipping region,
+	// in the current user space.
+	clip rect.Rect
+
+	// active holds the colour glyphs which are currently being drawn,
+	// to break cycles of PaintColrGlyph tables.
+	active map[glyph.ID]bool
+
+	alphaGS map[float64]*extgstate.ExtGState
+	blendGS map[pdf.Name]*extgstate.ExtGState
+}
+
+// draw emits the content stream operators for the paint p.
+// Errors are recorded in the builder.
+func (r *renderer) draw(p paint) {
+	if r.b.Err != nil {
+		return
+	}
+
+	switch p := p.(type) {
+	case *paintLayers:
+		for _, layer := range p.layers {
+			r.draw(layer)
+		}
+
+	case *paintSolid:
+		rgb, alpha := r.color(p.color)
+		if alpha <= 0 {
+			return
+		}
+		r.begin(alpha)
+		r.b.SetFillColor(rgb)
+		r.b.Rectangle(r.clip.LLx, r.clip.LLy, r.clip.Dx(), r.clip.Dy())
+		r.b.Fill()
+		r.end(alpha)
+
+	case *paintGlyph:
+		if r.info.Outlines.IsBlank(p.gid) {
+			return
+		}
+		outline := r.info.Outlines.Path(p.gid).ToCubic()
+		if solid, ok := p.child.(*paintSolid); ok {
+			// a solid fill clipped to a glyph is the glyph outline filled
+			rgb, alpha := r.color(solid.color)
+			if alpha <= 0 {
+				return
+			}
+			r.begin(alpha)
+			r.b.SetFillColor(rgb)
+			r.path(outline)
+			r.b.Fill()
+			r.end(alpha)
+			return
+		}
+
+		saved := r.clip
+		r.clip = intersect(r.clip, outline.BBox())
+		r.b.PushGraphicsState()
+		r.path(outline)
+		r.b.ClipNonZero()
+		r.b.EndPath()
+		r.draw(p.child)
+		r.b.PopGraphicsState()
+		r.clip = saved
+
+	case *paintColrGlyph:
+		child, ok := r.glyphs[p.gid]
+		if !ok || r.active[p.gid] {
+			return
+		}
+		r.active[p.gid] = true
+		r.draw(child)
+		delete(r.active, p.gid)
+
+	case *paintTransform:
+		inv, ok := p.M.Inv()
+		if !ok {
+			return
+		}
+		saved := r.clip
+		r.clip = r.clip.Transform(inv)
+		r.b.PushGraphicsState()
+		r.b.Transform(p.M)
+		r.draw(p.child)
+		r.b.PopGraphicsState()
+		r.clip = saved
+
+	case *paintComposite:
+		r.composite(p)
+
+	case *paintLinear:
+		r.linear(p)
+
+	case *paintRadial:
+		r.radial(p)
+
+	case *paintSweep:
+		r.sweep(p)
+	}
+}
+
+// composite draws a PaintComposite table.
+//
+// PDF has no equivalent of the Porter-Duff operators, other than
+// "source over".  The remaining operators are approximated by drawing
+// either or both of the paints in a suitable order.
+func (r *renderer) composite(p *paintComposite) {
+	switch p.mode {
+	case compositeClear:
+		// nothing is drawn
+	case compositeSrc:
+		r.draw(p.source)
+	case compositeDest:
+		r.draw(p.backdrop)
+	case compositeDestOver:
+		r.draw(p.source)
+		r.draw(p.backdrop)
+	default:
+		r.draw(p.backdrop)
+		mode, ok := blendModes[p.mode]
+		if !ok {
+			r.draw(p.source)
+			return
+		}
+		gs, ok := r.blendGS[mode]
+		if !ok {
+			gs = &extgstate.ExtGState{
+				Set:       graphics.StateBlendMode,
+				BlendMode: graphics.BlendMode{mode},
+				SingleUse: true,
+			}
+			r.blendGS[mode] = gs
+		}
+		r.b.PushGraphicsState()
+		r.b.SetExtGState(gs)
+		r.draw(p.source)
+		r.b.PopGraphicsState()
+	}
+}
+
+// blendModes maps the COLR composite modes to PDF blend modes.
+var blendModes = map[uint8]pdf.Name{
+	13: graphics.BlendModeScreen,
+	14: graphics.BlendModeOverlay,
+	15: graphics.BlendModeDarken,
+	16: graphics.BlendModeLighten,
+	17: graphics.BlendModeColorDodge,
+	18: graphics.BlendModeColorBurn,
+	19: graphics.BlendModeHardLight,
+	20: graphics.BlendModeSoftLight,
+	21: graphics.BlendModeDifference,
+	22: graphics.BlendModeExclusion,
+	23: graphics.BlendModeMultiply,
+	24: graphics.BlendModeHue,
+	25: graphics.BlendModeSaturation,
+	26: graphics.BlendModeColor,
+	27: graphics.BlendModeLuminosity,
+}
+
+// linear draws a linear gradient.
+func (r *renderer) linear(p *paintLinear) {
+	g := r.gradient(p.line)
+	if g == nil {
+		return
+	}
+
+	// The colour line runs along the projection of P1 onto the line through
+	// P0 which is perpendicular to P0P2.
+	p1 := p.p1
+	if d := p.p2.Sub(p.p0); d.X != 0 || d.Y != 0 {
+		n := vec.Vec2{X: d.Y, Y: -d.X}
+		p1 = p.p0.Add(n.Mul(p.p1.Sub(p.p0).Dot(n) / n.Dot(n)))
+	}
+	axis := p1.Sub(p.p0)
+	start := p.p0.Add(axis.Mul(g.t0))
+	axis = axis.Mul(g.t1 - g.t0)
+	if g.single() || axis.Dot(axis) == 0 {
+		r.fill(g.last(), g.alpha)
+		return
+	}
+
+	sh := &shading.Type2{
+		Common:      shading.Common{ColorSpace: color.SpaceDeviceRGB},
+		P0:          start,
+		P1:          start.Add(axis),
+		F:           g.function(),
+		ExtendStart: true,
+		ExtendEnd:   true,
+		SingleUse:   true,
+	}
+	if p.line.extend == extendRepeat || p.line.extend == extendReflect {
+		// Find the range of periods needed to cover the clip region.
+		lo, hi := math.Inf(1), math.Inf(-1)
+		for _, c := range corners(r.clip) {
+			u := c.Sub(start).Dot(axis) / axis.Dot(axis)
+			lo = min(lo, u)
+			hi = max(hi, u)
+		}
+		n0, n1 := math.Floor(lo), math.Ceil(hi)
+		if n1 == n0 {
+			n1++
+		}
+		if n1-n0 <= maxPeriods {
+			sh.P0 = start.Add(axis.Mul(n0))
+			sh.P1 = start.Add(axis.Mul(n1))
+			sh.TMin, sh.TMax = n0, n1
+			sh.F = periodic(sh.F, n0, n1, p.line.extend == extendReflect)
+		}
+	}
+	r.shade(sh, g.alpha)
+}
+
+// periodic repeats the function f, defined on [0, 1], to cover [n0, n1].
+// If reflect is set, every second copy is mirrored.
+func periodic(f pdf.Function, n0, n1 float64, reflect bool) pdf.Function {
+	k := int(n1 - n0)
+	res := &function.Type3{
+		XMin:      n0,
+		XMax:      n1,
+		Functions: make([]pdf.Function, k),
+		Encode:    make([]float64, 0, 2*k),
+	}
+	for i := range k {
+		res.Functions[i] = f
+		if i > 0 {
+			res.Bounds = append(res.Bounds, n0+float64(i))
+		}
+		if reflect && (int(n0)+i)&1 != 0 {
+			res.Encode = append(res.Encode, 1, 0)
+		} else {
+			res.Encode = append(res.Encode, 0, 1)
+		}
+	}
+	return res
+}
+
+// radial draws a radial gradient.
+// Repeating and reflecting colour lines are drawn as if they were padded.
+func (r *renderer) radial(p *paintRadial) {
+	g := r.gradient(p.line)
+	if g == nil {
+		return
+	}
+	if g.single() {
+		r.fill(g.last(), g.alpha)
+		return
+	}
+
+	center := func(t float64) vec.Vec2 {
+		return p.c0.Add(p.c1.Sub(p.c0).Mul(t))
+	}
+	radius := func(t float64) float64 {
+		return max(p.r0+t*(p.r1-p.r0), 0)
+	}
+	sh := &shading.Type3{
+		Common:      shading.Common{ColorSpace: color.SpaceDeviceRGB},
+		Center1:     center(g.t0),
+		R1:          radius(g.t0),
+		Center2:     center(g.t1),
+		R2:          radius(g.t1),
+		F:           g.function(),
+		ExtendStart: true,
+		ExtendEnd:   true,
+		SingleUse:   true,
+	}
+	r.shade(sh, g.alpha)
+}
+
+// sweep draws a sweep gradient.  PDF has no shading type for this, so a
+// function-based shading with a PostScript calculator function is used.
+// Repeating and reflecting colour lines are drawn as if they were padded.
+func (r *renderer) sweep(p *paintSweep) {
+	g := r.gradient(p.line)
+	if g == nil {
+		return
+	}
+	a0 := p.start + g.t0*(p.end-p.start)
+	a1 := p.start + g.t1*(p.end-p.start)
+	if g.single() || a0 == a1 || r.clip.Dx() <= 0 || r.clip.Dy() <= 0 {
+		r.fill(g.last(), g.alpha)
+		return
+	}
+
+	// The program maps (x, y) to the angle around the centre, and then
+	// to the colour at this angle.
+	prog := &strings.Builder{}
+	fmt.Fprintf(prog, "%s sub exch %s sub ", ps(p.center.Y), ps(p.center.X))
+	prog.WriteString("2 copy abs exch abs add 0 eq {pop pop 0} {atan} ifelse ")
+	fmt.Fprintf(prog, "%s sub %s mul ", ps(a0), ps(1/(a1-a0)))
+	prog.WriteString("dup 0 lt {pop 0} if dup 1 gt {pop 1} if")
+	g.program(prog, 0)
+
+	domain := []float64{r.clip.LLx, r.clip.URx, r.clip.LLy, r.clip.URy}
+	sh := &shading.Type1{
+		Common: shading.Common{ColorSpace: color.SpaceDeviceRGB},
+		F: &function.Type4{
+			Domain:  domain,
+			Range:   []float64{0, 1, 0, 1, 0, 1},
+			Program: prog.String(),
+		},
+		Domain:    domain,
+		SingleUse: true,
+	}
+	r.shade(sh, g.alpha)
+}
+
+// shade paints the shading over the current clip region.
+func (r *renderer) shade(sh graphics.Shading, alpha float64) {
+	if alpha <= 0 {
+		return
+	}
+	r.b.PushGraphicsState()
+	if alpha < 1 {
+		r.b.SetExtGState(r.alpha(alpha))
+	}
+	r.b.Rectangle(r.clip.LLx, r.clip.LLy, r.clip.Dx(), r.clip.Dy())
+	r.b.ClipNonZero()
+	r.b.EndPath()
+	r.b.DrawShading(sh)
+	r.b.PopGraphicsState()
+}
+
+// fill paints the current clip region in a single colour.
+func (r *renderer) fill(rgb color.DeviceRGB, alpha float64) {
+	if alpha <= 0 {
+		return
+	}
+	r.begin(alpha)
+	r.b.SetFillColor(rgb)
+	r.b.Rectangle(r.clip.LLx, r.clip.LLy, r.clip.Dx(), r.clip.Dy())
+	r.b.Fill()
+	r.end(alpha)
+}
+
+// begin sets the fill opacity, if needed.
+// Every call to begin must be matched by a call to end.
+func (r *renderer) begin(alpha float64) {
+	if alpha < 1 {
+		r.b.PushGraphicsState()
+		r.b.SetExtGState(r.alpha(alpha))
+	}
+}
+
+// end restores the graphics state saved by begin.
+func (r *renderer) end(alpha float64) {
+	if alpha < 1 {
+		r.b.PopGraphicsState()
+	}
+}
+
+// alpha returns a graphics state parameter dictionary which sets the
+// fill opacity.
+func (r *renderer) alpha(alpha float64) *extgstate.ExtGState {
+	alpha = math.Round(alpha*1000) / 1000
+	gs, ok := r.alphaGS[alpha]
+	if !ok {
+		gs = &extgstate.ExtGState{
+			Set:       graphics.StateFillAlpha,
+			FillAlpha: alpha,
+			SingleUse: true,
+		}
+		r.alphaGS[alpha] = gs
+	}
+	return gs
+}
+
+// color resolves a palette reference.
+func (r *renderer) color(c colorIndex) (color.DeviceRGB, float64) {
+	if int(c.index) < len(r.palette) {
+		e := r.palette[c.index]
+		return e.rgb, e.alpha * c.alpha
+	}
+	// The foreground colour, and any out-of-range index.
+	return r.fg, c.alpha
+}
+
+// path appends a glyph outline to the current path.
+func (r *renderer) path(outline path.Path) {
+	for cmd, pts := range outline {
+		switch cmd {
+		case path.CmdMoveTo:
+			r.b.MoveTo(pts[0].X, pts[0].Y)
+		case path.CmdLineTo:
+			r.b.LineTo(pts[0].X, pts[0].Y)
+		case path.CmdCubeTo:
+			r.b.CurveTo(pts[0].X, pts[0].Y, pts[1].X, pts[1].Y, pts[2].X, pts[2].Y)
+		case path.CmdClose:
+			r.b.ClosePath()
+		}
+	}
+}
+
+// gradientData holds a colour line, prepared for conversion into a PDF
+// function.
+type gradientData struct {
+	// t0 and t1 are the offsets of the first and last colour stop.
+	t0, t1 float64
+
+	// segments covers [0, 1], after rescaling the offsets from [t0, t1].
+	segments []segment
+
+	// alpha is the mean opacity of the colour stops.  Shadings cannot
+	// vary the opacity, so this is used for the whole gradient.
+	alpha float64
+}
+
+// segment is the part of a colour line between two consecutive stops.
+type segment struct {
+	u0, u1 float64
+	c0, c1 color.DeviceRGB
+}
+
+// gradient prepares a colour line for drawing.
+// The result is nil if the colour line has no stops.
+func (r *renderer) gradient(line *colorLine) *gradientData {
+	if len(line.stops) == 0 {
+		return nil
+	}
+	stops := slices.Clone(line.stops)
+	slices.SortStableFunc(stops, func(a, b colorStop) int {
+		return cmp.Compare(a.offset, b.offset)
+	})
+
+	g := &gradientData{
+		t0: stops[0].offset,
+		t1: stops[len(stops)-1].offset,
+	}
+	rgb := make([]color.DeviceRGB, len(stops))
+	for i, s := range stops {
+		var alpha float64
+		rgb[i], alpha = r.color(s.color)
+		g.alpha += alpha / float64(len(stops))
+	}
+
+	if g.t1 > g.t0 {
+		// Zero-length segments are dropped, so that hard colour changes
+		// give strictly increasing bounds in the stitching function.
+		for i := 1; i < len(stops); i++ {
+			u0 := (stops[i-1].offset - g.t0) / (g.t1 - g.t0)
+			u1 := (stops[i].offset - g.t0) / (g.t1 - g.t0)
+			if u1 > u0 {
+				g.segments = append(g.segments, segment{u0, u1, rgb[i-1], rgb[i]})
+			}
+		}
+	}
+	if len(g.segments) == 0 {
+		g.segments = []segment{{0, 1, rgb[len(rgb)-1], rgb[len(rgb)-1]}}
+	}
+	return g
+}
+
+// single reports whether the gradient has only one colour.
+func (g *gradientData) single() bool {
+	return g.t1 <= g.t0
+}
+
+// last returns the colour at the end of the gradient.
+func (g *gradientData) last() color.DeviceRGB {
+	return g.segments[len(g.segments)-1].c1
+}
+
+// function returns a PDF function which maps [0, 1] to the gradient colours.
+func (g *gradientData) function() pdf.Function {
+	if len(g.segments) == 1 {
+		s := g.segments[0]
+		return &function.Type2{XMin: 0, XMax: 1, C0: s.c0[:], C1: s.c1[:], N: 1}
+	}
+	res := &function.Type3{
+		XMin: 0,
+		XMax: 1,
+	}
+	for i, s := range g.segments {
+		res.Functions = append(res.Functions,
+			&function.Type2{XMin: 0, XMax: 1, C0: s.c0[:], C1: s.c1[:], N: 1})
+		if i > 0 {
+			res.Bounds = append(res.Bounds, s.u0)
+		}
+		res.Encode = append(res.Encode, 0, 1)
+	}
+	return res
+}
+
+// program writes PostScript calculator code which replaces the value u
+// on top of the stack with the gradient colour at u.  The segments from
+// index i onwards are considered.
+func (g *gradientData) program(w *strings.Builder, i int) {
+	s := g.segments[i]
+	if i < len(g.segments)-1 {
+		fmt.Fprintf(w, " dup %s le {", ps(s.u1))
+		defer func() {
+			w.WriteString("} {")
+			g.program(w, i+1)
+			w.WriteString("} ifelse")
+		}()
+	}
+	fmt.Fprintf(w, " %s sub %s mul", ps(s.u0), ps(1/(s.u1-s.u0)))
+	for j := range 3 {
+		fmt.Fprintf(w, " dup %s mul %s add exch", ps(s.c1[j]-s.c0[j]), ps(s.c0[j]))
+	}
+	w.WriteString(" pop")
+}
+
+// ps formats a number for use in a PostScript calculator function.
+func ps(x float64) string {
+	s := strconv.FormatFloat(x, 'f', 9, 64)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" {
+		s = "0"
+	}
+	return s
+}
+
+// corners returns the corners of a rectangle.
+func corners(r rect.Rect) []vec.Vec2 {
+	return []vec.Vec2{
+		{X: r.LLx, Y: r.LLy},
+		{X: r.URx, Y: r.LLy},
+		{X: r.LLx, Y: r.URy},
+		{X: r.URx, Y: r.URy},
+	}
+}
+
+// intersect returns the intersection of two rectangles.
+func intersect(a, b rect.Rect) rect.Rect {
+	return rect.Rect{
+		LLx: max(a.LLx, b.LLx),
+		LLy: max(a.LLy, b.LLy),
+		URx: min(a.URx, b.URx),
+		URy: min(a.URy, b.URy),
+	}
+}
@@ -34,6 +34,7 @@
 //   - [seehuhn.de/go/pdf/font/opentype.NewSimple]
 //   - [seehuhn.de/go/pdf/font/type1.New]
 //   - [seehuhn.de/go/pdf/font/type3.Font.New]
+//   - [seehuhn.de/go/pdf/font/colorfont.New], for colour fonts
 //
 // The following functions can be used to embed fonts as composite fonts:
 //   - [seehuhn.de/go/pdf/font/cff.NewComposite]
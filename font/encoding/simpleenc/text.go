@@ -29,11 +29,22 @@ import (
 // the same name [Simple.Encode] used: a code left out here because its text is
 // implied must be one the implication holds for.
 func (t *Simple) ToUnicode() *cmap.ToUnicodeFile {
+	return t.toUnicode(false)
+}
+
+// ToUnicodeAll returns a ToUnicode CMap which covers all codes allocated so
+// far, including those whose text is implied by the glyph name.  The result
+// is nil if no codes have been allocated.
+func (t *Simple) ToUnicodeAll() *cmap.ToUnicodeFile {
+	return t.toUnicode(true)
+}
+
+func (t *Simple) toUnicode(all bool) *cmap.ToUnicodeFile {
 	m := make(map[charcode.Code]string)
 	for k, c := range t.code {
 		glyphName := t.glyphName[k.gid]
 		implied := names.ToUnicode(glyphName, t.fontName)
-		if k.text != implied {
+		if all || k.text != implied {
 			m[charcode.Code(c)] = k.text
 		}
 	}
@@ -88,6 +88,11 @@ type Font struct {
 
 	UnderlinePosition  float64
 	UnderlineThickness float64
+
+	// AlwaysToUnicode forces a ToUnicode CMap to be written for all used
+	// glyphs.  By default, the map is only written for glyphs whose text
+	// is not implied by the glyph name.
+	AlwaysToUnicode bool
 }
 
 // Glyph represents a single glyph in a Type 3 font.
@@ -505,6 +510,9 @@ func (f *instance) makeFontDict(_ *pdf.EmbedHelper) (*dict.Type3, error) {
 		Resources:  f.Font.Resources,
 		ToUnicode:  f.Simple.ToUnicode(),
 	}
+	if f.Font.AlwaysToUnicode {
+		d.ToUnicode = f.Simple.ToUnicodeAll()
+	}
 	for c, info := range f.Simple.MappedCodes() {
 		d.Width[c] = info.Width
 	}
@@ -0,0 +1,135 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package fontmerge
+
+import (
+	"bytes"
+	"io"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/page"
+)
+
+// scan records the character codes which the stream shows in fonts which
+// can take part in a merge.
+func (c *converter) scan(s *stream) error {
+	return c.process(s, func(m *member, str pdf.String) pdf.String {
+		m.record(str)
+		s.uses[m] = true
+		return str
+	}, nil)
+}
+
+// rewrite returns the content of the stream, with the strings shown in
+// merged fonts converted to the codes of the merged fonts.
+func (c *converter) rewrite(s *stream) ([]byte, error) {
+	var buf bytes.Buffer
+	err := c.process(s, func(m *member, str pdf.String) pdf.String {
+		if m.group == nil || !m.group.merged {
+			return str
+		}
+		return m.reencode(str)
+	}, func(op content.Operator) error {
+		return op.Format(&buf)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// process runs through the operators of a content stream.  For every
+// string shown in a font which can take part in a merge, fn is called,
+// and the string is replaced by the return value.  If emit is not nil, it
+// is called for every operator.
+//
+// The font is part of the graphics state, so the current font is saved
+// and restored by the q and Q operators.
+func (c *converter) process(s *stream, fn func(*member, pdf.String) pdf.String, emit func(content.Operator) error) error {
+	if s.contents == nil {
+		return nil
+	}
+	open := func() (io.ReadCloser, error) { return c.openContent(s.contents) }
+	it := content.NewScanner(open).NewIter()
+
+	var cur *member
+	var stack []*member
+	for name, args := range it.All() {
+		switch name {
+		case content.OpPushGraphicsState:
+			stack = append(stack, cur)
+		case content.OpPopGraphicsState:
+			if n := len(stack); n > 0 {
+				cur = stack[n-1]
+				stack = stack[:n-1]
+			}
+		case content.OpTextSetFont:
+			cur = nil
+			if len(args) >= 1 {
+				if fontName, ok := args[0].(pdf.Name); ok {
+					cur = c.fonts[s.fonts[fontName]]
+				}
+			}
+		case content.OpTextShow, content.OpTextShowMoveNextLine,
+			content.OpTextShowMoveNextLineSetSpacing:
+			// the shown string is the last operand
+			if cur != nil && len(args) >= 1 {
+				if str, ok := args[len(args)-1].(pdf.String); ok {
+					args = append([]pdf.Object(nil), args...)
+					args[len(args)-1] = fn(cur, str)
+				}
+			}
+		case content.OpTextShowArray:
+			if cur != nil && len(args) >= 1 {
+				if arr, ok := args[0].(pdf.Array); ok {
+					newArr := make(pdf.Array, len(arr))
+					for i, elem := range arr {
+						if str, ok := elem.(pdf.String); ok {
+							newArr[i] = fn(cur, str)
+						} else {
+							newArr[i] = elem
+						}
+					}
+					args = []pdf.Object{newArr}
+				}
+			}
+		}
+
+		if emit != nil {
+			if err := emit(content.Operator{Name: name, Args: args}); err != nil {
+				return err
+			}
+		}
+	}
+	return it.Err()
+}
+
+// openContent returns a reader for the decoded data of a content stream,
+// or of the concatenated content streams of a page.
+func (c *converter) openContent(contents pdf.Object) (io.ReadCloser, error) {
+	cur := pdf.CursorAt(c.x, nil)
+	resolved, err := cur.Resolve(contents)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := page.ExtractContents(cur, resolved)
+	if err != nil {
+		return nil, err
+	}
+	return page.SegmentsReader(segments), nil
+}
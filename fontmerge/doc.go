@@ -0,0 +1,47 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package fontmerge merges the subsets of a font which are embedded
+// several times in a PDF document.
+//
+// Documents concatenated from many files produced by the same tool often
+// carry one subset of the same font per input file, for example
+// ABCDEF+Helvetica, GHIJKL+Helvetica, and so on.  [Write] copies a
+// document, finds the embedded subsets which were made from the same font
+// program, and replaces each such family by a single font which contains
+// the union of the glyphs.  The character codes in all content streams
+// which show text in one of the merged fonts are rewritten for the merged
+// font, and the glyph widths and the ToUnicode map are rebuilt.
+//
+// Two subsets are taken to come from the same font program if they have
+// the same PostScript name (ignoring the subset tag), the same number of
+// font units per em, and identical hinting tables.  Glyphs are compared by
+// their outline data, so glyphs which are present in several subsets are
+// stored only once.
+//
+// Only TrueType subsets with glyf outlines are merged, both simple
+// TrueType fonts and composite fonts with a CIDFontType2 descendant.  Simple
+// fonts are merged into a simple font, as long as the merged font needs at
+// most 256 character codes.  Composite fonts are merged into a composite
+// font with Identity-H encoding.  Fonts which are used for vertical text,
+// which are listed in the default resources of the interactive form, or
+// which cannot be merged without changing how word spacing applies to the
+// text, are left unchanged.
+//
+// The content streams searched are the page contents, form XObjects,
+// tiling patterns, soft masks, the glyph descriptions of Type 3 fonts, and
+// the appearance streams of annotations.
+package fontmerge
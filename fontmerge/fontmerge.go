@@ -0,0 +1,245 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package fontmerge
+
+import (
+	"fmt"
+	"io"
+	"maps"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/internal/rewrite"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// Report summarises the result of [Write].
+type Report struct {
+	// Merged lists the fonts which replace several subsets.
+	Merged []Merged
+}
+
+// Merged describes a font which replaces several subsets.
+type Merged struct {
+	// PostScriptName is the name of the merged font, including the new
+	// subset tag.
+	PostScriptName string
+
+	// Fonts is the number of font dictionaries which were replaced by the
+	// merged font.
+	Fonts int
+
+	// Glyphs is the number of glyphs in the merged font, including the
+	// .notdef glyph.
+	Glyphs int
+}
+
+type converter struct {
+	r    pdf.Getter
+	x    *pdf.Extractor
+	out  *pdf.Writer
+	rm   *pdf.ResourceManager
+	copy *pdf.Copier
+
+	// fonts holds the fonts found in the resource dictionaries, in the
+	// order in which they were found.  Fonts which cannot be merged map to
+	// nil.
+	fonts     map[pdf.Reference]*member
+	fontOrder []*member
+
+	// pinned lists fonts which must not be changed.
+	pinned map[pdf.Reference]bool
+
+	// streams holds the content streams other than the page contents,
+	// in the order in which they were found.
+	streams     map[pdf.Reference]*stream
+	streamOrder []*stream
+
+	// resources lists the indirect resource dictionaries already visited.
+	resources map[pdf.Reference]bool
+}
+
+// Write reads the document from r, merges the subsets of fonts which were
+// made from the same font program, and writes the result to w.
+func Write(w io.Writer, r pdf.Getter) (*Report, error) {
+	refs, dicts, err := rewrite.ReadPages(r)
+	if err != nil {
+		return nil, err
+	}
+
+	metaIn := r.GetMeta()
+	out, err := rewrite.NewWriter(w, r, pdf.GetVersion(r))
+	if err != nil {
+		return nil, err
+	}
+	rm := pdf.NewResourceManager(out)
+	c := &converter{
+		r:         r,
+		x:         pdf.NewExtractor(r),
+		out:       out,
+		rm:        rm,
+		copy:      pdf.NewCopier(out, r),
+		fonts:     map[pdf.Reference]*member{},
+		pinned:    map[pdf.Reference]bool{},
+		streams:   map[pdf.Reference]*stream{},
+		resources: map[pdf.Reference]bool{},
+	}
+
+	if err := c.pinFormFonts(metaIn.Catalog.AcroForm); err != nil {
+		return nil, err
+	}
+
+	// Find all content streams, and the character codes they use.
+	pages := make([]*stream, len(dicts))
+	for i, dict := range dicts {
+		pages[i], err = c.visitPage(dict)
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+	}
+	for i, s := range pages {
+		if err := c.scan(s); err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+	}
+	for _, s := range c.streamOrder {
+		if err := c.scan(s); err != nil {
+			return nil, err
+		}
+	}
+
+	// Merge the fonts.  The member fonts are redirected to the merged
+	// fonts, so that the resource dictionaries need no changes.
+	report := &Report{}
+	for _, g := range c.groups() {
+		merged, err := c.merge(g)
+		if err != nil {
+			return nil, err
+		}
+		if merged != nil {
+			report.Merged = append(report.Merged, *merged)
+		}
+	}
+
+	newRefs := rewrite.RedirectPages(out, c.copy, refs)
+	var changed []*stream
+	for _, s := range c.streamOrder {
+		if s.needsRewrite() {
+			s.newRef = out.Alloc()
+			c.copy.Redirect(s.ref, s.newRef)
+			changed = append(changed, s)
+		}
+	}
+	for _, s := range changed {
+		if err := c.writeStream(s); err != nil {
+			return nil, err
+		}
+	}
+
+	tree := pagetree.NewWriter(out, rm)
+	for i, dict := range dicts {
+		var newDict pdf.Dict
+		if pages[i].needsRewrite() {
+			newDict, err = c.rewritePage(dict, pages[i])
+		} else {
+			newDict, err = c.copy.CopyDict(dict)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		if err := tree.AppendPageDict(newRefs[i], newDict); err != nil {
+			return nil, err
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	meta := out.GetMeta()
+	meta.Info = metaIn.Info
+	if err := rewrite.CopyCatalog(out, c.copy, metaIn.Catalog); err != nil {
+		return nil, err
+	}
+	meta.Catalog.Pages = pagesRef
+
+	if err := rm.Close(); err != nil {
+		return nil, err
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// rewritePage returns the copy of a page dictionary, with the character
+// codes in the content stream rewritten for the merged fonts.
+func (c *converter) rewritePage(src pdf.Dict, s *stream) (pdf.Dict, error) {
+	data, err := c.rewrite(s)
+	if err != nil {
+		return nil, err
+	}
+	ref := c.out.Alloc()
+	stm, err := c.out.OpenStream(ref, nil, pdf.FilterCompress{})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stm.Write(data); err != nil {
+		return nil, err
+	}
+	if err := stm.Close(); err != nil {
+		return nil, err
+	}
+
+	rest := maps.Clone(src)
+	delete(rest, "Contents")
+	dict, err := c.copy.CopyDict(rest)
+	if err != nil {
+		return nil, err
+	}
+	dict["Contents"] = ref
+	return dict, nil
+}
+
+// writeStream writes the rewritten form of a content stream other than the
+// page contents.  The stream dictionary is copied, apart from the entries
+// which describe the encoding of the stream data.
+func (c *converter) writeStream(s *stream) error {
+	data, err := c.rewrite(s)
+	if err != nil {
+		return err
+	}
+	stm, err := pdf.CursorAt(c.x, nil).Stream(s.ref)
+	if err != nil {
+		return err
+	}
+	rest := maps.Clone(stm.Dict)
+	for _, key := range []pdf.Name{"Length", "Filter", "DecodeParms", "DL", "F", "FFilter", "FDecodeParms"} {
+		delete(rest, key)
+	}
+	dict, err := c.copy.CopyDict(rest)
+	if err != nil {
+		return err
+	}
+	w, err := c.out.OpenStream(s.newRef, dict, pdf.FilterCompress{})
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	return w.Close()
+}
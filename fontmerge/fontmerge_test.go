@@ -0,0 +1,205 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package fontmerge
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"slices"
+	"testing"
+
+	"seehuhn.de/go/sfnt/glyf"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/document"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/font/gofont"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+	"seehuhn.de/go/pdf/internal/rewrite/rewritetest"
+	"seehuhn.de/go/pdf/pagetree"
+	"seehuhn.de/go/pdf/search"
+)
+
+var pageTexts = []string{"Hello World", "Goodbye, World!", "Hello again", "Fjord quay"}
+
+// makeSource writes a document in which every page uses its own instance
+// of the Go Regular font, so that each page carries a different subset.
+// The first two pages use simple fonts, the other two composite fonts.
+func makeSource(t *testing.T) *pdf.Reader {
+	t.Helper()
+
+	buf := memfile.New()
+	doc, err := document.WriteMultiPage(buf, document.A4, pdf.V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, text := range pageTexts {
+		var F font.Layouter
+		if i < 2 {
+			F, err = gofont.Regular.NewSimple(nil)
+		} else {
+			F, err = gofont.Regular.NewComposite(nil)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := doc.AddPage()
+		p.TextBegin()
+		p.TextSetFont(F, 12)
+		p.TextFirstLine(72, 700)
+		p.TextShow(text)
+		p.TextEnd()
+		if err := p.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := pdf.NewReader(buf, int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// pageFonts returns the font dictionary references of all pages.
+func pageFonts(t *testing.T, r pdf.Getter) []pdf.Reference {
+	t.Helper()
+
+	var res []pdf.Reference
+	for i := range pageTexts {
+		_, dict, err := pagetree.GetPage(r, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c := &converter{x: pdf.NewExtractor(r)}
+		fonts, err := c.fontRefs(dict["Resources"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(fonts) != 1 {
+			t.Fatalf("page %d: want 1 font, got %d", i+1, len(fonts))
+		}
+		for _, ref := range fonts {
+			res = append(res, ref)
+		}
+	}
+	return res
+}
+
+// shownGlyphs returns the outlines and widths of the glyphs shown on a
+// page.
+func shownGlyphs(t *testing.T, r pdf.Getter, pageNo int) []string {
+	t.Helper()
+
+	_, dict, err := pagetree.GetPage(r, pageNo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &converter{
+		x:         pdf.NewExtractor(r),
+		fonts:     map[pdf.Reference]*member{},
+		streams:   map[pdf.Reference]*stream{},
+		resources: map[pdf.Reference]bool{},
+	}
+	s, err := c.visitPage(dict)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var res []string
+	err = c.process(s, func(m *member, str pdf.String) pdf.String {
+		for len(str) > 0 {
+			_, k, _ := m.codec.Decode(str)
+			code := []byte(str[:k])
+			g := m.outlines.Glyphs[m.glyph(code)]
+			res = append(res, fmt.Sprintf("%g:%x", m.width(code), glyf.Glyphs{g}.Encode().GlyfData))
+			str = str[k:]
+		}
+		return str
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestWrite(t *testing.T) {
+	r := makeSource(t)
+
+	before := pageFonts(t, r)
+	for i := 1; i < len(before); i++ {
+		if before[i] == before[i-1] {
+			t.Fatal("test setup: pages share a font")
+		}
+	}
+
+	var out bytes.Buffer
+	report, err := Write(&out, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err := pdf.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Merged) != 2 {
+		t.Fatalf("want 2 merged fonts, got %d", len(report.Merged))
+	}
+	for _, m := range report.Merged {
+		if m.Fonts != 2 {
+			t.Errorf("%s: want 2 fonts, got %d", m.PostScriptName, m.Fonts)
+		}
+	}
+
+	// the simple fonts and the composite fonts are merged separately
+	after := pageFonts(t, rr)
+	if after[0] != after[1] || after[2] != after[3] || after[0] == after[2] {
+		t.Errorf("unexpected fonts after merging: %v", after)
+	}
+
+	for i, text := range pageTexts {
+		hits, err := search.Find(rr, text, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hits) != 1 || hits[0].Page != i {
+			t.Errorf("text %q: got %d hits", text, len(hits))
+		}
+
+		want := shownGlyphs(t, r, i)
+		got := shownGlyphs(t, rr, i)
+		if len(want) != len([]rune(text)) {
+			t.Fatalf("page %d: test setup shows %d glyphs", i+1, len(want))
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("page %d: shown glyphs differ after merging", i+1)
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	r := rewritetest.Source(t)
+	res := rewritetest.Rewrite(t, r, func(w io.Writer, r pdf.Getter) error {
+		_, err := Write(w, r)
+		return err
+	})
+	rewritetest.CheckCatalog(t, res)
+}
@@ -0,0 +1,249 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package fontmerge
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+
+	"seehuhn.de/go/postscript/cid"
+	"seehuhn.de/go/sfnt"
+	"seehuhn.de/go/sfnt/glyf"
+	"seehuhn.de/go/sfnt/glyph"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/font/charcode"
+	"seehuhn.de/go/pdf/font/dict"
+	"seehuhn.de/go/pdf/font/glyphdata"
+	"seehuhn.de/go/pdf/font/glyphdata/sfntglyphs"
+	"seehuhn.de/go/pdf/graphics/extract"
+)
+
+// member is a font subset which can take part in a merge.  Exactly one of
+// simple and composite is set.
+type member struct {
+	ref       pdf.Reference
+	simple    *dict.TrueType
+	composite *dict.CIDFontType2
+
+	psName string
+	tag    string
+	desc   *font.Descriptor
+
+	inst     font.Instance
+	codec    *charcode.Codec
+	sfnt     *sfnt.Font
+	outlines *glyf.Outlines
+
+	// selector maps the character codes of a simple font to glyphs.
+	selector func(cid.CID) (glyph.ID, bool)
+
+	// used maps the character codes shown in the document to their
+	// description.
+	used map[string]font.Code
+
+	// group is the set of fonts made from the same font program.  If the
+	// group is merged, newCode maps the character codes in used to the
+	// codes of the merged font.
+	group   *group
+	newCode map[string][]byte
+}
+
+// group is a set of fonts made from the same font program.
+type group struct {
+	members []*member
+	merged  bool
+}
+
+// addFont records a font found in a resource dictionary.
+func (c *converter) addFont(ref pdf.Reference) error {
+	if _, seen := c.fonts[ref]; seen {
+		return nil
+	}
+	m, err := c.loadFont(ref)
+	if err != nil {
+		return err
+	}
+	c.fonts[ref] = m
+	if m != nil {
+		c.fontOrder = append(c.fontOrder, m)
+	}
+	return nil
+}
+
+// loadFont reads a font dictionary.  The result is nil if the font is not
+// an embedded TrueType subset which can be merged.
+func (c *converter) loadFont(ref pdf.Reference) (*member, error) {
+	d, err := extract.Dict(pdf.CursorAt(c.x, nil), ref, false)
+	if pdf.IsReadError(err) {
+		return nil, err
+	} else if err != nil {
+		return nil, nil
+	}
+
+	m := &member{
+		ref:   ref,
+		inst:  d.MakeFont(),
+		codec: d.Codec(),
+		used:  map[string]font.Code{},
+	}
+	var fontFile *glyphdata.Stream
+	switch d := d.(type) {
+	case *dict.TrueType:
+		m.simple = d
+		m.psName, m.tag, m.desc = d.PostScriptName, d.SubsetTag, d.Descriptor
+		fontFile = d.FontFile
+	case *dict.CIDFontType2:
+		if d.CMap == nil || d.CMap.WMode != font.Horizontal {
+			return nil, nil
+		}
+		m.composite = d
+		m.psName, m.tag, m.desc = d.PostScriptName, d.SubsetTag, d.Descriptor
+		fontFile = d.FontFile
+	default:
+		return nil, nil
+	}
+	if m.tag == "" || m.desc == nil || m.codec == nil || fontFile == nil {
+		return nil, nil
+	}
+	if fontFile.Type != glyphdata.TrueType && fontFile.Type != glyphdata.OpenTypeGlyf {
+		return nil, nil
+	}
+
+	sf, err := sfntglyphs.FromStream(fontFile)
+	if pdf.IsReadError(err) {
+		return nil, err
+	} else if err != nil {
+		return nil, nil
+	}
+	outlines, ok := sf.Outlines.(*glyf.Outlines)
+	if !ok || sf.IsVariable() {
+		return nil, nil
+	}
+	m.sfnt = sf
+	m.outlines = outlines
+	if m.simple != nil {
+		m.selector = sfntglyphs.NewTrueTypeSelector(sf, m.desc.IsSymbolic, m.simple.Encoding)
+	}
+	return m, nil
+}
+
+// record notes the character codes in a string shown in the font.
+func (m *member) record(s pdf.String) {
+	for len(s) > 0 {
+		_, k, _ := m.codec.Decode(s)
+		k = max(k, 1)
+		key := string(s[:k])
+		if _, seen := m.used[key]; !seen {
+			for code := range m.inst.Codes(s[:k]) {
+				m.used[key] = code
+				break
+			}
+		}
+		s = s[k:]
+	}
+}
+
+// reencode converts a string shown in the font to the character codes of
+// the merged font.
+func (m *member) reencode(s pdf.String) pdf.String {
+	res := make(pdf.String, 0, 2*len(s))
+	for len(s) > 0 {
+		_, k, _ := m.codec.Decode(s)
+		k = max(k, 1)
+		if code, ok := m.newCode[string(s[:k])]; ok {
+			res = append(res, code...)
+		} else {
+			res = append(res, s[:k]...)
+		}
+		s = s[k:]
+	}
+	return res
+}
+
+// glyph returns the glyph shown for a character code.  Codes which do not
+// map to a glyph show the .notdef glyph.
+func (m *member) glyph(code []byte) glyph.ID {
+	var gid glyph.ID
+	if m.simple != nil {
+		gid, _ = m.selector(cid.CID(code[0]) + 1)
+	} else {
+		cidVal := m.composite.CMap.LookupCID(code)
+		switch {
+		case m.composite.CIDToGID != nil:
+			if int(cidVal) < len(m.composite.CIDToGID) {
+				gid = m.composite.CIDToGID[cidVal]
+			}
+		case cidVal <= 0xFFFF:
+			gid = glyph.ID(cidVal)
+		}
+	}
+	if int(gid) >= len(m.outlines.Glyphs) {
+		gid = 0
+	}
+	return gid
+}
+
+// width returns the width of a character code, in PDF glyph space units.
+func (m *member) width(code []byte) float64 {
+	if m.simple != nil {
+		return m.simple.Width[code[0]]
+	}
+	cidVal := m.composite.CMap.LookupCID(code)
+	if w, ok := m.composite.Width[cidVal]; ok {
+		return w
+	}
+	return m.composite.DefaultWidth
+}
+
+// groups sorts the fonts into groups made from the same font program.
+// Only groups with at least two fonts are returned.
+func (c *converter) groups() []*group {
+	var all []*group
+	byKey := map[string][]*group{}
+	for _, m := range c.fontOrder {
+		if c.pinned[m.ref] {
+			continue
+		}
+
+		key := fmt.Sprintf("%t/%d/%s", m.simple != nil, m.sfnt.UnitsPerEm, m.psName)
+		var g *group
+		for _, cand := range byKey[key] {
+			if maps.EqualFunc(cand.members[0].outlines.Tables, m.outlines.Tables, bytes.Equal) {
+				g = cand
+				break
+			}
+		}
+		if g == nil {
+			g = &group{}
+			byKey[key] = append(byKey[key], g)
+			all = append(all, g)
+		}
+		g.members = append(g.members, m)
+		m.group = g
+	}
+
+	var res []*group
+	for _, g := range all {
+		if len(g.members) > 1 {
+			res = append(res, g)
+		}
+	}
+	return res
+}
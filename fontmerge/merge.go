@@ -0,0 +1,402 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package fontmerge
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"seehuhn.de/go/postscript/funit"
+
+	sfntcmap "seehuhn.de/go/sfnt/cmap"
+	"seehuhn.de/go/sfnt/glyf"
+	"seehuhn.de/go/sfnt/glyph"
+	"seehuhn.de/go/sfnt/maxp"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font/charcode"
+	"seehuhn.de/go/pdf/font/cmap"
+	"seehuhn.de/go/pdf/font/dict"
+	"seehuhn.de/go/pdf/font/encoding"
+	"seehuhn.de/go/pdf/font/glyphdata"
+	"seehuhn.de/go/pdf/font/glyphdata/sfntglyphs"
+	"seehuhn.de/go/pdf/font/subset"
+)
+
+// maxComponentDepth limits the nesting of composite glyphs.  Deeper
+// nesting only occurs in malformed fonts, where it may be circular.
+const maxComponentDepth = 16
+
+var errFontNotIndirect = errors.New("font embed did not return a reference")
+
+// builder collects the glyphs of a merged font.  Glyphs with identical
+// outlines and advance widths are stored only once.
+type builder struct {
+	glyphs glyf.Glyphs
+	widths []funit.Uint16
+	index  map[string]glyph.ID
+}
+
+// add adds a glyph of the font m, together with its components, and
+// returns the glyph ID in the merged font.  The map memo caches the
+// results for the glyphs of m.
+func (b *builder) add(m *member, gid glyph.ID, memo map[glyph.ID]glyph.ID, depth int) glyph.ID {
+	if newGID, ok := memo[gid]; ok {
+		return newGID
+	}
+	if int(gid) >= len(m.outlines.Glyphs) || depth > maxComponentDepth {
+		return 0
+	}
+
+	g := m.outlines.Glyphs[gid]
+	if comps := g.Components(); comps != nil {
+		fix := make(map[glyph.ID]glyph.ID, len(comps))
+		for _, comp := range comps {
+			fix[comp] = b.add(m, comp, memo, depth+1)
+		}
+		g = g.FixComponents(fix)
+	}
+	var w funit.Uint16
+	if int(gid) < len(m.outlines.Widths) {
+		w = m.outlines.Widths[gid]
+	}
+
+	key := fmt.Sprintf("%d:%s", w, glyf.Glyphs{g}.Encode().GlyfData)
+	newGID, ok := b.index[key]
+	if !ok {
+		newGID = glyph.ID(len(b.glyphs))
+		b.glyphs = append(b.glyphs, g)
+		b.widths = append(b.widths, w)
+		b.index[key] = newGID
+	}
+	memo[gid] = newGID
+	return newGID
+}
+
+// slot is a character code of the merged font.  Codes of the member fonts
+// share a slot if they show the same glyph with the same width and text.
+type slot struct {
+	gid   glyph.ID
+	width float64
+	text  string
+	useWS bool
+
+	// orig is the first member code which uses the slot, and code is the
+	// code assigned in the merged font.
+	orig []byte
+	code []byte
+}
+
+// merge combines the fonts of a group into one font.  If the fonts cannot
+// be merged, the group is left unchanged and the result is nil.
+func (c *converter) merge(g *group) (*Merged, error) {
+	base := g.members[0]
+
+	notdef := base.outlines.Glyphs[0]
+	if notdef.Components() != nil {
+		notdef = nil
+	}
+	b := &builder{
+		glyphs: glyf.Glyphs{notdef},
+		widths: []funit.Uint16{base.outlines.Widths[0]},
+		index:  map[string]glyph.ID{},
+	}
+
+	var slots []*slot
+	slotIndex := map[string]*slot{}
+	memberSlots := make([]map[string]*slot, len(g.members))
+	var origGIDs []glyph.ID
+	origNumGlyphs := 0
+	for i, m := range g.members {
+		memo := map[glyph.ID]glyph.ID{0: 0}
+		memberSlots[i] = map[string]*slot{}
+		for _, key := range slices.Sorted(maps.Keys(m.used)) {
+			code := []byte(key)
+			gid := b.add(m, m.glyph(code), memo, 0)
+			s := &slot{
+				gid:   gid,
+				width: m.width(code),
+				text:  m.used[key].Text,
+				useWS: m.used[key].UseWordSpacing,
+				orig:  code,
+			}
+			sKey := fmt.Sprintf("%d/%g/%t/%s", s.gid, s.width, s.useWS, s.text)
+			if prev, ok := slotIndex[sKey]; ok {
+				s = prev
+			} else {
+				slotIndex[sKey] = s
+				slots = append(slots, s)
+			}
+			memberSlots[i][key] = s
+		}
+		for _, gid := range slices.Sorted(maps.Keys(memo)) {
+			origGIDs = append(origGIDs, glyph.ID(origNumGlyphs)+gid)
+		}
+		origNumGlyphs += len(m.outlines.Glyphs)
+	}
+	if len(b.glyphs) > 0xFFFF {
+		return nil, nil
+	}
+
+	var ok bool
+	if base.simple != nil {
+		ok = assignSimple(slots)
+	} else {
+		ok = assignComposite(slots, len(b.glyphs))
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	tag := subset.Retag(subset.Tag(origGIDs, origNumGlyphs), base.tag)
+	fontName := subset.Join(tag, base.psName)
+
+	sf := sfntglyphs.StripForEmbedding(base.sfnt)
+	sf.Outlines = &glyf.Outlines{
+		Glyphs: b.glyphs,
+		Widths: b.widths,
+		Tables: base.outlines.Tables,
+		Maxp:   mergeMaxp(g.members),
+	}
+
+	// The embedded program names itself the same as BaseFont and the
+	// descriptor's FontName, unless the name cannot be stored in a "name"
+	// table.
+	programName := fontName
+	if sf.CheckFontName(programName) != nil {
+		programName = ""
+	}
+	sf.FontName = programName
+
+	fd := *base.desc
+	fd.FontName = fontName
+	for _, m := range g.members[1:] {
+		fd.FontBBox.Extend(m.desc.FontBBox)
+	}
+
+	var fontDict pdf.Embedder
+	if base.simple != nil {
+		subtable := sfntcmap.Format4{}
+		toUni := map[charcode.Code]string{}
+		tt := &dict.TrueType{
+			PostScriptName: base.psName,
+			SubsetTag:      tag,
+			Descriptor:     &fd,
+			Encoding:       encoding.Builtin,
+		}
+		for _, s := range slots {
+			code := s.code[0]
+			if s.gid != 0 {
+				subtable[uint16(code)] = s.gid
+			}
+			if s.text != "" {
+				toUni[charcode.Code(code)] = s.text
+			}
+			tt.Width[code] = s.width
+		}
+		sf.CMapTable = sfntcmap.Table{
+			{PlatformID: 1, EncodingID: 0}: subtable.Encode(0),
+		}
+		fd.IsSymbolic = true
+		tt.FontFile = sfntglyphs.ToStream(sf, glyphdata.TrueType)
+		tu, err := cmap.NewToUnicodeFile(charcode.Simple, toUni)
+		if err != nil {
+			return nil, err
+		}
+		tt.ToUnicode = tu
+		fontDict = tt
+	} else {
+		cmapFile, err := cmap.Predefined("Identity-H")
+		if err != nil {
+			return nil, err
+		}
+		codec, err := charcode.NewCodec(charcode.UCS2)
+		if err != nil {
+			return nil, err
+		}
+
+		ww := make(map[cmap.CID]float64, len(slots))
+		toUni := map[charcode.Code]string{}
+		maxCID := 0
+		identity := true
+		for _, s := range slots {
+			cidVal := int(s.code[0])<<8 | int(s.code[1])
+			ww[cmap.CID(cidVal)] = s.width
+			if s.text != "" {
+				code, _, _ := codec.Decode(s.code)
+				toUni[code] = s.text
+			}
+			maxCID = max(maxCID, cidVal)
+			if cidVal != int(s.gid) {
+				identity = false
+			}
+		}
+		var cidToGID []glyph.ID
+		if !identity {
+			cidToGID = make([]glyph.ID, maxCID+1)
+			for _, s := range slots {
+				cidToGID[int(s.code[0])<<8|int(s.code[1])] = s.gid
+			}
+		}
+		tu, err := cmap.NewToUnicodeFile(charcode.UCS2, toUni)
+		if err != nil {
+			return nil, err
+		}
+
+		// composite fonts require MissingWidth to be zero
+		fd.MissingWidth = 0
+		fontDict = &dict.CIDFontType2{
+			PostScriptName:  base.psName,
+			SubsetTag:       tag,
+			Descriptor:      &fd,
+			ROS:             cmap.NewGIDToCIDIdentity().ROS(),
+			CMap:            cmapFile,
+			Width:           ww,
+			DefaultWidth:    math.Round(sf.GlyphWidthPDF(0)),
+			DefaultVMetrics: dict.DefaultVMetricsDefault,
+			ToUnicode:       tu,
+			CIDToGID:        cidToGID,
+			FontFile:        sfntglyphs.ToStream(sf, glyphdata.TrueType),
+		}
+	}
+
+	obj, err := c.rm.Embed(fontDict)
+	if err != nil {
+		return nil, err
+	}
+	ref, isRef := obj.(pdf.Reference)
+	if !isRef {
+		return nil, errFontNotIndirect
+	}
+
+	for i, m := range g.members {
+		m.newCode = make(map[string][]byte, len(memberSlots[i]))
+		for key, s := range memberSlots[i] {
+			m.newCode[key] = s.code
+		}
+		c.copy.Redirect(m.ref, ref)
+	}
+	g.merged = true
+
+	return &Merged{
+		PostScriptName: fontName,
+		Fonts:          len(g.members),
+		Glyphs:         len(b.glyphs),
+	}, nil
+}
+
+// assignSimple assigns single-byte codes to the slots of a merged simple
+// font.  Where possible, the slots keep their original codes.  Word
+// spacing only applies to code 32, so this code is reserved for the slot
+// which used it in the member fonts.  The return value is false if the
+// slots do not fit into 256 codes.
+func assignSimple(slots []*slot) bool {
+	var taken [256]bool
+	for _, s := range slots {
+		if !s.useWS {
+			continue
+		}
+		if taken[32] {
+			return false
+		}
+		s.code = []byte{32}
+		taken[32] = true
+	}
+	var rest []*slot
+	for _, s := range slots {
+		if s.useWS {
+			continue
+		}
+		if code := s.orig[0]; code != 32 && !taken[code] {
+			s.code = []byte{code}
+			taken[code] = true
+		} else {
+			rest = append(rest, s)
+		}
+	}
+	next := 0
+	for _, s := range rest {
+		for next < 256 && (taken[next] || next == 32) {
+			next++
+		}
+		if next >= 256 {
+			return false
+		}
+		s.code = []byte{byte(next)}
+		taken[next] = true
+	}
+	return true
+}
+
+// assignComposite assigns two-byte codes to the slots of a merged
+// composite font.  With the Identity-H encoding, the codes equal the CIDs.
+// The first slot for each glyph uses the glyph ID as its CID, further slots
+// for the same glyph use CIDs after the last glyph.  The return value is
+// false if a slot needs word spacing, which does not apply to two-byte
+// codes, or if the CIDs run out.
+func assignComposite(slots []*slot, numGlyphs int) bool {
+	used := map[glyph.ID]bool{}
+	next := numGlyphs
+	for _, s := range slots {
+		if s.useWS {
+			return false
+		}
+		cidVal := int(s.gid)
+		if used[s.gid] {
+			cidVal = next
+			next++
+		}
+		if cidVal > 0xFFFF {
+			return false
+		}
+		used[s.gid] = true
+		s.code = []byte{byte(cidVal >> 8), byte(cidVal)}
+	}
+	return true
+}
+
+// mergeMaxp returns the "maxp" table information for the merged font.
+// Each limit is the largest value among the member fonts.
+func mergeMaxp(members []*member) *maxp.TTFInfo {
+	var res *maxp.TTFInfo
+	for _, m := range members {
+		info := m.outlines.Maxp
+		if info == nil {
+			continue
+		}
+		if res == nil {
+			res = &maxp.TTFInfo{}
+		}
+		res.MaxPoints = max(res.MaxPoints, info.MaxPoints)
+		res.MaxContours = max(res.MaxContours, info.MaxContours)
+		res.MaxCompositePoints = max(res.MaxCompositePoints, info.MaxCompositePoints)
+		res.MaxCompositeContours = max(res.MaxCompositeContours, info.MaxCompositeContours)
+		res.MaxZones = max(res.MaxZones, info.MaxZones)
+		res.MaxTwilightPoints = max(res.MaxTwilightPoints, info.MaxTwilightPoints)
+		res.MaxStorage = max(res.MaxStorage, info.MaxStorage)
+		res.MaxFunctionDefs = max(res.MaxFunctionDefs, info.MaxFunctionDefs)
+		res.MaxInstructionDefs = max(res.MaxInstructionDefs, info.MaxInstructionDefs)
+		res.MaxStackElements = max(res.MaxStackElements, info.MaxStackElements)
+		res.MaxSizeOfInstructions = max(res.MaxSizeOfInstructions, info.MaxSizeOfInstructions)
+		res.MaxComponentElements = max(res.MaxComponentElements, info.MaxComponentElements)
+		res.MaxComponentDepth = max(res.MaxComponentDepth, info.MaxComponentDepth)
+	}
+	return res
+}
@@ -0,0 +1,317 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package fontmerge
+
+import (
+	"maps"
+	"slices"
+
+	"seehuhn.de/go/pdf"
+)
+
+// stream is a content stream, together with the fonts named in its
+// resources.
+type stream struct {
+	// ref is the stream object, or 0 for the contents of a page.
+	ref pdf.Reference
+
+	// newRef is the reference of the rewritten stream.
+	newRef pdf.Reference
+
+	// contents is the stream object, or the Contents entry of a page.
+	contents pdf.Object
+
+	// fonts maps font resource names to the font dictionaries.  Fonts
+	// which are not given by reference are omitted.
+	fonts map[pdf.Name]pdf.Reference
+
+	// uses lists the fonts in which the stream shows text.
+	uses map[*member]bool
+}
+
+// needsRewrite reports whether the stream shows text in a font which
+// was merged.
+func (s *stream) needsRewrite() bool {
+	for m := range s.uses {
+		if m.group != nil && m.group.merged {
+			return true
+		}
+	}
+	return false
+}
+
+// visitPage collects the content streams and fonts of a page.  The return
+// value describes the page contents.
+func (c *converter) visitPage(dict pdf.Dict) (*stream, error) {
+	cur := pdf.CursorAt(c.x, nil)
+
+	res := dict["Resources"]
+	fonts, err := c.fontRefs(res)
+	if err != nil {
+		return nil, err
+	}
+	s := &stream{
+		contents: dict["Contents"],
+		fonts:    fonts,
+		uses:     map[*member]bool{},
+	}
+	if err := c.visitResources(res); err != nil {
+		return nil, err
+	}
+
+	annots, err := cur.Array(dict["Annots"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	for _, obj := range annots {
+		annot, err := cur.Dict(obj)
+		if pdf.IsReadError(err) {
+			return nil, err
+		}
+		ap, err := cur.Dict(annot["AP"])
+		if pdf.IsReadError(err) {
+			return nil, err
+		}
+		for _, key := range []pdf.Name{"N", "R", "D"} {
+			if err := c.visitAppearance(ap[key]); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// visitAppearance collects the content streams of an entry in an
+// appearance dictionary.  The entry is either a single stream, or a
+// dictionary which maps appearance states to streams.
+func (c *converter) visitAppearance(obj pdf.Object) error {
+	resolved, err := pdf.CursorAt(c.x, nil).Resolve(obj)
+	if pdf.IsReadError(err) {
+		return err
+	}
+	switch x := resolved.(type) {
+	case *pdf.Stream:
+		return c.visitStream(obj, nil)
+	case pdf.Dict:
+		for _, key := range slices.Sorted(maps.Keys(x)) {
+			if err := c.visitStream(x[key], nil); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// visitStream collects a content stream, together with the content streams
+// and fonts reachable from its resources.  If the stream has no resource
+// dictionary, parentRes is used instead.
+func (c *converter) visitStream(obj pdf.Object, parentRes pdf.Object) error {
+	ref, ok := obj.(pdf.Reference)
+	if !ok {
+		return nil
+	}
+	stm, err := pdf.CursorAt(c.x, nil).Stream(ref)
+	if pdf.IsReadError(err) {
+		return err
+	}
+	if stm == nil {
+		return nil
+	}
+
+	res := stm.Dict["Resources"]
+	if res == nil {
+		res = parentRes
+	}
+	fonts, err := c.fontRefs(res)
+	if err != nil {
+		return err
+	}
+
+	if s, ok := c.streams[ref]; ok {
+		// A stream without resources, used in different contexts, may
+		// name different fonts each time.  Such fonts are left unchanged.
+		if !maps.Equal(s.fonts, fonts) {
+			for _, fontRef := range s.fonts {
+				c.pinned[fontRef] = true
+			}
+			for _, fontRef := range fonts {
+				c.pinned[fontRef] = true
+			}
+		}
+		return nil
+	}
+	s := &stream{
+		ref:      ref,
+		contents: ref,
+		fonts:    fonts,
+		uses:     map[*member]bool{},
+	}
+	c.streams[ref] = s
+	c.streamOrder = append(c.streamOrder, s)
+
+	return c.visitResources(res)
+}
+
+// visitResources collects the fonts in a resource dictionary, and the
+// content streams of the form XObjects, tiling patterns, soft masks and
+// Type 3 fonts.
+func (c *converter) visitResources(res pdf.Object) error {
+	if ref, ok := res.(pdf.Reference); ok {
+		if c.resources[ref] {
+			return nil
+		}
+		c.resources[ref] = true
+	}
+
+	cur := pdf.CursorAt(c.x, nil)
+	dict, err := cur.Dict(res)
+	if pdf.IsReadError(err) {
+		return err
+	}
+	if dict == nil {
+		return nil
+	}
+
+	fonts, err := cur.Dict(dict["Font"])
+	if pdf.IsReadError(err) {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(fonts)) {
+		fontDict, err := cur.Dict(fonts[name])
+		if pdf.IsReadError(err) {
+			return err
+		}
+		if fontDict["Subtype"] == pdf.Name("Type3") {
+			procs, err := cur.Dict(fontDict["CharProcs"])
+			if pdf.IsReadError(err) {
+				return err
+			}
+			procRes := fontDict["Resources"]
+			if procRes == nil {
+				procRes = res
+			}
+			for _, key := range slices.Sorted(maps.Keys(procs)) {
+				if err := c.visitStream(procs[key], procRes); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if ref, ok := fonts[name].(pdf.Reference); ok {
+			if err := c.addFont(ref); err != nil {
+				return err
+			}
+		}
+	}
+
+	xObjects, err := cur.Dict(dict["XObject"])
+	if pdf.IsReadError(err) {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(xObjects)) {
+		stm, err := cur.Stream(xObjects[name])
+		if pdf.IsReadError(err) {
+			return err
+		}
+		if stm == nil || stm.Dict["Subtype"] != pdf.Name("Form") {
+			continue
+		}
+		if err := c.visitStream(xObjects[name], res); err != nil {
+			return err
+		}
+	}
+
+	patterns, err := cur.Dict(dict["Pattern"])
+	if pdf.IsReadError(err) {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(patterns)) {
+		stm, err := cur.Stream(patterns[name])
+		if pdf.IsReadError(err) {
+			return err
+		}
+		if stm == nil {
+			continue
+		}
+		if err := c.visitStream(patterns[name], res); err != nil {
+			return err
+		}
+	}
+
+	extGStates, err := cur.Dict(dict["ExtGState"])
+	if pdf.IsReadError(err) {
+		return err
+	}
+	for _, name := range slices.Sorted(maps.Keys(extGStates)) {
+		gs, err := cur.Dict(extGStates[name])
+		if pdf.IsReadError(err) {
+			return err
+		}
+		mask, err := cur.Dict(gs["SMask"])
+		if pdf.IsReadError(err) {
+			return err
+		}
+		if mask == nil {
+			continue
+		}
+		if err := c.visitStream(mask["G"], res); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fontRefs returns the fonts named in a resource dictionary.  Fonts which
+// are not given by reference are omitted.
+func (c *converter) fontRefs(res pdf.Object) (map[pdf.Name]pdf.Reference, error) {
+	cur := pdf.CursorAt(c.x, nil)
+	dict, err := cur.Dict(res)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	fonts, err := cur.Dict(dict["Font"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	refs := make(map[pdf.Name]pdf.Reference, len(fonts))
+	for name, obj := range fonts {
+		if ref, ok := obj.(pdf.Reference); ok {
+			refs[name] = ref
+		}
+	}
+	return refs, nil
+}
+
+// pinFormFonts marks the fonts in the default resources of the interactive
+// form as fixed.  Viewers use these fonts to generate new appearance
+// streams, which may need glyphs not shown anywhere in the document.
+func (c *converter) pinFormFonts(acroForm pdf.Object) error {
+	cur := pdf.CursorAt(c.x, nil)
+	form, err := cur.Dict(acroForm)
+	if pdf.IsReadError(err) {
+		return err
+	}
+	fonts, err := c.fontRefs(form["DR"])
+	if err != nil {
+		return err
+	}
+	for _, ref := range fonts {
+		c.pinned[ref] = true
+	}
+	return nil
+}
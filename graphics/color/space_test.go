@@ -1446,3 +1446,34 @@ func TestExtractDeviceNRepairsAttributes(t *testing.T) {
 		})
 	}
 }
+
+func TestIndexedLookup(t *testing.T) {
+	space, err := Indexed([]Color{
+		DeviceRGB{0, 0, 0},
+		DeviceRGB{1, 0.6, 0.2},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		index int
+		want  []float64
+	}{
+		{0, []float64{0, 0, 0}},
+		{1, []float64{1, 0.6, 0.2}},
+		{5, []float64{1, 0.6, 0.2}}, // out of range, clamped
+		{-1, []float64{0, 0, 0}},
+	}
+	for _, c := range cases {
+		got := space.Lookup(c.index)
+		if len(got) != len(c.want) {
+			t.Fatalf("Lookup(%d) = %v, want %v", c.index, got, c.want)
+		}
+		for i := range got {
+			if math.Abs(got[i]-c.want[i]) > 1.0/255 {
+				t.Errorf("Lookup(%d) = %v, want %v", c.index, got, c.want)
+				break
+			}
+		}
+	}
+}
@@ -230,6 +230,14 @@ func (s *SpaceIndexed) Convert(c stdcolor.Color) stdcolor.Color {
 	return colorIndexed{Space: s, Index: bestIdx}
 }
 
+// Lookup returns the component values of a palette entry in the base color
+// space.  An index outside [0, NumCol-1] is adjusted to the nearest valid
+// value.
+func (s *SpaceIndexed) Lookup(index int) []float64 {
+	index = max(0, min(index, s.NumCol-1))
+	return slices.Clone(s.lookupValues(index, &icc.Workspace{}))
+}
+
 // lookupValues decodes the palette entry at the given index into color values
 // for the base color space.
 func (s *SpaceIndexed) lookupValues(index int, ws *icc.Workspace) []float64 {
@@ -0,0 +1,38 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package imageextract locates the images drawn in a PDF document and
+// exports them to standard image file formats.
+//
+// [Walk] visits the content stream of every page, recursing into form
+// XObjects, the tiling patterns used for filling and stroking, and the
+// normal appearances of annotations.  For every image XObject or inline
+// image it finds, it reports an [Image] which records where the image is
+// placed: the page, the transformation from image space to the default user
+// space of the page, and the effective resolution.
+//
+// [Image.Write] stores the image in a file format close to how the image is
+// stored in the PDF file:
+//
+//   - DCT-encoded images are written unchanged, as JPEG files;
+//   - JPX-encoded images are written unchanged, as JPEG 2000 files;
+//   - JBIG2-encoded images are written as PBM files;
+//   - other bilevel images and stencil masks are written as PNG or as TIFF
+//     files with CCITT Group 4 compression;
+//   - all other images are converted to sRGB, using ICC profiles where
+//     present, and written as PNG files.  Soft masks, image masks and
+//     colour-key masks become the alpha channel of the PNG image.
+package imageextract
@@ -0,0 +1,433 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package imageextract
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/membudget"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/image"
+	"seehuhn.de/go/pdf/internal/limits"
+)
+
+// Image describes one placement of an image on a page.
+type Image struct {
+	// Page is the zero-based index of the page on which the image is drawn.
+	Page int
+
+	// Source describes the kind of content stream which draws the image.
+	Source Source
+
+	// Ref is the reference of the image XObject, or 0 for inline images.
+	Ref pdf.Reference
+
+	// Inline numbers the inline images of a page, starting from 1.
+	// The value is 0 for image XObjects.
+	Inline int
+
+	// CTM maps the unit square of image space to the default user space
+	// of the page.
+	CTM matrix.Matrix
+
+	// Width and Height give the size of the image in pixels.
+	Width, Height int
+
+	// DPIX and DPIY give the effective resolution of the image on the page,
+	// in pixels per inch along the image's horizontal and vertical axes.
+	// The values take the /UserUnit of the page into account.
+	DPIX, DPIY float64
+
+	// XObject is the decoded image, either an [*image.Dict] or an
+	// [*image.Mask].  For inline images, this is an equivalent image
+	// constructed from the inline image dictionary.
+	XObject graphics.Image
+
+	r pdf.Getter
+
+	// stream is the encoded image stream of an image XObject.
+	stream *pdf.Stream
+
+	// inlineData and inlineFilters hold the encoded data and the filter
+	// chain of an inline image.
+	inlineData    []byte
+	inlineFilters []pdf.Filter
+}
+
+// Format is an image file format used by [Image.Write].
+type Format int
+
+const (
+	// FormatPNG is the PNG format.
+	FormatPNG Format = iota
+
+	// FormatJPEG is the JPEG format.  It is only available for
+	// DCT-encoded images, which are written without re-encoding.
+	FormatJPEG
+
+	// FormatJPX is the JPEG 2000 format.  It is only available for
+	// JPX-encoded images, which are written without re-encoding.
+	FormatJPX
+
+	// FormatPBM is the binary portable bitmap format.  It is only
+	// available for bilevel images.
+	FormatPBM
+
+	// FormatTIFF is TIFF with CCITT Group 4 compression.  It is only
+	// available for bilevel images.
+	FormatTIFF
+)
+
+// Ext returns the customary file name extension for the format,
+// including the leading dot.
+func (f Format) Ext() string {
+	switch f {
+	case FormatPNG:
+		return ".png"
+	case FormatJPEG:
+		return ".jpg"
+	case FormatJPX:
+		return ".jp2"
+	case FormatPBM:
+		return ".pbm"
+	case FormatTIFF:
+		return ".tif"
+	default:
+		return ""
+	}
+}
+
+func (f Format) String() string {
+	switch f {
+	case FormatPNG:
+		return "PNG"
+	case FormatJPEG:
+		return "JPEG"
+	case FormatJPX:
+		return "JPEG 2000"
+	case FormatPBM:
+		return "PBM"
+	case FormatTIFF:
+		return "TIFF"
+	default:
+		return fmt.Sprintf("Format(%d)", int(f))
+	}
+}
+
+// NativeFormat returns the file format which is closest to how the image is
+// stored in the PDF file.  DCT and JPX images map to [FormatJPEG] and
+// [FormatJPX], JBIG2 images to [FormatPBM], and other bilevel images to
+// bilevel (which must be [FormatPNG] or [FormatTIFF]).  All remaining images
+// map to [FormatPNG].
+func (img *Image) NativeFormat(bilevel Format) Format {
+	switch img.lastFilter() {
+	case "DCTDecode":
+		return FormatJPEG
+	case "JPXDecode":
+		return FormatJPX
+	case "JBIG2Decode":
+		if img.IsBilevel() {
+			return FormatPBM
+		}
+	}
+	if img.IsBilevel() {
+		return bilevel
+	}
+	return FormatPNG
+}
+
+// IsBilevel reports whether the image has only two colours and no
+// transparency, so that it can be stored in a bilevel image format.
+// This is the case for stencil masks and for single-channel images with
+// one bit per sample.
+func (img *Image) IsBilevel() bool {
+	switch x := img.XObject.(type) {
+	case *image.Mask:
+		return true
+	case *image.Dict:
+		if x.ColorSpace == nil || x.BitsPerComponent != 1 {
+			return false
+		}
+		if x.ColorSpace.Channels() != 1 || x.ColorSpace.Family() == color.FamilyIndexed {
+			return false
+		}
+		return x.SMask == nil && x.MaskImage == nil && x.MaskColors == nil
+	}
+	return false
+}
+
+// Write stores the image in the given format.
+//
+// [FormatJPEG] and [FormatJPX] are only supported for images which are
+// encoded in these formats in the PDF file; the encoded data is copied
+// unchanged.  [FormatPBM] and [FormatTIFF] are only supported for bilevel
+// images, see [Image.IsBilevel].  [FormatPNG] is supported for all images
+// except JPX-encoded ones.
+func (img *Image) Write(w io.Writer, f Format) error {
+	switch f {
+	case FormatJPEG:
+		return img.writeEncoded(w, "DCTDecode")
+	case FormatJPX:
+		return img.writeEncoded(w, "JPXDecode")
+	case FormatPBM, FormatTIFF:
+		if !img.IsBilevel() {
+			return errors.New("image is not bilevel")
+		}
+		bits, err := img.bilevel()
+		if err != nil {
+			return err
+		}
+		if f == FormatPBM {
+			return writePBM(w, bits, img.Width, img.Height)
+		}
+		return writeTIFF(w, bits, img.Width, img.Height, img.DPIX, img.DPIY)
+	case FormatPNG:
+		if img.IsBilevel() {
+			bits, err := img.bilevel()
+			if err != nil {
+				return err
+			}
+			return writeBilevelPNG(w, bits, img.Width, img.Height)
+		}
+		return img.writePNG(w)
+	default:
+		return fmt.Errorf("unsupported image format %d", int(f))
+	}
+}
+
+// filters returns the filter chain of the encoded image data.
+func (img *Image) filters() []pdf.Filter {
+	if img.stream == nil {
+		return img.inlineFilters
+	}
+	filters, err := pdf.GetFilters(img.r, nil, img.stream.Dict)
+	if err != nil {
+		return nil
+	}
+	return filters
+}
+
+// lastFilter returns the name of the last filter in the decoding chain,
+// i.e. the one which defines the image compression format.
+func (img *Image) lastFilter() pdf.Name {
+	filters := img.filters()
+	if len(filters) == 0 {
+		return ""
+	}
+	name, _, _ := filters[len(filters)-1].Info(pdf.GetVersion(img.r))
+	return name
+}
+
+// writeEncoded writes the image data with all filters except the last one
+// removed.  The last filter must be the one named by last.
+func (img *Image) writeEncoded(w io.Writer, last pdf.Name) error {
+	if img.lastFilter() != last {
+		return fmt.Errorf("image is not %s-encoded", last)
+	}
+	filters := img.filters()
+
+	var src io.ReadCloser
+	var size int64
+	if img.stream != nil {
+		var err error
+		src, err = pdf.RawStreamReader(img.r, img.stream)
+		if err != nil {
+			return err
+		}
+		if l, ok := img.stream.Dict["Length"].(pdf.Integer); ok {
+			size = int64(l)
+		}
+	} else {
+		src = io.NopCloser(bytes.NewReader(img.inlineData))
+		size = int64(len(img.inlineData))
+	}
+	defer src.Close()
+
+	v := pdf.GetVersion(img.r)
+	budget := membudget.New(limits.StreamBudget(size))
+	var r io.ReadCloser = src
+	for _, f := range filters[:len(filters)-1] {
+		if _, isCrypt := f.(pdf.CryptFilter); isCrypt {
+			// already decrypted by RawStreamReader
+			continue
+		}
+		var err error
+		r, err = f.Decode(v, r, budget)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := io.Copy(w, r)
+	return err
+}
+
+// inlineImage constructs an image equivalent to an inline image.  It also
+// returns the filter chain of the inline image data.
+func inlineImage(op content.Operator, res *content.Resources) (graphics.Image, []pdf.Filter, error) {
+	if len(op.Args) < 2 {
+		return nil, nil, errors.New("malformed inline image")
+	}
+	dict, ok := op.Args[0].(pdf.Dict)
+	if !ok {
+		return nil, nil, errors.New("malformed inline image")
+	}
+
+	filters, err := inlineFilters(dict)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := content.DecodeInlineImage(op, res)
+	if err != nil {
+		return nil, nil, err
+	}
+	source := &image.FlateSource{
+		WriteData: func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		},
+	}
+
+	width := inlineInt(dict, "W", "Width")
+	height := inlineInt(dict, "H", "Height")
+	if width <= 0 || height <= 0 {
+		return nil, nil, errors.New("invalid inline image size")
+	}
+	decode, _ := inlineEntry(dict, "D", "Decode").(pdf.Array)
+
+	if isMask, _ := inlineEntry(dict, "IM", "ImageMask").(pdf.Boolean); isMask {
+		inverted := len(decode) == 2 && number(decode[0]) == 1
+		return &image.Mask{
+			Width:    width,
+			Height:   height,
+			Inverted: inverted,
+			Source:   source,
+		}, filters, nil
+	}
+
+	cs := content.InlineImageColorSpace(dict, res)
+	bpc := inlineInt(dict, "BPC", "BitsPerComponent")
+	if cs == nil || bpc <= 0 {
+		return nil, nil, errors.New("incomplete inline image dictionary")
+	}
+	img := &image.Dict{
+		Width:            width,
+		Height:           height,
+		ColorSpace:       cs,
+		BitsPerComponent: bpc,
+		Data:             source,
+	}
+	if len(decode) == 2*cs.Channels() {
+		for _, x := range decode {
+			img.Decode = append(img.Decode, number(x))
+		}
+	}
+	return img, filters, nil
+}
+
+// inlineFilters returns the filter chain of an inline image.
+func inlineFilters(dict pdf.Dict) ([]pdf.Filter, error) {
+	var names []pdf.Name
+	switch f := inlineEntry(dict, "F", "Filter").(type) {
+	case nil:
+		return nil, nil
+	case pdf.Name:
+		names = []pdf.Name{f}
+	case pdf.Array:
+		for _, elem := range f {
+			name, ok := elem.(pdf.Name)
+			if !ok {
+				return nil, errors.New("malformed inline image filter")
+			}
+			names = append(names, name)
+		}
+	default:
+		return nil, errors.New("malformed inline image filter")
+	}
+
+	parms := inlineEntry(dict, "DP", "DecodeParms")
+	filters := make([]pdf.Filter, len(names))
+	for i, name := range names {
+		if full, ok := inlineFilterNames[name]; ok {
+			name = full
+		}
+		var p pdf.Dict
+		switch parms := parms.(type) {
+		case pdf.Dict:
+			if i == 0 {
+				p = parms
+			}
+		case pdf.Array:
+			if i < len(parms) {
+				p, _ = parms[i].(pdf.Dict)
+			}
+		}
+		f, err := pdf.MakeFilter(name, p)
+		if err != nil {
+			return nil, err
+		}
+		filters[i] = f
+	}
+	return filters, nil
+}
+
+// inlineFilterNames maps abbreviated inline image filter names to their
+// full names.
+var inlineFilterNames = map[pdf.Name]pdf.Name{
+	"AHx": "ASCIIHexDecode",
+	"A85": "ASCII85Decode",
+	"LZW": "LZWDecode",
+	"Fl":  "FlateDecode",
+	"RL":  "RunLengthDecode",
+	"CCF": "CCITTFaxDecode",
+	"DCT": "DCTDecode",
+}
+
+// inlineEntry returns the value of an inline image dictionary entry, which
+// can be given under an abbreviated or a full key.
+func inlineEntry(dict pdf.Dict, abbrev, full pdf.Name) pdf.Object {
+	if v, ok := dict[abbrev]; ok {
+		return v
+	}
+	return dict[full]
+}
+
+// inlineInt returns the value of an integer entry in an inline image
+// dictionary, or 0 if the entry is missing or malformed.
+func inlineInt(dict pdf.Dict, abbrev, full pdf.Name) int {
+	return int(number(inlineEntry(dict, abbrev, full)))
+}
+
+// number returns the value of a numeric PDF object, or 0 for other objects.
+func number(obj pdf.Object) float64 {
+	switch x := obj.(type) {
+	case pdf.Integer:
+		return float64(x)
+	case pdf.Real:
+		return float64(x)
+	case pdf.Number:
+		return float64(x)
+	}
+	return 0
+}
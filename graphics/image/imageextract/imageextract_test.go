@@ -0,0 +1,361 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package imageextract
+
+import (
+	"bytes"
+	goimage "image"
+	gocolor "image/color"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+
+	"seehuhn.de/go/geom/matrix"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/document"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/content/builder"
+	"seehuhn.de/go/pdf/graphics/form"
+	"seehuhn.de/go/pdf/graphics/image"
+	"seehuhn.de/go/pdf/graphics/pattern"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+)
+
+// testImage returns an RGBA image with a simple gradient.
+func testImage(w, h int) *goimage.NRGBA {
+	img := goimage.NewNRGBA(goimage.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, gocolor.NRGBA{R: uint8(x * 255 / w), G: uint8(y * 255 / h), B: 128, A: 255})
+		}
+	}
+	return img
+}
+
+// makeDoc writes a two-page document.  Page 1 shows an RGB image at
+// 144x72 points and an inline bilevel image; page 2 shows a JPEG image
+// inside a form XObject.
+func makeDoc(t *testing.T) *pdf.Reader {
+	t.Helper()
+	buf := memfile.New()
+	doc, err := document.WriteMultiPage(buf, document.A4, pdf.V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := doc.AddPage()
+	rgb := image.FromImage(testImage(40, 20), color.SpaceDeviceRGB, 8)
+	p.PushGraphicsState()
+	p.Transform(matrix.Matrix{144, 0, 0, 72, 100, 500})
+	p.DrawXObject(rgb)
+	p.PopGraphicsState()
+	p.PushGraphicsState()
+	p.Transform(matrix.Matrix{72, 0, 0, 72, 100, 300})
+	p.DrawInlineImageRaw(pdf.Dict{
+		"W":   pdf.Integer(8),
+		"H":   pdf.Integer(2),
+		"CS":  pdf.Name("G"),
+		"BPC": pdf.Integer(1),
+	}, []byte{0x0F, 0xF0})
+	p.PopGraphicsState()
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	p = doc.AddPage()
+	jpg := &image.Dict{
+		Width:            16,
+		Height:           16,
+		ColorSpace:       color.SpaceDeviceRGB,
+		BitsPerComponent: 8,
+		Data:             &image.DCTSource{Image: testImage(16, 16)},
+	}
+	b := builder.New(content.Form, nil, pdf.V1_7)
+	b.Transform(matrix.Matrix{50, 0, 0, 50, 0, 0})
+	b.DrawXObject(jpg)
+	f := &form.Form{
+		Content: &content.Operators{Ops: b.Stream},
+		Res:     b.Resources,
+		BBox:    pdf.Rectangle{URx: 100, URy: 100},
+		Matrix:  matrix.Translate(200, 200),
+	}
+	p.DrawXObject(f)
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := doc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := pdf.NewReader(buf, int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func collect(t *testing.T, r pdf.Getter, opts *Options) []*Image {
+	t.Helper()
+	var res []*Image
+	err := Walk(r, opts, func(img *Image) error {
+		res = append(res, img)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+func TestWalk(t *testing.T) {
+	r := makeDoc(t)
+	images := collect(t, r, nil)
+	if len(images) != 3 {
+		t.Fatalf("got %d images, want 3", len(images))
+	}
+
+	rgb, inline, jpg := images[0], images[1], images[2]
+
+	if rgb.Page != 0 || rgb.Source != SourcePage || rgb.Ref == 0 {
+		t.Errorf("unexpected placement for RGB image: %+v", rgb)
+	}
+	if rgb.Width != 40 || rgb.Height != 20 {
+		t.Errorf("RGB image size %dx%d, want 40x20", rgb.Width, rgb.Height)
+	}
+	// 40 pixels over 2 inches, 20 pixels over 1 inch
+	if math.Abs(rgb.DPIX-20) > 1e-6 || math.Abs(rgb.DPIY-20) > 1e-6 {
+		t.Errorf("RGB image resolution %gx%g, want 20x20", rgb.DPIX, rgb.DPIY)
+	}
+	if got := rgb.NativeFormat(FormatPNG); got != FormatPNG {
+		t.Errorf("RGB image format %v, want PNG", got)
+	}
+
+	if inline.Page != 0 || inline.Ref != 0 || inline.Inline != 1 {
+		t.Errorf("unexpected placement for inline image: %+v", inline)
+	}
+	if !inline.IsBilevel() {
+		t.Error("inline image not recognised as bilevel")
+	}
+	if got := inline.NativeFormat(FormatTIFF); got != FormatTIFF {
+		t.Errorf("inline image format %v, want TIFF", got)
+	}
+
+	if jpg.Page != 1 || jpg.Source != SourceForm {
+		t.Errorf("unexpected placement for JPEG image: %+v", jpg)
+	}
+	want := matrix.Matrix{50, 0, 0, 50, 200, 200}
+	for i := range want {
+		if math.Abs(jpg.CTM[i]-want[i]) > 1e-6 {
+			t.Errorf("JPEG image CTM %v, want %v", jpg.CTM, want)
+			break
+		}
+	}
+	if got := jpg.NativeFormat(FormatPNG); got != FormatJPEG {
+		t.Errorf("JPEG image format %v, want JPEG", got)
+	}
+}
+
+func TestWalkPages(t *testing.T) {
+	r := makeDoc(t)
+	images := collect(t, r, &Options{Pages: []int{1}})
+	if len(images) != 1 || images[0].Page != 1 {
+		t.Errorf("got %d images, want one image on page 2", len(images))
+	}
+}
+
+func TestWrite(t *testing.T) {
+	r := makeDoc(t)
+	images := collect(t, r, nil)
+
+	// RGB image as PNG
+	var buf bytes.Buffer
+	if err := images[0].Write(&buf, FormatPNG); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := decoded.Bounds(); b.Dx() != 40 || b.Dy() != 20 {
+		t.Errorf("PNG size %dx%d, want 40x20", b.Dx(), b.Dy())
+	}
+
+	// bilevel inline image as PBM
+	buf.Reset()
+	if err := images[1].Write(&buf, FormatPBM); err != nil {
+		t.Fatal(err)
+	}
+	wantPBM := []byte("P4\n8 2\n\xf0\x0f") // black is 1 in PBM
+	if !bytes.Equal(buf.Bytes(), wantPBM) {
+		t.Errorf("PBM data %q, want %q", buf.Bytes(), wantPBM)
+	}
+
+	// bilevel inline image as TIFF
+	buf.Reset()
+	if err := images[1].Write(&buf, FormatTIFF); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(buf.Bytes(), []byte("II*\x00")) {
+		t.Errorf("missing TIFF header: %q", buf.Bytes()[:4])
+	}
+
+	// JPEG image is copied unchanged
+	buf.Reset()
+	if err := images[2].Write(&buf, FormatJPEG); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := jpeg.DecodeConfig(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Width != 16 || cfg.Height != 16 {
+		t.Errorf("JPEG size %dx%d, want 16x16", cfg.Width, cfg.Height)
+	}
+
+	// JPEG export is not available for other images
+	if err := images[0].Write(&buf, FormatJPEG); err == nil {
+		t.Error("JPEG export of a Flate image succeeded")
+	}
+}
+
+func TestSoftMaskAlpha(t *testing.T) {
+	src := testImage(4, 4)
+	for x := range 4 {
+		src.Pix[src.PixOffset(x, 0)+3] = 0
+	}
+
+	buf := memfile.New()
+	doc, err := document.WriteMultiPage(buf, document.A4, pdf.V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := doc.AddPage()
+	p.Transform(matrix.Scale(100, 100))
+	p.DrawXObject(image.FromImageWithMask(src, src, color.SpaceDeviceRGB, 8))
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := pdf.NewReader(buf, int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images := collect(t, r, nil)
+	if len(images) != 1 {
+		t.Fatalf("got %d images, want 1", len(images))
+	}
+	var out bytes.Buffer
+	if err := images[0].Write(&out, FormatPNG); err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := png.Decode(&out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, a := decoded.At(1, 0).RGBA(); a != 0 {
+		t.Errorf("top row alpha %d, want 0", a)
+	}
+	if _, _, _, a := decoded.At(1, 1).RGBA(); a != 0xffff {
+		t.Errorf("second row alpha %d, want opaque", a)
+	}
+}
+
+// imagePattern returns a coloured tiling pattern whose cell shows an
+// RGB image of the given size, scaled to 10x10 units.
+func imagePattern(w, h int) *pattern.Type1 {
+	b := builder.New(content.PatternColored, nil, pdf.V1_7)
+	b.Transform(matrix.Scale(10, 10))
+	b.DrawXObject(image.FromImage(testImage(w, h), color.SpaceDeviceRGB, 8))
+	return &pattern.Type1{
+		TilingType: 1,
+		BBox:       pdf.Rectangle{URx: 10, URy: 10},
+		XStep:      10,
+		YStep:      10,
+		Color:      true,
+		Content:    &content.Operators{Ops: b.Stream},
+		Res:        b.Resources,
+		Matrix:     matrix.Translate(100, 400),
+	}
+}
+
+// TestWalkPatterns checks that only the tiling patterns which are used to
+// paint an area are visited.
+func TestWalkPatterns(t *testing.T) {
+	buf := memfile.New()
+	doc, err := document.WriteMultiPage(buf, document.A4, pdf.V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := doc.AddPage()
+	used := imagePattern(4, 4)
+	selected := imagePattern(5, 5)
+	unused := imagePattern(6, 6)
+
+	// selected, but replaced before anything is painted
+	p.SetFillColor(color.PatternColored(selected))
+	p.SetFillColor(color.DeviceRGB{1, 0, 0})
+	p.Rectangle(0, 0, 10, 10)
+	p.Fill()
+
+	// The pattern matrix refers to the default coordinate space of the
+	// page, so the scaling does not change where the cell is drawn.
+	p.PushGraphicsState()
+	p.Transform(matrix.Scale(2, 2))
+	p.SetFillColor(color.PatternColored(used))
+	p.Rectangle(50, 200, 100, 100)
+	p.Fill()
+	p.PopGraphicsState()
+
+	// after Q, the fill colour is no longer the pattern
+	p.Rectangle(0, 0, 10, 10)
+	p.Fill()
+
+	// listed in the resource dictionary, but never selected
+	p.Resources.Pattern["Unused"] = unused
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := pdf.NewReader(buf, int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	images := collect(t, r, nil)
+	if len(images) != 1 {
+		t.Fatalf("got %d images, want 1", len(images))
+	}
+	img := images[0]
+	if img.Source != SourcePattern || img.Width != 4 {
+		t.Errorf("unexpected image: source %v, width %d", img.Source, img.Width)
+	}
+	want := matrix.Matrix{10, 0, 0, 10, 100, 400}
+	for i := range want {
+		if math.Abs(img.CTM[i]-want[i]) > 1e-6 {
+			t.Errorf("pattern image CTM %v, want %v", img.CTM, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,253 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package imageextract
+
+import (
+	"errors"
+	"fmt"
+	goimage "image"
+	gocolor "image/color"
+	"image/png"
+	"io"
+
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/image"
+)
+
+// bilevel returns the pixels of a bilevel image, packed eight to a byte with
+// every row starting on a byte boundary.  A set bit represents a black
+// pixel.  For stencil masks, the painted pixels are black.
+func (img *Image) bilevel() ([]byte, error) {
+	w, h := img.Width, img.Height
+	rowBytes := (w + 7) / 8
+	bits := make([]byte, rowBytes*h)
+
+	switch x := img.XObject.(type) {
+	case *image.Mask:
+		alpha, err := x.LoadAlpha()
+		if err != nil {
+			return nil, err
+		}
+		for y := range h {
+			for i := range w {
+				if alpha.Pix[y*alpha.Stride+i] != 0 {
+					bits[y*rowBytes+i/8] |= 0x80 >> (i % 8)
+				}
+			}
+		}
+	case *image.Dict:
+		data, err := x.Load()
+		if err != nil {
+			return nil, err
+		}
+		rgba := data.ToRGBA()
+		for y := range h {
+			for i := range w {
+				p := rgba.PixOffset(i, y)
+				r, g, b := int(rgba.Pix[p]), int(rgba.Pix[p+1]), int(rgba.Pix[p+2])
+				if 299*r+587*g+114*b < 128*1000 {
+					bits[y*rowBytes+i/8] |= 0x80 >> (i % 8)
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported image type %T", img.XObject)
+	}
+	return bits, nil
+}
+
+// writePNG converts the image to sRGB and writes it as a PNG file.  Any mask
+// of the image becomes the alpha channel.
+func (img *Image) writePNG(w io.Writer) error {
+	dict, ok := img.XObject.(*image.Dict)
+	if !ok {
+		return fmt.Errorf("unsupported image type %T", img.XObject)
+	}
+	if dict.Data != nil && dict.Data.IsJPX() {
+		return errors.New("JPXDecode decoding not supported")
+	}
+
+	data, err := dict.Load()
+	if err != nil {
+		return err
+	}
+	rgba := data.ToRGBA()
+
+	alpha, err := imageAlpha(dict)
+	if err != nil {
+		return err
+	}
+	if alpha == nil {
+		return png.Encode(w, rgba)
+	}
+
+	var matte [3]float64
+	hasMatte := dict.SMask != nil && len(dict.SMask.Matte) == dict.ColorSpace.Channels()
+	if hasMatte {
+		r, g, b, _ := color.FromValues(dict.ColorSpace, dict.SMask.Matte, nil).RGBA()
+		matte = [3]float64{float64(r >> 8), float64(g >> 8), float64(b >> 8)}
+	}
+
+	out := goimage.NewNRGBA(rgba.Rect)
+	for y := range img.Height {
+		for x := range img.Width {
+			p := rgba.PixOffset(x, y)
+			a := alpha.Pix[y*alpha.Stride+x]
+			for c := range 3 {
+				v := rgba.Pix[p+c]
+				if hasMatte && a > 0 {
+					// undo the pre-blending with the matte colour
+					m := matte[c]
+					u := m + (float64(v)-m)*255/float64(a)
+					v = uint8(min(max(u+0.5, 0), 255))
+				}
+				out.Pix[p+c] = v
+			}
+			out.Pix[p+3] = a
+		}
+	}
+	return png.Encode(w, out)
+}
+
+// imageAlpha returns the alpha channel of an image at the resolution of the
+// image, or nil if the image is opaque.
+func imageAlpha(dict *image.Dict) (*goimage.Alpha, error) {
+	var alpha *goimage.Alpha
+	var err error
+	switch {
+	case dict.SMask != nil:
+		alpha, err = dict.SMask.LoadAlpha()
+	case dict.MaskImage != nil:
+		alpha, err = dict.MaskImage.LoadAlpha()
+	case dict.MaskColors != nil:
+		alpha, err = colorKeyAlpha(dict)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return scaleAlpha(alpha, dict.Width, dict.Height), nil
+}
+
+// colorKeyAlpha computes the alpha channel for an image with a colour-key
+// mask.  Pixels whose samples all lie within the ranges given by MaskColors
+// are transparent.
+func colorKeyAlpha(dict *image.Dict) (*goimage.Alpha, error) {
+	n := dict.ColorSpace.Channels()
+	bpc := dict.BitsPerComponent
+	if len(dict.MaskColors) != 2*n {
+		return nil, nil
+	}
+	raw, err := dict.Data.Pixels()
+	if err != nil {
+		return nil, err
+	}
+
+	width, height := dict.Width, dict.Height
+	rowBits := width * n * bpc
+	rowBytes := (rowBits + 7) / 8
+	alpha := goimage.NewAlpha(goimage.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			masked := true
+			for c := range n {
+				s := sample(raw, y*rowBytes*8+(x*n+c)*bpc, bpc)
+				if s < dict.MaskColors[2*c] || s > dict.MaskColors[2*c+1] {
+					masked = false
+					break
+				}
+			}
+			if !masked {
+				alpha.Pix[y*alpha.Stride+x] = 255
+			}
+		}
+	}
+	return alpha, nil
+}
+
+// sample extracts a sample of the given bit width, starting at the given bit
+// offset.  Samples beyond the end of data are zero.
+func sample(data []byte, bitOffset, bpc int) uint16 {
+	switch bpc {
+	case 8:
+		i := bitOffset / 8
+		if i >= len(data) {
+			return 0
+		}
+		return uint16(data[i])
+	case 16:
+		i := bitOffset / 8
+		if i+1 >= len(data) {
+			return 0
+		}
+		return uint16(data[i])<<8 | uint16(data[i+1])
+	default:
+		i := bitOffset / 8
+		if i >= len(data) {
+			return 0
+		}
+		shift := 8 - bpc - bitOffset%8
+		return uint16(data[i]>>shift) & (1<<bpc - 1)
+	}
+}
+
+// scaleAlpha resamples an alpha mask to the given size, using
+// nearest-neighbour interpolation.
+func scaleAlpha(a *goimage.Alpha, width, height int) *goimage.Alpha {
+	b := a.Bounds()
+	if b.Dx() == width && b.Dy() == height {
+		return a
+	}
+	out := goimage.NewAlpha(goimage.Rect(0, 0, width, height))
+	if b.Dx() == 0 || b.Dy() == 0 {
+		return out
+	}
+	for y := range height {
+		sy := y * b.Dy() / height
+		for x := range width {
+			sx := x * b.Dx() / width
+			out.Pix[y*out.Stride+x] = a.Pix[sy*a.Stride+sx]
+		}
+	}
+	return out
+}
+
+// writePBM writes a bilevel image in the binary PBM format.
+func writePBM(w io.Writer, bits []byte, width, height int) error {
+	if _, err := fmt.Fprintf(w, "P4\n%d %d\n", width, height); err != nil {
+		return err
+	}
+	_, err := w.Write(bits)
+	return err
+}
+
+// writeBilevelPNG writes a bilevel image as a PNG file with a two-colour
+// palette.
+func writeBilevelPNG(w io.Writer, bits []byte, width, height int) error {
+	palette := gocolor.Palette{gocolor.White, gocolor.Black}
+	out := goimage.NewPaletted(goimage.Rect(0, 0, width, height), palette)
+	rowBytes := (width + 7) / 8
+	for y := range height {
+		for x := range width {
+			if bits[y*rowBytes+x/8]&(0x80>>(x%8)) != 0 {
+				out.Pix[y*out.Stride+x] = 1
+			}
+		}
+	}
+	return png.Encode(w, out)
+}
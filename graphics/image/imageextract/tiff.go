@@ -0,0 +1,143 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package imageextract
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"seehuhn.de/go/pdf"
+)
+
+// TIFF tag numbers and field types, see the TIFF 6.0 specification.
+const (
+	tiffTagImageWidth      = 256
+	tiffTagImageLength     = 257
+	tiffTagBitsPerSample   = 258
+	tiffTagCompression     = 259
+	tiffTagPhotometric     = 262
+	tiffTagStripOffsets    = 273
+	tiffTagSamplesPerPixel = 277
+	tiffTagRowsPerStrip    = 278
+	tiffTagStripByteCounts = 279
+	tiffTagXResolution     = 282
+	tiffTagYResolution     = 283
+	tiffTagT6Options       = 293
+	tiffTagResolutionUnit  = 296
+
+	tiffShort    = 3
+	tiffLong     = 4
+	tiffRational = 5
+
+	tiffCompressionG4  = 4
+	tiffWhiteIsZero    = 0
+	tiffUnitInch       = 2
+	tiffDefaultDPI     = 72
+	tiffRationalDenom  = 100
+	tiffHeaderSize     = 8
+	tiffIFDEntrySize   = 12
+	tiffRationalLength = 8
+)
+
+// writeTIFF writes a bilevel image as a single-strip TIFF file with CCITT
+// Group 4 compression.  A set bit in bits represents a black pixel.  If the
+// resolution is known, it is recorded in the file.
+func writeTIFF(w io.Writer, bits []byte, width, height int, dpiX, dpiY float64) error {
+	var body bytes.Buffer
+	enc, err := pdf.FilterCCITTFax{
+		K:        -1,
+		Columns:  width,
+		BlackIs1: true,
+	}.Encode(pdf.V2_0, nopCloser{&body})
+	if err != nil {
+		return err
+	}
+	if _, err := enc.Write(bits); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+
+	type entry struct {
+		tag, typ uint16
+		value    uint32
+	}
+	const numEntries = 13
+	ifdSize := 2 + numEntries*tiffIFDEntrySize + 4
+	xResOffset := uint32(tiffHeaderSize + ifdSize)
+	yResOffset := xResOffset + tiffRationalLength
+	dataOffset := yResOffset + tiffRationalLength
+
+	entries := [numEntries]entry{
+		{tiffTagImageWidth, tiffLong, uint32(width)},
+		{tiffTagImageLength, tiffLong, uint32(height)},
+		{tiffTagBitsPerSample, tiffShort, 1},
+		{tiffTagCompression, tiffShort, tiffCompressionG4},
+		{tiffTagPhotometric, tiffShort, tiffWhiteIsZero},
+		{tiffTagStripOffsets, tiffLong, dataOffset},
+		{tiffTagSamplesPerPixel, tiffShort, 1},
+		{tiffTagRowsPerStrip, tiffLong, uint32(height)},
+		{tiffTagStripByteCounts, tiffLong, uint32(body.Len())},
+		{tiffTagXResolution, tiffRational, xResOffset},
+		{tiffTagYResolution, tiffRational, yResOffset},
+		{tiffTagT6Options, tiffLong, 0},
+		{tiffTagResolutionUnit, tiffShort, tiffUnitInch},
+	}
+
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	buf.WriteString("II")
+	buf.Write(le.AppendUint16(nil, 42))
+	buf.Write(le.AppendUint32(nil, tiffHeaderSize))
+
+	buf.Write(le.AppendUint16(nil, numEntries))
+	for _, e := range entries {
+		buf.Write(le.AppendUint16(nil, e.tag))
+		buf.Write(le.AppendUint16(nil, e.typ))
+		buf.Write(le.AppendUint32(nil, 1))
+		if e.typ == tiffShort {
+			buf.Write(le.AppendUint16(nil, uint16(e.value)))
+			buf.Write([]byte{0, 0})
+		} else {
+			buf.Write(le.AppendUint32(nil, e.value))
+		}
+	}
+	buf.Write(le.AppendUint32(nil, 0)) // no further IFDs
+
+	for _, dpi := range []float64{dpiX, dpiY} {
+		if !(dpi > 0) || dpi > math.MaxUint32/tiffRationalDenom {
+			dpi = tiffDefaultDPI
+		}
+		buf.Write(le.AppendUint32(nil, uint32(math.Round(dpi*tiffRationalDenom))))
+		buf.Write(le.AppendUint32(nil, tiffRationalDenom))
+	}
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+	_, err = w.Write(body.Bytes())
+	return err
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -0,0 +1,551 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package imageextract
+
+import (
+	"fmt"
+	"math"
+
+	"seehuhn.de/go/geom/matrix"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/annotation/appearance"
+	"seehuhn.de/go/pdf/graphics"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/extract"
+	"seehuhn.de/go/pdf/graphics/form"
+	"seehuhn.de/go/pdf/graphics/pattern"
+	"seehuhn.de/go/pdf/page"
+	"seehuhn.de/go/pdf/pagetree"
+	"seehuhn.de/go/pdf/reader"
+)
+
+// maxDepth bounds the nesting of form XObjects and patterns which [Walk]
+// follows.
+const maxDepth = 32
+
+// Options control which parts of a document [Walk] visits.  The zero value
+// (or a nil *Options) visits every page.
+type Options struct {
+	// Pages selects the pages to visit, as zero-based page indices.
+	// A nil slice visits every page in document order.
+	Pages []int
+}
+
+// Walk calls fn for every image drawn on the pages of the document.
+//
+// An image XObject which is drawn several times is reported once per
+// placement, with the same [Image.Ref].  The walk stops at the first error
+// returned by fn.  Pages or resources which cannot be decoded are skipped.
+func Walk(r pdf.Getter, opts *Options, fn func(*Image) error) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	var want map[int]bool
+	if opts.Pages != nil {
+		want = make(map[int]bool, len(opts.Pages))
+		for _, p := range opts.Pages {
+			want[p] = true
+		}
+	}
+
+	w := &walker{
+		r:  r,
+		x:  pdf.NewExtractor(r),
+		fn: fn,
+	}
+
+	it := pagetree.NewIterator(r)
+	pageNo := 0
+	for _, dict := range it.All() {
+		if want == nil || want[pageNo] {
+			if err := w.walkPage(pageNo, dict); err != nil {
+				return err
+			}
+		}
+		pageNo++
+	}
+	return it.Err
+}
+
+// walker holds the state shared while visiting one document.
+type walker struct {
+	r  pdf.Getter
+	x  *pdf.Extractor
+	fn func(*Image) error
+
+	// per-page state
+	pageNo   int
+	userUnit float64
+	inline   int // number of inline images found on the current page
+
+	// active holds the form XObjects and patterns currently being visited,
+	// to break reference cycles.
+	active map[pdf.Reference]bool
+}
+
+// stream describes a content stream to be visited.
+type stream struct {
+	source  Source
+	content content.Stream
+	typ     content.Type
+	res     *content.Resources
+	rawRes  pdf.Dict
+	ctm     matrix.Matrix
+	depth   int
+}
+
+func (w *walker) walkPage(pageNo int, dict pdf.Dict) error {
+	c := pdf.CursorAt(w.x, nil)
+
+	w.pageNo = pageNo
+	w.inline = 0
+	w.active = make(map[pdf.Reference]bool)
+	w.userUnit = 1
+	if u, err := c.Number(dict["UserUnit"]); err == nil && u > 0 {
+		w.userUnit = u
+	}
+
+	rawRes, _ := c.Dict(dict["Resources"])
+	res, err := pdf.Decode(c, dict["Resources"], extract.Resources)
+	if pdf.IsMalformed(err) {
+		res = nil
+	} else if err != nil {
+		return err
+	}
+
+	if contentsObj := dict["Contents"]; contentsObj != nil {
+		resolved, err := c.Resolve(contentsObj)
+		if err != nil && !pdf.IsMalformed(err) {
+			return err
+		}
+		segments, err := page.ExtractContents(c, resolved)
+		if err != nil && !pdf.IsMalformed(err) {
+			return err
+		}
+		if len(segments) > 0 {
+			pg := &page.Page{Contents: segments}
+			err := w.walkStream(&stream{
+				source:  SourcePage,
+				content: pg,
+				typ:     content.Page,
+				res:     res,
+				rawRes:  rawRes,
+				ctm:     matrix.Identity,
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return w.walkAnnotations(dict["Annots"])
+}
+
+// walkAnnotations visits the normal appearances of the annotations of a page.
+func (w *walker) walkAnnotations(annotsObj pdf.Object) error {
+	c := pdf.CursorAt(w.x, nil)
+	annots, err := c.Array(annotsObj)
+	if err != nil {
+		return nil
+	}
+	for _, obj := range annots {
+		annot, err := c.Dict(obj)
+		if err != nil || annot == nil {
+			continue
+		}
+		rect, err := c.Rectangle(annot["Rect"])
+		if err != nil || rect == nil {
+			continue
+		}
+		apDict, err := c.Dict(annot["AP"])
+		if err != nil || apDict == nil {
+			continue
+		}
+
+		// /N is either a form XObject or a subdictionary keyed by the
+		// appearance state
+		apObj := apDict["N"]
+		if stm, _ := c.Stream(apObj); stm == nil {
+			state, _ := c.Name(annot["AS"])
+			sub, _ := c.Dict(apObj)
+			if state == "" || sub == nil {
+				continue
+			}
+			apObj = sub[state]
+		}
+
+		if err := w.walkForm(SourceAnnotation, apObj, matrix.Identity, nil, nil, 0, rect); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkStream visits a single content stream.
+func (w *walker) walkStream(s *stream) error {
+	r := reader.New(w.x)
+	st := content.NewState(s.typ, s.res)
+	st.GState.CTM = s.ctm
+	r.State = st
+
+	// The tiling patterns selected as fill and stroke colour, saved and
+	// restored together with the graphics state.
+	var cur selectedPatterns
+	var saved []selectedPatterns
+
+	r.InlineImage = func(op content.Operator, ctm matrix.Matrix) error {
+		return w.foundInline(s.source, op, r.State.Resources, ctm)
+	}
+	r.EveryOp = func(op string, args []pdf.Object) error {
+		switch name := content.OpName(op); name {
+		case content.OpXObject:
+			if len(args) < 1 {
+				return nil
+			}
+			xname, ok := args[0].(pdf.Name)
+			if !ok {
+				return nil
+			}
+			return w.doXObject(s, xname, r.State.GState.CTM)
+		case content.OpPushGraphicsState:
+			saved = append(saved, cur)
+		case content.OpPopGraphicsState:
+			if n := len(saved); n > 0 {
+				cur = saved[n-1]
+				saved = saved[:n-1]
+			}
+		case content.OpSetFillColorN:
+			cur.fill = patternName(args)
+		case content.OpSetStrokeColorN:
+			cur.stroke = patternName(args)
+		case content.OpSetFillColorSpace, content.OpSetFillColor,
+			content.OpSetFillGray, content.OpSetFillRGB, content.OpSetFillCMYK:
+			cur.fill = ""
+		case content.OpSetStrokeColorSpace, content.OpSetStrokeColor,
+			content.OpSetStrokeGray, content.OpSetStrokeRGB, content.OpSetStrokeCMYK:
+			cur.stroke = ""
+		default:
+			fill, stroke := paints(name, r.State.GState.TextRenderingMode)
+			if fill && cur.fill != "" {
+				if err := w.walkPattern(s, cur.fill); err != nil {
+					return err
+				}
+			}
+			if stroke && cur.stroke != "" && !(fill && cur.stroke == cur.fill) {
+				if err := w.walkPattern(s, cur.stroke); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	err := r.ProcessIter(s.content.NewIter())
+	if pdf.IsMalformed(err) {
+		return nil
+	}
+	return err
+}
+
+// selectedPatterns holds the names of the patterns selected as the current
+// fill and stroke colour, or the empty name for other colours.
+type selectedPatterns struct {
+	fill, stroke pdf.Name
+}
+
+// patternName returns the pattern name given as the last operand of an scn
+// or SCN operator, or the empty name if the operator sets a different
+// colour.
+func patternName(args []pdf.Object) pdf.Name {
+	if len(args) == 0 {
+		return ""
+	}
+	name, _ := args[len(args)-1].(pdf.Name)
+	return name
+}
+
+// paints reports whether the operator op fills and whether it strokes
+// using the current colours.  For text-showing operators, this depends on
+// the text rendering mode.
+func paints(op content.OpName, mode graphics.TextRenderingMode) (fill, stroke bool) {
+	switch op {
+	case content.OpFill, content.OpFillCompat, content.OpFillEvenOdd:
+		return true, false
+	case content.OpStroke, content.OpCloseAndStroke:
+		return false, true
+	case content.OpFillAndStroke, content.OpFillAndStrokeEvenOdd,
+		content.OpCloseFillAndStroke, content.OpCloseFillAndStrokeEvenOdd:
+		return true, true
+	case content.OpTextShow, content.OpTextShowArray,
+		content.OpTextShowMoveNextLine, content.OpTextShowMoveNextLineSetSpacing:
+		switch mode {
+		case graphics.TextRenderingModeFill, graphics.TextRenderingModeFillClip:
+			return true, false
+		case graphics.TextRenderingModeStroke, graphics.TextRenderingModeStrokeClip:
+			return false, true
+		case graphics.TextRenderingModeFillStroke, graphics.TextRenderingModeFillStrokeClip:
+			return true, true
+		}
+	}
+	return false, false
+}
+
+// walkPattern visits the cell of the tiling pattern with the given name in
+// the resource dictionary of s, when an area is painted with this pattern.
+// The pattern matrix maps pattern space to the default coordinate space of
+// the content stream in which the pattern is used, independent of the CTM
+// at the painting operator (section 8.7.2 of ISO 32000-2:2020).  The cell is
+// visited once for every painting operator which uses the pattern.
+func (w *walker) walkPattern(s *stream, name pdf.Name) error {
+	if s.depth >= maxDepth {
+		return nil
+	}
+	c := pdf.CursorAt(w.x, nil)
+	patterns, err := c.Dict(s.rawRes["Pattern"])
+	if err != nil || patterns == nil {
+		return nil
+	}
+	ref, _ := patterns[name].(pdf.Reference)
+	if ref == 0 || w.active[ref] {
+		return nil
+	}
+	stm, err := c.Stream(ref)
+	if err != nil || stm == nil {
+		return nil
+	}
+	pat, err := pdf.Decode(c, ref, extract.Pattern)
+	if err != nil {
+		return nil
+	}
+	tiling, ok := pat.(*pattern.Type1)
+	if !ok || tiling.Content == nil {
+		return nil
+	}
+
+	rawRes, _ := c.Dict(stm.Dict["Resources"])
+	typ := content.PatternUncolored
+	if tiling.Color {
+		typ = content.PatternColored
+	}
+
+	w.active[ref] = true
+	defer delete(w.active, ref)
+	return w.walkStream(&stream{
+		source:  SourcePattern,
+		content: tiling.Content,
+		typ:     typ,
+		res:     tiling.Res,
+		rawRes:  rawRes,
+		ctm:     orIdentity(tiling.Matrix).Mul(s.ctm),
+		depth:   s.depth + 1,
+	})
+}
+
+// doXObject handles a Do operator in the content stream s.
+func (w *walker) doXObject(s *stream, name pdf.Name, ctm matrix.Matrix) error {
+	c := pdf.CursorAt(w.x, nil)
+	xobjects, err := c.Dict(s.rawRes["XObject"])
+	if err != nil || xobjects == nil {
+		return nil
+	}
+	obj := xobjects[name]
+	stm, err := c.Stream(obj)
+	if err != nil || stm == nil {
+		return nil
+	}
+	subtype, _ := c.Name(stm.Dict["Subtype"])
+	switch subtype {
+	case "Image":
+		xobj, err := pdf.Decode(c, obj, extract.XObject)
+		if err != nil {
+			return nil
+		}
+		img, ok := xobj.(graphics.Image)
+		if !ok {
+			return nil
+		}
+		ref, _ := obj.(pdf.Reference)
+		return w.report(&Image{
+			Source:  s.source,
+			Ref:     ref,
+			CTM:     ctm,
+			XObject: img,
+			stream:  stm,
+		})
+	case "Form":
+		return w.walkForm(s.source.nested(), obj, ctm, s.res, s.rawRes, s.depth+1, nil)
+	}
+	return nil
+}
+
+// walkForm visits the content of a form XObject.  If rect is non-nil, the
+// form is an annotation appearance and is mapped into rect as described
+// in section 12.5.5 of ISO 32000-2:2020.  Otherwise, ctm is the current
+// transformation matrix at the point where the form is drawn.
+func (w *walker) walkForm(source Source, obj pdf.Object, ctm matrix.Matrix, parentRes *content.Resources, parentRaw pdf.Dict, depth int, rect *pdf.Rectangle) error {
+	if depth >= maxDepth {
+		return nil
+	}
+	ref, _ := obj.(pdf.Reference)
+	if ref != 0 {
+		if w.active[ref] {
+			return nil
+		}
+		w.active[ref] = true
+		defer delete(w.active, ref)
+	}
+
+	c := pdf.CursorAt(w.x, nil)
+	stm, err := c.Stream(obj)
+	if err != nil || stm == nil {
+		return nil
+	}
+	f, err := pdf.Decode(c, obj, extract.Form)
+	if err != nil || f.Content == nil {
+		return nil
+	}
+
+	var m matrix.Matrix
+	if rect != nil {
+		var ok bool
+		m, ok = appearance.ToRect(f, *rect)
+		if !ok {
+			return nil
+		}
+	} else {
+		m = orIdentity(f.Matrix).Mul(ctm)
+	}
+
+	res, rawRes := f.Res, parentRaw
+	if res == nil {
+		res = parentRes
+	}
+	if d, _ := c.Dict(stm.Dict["Resources"]); d != nil {
+		rawRes = d
+	}
+
+	return w.walkStream(&stream{
+		source:  source,
+		content: f.Content,
+		typ:     formType(f),
+		res:     res,
+		rawRes:  rawRes,
+		ctm:     m,
+		depth:   depth,
+	})
+}
+
+// foundInline reports an inline image.
+func (w *walker) foundInline(source Source, op content.Operator, res *content.Resources, ctm matrix.Matrix) error {
+	img, filters, err := inlineImage(op, res)
+	if err != nil {
+		return nil // skip malformed inline images
+	}
+	w.inline++
+	raw, _ := op.Args[1].(pdf.String)
+	return w.report(&Image{
+		Source:        source,
+		Inline:        w.inline,
+		CTM:           ctm,
+		XObject:       img,
+		inlineData:    []byte(raw),
+		inlineFilters: filters,
+	})
+}
+
+// report fills in the placement information and calls the user callback.
+func (w *walker) report(img *Image) error {
+	img.Page = w.pageNo
+	img.r = w.r
+	b := img.XObject.Bounds()
+	img.Width, img.Height = b.Dx(), b.Dy()
+
+	// The image occupies the unit square in image space; the lengths of the
+	// images of the two unit vectors give its size in default user space.
+	inchesX := math.Hypot(img.CTM[0], img.CTM[1]) * w.userUnit / 72
+	inchesY := math.Hypot(img.CTM[2], img.CTM[3]) * w.userUnit / 72
+	if inchesX > 0 {
+		img.DPIX = float64(img.Width) / inchesX
+	}
+	if inchesY > 0 {
+		img.DPIY = float64(img.Height) / inchesY
+	}
+
+	return w.fn(img)
+}
+
+// formType returns the content stream type of a form XObject.
+func formType(f *form.Form) content.Type {
+	if f.Group != nil {
+		return content.TransparencyGroup
+	}
+	return content.Form
+}
+
+// orIdentity returns m, or the identity matrix if m is the zero matrix.
+func orIdentity(m matrix.Matrix) matrix.Matrix {
+	if m == (matrix.Matrix{}) {
+		return matrix.Identity
+	}
+	return m
+}
+
+// Source identifies the kind of content stream in which an image is drawn.
+type Source int
+
+const (
+	// SourcePage indicates an image drawn directly by the page content
+	// stream.
+	SourcePage Source = iota
+
+	// SourceForm indicates an image drawn by a form XObject.
+	SourceForm
+
+	// SourcePattern indicates an image drawn in the cell of a tiling
+	// pattern.
+	SourcePattern
+
+	// SourceAnnotation indicates an image drawn by the normal appearance of
+	// an annotation.
+	SourceAnnotation
+)
+
+// nested returns the source to report for content drawn by a form XObject
+// which is invoked from a stream of source s.
+func (s Source) nested() Source {
+	if s == SourcePage {
+		return SourceForm
+	}
+	return s
+}
+
+func (s Source) String() string {
+	switch s {
+	case SourcePage:
+		return "page"
+	case SourceForm:
+		return "form"
+	case SourcePattern:
+		return "pattern"
+	case SourceAnnotation:
+		return "annotation"
+	default:
+		return fmt.Sprintf("Source(%d)", int(s))
+	}
+}
@@ -0,0 +1,145 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package imposition
+
+import (
+	"maps"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/vec"
+
+	"seehuhn.de/go/pdf"
+)
+
+// droppedSubtypes lists the annotation types which are not carried over.
+// Links point to pages of the source document, widgets belong to the
+// interactive form, and pop-ups only make sense together with their parent.
+var droppedSubtypes = map[pdf.Name]bool{
+	"Link":   true,
+	"Widget": true,
+	"Popup":  true,
+}
+
+// droppedKeys lists annotation dictionary entries which refer to document
+// structure that is not copied.  Copying them would pull the source pages
+// into the output.
+var droppedKeys = []pdf.Name{
+	"P", "Popup", "Parent", "IRT", "StructParent", "A", "AA", "Dest",
+}
+
+// pointKeys lists annotation dictionary entries which hold flat arrays of
+// point coordinates in default user space.
+var pointKeys = []pdf.Name{"QuadPoints", "L", "Vertices", "CL"}
+
+// copyAnnotations copies the annotations of a source page to the output,
+// moving them to the position given by m.  The matrix must consist of
+// scaling and translation only.
+func (c *imposer) copyAnnotations(p *sourcePage, m matrix.Matrix) (pdf.Array, error) {
+	cur := pdf.CursorAt(c.x, nil)
+	annots, err := cur.Array(p.dict["Annots"])
+	if err != nil || len(annots) == 0 {
+		return nil, nil
+	}
+
+	var res pdf.Array
+	for _, obj := range annots {
+		src, err := cur.Dict(obj)
+		if err != nil || src == nil {
+			continue
+		}
+		subtype, _ := cur.Name(src["Subtype"])
+		if droppedSubtypes[subtype] {
+			continue
+		}
+		rect, err := cur.Rectangle(src["Rect"])
+		if err != nil || rect == nil {
+			continue
+		}
+
+		dict := maps.Clone(src)
+		for _, key := range droppedKeys {
+			delete(dict, key)
+		}
+		dict["Rect"] = transformRect(rect, m)
+		for _, key := range pointKeys {
+			if a, err := cur.Array(dict[key]); err == nil && a != nil {
+				dict[key] = transformPoints(cur, a, m)
+			}
+		}
+		if ink, err := cur.Array(dict["InkList"]); err == nil && ink != nil {
+			paths := make(pdf.Array, 0, len(ink))
+			for _, path := range ink {
+				if a, err := cur.Array(path); err == nil {
+					paths = append(paths, transformPoints(cur, a, m))
+				}
+			}
+			dict["InkList"] = paths
+		}
+		if rd, err := cur.Array(dict["RD"]); err == nil && len(rd) == 4 {
+			scaled := make(pdf.Array, 4)
+			for i, v := range rd {
+				x, _ := cur.Number(v)
+				s := m[0]
+				if i%2 == 1 {
+					s = m[3]
+				}
+				scaled[i] = pdf.Number(pdf.Round(x*s, coordDigits))
+			}
+			dict["RD"] = scaled
+		}
+
+		copied, err := c.copy.CopyDict(dict)
+		if err != nil {
+			return nil, err
+		}
+		ref := c.out.Alloc()
+		if err := c.out.Put(ref, copied); err != nil {
+			return nil, err
+		}
+		res = append(res, ref)
+	}
+	return res, nil
+}
+
+// transformRect returns the bounding box of the image of r under m.
+func transformRect(r *pdf.Rectangle, m matrix.Matrix) *pdf.Rectangle {
+	ll := m.Apply(vec.Vec2{X: r.LLx, Y: r.LLy})
+	res := &pdf.Rectangle{LLx: ll.X, LLy: ll.Y, URx: ll.X, URy: ll.Y}
+	for _, v := range []vec.Vec2{
+		{X: r.URx, Y: r.LLy}, {X: r.LLx, Y: r.URy}, {X: r.URx, Y: r.URy},
+	} {
+		w := m.Apply(v)
+		res.LLx = min(res.LLx, w.X)
+		res.LLy = min(res.LLy, w.Y)
+		res.URx = max(res.URx, w.X)
+		res.URy = max(res.URy, w.Y)
+	}
+	res.IRound(coordDigits)
+	return res
+}
+
+// transformPoints applies m to a flat array of x, y coordinates.
+func transformPoints(cur pdf.Cursor, a pdf.Array, m matrix.Matrix) pdf.Array {
+	res := make(pdf.Array, 0, len(a))
+	for i := 0; i+1 < len(a); i += 2 {
+		x, _ := cur.Number(a[i])
+		y, _ := cur.Number(a[i+1])
+		w := m.Apply(vec.Vec2{X: x, Y: y})
+		res = append(res, pdf.Number(pdf.Round(w.X, coordDigits)), pdf.Number(pdf.Round(w.Y, coordDigits)))
+	}
+	return res
+}
@@ -0,0 +1,38 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package imposition arranges the pages of a PDF document on larger sheets
+// for printing.
+//
+// Every source page is wrapped in a form XObject, clipped to the page's crop
+// box, and placed on the output sheets in one of three layouts:
+//
+//   - [NUp] fills a grid of cells with consecutive pages, in reading order;
+//   - [Booklet] places pages two per side in saddle-stitch order, so that
+//     the printed, folded and stapled sheets form a booklet;
+//   - [StepAndRepeat] fills every cell of a sheet with copies of the same
+//     page, as used for labels and business cards.
+//
+// Pages are scaled to fit their cell unless a fixed scale is given, and can
+// be rotated in steps of 90 degrees.  The page's own /Rotate entry is applied
+// first, so that the page appears on the sheet the way a viewer shows it.
+// Booklets support creep compensation, which moves the pages on inner
+// sheets towards the spine.
+//
+// Annotations are carried over to the sheets where their placement can be
+// expressed without rotation.  Links, form fields and pop-up windows are
+// dropped, since they refer to document structure which is not copied.
+package imposition
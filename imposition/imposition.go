@@ -0,0 +1,418 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package imposition
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+
+	"seehuhn.de/go/geom/matrix"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/internal/rewrite"
+	"seehuhn.de/go/pdf/page"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// coordDigits is the number of decimal digits kept for coordinates written
+// to content streams and annotation dictionaries.
+const coordDigits = 4
+
+// Layout selects how pages are arranged on the sheets.
+type Layout int
+
+const (
+	// NUp places consecutive pages into the cells of each sheet, filling
+	// rows from left to right and from top to bottom.
+	NUp Layout = iota
+
+	// Booklet places two pages side by side on each sheet side, in
+	// saddle-stitch order.  The number of pages is padded to a multiple of
+	// four with blank pages.  Sheets are meant to be printed double-sided,
+	// flipped on the short edge.
+	Booklet
+
+	// StepAndRepeat fills every cell of a sheet with the same page, and
+	// uses one sheet for each source page.
+	StepAndRepeat
+)
+
+func (l Layout) String() string {
+	switch l {
+	case NUp:
+		return "n-up"
+	case Booklet:
+		return "booklet"
+	case StepAndRepeat:
+		return "step-and-repeat"
+	default:
+		return fmt.Sprintf("Layout(%d)", int(l))
+	}
+}
+
+// Options control the imposition.  A nil *Options selects a 2-up layout
+// of all pages, on sheets just large enough to hold the pages at their
+// natural size.
+type Options struct {
+	// Layout selects the arrangement of pages on the sheets.
+	Layout Layout
+
+	// Cols and Rows give the size of the grid of cells on each sheet.  If
+	// both are zero, a grid of two columns and one row is used.  If only one
+	// of them is zero, it is taken to be one.  For [Booklet], the grid is
+	// always two columns and one row.
+	Cols, Rows int
+
+	// Sheet is the size of the output sheets.  If this is nil, the sheet is
+	// made just large enough to hold the grid of cells, with cells the size
+	// of the first selected page (after rotation and scaling).
+	Sheet *pdf.Rectangle
+
+	// Margin is the blank space left at all four edges of the sheet, in PDF
+	// units.
+	Margin float64
+
+	// Gutter is the space between neighbouring cells, in PDF units.
+	Gutter float64
+
+	// Creep is the total creep compensation for booklets, in PDF units.
+	// Pages on the innermost sheet are moved this distance towards the
+	// spine, and pages on the other sheets are moved proportionally less.
+	// The value is ignored for the other layouts.
+	Creep float64
+
+	// Rotate rotates every page clockwise by the given number of degrees,
+	// which must be a multiple of 90.  The rotation is applied in addition
+	// to the /Rotate entry of the source page.
+	Rotate int
+
+	// Scale is the scale factor applied to the pages.  If this is zero, each
+	// page is scaled to fit its cell, preserving the aspect ratio.
+	Scale float64
+
+	// Pages selects which source pages to use, as zero-based page indices in
+	// order.  A nil slice uses every page in document order.
+	Pages []int
+
+	// DropAnnotations disables copying annotations to the output sheets.
+	DropAnnotations bool
+}
+
+// Write reads the document from r, imposes its pages onto sheets, and writes
+// the result to w.
+func Write(w io.Writer, r pdf.Getter, opts *Options) error {
+	if opts == nil {
+		opts = &Options{}
+	}
+	if opts.Rotate%90 != 0 {
+		return fmt.Errorf("invalid rotation %d (must be a multiple of 90)", opts.Rotate)
+	}
+	if opts.Scale < 0 {
+		return fmt.Errorf("invalid scale %g", opts.Scale)
+	}
+	if opts.Cols < 0 || opts.Rows < 0 {
+		return fmt.Errorf("invalid grid %dx%d", opts.Cols, opts.Rows)
+	}
+
+	// form XObjects need PDF 1.2; everything else is copied from the source
+	// and covered by its version
+	version := max(pdf.GetVersion(r), pdf.V1_2)
+	out, err := rewrite.NewWriter(w, r, version)
+	if err != nil {
+		return err
+	}
+	rm := pdf.NewResourceManager(out)
+
+	c := &imposer{
+		r:     r,
+		x:     pdf.NewExtractor(r),
+		out:   out,
+		copy:  pdf.NewCopier(out, r),
+		opts:  opts,
+		forms: map[int]pdf.Reference{},
+	}
+
+	if err := c.loadPages(); err != nil {
+		return err
+	}
+	if len(c.pages) == 0 {
+		return errors.New("no pages to impose")
+	}
+
+	lay, err := c.newGeometry()
+	if err != nil {
+		return err
+	}
+
+	tree := pagetree.NewWriter(out, rm)
+	for _, sheet := range plan(opts, len(c.pages)) {
+		dict, err := c.writeSheet(lay, sheet)
+		if err != nil {
+			return err
+		}
+		if err := tree.AppendPageDict(out.Alloc(), dict); err != nil {
+			return err
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		return err
+	}
+
+	metaIn := r.GetMeta()
+	meta := out.GetMeta()
+	meta.Catalog.Pages = pagesRef
+	meta.Info = metaIn.Info
+	if oi, ok := metaIn.Catalog.OutputIntents.(pdf.Native); ok && oi != nil {
+		copied, err := c.copy.Copy(oi)
+		if err != nil {
+			return err
+		}
+		meta.Catalog.OutputIntents = copied
+	}
+
+	if err := rm.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// imposer holds the state shared while imposing one document.
+type imposer struct {
+	r    pdf.Getter
+	x    *pdf.Extractor
+	out  *pdf.Writer
+	copy *pdf.Copier
+	opts *Options
+
+	// pages holds the selected source pages, in output order.
+	pages []*sourcePage
+
+	// forms maps a source page number to the form XObject wrapping the page.
+	forms map[int]pdf.Reference
+}
+
+// sourcePage describes one selected page of the input document.
+type sourcePage struct {
+	pageNo int
+	dict   pdf.Dict
+
+	// box is the visible area of the page, in default user space.
+	box pdf.Rectangle
+
+	// rotate is the total clockwise rotation, in degrees, applied when
+	// placing the page.  This is one of 0, 90, 180 and 270.
+	rotate int
+}
+
+// size returns the width and height of the page after rotation.
+func (p *sourcePage) size() (float64, float64) {
+	if p.rotate%180 != 0 {
+		return p.box.Dy(), p.box.Dx()
+	}
+	return p.box.Dx(), p.box.Dy()
+}
+
+// loadPages reads the selected pages of the input document.
+func (c *imposer) loadPages() error {
+	var all []pdf.Dict
+	it := pagetree.NewIterator(c.r)
+	for _, dict := range it.All() {
+		all = append(all, dict)
+	}
+	if it.Err != nil {
+		return it.Err
+	}
+
+	sel := c.opts.Pages
+	if sel == nil {
+		sel = make([]int, len(all))
+		for i := range sel {
+			sel[i] = i
+		}
+	}
+
+	cur := pdf.CursorAt(c.x, nil)
+	for _, pageNo := range sel {
+		if pageNo < 0 || pageNo >= len(all) {
+			return fmt.Errorf("page index %d out of range (have %d pages)", pageNo, len(all))
+		}
+		dict := all[pageNo]
+
+		mediaBox, err := cur.Rectangle(dict["MediaBox"])
+		if err != nil || mediaBox == nil || mediaBox.IsZero() {
+			return fmt.Errorf("page %d: missing or invalid MediaBox", pageNo+1)
+		}
+		box := mediaBox
+		if cropBox, err := cur.Rectangle(dict["CropBox"]); err == nil && cropBox != nil {
+			if b := cropBox.Intersect(mediaBox); b != nil && !b.IsZero() {
+				box = b
+			}
+		}
+
+		rotate := 0
+		if v, err := cur.Integer(dict["Rotate"]); err == nil {
+			rotate = page.RotationFromDegrees(int(v)).Degrees()
+		}
+		rotate = ((rotate+c.opts.Rotate)%360 + 360) % 360
+
+		c.pages = append(c.pages, &sourcePage{
+			pageNo: pageNo,
+			dict:   dict,
+			box:    *box,
+			rotate: rotate,
+		})
+	}
+	return nil
+}
+
+// writeSheet writes the content stream of one output sheet and returns the
+// page dictionary.
+func (c *imposer) writeSheet(lay *geometry, sheet []placement) (pdf.Dict, error) {
+	xobjects := pdf.Dict{}
+	var annots pdf.Array
+	var buf bytes.Buffer
+
+	for _, pl := range sheet {
+		if pl.page < 0 {
+			continue // blank page
+		}
+		p := c.pages[pl.page]
+		ref, err := c.pageForm(p)
+		if err != nil {
+			return nil, err
+		}
+		name := pdf.Name(fmt.Sprintf("P%d", p.pageNo))
+		xobjects[name] = ref
+
+		m := lay.transform(p, pl)
+		ops := []content.Operator{
+			{Name: content.OpPushGraphicsState},
+			{Name: content.OpTransform, Args: matrixArgs(m)},
+			{Name: content.OpXObject, Args: []pdf.Object{name}},
+			{Name: content.OpPopGraphicsState},
+		}
+		for _, op := range ops {
+			if err := op.Format(&buf); err != nil {
+				return nil, err
+			}
+			buf.WriteByte('\n')
+		}
+
+		if !c.opts.DropAnnotations && p.rotate == 0 {
+			a, err := c.copyAnnotations(p, m)
+			if err != nil {
+				return nil, err
+			}
+			annots = append(annots, a...)
+		}
+	}
+
+	contentRef := c.out.Alloc()
+	stm, err := c.out.OpenStream(contentRef, nil, pdf.FilterCompress{})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stm.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := stm.Close(); err != nil {
+		return nil, err
+	}
+
+	sheetBox := lay.sheet
+	dict := pdf.Dict{
+		"Type":     pdf.Name("Page"),
+		"MediaBox": &sheetBox,
+		"Contents": contentRef,
+	}
+	if len(xobjects) > 0 {
+		dict["Resources"] = pdf.Dict{"XObject": xobjects}
+	} else {
+		dict["Resources"] = pdf.Dict{}
+	}
+	if len(annots) > 0 {
+		dict["Annots"] = annots
+	}
+	return dict, nil
+}
+
+// pageForm returns a form XObject which draws the given page.  The form is
+// written on first use, and shared by all placements of the same page.
+func (c *imposer) pageForm(p *sourcePage) (pdf.Reference, error) {
+	if ref, ok := c.forms[p.pageNo]; ok {
+		return ref, nil
+	}
+
+	box := p.box
+	dict := pdf.Dict{
+		"Type":    pdf.Name("XObject"),
+		"Subtype": pdf.Name("Form"),
+		"BBox":    &box,
+	}
+	for _, key := range []pdf.Name{"Resources", "Group"} {
+		v, ok := p.dict[key].(pdf.Native)
+		if !ok {
+			continue
+		}
+		cv, err := c.copy.Copy(v)
+		if err != nil {
+			return 0, err
+		}
+		dict[key] = cv
+	}
+
+	ref := c.out.Alloc()
+	stm, err := c.out.OpenStream(ref, dict, pdf.FilterCompress{})
+	if err != nil {
+		return 0, err
+	}
+	if contents := p.dict["Contents"]; contents != nil {
+		cur := pdf.CursorAt(c.x, nil)
+		resolved, err := cur.Resolve(contents)
+		if err != nil {
+			return 0, err
+		}
+		segments, err := page.ExtractContents(cur, resolved)
+		if err != nil {
+			return 0, err
+		}
+		body := page.SegmentsReader(segments)
+		_, err = io.Copy(stm, body)
+		body.Close()
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err := stm.Close(); err != nil {
+		return 0, err
+	}
+
+	c.forms[p.pageNo] = ref
+	return ref, nil
+}
+
+func matrixArgs(m matrix.Matrix) []pdf.Object {
+	args := make([]pdf.Object, 6)
+	for i, x := range m {
+		args[i] = pdf.Number(pdf.Round(x, coordDigits))
+	}
+	return args
+}
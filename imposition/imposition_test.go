@@ -0,0 +1,299 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package imposition
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/xmp"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+	"seehuhn.de/go/pdf/internal/rewrite/rewritetest"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// makeSource writes n pages of size 100x200 and returns a reader for the
+// document.  The first page carries a text annotation and a link.
+func makeSource(t *testing.T, n int, rotate int) *pdf.Reader {
+	t.Helper()
+
+	w, buf := memfile.NewPDFWriter(pdf.V1_7, nil)
+	rm := pdf.NewResourceManager(w)
+	tree := pagetree.NewWriter(w, rm)
+	for i := range n {
+		contentRef := w.Alloc()
+		stm, err := w.OpenStream(contentRef, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := stm.Write([]byte("0 0 100 200 re f")); err != nil {
+			t.Fatal(err)
+		}
+		if err := stm.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		dict := pdf.Dict{
+			"Type":      pdf.Name("Page"),
+			"MediaBox":  &pdf.Rectangle{URx: 100, URy: 200},
+			"Resources": pdf.Dict{},
+			"Contents":  contentRef,
+		}
+		if rotate != 0 {
+			dict["Rotate"] = pdf.Integer(rotate)
+		}
+		if i == 0 {
+			dict["Annots"] = pdf.Array{
+				pdf.Dict{
+					"Type":     pdf.Name("Annot"),
+					"Subtype":  pdf.Name("Text"),
+					"Rect":     &pdf.Rectangle{LLx: 10, LLy: 20, URx: 30, URy: 40},
+					"Contents": pdf.TextString("note"),
+				},
+				pdf.Dict{
+					"Type":    pdf.Name("Annot"),
+					"Subtype": pdf.Name("Link"),
+					"Rect":    &pdf.Rectangle{LLx: 0, LLy: 0, URx: 10, URy: 10},
+				},
+			}
+		}
+		if err := tree.AppendPageDict(w.Alloc(), dict); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ref, err := tree.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.GetMeta().Catalog.Pages = ref
+	if err := rm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(bytes.NewReader(buf.Data), int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// impose runs Write and returns the page dictionaries of the result.
+func impose(t *testing.T, r *pdf.Reader, opts *Options) (*pdf.Reader, []pdf.Dict) {
+	t.Helper()
+
+	var out bytes.Buffer
+	if err := Write(&out, r, opts); err != nil {
+		t.Fatal(err)
+	}
+	rr, err := pdf.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pages []pdf.Dict
+	for _, dict := range pagetree.NewIterator(rr).All() {
+		pages = append(pages, dict)
+	}
+	return rr, pages
+}
+
+func TestPlanBooklet(t *testing.T) {
+	sheets := plan(&Options{Layout: Booklet}, 6)
+
+	var got [][2]int
+	for _, sheet := range sheets {
+		got = append(got, [2]int{sheet[0].page, sheet[1].page})
+	}
+	want := [][2]int{{-1, 0}, {1, -1}, {5, 2}, {3, 4}}
+	if d := cmp.Diff(want, got); d != "" {
+		t.Errorf("booklet order (-want +got):\n%s", d)
+	}
+}
+
+func TestPlanCreep(t *testing.T) {
+	sheets := plan(&Options{Layout: Booklet, Creep: 2}, 12)
+	if len(sheets) != 6 {
+		t.Fatalf("got %d sides, want 6", len(sheets))
+	}
+	for side, sheet := range sheets {
+		want := float64(side/2) * 1.0 // three sheets, 2pt of total creep
+		if sheet[0].shift != want || sheet[1].shift != -want {
+			t.Errorf("side %d: shifts %g/%g, want %g/%g",
+				side, sheet[0].shift, sheet[1].shift, want, -want)
+		}
+	}
+}
+
+func TestPlanNUp(t *testing.T) {
+	sheets := plan(&Options{Cols: 2, Rows: 2}, 5)
+	if len(sheets) != 2 || len(sheets[0]) != 4 || len(sheets[1]) != 1 {
+		t.Fatalf("unexpected sheets %v", sheets)
+	}
+	if p := sheets[0][3]; p.page != 3 || p.col != 1 || p.row != 1 {
+		t.Errorf("unexpected placement %+v", p)
+	}
+}
+
+func TestTwoUp(t *testing.T) {
+	r := makeSource(t, 3, 0)
+	_, pages := impose(t, r, &Options{Gutter: 10, Margin: 5})
+	if len(pages) != 2 {
+		t.Fatalf("got %d sheets, want 2", len(pages))
+	}
+
+	mediaBox, ok := pages[0]["MediaBox"].(pdf.Array)
+	if !ok {
+		t.Fatalf("missing MediaBox")
+	}
+	want := pdf.Array{pdf.Integer(0), pdf.Integer(0), pdf.Integer(220), pdf.Integer(210)}
+	if d := cmp.Diff(want, mediaBox); d != "" {
+		t.Errorf("sheet size (-want +got):\n%s", d)
+	}
+
+	res, _ := pages[0]["Resources"].(pdf.Dict)
+	xobj, _ := res["XObject"].(pdf.Dict)
+	if len(xobj) != 2 {
+		t.Errorf("got %d XObjects on the first sheet, want 2", len(xobj))
+	}
+}
+
+func TestStepAndRepeat(t *testing.T) {
+	r := makeSource(t, 2, 0)
+	rr, pages := impose(t, r, &Options{
+		Layout:          StepAndRepeat,
+		Cols:            3,
+		Rows:            4,
+		DropAnnotations: true,
+	})
+	if len(pages) != 2 {
+		t.Fatalf("got %d sheets, want 2", len(pages))
+	}
+	if _, ok := pages[0]["Annots"]; ok {
+		t.Error("annotations were copied")
+	}
+
+	res, _ := pages[0]["Resources"].(pdf.Dict)
+	xobj, _ := res["XObject"].(pdf.Dict)
+	if len(xobj) != 1 {
+		t.Errorf("got %d XObjects, want 1", len(xobj))
+	}
+	stm, err := pdf.NewCursor(rr).Stream(pages[0]["Contents"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := pdf.ReadAll(rr, nil, stm, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(data, []byte(" Do")); n != 12 {
+		t.Errorf("page drawn %d times, want 12", n)
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+	r := makeSource(t, 2, 0)
+	rr, pages := impose(t, r, &Options{Scale: 0.5})
+
+	c := pdf.NewCursor(rr)
+	annots, err := c.Array(pages[0]["Annots"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annots) != 1 {
+		t.Fatalf("got %d annotations, want 1 (link dropped)", len(annots))
+	}
+	annot, err := c.Dict(annots[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	rect, err := c.Rectangle(annot["Rect"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &pdf.Rectangle{LLx: 5, LLy: 10, URx: 15, URy: 20}
+	if !rect.NearlyEqual(want, 1e-6) {
+		t.Errorf("annotation at %v, want %v", rect, want)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	p := &sourcePage{box: pdf.Rectangle{URx: 100, URy: 200}, rotate: 90}
+	g := &geometry{sheet: pdf.Rectangle{URx: 200, URy: 100}, cellW: 200, cellH: 100}
+	m := g.transform(p, placement{})
+
+	// the page is turned clockwise: its top left corner ends up at the
+	// top right corner of the cell
+	checkPoint(t, m, 0, 200, 200, 100)
+	checkPoint(t, m, 100, 0, 0, 0)
+
+	r := makeSource(t, 1, 90)
+	_, pages := impose(t, r, &Options{Cols: 1, Rows: 1})
+	mediaBox, _ := pages[0]["MediaBox"].(pdf.Array)
+	want := pdf.Array{pdf.Integer(0), pdf.Integer(0), pdf.Integer(200), pdf.Integer(100)}
+	if d := cmp.Diff(want, mediaBox); d != "" {
+		t.Errorf("sheet size (-want +got):\n%s", d)
+	}
+	if _, ok := pages[0]["Annots"]; ok {
+		t.Error("annotations copied onto a rotated placement")
+	}
+}
+
+// TestMetadata checks that the XMP metadata of the source document is
+// carried over.
+func TestMetadata(t *testing.T) {
+	rr, _ := impose(t, rewritetest.Source(t), &Options{Cols: 2, Rows: 1})
+	md := rr.GetMeta().Catalog.Metadata
+	if md == nil {
+		t.Fatal("XMP metadata missing")
+	}
+	dc := &xmp.DublinCore{}
+	if err := md.Data.Get(dc); err != nil || dc.Title.Default.V != rewritetest.Title {
+		t.Errorf("title = %q, %v", dc.Title.Default.V, err)
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	r := makeSource(t, 1, 0)
+	for _, opts := range []*Options{
+		{Rotate: 45},
+		{Scale: -1},
+		{Pages: []int{3}},
+		{Sheet: &pdf.Rectangle{URx: 10, URy: 10}, Margin: 10},
+	} {
+		var out bytes.Buffer
+		if err := Write(&out, r, opts); err == nil {
+			t.Errorf("no error for %+v", opts)
+		}
+	}
+}
+
+func checkPoint(t *testing.T, m matrix.Matrix, x, y, wantX, wantY float64) {
+	t.Helper()
+	gotX := m[0]*x + m[2]*y + m[4]
+	gotY := m[1]*x + m[3]*y + m[5]
+	if math.Abs(gotX-wantX) > 1e-9 || math.Abs(gotY-wantY) > 1e-9 {
+		t.Errorf("(%g, %g) maps to (%g, %g), want (%g, %g)", x, y, gotX, gotY, wantX, wantY)
+	}
+}
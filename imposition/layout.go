@@ -0,0 +1,188 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package imposition
+
+import (
+	"fmt"
+
+	"seehuhn.de/go/geom/matrix"
+
+	"seehuhn.de/go/pdf"
+)
+
+// placement describes where one page goes on a sheet.
+type placement struct {
+	// page is the index into the selected pages, or -1 for a blank page.
+	page int
+
+	// col and row give the grid cell, with row 0 at the top of the sheet.
+	col, row int
+
+	// align is the horizontal alignment of the page within its cell:
+	// -1 for left, 0 for centred and +1 for right.
+	align int
+
+	// shift moves the page horizontally, in PDF units.
+	shift float64
+}
+
+// grid returns the number of columns and rows for the given options.
+func grid(opts *Options) (int, int) {
+	if opts.Layout == Booklet {
+		return 2, 1
+	}
+	cols, rows := opts.Cols, opts.Rows
+	if cols == 0 && rows == 0 {
+		return 2, 1
+	}
+	return max(cols, 1), max(rows, 1)
+}
+
+// plan assigns n selected pages to sheets.  The result has one entry per
+// output sheet.
+func plan(opts *Options, n int) [][]placement {
+	cols, rows := grid(opts)
+	perSheet := cols * rows
+
+	var sheets [][]placement
+	switch opts.Layout {
+	case Booklet:
+		total := (n + 3) / 4 * 4
+		numSheets := total / 4
+		page := func(i int) int {
+			if i >= n {
+				return -1
+			}
+			return i
+		}
+		for side := range total / 2 {
+			left, right := total-1-side, side
+			if side%2 == 1 {
+				left, right = side, total-1-side
+			}
+
+			// physical sheet 0 is the outermost one
+			var shift float64
+			if numSheets > 1 {
+				shift = opts.Creep * float64(side/2) / float64(numSheets-1)
+			}
+			sheets = append(sheets, []placement{
+				{page: page(left), col: 0, align: +1, shift: shift},
+				{page: page(right), col: 1, align: -1, shift: -shift},
+			})
+		}
+
+	case StepAndRepeat:
+		for i := range n {
+			sheet := make([]placement, 0, perSheet)
+			for cell := range perSheet {
+				sheet = append(sheet, placement{page: i, col: cell % cols, row: cell / cols})
+			}
+			sheets = append(sheets, sheet)
+		}
+
+	default:
+		for start := 0; start < n; start += perSheet {
+			sheet := make([]placement, 0, perSheet)
+			for cell := range min(perSheet, n-start) {
+				sheet = append(sheet, placement{page: start + cell, col: cell % cols, row: cell / cols})
+			}
+			sheets = append(sheets, sheet)
+		}
+	}
+	return sheets
+}
+
+// geometry holds the sheet size and the grid of cells.
+type geometry struct {
+	sheet        pdf.Rectangle
+	margin       float64
+	gutter       float64
+	cellW, cellH float64
+	scale        float64 // zero means fit to cell
+}
+
+// newGeometry computes the sheet and cell sizes.
+func (c *imposer) newGeometry() (*geometry, error) {
+	opts := c.opts
+	cols, rows := grid(opts)
+	g := &geometry{
+		margin: opts.Margin,
+		gutter: opts.Gutter,
+		scale:  opts.Scale,
+	}
+
+	if opts.Sheet != nil {
+		g.sheet = *opts.Sheet
+	} else {
+		s := opts.Scale
+		if s == 0 {
+			s = 1
+		}
+		w, h := c.pages[0].size()
+		g.sheet = pdf.Rectangle{
+			URx: 2*opts.Margin + float64(cols)*w*s + float64(cols-1)*opts.Gutter,
+			URy: 2*opts.Margin + float64(rows)*h*s + float64(rows-1)*opts.Gutter,
+		}
+	}
+
+	g.cellW = (g.sheet.Dx() - 2*g.margin - float64(cols-1)*g.gutter) / float64(cols)
+	g.cellH = (g.sheet.Dy() - 2*g.margin - float64(rows-1)*g.gutter) / float64(rows)
+	if !(g.cellW > 0 && g.cellH > 0) {
+		return nil, fmt.Errorf("sheet %gx%g too small for a %dx%d grid",
+			g.sheet.Dx(), g.sheet.Dy(), cols, rows)
+	}
+	return g, nil
+}
+
+// transform returns the matrix which maps the default user space of a
+// source page to its position on the sheet.
+func (g *geometry) transform(p *sourcePage, pl placement) matrix.Matrix {
+	box := p.box
+	w, h := p.size()
+
+	// move the visible area to the origin, then rotate clockwise so that the
+	// rotated page again occupies [0,w]x[0,h]
+	m := matrix.Translate(-box.LLx, -box.LLy)
+	switch p.rotate {
+	case 90:
+		m = m.Mul(matrix.Matrix{0, -1, 1, 0, 0, h})
+	case 180:
+		m = m.Mul(matrix.Matrix{-1, 0, 0, -1, w, h})
+	case 270:
+		m = m.Mul(matrix.Matrix{0, 1, -1, 0, w, 0})
+	}
+
+	s := g.scale
+	if s == 0 {
+		s = min(g.cellW/w, g.cellH/h)
+	}
+	m = m.Mul(matrix.Scale(s, s))
+
+	x := g.sheet.LLx + g.margin + float64(pl.col)*(g.cellW+g.gutter)
+	y := g.sheet.URy - g.margin - float64(pl.row+1)*g.cellH - float64(pl.row)*g.gutter
+	switch pl.align {
+	case 0:
+		x += (g.cellW - w*s) / 2
+	case 1:
+		x += g.cellW - w*s
+	}
+	x += pl.shift
+	y += (g.cellH - h*s) / 2
+
+	return m.Mul(matrix.Translate(x, y))
+}
@@ -0,0 +1,238 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdf
+
+import (
+	"bufio"
+	"crypto/rand"
+	"errors"
+	"io"
+	"maps"
+	"reflect"
+	"slices"
+)
+
+// incrementalBase describes the original file of an incremental update.
+type incrementalBase struct {
+	r *Reader
+
+	// xRefPos is the byte offset of the last cross-reference section in the
+	// original file.  This is used for the /Prev entry of the new trailer.
+	xRefPos int64
+
+	root     Reference
+	infoRef  Reference
+	info     Info
+	metadata Reference
+}
+
+// NewIncrementalWriter prepares an incremental update of the PDF file read
+// by r.
+//
+// The contents of the original file are first copied to w unchanged.  All
+// objects written to the returned Writer are then appended to the file,
+// followed by a cross-reference section which links to the cross-reference
+// data of the original file.  Objects of the original file keep their
+// references, and writing an object with an existing reference replaces
+// this object in the updated file.  Since the bytes of the original file
+// are not modified, existing digital signatures remain valid.
+//
+// The [MetaInfo] of the Writer is initialised from r.  On [Writer.Close],
+// the document catalog is written to its original reference.  The Info
+// dictionary and the document metadata stream are only written if they
+// were changed.  If the original file is encrypted, the new objects are
+// encrypted using the same key.  The Reader r must remain open until the
+// Writer is closed.
+//
+// If w implements [io.ReadSeeker], [Writer.Get] can be used to read objects
+// from both the original file and the update.
+func NewIncrementalWriter(w io.Writer, r *Reader) (*Writer, error) {
+	if r.headerOffset != 0 {
+		return nil, errors.New("incremental update: data before PDF header")
+	}
+	xRefPos, err := r.findXRef(r.size)
+	if err != nil {
+		return nil, Wrap(err, "incremental update")
+	}
+	head := make([]byte, 4)
+	_, err = r.r.ReadAt(head, xRefPos)
+	if err != nil {
+		return nil, err
+	}
+	useTable := string(head) == "xref"
+
+	base := &incrementalBase{
+		r:       r,
+		xRefPos: xRefPos,
+	}
+	base.root, _ = r.meta.Trailer["Root"].(Reference)
+	base.infoRef, _ = r.meta.Trailer["Info"].(Reference)
+	if r.meta.Info != nil {
+		base.info = *r.meta.Info
+	}
+	catDict, err := NewCursor(r).Dict(r.meta.Trailer["Root"])
+	if IsReadError(err) {
+		return nil, err
+	}
+	base.metadata, _ = catDict["Metadata"].(Reference)
+
+	nextRef := uint32(1)
+	for number := range r.xref {
+		nextRef = max(nextRef, number+1)
+	}
+
+	var ID [][]byte
+	if r.meta.ID != nil {
+		// The first identifier stays the same, the second one
+		// changes with every update.
+		id := make([]byte, 16)
+		_, err := io.ReadFull(rand.Reader, id)
+		if err != nil {
+			return nil, err
+		}
+		ID = [][]byte{r.meta.ID[0], id}
+	}
+
+	trailer := r.meta.Trailer.Clone()
+	delete(trailer, "Root")
+	delete(trailer, "Info")
+	delete(trailer, "ID")
+
+	catalog := &Catalog{}
+	if r.meta.Catalog != nil {
+		*catalog = *r.meta.Catalog
+	}
+	info := &Info{}
+	if r.meta.Info != nil {
+		*info = *r.meta.Info
+		info.Custom = maps.Clone(r.meta.Info.Custom)
+	}
+
+	v := r.meta.Version
+	outOpt := defaultOutputOptions(v)
+	if useTable {
+		outOpt &= ^(optObjStm | optXRefStream)
+	}
+	if v < V2_0 {
+		outOpt |= OptTrimStandardFonts
+	}
+
+	bufferedW, ok := w.(writeFlusher)
+	if !ok {
+		bufferedW = bufio.NewWriter(w)
+	}
+
+	pdf := &Writer{
+		meta: MetaInfo{
+			Version:    v,
+			Catalog:    catalog,
+			Info:       info,
+			ID:         ID,
+			Trailer:    trailer,
+			Encryption: r.meta.Encryption,
+		},
+
+		w: &posWriter{
+			w: bufferedW,
+		},
+		origW: w,
+
+		nextRef: nextRef,
+		xref:    make(map[uint32]*xRefEntry),
+
+		outputOptions: outOpt,
+
+		documentMetadata: catalog.Metadata,
+		refIsPlaintext:   map[Reference]bool{},
+
+		base: base,
+	}
+	pdf.rm = NewResourceManager(pdf)
+
+	// The existing metadata stream is used unchanged, unless the caller
+	// replaces Catalog.Metadata.
+	if catalog.Metadata != nil && base.metadata != 0 {
+		pdf.rm.embedded[catalog.Metadata] = base.metadata
+	}
+
+	_, err = io.Copy(pdf.w, io.NewSectionReader(r.r, 0, r.size))
+	if err != nil {
+		return nil, err
+	}
+	last := make([]byte, 1)
+	_, err = r.r.ReadAt(last, r.size-1)
+	if err != nil {
+		return nil, err
+	}
+	if last[0] != '\n' && last[0] != '\r' {
+		_, err = pdf.w.Write([]byte("\n"))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// New objects use the encryption of the original file.  This is set
+	// only after copying, so that the copied bytes are not affected.
+	pdf.w.enc = r.enc
+
+	return pdf, nil
+}
+
+// xRefSections returns the subsections of the cross-reference data to be
+// written, as pairs of first object number and number of entries.
+//
+// For a complete file, a single subsection covers all objects.  For an
+// incremental update, only the objects written as part of the update are
+// included.
+func (w *Writer) xRefSections() [][2]uint32 {
+	if w.base == nil {
+		return [][2]uint32{{0, w.nextRef}}
+	}
+
+	numbers := slices.Sorted(maps.Keys(w.xref))
+	var res [][2]uint32
+	for _, n := range numbers {
+		if k := len(res) - 1; k >= 0 && res[k][0]+res[k][1] == n {
+			res[k][1]++
+		} else {
+			res = append(res, [2]uint32{n, 1})
+		}
+	}
+	return res
+}
+
+// closeIncremental prepares the document catalog and the trailer for
+// writing an incremental update.  Catalog updates are written to the
+// original catalog reference, and the Info dictionary is only written
+// if it was changed.
+func (w *Writer) closeIncremental(trailer Dict) (skipInfo bool) {
+	if w.base.root != 0 {
+		if _, seen := w.rm.embedded[w.meta.Catalog]; !seen {
+			w.rm.embedded[w.meta.Catalog] = w.base.root
+			w.rm.reserved[w.meta.Catalog] = true
+		}
+	}
+
+	trailer["Prev"] = Integer(w.base.xRefPos)
+
+	if w.meta.Info != nil && w.base.infoRef != 0 &&
+		reflect.DeepEqual(*w.meta.Info, w.base.info) {
+		trailer["Info"] = w.base.infoRef
+		return true
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdf
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+// writeIncrementalBase writes a minimal PDF file for testing incremental
+// updates.
+func writeIncrementalBase(t *testing.T, v Version, opt *WriterOptions) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, v, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pagesRef := w.Alloc()
+	pageRef := w.Alloc()
+	err = w.Put(pagesRef, Dict{
+		"Type":  Name("Pages"),
+		"Kids":  Array{pageRef},
+		"Count": Integer(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.Put(pageRef, Dict{
+		"Type":     Name("Page"),
+		"Parent":   pagesRef,
+		"MediaBox": Array{Integer(0), Integer(0), Integer(100), Integer(100)},
+		"Comment":  String("original page"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.GetMeta().Catalog.Pages = pagesRef
+	w.GetMeta().Info = &Info{Title: "Base Document"}
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestIncrementalWriter(t *testing.T) {
+	cases := []struct {
+		name string
+		v    Version
+		opt  *WriterOptions
+	}{
+		{"table", V1_4, nil},
+		{"stream", V1_7, nil},
+		{"encrypted", V1_7, &WriterOptions{UserPassword: "secret"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			data1 := writeIncrementalBase(t, tc.v, tc.opt)
+			ropt := &ReaderOptions{Password: "secret"}
+			r1, err := NewReader(bytes.NewReader(data1), int64(len(data1)), ropt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			oldRoot := r1.meta.Trailer["Root"]
+			oldInfo := r1.meta.Trailer["Info"]
+
+			buf := &bytes.Buffer{}
+			w, err := NewIncrementalWriter(buf, r1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			newRef := w.Alloc()
+			err = w.Put(newRef, Dict{"Test": String("added in update")})
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.GetMeta().Catalog.Lang = language.MustParse("de-DE")
+			w.GetMeta().Catalog.AA = newRef
+			err = w.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data2 := buf.Bytes()
+
+			if !bytes.HasPrefix(data2, data1) {
+				t.Fatal("original file contents were modified")
+			}
+
+			r2, err := NewReader(bytes.NewReader(data2), int64(len(data2)), ropt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if r2.meta.Trailer["Root"] != oldRoot {
+				t.Errorf("catalog moved from %v to %v", oldRoot, r2.meta.Trailer["Root"])
+			}
+			if r2.meta.Trailer["Info"] != oldInfo {
+				t.Errorf("unchanged Info dictionary was rewritten")
+			}
+			if r2.meta.Info == nil || r2.meta.Info.Title != "Base Document" {
+				t.Errorf("wrong Info dictionary %v", r2.meta.Info)
+			}
+			if r2.meta.Catalog.Lang != language.MustParse("de-DE") {
+				t.Errorf("catalog update missing: Lang=%v", r2.meta.Catalog.Lang)
+			}
+			if r1.meta.ID != nil && (len(r2.meta.ID) != 2 || !bytes.Equal(r2.meta.ID[0], r1.meta.ID[0])) {
+				t.Errorf("wrong file identifier %x", r2.meta.ID)
+			}
+
+			c := NewCursor(r2)
+			dict, err := c.Dict(r2.meta.Catalog.AA)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s, _ := dict["Test"].(String); string(s) != "added in update" {
+				t.Errorf("wrong new object %v", dict)
+			}
+
+			pages, err := c.Dict(r2.meta.Catalog.Pages)
+			if err != nil {
+				t.Fatal(err)
+			}
+			page, err := c.Dict(pages["Kids"].(Array)[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s, _ := page["Comment"].(String); string(s) != "original page" {
+				t.Errorf("wrong original object %v", page)
+			}
+		})
+	}
+}
+
+func TestIncrementalInfo(t *testing.T) {
+	data1 := writeIncrementalBase(t, V1_7, nil)
+	r1, err := NewReader(bytes.NewReader(data1), int64(len(data1)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewIncrementalWriter(buf, r1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.GetMeta().Info.Title = "Updated Document"
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r1.meta.Info.Title != "Base Document" {
+		t.Error("Info of the original Reader was modified")
+	}
+
+	data2 := buf.Bytes()
+	r2, err := NewReader(bytes.NewReader(data2), int64(len(data2)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r2.meta.Info.Title != "Updated Document" {
+		t.Errorf("wrong title %q", r2.meta.Info.Title)
+	}
+}
@@ -0,0 +1,254 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package colorants finds the named colorants used by a page.
+//
+// The search covers the colour spaces listed in a resource dictionary,
+// together with those of images, shadings and patterns, and recurses into
+// the resources of form XObjects and tiling patterns.  Colorants are
+// reported as Separation colour spaces, one per colorant name.  Colorants
+// of DeviceN colour spaces are converted into equivalent Separation colour
+// spaces.
+package colorants
+
+import (
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/function"
+	"seehuhn.de/go/pdf/graphics/color"
+)
+
+// maxDepth limits the nesting of form XObjects and patterns.
+const maxDepth = 32
+
+// IsProcess reports whether name is one of the four process colorants of
+// DeviceCMYK.
+func IsProcess(name pdf.Name) bool {
+	switch name {
+	case "Cyan", "Magenta", "Yellow", "Black":
+		return true
+	}
+	return false
+}
+
+// Collect returns the spot colorants used in the given resource dictionary.
+// The special colorants All and None, and the process colorants, are not
+// included.  Malformed entries are skipped.
+func Collect(x *pdf.Extractor, resources pdf.Object) (map[pdf.Name]*color.SpaceSeparation, error) {
+	c := &collector{
+		x:    x,
+		seen: map[pdf.Reference]bool{},
+		res:  map[pdf.Name]*color.SpaceSeparation{},
+	}
+	if err := c.resources(resources, 0); err != nil {
+		return nil, err
+	}
+	return c.res, nil
+}
+
+type collector struct {
+	x    *pdf.Extractor
+	seen map[pdf.Reference]bool
+	res  map[pdf.Name]*color.SpaceSeparation
+}
+
+// visit reports whether obj still needs to be examined, and marks indirect
+// objects as seen.
+func (c *collector) visit(obj pdf.Object) bool {
+	ref, ok := obj.(pdf.Reference)
+	if !ok {
+		return true
+	}
+	if c.seen[ref] {
+		return false
+	}
+	c.seen[ref] = true
+	return true
+}
+
+func (c *collector) resources(obj pdf.Object, depth int) error {
+	if depth > maxDepth || !c.visit(obj) {
+		return nil
+	}
+	cur := pdf.CursorAt(c.x, nil)
+	res, err := cur.Dict(obj)
+	if err != nil || res == nil {
+		return readError(err)
+	}
+
+	spaces, _ := cur.Dict(res["ColorSpace"])
+	for _, cs := range spaces {
+		if err := c.space(cs); err != nil {
+			return err
+		}
+	}
+
+	shadings, _ := cur.Dict(res["Shading"])
+	for _, sh := range shadings {
+		if err := c.shading(sh); err != nil {
+			return err
+		}
+	}
+
+	xobjects, _ := cur.Dict(res["XObject"])
+	for _, xo := range xobjects {
+		if !c.visit(xo) {
+			continue
+		}
+		stm, err := cur.Stream(xo)
+		if err != nil || stm == nil {
+			if err := readError(err); err != nil {
+				return err
+			}
+			continue
+		}
+		subtype, _ := cur.Name(stm.Dict["Subtype"])
+		switch subtype {
+		case "Image":
+			if err := c.space(stm.Dict["ColorSpace"]); err != nil {
+				return err
+			}
+		case "Form":
+			if err := c.resources(stm.Dict["Resources"], depth+1); err != nil {
+				return err
+			}
+		}
+	}
+
+	patterns, _ := cur.Dict(res["Pattern"])
+	for _, pat := range patterns {
+		if !c.visit(pat) {
+			continue
+		}
+		resolved, err := cur.Resolve(pat)
+		if err != nil {
+			if err := readError(err); err != nil {
+				return err
+			}
+			continue
+		}
+		switch pat := resolved.(type) {
+		case *pdf.Stream: // tiling pattern
+			if err := c.resources(pat.Dict["Resources"], depth+1); err != nil {
+				return err
+			}
+		case pdf.Dict: // shading pattern
+			if err := c.shading(pat["Shading"]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *collector) shading(obj pdf.Object) error {
+	cur := pdf.CursorAt(c.x, nil)
+	resolved, err := cur.Resolve(obj)
+	if err != nil {
+		return readError(err)
+	}
+	var dict pdf.Dict
+	switch sh := resolved.(type) {
+	case pdf.Dict:
+		dict = sh
+	case *pdf.Stream:
+		dict = sh.Dict
+	default:
+		return nil
+	}
+	return c.space(dict["ColorSpace"])
+}
+
+// space records the colorants of a colour space.
+func (c *collector) space(obj pdf.Object) error {
+	if obj == nil {
+		return nil
+	}
+	space, err := pdf.DecodeOptional(pdf.CursorAt(c.x, nil), obj, color.ExtractSpace)
+	if err != nil {
+		return readError(err)
+	}
+
+	// the base space of an Indexed colour space may be a spot colour space
+	if idx, ok := space.(*color.SpaceIndexed); ok {
+		space = idx.Base
+	}
+
+	switch s := space.(type) {
+	case *color.SpaceSeparation:
+		c.add(s.Colorant, s)
+	case *color.SpaceDeviceN:
+		for i, name := range s.Colorants {
+			if c.res[name] != nil || !IsSpot(name) {
+				continue
+			}
+			if sep := fromDeviceN(s, i); sep != nil {
+				c.add(name, sep)
+			}
+		}
+	}
+	return nil
+}
+
+func (c *collector) add(name pdf.Name, s *color.SpaceSeparation) {
+	if !IsSpot(name) || c.res[name] != nil {
+		return
+	}
+	c.res[name] = s
+}
+
+// IsSpot reports whether name is a spot colorant, that is neither a
+// process colorant nor one of the special colorants All and None.
+func IsSpot(name pdf.Name) bool {
+	return name != "All" && name != "None" && !IsProcess(name)
+}
+
+// fromDeviceN constructs a Separation colour space for colorant i of a
+// DeviceN colour space.  The tint transform is approximated by linear
+// interpolation between the alternate colours for tints 0 and 1.
+func fromDeviceN(s *color.SpaceDeviceN, i int) *color.SpaceSeparation {
+	nIn, nOut := s.Transform.Shape()
+	if i >= nIn {
+		return nil
+	}
+	in := make([]float64, nIn)
+	c0 := make([]float64, nOut)
+	s.Transform.Apply(c0, in...)
+	in[i] = 1
+	c1 := make([]float64, nOut)
+	s.Transform.Apply(c1, in...)
+
+	sep, err := color.Separation(s.Colorants[i], s.Alternate, &function.Type2{
+		XMin: 0,
+		XMax: 1,
+		C0:   c0,
+		C1:   c1,
+		N:    1,
+	})
+	if err != nil {
+		return nil
+	}
+	return sep
+}
+
+// readError returns err if it is a read error, and nil for malformed
+// objects, which are skipped.
+func readError(err error) error {
+	if pdf.IsReadError(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package colorants
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/function"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+)
+
+func TestCollect(t *testing.T) {
+	w, _ := memfile.NewPDFWriter(pdf.V1_7, nil)
+	rm := pdf.NewResourceManager(w)
+
+	gold, err := color.Separation("Gold", color.SpaceDeviceCMYK, &function.Type2{
+		XMin: 0,
+		XMax: 1,
+		C0:   []float64{0, 0, 0, 0},
+		C1:   []float64{0, 0.2, 0.8, 0.1},
+		N:    1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := color.Separation("All", color.SpaceDeviceGray, &function.Type2{
+		XMin: 0,
+		XMax: 1,
+		C0:   []float64{1},
+		C1:   []float64{0},
+		N:    1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	duo, err := color.DeviceN([]pdf.Name{"Cyan", "Silver"}, color.SpaceDeviceCMYK, &function.Type4{
+		Domain:  []float64{0, 1, 0, 1},
+		Range:   []float64{0, 1, 0, 1, 0, 1, 0, 1},
+		Program: "0 0 3 -1 roll 2 div",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	embed := func(s color.Space) pdf.Object {
+		obj, err := rm.Embed(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return obj
+	}
+
+	// the DeviceN space is only reachable through a form XObject
+	formRef := w.Alloc()
+	formDict := pdf.Dict{
+		"Type":    pdf.Name("XObject"),
+		"Subtype": pdf.Name("Form"),
+		"BBox":    &pdf.Rectangle{URx: 1, URy: 1},
+		"Resources": pdf.Dict{
+			"ColorSpace": pdf.Dict{"CS0": embed(duo)},
+		},
+	}
+	stm, err := w.OpenStream(formRef, formDict)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	res := pdf.Dict{
+		"ColorSpace": pdf.Dict{
+			"CS0": embed(gold),
+			"CS1": embed(all),
+		},
+		"XObject": pdf.Dict{"F0": formRef},
+	}
+	if err := rm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Collect(pdf.NewExtractor(w), res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []pdf.Name
+	for name := range got {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	if want := []pdf.Name{"Gold", "Silver"}; !slices.Equal(names, want) {
+		t.Fatalf("got colorants %v, want %v", names, want)
+	}
+
+	// full Silver maps to 50% black, as given by the DeviceN tint transform
+	out := make([]float64, 4)
+	got["Silver"].Transform.Apply(out, 1)
+	if want := []float64{0, 0, 0, 0.5}; !slices.EqualFunc(out, want, func(a, b float64) bool {
+		return math.Abs(a-b) < 1e-9
+	}) {
+		t.Errorf("Silver at full tint is %v, want %v", out, want)
+	}
+}
+
+func TestIsProcess(t *testing.T) {
+	for _, name := range []pdf.Name{"Cyan", "Magenta", "Yellow", "Black"} {
+		if !IsProcess(name) {
+			t.Errorf("%q not recognised as a process colorant", name)
+		}
+	}
+	if IsProcess("Gold") || IsProcess("All") {
+		t.Error("spot colorant reported as process colorant")
+	}
+}
@@ -280,6 +280,12 @@ const (
 	// for CJK fonts while bounding decompression amplification.
 	MaxFontProgramBytes = 16 << 20
 
+	// MaxValidationDataBytes caps the decoded byte count of a certificate,
+	// OCSP response, CRL or time-stamp token stream in a document security
+	// store.  Revocation lists of large certification authorities can reach
+	// several MiB.
+	MaxValidationDataBytes = 64 << 20
+
 	// MaxStringOrStreamBytes caps the decoded byte count of a value held
 	// in the "text string or stream" dual form (form field values, rich
 	// text contents, embedded scripts).  Such values carry text, never
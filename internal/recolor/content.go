@@ -0,0 +1,251 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package recolor
+
+import (
+	"bytes"
+	"io"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/page"
+)
+
+// srcSpace describes a colour space selected by the source content.
+type srcSpace struct {
+	// space is the colour space.  For pattern colour spaces, this is the
+	// underlying colour space of uncoloured patterns, or nil for coloured
+	// patterns.
+	space color.Space
+
+	pattern bool
+}
+
+// colorState holds the current colour spaces of the source content.  A nil
+// entry means that the colour space is inherited from the surrounding
+// content and is not known.
+type colorState struct {
+	fill, stroke *srcSpace
+}
+
+func (s *colorState) set(stroke bool, space *srcSpace) {
+	if stroke {
+		s.stroke = space
+	} else {
+		s.fill = space
+	}
+}
+
+func (s *colorState) get(stroke bool) *srcSpace {
+	if stroke {
+		return s.stroke
+	}
+	return s.fill
+}
+
+// content converts a content stream, or an array of content streams, and
+// appends the result to buf.
+func (r *Rewriter) content(buf *bytes.Buffer, contents pdf.Object, res *resources, init *colorState) error {
+	open := func() (io.ReadCloser, error) { return r.openContent(contents) }
+	it := content.NewScanner(open).NewIter()
+
+	state := *init
+	var stack []colorState
+	for name, args := range it.All() {
+		op := content.Operator{Name: name, Args: args}
+
+		var err error
+		switch name {
+		case content.OpPushGraphicsState:
+			stack = append(stack, state)
+			err = write(buf, op)
+
+		case content.OpPopGraphicsState:
+			if n := len(stack); n > 0 {
+				state = stack[n-1]
+				stack = stack[:n-1]
+			}
+			err = write(buf, op)
+
+		case content.OpSetFillGray, content.OpSetStrokeGray:
+			err = r.deviceColor(buf, &state, name == content.OpSetStrokeGray,
+				r.device(res, color.FamilyDeviceGray), op)
+		case content.OpSetFillRGB, content.OpSetStrokeRGB:
+			err = r.deviceColor(buf, &state, name == content.OpSetStrokeRGB,
+				r.device(res, color.FamilyDeviceRGB), op)
+		case content.OpSetFillCMYK, content.OpSetStrokeCMYK:
+			err = r.deviceColor(buf, &state, name == content.OpSetStrokeCMYK,
+				r.device(res, color.FamilyDeviceCMYK), op)
+
+		case content.OpSetFillColorSpace, content.OpSetStrokeColorSpace:
+			err = r.colorSpace(buf, &state, name == content.OpSetStrokeColorSpace, res, op)
+
+		case content.OpSetFillColor, content.OpSetFillColorN:
+			err = r.color(buf, state.fill, false, op)
+		case content.OpSetStrokeColor, content.OpSetStrokeColorN:
+			err = r.color(buf, state.stroke, true, op)
+
+		case content.OpInlineImage:
+			err = r.inlineImage(buf, res, op)
+
+		default:
+			err = write(buf, op)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// deviceColor converts one of the operators g, G, rg, RG, k and K.
+func (r *Rewriter) deviceColor(buf *bytes.Buffer, state *colorState, stroke bool, space color.Space, op content.Operator) error {
+	state.set(stroke, &srcSpace{space: space})
+	if r.keeps(space) {
+		return write(buf, op)
+	}
+	return r.setColor(buf, stroke, r.convert(space, numbers(op.Args)), nil)
+}
+
+// colorSpace converts one of the operators cs and CS.  Since these operators
+// also set the initial colour of the new colour space, a non-kept colour
+// space is replaced by an operator which sets the converted initial colour.
+func (r *Rewriter) colorSpace(buf *bytes.Buffer, state *colorState, stroke bool, res *resources, op content.Operator) error {
+	var space *srcSpace
+	if len(op.Args) > 0 {
+		if name, ok := op.Args[0].(pdf.Name); ok {
+			space = r.space(res, name)
+		}
+	}
+	state.set(stroke, space)
+
+	switch {
+	case space == nil:
+		return write(buf, op)
+
+	case space.pattern:
+		if space.space == nil || r.keeps(space.space) {
+			return write(buf, op)
+		}
+		op.Args = []pdf.Object{res.patternSpaceName()}
+		return write(buf, op)
+
+	case r.keeps(space.space):
+		return write(buf, op)
+	}
+
+	init, _ := color.Values(space.space.Default())
+	return r.setColor(buf, stroke, r.convert(space.space, init), nil)
+}
+
+// color converts one of the operators sc, scn, SC and SCN.
+func (r *Rewriter) color(buf *bytes.Buffer, space *srcSpace, stroke bool, op content.Operator) error {
+	if space == nil || space.space == nil || r.keeps(space.space) {
+		return write(buf, op)
+	}
+	if !space.pattern {
+		return r.setColor(buf, stroke, r.convert(space.space, numbers(op.Args)), nil)
+	}
+
+	// uncoloured patterns: the colour components are followed by the
+	// pattern name
+	n := len(op.Args)
+	if n == 0 {
+		return write(buf, op)
+	}
+	name, ok := op.Args[n-1].(pdf.Name)
+	if !ok {
+		return write(buf, op)
+	}
+	return r.setColor(buf, stroke, r.convert(space.space, numbers(op.Args[:n-1])), &name)
+}
+
+// setColor writes an operator which sets a colour in the target colour
+// space.  If pattern is not nil, the colour is used for the given
+// uncoloured pattern, and the current colour space must already be a
+// pattern colour space over the target space.
+func (r *Rewriter) setColor(buf *bytes.Buffer, stroke bool, values []float64, pattern *pdf.Name) error {
+	args := make([]pdf.Object, 0, len(values)+1)
+	for _, v := range values {
+		args = append(args, pdf.Number(pdf.Round(v, coordDigits)))
+	}
+
+	var name content.OpName
+	if pattern != nil {
+		args = append(args, *pattern)
+		name = content.OpSetFillColorN
+		if stroke {
+			name = content.OpSetStrokeColorN
+		}
+	} else {
+		switch r.target.Family() {
+		case color.FamilyDeviceGray:
+			name = content.OpSetFillGray
+			if stroke {
+				name = content.OpSetStrokeGray
+			}
+		case color.FamilyDeviceRGB:
+			name = content.OpSetFillRGB
+			if stroke {
+				name = content.OpSetStrokeRGB
+			}
+		default:
+			name = content.OpSetFillCMYK
+			if stroke {
+				name = content.OpSetStrokeCMYK
+			}
+		}
+	}
+	return write(buf, content.Operator{Name: name, Args: args})
+}
+
+// openContent returns a reader over the decoded, concatenated content named
+// by contents, which may be a single stream or an array of streams.
+func (r *Rewriter) openContent(contents pdf.Object) (io.ReadCloser, error) {
+	cur := pdf.CursorAt(r.x, nil)
+	resolved, err := cur.Resolve(contents)
+	if err != nil {
+		return nil, err
+	}
+	segments, err := page.ExtractContents(cur, resolved)
+	if err != nil {
+		return nil, err
+	}
+	return page.SegmentsReader(segments), nil
+}
+
+// write appends an operator to buf.
+func write(buf *bytes.Buffer, op content.Operator) error {
+	return op.Format(buf)
+}
+
+// numbers returns the numeric operands of an operator.
+func numbers(args []pdf.Object) []float64 {
+	res := make([]float64, 0, len(args))
+	for _, arg := range args {
+		switch x := arg.(type) {
+		case pdf.Integer:
+			res = append(res, float64(x))
+		case pdf.Real:
+			res = append(res, float64(x))
+		case pdf.Number:
+			res = append(res, float64(x))
+		}
+	}
+	return res
+}
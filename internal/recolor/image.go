@@ -0,0 +1,357 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package recolor
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"io"
+	"maps"
+	"math"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/image"
+)
+
+// maxCacheSize bounds the number of distinct pixel colours remembered while
+// an image is converted.
+const maxCacheSize = 1 << 16
+
+// imageKeys lists the image dictionary entries which describe the samples,
+// and which are replaced when an image is converted.
+var imageKeys = []pdf.Name{"ColorSpace", "BitsPerComponent", "Decode", "SMaskInData"}
+
+// image converts an image XObject.  Image masks, JPEG 2000 images and images
+// in kept colour spaces are copied.  The palette of an Indexed image is
+// converted in place; all other images are decoded and written with 8 bits
+// per component in the target colour space.
+func (r *Rewriter) image(obj pdf.Object, stm *pdf.Stream) (pdf.Object, error) {
+	cur := pdf.CursorAt(r.x, nil)
+	if isMask, _ := cur.Boolean(stm.Dict["ImageMask"]); isMask {
+		return r.copyNative(obj)
+	}
+	img, err := pdf.DecodeOptional(cur, obj, image.ExtractDict)
+	if err := readError(err); err != nil {
+		return nil, err
+	}
+	if img == nil || img.ColorSpace == nil || img.Data == nil || img.Data.IsJPX() ||
+		r.keeps(img.ColorSpace) {
+		return r.copyNative(obj)
+	}
+
+	if idx, ok := img.ColorSpace.(*color.SpaceIndexed); ok {
+		return r.indexedImage(stm, idx)
+	}
+
+	data, err := img.Load()
+	if err != nil {
+		if err := readError(err); err != nil {
+			return nil, err
+		}
+		return r.copyNative(obj)
+	}
+	pix := r.convertPixels(data)
+
+	var omit []pdf.Name
+	omit = append(omit, imageKeys...)
+	if _, isArray := stm.Dict["Mask"].(pdf.Array); isArray {
+		omit = append(omit, "Mask")
+	}
+	dict, err := r.copyStreamDict(stm.Dict, omit...)
+	if err != nil {
+		return nil, err
+	}
+	dict["ColorSpace"] = r.target.Family()
+	dict["BitsPerComponent"] = pdf.Integer(8)
+
+	// A colour key mask refers to the original samples, so it is replaced
+	// by an equivalent soft mask.  An existing soft mask takes precedence.
+	if img.MaskColors != nil && img.SMask == nil {
+		smask, err := r.colorKeyMask(img)
+		if err != nil {
+			return nil, err
+		}
+		if smask != 0 {
+			dict["SMask"] = smask
+		}
+	}
+
+	ref := r.out.Alloc()
+	if err := r.writeStream(ref, dict, pix); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// indexedImage copies an Indexed image, converting the colour palette.
+func (r *Rewriter) indexedImage(stm *pdf.Stream, idx *color.SpaceIndexed) (pdf.Object, error) {
+	// the original colour space is not copied, since it is replaced
+	src := *stm
+	src.Dict = maps.Clone(stm.Dict)
+	delete(src.Dict, "ColorSpace")
+	copied, err := r.copy.Copy(&src)
+	if err != nil {
+		return nil, err
+	}
+	out, ok := copied.(*pdf.Stream)
+	if !ok {
+		return copied, nil
+	}
+	out.Dict["ColorSpace"] = r.palette(idx)
+
+	ref := r.out.Alloc()
+	if err := r.out.Put(ref, out); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// palette returns an Indexed colour space over the target space, with the
+// converted colours of idx.
+func (r *Rewriter) palette(idx *color.SpaceIndexed) pdf.Array {
+	lookup := make([]byte, 0, idx.NumCol*r.target.Channels())
+	for i := range idx.NumCol {
+		for _, v := range r.convert(idx, []float64{float64(i)}) {
+			lookup = append(lookup, byte(math.Round(v*255)))
+		}
+	}
+	return pdf.Array{
+		pdf.Name(color.FamilyIndexed),
+		r.target.Family(),
+		pdf.Integer(idx.NumCol - 1),
+		pdf.String(lookup),
+	}
+}
+
+// convertPixels converts decoded image data to 8-bit samples in the target
+// colour space.
+func (r *Rewriter) convertPixels(data *image.Data) []byte {
+	n := data.NComp
+	nOut := r.target.Channels()
+	w, h := data.Rect.Dx(), data.Rect.Dy()
+	res := make([]byte, 0, w*h*nOut)
+
+	cache := make(map[string][]byte)
+	key := make([]byte, 4*n)
+	values := make([]float64, n)
+	for y := range h {
+		row := data.Pix[y*data.Stride:]
+		for x := range w {
+			px := row[x*n : (x+1)*n]
+			for i, v := range px {
+				binary.LittleEndian.PutUint32(key[4*i:], math.Float32bits(v))
+			}
+			out, ok := cache[string(key)]
+			if !ok {
+				for i, v := range px {
+					values[i] = float64(v)
+				}
+				out = make([]byte, nOut)
+				for i, v := range r.convert(data.CS, values) {
+					out[i] = byte(math.Round(v * 255))
+				}
+				if len(cache) >= maxCacheSize {
+					clear(cache)
+				}
+				cache[string(key)] = out
+			}
+			res = append(res, out...)
+		}
+	}
+	return res
+}
+
+// colorKeyMask writes a soft mask which is transparent exactly where the
+// colour key mask of img masks out the image.  The result is zero if no
+// pixel is masked.
+func (r *Rewriter) colorKeyMask(img *image.Dict) (pdf.Reference, error) {
+	raw, err := img.Data.Pixels()
+	if err != nil {
+		return 0, readError(err)
+	}
+	n := img.ColorSpace.Channels()
+	if len(img.MaskColors) < 2*n {
+		return 0, nil
+	}
+
+	alpha := make([]byte, img.Width*img.Height)
+	masked := false
+	for y := range img.Height {
+		for x := range img.Width {
+			inside := true
+			for c := range n {
+				s := rawSample(raw, img.Width, n, img.BitsPerComponent, x, y, c)
+				if s < img.MaskColors[2*c] || s > img.MaskColors[2*c+1] {
+					inside = false
+					break
+				}
+			}
+			if inside {
+				masked = true
+			} else {
+				alpha[y*img.Width+x] = 255
+			}
+		}
+	}
+	if !masked {
+		return 0, nil
+	}
+
+	ref := r.out.Alloc()
+	dict := pdf.Dict{
+		"Type":             pdf.Name("XObject"),
+		"Subtype":          pdf.Name("Image"),
+		"Width":            pdf.Integer(img.Width),
+		"Height":           pdf.Integer(img.Height),
+		"ColorSpace":       pdf.Name(color.FamilyDeviceGray),
+		"BitsPerComponent": pdf.Integer(8),
+	}
+	if err := r.writeStream(ref, dict, alpha); err != nil {
+		return 0, err
+	}
+	return ref, nil
+}
+
+// rawSample returns the undecoded value of component c of pixel (x, y).
+// Missing data reads as zero.
+func rawSample(data []byte, width, n, bpc, x, y, c int) uint16 {
+	rowBytes := (width*n*bpc + 7) / 8
+	bit := (x*n + c) * bpc
+	pos := y*rowBytes + bit/8
+	switch bpc {
+	case 16:
+		if pos+1 < len(data) {
+			return uint16(data[pos])<<8 | uint16(data[pos+1])
+		}
+	case 8:
+		if pos < len(data) {
+			return uint16(data[pos])
+		}
+	default:
+		if pos < len(data) {
+			shift := 8 - bpc - bit%8
+			return uint16(data[pos]>>shift) & (1<<bpc - 1)
+		}
+	}
+	return 0
+}
+
+// inlineImage converts an inline image.  The converted image is written
+// with 8 bits per component, Flate-compressed and hex-encoded.
+func (r *Rewriter) inlineImage(buf *bytes.Buffer, res *resources, op content.Operator) error {
+	if len(op.Args) < 2 {
+		return write(buf, op)
+	}
+	dict, _ := op.Args[0].(pdf.Dict)
+	if isMask, _ := dict["IM"].(pdf.Boolean); isMask {
+		return write(buf, op)
+	}
+	if isMask, _ := dict["ImageMask"].(pdf.Boolean); isMask {
+		return write(buf, op)
+	}
+
+	ir := r.inlineResources(res)
+	space := content.InlineImageColorSpace(dict, ir)
+	if space == nil || r.keeps(space) {
+		return write(buf, op)
+	}
+	raw, err := content.DecodeInlineImage(op, ir)
+	if err != nil {
+		return write(buf, op)
+	}
+
+	width, _ := inlineInt(dict, "W", "Width")
+	height, _ := inlineInt(dict, "H", "Height")
+	bpc, _ := inlineInt(dict, "BPC", "BitsPerComponent")
+	img := &image.Dict{
+		Width:            width,
+		Height:           height,
+		ColorSpace:       space,
+		BitsPerComponent: bpc,
+		Data: image.NewFlateSource(width, space, bpc, func(w io.Writer) error {
+			_, err := w.Write(raw)
+			return err
+		}),
+	}
+	if d, ok := inlineValue(dict, "D", "Decode").(pdf.Array); ok {
+		img.Decode = numbers(d)
+	}
+	if width <= 0 || height <= 0 {
+		return write(buf, op)
+	}
+	data, err := img.Load()
+	if err != nil {
+		return write(buf, op)
+	}
+	pix := r.convertPixels(data)
+
+	var z bytes.Buffer
+	zw := zlib.NewWriter(&z)
+	if _, err := zw.Write(pix); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	encoded := make([]byte, hex.EncodedLen(z.Len())+1)
+	hex.Encode(encoded, z.Bytes())
+	encoded[len(encoded)-1] = '>'
+
+	var cs pdf.Name
+	switch r.target.Family() {
+	case color.FamilyDeviceGray:
+		cs = "G"
+	case color.FamilyDeviceRGB:
+		cs = "RGB"
+	default:
+		cs = "CMYK"
+	}
+	newDict := pdf.Dict{
+		"W":   pdf.Integer(width),
+		"H":   pdf.Integer(height),
+		"CS":  cs,
+		"BPC": pdf.Integer(8),
+		"F":   pdf.Array{pdf.Name("AHx"), pdf.Name("Fl")},
+	}
+	for _, key := range []pdf.Name{"I", "Interpolate", "Intent"} {
+		if v, ok := dict[key]; ok {
+			newDict[key] = v
+		}
+	}
+	return write(buf, content.Operator{
+		Name: content.OpInlineImage,
+		Args: []pdf.Object{newDict, pdf.String(encoded)},
+	})
+}
+
+// inlineValue returns an entry of an inline image dictionary, which may be
+// given under its abbreviated or its full name.
+func inlineValue(dict pdf.Dict, abbrev, full pdf.Name) pdf.Object {
+	if v, ok := dict[abbrev]; ok {
+		return v
+	}
+	return dict[full]
+}
+
+func inlineInt(dict pdf.Dict, abbrev, full pdf.Name) (int, bool) {
+	v, ok := inlineValue(dict, abbrev, full).(pdf.Integer)
+	return int(v), ok
+}
@@ -0,0 +1,201 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package recolor rewrites the colours of PDF content.
+//
+// A [Rewriter] copies content streams, together with the resources they
+// use, from a source document to a writer, and converts every colour on the
+// way using a [Mapper].  This covers the colour operators in content
+// streams, images (including inline images), shadings, coloured tiling
+// patterns, shading patterns, form XObjects and the glyphs of Type 3 fonts.
+// Soft masks are copied unchanged, since they describe opacity rather than
+// colour.
+//
+// Colours in colour spaces which the Mapper keeps are written unchanged, and
+// so are JPEG 2000 images, whose samples cannot be decoded.
+package recolor
+
+import (
+	"bytes"
+	"math"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/color"
+)
+
+// coordDigits is the number of decimal digits kept for colour values written
+// to content streams.
+const coordDigits = 4
+
+// maxDepth bounds the recursion through nested form XObjects, patterns and
+// Type 3 fonts.
+const maxDepth = 40
+
+// A Mapper decides how colours are converted.
+type Mapper interface {
+	// Target returns the colour space colours are converted into.  This
+	// must be one of DeviceGray, DeviceRGB and DeviceCMYK.
+	Target() color.Space
+
+	// Keep reports whether colours in the given colour space are left
+	// unchanged.  Pattern and Indexed colour spaces are never passed to
+	// Keep; for Indexed spaces, the base space is checked instead.
+	Keep(space color.Space) bool
+
+	// Convert converts a colour, given by its colour space and component
+	// values, to the target space.  The result is written to dst, which has
+	// one element per channel of the target space.  The colour space is
+	// never a Pattern or Indexed space, and is never one which Keep
+	// accepts.
+	Convert(space color.Space, values []float64, dst []float64)
+}
+
+// Rewriter converts content streams and their resources.  Objects which are
+// used more than once are converted only once.  Objects without colour, for
+// example fonts other than Type 3 fonts, are copied using the Copier given
+// to [New].
+type Rewriter struct {
+	x      *pdf.Extractor
+	out    *pdf.Writer
+	rm     *pdf.ResourceManager
+	copy   *pdf.Copier
+	m      Mapper
+	target color.Space
+
+	// done maps source objects to their converted copies.
+	done map[pdf.Reference]pdf.Object
+}
+
+// New returns a Rewriter which reads objects through x and writes the
+// converted objects using rm.
+func New(x *pdf.Extractor, rm *pdf.ResourceManager, copier *pdf.Copier, m Mapper) *Rewriter {
+	return &Rewriter{
+		x:      x,
+		out:    rm.Out,
+		rm:     rm,
+		copy:   copier,
+		m:      m,
+		target: m.Target(),
+		done:   map[pdf.Reference]pdf.Object{},
+	}
+}
+
+// Page converts the content of a page.  The function writes a new content
+// stream and returns a reference to it, together with the converted resource
+// dictionary.
+//
+// The initial colour of a page is black in DeviceGray.  Since this may not
+// be black after conversion, the new content stream starts by setting the
+// converted initial colours.
+func (r *Rewriter) Page(contents, resources pdf.Object) (pdf.Reference, pdf.Object, error) {
+	res, err := r.newResources(resources)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var buf bytes.Buffer
+	black := r.convert(color.SpaceDeviceGray, []float64{0})
+	if black != nil {
+		for _, stroke := range []bool{false, true} {
+			if err := r.setColor(&buf, stroke, black, nil); err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+	if contents != nil {
+		gray := &srcSpace{space: color.SpaceDeviceGray}
+		init := &colorState{fill: gray, stroke: gray}
+		if err := r.content(&buf, contents, res, init); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	newRes, err := r.resources(res, 0)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	ref := r.out.Alloc()
+	stm, err := r.out.OpenStream(ref, nil, pdf.FilterCompress{})
+	if err != nil {
+		return 0, nil, err
+	}
+	if _, err := stm.Write(buf.Bytes()); err != nil {
+		return 0, nil, err
+	}
+	if err := stm.Close(); err != nil {
+		return 0, nil, err
+	}
+	return ref, newRes, nil
+}
+
+// Form converts a form XObject, for example the appearance stream of an
+// annotation, and returns the converted copy.
+func (r *Rewriter) Form(obj pdf.Object) (pdf.Object, error) {
+	return r.xObject(obj, 0)
+}
+
+// Group converts a group attributes dictionary, for example the Group entry
+// of a page.  The blending colour space of a transparency group is replaced
+// by the target colour space, unless it is kept.
+func (r *Rewriter) Group(obj pdf.Object) (pdf.Object, error) {
+	return r.group(obj)
+}
+
+// convert converts a colour.  The result is nil if the colour is kept
+// unchanged.  Missing component values are taken from the default colour of
+// the colour space.
+func (r *Rewriter) convert(space color.Space, values []float64) []float64 {
+	if idx, ok := space.(*color.SpaceIndexed); ok {
+		if r.m.Keep(idx.Base) {
+			return nil
+		}
+		i := 0
+		if len(values) > 0 {
+			i = int(math.Round(values[0]))
+		}
+		space, values = idx.Base, idx.Lookup(i)
+	} else if r.m.Keep(space) {
+		return nil
+	}
+
+	n := space.Channels()
+	if len(values) < n {
+		def, _ := color.Values(space.Default())
+		values = append(values[:len(values):len(values)], def[len(values):]...)
+	}
+	dst := make([]float64, r.target.Channels())
+	r.m.Convert(space, values[:n], dst)
+	for i, v := range dst {
+		dst[i] = max(0, min(1, v))
+	}
+	return dst
+}
+
+// keeps reports whether the colours of the given colour space are written
+// unchanged.
+func (r *Rewriter) keeps(space color.Space) bool {
+	switch s := space.(type) {
+	case *color.SpaceIndexed:
+		return r.m.Keep(s.Base)
+	case nil:
+		return true
+	}
+	if color.IsPattern(space) {
+		return false
+	}
+	return r.m.Keep(space)
+}
@@ -0,0 +1,262 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package recolor
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+
+	"seehuhn.de/go/geom/vec"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/function"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/image"
+	"seehuhn.de/go/pdf/graphics/shading"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+)
+
+// grayMapper converts RGB colours to their average and spot colours to
+// one minus the tint.  DeviceGray is kept.
+type grayMapper struct{}
+
+func (grayMapper) Target() color.Space { return color.SpaceDeviceGray }
+
+func (grayMapper) Keep(space color.Space) bool {
+	return space.Family() == color.FamilyDeviceGray
+}
+
+func (grayMapper) Convert(space color.Space, values []float64, dst []float64) {
+	switch space.Family() {
+	case color.FamilyDeviceRGB:
+		dst[0] = (values[0] + values[1] + values[2]) / 3
+	default:
+		dst[0] = 1 - values[0]
+	}
+}
+
+// source writes the objects used by the tests and returns the writer,
+// together with the resource dictionary of the test page.
+func source(t *testing.T) (*pdf.Writer, pdf.Dict) {
+	t.Helper()
+
+	w, _ := memfile.NewPDFWriter(pdf.V1_7, nil)
+	rm := pdf.NewResourceManager(w)
+
+	gold, err := color.Separation("Gold", color.SpaceDeviceCMYK, &function.Type2{
+		XMin: 0,
+		XMax: 1,
+		C0:   []float64{0, 0, 0, 0},
+		C1:   []float64{0, 0.2, 0.8, 0.1},
+		N:    1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	goldObj, err := rm.Embed(gold)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a 2x1 RGB image with red and blue pixels
+	img := &image.Dict{
+		Width:            2,
+		Height:           1,
+		ColorSpace:       color.SpaceDeviceRGB,
+		BitsPerComponent: 8,
+		Data: image.NewFlateSource(2, color.SpaceDeviceRGB, 8, func(w io.Writer) error {
+			_, err := w.Write([]byte{255, 0, 0, 0, 0, 255})
+			return err
+		}),
+	}
+	imgObj, err := rm.Embed(img)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	formRef := w.Alloc()
+	stm, err := w.OpenStream(formRef, pdf.Dict{
+		"Type":      pdf.Name("XObject"),
+		"Subtype":   pdf.Name("Form"),
+		"BBox":      &pdf.Rectangle{URx: 10, URy: 10},
+		"Resources": pdf.Dict{"XObject": pdf.Dict{"Im": imgObj}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stm.Write([]byte("0 0 1 RG /Im Do\n")); err != nil {
+		t.Fatal(err)
+	}
+	if err := stm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	res := pdf.Dict{
+		"ColorSpace": pdf.Dict{"CS0": goldObj},
+		"XObject":    pdf.Dict{"F0": formRef, "F1": formRef},
+	}
+	return w, res
+}
+
+func TestPage(t *testing.T) {
+	src, res := source(t)
+
+	contentRef := src.Alloc()
+	stm, err := src.OpenStream(contentRef, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := "1 1 1 rg 0 0 1 1 re f\n" +
+		"/CS0 cs 0.25 sc 0 0 1 1 re f\n" +
+		"q 0.5 g Q\n" +
+		"BI /W 1 /H 1 /CS /RGB /BPC 8 ID \x00\xff\x00\nEI\n" +
+		"/F0 Do /F1 Do\n"
+	if _, err := stm.Write([]byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	if err := stm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	w, _ := memfile.NewPDFWriter(pdf.V1_7, nil)
+	rm := pdf.NewResourceManager(w)
+	rw := New(pdf.NewExtractor(src), rm, pdf.NewCopier(w, src), grayMapper{})
+	ref, newRes, err := rw.Page(contentRef, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	ops := readOps(t, w, ref)
+	want := []string{
+		"1 g", "re", "f",
+		"0 g", "0.75 g", "re", "f",
+		"q", "0.5 g", "Q",
+		"%image%",
+		"Do", "Do",
+	}
+	if !slices.Equal(ops, want) {
+		t.Errorf("got operators %q, want %q", ops, want)
+	}
+
+	resDict, _ := newRes.(pdf.Dict)
+	xobj, _ := resDict["XObject"].(pdf.Dict)
+	if xobj["F0"] == nil || xobj["F0"] != xobj["F1"] {
+		t.Fatalf("form XObject not converted exactly once: %v", xobj)
+	}
+
+	// the image inside the form is converted to gray
+	cur := pdf.NewCursor(w)
+	form, err := cur.Stream(xobj["F0"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	formRes, _ := cur.Dict(form.Dict["Resources"])
+	formXObj, _ := cur.Dict(formRes["XObject"])
+	img, err := image.ExtractDict(cur, formXObj["Im"], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.ColorSpace.Family() != color.FamilyDeviceGray {
+		t.Errorf("image colour space is %s, want DeviceGray", img.ColorSpace.Family())
+	}
+	pix, err := img.Data.Pixels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []byte{85, 85}; !bytes.Equal(pix, want) {
+		t.Errorf("image samples are %v, want %v", pix, want)
+	}
+}
+
+func TestShading(t *testing.T) {
+	w, _ := memfile.NewPDFWriter(pdf.V1_7, nil)
+	rm := pdf.NewResourceManager(w)
+	rw := New(pdf.NewExtractor(w), rm, pdf.NewCopier(w, w), grayMapper{})
+
+	sh := &shading.Type2{
+		Common: shading.Common{ColorSpace: color.SpaceDeviceRGB},
+		P1:     vec.Vec2{X: 100},
+		F: &function.Type2{
+			XMin: 0,
+			XMax: 1,
+			C0:   []float64{0, 0, 0},
+			C1:   []float64{1, 1, 0},
+			N:    1,
+		},
+		TMax: 1,
+	}
+	conv, ok := rw.convertShading(sh).(*shading.Type2)
+	if !ok {
+		t.Fatal("wrong shading type")
+	}
+	if conv.ColorSpace != color.SpaceDeviceGray {
+		t.Errorf("shading colour space is %v, want DeviceGray", conv.ColorSpace)
+	}
+	out := make([]float64, 1)
+	for _, x := range []float64{0, 0.5, 1} {
+		conv.F.Apply(out, x)
+		if want := 2 * x / 3; math.Abs(out[0]-want) > 1e-3 {
+			t.Errorf("F(%g) = %g, want %g", x, out[0], want)
+		}
+	}
+	if _, err := rm.Embed(conv); err != nil {
+		t.Error(err)
+	}
+}
+
+// readOps returns the operators of a content stream, with their operands
+// for colour operators.
+func readOps(t *testing.T, w *pdf.Writer, ref pdf.Reference) []string {
+	t.Helper()
+	stm, err := pdf.NewCursor(w).Stream(ref)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := pdf.ReadAll(w, nil, stm, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	open := func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(data)), nil }
+	it := content.NewScanner(open).NewIter()
+	var ops []string
+	for name, args := range it.All() {
+		var buf bytes.Buffer
+		switch name {
+		case content.OpSetFillGray, content.OpSetStrokeGray:
+			_ = content.Operator{Name: name, Args: args}.Format(&buf)
+		default:
+			buf.WriteString(string(name))
+		}
+		ops = append(ops, strings.TrimSpace(buf.String()))
+	}
+	if err := it.Err(); err != nil {
+		t.Fatal(err)
+	}
+	return ops
+}
@@ -0,0 +1,552 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package recolor
+
+import (
+	"bytes"
+	"fmt"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/content"
+)
+
+// streamKeys lists the stream dictionary entries which are rewritten when
+// the content of a stream is replaced.
+var streamKeys = []pdf.Name{"Length", "Filter", "DecodeParms", "F", "FFilter", "FDecodeParms"}
+
+// resources holds a source resource dictionary while the content using it
+// is converted.
+type resources struct {
+	dict pdf.Dict
+
+	// spaces caches the colour spaces selected by name.
+	spaces map[pdf.Name]*srcSpace
+
+	// patternSpace is the name of the colour space for uncoloured patterns
+	// over the target colour space, or the empty name if this is not used.
+	patternSpace pdf.Name
+
+	// inline holds the decoded colour spaces, for decoding inline images.
+	inline *content.Resources
+}
+
+func (r *Rewriter) newResources(obj pdf.Object) (*resources, error) {
+	dict, err := pdf.CursorAt(r.x, nil).Dict(obj)
+	if err := readError(err); err != nil {
+		return nil, err
+	}
+	return &resources{dict: dict, spaces: map[pdf.Name]*srcSpace{}}, nil
+}
+
+// patternSpaceName returns the name under which the colour space for
+// uncoloured patterns over the target space is stored.  The name is chosen
+// on first use, so that it does not clash with existing colour spaces.
+func (res *resources) patternSpaceName() pdf.Name {
+	if res.patternSpace != "" {
+		return res.patternSpace
+	}
+	spaces, _ := res.dict["ColorSpace"].(pdf.Dict)
+	name := pdf.Name("PatternRecolor")
+	for i := 1; ; i++ {
+		if _, clash := spaces[name]; !clash {
+			break
+		}
+		name = pdf.Name(fmt.Sprintf("PatternRecolor%d", i))
+	}
+	res.patternSpace = name
+	return name
+}
+
+// space returns the colour space selected by the given name in a cs or CS
+// operator.  The result is nil if the name cannot be resolved.
+func (r *Rewriter) space(res *resources, name pdf.Name) *srcSpace {
+	switch name {
+	case color.FamilyDeviceGray, color.FamilyDeviceRGB, color.FamilyDeviceCMYK:
+		return &srcSpace{space: r.device(res, name)}
+	case color.FamilyPattern:
+		return &srcSpace{pattern: true}
+	}
+	if s, ok := res.spaces[name]; ok {
+		return s
+	}
+
+	var s *srcSpace
+	cur := pdf.CursorAt(r.x, nil)
+	spaces, _ := cur.Dict(res.dict["ColorSpace"])
+	obj := spaces[name]
+	if a, _ := cur.Array(obj); len(a) > 0 && a[0] == pdf.Name(color.FamilyPattern) {
+		s = &srcSpace{pattern: true}
+		if len(a) > 1 {
+			s.space, _ = pdf.DecodeOptional(cur, a[1], color.ExtractSpace)
+		}
+	} else if space, _ := pdf.DecodeOptional(cur, obj, color.ExtractSpace); space != nil {
+		if color.IsPattern(space) {
+			s = &srcSpace{pattern: true}
+		} else {
+			s = &srcSpace{space: space}
+		}
+	}
+	res.spaces[name] = s
+	return s
+}
+
+// device returns the colour space used for the device colour space of the
+// given family.  This takes the DefaultGray, DefaultRGB and DefaultCMYK
+// entries of the resource dictionary into account.
+func (r *Rewriter) device(res *resources, family pdf.Name) color.Space {
+	var space color.Space
+	switch family {
+	case color.FamilyDeviceGray:
+		space = color.SpaceDeviceGray
+	case color.FamilyDeviceRGB:
+		space = color.SpaceDeviceRGB
+	default:
+		space = color.SpaceDeviceCMYK
+	}
+
+	cur := pdf.CursorAt(r.x, nil)
+	spaces, _ := cur.Dict(res.dict["ColorSpace"])
+	obj, ok := spaces["Default"+family[len("Device"):]]
+	if !ok {
+		return space
+	}
+	def, _ := pdf.DecodeOptional(cur, obj, color.ExtractSpace)
+	if def == nil || color.IsSpecial(def) || def.Channels() != space.Channels() {
+		return space
+	}
+	return def
+}
+
+// inlineResources returns the colour spaces of the resource dictionary, in
+// the form needed for decoding inline images.
+func (r *Rewriter) inlineResources(res *resources) *content.Resources {
+	if res.inline != nil {
+		return res.inline
+	}
+	res.inline = &content.Resources{ColorSpace: map[pdf.Name]color.Space{}}
+	cur := pdf.CursorAt(r.x, nil)
+	spaces, _ := cur.Dict(res.dict["ColorSpace"])
+	for name, obj := range spaces {
+		if space, _ := pdf.DecodeOptional(cur, obj, color.ExtractSpace); space != nil {
+			res.inline.ColorSpace[name] = space
+		}
+	}
+	return res.inline
+}
+
+// resources writes the converted resource dictionary.  The content using the
+// resources must have been converted first, since this may add a colour
+// space to the dictionary.
+func (r *Rewriter) resources(res *resources, depth int) (pdf.Object, error) {
+	if res.dict == nil && res.patternSpace == "" {
+		return nil, nil
+	}
+
+	// sorted: converting a value allocates an object number
+	out := pdf.Dict{}
+	for _, key := range res.dict.SortedKeys() {
+		val := res.dict[key]
+		var conv func(pdf.Object, int) (pdf.Object, error)
+		switch key {
+		case "XObject":
+			conv = r.xObject
+		case "Pattern":
+			conv = r.pattern
+		case "Shading":
+			conv = func(obj pdf.Object, _ int) (pdf.Object, error) { return r.shading(obj) }
+		case "Font":
+			conv = r.font
+		case "ColorSpace":
+			if res.patternSpace != "" {
+				continue // written below
+			}
+		}
+
+		var cv pdf.Object
+		var err error
+		if conv != nil {
+			cv, err = r.subdict(val, depth, conv)
+		} else {
+			cv, err = r.copyNative(val)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if cv != nil {
+			out[key] = cv
+		}
+	}
+
+	if res.patternSpace != "" {
+		spaces, err := pdf.CursorAt(r.x, nil).Dict(res.dict["ColorSpace"])
+		if err := readError(err); err != nil {
+			return nil, err
+		}
+		copied, err := r.copy.CopyDict(spaces)
+		if err != nil {
+			return nil, err
+		}
+		copied[res.patternSpace] = pdf.Array{
+			pdf.Name(color.FamilyPattern),
+			r.target.Family(),
+		}
+		out["ColorSpace"] = copied
+	}
+	return out, nil
+}
+
+// subdict converts the entries of a resource subdictionary.
+func (r *Rewriter) subdict(obj pdf.Object, depth int, conv func(pdf.Object, int) (pdf.Object, error)) (pdf.Object, error) {
+	src, err := pdf.CursorAt(r.x, nil).Dict(obj)
+	if err != nil || src == nil {
+		return nil, readError(err)
+	}
+	// sorted: converting a value allocates an object number
+	out := pdf.Dict{}
+	for _, name := range src.SortedKeys() {
+		cv, err := conv(src[name], depth)
+		if err != nil {
+			return nil, err
+		}
+		if cv != nil {
+			out[name] = cv
+		}
+	}
+	return out, nil
+}
+
+// xObject converts an XObject.  Form XObjects are rewritten and images are
+// converted; other XObjects are copied.
+func (r *Rewriter) xObject(obj pdf.Object, depth int) (pdf.Object, error) {
+	ref, isRef := obj.(pdf.Reference)
+	if v, ok := r.done[ref]; isRef && ok {
+		return v, nil
+	}
+
+	stm, err := pdf.CursorAt(r.x, nil).Stream(obj)
+	if err != nil || stm == nil {
+		if err := readError(err); err != nil {
+			return nil, err
+		}
+		return r.copyNative(obj)
+	}
+
+	switch stm.Dict["Subtype"] {
+	case pdf.Name("Form"):
+		if depth >= maxDepth {
+			return r.copyNative(obj)
+		}
+		outRef := r.out.Alloc()
+		if isRef {
+			r.done[ref] = outRef // publish before recursing, breaking cycles
+		}
+		if err := r.form(outRef, stm, depth); err != nil {
+			return nil, err
+		}
+		return outRef, nil
+
+	case pdf.Name("Image"):
+		v, err := r.image(obj, stm)
+		if err != nil {
+			return nil, err
+		}
+		if isRef {
+			r.done[ref] = v
+		}
+		return v, nil
+	}
+	return r.copyNative(obj)
+}
+
+// form writes a converted copy of a form XObject to outRef.
+func (r *Rewriter) form(outRef pdf.Reference, stm *pdf.Stream, depth int) error {
+	res, err := r.newResources(stm.Dict["Resources"])
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := r.content(&buf, stm, res, &colorState{}); err != nil {
+		return err
+	}
+	newRes, err := r.resources(res, depth+1)
+	if err != nil {
+		return err
+	}
+
+	dict, err := r.copyStreamDict(stm.Dict, "Resources", "Group")
+	if err != nil {
+		return err
+	}
+	if newRes != nil {
+		dict["Resources"] = newRes
+	}
+	if group, err := r.group(stm.Dict["Group"]); err != nil {
+		return err
+	} else if group != nil {
+		dict["Group"] = group
+	}
+	return r.writeStream(outRef, dict, buf.Bytes())
+}
+
+// group copies a group attributes dictionary.  The blending colour space of
+// a transparency group is replaced by the target colour space, unless the
+// Mapper keeps it.
+func (r *Rewriter) group(obj pdf.Object) (pdf.Object, error) {
+	cur := pdf.CursorAt(r.x, nil)
+	dict, err := cur.Dict(obj)
+	if err != nil || dict == nil {
+		if err := readError(err); err != nil {
+			return nil, err
+		}
+		return r.copyNative(obj)
+	}
+	out, err := r.copy.CopyDict(dict)
+	if err != nil {
+		return nil, err
+	}
+	if cs, ok := dict["CS"]; ok {
+		space, _ := pdf.DecodeOptional(cur, cs, color.ExtractSpace)
+		if space == nil || !r.keeps(space) {
+			out["CS"] = r.target.Family()
+		}
+	}
+	return out, nil
+}
+
+// pattern converts a pattern.  Coloured tiling patterns are rewritten, and
+// the shadings of shading patterns are converted.  Uncoloured tiling
+// patterns have no colour of their own and are copied.
+func (r *Rewriter) pattern(obj pdf.Object, depth int) (pdf.Object, error) {
+	ref, isRef := obj.(pdf.Reference)
+	if v, ok := r.done[ref]; isRef && ok {
+		return v, nil
+	}
+
+	cur := pdf.CursorAt(r.x, nil)
+	resolved, err := cur.Resolve(obj)
+	if err != nil {
+		if err := readError(err); err != nil {
+			return nil, err
+		}
+		return r.copyNative(obj)
+	}
+
+	switch pat := resolved.(type) {
+	case *pdf.Stream: // tiling pattern
+		paintType, _ := cur.Integer(pat.Dict["PaintType"])
+		if paintType != 1 || depth >= maxDepth {
+			return r.copyNative(obj)
+		}
+		outRef := r.out.Alloc()
+		if isRef {
+			r.done[ref] = outRef // publish before recursing, breaking cycles
+		}
+
+		res, err := r.newResources(pat.Dict["Resources"])
+		if err != nil {
+			return nil, err
+		}
+		var buf bytes.Buffer
+		if err := r.content(&buf, pat, res, &colorState{}); err != nil {
+			return nil, err
+		}
+		newRes, err := r.resources(res, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		dict, err := r.copyStreamDict(pat.Dict, "Resources")
+		if err != nil {
+			return nil, err
+		}
+		if newRes != nil {
+			dict["Resources"] = newRes
+		}
+		if err := r.writeStream(outRef, dict, buf.Bytes()); err != nil {
+			return nil, err
+		}
+		return outRef, nil
+
+	case pdf.Dict: // shading pattern
+		sh, err := r.shading(pat["Shading"])
+		if err != nil {
+			return nil, err
+		}
+		rest := pdf.Dict{}
+		for key, val := range pat {
+			if key != "Shading" {
+				rest[key] = val
+			}
+		}
+		dict, err := r.copy.CopyDict(rest)
+		if err != nil {
+			return nil, err
+		}
+		if sh != nil {
+			dict["Shading"] = sh
+		}
+		var res pdf.Object = dict
+		if isRef {
+			outRef := r.out.Alloc()
+			if err := r.out.Put(outRef, dict); err != nil {
+				return nil, err
+			}
+			res = outRef
+			r.done[ref] = outRef
+		}
+		return res, nil
+	}
+	return r.copyNative(obj)
+}
+
+// font converts a font.  The glyph descriptions of Type 3 fonts are
+// rewritten; all other fonts are copied.
+func (r *Rewriter) font(obj pdf.Object, depth int) (pdf.Object, error) {
+	ref, isRef := obj.(pdf.Reference)
+	if v, ok := r.done[ref]; isRef && ok {
+		return v, nil
+	}
+
+	cur := pdf.CursorAt(r.x, nil)
+	dict, err := cur.Dict(obj)
+	if err != nil || dict == nil {
+		if err := readError(err); err != nil {
+			return nil, err
+		}
+		return r.copyNative(obj)
+	}
+	if dict["Subtype"] != pdf.Name("Type3") || depth >= maxDepth {
+		return r.copyNative(obj)
+	}
+
+	var outRef pdf.Reference
+	if isRef {
+		outRef = r.out.Alloc()
+		r.done[ref] = outRef // publish before recursing, breaking cycles
+	}
+
+	res, err := r.newResources(dict["Resources"])
+	if err != nil {
+		return nil, err
+	}
+	procs, err := cur.Dict(dict["CharProcs"])
+	if err := readError(err); err != nil {
+		return nil, err
+	}
+	newProcs := pdf.Dict{}
+	for _, name := range procs.SortedKeys() {
+		stm, err := cur.Stream(procs[name])
+		if err != nil || stm == nil {
+			if err := readError(err); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		var buf bytes.Buffer
+		if err := r.content(&buf, stm, res, &colorState{}); err != nil {
+			return nil, err
+		}
+		procDict, err := r.copyStreamDict(stm.Dict)
+		if err != nil {
+			return nil, err
+		}
+		procRef := r.out.Alloc()
+		if err := r.writeStream(procRef, procDict, buf.Bytes()); err != nil {
+			return nil, err
+		}
+		newProcs[name] = procRef
+	}
+	newRes, err := r.resources(res, depth+1)
+	if err != nil {
+		return nil, err
+	}
+
+	rest := pdf.Dict{}
+	for key, val := range dict {
+		if key != "CharProcs" && key != "Resources" {
+			rest[key] = val
+		}
+	}
+	out, err := r.copy.CopyDict(rest)
+	if err != nil {
+		return nil, err
+	}
+	out["CharProcs"] = newProcs
+	if newRes != nil {
+		out["Resources"] = newRes
+	}
+
+	if !isRef {
+		return out, nil
+	}
+	if err := r.out.Put(outRef, out); err != nil {
+		return nil, err
+	}
+	return outRef, nil
+}
+
+// copyStreamDict copies the dictionary of a stream whose content is
+// replaced, omitting the entries which describe the encoding of the content
+// and the given extra keys.
+func (r *Rewriter) copyStreamDict(src pdf.Dict, omit ...pdf.Name) (pdf.Dict, error) {
+	rest := pdf.Dict{}
+outer:
+	for key, val := range src {
+		for _, k := range streamKeys {
+			if key == k {
+				continue outer
+			}
+		}
+		for _, k := range omit {
+			if key == k {
+				continue outer
+			}
+		}
+		rest[key] = val
+	}
+	return r.copy.CopyDict(rest)
+}
+
+// writeStream writes a compressed stream.
+func (r *Rewriter) writeStream(ref pdf.Reference, dict pdf.Dict, data []byte) error {
+	stm, err := r.out.OpenStream(ref, dict, pdf.FilterCompress{})
+	if err != nil {
+		return err
+	}
+	if _, err := stm.Write(data); err != nil {
+		return err
+	}
+	return stm.Close()
+}
+
+// copyNative copies a value through the copier, skipping non-Native values.
+func (r *Rewriter) copyNative(val pdf.Object) (pdf.Object, error) {
+	nv, ok := val.(pdf.Native)
+	if !ok {
+		return nil, nil
+	}
+	return r.copy.Copy(nv)
+}
+
+// readError returns err if it is a read error, and nil for malformed
+// objects, which are skipped.
+func readError(err error) error {
+	if pdf.IsReadError(err) {
+		return err
+	}
+	return nil
+}
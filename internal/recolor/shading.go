@@ -0,0 +1,220 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package recolor
+
+import (
+	"slices"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/function"
+	"seehuhn.de/go/pdf/graphics"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/shading"
+)
+
+// Number of samples per input dimension used when a shading function is
+// replaced by a sampled function.
+const (
+	samples1D = 256
+	samples2D = 33
+)
+
+// shading converts a shading.  Shadings which cannot be decoded are copied
+// unchanged.
+func (r *Rewriter) shading(obj pdf.Object) (pdf.Object, error) {
+	ref, isRef := obj.(pdf.Reference)
+	if v, ok := r.done[ref]; isRef && ok {
+		return v, nil
+	}
+
+	sh, err := pdf.DecodeOptional(pdf.CursorAt(r.x, nil), obj, shading.Extract)
+	if err := readError(err); err != nil {
+		return nil, err
+	}
+	var res pdf.Object
+	if sh == nil || r.keeps(sh.GetShadingCommon().ColorSpace) {
+		res, err = r.copyNative(obj)
+	} else {
+		res, err = r.rm.Embed(r.convertShading(sh))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if isRef {
+		r.done[ref] = res
+	}
+	return res, nil
+}
+
+// convertShading returns a copy of sh which uses the target colour space.
+// Shading functions are replaced by sampled functions of the converted
+// colours.
+func (r *Rewriter) convertShading(sh graphics.Shading) graphics.Shading {
+	convertCommon := func(c graphics.ShadingCommon) graphics.ShadingCommon {
+		space := c.ColorSpace
+		c.ColorSpace = r.target
+		if c.Background != nil {
+			c.Background = r.convert(space, c.Background)
+		}
+		return c
+	}
+
+	switch s := sh.(type) {
+	case *shading.Type1:
+		res := *s
+		res.Common = convertCommon(s.Common)
+		res.F = r.sampleFunction(s.Common.ColorSpace, s.F, s.Domain)
+		return &res
+
+	case *shading.Type2:
+		res := *s
+		res.Common = convertCommon(s.Common)
+		res.F = r.sampleFunction(s.Common.ColorSpace, s.F, []float64{s.TMin, s.TMax})
+		return &res
+
+	case *shading.Type3:
+		res := *s
+		res.Common = convertCommon(s.Common)
+		res.F = r.sampleFunction(s.Common.ColorSpace, s.F, []float64{s.TMin, s.TMax})
+		return &res
+
+	case *shading.Type4:
+		res := *s
+		res.Common = convertCommon(s.Common)
+		if s.F != nil {
+			res.F = r.sampleFunction(s.Common.ColorSpace, s.F, s.Decode[4:6])
+		} else {
+			res.Decode = r.meshDecode(s.Decode)
+			res.Vertices = slices.Clone(s.Vertices)
+			for i := range res.Vertices {
+				res.Vertices[i].Color = r.convert(s.Common.ColorSpace, res.Vertices[i].Color)
+			}
+		}
+		return &res
+
+	case *shading.Type5:
+		res := *s
+		res.Common = convertCommon(s.Common)
+		if s.F != nil {
+			res.F = r.sampleFunction(s.Common.ColorSpace, s.F, s.Decode[4:6])
+		} else {
+			res.Decode = r.meshDecode(s.Decode)
+			res.Vertices = slices.Clone(s.Vertices)
+			for i := range res.Vertices {
+				res.Vertices[i].Color = r.convert(s.Common.ColorSpace, res.Vertices[i].Color)
+			}
+		}
+		return &res
+
+	case *shading.Type6:
+		res := *s
+		res.Common = convertCommon(s.Common)
+		if s.F != nil {
+			res.F = r.sampleFunction(s.Common.ColorSpace, s.F, s.Decode[4:6])
+		} else {
+			res.Decode = r.meshDecode(s.Decode)
+			res.Patches = slices.Clone(s.Patches)
+			for i := range res.Patches {
+				res.Patches[i].CornerColors = r.convertCorners(s.Common.ColorSpace, s.Patches[i].CornerColors)
+			}
+		}
+		return &res
+
+	case *shading.Type7:
+		res := *s
+		res.Common = convertCommon(s.Common)
+		if s.F != nil {
+			res.F = r.sampleFunction(s.Common.ColorSpace, s.F, s.Decode[4:6])
+		} else {
+			res.Decode = r.meshDecode(s.Decode)
+			res.Patches = slices.Clone(s.Patches)
+			for i := range res.Patches {
+				res.Patches[i].CornerColors = r.convertCorners(s.Common.ColorSpace, s.Patches[i].CornerColors)
+			}
+		}
+		return &res
+	}
+	return sh
+}
+
+func (r *Rewriter) convertCorners(space color.Space, corners [][]float64) [][]float64 {
+	res := make([][]float64, len(corners))
+	for i, c := range corners {
+		res[i] = r.convert(space, c)
+	}
+	return res
+}
+
+// meshDecode returns the Decode array of a mesh shading whose colour
+// components have been converted to the target space.  The coordinate
+// ranges are kept.
+func (r *Rewriter) meshDecode(decode []float64) []float64 {
+	res := slices.Clone(decode[:4])
+	for range r.target.Channels() {
+		res = append(res, 0, 1)
+	}
+	return res
+}
+
+// sampleFunction returns a sampled function which maps the given domain to
+// the converted colours of fn.  The domain has one or two input ranges.
+func (r *Rewriter) sampleFunction(space color.Space, fn pdf.Function, domain []float64) pdf.Function {
+	nIn := len(domain) / 2
+	size := samples1D
+	if nIn > 1 {
+		size = samples2D
+	}
+	_, nOut := fn.Shape()
+	nTarget := r.target.Channels()
+
+	f := &function.Type0{
+		Domain:        slices.Clone(domain),
+		BitsPerSample: 16,
+	}
+	for range nIn {
+		f.Size = append(f.Size, size)
+		f.Encode = append(f.Encode, 0, float64(size-1))
+	}
+	for range nTarget {
+		f.Range = append(f.Range, 0, 1)
+		f.Decode = append(f.Decode, 0, 1)
+	}
+
+	in := make([]float64, nIn)
+	out := make([]float64, nOut)
+	total := 1
+	for range nIn {
+		total *= size
+	}
+	f.Samples = make([]byte, 0, 2*total*nTarget)
+	for k := range total {
+		// the first input varies fastest
+		idx := k
+		for i := range nIn {
+			j := idx % size
+			idx /= size
+			lo, hi := domain[2*i], domain[2*i+1]
+			in[i] = lo + (hi-lo)*float64(j)/float64(size-1)
+		}
+		fn.Apply(out, in...)
+		for _, v := range r.convert(space, out) {
+			s := uint16(v*65535 + 0.5)
+			f.Samples = append(f.Samples, byte(s>>8), byte(s))
+		}
+	}
+	return f
+}
@@ -0,0 +1,175 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package rewrite implements the steps shared by the tools which copy a
+// document page by page, replacing some of the page content along the way.
+//
+// A typical pass reads the page tree using [ReadPages], creates the output
+// using [NewWriter], allocates the new page references using
+// [RedirectPages], writes the modified pages, and finally carries over the
+// document-level data using [CopyCatalog].
+package rewrite
+
+import (
+	"errors"
+	"io"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// NewWriter creates the output file for a rewritten copy of the document r.
+// The document-level XMP metadata of r is carried over to the new file,
+// if the PDF version v supports metadata streams.
+func NewWriter(w io.Writer, r pdf.Getter, v pdf.Version) (*pdf.Writer, error) {
+	var opt *pdf.WriterOptions
+	if md := r.GetMeta().Catalog.Metadata; md != nil && v >= pdf.V1_4 {
+		opt = &pdf.WriterOptions{DocumentMetadata: md}
+	}
+	return pdf.NewWriter(w, v, opt)
+}
+
+// ReadPages returns the references and the dictionaries of all pages in r,
+// in document order.  An error is returned if the document has no pages.
+func ReadPages(r pdf.Getter) ([]pdf.Reference, []pdf.Dict, error) {
+	var refs []pdf.Reference
+	var dicts []pdf.Dict
+	it := pagetree.NewIterator(r)
+	for ref, dict := range it.All() {
+		refs = append(refs, ref)
+		dicts = append(dicts, dict)
+	}
+	if it.Err != nil {
+		return nil, nil, it.Err
+	}
+	if len(dicts) == 0 {
+		return nil, nil, errors.New("document has no pages")
+	}
+	return refs, dicts, nil
+}
+
+// RedirectPages allocates a new reference in out for each of the given
+// pages, and registers the new references with copy.
+//
+// Pages are referenced from elsewhere in the document, for example from
+// outlines and annotations.  Allocating the new references before anything
+// is copied makes sure that copying these references picks up the new
+// pages.
+func RedirectPages(out *pdf.Writer, copy *pdf.Copier, refs []pdf.Reference) []pdf.Reference {
+	newRefs := make([]pdf.Reference, len(refs))
+	for i, ref := range refs {
+		newRefs[i] = out.Alloc()
+		copy.Redirect(ref, newRefs[i])
+	}
+	return newRefs
+}
+
+// CopyCatalog copies the document-level entries of src into the catalog of
+// out.  The pages must be written one-to-one, and redirected using
+// [RedirectPages], so that references to the pages remain valid.
+//
+// The Pages and Version entries are not copied, and the XMP metadata is set
+// up by [NewWriter].  Entries listed in skip are left for the caller to
+// fill in.  Entries which are not allowed in the PDF version of out are
+// dropped.
+func CopyCatalog(out *pdf.Writer, copy *pdf.Copier, src *pdf.Catalog, skip ...pdf.Name) error {
+	dst := out.GetMeta().Catalog
+	v := pdf.GetVersion(out)
+
+	omit := make(map[pdf.Name]bool, len(skip))
+	for _, key := range skip {
+		omit[key] = true
+	}
+	use := func(key pdf.Name, minVersion pdf.Version) bool {
+		return !omit[key] && v >= minVersion
+	}
+
+	if use("PageLayout", pdf.V1_0) {
+		dst.PageLayout = src.PageLayout
+	}
+	if use("PageMode", pdf.V1_0) {
+		dst.PageMode = src.PageMode
+	}
+	if use("Lang", pdf.V1_4) {
+		dst.Lang = src.Lang
+	}
+	if use("NeedsRendering", pdf.V1_5) {
+		dst.NeedsRendering = src.NeedsRendering
+	}
+
+	for _, entry := range []struct {
+		key        pdf.Name
+		minVersion pdf.Version
+		dst        *pdf.Object
+		src        pdf.Object
+	}{
+		{"Extensions", pdf.V1_7, &dst.Extensions, src.Extensions},
+		{"PageLabels", pdf.V1_3, &dst.PageLabels, src.PageLabels},
+		{"Names", pdf.V1_2, &dst.Names, src.Names},
+		{"Dests", pdf.V1_1, &dst.Dests, src.Dests},
+		{"ViewerPreferences", pdf.V1_2, &dst.ViewerPreferences, src.ViewerPreferences},
+		{"OpenAction", pdf.V1_1, &dst.OpenAction, src.OpenAction},
+		{"AA", pdf.V1_2, &dst.AA, src.AA},
+		{"URI", pdf.V1_1, &dst.URI, src.URI},
+		{"AcroForm", pdf.V1_2, &dst.AcroForm, src.AcroForm},
+		{"StructTreeRoot", pdf.V1_3, &dst.StructTreeRoot, src.StructTreeRoot},
+		{"MarkInfo", pdf.V1_4, &dst.MarkInfo, src.MarkInfo},
+		{"SpiderInfo", pdf.V1_3, &dst.SpiderInfo, src.SpiderInfo},
+		{"OutputIntents", pdf.V1_4, &dst.OutputIntents, src.OutputIntents},
+		{"PieceInfo", pdf.V1_4, &dst.PieceInfo, src.PieceInfo},
+		{"OCProperties", pdf.V1_5, &dst.OCProperties, src.OCProperties},
+		{"Perms", pdf.V1_5, &dst.Perms, src.Perms},
+		{"Legal", pdf.V1_5, &dst.Legal, src.Legal},
+		{"Requirements", pdf.V1_7, &dst.Requirements, src.Requirements},
+		{"Collection", pdf.V1_7, &dst.Collection, src.Collection},
+		{"DSS", pdf.V1_7, &dst.DSS, src.DSS},
+		{"AF", pdf.V1_7, &dst.AF, src.AF},
+		{"DPartRoot", pdf.V2_0, &dst.DPartRoot, src.DPartRoot},
+	} {
+		if !use(entry.key, entry.minVersion) {
+			continue
+		}
+		nv, ok := entry.src.(pdf.Native)
+		if !ok || nv == nil {
+			continue
+		}
+		copied, err := copy.Copy(nv)
+		if err != nil {
+			return err
+		}
+		*entry.dst = copied
+	}
+
+	for _, entry := range []struct {
+		key        pdf.Name
+		minVersion pdf.Version
+		dst        *pdf.Reference
+		src        pdf.Reference
+	}{
+		{"Outlines", pdf.V1_0, &dst.Outlines, src.Outlines},
+		{"Threads", pdf.V1_1, &dst.Threads, src.Threads},
+	} {
+		if !use(entry.key, entry.minVersion) || entry.src == 0 {
+			continue
+		}
+		copied, err := copy.CopyReference(entry.src)
+		if err != nil {
+			return err
+		}
+		*entry.dst = copied
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rewrite
+
+import (
+	"io"
+	"testing"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/internal/rewrite/rewritetest"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// copyPages copies all pages of r unchanged, using the helpers of this
+// package.
+func copyPages(w io.Writer, r pdf.Getter, v pdf.Version, skip ...pdf.Name) error {
+	refs, dicts, err := ReadPages(r)
+	if err != nil {
+		return err
+	}
+	out, err := NewWriter(w, r, v)
+	if err != nil {
+		return err
+	}
+	rm := pdf.NewResourceManager(out)
+	copy := pdf.NewCopier(out, r)
+
+	newRefs := RedirectPages(out, copy, refs)
+	tree := pagetree.NewWriter(out, rm)
+	for i, dict := range dicts {
+		newDict, err := copy.CopyDict(dict)
+		if err != nil {
+			return err
+		}
+		if err := tree.AppendPageDict(newRefs[i], newDict); err != nil {
+			return err
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		return err
+	}
+
+	meta := out.GetMeta()
+	if err := CopyCatalog(out, copy, r.GetMeta().Catalog, skip...); err != nil {
+		return err
+	}
+	meta.Catalog.Pages = pagesRef
+
+	if err := rm.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func TestCopyCatalog(t *testing.T) {
+	r := rewritetest.Source(t)
+	res := rewritetest.Rewrite(t, r, func(w io.Writer, r pdf.Getter) error {
+		return copyPages(w, r, pdf.GetVersion(r))
+	})
+	rewritetest.CheckCatalog(t, res)
+}
+
+func TestCopyCatalogSkip(t *testing.T) {
+	r := rewritetest.Source(t)
+	res := rewritetest.Rewrite(t, r, func(w io.Writer, r pdf.Getter) error {
+		return copyPages(w, r, pdf.GetVersion(r), "AF", "Lang")
+	})
+
+	cat := res.GetMeta().Catalog
+	if cat.AF != nil {
+		t.Errorf("AF: got %v, want nil", cat.AF)
+	}
+	if cat.Lang.String() != "und" {
+		t.Errorf("Lang: got %v, want und", cat.Lang)
+	}
+	if cat.AA == nil {
+		t.Error("AA missing")
+	}
+}
+
+// TestCopyCatalogVersion checks that entries which need a newer PDF version
+// than the output file are dropped.
+func TestCopyCatalogVersion(t *testing.T) {
+	r := rewritetest.Source(t)
+	res := rewritetest.Rewrite(t, r, func(w io.Writer, r pdf.Getter) error {
+		return copyPages(w, r, pdf.V1_4)
+	})
+
+	cat := res.GetMeta().Catalog
+	if cat.Extensions != nil || cat.AF != nil {
+		t.Errorf("PDF 1.7 entries kept: Extensions=%v, AF=%v", cat.Extensions, cat.AF)
+	}
+	if cat.Metadata == nil {
+		t.Error("XMP metadata missing")
+	}
+	if cat.Outlines == 0 || cat.OpenAction == nil {
+		t.Error("outline or open action missing")
+	}
+}
@@ -0,0 +1,260 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package rewritetest provides a test document for the passes which rewrite
+// a document page by page.
+package rewritetest
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"golang.org/x/text/language"
+
+	"seehuhn.de/go/xmp"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/document"
+	"seehuhn.de/go/pdf/font/standard"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+	"seehuhn.de/go/pdf/page"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// Title is the document title stored in the XMP metadata of the test
+// document.
+const Title = "Rewrite Test"
+
+// BodyText is the text shown on every page of the test document.
+const BodyText = "body text"
+
+// Source writes a PDF 1.7 document with three pages: an unrotated page, a
+// landscape page rotated by 90 degrees, and a page with a crop box.  Each
+// page shows [BodyText] at (100, 200) in default user space, and leaves the
+// graphics state modified at the end of the content stream.
+//
+// The document catalog contains XMP metadata and a selection of other
+// document-level entries, which can be checked using [CheckCatalog].
+func Source(t *testing.T) *pdf.Reader {
+	t.Helper()
+
+	F, err := standard.Helvetica.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	packet := xmp.NewPacket()
+	dc := &xmp.DublinCore{}
+	dc.Title.Set(language.Und, Title)
+	if err := packet.Set(dc); err != nil {
+		t.Fatal(err)
+	}
+	opt := &pdf.WriterOptions{
+		DocumentMetadata: &pdf.MetadataStream{Data: packet},
+	}
+
+	buf := memfile.New()
+	doc, err := document.WriteMultiPage(buf, &pdf.Rectangle{URx: 400, URy: 600}, pdf.V1_7, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := doc.Out
+
+	var pageRefs []pdf.Reference
+	for i := range 3 {
+		p := doc.AddPage()
+		p.Ref = out.Alloc()
+		pageRefs = append(pageRefs, p.Ref)
+		switch i {
+		case 1:
+			p.Page.MediaBox = &pdf.Rectangle{URx: 600, URy: 400}
+			p.Page.Rotate = page.Rotate90
+		case 2:
+			p.Page.CropBox = &pdf.Rectangle{LLx: 50, LLy: 100, URx: 350, URy: 500}
+		}
+		p.Transform([6]float64{2, 0, 0, 2, 0, 0})
+		p.TextBegin()
+		p.TextSetFont(F, 6)
+		p.TextFirstLine(50, 100)
+		p.TextShow(BodyText)
+		p.TextEnd()
+		if err := p.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	outlinesRef := out.Alloc()
+	itemRef := out.Alloc()
+	err = out.Put(outlinesRef, pdf.Dict{
+		"Type":  pdf.Name("Outlines"),
+		"First": itemRef,
+		"Last":  itemRef,
+		"Count": pdf.Integer(1),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = out.Put(itemRef, pdf.Dict{
+		"Title":  pdf.TextString("Second Page"),
+		"Parent": outlinesRef,
+		"Dest":   pdf.Array{pageRefs[1], pdf.Name("Fit")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cat := out.GetMeta().Catalog
+	cat.Lang = language.German
+	cat.PageLayout = "TwoColumnLeft"
+	cat.PageMode = "UseOutlines"
+	cat.Outlines = outlinesRef
+	cat.OpenAction = pdf.Array{pageRefs[0], pdf.Name("Fit")}
+	cat.PageLabels = pdf.Dict{
+		"Nums": pdf.Array{pdf.Integer(0), pdf.Dict{"S": pdf.Name("r")}},
+	}
+	cat.AA = pdf.Dict{
+		"WC": pdf.Dict{"S": pdf.Name("JavaScript"), "JS": pdf.String("1;")},
+	}
+	cat.Extensions = pdf.Dict{
+		"ADBE": pdf.Dict{
+			"BaseVersion":    pdf.Name("1.7"),
+			"ExtensionLevel": pdf.Integer(3),
+		},
+	}
+	cat.AF = pdf.Array{
+		pdf.Dict{
+			"Type":           pdf.Name("Filespec"),
+			"F":              pdf.String("notes.txt"),
+			"UF":             pdf.TextString("notes.txt"),
+			"AFRelationship": pdf.Name("Supplement"),
+		},
+	}
+
+	if err := doc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(buf, int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// Rewrite runs a rewriting pass on r and reads back the result.
+func Rewrite(t *testing.T, r pdf.Getter, write func(io.Writer, pdf.Getter) error) *pdf.Reader {
+	t.Helper()
+
+	out := &bytes.Buffer{}
+	if err := write(out, r); err != nil {
+		t.Fatal(err)
+	}
+	data := out.Bytes()
+	res, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return res
+}
+
+// CheckCatalog verifies that the document-level data of the document
+// written by [Source] is present in res, and that references to the pages
+// point to the corresponding pages of res.
+func CheckCatalog(t *testing.T, res pdf.Getter) {
+	t.Helper()
+
+	var pageRefs []pdf.Reference
+	it := pagetree.NewIterator(res)
+	for ref := range it.All() {
+		pageRefs = append(pageRefs, ref)
+	}
+	if it.Err != nil {
+		t.Fatal(it.Err)
+	}
+	if len(pageRefs) != 3 {
+		t.Fatalf("got %d pages, want 3", len(pageRefs))
+	}
+
+	cat := res.GetMeta().Catalog
+	if cat.Metadata == nil {
+		t.Error("XMP metadata missing")
+	} else {
+		dc := &xmp.DublinCore{}
+		if err := cat.Metadata.Data.Get(dc); err != nil {
+			t.Error(err)
+		} else if got := dc.Title.Default.V; got != Title {
+			t.Errorf("metadata title: got %q, want %q", got, Title)
+		}
+	}
+
+	if cat.Lang != language.German {
+		t.Errorf("Lang: got %v, want %v", cat.Lang, language.German)
+	}
+	if cat.PageLayout != "TwoColumnLeft" {
+		t.Errorf("PageLayout: got %q", cat.PageLayout)
+	}
+	if cat.PageMode != "UseOutlines" {
+		t.Errorf("PageMode: got %q", cat.PageMode)
+	}
+
+	c := pdf.NewCursor(res)
+	for _, entry := range []struct {
+		key pdf.Name
+		obj pdf.Object
+		sub pdf.Name
+	}{
+		{"PageLabels", cat.PageLabels, "Nums"},
+		{"AA", cat.AA, "WC"},
+		{"Extensions", cat.Extensions, "ADBE"},
+	} {
+		dict, err := c.Dict(entry.obj)
+		if err != nil {
+			t.Errorf("%s: %v", entry.key, err)
+		} else if dict[entry.sub] == nil {
+			t.Errorf("%s: missing %s entry", entry.key, entry.sub)
+		}
+	}
+
+	af, err := c.Array(cat.AF)
+	if err != nil {
+		t.Errorf("AF: %v", err)
+	} else if len(af) != 1 {
+		t.Errorf("AF: got %d entries, want 1", len(af))
+	}
+
+	openAction, err := c.Array(cat.OpenAction)
+	if err != nil || len(openAction) == 0 {
+		t.Errorf("OpenAction: got %v, %v", openAction, err)
+	} else if openAction[0] != pageRefs[0] {
+		t.Errorf("OpenAction: got page %v, want %v", openAction[0], pageRefs[0])
+	}
+
+	outlines, err := c.Dict(cat.Outlines)
+	if err != nil || outlines == nil {
+		t.Fatalf("Outlines: got %v, %v", outlines, err)
+	}
+	item, err := c.Dict(outlines["First"])
+	if err != nil || item == nil {
+		t.Fatalf("outline item: got %v, %v", item, err)
+	}
+	dest, err := c.Array(item["Dest"])
+	if err != nil || len(dest) == 0 {
+		t.Errorf("outline destination: got %v, %v", dest, err)
+	} else if dest[0] != pageRefs[1] {
+		t.Errorf("outline destination: got page %v, want %v", dest[0], pageRefs[1])
+	}
+}
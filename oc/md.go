@@ -72,16 +72,17 @@ func ExtractMembership(c pdf.Cursor, obj pdf.Object, isDirect bool) (*Membership
 	m := &Membership{}
 
 	// /OCGs is one of: an array of OCG references/dicts, a single OCG
-	// reference, or a single inline OCG dict.  Detect the array case via
-	// Cursor.Array (which resolves but returns nil for non-array values), and
-	// fall through to the single-OCG case otherwise — passing the original
-	// dict["OCGs"] preserves the reference so [pdf.Decode] can hit
-	// the cache and return the same *Group as other extraction paths.
+	// reference, or a single inline OCG dict.  Detect the array case on the
+	// resolved value, and fall through to the single-OCG case otherwise —
+	// passing the original dict["OCGs"] preserves the reference so
+	// [pdf.Decode] can hit the cache and return the same *Group as other
+	// extraction paths.
 	ocgsRaw := dict["OCGs"]
-	arr, err := pdf.Optional(c.Array(ocgsRaw))
+	ocgs, err := pdf.Optional(c.Resolve(ocgsRaw))
 	if err != nil {
 		return nil, err
 	}
+	arr, _ := ocgs.(pdf.Array)
 	if arr != nil {
 		for _, item := range arr {
 			if group, err := pdf.DecodeOptional(c, item, ExtractGroup); err != nil {
@@ -41,13 +41,13 @@ var (
 // optional content group (as a reference or inline dictionary).
 func ExtractVisibilityExpression(c pdf.Cursor, obj pdf.Object, isDirect bool) (VisibilityExpression, error) {
 	// the value is either an array (operator + operands) or a single OCG
-	// reference/dict.  Detect the array case via Cursor.Array (which resolves
-	// but returns nil for non-array values), and fall through to the
-	// single-OCG case otherwise.
-	arr, err := pdf.Optional(c.Array(obj))
+	// reference/dict.  Detect the array case on the resolved value, and fall
+	// through to the single-OCG case otherwise.
+	resolved, err := pdf.Optional(c.Resolve(obj))
 	if err != nil {
 		return nil, err
 	}
+	arr, _ := resolved.(pdf.Array)
 	if arr != nil {
 		if len(arr) == 0 {
 			return nil, pdf.Error("invalid visibility expression: empty array")
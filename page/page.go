@@ -651,7 +651,7 @@ func Decode(c pdf.Cursor, obj pdf.Object, _ bool) (*Page, error) {
 
 	// MediaBox (required, inheritable)
 	if mediaBox, err := c.Rectangle(dict["MediaBox"]); err != nil {
-		return nil, err
+		return nil, pdf.Wrap(err, "MediaBox")
 	} else if mediaBox != nil {
 		p.MediaBox = mediaBox
 	}
@@ -678,28 +678,28 @@ func Decode(c pdf.Cursor, obj pdf.Object, _ bool) (*Page, error) {
 
 	// CropBox (optional, inheritable)
 	if cropBox, err := c.Rectangle(dict["CropBox"]); err != nil {
-		return nil, err
+		return nil, pdf.Wrap(err, "CropBox")
 	} else if cropBox != nil {
 		p.CropBox = cropBox
 	}
 
 	// BleedBox (optional)
 	if bleedBox, err := c.Rectangle(dict["BleedBox"]); err != nil {
-		return nil, err
+		return nil, pdf.Wrap(err, "BleedBox")
 	} else if bleedBox != nil {
 		p.BleedBox = bleedBox
 	}
 
 	// TrimBox (optional)
 	if trimBox, err := c.Rectangle(dict["TrimBox"]); err != nil {
-		return nil, err
+		return nil, pdf.Wrap(err, "TrimBox")
 	} else if trimBox != nil {
 		p.TrimBox = trimBox
 	}
 
 	// ArtBox (optional)
 	if artBox, err := c.Rectangle(dict["ArtBox"]); err != nil {
-		return nil, err
+		return nil, pdf.Wrap(err, "ArtBox")
 	} else if artBox != nil {
 		p.ArtBox = artBox
 	}
@@ -0,0 +1,377 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pageedit
+
+import (
+	"maps"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/nametree"
+)
+
+// fix describes how a destination or action was changed.
+type fix int
+
+const (
+	unchanged fix = iota
+	replaced
+	dropped
+)
+
+// findDeadNames finds the named destinations of the main document which
+// point to removed pages.
+func (ww *writer) findDeadNames() error {
+	st := ww.state[ww.d.main]
+	cat := st.r.GetMeta().Catalog
+
+	dests, _ := pdf.Resolve(st.r, cat.Dests)
+	if dict, ok := dests.(pdf.Dict); ok {
+		for name, val := range dict {
+			if st.deadDest(val) {
+				ww.deadNames[name] = true
+			}
+		}
+	}
+
+	tree, err := st.destTree()
+	if err != nil {
+		return err
+	}
+	if tree != nil {
+		for name, val := range tree.All() {
+			if st.deadDest(val) {
+				ww.deadNames[name] = true
+			}
+		}
+	}
+	return nil
+}
+
+// destTree returns the name tree of named destinations of the source
+// document, or nil if there is none.
+func (st *srcState) destTree() (*nametree.InMemory, error) {
+	names, _ := pdf.Resolve(st.r, st.r.GetMeta().Catalog.Names)
+	dict, _ := names.(pdf.Dict)
+	if dict == nil {
+		return nil, nil
+	}
+	return pdf.Optional(nametree.ExtractInMemory(st.r, dict["Dests"]))
+}
+
+// lookupDest returns the explicit destination for a named destination in
+// the source document, or nil if the name is not defined.
+func (st *srcState) lookupDest(name pdf.Name) pdf.Object {
+	if st.dests == nil {
+		st.dests = make(map[pdf.Name]pdf.Object)
+		dests, _ := pdf.Resolve(st.r, st.r.GetMeta().Catalog.Dests)
+		if dict, ok := dests.(pdf.Dict); ok {
+			maps.Copy(st.dests, dict)
+		}
+		if tree, _ := st.destTree(); tree != nil {
+			for key, val := range tree.All() {
+				st.dests[key] = val
+			}
+		}
+	}
+
+	obj, _ := pdf.Resolve(st.r, st.dests[name])
+	if dict, ok := obj.(pdf.Dict); ok {
+		obj, _ = pdf.Resolve(st.r, dict["D"])
+	}
+	if arr, ok := obj.(pdf.Array); ok {
+		return arr
+	}
+	return nil
+}
+
+// deadDest reports whether obj is an explicit destination which points to
+// a removed page.  The destination can also be given as a dictionary with
+// a /D entry, as used for the values of named destinations.
+func (st *srcState) deadDest(obj pdf.Object) bool {
+	obj, _ = pdf.Resolve(st.r, obj)
+	if dict, ok := obj.(pdf.Dict); ok {
+		obj, _ = pdf.Resolve(st.r, dict["D"])
+	}
+	arr, ok := obj.(pdf.Array)
+	if !ok || len(arr) == 0 {
+		return false
+	}
+	ref, ok := arr[0].(pdf.Reference)
+	return ok && st.removed[ref]
+}
+
+// fixDest checks a destination from the source document.  Destinations
+// which point to removed pages are dropped.  Named destinations of
+// inserted documents are replaced by explicit destinations, since the
+// names are not copied to the output file.
+func (ww *writer) fixDest(st *srcState, obj pdf.Object) (pdf.Object, fix) {
+	resolved, _ := pdf.Resolve(st.r, obj)
+	var name pdf.Name
+	switch x := resolved.(type) {
+	case pdf.Name:
+		name = x
+	case pdf.String:
+		name = pdf.Name(x)
+	default:
+		if st.deadDest(resolved) {
+			return nil, dropped
+		}
+		return obj, unchanged
+	}
+
+	if st.source == ww.d.main {
+		if ww.deadNames[name] {
+			return nil, dropped
+		}
+		return obj, unchanged
+	}
+	target := st.lookupDest(name)
+	if target == nil || st.deadDest(target) {
+		return nil, dropped
+	}
+	return target, replaced
+}
+
+// fixAction checks an action from the source document.  Go-to actions
+// are dropped if their destination points to a removed page.
+//
+// Only the action itself is checked, actions in the /Next entry are left
+// unchanged.
+func (ww *writer) fixAction(st *srcState, obj pdf.Object) (pdf.Object, fix) {
+	resolved, _ := pdf.Resolve(st.r, obj)
+	act, _ := resolved.(pdf.Dict)
+	if act == nil {
+		return obj, unchanged
+	}
+	tp, _ := pdf.Resolve(st.r, act["S"])
+	if tp != pdf.Name("GoTo") {
+		return obj, unchanged
+	}
+
+	dest, f := ww.fixDest(st, act["D"])
+	switch f {
+	case dropped:
+		return nil, dropped
+	case replaced:
+		act = maps.Clone(act)
+		act["D"] = dest
+		return act, replaced
+	}
+	return obj, unchanged
+}
+
+// fixTargets checks the /Dest and /A entries of an annotation or outline
+// item.  If anything needs to change, a modified copy of the dictionary is
+// returned together with true.
+func (ww *writer) fixTargets(st *srcState, dict pdf.Dict) (pdf.Dict, bool) {
+	var res pdf.Dict
+	update := func(key pdf.Name, val pdf.Object, f fix) {
+		if f == unchanged {
+			return
+		}
+		if res == nil {
+			res = maps.Clone(dict)
+		}
+		if f == dropped {
+			delete(res, key)
+		} else {
+			res[key] = val
+		}
+	}
+	if dict["Dest"] != nil {
+		val, f := ww.fixDest(st, dict["Dest"])
+		update("Dest", val, f)
+	}
+	if dict["A"] != nil {
+		val, f := ww.fixAction(st, dict["A"])
+		update("A", val, f)
+	}
+
+	if res == nil {
+		return dict, false
+	}
+	return res, true
+}
+
+// fixAnnots checks the annotations on the kept pages of a source document.
+// Links to removed pages are dropped.  Annotations of inserted pages lose
+// their place in the structure tree of their source document.
+func (ww *writer) fixAnnots(st *srcState) {
+	isMain := st.source == ww.d.main
+	for i, ref := range st.refs {
+		if !st.kept[ref] {
+			continue
+		}
+		for _, annot := range st.annots(i) {
+			dict := ww.current(st, annot)
+			if dict == nil {
+				continue
+			}
+			dict, changed := ww.fixTargets(st, dict)
+			if !isMain && dict["StructParent"] != nil {
+				if !changed {
+					dict = maps.Clone(dict)
+					changed = true
+				}
+				delete(dict, "StructParent")
+			}
+			if changed {
+				ww.override(st, annot, dict)
+			}
+		}
+	}
+}
+
+// fixOutline checks the destinations of the outline items in the main
+// document.  Items which point to removed pages are kept, but lose their
+// destination.
+func (ww *writer) fixOutline() {
+	st := ww.state[ww.d.main]
+	rootRef := st.r.GetMeta().Catalog.Outlines
+	if rootRef == 0 {
+		return
+	}
+	root := ww.current(st, rootRef)
+	if root == nil {
+		return
+	}
+
+	seen := make(map[pdf.Reference]bool)
+	var walk func(first pdf.Object)
+	walk = func(first pdf.Object) {
+		ref, _ := first.(pdf.Reference)
+		for ref != 0 && !seen[ref] {
+			seen[ref] = true
+			dict := ww.current(st, ref)
+			if dict == nil {
+				return
+			}
+			if fixed, changed := ww.fixTargets(st, dict); changed {
+				ww.override(st, ref, fixed)
+			}
+			walk(dict["First"])
+			ref, _ = dict["Next"].(pdf.Reference)
+		}
+	}
+	walk(root["First"])
+}
+
+// openAction returns the open action for the output file.
+func (ww *writer) openAction(obj pdf.Object) (pdf.Object, error) {
+	st := ww.state[ww.d.main]
+	resolved, _ := pdf.Resolve(st.r, obj)
+
+	var f fix
+	if _, isDest := resolved.(pdf.Array); isDest {
+		obj, f = ww.fixDest(st, obj)
+	} else {
+		obj, f = ww.fixAction(st, obj)
+	}
+	if f == dropped {
+		return nil, nil
+	}
+	return ww.copyObj(st, obj)
+}
+
+// destsDict returns the /Dests dictionary for the output file, omitting
+// destinations which point to removed pages.
+func (ww *writer) destsDict(obj pdf.Object) (pdf.Object, error) {
+	st := ww.state[ww.d.main]
+	resolved, _ := pdf.Resolve(st.r, obj)
+	dict, ok := resolved.(pdf.Dict)
+	if !ok {
+		return nil, nil
+	}
+
+	res := pdf.Dict{}
+	for name, val := range dict {
+		if !ww.deadNames[name] {
+			res[name] = val
+		}
+	}
+	if len(res) == 0 {
+		return nil, nil
+	}
+	copied, err := st.copy.CopyDict(res)
+	if err != nil {
+		return nil, err
+	}
+	ref := ww.out.Alloc()
+	if err := ww.out.Put(ref, copied); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// namesDict returns the name dictionary for the output file.  Named
+// destinations which point to removed pages are omitted.
+func (ww *writer) namesDict(obj pdf.Object) (pdf.Object, error) {
+	st := ww.state[ww.d.main]
+	resolved, _ := pdf.Resolve(st.r, obj)
+	dict, ok := resolved.(pdf.Dict)
+	if !ok {
+		return nil, nil
+	}
+	if len(ww.deadNames) == 0 {
+		return st.copy.CopyDict(dict)
+	}
+
+	rest := maps.Clone(dict)
+	delete(rest, "Dests")
+	res, err := st.copy.CopyDict(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	tree, err := st.destTree()
+	if err != nil {
+		return nil, err
+	}
+	if tree != nil {
+		var copyErr error
+		data := func(yield func(pdf.Name, pdf.Object) bool) {
+			for name, val := range tree.All() {
+				if ww.deadNames[name] {
+					continue
+				}
+				copied, err := ww.copyObj(st, val)
+				if err != nil {
+					copyErr = err
+					return
+				}
+				if !yield(name, copied) {
+					return
+				}
+			}
+		}
+		ref, err := nametree.Write(ww.out, data)
+		if err != nil {
+			return nil, err
+		}
+		if copyErr != nil {
+			return nil, copyErr
+		}
+		if ref != 0 {
+			res["Dests"] = ref
+		}
+	}
+
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return res, nil
+}
@@ -0,0 +1,45 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package pageedit rearranges the pages of an existing PDF document.
+//
+// A [Document] is loaded from a PDF file using [Open].  Pages can then be
+// deleted, moved and reordered, pages from other PDF files can be inserted
+// at arbitrary positions, and the rotation and page boxes of individual
+// pages can be changed.  Finally, [Document.Write] writes the edited
+// document to a new file.
+//
+// Pages are referenced from many places outside the page tree.  When the
+// document is written, these references are fixed up:
+//
+//   - Outline items, named destinations, link annotations and the open
+//     action lose their destinations if they point to a removed page.
+//   - Page labels are recomputed, so that every page keeps the label it had
+//     in its source document.
+//   - Widget annotations of removed pages are removed from the interactive
+//     form, and fields which are left without widgets are dropped.  Form
+//     fields of inserted pages are added to the form, and are renamed if
+//     their name clashes with an existing field.
+//   - Marked content and object references to removed pages are removed
+//     from the structure tree.
+//   - Article beads on removed pages are unlinked from their threads.
+//
+// Document-level information, such as the outline, the structure tree and
+// the article threads, is only taken from the document passed to [Open].
+// Inserted pages keep their content, annotations and form fields, but not
+// their place in the structure tree or in article threads of their source
+// document.
+package pageedit
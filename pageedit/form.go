@@ -0,0 +1,197 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pageedit
+
+import (
+	"fmt"
+	"maps"
+
+	"seehuhn.de/go/pdf"
+)
+
+// fieldRoot is a root field of the interactive form of the output file.
+type fieldRoot struct {
+	st  *srcState
+	ref pdf.Reference
+}
+
+// fixForms determines the form fields of the output file.  Widgets on
+// removed pages are removed from their fields, and fields which are left
+// without widgets are dropped.  The fields of the main document are kept,
+// fields of inserted documents are only included if they have a widget on
+// an inserted page.  Root fields of inserted documents are renamed if
+// their name is already in use.
+func (ww *writer) fixForms() {
+	used := make(map[string]bool)
+	for _, src := range ww.d.sources {
+		st := ww.state[src]
+		isMain := src == ww.d.main
+
+		obj, _ := pdf.Resolve(st.r, st.r.GetMeta().Catalog.AcroForm)
+		acro, _ := obj.(pdf.Dict)
+		if acro == nil {
+			continue
+		}
+		obj, _ = pdf.Resolve(st.r, acro["Fields"])
+		fields, _ := obj.(pdf.Array)
+
+		seen := make(map[pdf.Reference]bool)
+		for _, field := range fields {
+			ref, ok := field.(pdf.Reference)
+			if !ok {
+				continue
+			}
+			keep, hasWidget := ww.fixField(st, ref, seen)
+			if !keep || !isMain && !hasWidget {
+				continue
+			}
+
+			dict := ww.current(st, ref)
+			name, _ := pdf.Resolve(st.r, dict["T"])
+			if s, ok := name.(pdf.String); ok {
+				partial := string(s.AsTextString())
+				if !isMain && used[partial] {
+					partial = uniqueName(used, partial)
+					dict = maps.Clone(dict)
+					dict["T"] = pdf.TextString(partial)
+					ww.override(st, ref, dict)
+				}
+				used[partial] = true
+			}
+			ww.fields = append(ww.fields, fieldRoot{st: st, ref: ref})
+		}
+	}
+}
+
+// fixField removes widgets on removed pages from the field ref and its
+// descendants.  The return values indicate whether the field is kept, and
+// whether the field has a widget on a kept page.
+func (ww *writer) fixField(st *srcState, ref pdf.Reference, seen map[pdf.Reference]bool) (keep, hasWidget bool) {
+	if st.removedAnnots[ref] || seen[ref] {
+		ww.dropField(st, ref)
+		return false, false
+	}
+	seen[ref] = true
+
+	dict := ww.current(st, ref)
+	if dict == nil {
+		return false, false
+	}
+	obj, _ := pdf.Resolve(st.r, dict["Kids"])
+	kids, _ := obj.(pdf.Array)
+	if len(kids) == 0 {
+		return true, st.keptAnnots[ref]
+	}
+
+	var newKids pdf.Array
+	for _, kid := range kids {
+		kidRef, ok := kid.(pdf.Reference)
+		if !ok {
+			newKids = append(newKids, kid)
+			continue
+		}
+		keep, w := ww.fixField(st, kidRef, seen)
+		if keep {
+			newKids = append(newKids, kid)
+			hasWidget = hasWidget || w
+		}
+	}
+	if len(newKids) == 0 {
+		ww.dropField(st, ref)
+		return false, false
+	}
+	if len(newKids) < len(kids) {
+		dict = maps.Clone(dict)
+		dict["Kids"] = newKids
+		ww.override(st, ref, dict)
+	}
+	return true, hasWidget
+}
+
+// dropField records that a field of the main document is not written.
+func (ww *writer) dropField(st *srcState, ref pdf.Reference) {
+	if st.source == ww.d.main {
+		ww.droppedFields[ref] = true
+	}
+}
+
+// acroForm returns the interactive form dictionary for the output file.
+func (ww *writer) acroForm(obj pdf.Object) (pdf.Object, error) {
+	st := ww.state[ww.d.main]
+	resolved, _ := pdf.Resolve(st.r, obj)
+	acro, _ := resolved.(pdf.Dict)
+	if acro == nil && len(ww.fields) == 0 {
+		return nil, nil
+	}
+
+	res := pdf.Dict{}
+	if acro != nil {
+		rest := maps.Clone(acro)
+		delete(rest, "Fields")
+		delete(rest, "CO")
+		var err error
+		res, err = st.copy.CopyDict(rest)
+		if err != nil {
+			return nil, err
+		}
+
+		// remove dropped fields from the calculation order
+		co, _ := pdf.Resolve(st.r, acro["CO"])
+		if arr, ok := co.(pdf.Array); ok {
+			var newCO pdf.Array
+			for _, field := range arr {
+				if ref, ok := field.(pdf.Reference); ok && ww.droppedFields[ref] {
+					continue
+				}
+				newCO = append(newCO, field)
+			}
+			if len(newCO) > 0 {
+				res["CO"], err = st.copy.CopyArray(newCO)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	fields := pdf.Array{}
+	for _, f := range ww.fields {
+		ref, err := f.st.copy.CopyReference(f.ref)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, ref)
+	}
+	res["Fields"] = fields
+
+	ref := ww.out.Alloc()
+	if err := ww.out.Put(ref, res); err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// uniqueName returns a partial field name, derived from name, which is not
+// yet in use.
+func uniqueName(used map[string]bool, name string) string {
+	for i := 2; ; i++ {
+		cand := fmt.Sprintf("%s_%d", name, i)
+		if !used[cand] {
+			return cand
+		}
+	}
+}
@@ -0,0 +1,230 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pageedit
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/page"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// Document is a PDF document whose pages are being edited.
+type Document struct {
+	main    *source
+	sources []*source
+	pages   []*Page
+}
+
+// Page is a page of a [Document].
+//
+// The page boxes and the rotation can be changed before the document is
+// written.  Boxes other than the media box can be set to nil, to remove
+// them from the page.
+type Page struct {
+	MediaBox *pdf.Rectangle
+	CropBox  *pdf.Rectangle
+	BleedBox *pdf.Rectangle
+	TrimBox  *pdf.Rectangle
+	ArtBox   *pdf.Rectangle
+
+	// Rotate is the clockwise rotation of the page when it is displayed.
+	Rotate page.Rotation
+
+	src   *source
+	index int
+}
+
+// source is a PDF file which provides pages for a [Document].
+type source struct {
+	r     pdf.Getter
+	refs  []pdf.Reference
+	dicts []pdf.Dict
+}
+
+// Open loads the page tree of a PDF document for editing.
+// Initially, the document contains all pages of r, in their original order.
+func Open(r pdf.Getter) (*Document, error) {
+	d := &Document{}
+	src, err := d.source(r)
+	if err != nil {
+		return nil, err
+	}
+	d.main = src
+	d.pages = src.newPages(nil)
+	return d, nil
+}
+
+// NumPages returns the number of pages in the document.
+func (d *Document) NumPages() int {
+	return len(d.pages)
+}
+
+// Page returns the page with the given 0-based index.
+// The returned page can be modified to change the page boxes and rotation.
+func (d *Document) Page(pageNo int) *Page {
+	return d.pages[pageNo]
+}
+
+// Insert inserts pages from r before the page with index pos.
+// If pos equals the number of pages, the pages are appended at the end.
+// The pages to insert are given by their 0-based index in r.  If no pages
+// are given, all pages of r are inserted.
+//
+// The document r must not be closed before [Document.Write] has been
+// called.  Pages of the document being edited can be inserted again, to
+// duplicate them; a duplicated page loses its annotations.
+func (d *Document) Insert(pos int, r pdf.Getter, pages ...int) error {
+	if pos < 0 || pos > len(d.pages) {
+		return fmt.Errorf("insert position %d out of range", pos)
+	}
+	src, err := d.source(r)
+	if err != nil {
+		return err
+	}
+	for _, pageNo := range pages {
+		if pageNo < 0 || pageNo >= len(src.refs) {
+			return fmt.Errorf("page %d out of range", pageNo+1)
+		}
+	}
+	d.pages = slices.Insert(d.pages, pos, src.newPages(pages)...)
+	return nil
+}
+
+// Delete removes the pages with the given 0-based indices.
+func (d *Document) Delete(pages ...int) error {
+	del := make(map[int]bool, len(pages))
+	for _, pageNo := range pages {
+		if pageNo < 0 || pageNo >= len(d.pages) {
+			return fmt.Errorf("page %d out of range", pageNo+1)
+		}
+		del[pageNo] = true
+	}
+	var keep []*Page
+	for i, p := range d.pages {
+		if !del[i] {
+			keep = append(keep, p)
+		}
+	}
+	d.pages = keep
+	return nil
+}
+
+// Move moves the page with index from, so that it ends up at index to.
+// All indices are 0-based.
+func (d *Document) Move(from, to int) error {
+	if from < 0 || from >= len(d.pages) {
+		return fmt.Errorf("page %d out of range", from+1)
+	}
+	if to < 0 || to >= len(d.pages) {
+		return fmt.Errorf("page %d out of range", to+1)
+	}
+	p := d.pages[from]
+	d.pages = slices.Delete(d.pages, from, from+1)
+	d.pages = slices.Insert(d.pages, to, p)
+	return nil
+}
+
+// Reorder rearranges the pages of the document.  The new page i is the
+// page which had index order[i] before the call.  Every index can occur at
+// most once, and pages which are not listed in order are removed.
+func (d *Document) Reorder(order []int) error {
+	seen := make(map[int]bool, len(order))
+	pages := make([]*Page, len(order))
+	for i, pageNo := range order {
+		if pageNo < 0 || pageNo >= len(d.pages) {
+			return fmt.Errorf("page %d out of range", pageNo+1)
+		}
+		if seen[pageNo] {
+			return fmt.Errorf("page %d listed twice", pageNo+1)
+		}
+		seen[pageNo] = true
+		pages[i] = d.pages[pageNo]
+	}
+	d.pages = pages
+	return nil
+}
+
+// source returns the source for the document r, loading its page tree
+// if needed.
+func (d *Document) source(r pdf.Getter) (*source, error) {
+	for _, src := range d.sources {
+		if src.r == r {
+			return src, nil
+		}
+	}
+
+	src := &source{r: r}
+	it := pagetree.NewIterator(r)
+	for ref, dict := range it.All() {
+		src.refs = append(src.refs, ref)
+		src.dicts = append(src.dicts, dict)
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	if len(src.refs) == 0 {
+		return nil, errors.New("document has no pages")
+	}
+	d.sources = append(d.sources, src)
+	return src, nil
+}
+
+// newPages returns new [Page] objects for the given pages of src.
+// If pages is empty, all pages are used.
+func (src *source) newPages(pages []int) []*Page {
+	if len(pages) == 0 {
+		pages = make([]int, len(src.refs))
+		for i := range pages {
+			pages[i] = i
+		}
+	}
+
+	c := pdf.NewCursor(src.r)
+	box := func(dict pdf.Dict, key pdf.Name) *pdf.Rectangle {
+		rect, _ := c.Rectangle(dict[key])
+		return rect
+	}
+
+	res := make([]*Page, len(pages))
+	for i, pageNo := range pages {
+		dict := src.dicts[pageNo]
+		p := &Page{
+			MediaBox: box(dict, "MediaBox"),
+			CropBox:  box(dict, "CropBox"),
+			BleedBox: box(dict, "BleedBox"),
+			TrimBox:  box(dict, "TrimBox"),
+			ArtBox:   box(dict, "ArtBox"),
+			Rotate:   page.Rotate0,
+			src:      src,
+			index:    pageNo,
+		}
+		if p.MediaBox == nil {
+			// The media box is required, but not all files have one.
+			// Viewers commonly fall back to US letter size.
+			p.MediaBox = &pdf.Rectangle{URx: 612, URy: 792}
+		}
+		if rot, err := c.Integer(dict["Rotate"]); err == nil {
+			p.Rotate = page.RotationFromDegrees(int(rot))
+		}
+		res[i] = p
+	}
+	return res
+}
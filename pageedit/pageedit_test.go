@@ -0,0 +1,515 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pageedit
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/internal/rewrite/rewritetest"
+	"seehuhn.de/go/pdf/nametree"
+	"seehuhn.de/go/pdf/numtree"
+	"seehuhn.de/go/pdf/page"
+	"seehuhn.de/go/pdf/pagelabel"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// makeDoc writes a document with n pages.  Page i shows the text
+// "<prefix><i+1>", has an outline item and a named destination "<prefix><i+1>",
+// a widget of the text field "name", and a paragraph in the structure tree.
+// The first page has a link to the last page, and the pages are labelled
+// "<prefix>-1", "<prefix>-2", ...
+func makeDoc(t *testing.T, prefix string, n int) *pdf.Reader {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	w, err := pdf.NewWriter(buf, pdf.V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rm := pdf.NewResourceManager(w)
+
+	put := func(ref pdf.Reference, obj pdf.Object) {
+		t.Helper()
+		if err := w.Put(ref, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	pageRefs := make([]pdf.Reference, n)
+	for i := range pageRefs {
+		pageRefs[i] = w.Alloc()
+	}
+	outlineRef := w.Alloc()
+	fieldRef := w.Alloc()
+	structRef := w.Alloc()
+	docElemRef := w.Alloc()
+
+	tree := pagetree.NewWriter(w, rm)
+	var items []pdf.Reference
+	var widgets, paras, parentTree pdf.Array
+	var dests []pdf.Name
+	for i, ref := range pageRefs {
+		label := fmt.Sprintf("%s%d", prefix, i+1)
+
+		contentRef := w.Alloc()
+		stm, err := w.OpenStream(contentRef, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fmt.Fprintf(stm, "/P <</MCID 0>> BDC BT /F1 12 Tf 72 72 Td (%s) Tj ET EMC\n", label)
+		if err := stm.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		widgetRef := w.Alloc()
+		put(widgetRef, pdf.Dict{
+			"Type":    pdf.Name("Annot"),
+			"Subtype": pdf.Name("Widget"),
+			"Rect":    &pdf.Rectangle{LLx: 72, LLy: 700, URx: 272, URy: 720},
+			"Parent":  fieldRef,
+			"P":       ref,
+		})
+		widgets = append(widgets, widgetRef)
+		annots := pdf.Array{widgetRef}
+		if i == 0 {
+			linkRef := w.Alloc()
+			put(linkRef, pdf.Dict{
+				"Type":    pdf.Name("Annot"),
+				"Subtype": pdf.Name("Link"),
+				"Rect":    &pdf.Rectangle{LLx: 72, LLy: 600, URx: 272, URy: 620},
+				"Dest":    pdf.Array{pageRefs[n-1], pdf.Name("Fit")},
+			})
+			annots = append(annots, linkRef)
+		}
+
+		itemRef := w.Alloc()
+		items = append(items, itemRef)
+
+		paraRef := w.Alloc()
+		put(paraRef, pdf.Dict{
+			"Type": pdf.Name("StructElem"),
+			"S":    pdf.Name("P"),
+			"P":    docElemRef,
+			"Pg":   ref,
+			"K":    pdf.Integer(0),
+		})
+		paras = append(paras, paraRef)
+		parentTree = append(parentTree, pdf.Integer(i), pdf.Array{paraRef})
+		dests = append(dests, pdf.Name(label))
+
+		err = tree.AppendPageDict(ref, pdf.Dict{
+			"Type":          pdf.Name("Page"),
+			"MediaBox":      &pdf.Rectangle{URx: 400, URy: 600},
+			"Contents":      contentRef,
+			"Annots":        annots,
+			"StructParents": pdf.Integer(i),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, ref := range items {
+		item := pdf.Dict{
+			"Title":  pdf.TextString(fmt.Sprintf("%s%d", prefix, i+1)),
+			"Parent": outlineRef,
+			"Dest":   pdf.Array{pageRefs[i], pdf.Name("Fit")},
+		}
+		if i > 0 {
+			item["Prev"] = items[i-1]
+		}
+		if i < n-1 {
+			item["Next"] = items[i+1]
+		}
+		put(ref, item)
+	}
+	put(outlineRef, pdf.Dict{
+		"First": items[0],
+		"Last":  items[n-1],
+		"Count": pdf.Integer(n),
+	})
+
+	put(fieldRef, pdf.Dict{
+		"FT":   pdf.Name("Tx"),
+		"T":    pdf.String("name"),
+		"Kids": widgets,
+	})
+
+	put(docElemRef, pdf.Dict{
+		"Type": pdf.Name("StructElem"),
+		"S":    pdf.Name("Document"),
+		"P":    structRef,
+		"K":    paras,
+	})
+	put(structRef, pdf.Dict{
+		"Type":       pdf.Name("StructTreeRoot"),
+		"K":          docElemRef,
+		"ParentTree": pdf.Dict{"Nums": parentTree},
+	})
+
+	destData := make(map[pdf.Name]pdf.Object)
+	for i, name := range dests {
+		destData[name] = pdf.Array{pageRefs[i], pdf.Name("Fit")}
+	}
+	destTree, err := nametree.WriteMap(w, destData)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	labels, err := pagelabel.New(pagelabel.Entry{
+		Range: pagelabel.Range{Style: pagelabel.Decimal, Prefix: prefix + "-"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	labelsObj, err := rm.Embed(labels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cat := w.GetMeta().Catalog
+	cat.Pages = pagesRef
+	cat.Outlines = outlineRef
+	cat.Names = pdf.Dict{"Dests": destTree}
+	cat.PageLabels = labelsObj
+	cat.AcroForm = pdf.Dict{"Fields": pdf.Array{fieldRef}}
+	cat.StructTreeRoot = structRef
+	cat.MarkInfo = pdf.Dict{"Marked": pdf.Boolean(true)}
+	cat.OpenAction = pdf.Array{pageRefs[n-1], pdf.Name("Fit")}
+
+	if err := rm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data := buf.Bytes()
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// write writes d and reads back the result.
+func write(t *testing.T, d *Document) *pdf.Reader {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	if err := d.Write(buf); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// pageTexts returns the text shown on every page of a document made by
+// makeDoc.
+func pageTexts(t *testing.T, r pdf.Getter) []string {
+	t.Helper()
+
+	var res []string
+	it := pagetree.NewIterator(r)
+	for _, dict := range it.All() {
+		rc, err := pagetree.ContentStream(r, dict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := string(body)
+		start := strings.Index(s, "(")
+		end := strings.Index(s, ")")
+		if start < 0 || end < start {
+			t.Fatalf("no text in %q", s)
+		}
+		res = append(res, s[start+1:end])
+	}
+	if it.Err != nil {
+		t.Fatal(it.Err)
+	}
+	return res
+}
+
+// pageIndex returns the 0-based index of the page ref in r, or -1.
+func pageIndex(t *testing.T, r pdf.Getter, ref pdf.Object) int {
+	t.Helper()
+
+	refs, err := pagetree.FindPages(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, pageRef := range refs {
+		if pageRef == ref {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestEdit(t *testing.T) {
+	a := makeDoc(t, "A", 3)
+	b := makeDoc(t, "B", 2)
+
+	d, err := Open(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Insert(1, b, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Move(0, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Delete(1); err != nil {
+		t.Fatal(err)
+	}
+	d.Page(0).Rotate = page.Rotate90
+	d.Page(2).CropBox = &pdf.Rectangle{LLx: 10, LLy: 10, URx: 390, URy: 590}
+	r := write(t, d)
+
+	got := strings.Join(pageTexts(t, r), " ")
+	if want := "B1 A3 A1"; got != want {
+		t.Fatalf("got pages %q, want %q", got, want)
+	}
+
+	c := pdf.NewCursor(r)
+	refs, err := pagetree.FindPages(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, p0, err := pagetree.GetPage(r, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rot, _ := c.Integer(p0["Rotate"]); rot != 90 {
+		t.Errorf("page 1: rotation %d, want 90", rot)
+	}
+	_, p2, err := pagetree.GetPage(r, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if box, _ := c.Rectangle(p2["CropBox"]); box == nil || box.LLx != 10 {
+		t.Errorf("page 3: crop box %v", box)
+	}
+
+	// page labels are taken from the source documents
+	labelsObj := r.GetMeta().Catalog.PageLabels
+	labels, err := pagelabel.Extract(r, labelsObj)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []string{"B-1", "A-3", "A-1"} {
+		if got := labels.Format(i); got != want {
+			t.Errorf("page %d: label %q, want %q", i+1, got, want)
+		}
+	}
+
+	// The outline item for the removed page A2 has lost its destination,
+	// the other items point to the new pages.
+	outlines, _ := c.Dict(r.GetMeta().Catalog.Outlines)
+	item, _ := c.Dict(outlines["First"])
+	var targets []int
+	for item != nil {
+		dest, _ := c.Array(item["Dest"])
+		if len(dest) > 0 {
+			targets = append(targets, pageIndex(t, r, dest[0]))
+		} else {
+			targets = append(targets, -1)
+		}
+		item, _ = c.Dict(item["Next"])
+	}
+	if fmt.Sprint(targets) != "[2 -1 1]" {
+		t.Errorf("outline targets %v, want [2 -1 1]", targets)
+	}
+
+	// named destinations of the main document
+	names, _ := c.Dict(r.GetMeta().Catalog.Names)
+	tree, err := nametree.ExtractInMemory(r, names["Dests"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var destNames []string
+	for name, val := range tree.All() {
+		dest, _ := c.Array(val)
+		destNames = append(destNames, fmt.Sprintf("%s:%d", name, pageIndex(t, r, dest[0])))
+	}
+	if got := strings.Join(destNames, " "); got != "A1:2 A3:1" {
+		t.Errorf("named destinations %q", got)
+	}
+
+	// The open action and the link on page A1 point to A3.  The link on
+	// page B1 pointed to B2, which has not been inserted.
+	if oa, _ := c.Array(r.GetMeta().Catalog.OpenAction); len(oa) == 0 || oa[0] != refs[1] {
+		t.Errorf("open action %v, want page 2", oa)
+	}
+	annots, _ := c.Array(p2["Annots"])
+	if len(annots) != 2 {
+		t.Fatalf("page 3: %d annotations, want 2", len(annots))
+	}
+	link, _ := c.Dict(annots[1])
+	if dest, _ := c.Array(link["Dest"]); len(dest) == 0 || dest[0] != refs[1] {
+		t.Errorf("link destination %v, want page 2", dest)
+	}
+	annots, _ = c.Array(p0["Annots"])
+	if len(annots) != 2 {
+		t.Fatalf("page 1: %d annotations, want 2", len(annots))
+	}
+	link, _ = c.Dict(annots[1])
+	if link["Dest"] != nil {
+		t.Errorf("link to a missing page kept its destination %v", link["Dest"])
+	}
+
+	// The form has the field of the main document, with the widgets of
+	// the kept pages, and the renamed field of the inserted document.
+	acro, _ := c.Dict(r.GetMeta().Catalog.AcroForm)
+	fields, _ := c.Array(acro["Fields"])
+	var fieldDesc []string
+	for _, obj := range fields {
+		field, _ := c.Dict(obj)
+		name, _ := c.TextString(field["T"])
+		kids, _ := c.Array(field["Kids"])
+		var pages []int
+		for _, kid := range kids {
+			widget, _ := c.Dict(kid)
+			pages = append(pages, pageIndex(t, r, widget["P"]))
+		}
+		fieldDesc = append(fieldDesc, fmt.Sprintf("%s:%v", name, pages))
+	}
+	if got := strings.Join(fieldDesc, " "); got != "name:[2 1] name_2:[0]" {
+		t.Errorf("form fields %q", got)
+	}
+
+	// The structure tree has lost the paragraph of page A2.
+	root, _ := c.Dict(r.GetMeta().Catalog.StructTreeRoot)
+	docElem, _ := c.Dict(root["K"])
+	paras, _ := c.Array(docElem["K"])
+	var paraPages []int
+	for _, obj := range paras {
+		para, _ := c.Dict(obj)
+		paraPages = append(paraPages, pageIndex(t, r, para["Pg"]))
+	}
+	if fmt.Sprint(paraPages) != "[2 1]" {
+		t.Errorf("paragraphs on pages %v, want [2 1]", paraPages)
+	}
+	parentTree, err := numtree.ExtractInMemory(r, root["ParentTree"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []pdf.Integer
+	for key := range parentTree.All() {
+		keys = append(keys, key)
+	}
+	if fmt.Sprint(keys) != "[0 2]" {
+		t.Errorf("parent tree keys %v, want [0 2]", keys)
+	}
+	if _, ok := p0["StructParents"]; ok {
+		t.Error("inserted page kept its /StructParents entry")
+	}
+}
+
+func TestDuplicate(t *testing.T) {
+	a := makeDoc(t, "A", 2)
+
+	d, err := Open(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Insert(2, a, 0); err != nil {
+		t.Fatal(err)
+	}
+	r := write(t, d)
+
+	got := strings.Join(pageTexts(t, r), " ")
+	if want := "A1 A2 A1"; got != want {
+		t.Fatalf("got pages %q, want %q", got, want)
+	}
+	_, dup, err := pagetree.GetPage(r, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dup["Annots"] != nil {
+		t.Error("duplicated page kept its annotations")
+	}
+
+	labels, err := pagelabel.Extract(r, r.GetMeta().Catalog.PageLabels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := labels.Format(2); got != "A-1" {
+		t.Errorf("duplicated page has label %q, want %q", got, "A-1")
+	}
+}
+
+func TestReorder(t *testing.T) {
+	a := makeDoc(t, "A", 4)
+
+	d, err := Open(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Reorder([]int{3, 1, 1}); err == nil {
+		t.Error("repeated page accepted")
+	}
+	if err := d.Reorder([]int{4}); err == nil {
+		t.Error("invalid page accepted")
+	}
+	if err := d.Reorder([]int{3, 0, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if d.NumPages() != 3 {
+		t.Fatalf("%d pages, want 3", d.NumPages())
+	}
+	r := write(t, d)
+
+	got := strings.Join(pageTexts(t, r), " ")
+	if want := "A4 A1 A3"; got != want {
+		t.Errorf("got pages %q, want %q", got, want)
+	}
+
+	if err := d.Delete(0, 1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Write(io.Discard); err == nil {
+		t.Error("empty document written")
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	d, err := Open(rewritetest.Source(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rewritetest.CheckCatalog(t, write(t, d))
+}
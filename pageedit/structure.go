@@ -0,0 +1,252 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pageedit
+
+import (
+	"maps"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/numtree"
+)
+
+// structRoot describes the structure tree root of the output file.
+type structRoot struct {
+	newRef pdf.Reference
+	dict   pdf.Dict
+
+	// deadKeys lists the keys in the parent tree which belong to removed
+	// pages and annotations.
+	deadKeys map[pdf.Integer]bool
+}
+
+// fixStructTree removes marked content and object references on removed
+// pages from the structure tree of the main document.  Structure elements
+// which are left without content are removed.
+func (ww *writer) fixStructTree() {
+	st := ww.state[ww.d.main]
+	obj := st.r.GetMeta().Catalog.StructTreeRoot
+	resolved, _ := pdf.Resolve(st.r, obj)
+	root, _ := resolved.(pdf.Dict)
+	if root == nil {
+		return
+	}
+
+	// Structure elements refer back to the root via their /P entry.  The
+	// root is written by writeStructTree, once the parent tree is ready.
+	sr := &structRoot{
+		newRef:   ww.out.Alloc(),
+		deadKeys: make(map[pdf.Integer]bool),
+	}
+	if ref, ok := obj.(pdf.Reference); ok {
+		st.copy.Redirect(ref, sr.newRef)
+	}
+
+	seen := make(map[pdf.Reference]bool)
+	k, f := ww.fixStructKids(st, root["K"], false, seen)
+	sr.dict = root
+	if f != unchanged {
+		sr.dict = maps.Clone(root)
+		if f == dropped {
+			delete(sr.dict, "K")
+		} else {
+			sr.dict["K"] = k
+		}
+	}
+
+	addKey := func(obj pdf.Object) {
+		key, _ := pdf.Resolve(st.r, obj)
+		if i, ok := key.(pdf.Integer); ok {
+			sr.deadKeys[i] = true
+		}
+	}
+	for i, ref := range st.refs {
+		if st.removed[ref] && st.dicts[i]["StructParents"] != nil {
+			addKey(st.dicts[i]["StructParents"])
+		}
+	}
+	for ref := range st.removedAnnots {
+		obj, _ := pdf.Resolve(st.r, ref)
+		if dict, ok := obj.(pdf.Dict); ok && dict["StructParent"] != nil {
+			addKey(dict["StructParent"])
+		}
+	}
+
+	ww.structRoot = sr
+}
+
+// fixStructKids removes content on removed pages from the /K entry of a
+// structure element.  If pageRemoved is set, the page given by the /Pg
+// entry of the element has been removed.
+func (ww *writer) fixStructKids(st *srcState, k pdf.Object, pageRemoved bool, seen map[pdf.Reference]bool) (pdf.Object, fix) {
+	if k == nil {
+		return nil, unchanged
+	}
+	resolved, _ := pdf.Resolve(st.r, k)
+	kids, isArray := resolved.(pdf.Array)
+	if !isArray {
+		kid, f := ww.fixStructKid(st, k, pageRemoved, seen)
+		return kid, f
+	}
+
+	var res pdf.Array
+	changed := false
+	for _, kid := range kids {
+		newKid, f := ww.fixStructKid(st, kid, pageRemoved, seen)
+		switch f {
+		case dropped:
+			changed = true
+			continue
+		case replaced:
+			changed = true
+		}
+		res = append(res, newKid)
+	}
+	switch {
+	case len(res) == 0:
+		return nil, dropped
+	case changed:
+		return res, replaced
+	default:
+		return k, unchanged
+	}
+}
+
+// fixStructKid checks a single kid of a structure element.  This can be a
+// marked-content identifier, a marked-content reference, an object
+// reference, or another structure element.
+func (ww *writer) fixStructKid(st *srcState, kid pdf.Object, pageRemoved bool, seen map[pdf.Reference]bool) (pdf.Object, fix) {
+	ref, isRef := kid.(pdf.Reference)
+	if isRef {
+		if seen[ref] {
+			return kid, unchanged
+		}
+		seen[ref] = true
+	}
+
+	resolved, _ := pdf.Resolve(st.r, kid)
+	var dict pdf.Dict
+	switch x := resolved.(type) {
+	case pdf.Integer:
+		if pageRemoved {
+			return nil, dropped
+		}
+		return kid, unchanged
+	case pdf.Dict:
+		dict = x
+	default:
+		return kid, unchanged
+	}
+
+	pg, hasPage := dict["Pg"].(pdf.Reference)
+	onRemoved := pageRemoved
+	if hasPage {
+		onRemoved = st.removed[pg]
+	}
+	tp, _ := pdf.Resolve(st.r, dict["Type"])
+	switch tp {
+	case pdf.Name("MCR"):
+		if onRemoved {
+			return nil, dropped
+		}
+		return kid, unchanged
+	case pdf.Name("OBJR"):
+		obj, _ := dict["Obj"].(pdf.Reference)
+		if onRemoved || st.removedAnnots[obj] {
+			return nil, dropped
+		}
+		return kid, unchanged
+	}
+
+	// kid is a structure element
+	k, f := ww.fixStructKids(st, dict["K"], onRemoved, seen)
+	if f == dropped {
+		return nil, dropped
+	}
+	if f == unchanged && !(hasPage && st.removed[pg]) {
+		return kid, unchanged
+	}
+	dict = maps.Clone(dict)
+	if f == replaced {
+		dict["K"] = k
+	}
+	if hasPage && st.removed[pg] {
+		delete(dict, "Pg")
+	}
+	if isRef {
+		ww.override(st, ref, dict)
+		return kid, unchanged
+	}
+	return dict, replaced
+}
+
+// writeStructTree writes the structure tree root of the output file.
+// Entries for removed pages and annotations are omitted from the parent
+// tree.
+func (ww *writer) writeStructTree() (pdf.Object, error) {
+	sr := ww.structRoot
+	if sr == nil {
+		return nil, nil
+	}
+	st := ww.state[ww.d.main]
+
+	rest := maps.Clone(sr.dict)
+	if len(sr.deadKeys) > 0 {
+		delete(rest, "ParentTree")
+	}
+	dict, err := st.copy.CopyDict(rest)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sr.deadKeys) > 0 {
+		tree, err := pdf.Optional(numtree.ExtractInMemory(st.r, sr.dict["ParentTree"]))
+		if err != nil {
+			return nil, err
+		}
+		var copyErr error
+		data := func(yield func(pdf.Integer, pdf.Object) bool) {
+			for key, val := range tree.All() {
+				if sr.deadKeys[key] {
+					continue
+				}
+				copied, err := ww.copyObj(st, val)
+				if err != nil {
+					copyErr = err
+					return
+				}
+				if !yield(key, copied) {
+					return
+				}
+			}
+		}
+		ref, err := numtree.Write(ww.out, data)
+		if err != nil {
+			return nil, err
+		}
+		if copyErr != nil {
+			return nil, copyErr
+		}
+		if ref != 0 {
+			dict["ParentTree"] = ref
+		}
+	}
+
+	if err := ww.out.Put(sr.newRef, dict); err != nil {
+		return nil, err
+	}
+	return sr.newRef, nil
+}
@@ -0,0 +1,108 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pageedit
+
+import (
+	"maps"
+
+	"seehuhn.de/go/pdf"
+)
+
+// fixThreads unlinks article beads on removed pages from the article
+// threads of the main document.  Threads without any remaining beads are
+// dropped.
+func (ww *writer) fixThreads() {
+	st := ww.state[ww.d.main]
+	obj, _ := pdf.Resolve(st.r, st.r.GetMeta().Catalog.Threads)
+	threads, _ := obj.(pdf.Array)
+
+	for _, thread := range threads {
+		threadRef, ok := thread.(pdf.Reference)
+		if !ok {
+			continue
+		}
+		threadDict := ww.current(st, threadRef)
+		if threadDict == nil {
+			continue
+		}
+
+		// The beads of a thread form a circular list.
+		var beads, kept []pdf.Reference
+		var dicts []pdf.Dict
+		seen := make(map[pdf.Reference]bool)
+		bead, _ := threadDict["F"].(pdf.Reference)
+		for bead != 0 && !seen[bead] {
+			seen[bead] = true
+			dict := ww.current(st, bead)
+			if dict == nil {
+				break
+			}
+			beads = append(beads, bead)
+			if pg, _ := dict["P"].(pdf.Reference); !st.removed[pg] {
+				kept = append(kept, bead)
+				dicts = append(dicts, dict)
+			}
+			bead, _ = dict["N"].(pdf.Reference)
+		}
+
+		if len(kept) == 0 {
+			continue
+		}
+		ww.threads = append(ww.threads, threadRef)
+		if len(kept) == len(beads) {
+			continue
+		}
+
+		n := len(kept)
+		for i, dict := range dicts {
+			dict = maps.Clone(dict)
+			dict["N"] = kept[(i+1)%n]
+			dict["V"] = kept[(i+n-1)%n]
+			if i == 0 {
+				dict["T"] = threadRef
+			} else {
+				delete(dict, "T")
+			}
+			ww.override(st, kept[i], dict)
+		}
+		threadDict = maps.Clone(threadDict)
+		threadDict["F"] = kept[0]
+		ww.override(st, threadRef, threadDict)
+	}
+}
+
+// writeThreads writes the array of article threads for the output file.
+func (ww *writer) writeThreads(ref pdf.Reference) (pdf.Reference, error) {
+	if ref == 0 || len(ww.threads) == 0 {
+		return 0, nil
+	}
+	st := ww.state[ww.d.main]
+
+	threads := make(pdf.Array, len(ww.threads))
+	for i, thread := range ww.threads {
+		threads[i] = thread
+	}
+	copied, err := st.copy.CopyArray(threads)
+	if err != nil {
+		return 0, err
+	}
+	newRef := ww.out.Alloc()
+	if err := ww.out.Put(newRef, copied); err != nil {
+		return 0, err
+	}
+	return newRef, nil
+}
@@ -0,0 +1,410 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pageedit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/internal/rewrite"
+	"seehuhn.de/go/pdf/pagelabel"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// writer holds the state while a [Document] is written.
+type writer struct {
+	d   *Document
+	out *pdf.Writer
+	rm  *pdf.ResourceManager
+
+	// null is written as a null object.  References to removed pages and
+	// their annotations are redirected to this object.
+	null pdf.Reference
+
+	state map[*source]*srcState
+
+	// deadNames lists the named destinations of the main document which
+	// point to removed pages.
+	deadNames map[pdf.Name]bool
+
+	// overrides holds modified copies of objects from the source documents.
+	// These are written in place of the original objects.
+	overrides     map[overrideKey]*override
+	overrideOrder []overrideKey
+
+	fields        []fieldRoot
+	droppedFields map[pdf.Reference]bool
+	threads       []pdf.Reference
+	structRoot    *structRoot
+}
+
+// srcState holds the per-source state while a [Document] is written.
+type srcState struct {
+	*source
+	copy *pdf.Copier
+
+	kept          map[pdf.Reference]bool
+	removed       map[pdf.Reference]bool
+	keptAnnots    map[pdf.Reference]bool
+	removedAnnots map[pdf.Reference]bool
+
+	dests map[pdf.Name]pdf.Object
+}
+
+type overrideKey struct {
+	st  *srcState
+	ref pdf.Reference
+}
+
+type override struct {
+	newRef pdf.Reference
+	dict   pdf.Dict
+}
+
+// Write writes the edited document to w.
+func (d *Document) Write(w io.Writer) error {
+	if len(d.pages) == 0 {
+		return errors.New("document has no pages")
+	}
+
+	version := pdf.GetVersion(d.main.r)
+	for _, src := range d.sources {
+		version = max(version, pdf.GetVersion(src.r))
+	}
+	out, err := rewrite.NewWriter(w, d.main.r, version)
+	if err != nil {
+		return err
+	}
+	rm := pdf.NewResourceManager(out)
+	ww := &writer{
+		d:             d,
+		out:           out,
+		rm:            rm,
+		null:          out.Alloc(),
+		state:         make(map[*source]*srcState),
+		deadNames:     make(map[pdf.Name]bool),
+		overrides:     make(map[overrideKey]*override),
+		droppedFields: make(map[pdf.Reference]bool),
+	}
+	for _, src := range d.sources {
+		ww.state[src] = &srcState{
+			source:        src,
+			copy:          pdf.NewCopier(out, src.r),
+			kept:          make(map[pdf.Reference]bool),
+			removed:       make(map[pdf.Reference]bool),
+			keptAnnots:    make(map[pdf.Reference]bool),
+			removedAnnots: make(map[pdf.Reference]bool),
+		}
+	}
+
+	// Pages are referenced from elsewhere in the document, for example from
+	// outlines and annotations.  Allocate the new references first, so that
+	// copying these references picks up the new pages.  If a page occurs
+	// more than once, references go to the first copy.
+	newRefs := make([]pdf.Reference, len(d.pages))
+	first := make([]bool, len(d.pages))
+	for i, p := range d.pages {
+		st := ww.state[p.src]
+		ref := p.src.refs[p.index]
+		newRefs[i] = out.Alloc()
+		if !st.kept[ref] {
+			st.kept[ref] = true
+			st.copy.Redirect(ref, newRefs[i])
+			first[i] = true
+		}
+	}
+	for _, src := range d.sources {
+		ww.state[src].findRemoved(ww.null)
+	}
+
+	// Modified objects must be registered before any copying starts, so
+	// that all references to them are redirected.
+	if err := ww.findDeadNames(); err != nil {
+		return err
+	}
+	for _, src := range d.sources {
+		ww.fixAnnots(ww.state[src])
+	}
+	ww.fixOutline()
+	ww.fixForms()
+	ww.fixStructTree()
+	ww.fixThreads()
+
+	tree := pagetree.NewWriter(out, rm)
+	for i, p := range d.pages {
+		newDict, err := ww.pageDict(p, first[i])
+		if err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+		if err := tree.AppendPageDict(newRefs[i], newDict); err != nil {
+			return err
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		return err
+	}
+
+	metaIn := d.main.r.GetMeta()
+	meta := out.GetMeta()
+	meta.Info = metaIn.Info
+	if err := ww.writeCatalog(meta.Catalog, metaIn.Catalog); err != nil {
+		return err
+	}
+	meta.Catalog.Pages = pagesRef
+
+	for _, key := range ww.overrideOrder {
+		o := ww.overrides[key]
+		dict, err := key.st.copy.CopyDict(o.dict)
+		if err != nil {
+			return err
+		}
+		if err := out.Put(o.newRef, dict); err != nil {
+			return err
+		}
+	}
+	if err := out.Put(ww.null, nil); err != nil {
+		return err
+	}
+
+	if err := rm.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// findRemoved records which pages of the source are not written, and
+// which annotations are on kept and on removed pages.  References to
+// removed pages and annotations are redirected to null.
+func (st *srcState) findRemoved(null pdf.Reference) {
+	for i, ref := range st.refs {
+		isKept := st.kept[ref]
+		if !isKept {
+			st.removed[ref] = true
+			st.copy.Redirect(ref, null)
+		}
+		for _, annot := range st.annots(i) {
+			if isKept {
+				st.keptAnnots[annot] = true
+			} else {
+				st.removedAnnots[annot] = true
+				st.copy.Redirect(annot, null)
+			}
+		}
+	}
+}
+
+// annots returns the references to the annotations of the given page.
+func (st *srcState) annots(pageNo int) []pdf.Reference {
+	annots, _ := pdf.Resolve(st.r, st.dicts[pageNo]["Annots"])
+	arr, _ := annots.(pdf.Array)
+	var res []pdf.Reference
+	for _, obj := range arr {
+		if ref, ok := obj.(pdf.Reference); ok {
+			res = append(res, ref)
+		}
+	}
+	return res
+}
+
+// pageDict returns the page dictionary for p in the output file.
+// If first is false, the page is a duplicate of an earlier page, and
+// page-specific entries are omitted.
+func (ww *writer) pageDict(p *Page, first bool) (pdf.Dict, error) {
+	if p.MediaBox == nil {
+		return nil, errors.New("missing media box")
+	}
+
+	st := ww.state[p.src]
+	dict := maps.Clone(p.src.dicts[p.index])
+	if !first {
+		delete(dict, "Annots")
+	}
+	if !first || p.src != ww.d.main {
+		// The structure tree and the article threads are only taken from
+		// the main document.
+		delete(dict, "StructParents")
+		delete(dict, "B")
+	}
+	res, err := st.copy.CopyDict(dict)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, box := range []struct {
+		key  pdf.Name
+		rect *pdf.Rectangle
+	}{
+		{"MediaBox", p.MediaBox},
+		{"CropBox", p.CropBox},
+		{"BleedBox", p.BleedBox},
+		{"TrimBox", p.TrimBox},
+		{"ArtBox", p.ArtBox},
+	} {
+		if box.rect != nil {
+			res[box.key] = box.rect
+		} else {
+			delete(res, box.key)
+		}
+	}
+	if deg := p.Rotate.Degrees(); deg != 0 {
+		res["Rotate"] = pdf.Integer(deg)
+	} else {
+		delete(res, "Rotate")
+	}
+	return res, nil
+}
+
+// override registers a modified version of the object ref from the source
+// document.  The modified dictionary is copied to the output file in place
+// of the original object.
+func (ww *writer) override(st *srcState, ref pdf.Reference, dict pdf.Dict) {
+	key := overrideKey{st, ref}
+	if o, ok := ww.overrides[key]; ok {
+		o.dict = dict
+		return
+	}
+	newRef := ww.out.Alloc()
+	st.copy.Redirect(ref, newRef)
+	ww.overrides[key] = &override{newRef: newRef, dict: dict}
+	ww.overrideOrder = append(ww.overrideOrder, key)
+}
+
+// current returns the dictionary ref from the source document, taking
+// previous overrides into account.
+func (ww *writer) current(st *srcState, ref pdf.Reference) pdf.Dict {
+	if o, ok := ww.overrides[overrideKey{st, ref}]; ok {
+		return o.dict
+	}
+	obj, _ := pdf.Resolve(st.r, ref)
+	dict, _ := obj.(pdf.Dict)
+	return dict
+}
+
+// copyObj copies an object from the source document to the output file.
+func (ww *writer) copyObj(st *srcState, obj pdf.Object) (pdf.Native, error) {
+	if obj == nil {
+		return nil, nil
+	}
+	return st.copy.Copy(obj.AsPDF(ww.out.GetOptions()))
+}
+
+// writeCatalog fills in the document catalog of the output file.
+func (ww *writer) writeCatalog(dst, src *pdf.Catalog) error {
+	st := ww.state[ww.d.main]
+
+	// The entries which refer to pages are rebuilt below, since pages may
+	// have been removed, reordered or taken from other documents.  The
+	// document parts hierarchy cannot be adjusted and is dropped.
+	err := rewrite.CopyCatalog(ww.out, st.copy, src,
+		"OpenAction", "Dests", "Names", "PageLabels", "AcroForm",
+		"StructTreeRoot", "Threads", "DPartRoot")
+	if err != nil {
+		return err
+	}
+
+	openAction, err := ww.openAction(src.OpenAction)
+	if err != nil {
+		return err
+	}
+	dst.OpenAction = openAction
+
+	dst.Dests, err = ww.destsDict(src.Dests)
+	if err != nil {
+		return err
+	}
+	dst.Names, err = ww.namesDict(src.Names)
+	if err != nil {
+		return err
+	}
+
+	labels, err := ww.pageLabels()
+	if err != nil {
+		return err
+	}
+	if labels != nil {
+		dst.PageLabels, err = ww.rm.Embed(labels)
+		if err != nil {
+			return err
+		}
+	}
+
+	dst.AcroForm, err = ww.acroForm(src.AcroForm)
+	if err != nil {
+		return err
+	}
+	dst.StructTreeRoot, err = ww.writeStructTree()
+	if err != nil {
+		return err
+	}
+	dst.Threads, err = ww.writeThreads(src.Threads)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// pageLabels computes the page labels of the output file.  Every page
+// keeps the label it had in its source document.  If none of the source
+// documents has page labels, nil is returned.
+func (ww *writer) pageLabels() (*pagelabel.Labels, error) {
+	labels := make(map[*source]*pagelabel.Labels)
+	for _, src := range ww.d.sources {
+		obj := src.r.GetMeta().Catalog.PageLabels
+		if obj == nil {
+			continue
+		}
+		l, err := pdf.Optional(pagelabel.Extract(src.r, obj))
+		if err != nil {
+			return nil, err
+		}
+		if l != nil {
+			labels[src] = l
+		}
+	}
+	if len(labels) == 0 {
+		return nil, nil
+	}
+
+	var entries []pagelabel.Entry
+	var prev pagelabel.Range
+	var prevNum int
+	for i, p := range ww.d.pages {
+		rng := pagelabel.Range{Style: pagelabel.Decimal}
+		num := p.index + 1
+		if l := labels[p.src]; l != nil {
+			if ri, offset := l.RangeAt(p.index); ri >= 0 {
+				rng = l.Ranges[ri].Range
+				num = rng.Start + offset
+			}
+		}
+
+		if i > 0 && rng.Style == prev.Style && rng.Prefix == prev.Prefix &&
+			(rng.Style == pagelabel.None || num == prevNum+1) {
+			prevNum = num
+			continue
+		}
+		rng.Start = num
+		entries = append(entries, pagelabel.Entry{FirstPage: i, Range: rng})
+		prev = rng
+		prevNum = num
+	}
+	return pagelabel.New(entries...)
+}
@@ -0,0 +1,40 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package pagination stamps running headers, footers, page numbers and Bates
+// numbers onto the pages of an existing PDF document.
+//
+// Each stamped text is placed in one of six positions at the top or bottom
+// edge of the page, and may contain the following placeholders:
+//
+//   - {page}: the one-based page number;
+//   - {pages}: the total number of pages;
+//   - {label}: the page label, as defined by the document's page labels,
+//     or the page number if the document has no page labels;
+//   - {date}: the date, formatted using [Options.DateFormat];
+//   - {bates}: the Bates number of the page.
+//
+// The text is wrapped in an /Artifact marked-content sequence of type
+// /Pagination, so that text extraction and accessibility tools can
+// recognise it as a pagination artifact and separate it from the real
+// content of the page.  The /Subtype of the artifact is /Bates for texts
+// which contain a Bates number, /PageNum for texts which consist only of a
+// page number, and /Header or /Footer otherwise.
+//
+// Positions refer to the page as it is displayed: the text is placed inside
+// the visible region given by the crop box, and is rotated to compensate
+// for the /Rotate entry of the page.
+package pagination
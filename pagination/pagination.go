@@ -0,0 +1,368 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"time"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/font/standard"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/internal/rewrite"
+	"seehuhn.de/go/pdf/pagelabel"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// Position gives the place of a stamped text on the page.
+type Position int
+
+// These are the possible positions of stamped texts.
+const (
+	TopLeft Position = iota
+	TopCenter
+	TopRight
+	BottomLeft
+	BottomCenter
+	BottomRight
+)
+
+func (p Position) isTop() bool {
+	return p <= TopRight
+}
+
+// Field is a text which is stamped onto every page.
+type Field struct {
+	// Position is the place of the text on the page.
+	Position Position
+
+	// Text is the text to show.  See the package documentation for the
+	// placeholders which can be used.
+	Text string
+
+	// Subtype (optional) overrides the /Subtype of the pagination
+	// artifact.  If this is empty, the subtype is chosen based on the
+	// position and the placeholders used in Text.
+	Subtype pdf.Name
+}
+
+// Default values for the [Options] fields.
+const (
+	defaultFontSize    = 9
+	defaultMargin      = 24
+	defaultDateFormat  = "2006-01-02"
+	defaultBatesDigits = 6
+)
+
+// Options control the texts which are stamped onto the pages, and how they
+// are drawn.
+type Options struct {
+	// Fields lists the texts to stamp onto every page.
+	Fields []Field
+
+	// Font is the font used for the texts.  If this is nil, Helvetica is
+	// used.
+	Font font.Layouter
+
+	// FontSize is the font size, in PDF units.  If this is zero, 9 points is
+	// used.
+	FontSize float64
+
+	// Color is the text colour.  If this is nil, black is used.
+	Color color.Color
+
+	// Margin is the distance between the edge of the visible page and the
+	// text, in PDF units.  If this is zero, 24 points is used.
+	Margin float64
+
+	// Date is the date used for the {date} placeholder.  If this is the zero
+	// time, the current time is used.
+	Date time.Time
+
+	// DateFormat is the layout used to format Date, in the format of
+	// [time.Time.Format].  If this is empty, "2006-01-02" is used.
+	DateFormat string
+
+	// BatesPrefix and BatesSuffix are placed around the number for the
+	// {bates} placeholder.
+	BatesPrefix, BatesSuffix string
+
+	// BatesStart is the Bates number of the first page.
+	BatesStart int
+
+	// BatesDigits is the minimal number of digits in a Bates number.
+	// Shorter numbers are padded with zeros.  If this is zero, 6 digits are
+	// used.
+	BatesDigits int
+}
+
+// Write reads the document from r, stamps the configured texts onto every
+// page, and writes the result to w.
+func Write(w io.Writer, r pdf.Getter, opts *Options) error {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if len(o.Fields) == 0 {
+		return errors.New("no texts to stamp")
+	}
+	for _, f := range o.Fields {
+		if f.Position < TopLeft || f.Position > BottomRight {
+			return fmt.Errorf("invalid position %d", f.Position)
+		}
+	}
+	if o.FontSize < 0 || o.Margin < 0 || o.BatesStart < 0 || o.BatesDigits < 0 {
+		return errors.New("negative stamp dimensions")
+	}
+	if o.FontSize == 0 {
+		o.FontSize = defaultFontSize
+	}
+	if o.Margin == 0 {
+		o.Margin = defaultMargin
+	}
+	if o.Color == nil {
+		o.Color = color.DeviceGray(0)
+	}
+	if o.Date.IsZero() {
+		o.Date = time.Now()
+	}
+	if o.DateFormat == "" {
+		o.DateFormat = defaultDateFormat
+	}
+	if o.BatesDigits == 0 {
+		o.BatesDigits = defaultBatesDigits
+	}
+	if o.Font == nil {
+		F, err := standard.Helvetica.New()
+		if err != nil {
+			return err
+		}
+		o.Font = F
+	}
+
+	refs, dicts, err := rewrite.ReadPages(r)
+	if err != nil {
+		return err
+	}
+
+	// the /Subtype entry of artifacts needs PDF 1.7
+	version := max(pdf.GetVersion(r), pdf.V1_7)
+	out, err := rewrite.NewWriter(w, r, version)
+	if err != nil {
+		return err
+	}
+	rm := pdf.NewResourceManager(out)
+	s := &stamper{
+		x:        pdf.NewExtractor(r),
+		out:      out,
+		rm:       rm,
+		copy:     pdf.NewCopier(out, r),
+		opts:     &o,
+		version:  version,
+		numPages: len(dicts),
+	}
+
+	metaIn := r.GetMeta()
+	if metaIn.Catalog.PageLabels != nil {
+		labels, err := pagelabel.Extract(r, metaIn.Catalog.PageLabels)
+		if pdf.IsReadError(err) {
+			return err
+		} else if err == nil {
+			s.labels = labels
+		}
+	}
+
+	newRefs := rewrite.RedirectPages(out, s.copy, refs)
+
+	tree := pagetree.NewWriter(out, rm)
+	for i, dict := range dicts {
+		newDict, err := s.stampPage(dict, i)
+		if err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+		if err := tree.AppendPageDict(newRefs[i], newDict); err != nil {
+			return err
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		return err
+	}
+
+	meta := out.GetMeta()
+	meta.Info = metaIn.Info
+	if err := rewrite.CopyCatalog(out, s.copy, metaIn.Catalog); err != nil {
+		return err
+	}
+	meta.Catalog.Pages = pagesRef
+
+	if err := rm.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// stampPage copies a page dictionary and appends the stamped texts to the
+// page content.
+func (s *stamper) stampPage(src pdf.Dict, pageNo int) (pdf.Dict, error) {
+	frame, err := s.pageFrame(src)
+	if err != nil {
+		return nil, err
+	}
+	formRef, err := s.stampForm(frame, pageNo)
+	if err != nil {
+		return nil, err
+	}
+
+	src = maps.Clone(src)
+	contentsIn := src["Contents"]
+	resourcesIn := src["Resources"]
+	delete(src, "Contents")
+	delete(src, "Resources")
+	dict, err := s.copy.CopyDict(src)
+	if err != nil {
+		return nil, err
+	}
+
+	res, name, err := s.resources(resourcesIn, formRef)
+	if err != nil {
+		return nil, err
+	}
+	dict["Resources"] = res
+
+	contents, err := s.contents(contentsIn, name)
+	if err != nil {
+		return nil, err
+	}
+	dict["Contents"] = contents
+
+	return dict, nil
+}
+
+// resources copies the resource dictionary of a page and adds the form
+// XObject with the stamped texts.  The name of the form is returned.
+func (s *stamper) resources(obj pdf.Object, formRef pdf.Reference) (pdf.Dict, pdf.Name, error) {
+	cur := pdf.CursorAt(s.x, nil)
+	resIn, err := cur.Dict(obj)
+	if pdf.IsReadError(err) {
+		return nil, "", err
+	}
+	resIn = maps.Clone(resIn)
+	xobjIn, err := cur.Dict(resIn["XObject"])
+	if pdf.IsReadError(err) {
+		return nil, "", err
+	}
+	delete(resIn, "XObject")
+
+	res, err := s.copy.CopyDict(resIn)
+	if err != nil {
+		return nil, "", err
+	}
+	if res == nil {
+		res = pdf.Dict{}
+	}
+	xobj, err := s.copy.CopyDict(xobjIn)
+	if err != nil {
+		return nil, "", err
+	}
+	if xobj == nil {
+		xobj = pdf.Dict{}
+	}
+
+	var name pdf.Name
+	for k := 1; ; k++ {
+		name = pdf.Name(fmt.Sprintf("Pg%d", k))
+		if _, used := xobj[name]; !used {
+			break
+		}
+	}
+	xobj[name] = formRef
+	res["XObject"] = xobj
+	return res, name, nil
+}
+
+// contents returns the new /Contents entry of a page.  The original content
+// streams are enclosed in q/Q, so that changes to the graphics state cannot
+// affect the stamped texts, and a new stream which draws the named form
+// XObject is appended.
+func (s *stamper) contents(obj pdf.Object, name pdf.Name) (pdf.Array, error) {
+	cur := pdf.CursorAt(s.x, nil)
+	var streams pdf.Array
+	resolved, err := cur.Resolve(obj)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	switch x := resolved.(type) {
+	case pdf.Array:
+		streams = x
+	case *pdf.Stream:
+		streams = pdf.Array{obj}
+	}
+
+	var res pdf.Array
+	if len(streams) > 0 {
+		if s.open == 0 {
+			s.open, err = s.writeStream("q\n")
+			if err != nil {
+				return nil, err
+			}
+		}
+		res = append(res, s.open)
+		for _, stm := range streams {
+			ref, ok := stm.(pdf.Reference)
+			if !ok {
+				continue
+			}
+			copied, err := s.copy.CopyReference(ref)
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, copied)
+		}
+	}
+
+	body := fmt.Sprintf("q /%s Do Q\n", name)
+	if len(streams) > 0 {
+		body = "Q\n" + body
+	}
+	ref, err := s.writeStream(body)
+	if err != nil {
+		return nil, err
+	}
+	res = append(res, ref)
+	return res, nil
+}
+
+// writeStream writes a content stream with the given body.
+func (s *stamper) writeStream(body string) (pdf.Reference, error) {
+	ref := s.out.Alloc()
+	stm, err := s.out.OpenStream(ref, nil)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := stm.Write([]byte(body)); err != nil {
+		return 0, err
+	}
+	if err := stm.Close(); err != nil {
+		return 0, err
+	}
+	return ref, nil
+}
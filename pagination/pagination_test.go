@@ -0,0 +1,179 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pagination
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/internal/rewrite/rewritetest"
+	"seehuhn.de/go/pdf/pagetree"
+	"seehuhn.de/go/pdf/search"
+)
+
+func stamp(t *testing.T, r pdf.Getter, opts *Options) *pdf.Reader {
+	t.Helper()
+	return rewritetest.Rewrite(t, r, func(w io.Writer, r pdf.Getter) error {
+		return Write(w, r, opts)
+	})
+}
+
+func TestStamp(t *testing.T) {
+	r := rewritetest.Source(t)
+	opts := &Options{
+		Fields: []Field{
+			{Position: TopLeft, Text: "Report of {date}"},
+			{Position: BottomCenter, Text: "Page {page} of {pages}"},
+			{Position: BottomRight, Text: "{bates}"},
+		},
+		Date:        time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		BatesPrefix: "ABC",
+		BatesStart:  41,
+	}
+	res := stamp(t, r, opts)
+
+	for _, query := range []string{"Report of 2026-03-01", "Page 2 of 3", "ABC000043", "body text"} {
+		hits, err := search.Find(res, query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(hits) == 0 {
+			t.Errorf("%q not found", query)
+		}
+	}
+
+	// check the position of the footer on each page
+	hits, err := search.Find(res, `Page \d of 3`, &search.Options{Regexp: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 3 {
+		t.Fatalf("found %d page numbers, want 3", len(hits))
+	}
+	for _, hit := range hits {
+		q := hit.QuadPoints
+		baseline := q[1].Sub(q[0])
+		mid := q[0].Add(q[2]).Mul(0.5)
+		switch hit.Page {
+		case 0:
+			// upright at the bottom of the media box
+			if baseline.X <= 0 || baseline.Y != 0 || mid.Y > 40 || mid.X < 190 || mid.X > 210 {
+				t.Errorf("page 1: unexpected quad %v", q)
+			}
+		case 1:
+			// the page is rotated clockwise, so the bottom of the
+			// displayed page is the right edge of the media box, and the
+			// text runs upwards
+			if baseline.Y <= 0 || baseline.X != 0 || mid.X < 560 || mid.Y < 190 || mid.Y > 210 {
+				t.Errorf("page 2: unexpected quad %v", q)
+			}
+		case 2:
+			// inside the crop box
+			if baseline.X <= 0 || mid.Y < 100 || mid.Y > 140 || mid.X < 190 || mid.X > 210 {
+				t.Errorf("page 3: unexpected quad %v", q)
+			}
+		}
+	}
+}
+
+func TestArtifacts(t *testing.T) {
+	r := rewritetest.Source(t)
+	opts := &Options{
+		Fields: []Field{
+			{Position: TopCenter, Text: "Draft"},
+			{Position: BottomLeft, Text: "{date}"},
+			{Position: BottomCenter, Text: "- {label} -"},
+			{Position: BottomRight, Text: "{bates}"},
+			{Position: TopRight, Text: "x", Subtype: "Watermark"},
+		},
+	}
+	res := stamp(t, r, opts)
+
+	want := []string{
+		"/Artifact <</Type/Pagination/Subtype/Header/Attached[/Top]/BBox[",
+		"/Subtype/Footer/Attached[/Bottom]",
+		"/Subtype/PageNum",
+		"/Subtype/Bates",
+		"/Subtype/Watermark",
+	}
+
+	c := pdf.NewCursor(res)
+	for ref, dict := range pagetree.NewIterator(res).All() {
+		resources, err := c.Dict(dict["Resources"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		xobj, err := c.Dict(resources["XObject"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		stm, err := c.Stream(xobj["Pg1"])
+		if err != nil || stm == nil {
+			t.Fatalf("page %v: stamp form not found: %v", ref, err)
+		}
+		body, err := pdf.ReadAll(res, nil, stm, 1<<20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range want {
+			if !strings.Contains(string(body), s) {
+				t.Errorf("page %v: %q not found in\n%s", ref, s, body)
+			}
+		}
+		if n := strings.Count(string(body), "BDC"); n != len(opts.Fields) {
+			t.Errorf("page %v: found %d marked-content sequences, want %d", ref, n, len(opts.Fields))
+		}
+	}
+}
+
+func TestSubtype(t *testing.T) {
+	for _, test := range []struct {
+		field Field
+		want  pdf.Name
+	}{
+		{Field{Position: TopLeft, Text: "Annual Report"}, "Header"},
+		{Field{Position: BottomLeft, Text: "Annual Report"}, "Footer"},
+		{Field{Position: BottomLeft, Text: "{page}"}, "PageNum"},
+		{Field{Position: TopRight, Text: "Page {page} of {pages}"}, "PageNum"},
+		{Field{Position: BottomLeft, Text: "{label}"}, "PageNum"},
+		{Field{Position: BottomLeft, Text: "Printed {date}, page {page}"}, "Footer"},
+		{Field{Position: BottomRight, Text: "{bates}"}, "Bates"},
+		{Field{Position: BottomRight, Text: "{bates}", Subtype: "Footer"}, "Footer"},
+	} {
+		got := subtype(test.field)
+		if got != test.want {
+			t.Errorf("%q: got %s, want %s", test.field.Text, got, test.want)
+		}
+	}
+}
+
+func TestNoFields(t *testing.T) {
+	r := rewritetest.Source(t)
+	if err := Write(&bytes.Buffer{}, r, nil); err == nil {
+		t.Error("missing error for empty options")
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	r := rewritetest.Source(t)
+	res := stamp(t, r, &Options{Fields: []Field{{Position: BottomCenter, Text: "{page}"}}})
+	rewritetest.CheckCatalog(t, res)
+}
@@ -0,0 +1,261 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pagination
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/vec"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/content/builder"
+	"seehuhn.de/go/pdf/graphics/form"
+	"seehuhn.de/go/pdf/page"
+	"seehuhn.de/go/pdf/pagelabel"
+	"seehuhn.de/go/pdf/property"
+)
+
+// coordDigits is the number of decimal digits kept for coordinates.
+const coordDigits = 2
+
+// stamper holds the state shared while stamping one document.
+type stamper struct {
+	x       *pdf.Extractor
+	out     *pdf.Writer
+	rm      *pdf.ResourceManager
+	copy    *pdf.Copier
+	opts    *Options
+	version pdf.Version
+
+	numPages int
+	labels   *pagelabel.Labels
+
+	// open is the shared content stream which saves the graphics state
+	// before the original page content.
+	open pdf.Reference
+}
+
+// frame describes the visible region of a page, as it is displayed.
+type frame struct {
+	// width and height are the dimensions of the displayed page.
+	width, height float64
+
+	// toUser maps display coordinates, with the origin in the bottom left
+	// corner of the displayed page, to default user space.
+	toUser matrix.Matrix
+}
+
+// pageFrame determines the visible region of a page from the crop box and
+// the page rotation.
+func (s *stamper) pageFrame(dict pdf.Dict) (*frame, error) {
+	cur := pdf.CursorAt(s.x, nil)
+	media, err := cur.Rectangle(dict["MediaBox"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	} else if err != nil || media == nil || media.IsZero() {
+		return nil, errors.New("missing or invalid MediaBox")
+	}
+	box := *media
+	crop, err := cur.Rectangle(dict["CropBox"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	} else if err == nil && crop != nil {
+		if clipped := crop.Intersect(media); clipped != nil && !clipped.IsZero() {
+			box = *clipped
+		}
+	}
+
+	var rotate page.Rotation
+	if deg, err := cur.Integer(dict["Rotate"]); err == nil {
+		rotate = page.RotationFromDegrees(int(deg))
+	} else if pdf.IsReadError(err) {
+		return nil, err
+	}
+
+	w, h := box.Dx(), box.Dy()
+	f := &frame{width: w, height: h}
+	switch rotate {
+	case page.Rotate90:
+		f.width, f.height = h, w
+		f.toUser = matrix.Matrix{0, 1, -1, 0, box.URx, box.LLy}
+	case page.Rotate180:
+		f.toUser = matrix.Matrix{-1, 0, 0, -1, box.URx, box.URy}
+	case page.Rotate270:
+		f.width, f.height = h, w
+		f.toUser = matrix.Matrix{0, -1, 1, 0, box.LLx, box.URy}
+	default:
+		f.toUser = matrix.Translate(box.LLx, box.LLy)
+	}
+	return f, nil
+}
+
+// stampForm writes a form XObject which draws the stamped texts for one
+// page.  The form uses display coordinates, and its matrix maps these to
+// default user space.
+func (s *stamper) stampForm(f *frame, pageNo int) (pdf.Reference, error) {
+	o := s.opts
+	geom := o.Font.GetGeometry()
+	ascent := geom.Ascent * o.FontSize
+	descent := geom.Descent * o.FontSize
+
+	b := builder.New(content.Form, nil, s.version)
+	b.SetFillColor(o.Color)
+	for _, field := range o.Fields {
+		text := s.expand(field.Text, pageNo)
+		if text == "" {
+			continue
+		}
+		width := o.Font.Layout(nil, o.FontSize, text).TotalWidth()
+
+		var x float64
+		switch field.Position {
+		case TopLeft, BottomLeft:
+			x = o.Margin
+		case TopCenter, BottomCenter:
+			x = (f.width - width) / 2
+		default:
+			x = f.width - o.Margin - width
+		}
+		var y float64
+		var attached pdf.Name
+		if field.Position.isTop() {
+			y = f.height - o.Margin - ascent
+			attached = "Top"
+		} else {
+			y = o.Margin - descent
+			attached = "Bottom"
+		}
+
+		bbox := userBBox(f.toUser, x, y+descent, x+width, y+ascent)
+		artifact := &property.Artifact{
+			Type:      "Pagination",
+			Subtype:   subtype(field),
+			BBox:      &bbox,
+			Attached:  []pdf.Name{attached},
+			SingleUse: true,
+		}
+		b.MarkedContentStart(&graphics.MarkedContent{
+			Tag:        "Artifact",
+			Properties: artifact,
+			Inline:     true,
+		})
+		b.TextBegin()
+		b.TextSetFont(o.Font, o.FontSize)
+		b.TextFirstLine(pdf.Round(x, coordDigits), pdf.Round(y, coordDigits))
+		b.TextShow(text)
+		b.TextEnd()
+		b.MarkedContentEnd()
+	}
+	ops, err := b.Harvest()
+	if err != nil {
+		return 0, err
+	}
+
+	fm := &form.Form{
+		Content: ops,
+		Res:     b.Resources,
+		BBox:    pdf.Rectangle{URx: f.width, URy: f.height},
+		Matrix:  f.toUser,
+	}
+	obj, err := s.rm.Embed(fm)
+	if err != nil {
+		return 0, err
+	}
+	ref, ok := obj.(pdf.Reference)
+	if !ok {
+		return 0, fmt.Errorf("unexpected form object %T", obj)
+	}
+	return ref, nil
+}
+
+// userBBox maps a rectangle in display coordinates to default user space,
+// and returns the bounding box of the result.
+func userBBox(m matrix.Matrix, llx, lly, urx, ury float64) pdf.Rectangle {
+	var r pdf.Rectangle
+	first := true
+	for _, p := range []vec.Vec2{{X: llx, Y: lly}, {X: urx, Y: lly}, {X: urx, Y: ury}, {X: llx, Y: ury}} {
+		q := m.Apply(p)
+		if first {
+			r = pdf.Rectangle{LLx: q.X, LLy: q.Y, URx: q.X, URy: q.Y}
+			first = false
+		} else {
+			r.ExtendVec(q)
+		}
+	}
+	r.LLx = math.Floor(r.LLx*100) / 100
+	r.LLy = math.Floor(r.LLy*100) / 100
+	r.URx = math.Ceil(r.URx*100) / 100
+	r.URy = math.Ceil(r.URy*100) / 100
+	return r
+}
+
+// expand replaces the placeholders in a text by their values for the given
+// zero-based page number.
+func (s *stamper) expand(text string, pageNo int) string {
+	o := s.opts
+	number := strconv.Itoa(pageNo + 1)
+	label := number
+	if s.labels != nil {
+		if l := s.labels.Format(pageNo); l != "" {
+			label = l
+		}
+	}
+	bates := fmt.Sprintf("%s%0*d%s", o.BatesPrefix, o.BatesDigits, o.BatesStart+pageNo, o.BatesSuffix)
+
+	r := strings.NewReplacer(
+		"{page}", number,
+		"{pages}", strconv.Itoa(s.numPages),
+		"{label}", label,
+		"{date}", o.Date.Format(o.DateFormat),
+		"{bates}", bates,
+	)
+	return r.Replace(text)
+}
+
+// subtype returns the /Subtype of the pagination artifact for a field.
+func subtype(f Field) pdf.Name {
+	if f.Subtype != "" {
+		return f.Subtype
+	}
+	if strings.Contains(f.Text, "{bates}") {
+		return "Bates"
+	}
+	if isPageNumber(f.Text) {
+		return "PageNum"
+	}
+	if f.Position.isTop() {
+		return "Header"
+	}
+	return "Footer"
+}
+
+// isPageNumber reports whether a text shows a page number, and no other
+// information.  Short words like "Page" or "of" are allowed.
+func isPageNumber(text string) bool {
+	rest := strings.NewReplacer("{pages}", "", "{page}", "", "{label}", "").Replace(text)
+	if rest == text || strings.ContainsAny(rest, "{}0123456789") {
+		return false
+	}
+	return len(strings.Fields(rest)) <= 2
+}
@@ -0,0 +1,171 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdfdiff
+
+import "fmt"
+
+// maxCells limits the size of the table used to compute a longest common
+// subsequence.  Larger blocks of changes are reported as a deletion
+// followed by an insertion.
+const maxCells = 4_000_000
+
+// pair gives the indices of matching elements in two sequences.  An index
+// of -1 indicates an element which is only present in the other sequence.
+type pair struct {
+	a, b int
+}
+
+// align matches the elements of two sequences of lengths n and m, using a
+// longest common subsequence.  The function eq reports whether element i
+// of the first sequence equals element j of the second sequence.
+func align(n, m int, eq func(i, j int) bool) []pair {
+	res := make([]pair, 0, max(n, m))
+
+	pre := 0
+	for pre < n && pre < m && eq(pre, pre) {
+		res = append(res, pair{pre, pre})
+		pre++
+	}
+	suf := 0
+	for suf < n-pre && suf < m-pre && eq(n-1-suf, m-1-suf) {
+		suf++
+	}
+
+	res = append(res, lcs(pre, n-suf, pre, m-suf, eq)...)
+
+	for k := suf; k > 0; k-- {
+		res = append(res, pair{n - k, m - k})
+	}
+	return res
+}
+
+// lcs aligns the ranges a0 <= i < a1 and b0 <= j < b1 of two sequences.
+func lcs(a0, a1, b0, b1 int, eq func(i, j int) bool) []pair {
+	n := a1 - a0
+	m := b1 - b0
+	var res []pair
+	if n*m > maxCells {
+		for i := a0; i < a1; i++ {
+			res = append(res, pair{i, -1})
+		}
+		for j := b0; j < b1; j++ {
+			res = append(res, pair{-1, j})
+		}
+		return res
+	}
+
+	// L[i*(m+1)+j] is the length of the longest common subsequence of the
+	// elements starting at a0+i and b0+j.
+	L := make([]int32, (n+1)*(m+1))
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if eq(a0+i, b0+j) {
+				L[i*(m+1)+j] = L[(i+1)*(m+1)+j+1] + 1
+			} else {
+				L[i*(m+1)+j] = max(L[(i+1)*(m+1)+j], L[i*(m+1)+j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && eq(a0+i, b0+j):
+			res = append(res, pair{a0 + i, b0 + j})
+			i++
+			j++
+		case j == m || i < n && L[(i+1)*(m+1)+j] >= L[i*(m+1)+j+1]:
+			res = append(res, pair{a0 + i, -1})
+			i++
+		default:
+			res = append(res, pair{-1, b0 + j})
+			j++
+		}
+	}
+	return res
+}
+
+// pairGaps pairs up unmatched elements between two matches, in order.
+// Elements which remain unmatched are kept.
+func pairGaps(p []pair) []pair {
+	var res, dels, ins []pair
+	flush := func() {
+		k := min(len(dels), len(ins))
+		for i := range k {
+			res = append(res, pair{dels[i].a, ins[i].b})
+		}
+		res = append(res, dels[k:]...)
+		res = append(res, ins[k:]...)
+		dels = dels[:0]
+		ins = ins[:0]
+	}
+	for _, x := range p {
+		switch {
+		case x.b < 0:
+			dels = append(dels, x)
+		case x.a < 0:
+			ins = append(ins, x)
+		default:
+			flush()
+			res = append(res, x)
+		}
+	}
+	flush()
+	return res
+}
+
+// diffLines returns a line-based diff of a and b.  Only changed lines are
+// included; each block of changes is preceded by a line giving the line
+// numbers where the block starts.  If maxLines is positive, the output is
+// truncated after maxLines lines.  The result is nil if a and b are equal.
+func diffLines(a, b []string, maxLines int) []string {
+	p := align(len(a), len(b), func(i, j int) bool { return a[i] == b[j] })
+
+	var res []string
+	total := 0
+	emit := func(line string) {
+		total++
+		if maxLines <= 0 || len(res) < maxLines {
+			res = append(res, line)
+		}
+	}
+
+	nextA, nextB := 0, 0
+	inBlock := false
+	for _, x := range p {
+		if x.a >= 0 && x.b >= 0 {
+			inBlock = false
+			nextA, nextB = x.a+1, x.b+1
+			continue
+		}
+		if !inBlock {
+			emit(fmt.Sprintf("@@ -%d +%d", nextA+1, nextB+1))
+			inBlock = true
+		}
+		if x.a >= 0 {
+			emit("- " + a[x.a])
+			nextA = x.a + 1
+		} else {
+			emit("+ " + b[x.b])
+			nextB = x.b + 1
+		}
+	}
+	if total > len(res) {
+		res = append(res, fmt.Sprintf("... %d more lines", total-len(res)))
+	}
+	return res
+}
@@ -0,0 +1,527 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdfdiff
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"seehuhn.de/go/postscript/cid"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/acroform"
+	"seehuhn.de/go/pdf/annotation"
+	"seehuhn.de/go/pdf/annotation/decode"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/graphics"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/form"
+	"seehuhn.de/go/pdf/page"
+	"seehuhn.de/go/pdf/pagetree"
+	"seehuhn.de/go/pdf/reader"
+)
+
+// Options controls which aspects of the documents are compared by
+// [Compare].
+type Options struct {
+	// IgnoreContent disables the comparison of content stream operators.
+	IgnoreContent bool
+
+	// IgnoreText disables the comparison of the text on the pages.
+	IgnoreText bool
+
+	// MaxLines, if positive, limits the number of lines in each
+	// line-based diff.
+	MaxLines int
+}
+
+// pageInfo holds the information about a page which is used for the
+// comparison.
+type pageInfo struct {
+	number int // starting at 1
+
+	// page is the decoded page, or nil if the page could not be decoded.
+	// In this case, err gives the reason.
+	page *page.Page
+	err  error
+
+	ops  []string // normalised content stream operators
+	text []string // lines of text
+
+	// key is used to match pages between the two documents.
+	key [32]byte
+}
+
+// Compare compares the documents a and b.  Pages or page elements which
+// cannot be decoded are reported as differences, other errors are
+// returned.
+func Compare(a, b pdf.Getter, opt *Options) (*Report, error) {
+	if opt == nil {
+		opt = &Options{}
+	}
+
+	pagesA, err := readPages(a, opt)
+	if err != nil {
+		return nil, err
+	}
+	pagesB, err := readPages(b, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	rep := &Report{
+		PagesA:      len(pagesA),
+		PagesB:      len(pagesB),
+		Differences: []*Difference{},
+	}
+
+	match := align(len(pagesA), len(pagesB), func(i, j int) bool {
+		return pagesA[i].key == pagesB[j].key
+	})
+	for _, p := range pairGaps(match) {
+		switch {
+		case p.b < 0:
+			rep.add(&Difference{PageA: pagesA[p.a].number, Kind: KindPage, Change: Removed})
+		case p.a < 0:
+			rep.add(&Difference{PageB: pagesB[p.b].number, Kind: KindPage, Change: Added})
+		default:
+			comparePages(rep, pagesA[p.a], pagesB[p.b], opt)
+		}
+	}
+
+	fieldsA, err := readFields(a)
+	if err != nil {
+		return nil, err
+	}
+	fieldsB, err := readFields(b)
+	if err != nil {
+		return nil, err
+	}
+	compareFields(rep, fieldsA, fieldsB)
+
+	return rep, nil
+}
+
+func (r *Report) add(d *Difference) {
+	r.Differences = append(r.Differences, d)
+}
+
+// readPages reads the pages of a document.
+func readPages(r pdf.Getter, opt *Options) ([]*pageInfo, error) {
+	x := pdf.NewExtractor(r)
+	extraText := map[font.Instance]map[cid.CID]string{}
+
+	var res []*pageInfo
+	it := pagetree.NewIterator(r)
+	for _, dict := range it.All() {
+		info := &pageInfo{number: len(res) + 1}
+		res = append(res, info)
+
+		pg, err := pdf.Decode(pdf.CursorAt(x, nil), dict, page.Decode)
+		if pdf.IsMalformed(err) {
+			info.err = err
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		info.page = pg
+
+		if !opt.IgnoreContent {
+			it := pg.NewIter()
+			for name, args := range it.All() {
+				info.ops = append(info.ops, formatOp(name, args))
+			}
+			if err := it.Err(); err != nil && !pdf.IsMalformed(err) {
+				return nil, err
+			}
+		}
+
+		if !opt.IgnoreText {
+			c := &textCollector{x: x, extraText: extraText}
+			rd := reader.New(x)
+			rd.State = content.NewState(content.Page, pg.Resources)
+			err := c.run(rd, pg.NewIter(), 0)
+			if err != nil && !pdf.IsMalformed(err) {
+				return nil, err
+			}
+			c.newLine()
+			info.text = c.lines
+		}
+
+		h := sha256.New()
+		for _, line := range info.ops {
+			h.Write([]byte(line + "\n"))
+		}
+		h.Write([]byte{0})
+		for _, line := range info.text {
+			h.Write([]byte(line + "\n"))
+		}
+		h.Sum(info.key[:0])
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	return res, nil
+}
+
+// comparePages compares two matched pages.
+func comparePages(rep *Report, a, b *pageInfo, opt *Options) {
+	diff := func(kind Kind, change Change, item, descA, descB string) *Difference {
+		d := &Difference{
+			PageA:  a.number,
+			PageB:  b.number,
+			Kind:   kind,
+			Change: change,
+			Item:   item,
+			A:      descA,
+			B:      descB,
+		}
+		rep.add(d)
+		return d
+	}
+
+	if a.page == nil || b.page == nil {
+		if a.page != nil || b.page != nil {
+			diff(KindPage, Changed, "", errString(a.err), errString(b.err))
+		}
+		return
+	}
+	pa, pb := a.page, b.page
+
+	boxesA, boxesB := pageBoxes(pa), pageBoxes(pb)
+	for i, name := range boxNames {
+		// Boxes which are not set on either page follow the MediaBox or the
+		// CropBox, and are not reported separately.
+		if i > 0 && explicitBox(pa, i) == nil && explicitBox(pb, i) == nil {
+			continue
+		}
+		if !boxesA[i].Equal(boxesB[i]) {
+			diff(KindBox, Changed, name, boxString(boxesA[i]), boxString(boxesB[i]))
+		}
+	}
+	if ra, rb := pa.Rotate.Degrees(), pb.Rotate.Degrees(); ra != rb {
+		diff(KindRotate, Changed, "", fmt.Sprint(ra), fmt.Sprint(rb))
+	}
+
+	compareResources(pa.Resources, pb.Resources, diff)
+	compareAnnotations(pa.Annots, pb.Annots, diff)
+
+	if lines := diffLines(a.ops, b.ops, opt.MaxLines); lines != nil {
+		d := diff(KindContent, Changed, "", "", "")
+		d.Lines = lines
+	}
+	if lines := diffLines(a.text, b.text, opt.MaxLines); lines != nil {
+		d := diff(KindText, Changed, "", "", "")
+		d.Lines = lines
+	}
+}
+
+type diffFunc func(kind Kind, change Change, item, descA, descB string) *Difference
+
+func errString(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	return err.Error()
+}
+
+var boxNames = []string{"MediaBox", "CropBox", "BleedBox", "TrimBox", "ArtBox"}
+
+// pageBoxes returns the page boxes of a page, in the order given by
+// boxNames, with default values filled in.
+func pageBoxes(p *page.Page) []*pdf.Rectangle {
+	media := p.MediaBox
+	crop := p.CropBox
+	if crop == nil {
+		crop = media
+	}
+	orCrop := func(box *pdf.Rectangle) *pdf.Rectangle {
+		if box == nil {
+			return crop
+		}
+		return box
+	}
+	return []*pdf.Rectangle{media, crop, orCrop(p.BleedBox), orCrop(p.TrimBox), orCrop(p.ArtBox)}
+}
+
+// explicitBox returns the page box with index i in boxNames, as set in the
+// page dictionary.
+func explicitBox(p *page.Page, i int) *pdf.Rectangle {
+	return []*pdf.Rectangle{p.MediaBox, p.CropBox, p.BleedBox, p.TrimBox, p.ArtBox}[i]
+}
+
+func boxString(box *pdf.Rectangle) string {
+	if box == nil {
+		return "missing"
+	}
+	return box.String()
+}
+
+// compareResources compares the resource dictionaries of two pages.
+func compareResources(a, b *content.Resources, diff diffFunc) {
+	if a == nil {
+		a = &content.Resources{}
+	}
+	if b == nil {
+		b = &content.Resources{}
+	}
+
+	compareMap(diff, KindFont, "", a.Font, b.Font, font.InstancesEqual, fontName)
+	compareMap(diff, KindResource, "ExtGState ", a.ExtGState, b.ExtGState, equalMethod, nil)
+	compareMap(diff, KindResource, "ColorSpace ", a.ColorSpace, b.ColorSpace, color.SpacesEqual, nil)
+	compareMap(diff, KindResource, "Pattern ", a.Pattern, b.Pattern, equalMethod, nil)
+	compareMap(diff, KindResource, "Shading ", a.Shading, b.Shading, equalMethod, nil)
+	compareMap(diff, KindResource, "XObject ", a.XObject, b.XObject, xObjectsEqual, nil)
+	compareMap(diff, KindResource, "Properties ", a.Properties, b.Properties, equalMethod, nil)
+}
+
+// compareMap compares the entries of one category of resources.  If desc
+// is not nil, it is used to describe the resources.
+func compareMap[V any](diff diffFunc, kind Kind, prefix string, a, b map[pdf.Name]V, eq func(V, V) bool, desc func(V) string) {
+	describe := func(v V) string {
+		if desc == nil {
+			return ""
+		}
+		return desc(v)
+	}
+
+	keys := slices.Sorted(maps.Keys(a))
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		item := prefix + pdf.AsString(key)
+		va, inA := a[key]
+		vb, inB := b[key]
+		switch {
+		case !inB:
+			diff(kind, Removed, item, describe(va), "")
+		case !inA:
+			diff(kind, Added, item, "", describe(vb))
+		case !eq(va, vb):
+			diff(kind, Changed, item, describe(va), describe(vb))
+		}
+	}
+}
+
+// equalMethod compares two values using their Equal method.
+func equalMethod[V interface{ Equal(V) bool }](a, b V) bool {
+	if isNil(a) || isNil(b) {
+		return isNil(a) && isNil(b)
+	}
+	return a.Equal(b)
+}
+
+func isNil(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	return rv.Kind() == reflect.Pointer && rv.IsNil()
+}
+
+// xObjectsEqual compares two XObjects.  Form XObjects are compared using
+// [form.Form.Equal], other XObjects are only compared by type.
+func xObjectsEqual(a, b graphics.XObject) bool {
+	fa, okA := a.(*form.Form)
+	fb, okB := b.(*form.Form)
+	if okA && okB {
+		return fa.Equal(fb)
+	}
+	return reflect.TypeOf(a) == reflect.TypeOf(b)
+}
+
+func fontName(f font.Instance) string {
+	if f == nil {
+		return ""
+	}
+	name := f.PostScriptName()
+	if name == "" {
+		name = "unnamed font"
+	}
+	return name
+}
+
+// compareAnnotations compares the annotations of two pages.  Annotations
+// are matched by their type, position and contents.
+func compareAnnotations(a, b []annotation.Annotation, diff diffFunc) {
+	descA := make([]string, len(a))
+	for i, annot := range a {
+		descA[i] = describeAnnotation(annot)
+	}
+	descB := make([]string, len(b))
+	for j, annot := range b {
+		descB[j] = describeAnnotation(annot)
+	}
+
+	match := align(len(a), len(b), func(i, j int) bool { return descA[i] == descB[j] })
+	for _, p := range pairGaps(match) {
+		switch {
+		case p.b < 0:
+			diff(KindAnnotation, Removed, fmt.Sprintf("#%d", p.a+1), descA[p.a], "")
+		case p.a < 0:
+			diff(KindAnnotation, Added, fmt.Sprintf("#%d", p.b+1), "", descB[p.b])
+		case descA[p.a] != descB[p.b]:
+			diff(KindAnnotation, Changed, fmt.Sprintf("#%d", p.a+1), descA[p.a], descB[p.b])
+		default:
+			ca, cb := a[p.a].GetCommon(), b[p.b].GetCommon()
+			item := fmt.Sprintf("#%d", p.a+1)
+			if !reflect.DeepEqual(ca.Color, cb.Color) {
+				diff(KindAnnotation, Changed, item, descA[p.a]+", color", "")
+			}
+			if !appearancesEqual(ca, cb) {
+				diff(KindAnnotation, Changed, item, descA[p.a]+", appearance", "")
+			}
+		}
+	}
+}
+
+// describeAnnotation returns a short description of an annotation.
+func describeAnnotation(a annotation.Annotation) string {
+	c := a.GetCommon()
+	parts := []string{string(a.AnnotationType()), c.Rect.String()}
+	if c.Contents != "" {
+		parts = append(parts, fmt.Sprintf("%q", c.Contents))
+	}
+	if c.Name != "" {
+		parts = append(parts, "NM="+c.Name)
+	}
+	if c.Flags != 0 {
+		parts = append(parts, fmt.Sprintf("F=%d", c.Flags))
+	}
+	if c.AppearanceState != "" {
+		parts = append(parts, "AS="+string(c.AppearanceState))
+	}
+	return strings.Join(parts, " ")
+}
+
+// appearancesEqual compares the normal appearances of two annotations.
+func appearancesEqual(a, b *annotation.Common) bool {
+	if a.Appearance == nil || b.Appearance == nil {
+		return a.Appearance == nil && b.Appearance == nil
+	}
+	na, nb := a.Appearance, b.Appearance
+	return formsEqual(na.Normal, nb.Normal) &&
+		maps.EqualFunc(na.NormalMap, nb.NormalMap, formsEqual)
+}
+
+func formsEqual(a, b *form.Form) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(b)
+}
+
+// readFields returns the terminal fields of the interactive form of a
+// document, by fully qualified name.  The result is nil if the document has
+// no interactive form.
+func readFields(r pdf.Getter) (map[string]acroform.Field, error) {
+	obj := r.GetMeta().Catalog.AcroForm
+	if obj == nil {
+		return nil, nil
+	}
+	x := pdf.NewExtractor(r)
+	f, err := pdf.Decode(pdf.CursorAt(x, nil), obj, decode.Form)
+	if pdf.IsMalformed(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if f == nil {
+		return nil, nil
+	}
+
+	res := make(map[string]acroform.Field)
+	for name, field := range f.AllFields() {
+		res[name] = field
+	}
+	return res, nil
+}
+
+// compareFields compares the terminal fields of two interactive forms.
+func compareFields(rep *Report, a, b map[string]acroform.Field) {
+	names := slices.Sorted(maps.Keys(a))
+	for name := range b {
+		if _, ok := a[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		fa, inA := a[name]
+		fb, inB := b[name]
+		item := fmt.Sprintf("%q", name)
+		switch {
+		case !inB:
+			rep.add(&Difference{Kind: KindField, Change: Removed, Item: item, A: describeField(fa)})
+		case !inA:
+			rep.add(&Difference{Kind: KindField, Change: Added, Item: item, B: describeField(fb)})
+		default:
+			da, db := describeField(fa), describeField(fb)
+			if da != db {
+				rep.add(&Difference{Kind: KindField, Change: Changed, Item: item, A: da, B: db})
+			}
+		}
+	}
+}
+
+// describeField returns a short description of a field, including its
+// type, flags and value.
+func describeField(f acroform.Field) string {
+	desc := string(f.FieldType())
+	if c := f.GetCommon(); c != nil && c.Flags != 0 {
+		desc += fmt.Sprintf(" Ff=%d", c.Flags)
+	}
+	return desc + " " + fmt.Sprintf("%q", fieldValue(f))
+}
+
+// fieldValue returns the value of a terminal field, as a string.
+func fieldValue(field acroform.Field) string {
+	switch f := field.(type) {
+	case *acroform.TextField:
+		if f.V != nil {
+			return f.V.Value
+		}
+		return ""
+	case *acroform.ChoiceField:
+		return strings.Join(f.V, ", ")
+	case *acroform.ButtonField:
+		if f.Variant() == acroform.ButtonPush {
+			return ""
+		}
+		if f.V == "" {
+			return "Off"
+		}
+		return string(f.V)
+	case *acroform.SignatureField:
+		if f.V != nil {
+			return "<signed>"
+		}
+		return "<unsigned>"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,45 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package pdfdiff compares the structure and the contents of two PDF
+// documents.
+//
+// [Compare] matches the pages of the two documents and reports the
+// differences as a [Report].  Differences which do not change the meaning
+// of a document, for example a different object numbering, a different
+// compression of streams or a different layout of the content streams,
+// are not reported.
+//
+// Pages are matched by comparing their content streams and text, so that
+// an inserted or deleted page is reported as such, instead of as a change
+// of all following pages.  For each pair of matched pages, the following
+// properties are compared:
+//
+//   - the page boxes and the page rotation,
+//   - the resources of the page, using the Equal methods of the resource
+//     types.  Fonts are compared using [font.InstancesEqual].  Form
+//     XObjects are compared using [form.Form.Equal], other XObjects are
+//     only compared by type.
+//   - the annotations of the page, including their normal appearance,
+//   - the content stream operators, after normalising the number format,
+//   - the text extracted from the page.
+//
+// In addition, the terminal fields of the interactive forms of the
+// two documents are compared by their fully qualified names.
+//
+// A report can be written in human-readable form using [Report.WriteText],
+// or as JSON using [encoding/json].
+package pdfdiff
@@ -0,0 +1,182 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdfdiff
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/document"
+	"seehuhn.de/go/pdf/font/gofont"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+)
+
+// testPage describes a page of a test document.
+type testPage struct {
+	size *pdf.Rectangle
+	text string
+}
+
+// makeDoc writes a document with one line of text on each page.
+func makeDoc(t *testing.T, opt *pdf.WriterOptions, pages ...testPage) *pdf.Reader {
+	t.Helper()
+
+	F, err := gofont.Regular.NewSimple(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := memfile.New()
+	doc, err := document.WriteMultiPage(buf, document.A4, pdf.V1_7, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, pg := range pages {
+		p := doc.AddPage()
+		if pg.size != nil {
+			p.SetPageSize(pg.size)
+		}
+		p.TextBegin()
+		p.TextSetFont(F, 12)
+		p.TextFirstLine(72, 700)
+		p.TextShow(pg.text)
+		p.TextEnd()
+		if err := p.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(buf, int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func kinds(rep *Report) []string {
+	var res []string
+	for _, d := range rep.Differences {
+		res = append(res, string(d.Kind)+" "+string(d.Change))
+	}
+	return res
+}
+
+func TestEqualDocuments(t *testing.T) {
+	a := makeDoc(t, nil, testPage{text: "one"}, testPage{text: "two"})
+	b := makeDoc(t, &pdf.WriterOptions{HumanReadable: true},
+		testPage{text: "one"}, testPage{text: "two"})
+
+	rep, err := Compare(a, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rep.Equal() {
+		t.Errorf("unexpected differences: %q", kinds(rep))
+	}
+	if rep.PagesA != 2 || rep.PagesB != 2 {
+		t.Errorf("wrong page counts %d, %d", rep.PagesA, rep.PagesB)
+	}
+}
+
+func TestInsertedPage(t *testing.T) {
+	a := makeDoc(t, nil, testPage{text: "one"}, testPage{text: "two"})
+	b := makeDoc(t, nil, testPage{text: "one"}, testPage{text: "new"}, testPage{text: "two"})
+
+	rep, err := Compare(a, b, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rep.Differences) != 1 {
+		t.Fatalf("unexpected differences: %q", kinds(rep))
+	}
+	d := rep.Differences[0]
+	if d.Kind != KindPage || d.Change != Added || d.PageB != 2 || d.PageA != 0 {
+		t.Errorf("wrong difference %+v", d)
+	}
+}
+
+func TestChangedPage(t *testing.T) {
+	a := makeDoc(t, nil, testPage{text: "Hello world"})
+	b := makeDoc(t, nil, testPage{text: "Hello there", size: document.Letter})
+
+	rep, err := Compare(a, b, &Options{IgnoreContent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"box changed", "text changed"}
+	if got := kinds(rep); !slices.Equal(got, want) {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+	if item := rep.Differences[0].Item; item != "MediaBox" {
+		t.Errorf("wrong box %q", item)
+	}
+	wantLines := []string{"@@ -1 +1", "- Hello world", "+ Hello there"}
+	if got := rep.Differences[1].Lines; !slices.Equal(got, wantLines) {
+		t.Errorf("got %q, want %q", got, wantLines)
+	}
+
+	buf := &bytes.Buffer{}
+	if err := rep.WriteText(buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "page 1: box MediaBox changed: [0.00 0.00 595.28 841.89] -> [0.00 0.00 612.00 792.00]") {
+		t.Errorf("unexpected text report:\n%s", buf)
+	}
+
+	data, err := json.Marshal(rep)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var rep2 Report
+	if err := json.Unmarshal(data, &rep2); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(kinds(&rep2), want) {
+		t.Errorf("JSON round trip failed: %s", data)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	a := []string{"a", "b", "c", "d", "e"}
+	b := []string{"a", "x", "c", "d", "e", "f"}
+	want := []string{"@@ -2 +2", "- b", "+ x", "@@ -6 +6", "+ f"}
+	if got := diffLines(a, b, 0); !slices.Equal(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := diffLines(a, a, 0); got != nil {
+		t.Errorf("equal input: got %q", got)
+	}
+
+	want = []string{"@@ -2 +2", "- b", "... 3 more lines"}
+	if got := diffLines(a, b, 2); !slices.Equal(got, want) {
+		t.Errorf("truncated: got %q, want %q", got, want)
+	}
+}
+
+func TestFormatOp(t *testing.T) {
+	args := []pdf.Object{pdf.Real(1.0), pdf.Integer(1), pdf.Real(0.333333333), pdf.Real(-0.00001)}
+	if got, want := formatOp("cm", args), "1 1 0.3333 0 cm"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
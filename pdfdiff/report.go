@@ -0,0 +1,134 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdfdiff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Kind describes which aspect of a document differs.
+type Kind string
+
+// These are the kinds of differences reported by [Compare].
+const (
+	KindPage       Kind = "page"
+	KindBox        Kind = "box"
+	KindRotate     Kind = "rotate"
+	KindResource   Kind = "resource"
+	KindFont       Kind = "font"
+	KindAnnotation Kind = "annotation"
+	KindField      Kind = "field"
+	KindContent    Kind = "content"
+	KindText       Kind = "text"
+)
+
+// Change describes how an item differs between the two documents.
+type Change string
+
+// These are the possible values of [Difference.Change].
+const (
+	Added   Change = "added"
+	Removed Change = "removed"
+	Changed Change = "changed"
+)
+
+// Difference is a single difference between two documents.
+type Difference struct {
+	// PageA and PageB are the page numbers, starting at 1, in the first
+	// and in the second document.  A value of 0 indicates that the
+	// difference does not refer to a page of the respective document.
+	PageA int `json:"pageA,omitempty"`
+	PageB int `json:"pageB,omitempty"`
+
+	Kind   Kind   `json:"kind"`
+	Change Change `json:"change"`
+
+	// Item identifies the item which differs, for example the name of a
+	// page box or of a resource.
+	Item string `json:"item,omitempty"`
+
+	// A and B describe the item in the first and in the second document,
+	// where available.
+	A string `json:"a,omitempty"`
+	B string `json:"b,omitempty"`
+
+	// Lines is a line-based diff, for differences in the content stream
+	// and in the text of a page.  Lines starting with "-" are only in the
+	// first document, lines starting with "+" are only in the second
+	// document.  Lines starting with "@@" give the line numbers in the
+	// two documents where the following block of changes starts.
+	Lines []string `json:"lines,omitempty"`
+}
+
+// Report lists the differences between two documents.
+type Report struct {
+	PagesA      int           `json:"pagesA"`
+	PagesB      int           `json:"pagesB"`
+	Differences []*Difference `json:"differences"`
+}
+
+// Equal reports whether no differences were found.
+func (r *Report) Equal() bool {
+	return len(r.Differences) == 0
+}
+
+// WriteText writes the report to w, in human-readable form.
+func (r *Report) WriteText(w io.Writer) error {
+	if r.Equal() {
+		_, err := fmt.Fprintln(w, "no differences")
+		return err
+	}
+
+	for _, d := range r.Differences {
+		var b strings.Builder
+		switch {
+		case d.PageA > 0 && d.PageB > 0 && d.PageA == d.PageB:
+			fmt.Fprintf(&b, "page %d: ", d.PageA)
+		case d.PageA > 0 && d.PageB > 0:
+			fmt.Fprintf(&b, "page %d/%d: ", d.PageA, d.PageB)
+		case d.PageA > 0:
+			fmt.Fprintf(&b, "page %d (a): ", d.PageA)
+		case d.PageB > 0:
+			fmt.Fprintf(&b, "page %d (b): ", d.PageB)
+		}
+		b.WriteString(string(d.Kind))
+		if d.Item != "" {
+			b.WriteString(" " + d.Item)
+		}
+		b.WriteString(" " + string(d.Change))
+		switch {
+		case d.A != "" && d.B != "":
+			fmt.Fprintf(&b, ": %s -> %s", d.A, d.B)
+		case d.A != "":
+			fmt.Fprintf(&b, ": %s", d.A)
+		case d.B != "":
+			fmt.Fprintf(&b, ": %s", d.B)
+		}
+		b.WriteByte('\n')
+		for _, line := range d.Lines {
+			b.WriteString("    " + line + "\n")
+		}
+
+		_, err := io.WriteString(w, b.String())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdfdiff
+
+import (
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/postscript/cid"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/font/textextract"
+	"seehuhn.de/go/pdf/graphics"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/form"
+	"seehuhn.de/go/pdf/reader"
+)
+
+// maxDepth limits the nesting of form XObjects.
+const maxDepth = 32
+
+// textCollector gathers the text of a page, one string per line.
+type textCollector struct {
+	x     *pdf.Extractor
+	lines []string
+	cur   strings.Builder
+	space bool
+
+	extraText map[font.Instance]map[cid.CID]string
+
+	// actual is the replacement text of the current ActualText span.  It
+	// is emitted for the first glyph of the span.
+	actual     string
+	inActual   bool
+	actualDone bool
+}
+
+// run reads a content stream using the reader r.
+func (c *textCollector) run(r *reader.Reader, it content.Iter, depth int) error {
+	r.TextEvent = func(event reader.TextEvent, _ float64) {
+		switch event {
+		case reader.TextEventSpace:
+			c.space = true
+		case reader.TextEventNL:
+			c.newLine()
+		}
+	}
+	r.ActualText = func(event reader.ActualTextEvent, text string) error {
+		switch event {
+		case reader.ActualTextBegin:
+			c.actual, c.inActual, c.actualDone = text, true, false
+		case reader.ActualTextEnd:
+			c.inActual = false
+		}
+		return nil
+	}
+	r.Character = func(code font.Code) error {
+		c.character(r.State.GState, code)
+		return nil
+	}
+	r.XObject = func(obj graphics.XObject, ctm matrix.Matrix) error {
+		f, ok := obj.(*form.Form)
+		if !ok || f.Content == nil || depth >= maxDepth {
+			return nil
+		}
+
+		inner := reader.New(c.x)
+		inner.State = content.NewState(content.Form, f.Res)
+		inner.State.GState.CTM = f.Matrix.Mul(ctm)
+		c.newLine()
+		err := c.run(inner, f.Content.NewIter(), depth+1)
+		c.newLine()
+		return err
+	}
+	return r.ProcessIter(it)
+}
+
+// character adds the text of a glyph to the current line.
+func (c *textCollector) character(gs *graphics.State, code font.Code) {
+	text := code.Text
+	if text == "" && gs.TextFont != nil {
+		m, ok := c.extraText[gs.TextFont]
+		if !ok {
+			m = textextract.GlyphNameMapping(gs.TextFont)
+			c.extraText[gs.TextFont] = m
+		}
+		text = m[code.CID]
+	}
+	if c.inActual {
+		if c.actualDone {
+			return
+		}
+		text = c.actual
+		c.actualDone = true
+	}
+
+	if c.space && c.cur.Len() > 0 {
+		c.cur.WriteByte(' ')
+	}
+	c.space = false
+	c.cur.WriteString(text)
+}
+
+// newLine finishes the current line.  Empty lines are dropped.
+func (c *textCollector) newLine() {
+	line := strings.Join(strings.Fields(c.cur.String()), " ")
+	if line != "" {
+		c.lines = append(c.lines, line)
+	}
+	c.cur.Reset()
+	c.space = false
+}
+
+// formatOp returns a normalised, single-line representation of a content
+// stream operator.
+func formatOp(name content.OpName, args []pdf.Object) string {
+	var b strings.Builder
+	for _, arg := range args {
+		formatArg(&b, arg)
+		b.WriteByte(' ')
+	}
+	b.WriteString(string(name))
+	return b.String()
+}
+
+// formatArg writes an operator argument to b.  Integers and real numbers
+// are written in the same format, rounded to four decimal places.
+func formatArg(b *strings.Builder, obj pdf.Object) {
+	switch obj := obj.(type) {
+	case pdf.Integer:
+		b.WriteString(strconv.FormatInt(int64(obj), 10))
+	case pdf.Real:
+		b.WriteString(formatNumber(float64(obj)))
+	case pdf.Number:
+		b.WriteString(formatNumber(float64(obj)))
+	case pdf.Array:
+		b.WriteByte('[')
+		for i, elem := range obj {
+			if i > 0 {
+				b.WriteByte(' ')
+			}
+			formatArg(b, elem)
+		}
+		b.WriteByte(']')
+	case pdf.Dict:
+		b.WriteString("<<")
+		for _, key := range slices.Sorted(maps.Keys(obj)) {
+			b.WriteString(pdf.AsString(key) + " ")
+			formatArg(b, obj[key])
+			b.WriteByte(' ')
+		}
+		b.WriteString(">>")
+	case nil:
+		b.WriteString("null")
+	default:
+		b.WriteString(pdf.AsString(obj))
+	}
+}
+
+func formatNumber(x float64) string {
+	x = math.Round(x*1e4) / 1e4
+	if x == 0 {
+		x = 0 // avoid "-0"
+	}
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
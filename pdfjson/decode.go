@@ -0,0 +1,139 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdfjson
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"seehuhn.de/go/pdf"
+)
+
+// Decode reads the JSON representation of a PDF file from r, and writes
+// the corresponding PDF file to w.  Objects are written at the object
+// numbers given in the JSON data.  Decoded streams are compressed using
+// FlateDecode.  The output is not encrypted.
+func Decode(w io.Writer, r io.Reader) error {
+	f := &File{}
+	err := json.NewDecoder(r).Decode(f)
+	if err != nil {
+		return err
+	}
+	return Write(w, f)
+}
+
+// Write writes the PDF file described by f to w.
+func Write(w io.Writer, f *File) error {
+	v, err := pdf.ParseVersion(f.Version)
+	if err != nil {
+		return err
+	}
+	trailer, ok := f.Trailer.Native.(pdf.Dict)
+	if !ok {
+		return errors.New("trailer is not a dictionary")
+	}
+	root, ok := trailer["Root"].(pdf.Reference)
+	if !ok {
+		return errors.New("missing or invalid /Root in trailer")
+	}
+	info, _ := trailer["Info"].(pdf.Reference)
+
+	opt := &pdf.WriterOptions{}
+	if id, ok := trailer["ID"].(pdf.Array); ok && len(id) == 2 {
+		id0, ok0 := id[0].(pdf.String)
+		id1, ok1 := id[1].(pdf.String)
+		if ok0 && ok1 {
+			opt.ID = [][]byte{id0, id1}
+		}
+	}
+
+	out, err := pdf.NewWriter(w, v, opt)
+	if err != nil {
+		return err
+	}
+	out.SetTrailerRefs(root, info)
+	for key, val := range trailer {
+		switch key {
+		case "Root", "Info", "ID":
+			// set above
+		default:
+			out.GetMeta().Trailer[key] = val
+		}
+	}
+
+	// The writer allocates object numbers for stream lengths, object
+	// streams and the cross-reference stream.  These must not clash with
+	// the object numbers from the JSON data.
+	refs := make([]pdf.Reference, len(f.Objects))
+	for i, obj := range f.Objects {
+		if obj.Error != "" {
+			continue
+		}
+		ref, ok := parseRef(obj.Ref)
+		if !ok {
+			return fmt.Errorf("invalid reference %q", obj.Ref)
+		}
+		refs[i] = ref
+		out.Reserve(ref)
+	}
+
+	for i, obj := range f.Objects {
+		if obj.Error != "" {
+			continue
+		}
+		ref := refs[i]
+		switch {
+		case obj.Stream != nil:
+			err = writeStream(out, ref, obj.Stream)
+		case obj.Value != nil:
+			err = out.Put(ref, obj.Value.Native)
+		default:
+			err = fmt.Errorf("object %s has no value", obj.Ref)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return out.Close()
+}
+
+func writeStream(w *pdf.Writer, ref pdf.Reference, s *Stream) error {
+	if s.Data == nil {
+		return fmt.Errorf("stream %s: data omitted", formatRef(ref))
+	}
+	dict, ok := s.Dict.Native.(pdf.Dict)
+	if !ok {
+		return fmt.Errorf("stream %s: dictionary expected", formatRef(ref))
+	}
+
+	var filters []pdf.Filter
+	if s.Decoded {
+		filters = append(filters, pdf.FilterFlate{})
+	}
+	stm, err := w.OpenStream(ref, dict, filters...)
+	if err != nil {
+		return err
+	}
+	_, err = stm.Write(s.Data)
+	if err != nil {
+		return err
+	}
+	return stm.Close()
+}
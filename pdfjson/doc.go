@@ -0,0 +1,58 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package pdfjson converts PDF files to and from a JSON representation.
+//
+// [Encode] writes all indirect objects of a PDF file, together with the
+// trailer dictionary and the cross-reference information, as a JSON
+// document.  [Decode] reads such a JSON document and writes a PDF file
+// which contains the same objects, at the same object numbers.  The JSON
+// form is intended for debugging and for comparing PDF files using
+// text-based tools.
+//
+// The top-level JSON object has the following fields:
+//
+//   - "version": the PDF version, for example "1.7"
+//   - "encryption": the encryption of the original file, for information
+//     only.  The JSON representation is always decrypted.
+//   - "trailer": the trailer dictionary
+//   - "objects": an array of the indirect objects, ordered by object number
+//
+// Each entry of "objects" has a "ref" field, giving the reference in the
+// form "12 0 R", and an "xref" field, which describes where the object was
+// stored in the original file.  Ordinary objects have a "value" field.
+// Streams have a "stream" field instead, which contains the stream
+// dictionary ("dict") and the base64-encoded stream data ("data").  If
+// "decoded" is true, all filters have been removed from the stream data.
+// Objects which could not be read have an "error" field.
+//
+// PDF objects are represented as follows:
+//
+//   - null, booleans, integers and real numbers use the corresponding JSON
+//     values.  Real numbers always contain a decimal point or an exponent.
+//   - Names are written as "/Name".  Names which are not valid UTF-8 are
+//     written as "n:" followed by the name in hexadecimal.
+//   - Strings are written as "u:" followed by the text, if the string is a
+//     text string which can be converted back without change.  Otherwise,
+//     strings are written as "b:" followed by the string in hexadecimal.
+//   - References are written as "12 0 R".
+//   - Arrays and dictionaries use JSON arrays and objects.  The keys of
+//     dictionaries are written like names.
+//
+// Cross-reference streams, object streams and the encryption dictionary
+// are not included in the JSON representation, since [Decode] creates
+// these as needed.
+package pdfjson
@@ -0,0 +1,226 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdfjson
+
+import (
+	"encoding/json"
+	"io"
+	"maps"
+
+	"seehuhn.de/go/pdf"
+)
+
+// File is the JSON representation of a PDF file.
+type File struct {
+	Version    string   `json:"version"`
+	Encryption string   `json:"encryption,omitempty"`
+	Trailer    Value    `json:"trailer"`
+	Objects    []Object `json:"objects"`
+}
+
+// Object is the JSON representation of an indirect object.
+// Exactly one of Value, Stream and Error is set.
+type Object struct {
+	Ref    string    `json:"ref"`
+	XRef   *Location `json:"xref,omitempty"`
+	Value  *Value    `json:"value,omitempty"`
+	Stream *Stream   `json:"stream,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// Location describes where an object was stored in the original file.
+type Location struct {
+	// Offset is the byte offset of the object in the file.
+	Offset *int64 `json:"offset,omitempty"`
+
+	// ObjStm is the object stream which contains the object, and Index is
+	// the index of the object within the object stream.
+	ObjStm string `json:"objstm,omitempty"`
+	Index  *int64 `json:"index,omitempty"`
+}
+
+// Stream is the JSON representation of a stream object.
+type Stream struct {
+	Dict Value `json:"dict"`
+
+	// Data is the stream data, or nil if the data was omitted.
+	Data []byte `json:"data"`
+
+	// Decoded is true if all filters have been removed from the data.
+	// In this case, the stream dictionary contains no /Filter and
+	// /DecodeParms entries.
+	Decoded bool `json:"decoded,omitempty"`
+}
+
+// StreamData selects how stream data is represented by [Encode].
+type StreamData int
+
+const (
+	// DataRaw includes the stream data as stored in the file, with all
+	// filters except for encryption still applied.
+	DataRaw StreamData = iota
+
+	// DataDecoded includes the decoded stream data.  Streams which cannot
+	// be decoded are included as for DataRaw.
+	DataDecoded
+
+	// DataNone omits the stream data.  JSON files written with this
+	// setting cannot be converted back to PDF.
+	DataNone
+)
+
+// Options controls the output of [Encode].
+type Options struct {
+	Data StreamData
+
+	// Compact disables indentation of the JSON output.
+	Compact bool
+}
+
+// trailerSkip lists trailer entries which describe the layout of the
+// original file.  These are not included in the JSON representation.
+var trailerSkip = []pdf.Name{
+	"Size", "Prev", "XRefStm", "Encrypt",
+	"Type", "W", "Index", "Length", "Filter", "DecodeParms",
+}
+
+// Encode writes the JSON representation of the PDF file r to w.
+// If r is encrypted, it must have been opened with a valid password.
+func Encode(w io.Writer, r *pdf.Reader, opt *Options) error {
+	f, err := Convert(r, opt)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	if opt == nil || !opt.Compact {
+		enc.SetIndent("", "  ")
+	}
+	return enc.Encode(f)
+}
+
+// Convert returns the JSON representation of the PDF file r.
+// Only the Data field of opt is used.
+func Convert(r *pdf.Reader, opt *Options) (*File, error) {
+	if opt == nil {
+		opt = &Options{}
+	}
+	meta := r.GetMeta()
+
+	version, err := meta.Version.ToString()
+	if err != nil {
+		return nil, err
+	}
+	f := &File{Version: version}
+	if meta.Encryption != nil {
+		f.Encryption = meta.Encryption.String()
+	}
+
+	trailer := maps.Clone(meta.Trailer)
+	for _, key := range trailerSkip {
+		delete(trailer, key)
+	}
+	f.Trailer = Value{trailer}
+	encRef, _ := meta.Trailer["Encrypt"].(pdf.Reference)
+
+	for ref, loc := range r.Objects() {
+		if ref == encRef {
+			continue
+		}
+		obj := Object{Ref: formatRef(ref), XRef: newLocation(loc)}
+
+		val, err := r.Get(ref, true)
+		if pdf.IsReadError(err) {
+			return nil, err
+		} else if err != nil {
+			obj.Error = err.Error()
+			f.Objects = append(f.Objects, obj)
+			continue
+		}
+
+		if stm, isStream := val.(*pdf.Stream); isStream {
+			if tp, _ := stm.Dict["Type"].(pdf.Name); tp == "XRef" || tp == "ObjStm" {
+				continue
+			}
+			obj.Stream, err = convertStream(r, stm, opt.Data)
+			if pdf.IsReadError(err) {
+				return nil, err
+			} else if err != nil {
+				obj.Error = err.Error()
+			}
+		} else {
+			obj.Value = &Value{val}
+		}
+		f.Objects = append(f.Objects, obj)
+	}
+
+	return f, nil
+}
+
+func newLocation(loc pdf.ObjectLocation) *Location {
+	if loc.ObjStm != 0 {
+		return &Location{ObjStm: formatRef(loc.ObjStm), Index: &loc.Offset}
+	}
+	return &Location{Offset: &loc.Offset}
+}
+
+// convertStream returns the JSON representation of a stream.
+func convertStream(r *pdf.Reader, stm *pdf.Stream, mode StreamData) (*Stream, error) {
+	dict := maps.Clone(stm.Dict)
+	delete(dict, "Length")
+
+	switch mode {
+	case DataNone:
+		return &Stream{Dict: Value{dict}}, nil
+	case DataDecoded:
+		body, err := pdf.DecodeStream(r, nil, stm)
+		if err == nil {
+			var data []byte
+			data, err = io.ReadAll(body)
+			body.Close()
+			if err == nil {
+				delete(dict, "Filter")
+				delete(dict, "DecodeParms")
+				delete(dict, "DL")
+				return &Stream{Dict: Value{dict}, Data: data, Decoded: true}, nil
+			}
+		}
+		if pdf.IsReadError(err) {
+			return nil, err
+		}
+		// fall back to the raw stream data
+	}
+
+	body, err := pdf.RawStreamReader(r, stm)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, err
+	}
+	if r.GetMeta().Encryption != nil {
+		// The data has been decrypted, so an explicit crypt filter must
+		// be removed from the filter list.
+		if err := pdf.DropCryptFilter(r, dict); err != nil {
+			return nil, err
+		}
+	}
+	return &Stream{Dict: Value{dict}, Data: data}, nil
+}
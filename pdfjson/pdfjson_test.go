@@ -0,0 +1,380 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdfjson
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"seehuhn.de/go/pdf"
+)
+
+func TestValueRoundTrip(t *testing.T) {
+	cases := []struct {
+		obj  pdf.Native
+		json string
+	}{
+		{nil, `null`},
+		{pdf.Boolean(true), `true`},
+		{pdf.Integer(-7), `-7`},
+		{pdf.Real(2), `2.0`},
+		{pdf.Real(0.25), `0.25`},
+		{pdf.Real(1e30), `1e+30`},
+		{pdf.Name("Type"), `"/Type"`},
+		{pdf.Name("A B"), `"/A B"`},
+		{pdf.Name("\xff"), `"n:ff"`},
+		{pdf.String("Hello"), `"u:Hello"`},
+		{pdf.TextString("Grüße ☺").AsPDF(0), `"u:Grüße ☺"`},
+		{pdf.String("\x00\x01\xff"), `"b:0001ff"`},
+		{pdf.String("u:x"), `"u:u:x"`},
+		{pdf.NewReference(12, 3), `"12 3 R"`},
+		{pdf.Array{pdf.Integer(1), nil, pdf.Name("X")}, `[1,null,"/X"]`},
+		{pdf.Dict{"B": pdf.Integer(2), "A": pdf.Array{}}, `{"/A":[],"/B":2}`},
+	}
+	for _, tc := range cases {
+		data, err := json.Marshal(Value{tc.obj})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != tc.json {
+			t.Errorf("%v: got %s, want %s", tc.obj, data, tc.json)
+		}
+
+		var v Value
+		err = json.Unmarshal(data, &v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := cmp.Diff(tc.obj, v.Native); d != "" {
+			t.Errorf("%s: round trip failed (-want +got):\n%s", tc.json, d)
+		}
+	}
+}
+
+func TestInvalidValues(t *testing.T) {
+	for _, in := range []string{`"text"`, `"b:xyz"`, `{"Type":1}`, `1.2.3`} {
+		var v Value
+		if err := json.Unmarshal([]byte(in), &v); err == nil {
+			t.Errorf("%s: expected an error", in)
+		}
+	}
+}
+
+// writeTestFile writes a small PDF file with a content stream and an
+// object stream.
+func writeTestFile(t *testing.T, opt *pdf.WriterOptions) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	w, err := pdf.NewWriter(buf, pdf.V1_7, opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pagesRef := w.Alloc()
+	pageRef := w.Alloc()
+	contentRef := w.Alloc()
+	extraRef := w.Alloc()
+
+	stm, err := w.OpenStream(contentRef, nil, pdf.FilterFlate{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stm.Write([]byte("0 0 m 10 10 l S")); err != nil {
+		t.Fatal(err)
+	}
+	if err := stm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	page := pdf.Dict{
+		"Type":     pdf.Name("Page"),
+		"Parent":   pagesRef,
+		"MediaBox": pdf.Array{pdf.Integer(0), pdf.Integer(0), pdf.Real(595.5), pdf.Integer(842)},
+		"Contents": contentRef,
+		"Extra":    extraRef,
+	}
+	pages := pdf.Dict{
+		"Type":  pdf.Name("Pages"),
+		"Kids":  pdf.Array{pageRef},
+		"Count": pdf.Integer(1),
+	}
+	extra := pdf.Dict{
+		"Text":   pdf.String("Hello"),
+		"Binary": pdf.String("\x00\x80\xff"),
+		"Flag":   pdf.Boolean(false),
+		"Null":   nil,
+	}
+	err = w.WriteCompressed([]pdf.Reference{pagesRef, pageRef, extraRef}, pages, page, extra)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.GetMeta().Catalog.Pages = pagesRef
+	w.GetMeta().Info = &pdf.Info{Title: "JSON Test"}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func openTestFile(t *testing.T, data []byte, passwd string) *pdf.Reader {
+	t.Helper()
+	var opt *pdf.ReaderOptions
+	if passwd != "" {
+		opt = &pdf.ReaderOptions{Password: passwd}
+	}
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)), opt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// objectsByRef returns the values and streams of f, without the location
+// information.
+func objectsByRef(f *File) map[string]Object {
+	res := make(map[string]Object)
+	for _, obj := range f.Objects {
+		obj.XRef = nil
+		res[obj.Ref] = obj
+	}
+	return res
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, mode := range []StreamData{DataRaw, DataDecoded} {
+		data := writeTestFile(t, nil)
+		r := openTestFile(t, data, "")
+		f1, err := Convert(r, &Options{Data: mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		jsonBuf := &bytes.Buffer{}
+		if err := Encode(jsonBuf, r, &Options{Data: mode}); err != nil {
+			t.Fatal(err)
+		}
+		pdfBuf := &bytes.Buffer{}
+		if err := Decode(pdfBuf, jsonBuf); err != nil {
+			t.Fatal(err)
+		}
+
+		r2 := openTestFile(t, pdfBuf.Bytes(), "")
+		f2, err := Convert(r2, &Options{Data: mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if d := cmp.Diff(objectsByRef(f1), objectsByRef(f2), cmp.Comparer(valuesEqual)); d != "" {
+			t.Errorf("mode %d: objects differ (-want +got):\n%s", mode, d)
+		}
+		if d := cmp.Diff(r.GetMeta().ID, r2.GetMeta().ID); d != "" {
+			t.Errorf("mode %d: IDs differ:\n%s", mode, d)
+		}
+		if r2.GetMeta().Info == nil || r2.GetMeta().Info.Title != "JSON Test" {
+			t.Errorf("mode %d: Info not preserved", mode)
+		}
+	}
+}
+
+// TestRoundTripObjectStreams checks that a PDF 2.0 file with several
+// object streams can be written back from its JSON representation.  The
+// object numbers allocated by the writer must not clash with the object
+// numbers from the JSON data.
+func TestRoundTripObjectStreams(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := pdf.NewWriter(buf, pdf.V2_0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pagesRef := w.Alloc()
+	var kids pdf.Array
+	for i := range 3 {
+		pageRef := w.Alloc()
+		contentRef := w.Alloc()
+		fontRef := w.Alloc()
+		stm, err := w.OpenStream(contentRef, nil, pdf.FilterFlate{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fmt.Fprintf(stm, "BT /F1 12 Tf 72 %d Td (page %d) Tj ET\n", 700-10*i, i+1); err != nil {
+			t.Fatal(err)
+		}
+		// Long streams are written before their length is known, so the
+		// writer allocates an indirect /Length object for them.
+		rng := rand.New(rand.NewPCG(1, uint64(i)))
+		for range 500 {
+			_, err := fmt.Fprintf(stm, "%d %d m %d %d l S\n",
+				rng.IntN(200), rng.IntN(200), rng.IntN(200), rng.IntN(200))
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := stm.Close(); err != nil {
+			t.Fatal(err)
+		}
+		page := pdf.Dict{
+			"Type":      pdf.Name("Page"),
+			"Parent":    pagesRef,
+			"MediaBox":  pdf.Array{pdf.Integer(0), pdf.Integer(0), pdf.Integer(200), pdf.Integer(200)},
+			"Contents":  contentRef,
+			"Resources": pdf.Dict{"Font": pdf.Dict{"F1": fontRef}},
+		}
+		font := pdf.Dict{
+			"Type":     pdf.Name("Font"),
+			"Subtype":  pdf.Name("Type1"),
+			"BaseFont": pdf.Name("Helvetica"),
+		}
+		err = w.WriteCompressed([]pdf.Reference{pageRef, fontRef}, page, font)
+		if err != nil {
+			t.Fatal(err)
+		}
+		kids = append(kids, pageRef)
+	}
+	pages := pdf.Dict{
+		"Type":  pdf.Name("Pages"),
+		"Kids":  kids,
+		"Count": pdf.Integer(len(kids)),
+	}
+	if err := w.WriteCompressed([]pdf.Reference{pagesRef}, pages); err != nil {
+		t.Fatal(err)
+	}
+	w.GetMeta().Catalog.Pages = pagesRef
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r := openTestFile(t, buf.Bytes(), "")
+	for _, mode := range []StreamData{DataRaw, DataDecoded} {
+		f1, err := Convert(r, &Options{Data: mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		jsonBuf := &bytes.Buffer{}
+		if err := Encode(jsonBuf, r, &Options{Data: mode}); err != nil {
+			t.Fatal(err)
+		}
+		pdfBuf := &bytes.Buffer{}
+		if err := Decode(pdfBuf, jsonBuf); err != nil {
+			t.Fatalf("mode %d: %v", mode, err)
+		}
+
+		r2 := openTestFile(t, pdfBuf.Bytes(), "")
+		if v := r2.GetMeta().Version; v != pdf.V2_0 {
+			t.Errorf("mode %d: version %s, want 2.0", mode, v)
+		}
+		f2, err := Convert(r2, &Options{Data: mode})
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The writer adds new /Length objects, so only the objects of the
+		// original file are compared.
+		got := objectsByRef(f2)
+		for ref, want := range objectsByRef(f1) {
+			if d := cmp.Diff(want, got[ref], cmp.Comparer(valuesEqual)); d != "" {
+				t.Errorf("mode %d: object %s differs (-want +got):\n%s", mode, ref, d)
+			}
+		}
+	}
+}
+
+func valuesEqual(a, b Value) bool {
+	da, err1 := json.Marshal(a)
+	db, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && bytes.Equal(da, db)
+}
+
+func TestEncodeDetails(t *testing.T) {
+	data := writeTestFile(t, &pdf.WriterOptions{
+		UserPassword:  "secret",
+		OwnerPassword: "owner",
+	})
+	r := openTestFile(t, data, "secret")
+
+	f, err := Convert(r, &Options{Data: DataDecoded})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Encryption != "AES-128" {
+		t.Errorf("wrong encryption %q", f.Encryption)
+	}
+	trailer := f.Trailer.Native.(pdf.Dict)
+	for _, key := range []pdf.Name{"Encrypt", "Size", "Root"} {
+		_, has := trailer[key]
+		if has != (key == "Root") {
+			t.Errorf("trailer entry /%s: present=%t", key, has)
+		}
+	}
+
+	var sawObjStm, sawContent bool
+	for _, obj := range f.Objects {
+		if obj.XRef.ObjStm != "" {
+			sawObjStm = true
+		}
+		if obj.Stream != nil && string(obj.Stream.Data) == "0 0 m 10 10 l S" {
+			sawContent = obj.Stream.Decoded
+		}
+		if obj.Value != nil {
+			dict, _ := obj.Value.Native.(pdf.Dict)
+			if dict["Binary"] != nil && dict["Binary"].(pdf.String)[1] != 0x80 {
+				t.Error("string not decrypted")
+			}
+		}
+	}
+	if !sawObjStm {
+		t.Error("no objects from object streams")
+	}
+	if !sawContent {
+		t.Error("decoded content stream not found")
+	}
+
+	// The decrypted JSON can be converted back into an unencrypted file.
+	jsonBuf := &bytes.Buffer{}
+	if err := json.NewEncoder(jsonBuf).Encode(f); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(jsonBuf.String(), `"u:Hello"`) {
+		t.Error("text string not readable")
+	}
+	pdfBuf := &bytes.Buffer{}
+	if err := Decode(pdfBuf, jsonBuf); err != nil {
+		t.Fatal(err)
+	}
+	r2 := openTestFile(t, pdfBuf.Bytes(), "")
+	if r2.GetMeta().Encryption != nil {
+		t.Error("output is encrypted")
+	}
+}
+
+func TestDecodeOmittedData(t *testing.T) {
+	data := writeTestFile(t, nil)
+	jsonBuf := &bytes.Buffer{}
+	err := Encode(jsonBuf, openTestFile(t, data, ""), &Options{Data: DataNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Decode(&bytes.Buffer{}, jsonBuf)
+	if err == nil || !strings.Contains(err.Error(), "data omitted") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
@@ -0,0 +1,272 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdfjson
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"seehuhn.de/go/pdf"
+)
+
+// Value is a PDF object which can be converted to and from JSON.
+// Streams cannot be represented as a Value.
+type Value struct {
+	pdf.Native
+}
+
+// MarshalJSON implements the [json.Marshaler] interface.
+func (v Value) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	err := encodeValue(buf, v.Native)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements the [json.Unmarshaler] interface.
+func (v *Value) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var raw any
+	err := dec.Decode(&raw)
+	if err != nil {
+		return err
+	}
+	obj, err := decodeValue(raw)
+	if err != nil {
+		return err
+	}
+	v.Native = obj
+	return nil
+}
+
+func encodeValue(buf *bytes.Buffer, obj pdf.Native) error {
+	switch obj := obj.(type) {
+	case nil:
+		buf.WriteString("null")
+	case pdf.Boolean:
+		buf.WriteString(strconv.FormatBool(bool(obj)))
+	case pdf.Integer:
+		buf.WriteString(strconv.FormatInt(int64(obj), 10))
+	case pdf.Real:
+		x := float64(obj)
+		if math.IsNaN(x) || math.IsInf(x, 0) {
+			return fmt.Errorf("invalid real number %g", x)
+		}
+		s := strconv.FormatFloat(x, 'g', -1, 64)
+		if !strings.ContainsAny(s, ".e") {
+			s += ".0"
+		}
+		buf.WriteString(s)
+	case pdf.Name:
+		writeJSONString(buf, encodeName(obj))
+	case pdf.String:
+		writeJSONString(buf, encodeString(obj))
+	case pdf.Reference:
+		writeJSONString(buf, formatRef(obj))
+	case pdf.Array:
+		buf.WriteByte('[')
+		for i, elem := range obj {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			err := encodeValue(buf, native(elem))
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case pdf.Dict:
+		buf.WriteByte('{')
+		for i, key := range slices.Sorted(maps.Keys(obj)) {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			writeJSONString(buf, encodeName(key))
+			buf.WriteByte(':')
+			err := encodeValue(buf, native(obj[key]))
+			if err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	case *pdf.Stream:
+		return errors.New("unexpected stream object")
+	default:
+		return fmt.Errorf("unsupported object type %T", obj)
+	}
+	return nil
+}
+
+// native converts obj to a native PDF object.
+func native(obj pdf.Object) pdf.Native {
+	if obj == nil {
+		return nil
+	}
+	return obj.AsPDF(0)
+}
+
+func writeJSONString(buf *bytes.Buffer, s string) {
+	data, _ := json.Marshal(s) // cannot fail for strings
+	buf.Write(data)
+}
+
+// encodeName returns the JSON representation of a name.
+func encodeName(name pdf.Name) string {
+	if utf8.ValidString(string(name)) && !strings.ContainsFunc(string(name), unicode.IsControl) {
+		return "/" + string(name)
+	}
+	return "n:" + hex.EncodeToString([]byte(name))
+}
+
+// encodeString returns the JSON representation of a string.  Text strings
+// are written in readable form, if they can be converted back to the same
+// bytes.
+func encodeString(s pdf.String) string {
+	text := string(s.AsTextString())
+	if isReadable(text) && bytes.Equal(pdf.TextString(text).AsPDF(0).(pdf.String), s) {
+		return "u:" + text
+	}
+	return "b:" + hex.EncodeToString(s)
+}
+
+// isReadable reports whether text is suitable for the "u:" form.
+func isReadable(text string) bool {
+	for _, r := range text {
+		if r == utf8.RuneError || unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+			return false
+		}
+	}
+	return true
+}
+
+func formatRef(ref pdf.Reference) string {
+	return fmt.Sprintf("%d %d R", ref.Number(), ref.Generation())
+}
+
+var refRegexp = regexp.MustCompile(`^(\d+) (\d+) R$`)
+
+// parseRef parses a reference of the form "12 0 R".
+func parseRef(s string) (pdf.Reference, bool) {
+	m := refRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+	number, err1 := strconv.ParseUint(m[1], 10, 32)
+	gen, err2 := strconv.ParseUint(m[2], 10, 16)
+	if err1 != nil || err2 != nil {
+		return 0, false
+	}
+	return pdf.NewReference(uint32(number), uint16(gen)), true
+}
+
+// decodeName converts the JSON representation of a name back to a name.
+func decodeName(s string) (pdf.Name, error) {
+	switch {
+	case strings.HasPrefix(s, "/"):
+		return pdf.Name(s[1:]), nil
+	case strings.HasPrefix(s, "n:"):
+		data, err := hex.DecodeString(s[2:])
+		if err != nil {
+			return "", fmt.Errorf("invalid name %q", s)
+		}
+		return pdf.Name(data), nil
+	default:
+		return "", fmt.Errorf("invalid name %q", s)
+	}
+}
+
+// decodeValue converts a value decoded by encoding/json, with UseNumber
+// set, into a PDF object.
+func decodeValue(raw any) (pdf.Native, error) {
+	switch raw := raw.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		return pdf.Boolean(raw), nil
+	case json.Number:
+		s := string(raw)
+		if strings.ContainsAny(s, ".eE") {
+			x, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid number %q", s)
+			}
+			return pdf.Real(x), nil
+		}
+		x, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid integer %q", s)
+		}
+		return pdf.Integer(x), nil
+	case string:
+		switch {
+		case strings.HasPrefix(raw, "/"), strings.HasPrefix(raw, "n:"):
+			return decodeName(raw)
+		case strings.HasPrefix(raw, "u:"):
+			return pdf.TextString(raw[2:]).AsPDF(0), nil
+		case strings.HasPrefix(raw, "b:"):
+			data, err := hex.DecodeString(raw[2:])
+			if err != nil {
+				return nil, fmt.Errorf("invalid string %q", raw)
+			}
+			return pdf.String(data), nil
+		}
+		if ref, ok := parseRef(raw); ok {
+			return ref, nil
+		}
+		return nil, fmt.Errorf("invalid value %q", raw)
+	case []any:
+		res := make(pdf.Array, len(raw))
+		for i, elem := range raw {
+			obj, err := decodeValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = obj
+		}
+		return res, nil
+	case map[string]any:
+		res := make(pdf.Dict, len(raw))
+		for key, elem := range raw {
+			name, err := decodeName(key)
+			if err != nil {
+				return nil, err
+			}
+			obj, err := decodeValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			res[name] = obj
+		}
+		return res, nil
+	default:
+		return nil, fmt.Errorf("unexpected JSON value of type %T", raw)
+	}
+}
@@ -0,0 +1,216 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdflint
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/content"
+)
+
+// maxStreamProblems is the maximum number of problems reported for a
+// single content stream.
+const maxStreamProblems = 20
+
+// checkContent scans a content stream.  If res is nil for a content stream
+// other than a page, the use of named resources is not checked, since such
+// streams may use the resources of the page they are drawn on.
+func (l *linter) checkContent(ct content.Type, res pdf.Object, open func() (io.ReadCloser, error), pageNo int, ref pdf.Reference, path []string) error {
+	cc := &contentChecker{
+		l:      l,
+		pageNo: pageNo,
+		ref:    ref,
+		path:   path,
+	}
+
+	if res != nil || ct == content.Page {
+		resDict, err := l.c.Dict(res)
+		if pdf.IsReadError(err) {
+			return err
+		} else if err == nil {
+			cc.res = resDict
+			cc.sub = make(map[pdf.Name]pdf.Dict)
+			cc.checkNames = true
+		}
+	}
+
+	state := content.NewState(ct, nil)
+	state.Version = l.version
+
+	iter := content.NewScanner(open).NewIter()
+	n := 0
+	for op, args := range iter.All() {
+		n++
+		cc.n, cc.op = n, op
+
+		err := content.CheckOperatorVersion(op, l.version)
+		switch {
+		case errors.Is(err, content.ErrUnknown):
+			if !state.InCompatibilitySection() {
+				cc.report(Warning, err)
+			}
+		case errors.Is(err, content.ErrVersion):
+			cc.report(Warning, fmt.Errorf("%w %s", err, l.version))
+		case err != nil:
+			cc.report(Warning, err)
+		}
+
+		if err := state.CheckOperatorAllowed(op); err != nil {
+			cc.report(Error, err)
+		}
+
+		if err := cc.checkResource(op, args); err != nil {
+			return err
+		}
+
+		if err := state.ApplyStateChanges(op, args); err != nil {
+			cc.report(Error, err)
+		}
+
+		if cc.count >= maxStreamProblems || l.full() {
+			l.addPage(Warning, pageNo, ref, path,
+				errors.New("too many problems, remaining operators not checked"))
+			return nil
+		}
+	}
+	if err := iter.Err(); pdf.IsReadError(err) {
+		return err
+	} else if err != nil {
+		l.addPage(Error, pageNo, ref, path, err)
+	}
+
+	if err := state.CanClose(); err != nil {
+		l.addPage(Warning, pageNo, ref, path, fmt.Errorf("at end of stream: %w", err))
+	}
+	return nil
+}
+
+// checkGlyphs checks the glyph descriptions of a Type 3 font.
+func (l *linter) checkGlyphs(fontDict pdf.Dict, ref pdf.Reference, path []string) error {
+	procs, err := l.c.Dict(fontDict["CharProcs"])
+	if pdf.IsReadError(err) {
+		return err
+	} else if err != nil {
+		// already reported by the font decoder
+		return nil
+	}
+	for _, name := range procs.SortedKeys() {
+		stm, err := l.c.Stream(procs[name])
+		if pdf.IsReadError(err) {
+			return err
+		} else if err != nil || stm == nil {
+			continue
+		}
+		glyphPath := extend(path, "/CharProcs", formatKey(name))
+		err = l.checkContent(content.Glyph, fontDict["Resources"], l.streamOpener(stm), 0, ref, glyphPath)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// contentChecker holds the state for checking a single content stream.
+type contentChecker struct {
+	l      *linter
+	pageNo int
+	ref    pdf.Reference
+	path   []string
+
+	// checkNames indicates whether named resources are checked.
+	checkNames bool
+	res        pdf.Dict
+	sub        map[pdf.Name]pdf.Dict
+
+	n     int // 1-based index of the current operator
+	op    content.OpName
+	count int // number of problems reported for this stream
+}
+
+func (cc *contentChecker) report(sev Severity, err error) {
+	cc.count++
+	err = fmt.Errorf("operator %d (%s): %w", cc.n, cc.op, err)
+	cc.l.addPage(sev, cc.pageNo, cc.ref, cc.path, err)
+}
+
+// checkResource verifies that the named resources used by an operator
+// exist.  An error is only returned if the file could not be read.
+func (cc *contentChecker) checkResource(op content.OpName, args []pdf.Object) error {
+	var category pdf.Name
+	var name pdf.Object
+	switch op {
+	case content.OpTextSetFont:
+		category, name = "Font", arg(args, 0)
+	case content.OpXObject:
+		category, name = "XObject", arg(args, 0)
+	case content.OpSetExtGState:
+		category, name = "ExtGState", arg(args, 0)
+	case content.OpShading:
+		category, name = "Shading", arg(args, 0)
+	case content.OpSetFillColorSpace, content.OpSetStrokeColorSpace:
+		switch arg(args, 0) {
+		case pdf.Name("DeviceGray"), pdf.Name("DeviceRGB"), pdf.Name("DeviceCMYK"), pdf.Name("Pattern"):
+			return nil
+		}
+		category, name = "ColorSpace", arg(args, 0)
+	case content.OpSetFillColorN, content.OpSetStrokeColorN:
+		if _, isName := arg(args, len(args)-1).(pdf.Name); !isName {
+			return nil
+		}
+		category, name = "Pattern", args[len(args)-1]
+	case content.OpBeginMarkedContentWithProperties, content.OpMarkedContentPointWithProperties:
+		if _, isName := arg(args, 1).(pdf.Name); !isName {
+			return nil // inline property list
+		}
+		category, name = "Properties", args[1]
+	default:
+		return nil
+	}
+
+	key, ok := name.(pdf.Name)
+	if !ok {
+		cc.report(Error, errors.New("missing resource name"))
+		return nil
+	}
+	if !cc.checkNames {
+		return nil
+	}
+
+	sub, seen := cc.sub[category]
+	if !seen {
+		var err error
+		sub, err = cc.l.c.Dict(cc.res[category])
+		if pdf.IsReadError(err) {
+			return err
+		}
+		cc.sub[category] = sub
+	}
+	if _, exists := sub[key]; !exists {
+		cc.report(Error, fmt.Errorf("resource %s %s not found", category, pdf.AsString(key)))
+	}
+	return nil
+}
+
+func arg(args []pdf.Object, i int) pdf.Object {
+	if i < 0 || i >= len(args) {
+		return nil
+	}
+	return args[i]
+}
@@ -0,0 +1,87 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdflint
+
+import (
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/action"
+	"seehuhn.de/go/pdf/annotation"
+	"seehuhn.de/go/pdf/annotation/decode"
+	"seehuhn.de/go/pdf/collection"
+	"seehuhn.de/go/pdf/file"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/font/cmap"
+	"seehuhn.de/go/pdf/graphics/extract"
+	"seehuhn.de/go/pdf/graphics/group"
+	"seehuhn.de/go/pdf/graphics/halftone"
+	"seehuhn.de/go/pdf/oc"
+	"seehuhn.de/go/pdf/outline"
+	"seehuhn.de/go/pdf/sound"
+)
+
+// decodeFunc decodes obj into its Go representation.
+type decodeFunc func(c pdf.Cursor, obj pdf.Object) (any, error)
+
+func decoder[T any](fn func(pdf.Cursor, pdf.Object, bool) (T, error)) decodeFunc {
+	return func(c pdf.Cursor, obj pdf.Object) (any, error) {
+		return pdf.Decode(c, obj, fn)
+	}
+}
+
+// typeDecoders lists the decoders for dictionaries and streams, by the value
+// of the Type entry.
+//
+// Page dictionaries are not included here, since they need to be decoded
+// with the inherited attributes from the page tree.  See [linter.checkPages].
+var typeDecoders = map[pdf.Name]decodeFunc{
+	"Action":         decoder(action.Decode),
+	"Annot":          decoder(decode.Annotation),
+	"Border":         decoder(annotation.ExtractBorderStyle),
+	"Catalog":        decoder(pdf.DecodeCatalog),
+	"CMap":           decoder(cmap.Extract),
+	"Collection":     decoder(collection.ExtractCollection),
+	"EmbeddedFile":   decoder(file.ExtractStream),
+	"ExtGState":      decoder(extract.ExtGState),
+	"Filespec":       decoder(file.ExtractSpecification),
+	"Font":           decoder(extract.Dict),
+	"FontDescriptor": decoder(font.ExtractDescriptor),
+	"Group":          decoder(group.ExtractTransparencyAttributes),
+	"Halftone":       decoder(halftone.Extract),
+	"Mask":           decoder(extract.SoftMaskDict),
+	"Metadata":       decoder(pdf.ExtractMetadataStream),
+	"OCG":            decoder(oc.ExtractGroup),
+	"OCMD":           decoder(oc.ExtractMembership),
+	"Outlines":       decoder(outline.Decode),
+	"Pattern":        decoder(extract.Pattern),
+	"Sound":          decoder(sound.Extract),
+	"XObject":        decoder(extract.XObject),
+}
+
+// subtypeDecoders lists the decoders for streams without a Type entry, by
+// the value of the Subtype entry.
+var subtypeDecoders = map[pdf.Name]decodeFunc{
+	"Form":  decoder(extract.XObject),
+	"Image": decoder(extract.XObject),
+}
+
+// resourceDecoders lists the decoders for the entries of the
+// subdictionaries of a resource dictionary.  These are used for resources
+// which can not be recognised by their Type entry.
+var resourceDecoders = map[pdf.Name]decodeFunc{
+	"ColorSpace": decoder(extract.ColorSpace),
+	"Shading":    decoder(extract.Shading),
+}
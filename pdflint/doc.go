@@ -0,0 +1,53 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package pdflint checks PDF files for problems.
+//
+// [Check] reports the problems which the readers in this module can detect:
+// damage found while opening the file, errors returned by the decoders,
+// entries which are not allowed in the PDF version of the file, and
+// problems in content streams.  Each [Problem] records where in the file it
+// was found: the indirect object, the key path inside this object and, for
+// content streams, the page number.
+//
+// The following checks are performed:
+//
+//   - Errors found while opening the file, for example a damaged
+//     cross-reference table, are reported.
+//   - Every indirect object listed in the cross-reference table must be
+//     readable.
+//   - Every object reachable from the document catalog, the document
+//     information dictionary and the trailer is decoded using the decoder
+//     of the corresponding package, selected by the Type and Subtype
+//     entries.  For example, page dictionaries are decoded using
+//     [seehuhn.de/go/pdf/page.Decode].  All errors returned by the
+//     decoders are reported.
+//   - The decoders are permissive: many minor violations, such as entries
+//     of the wrong type or out-of-range values, are skipped or replaced by
+//     default values without returning an error.  The decoders run in
+//     strict mode, see [pdf.Extractor], and the values skipped using
+//     [pdf.Optional] are reported as warnings.
+//   - Decoded objects are re-encoded for the PDF version of the file.
+//     Entries which require a later PDF version are reported as warnings.
+//   - Content streams of pages, form XObjects, tiling patterns and Type 3
+//     glyphs are scanned.  Operators must be allowed in the current
+//     context, must be available in the PDF version of the file, and must
+//     be properly nested.  Named resources used by operators must be
+//     present in the resource dictionary.
+//
+// Errors are violations which can lead to wrong rendering or loss of data.
+// Warnings are violations which most PDF viewers tolerate.
+package pdflint
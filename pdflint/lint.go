@@ -0,0 +1,475 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdflint
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"slices"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/page"
+	"seehuhn.de/go/pdf/pagetree"
+	"seehuhn.de/go/pdf/walker"
+)
+
+// Options controls which checks are performed by [Check].
+type Options struct {
+	// SkipContent disables the checks of content streams.
+	SkipContent bool
+
+	// MaxProblems, if positive, limits the number of problems reported.
+	MaxProblems int
+}
+
+// Check validates the PDF file read by r and returns the problems found.
+// The reader should be opened with [pdf.ErrorHandlingReport], so that
+// errors encountered while opening the file can be included in the
+// result.
+//
+// Problems are reported in the order they are found.  A non-nil error
+// is only returned if the file could not be read, for example because
+// of an I/O error.
+func Check(r *pdf.Reader, opt *Options) ([]*Problem, error) {
+	if opt == nil {
+		opt = &Options{}
+	}
+
+	version := pdf.GetVersion(r)
+	if _, err := version.ToString(); err != nil {
+		// Re-encoding is only possible for known versions.
+		version = pdf.V2_0
+	}
+	w, err := pdf.NewWriter(io.Discard, version, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// The decoders report the invalid values which they skip or replace
+	// to the extractor.  These are collected while a decoder runs, see
+	// [linter.strict].
+	x := pdf.NewExtractor(r)
+	l := &linter{
+		r:        r,
+		c:        pdf.CursorAt(x, nil),
+		version:  pdf.GetVersion(r),
+		rm:       pdf.NewResourceManager(w),
+		opt:      opt,
+		reported: make(map[string]bool),
+	}
+	x.Repaired = func(err error) {
+		if l.decoding {
+			l.repairs = append(l.repairs, err)
+		}
+	}
+
+	for _, e := range r.Errors {
+		l.add(Error, 0, nil, e)
+	}
+
+	steps := []func() error{
+		l.checkObjects,
+		l.checkStructure,
+		l.checkPages,
+	}
+	for _, step := range steps {
+		if l.full() {
+			break
+		}
+		err := step()
+		if err != nil {
+			return l.problems, err
+		}
+	}
+
+	return l.problems, nil
+}
+
+type linter struct {
+	r       *pdf.Reader
+	c       pdf.Cursor
+	version pdf.Version
+	opt     *Options
+
+	// rm is used to re-encode decoded objects, in order to find entries
+	// which are not allowed in the PDF version of the file.
+	rm *pdf.ResourceManager
+
+	// reported records the messages of version errors and decoder errors
+	// which have already been reported, so that errors in shared objects
+	// are only reported once.
+	reported map[string]bool
+
+	// decoding is set while a decoder runs.  The invalid values which the
+	// decoder repairs are collected in repairs.
+	decoding bool
+	repairs  []error
+
+	problems []*Problem
+}
+
+func (l *linter) add(sev Severity, ref pdf.Reference, path []string, err error) {
+	l.addPage(sev, 0, ref, path, err)
+}
+
+func (l *linter) addPage(sev Severity, pageNo int, ref pdf.Reference, path []string, err error) {
+	if l.full() {
+		return
+	}
+	l.problems = append(l.problems, &Problem{
+		Severity: sev,
+		Ref:      ref,
+		Path:     path,
+		Page:     pageNo,
+		Err:      err,
+	})
+}
+
+// full reports whether the maximum number of problems has been reached.
+func (l *linter) full() bool {
+	return l.opt.MaxProblems > 0 && len(l.problems) >= l.opt.MaxProblems
+}
+
+// checkObjects verifies that all objects in the cross-reference table
+// can be read.
+func (l *linter) checkObjects() error {
+	versionChecked := false
+	for ref, loc := range l.r.Objects() {
+		if loc.ObjStm != 0 && !versionChecked {
+			versionChecked = true
+			err := pdf.CheckVersion(l.r, "object streams", pdf.V1_5)
+			if err != nil {
+				l.add(Warning, ref, nil, err)
+			}
+		}
+
+		_, err := l.r.Get(ref, true)
+		if pdf.IsReadError(err) {
+			return err
+		} else if err != nil {
+			l.add(Error, ref, nil, err)
+		}
+	}
+	return nil
+}
+
+// location tracks the innermost indirect object during a walk.
+type location struct {
+	ref   pdf.Reference
+	depth int        // length of the walker path at the reference
+	key   pdf.Object // last element of the walker path at the reference
+}
+
+// checkStructure walks all objects reachable from the trailer and decodes
+// the objects of known types.
+//
+// The walk is done in post-order, so that problems in shared objects are
+// reported at the innermost object where they occur.
+func (l *linter) checkStructure() error {
+	meta := l.r.GetMeta()
+	roots := map[pdf.Name]pdf.Reference{}
+	if ref, ok := meta.Trailer["Root"].(pdf.Reference); ok {
+		roots["catalog"] = ref
+	}
+	if ref, ok := meta.Trailer["Info"].(pdf.Reference); ok {
+		roots["info"] = ref
+	}
+
+	w := walker.New(l.r)
+	var stack []location
+	for path, obj := range w.PostOrder() {
+		// drop the references we have left
+		for len(stack) > 0 {
+			top := stack[len(stack)-1]
+			if top.depth <= len(path) && path[top.depth-1] == top.key {
+				break
+			}
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 && len(path) > 0 {
+			if name, _ := path[0].(pdf.Name); roots[name] != 0 {
+				stack = append(stack, location{ref: roots[name], depth: 1, key: name})
+			}
+		}
+
+		if ref, isRef := obj.(pdf.Reference); isRef {
+			stack = append(stack, location{ref: ref, depth: len(path), key: path[len(path)-1]})
+			continue
+		}
+
+		// find the containing object and the path inside this object
+		var ref pdf.Reference
+		var keys []string
+		if len(stack) > 0 {
+			top := stack[len(stack)-1]
+			ref = top.ref
+			for _, key := range path[top.depth:] {
+				keys = append(keys, formatKey(key))
+			}
+		} else if len(path) > 0 {
+			keys = append(keys, string(path[0].(pdf.Name)))
+			for _, key := range path[1:] {
+				keys = append(keys, formatKey(key))
+			}
+		}
+		isIndirect := len(stack) > 0 && stack[len(stack)-1].depth == len(path)
+
+		err := l.checkObject(obj, ref, keys, isIndirect)
+		if err != nil {
+			return err
+		}
+		if l.full() {
+			break
+		}
+	}
+	if pdf.IsReadError(w.Err) {
+		return w.Err
+	} else if w.Err != nil {
+		l.add(Error, 0, nil, w.Err)
+	}
+	return nil
+}
+
+// checkObject decodes a single object visited by the walker.  If
+// isIndirect is true, obj is the object ref itself, otherwise obj is
+// contained in ref at the given path.
+func (l *linter) checkObject(obj pdf.Native, ref pdf.Reference, path []string, isIndirect bool) error {
+	var dict pdf.Dict
+	var stm *pdf.Stream
+	switch obj := obj.(type) {
+	case pdf.Dict:
+		if _, isStreamDict := obj["Length"]; isStreamDict {
+			// The walker visits stream dictionaries separately from the
+			// stream.  Stream dictionaries are checked together with the
+			// stream.
+			return nil
+		}
+		dict = obj
+	case *pdf.Stream:
+		stm = obj
+		dict = obj.Dict
+	default:
+		return nil
+	}
+
+	var target pdf.Object = obj
+	if isIndirect {
+		target = ref
+	}
+
+	tp, _ := dict["Type"].(pdf.Name)
+	subtype, _ := dict["Subtype"].(pdf.Name)
+	dec := typeDecoders[tp]
+	if dec == nil && stm != nil {
+		dec = subtypeDecoders[subtype]
+	}
+	if dec != nil {
+		err := l.decode(dec, target, ref, path)
+		if err != nil {
+			return err
+		}
+	}
+
+	if res, ok := dict["Resources"]; ok {
+		err := l.checkResources(res, ref, extend(path, "/Resources"))
+		if err != nil {
+			return err
+		}
+	}
+
+	if l.opt.SkipContent {
+		return nil
+	}
+	switch {
+	case stm != nil && subtype == "Form":
+		ct := content.Form
+		if groupDict, _ := l.c.Dict(dict["Group"]); groupDict["S"] == pdf.Name("Transparency") {
+			ct = content.TransparencyGroup
+		}
+		return l.checkContent(ct, dict["Resources"], l.streamOpener(stm), 0, ref, path)
+	case stm != nil && dict["PatternType"] == pdf.Integer(1):
+		ct := content.PatternColored
+		if dict["PaintType"] == pdf.Integer(2) {
+			ct = content.PatternUncolored
+		}
+		return l.checkContent(ct, dict["Resources"], l.streamOpener(stm), 0, ref, path)
+	case tp == "Font" && subtype == "Type3":
+		return l.checkGlyphs(dict, ref, path)
+	}
+	return nil
+}
+
+// checkResources decodes the resources which can not be recognised by their
+// Type entry.
+func (l *linter) checkResources(res pdf.Object, ref pdf.Reference, path []string) error {
+	resDict, err := l.c.Dict(res)
+	if pdf.IsReadError(err) {
+		return err
+	} else if err != nil {
+		l.add(Error, ref, path, err)
+		return nil
+	}
+	for category, dec := range resourceDecoders {
+		sub, err := l.c.Dict(resDict[category])
+		if pdf.IsReadError(err) {
+			return err
+		} else if err != nil {
+			l.add(Error, ref, extend(path, formatKey(category)), err)
+			continue
+		}
+		for _, name := range sub.SortedKeys() {
+			err := l.decode(dec, sub[name], ref, extend(path, formatKey(category), formatKey(name)))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// decode runs a decoder and reports the problems found.
+func (l *linter) decode(dec decodeFunc, obj pdf.Object, ref pdf.Reference, path []string) error {
+	val, repairs, err := l.strict(func() (any, error) {
+		return dec(l.c, obj)
+	})
+	if pdf.IsReadError(err) {
+		return err
+	}
+	for _, e := range repairs {
+		if l.once(e) {
+			l.add(Warning, ref, path, e)
+		}
+	}
+	if err != nil {
+		if l.once(err) {
+			l.add(Error, ref, path, err)
+		}
+		return nil
+	}
+
+	if err := l.checkVersion(val); err != nil && l.once(err) {
+		l.add(Warning, ref, path, err)
+	}
+	return nil
+}
+
+// strict runs a decoder and returns the invalid values which the decoder,
+// or the decoders it calls, skipped or replaced by default values.
+func (l *linter) strict(decode func() (any, error)) (any, []error, error) {
+	l.decoding = true
+	l.repairs = nil
+	val, err := decode()
+	repairs := l.repairs
+	l.decoding = false
+	l.repairs = nil
+	return val, repairs, err
+}
+
+// checkVersion re-encodes a decoded object for the PDF version of the file,
+// and returns the [pdf.VersionError], if any.  Other errors during encoding
+// are ignored, since the encoders may be stricter than the specification.
+func (l *linter) checkVersion(val any) error {
+	var err error
+	switch val := val.(type) {
+	case pdf.Encoder:
+		_, err = val.Encode(l.rm)
+	case pdf.Embedder:
+		// the resource manager uses embedders as map keys
+		if reflect.TypeOf(val).Kind() != reflect.Pointer {
+			return nil
+		}
+		_, err = l.rm.Embed(val)
+	}
+	var versionErr *pdf.VersionError
+	if errors.As(err, &versionErr) {
+		return versionErr
+	}
+	return nil
+}
+
+// once reports whether an error with the same message has not been seen
+// before.
+func (l *linter) once(err error) bool {
+	msg := err.Error()
+	if l.reported[msg] {
+		return false
+	}
+	l.reported[msg] = true
+	return true
+}
+
+// checkPages decodes the page dictionaries, including inherited attributes,
+// and checks the page content streams.
+func (l *linter) checkPages() error {
+	it := pagetree.NewIterator(l.r)
+	pageNo := 0
+	for ref, dict := range it.All() {
+		pageNo++
+
+		val, repairs, err := l.strict(func() (any, error) {
+			return pdf.Decode(l.c, dict, page.Decode)
+		})
+		if pdf.IsReadError(err) {
+			return err
+		}
+		for _, e := range repairs {
+			if l.once(e) {
+				l.addPage(Warning, pageNo, ref, nil, e)
+			}
+		}
+		if err != nil {
+			if l.once(err) {
+				l.addPage(Error, pageNo, ref, nil, err)
+			}
+		} else if err := l.checkVersion(val); err != nil && l.once(err) {
+			l.addPage(Warning, pageNo, ref, nil, err)
+		}
+
+		if !l.opt.SkipContent && dict["Contents"] != nil {
+			open := func() (io.ReadCloser, error) {
+				return pagetree.ContentStream(l.r, dict)
+			}
+			err := l.checkContent(content.Page, dict["Resources"], open, pageNo, ref, []string{"/Contents"})
+			if err != nil {
+				return err
+			}
+		}
+
+		if l.full() {
+			break
+		}
+	}
+	if pdf.IsReadError(it.Err) {
+		return it.Err
+	} else if it.Err != nil {
+		l.add(Error, 0, []string{"catalog", "/Pages"}, it.Err)
+	}
+	return nil
+}
+
+// extend returns a new path, consisting of path followed by keys.
+func extend(path []string, keys ...string) []string {
+	return slices.Concat(path, keys)
+}
+
+func (l *linter) streamOpener(stm *pdf.Stream) func() (io.ReadCloser, error) {
+	return func() (io.ReadCloser, error) {
+		return l.c.StreamReader(stm)
+	}
+}
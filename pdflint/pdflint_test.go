@@ -0,0 +1,204 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdflint
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"seehuhn.de/go/pdf"
+)
+
+// makeTestFile writes a PDF 1.2 file with a number of problems.
+func makeTestFile(t *testing.T) *pdf.Reader {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	w, err := pdf.NewWriter(buf, pdf.V1_2, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	catRef := w.Alloc()
+	pagesRef := w.Alloc()
+	page1Ref := w.Alloc()
+	page2Ref := w.Alloc()
+	contentRef := w.Alloc()
+	fontRef := w.Alloc()
+
+	mustPut := func(ref pdf.Reference, obj pdf.Object) {
+		t.Helper()
+		if err := w.Put(ref, obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	mustPut(catRef, pdf.Dict{
+		"Type":  pdf.Name("Catalog"),
+		"Pages": pagesRef,
+		"Lang":  pdf.TextString("en"), // requires PDF 1.4
+	})
+	mustPut(pagesRef, pdf.Dict{
+		"Type":     pdf.Name("Pages"),
+		"Kids":     pdf.Array{page1Ref, page2Ref},
+		"Count":    pdf.Integer(2),
+		"MediaBox": &pdf.Rectangle{URx: 200, URy: 200},
+	})
+	mustPut(page1Ref, pdf.Dict{
+		"Type":   pdf.Name("Page"),
+		"Parent": pagesRef,
+		"Resources": pdf.Dict{
+			"Font": pdf.Dict{"F1": fontRef},
+		},
+		"Contents": contentRef,
+		"Annots": pdf.Array{
+			pdf.Dict{
+				"Type":    pdf.Name("Annot"),
+				"Subtype": pdf.Name("Link"),
+				"Rect":    &pdf.Rectangle{URx: 10, URy: 10},
+				"Border":  pdf.Name("X"), // not an array
+				"A": pdf.Dict{
+					"Type": pdf.Name("Action"),
+					"S":    pdf.Name("URI"), // the URI entry is missing
+				},
+			},
+		},
+	})
+	mustPut(page2Ref, pdf.Dict{
+		"Type":    pdf.Name("Page"),
+		"Parent":  pagesRef,
+		"CropBox": pdf.Name("Foo"), // not a rectangle
+	})
+	mustPut(fontRef, pdf.Dict{
+		"Type":     pdf.Name("Font"),
+		"Subtype":  pdf.Name("Type1"),
+		"BaseFont": pdf.Name("Helvetica"),
+	})
+
+	stm, err := w.OpenStream(contentRef, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = stm.Write([]byte("BT /F1 12 Tf (a) Tj 0 0 m ET\n" +
+		"BT /F2 12 Tf ET\n" +
+		"/Sh1 sh\n" +
+		"q\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = stm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w.SetTrailerRefs(catRef, 0)
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), &pdf.ReaderOptions{
+		ErrorHandling: pdf.ErrorHandlingReport,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestCheck(t *testing.T) {
+	r := makeTestFile(t)
+
+	problems, err := Check(r, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, p := range problems {
+		lines = append(lines, p.String())
+	}
+	for _, line := range lines {
+		t.Log(line)
+	}
+
+	want := []string{
+		"warning: 1 0 R: Catalog Lang entry requires PDF version 1.4 or later",
+		"error: page 1, 3 0 R /Contents: operator 4 (m): m in text context: operator not allowed in current context",
+		"error: page 1, 3 0 R /Contents: operator 7 (Tf): resource Font /F2 not found",
+		"warning: page 1, 3 0 R /Contents: operator 9 (sh): operator not available in PDF version 1.2",
+		"error: page 1, 3 0 R /Contents: operator 9 (sh): resource Shading /Sh1 not found",
+		"warning: page 1, 3 0 R /Contents: at end of stream: unclosed operators: q/Q",
+		"error: 3 0 R /Annots[0]/A: URI action: missing URI",
+		"warning: 3 0 R /Annots[0]: expected pdf.Array but got pdf.Name",
+		"error: page 2, 4 0 R: CropBox: expected pdf.Array but got pdf.Name",
+	}
+	for _, w := range want {
+		if !slices.ContainsFunc(lines, func(line string) bool { return strings.Contains(line, w) }) {
+			t.Errorf("missing problem %q", w)
+		}
+	}
+}
+
+func TestMaxProblems(t *testing.T) {
+	r := makeTestFile(t)
+
+	problems, err := Check(r, &Options{MaxProblems: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 2 {
+		t.Errorf("got %d problems, want 2", len(problems))
+	}
+}
+
+func TestSkipContent(t *testing.T) {
+	r := makeTestFile(t)
+
+	problems, err := Check(r, &Options{SkipContent: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range problems {
+		if slices.Contains(p.Path, "/Contents") {
+			t.Errorf("unexpected content problem: %s", p)
+		}
+	}
+}
+
+func TestProblemString(t *testing.T) {
+	p := &Problem{
+		Severity: Error,
+		Ref:      pdf.NewReference(7, 0),
+		Path:     []string{"/Resources", "/Font"},
+		Page:     2,
+		Err: &pdf.MalformedFileError{
+			Err: pdf.Error("bad font").(*pdf.MalformedFileError).Err,
+			Loc: []string{"F1", "decoding"},
+		},
+	}
+	want := "error: page 2, 7 0 R /Resources/Font: decoding: F1: bad font"
+	if got := p.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	p = &Problem{Severity: Warning, Path: []string{"trailer", "/ID"}, Err: pdf.Error("x")}
+	want = "warning: trailer/ID: x"
+	if got := p.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
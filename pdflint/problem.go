@@ -0,0 +1,124 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdflint
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"seehuhn.de/go/pdf"
+)
+
+// Severity describes how serious a [Problem] is.
+type Severity int
+
+// These are the possible values of [Severity].
+const (
+	// Error indicates a violation of the PDF specification which can lead
+	// to wrong rendering or loss of data.
+	Error Severity = iota
+
+	// Warning indicates a violation of the PDF specification which most
+	// PDF viewers tolerate.
+	Warning
+)
+
+func (s Severity) String() string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	default:
+		return "Severity(" + strconv.Itoa(int(s)) + ")"
+	}
+}
+
+// Problem describes a single problem found by [Check].
+type Problem struct {
+	Severity Severity
+
+	// Ref is the indirect object which contains the problem.
+	// This is zero if the problem is not located inside an indirect object,
+	// for example in the file trailer.
+	Ref pdf.Reference
+
+	// Path is the sequence of dictionary keys (like "/Resources") and array
+	// indices (like "[2]") which leads from the object Ref to the location
+	// of the problem.  If Ref is zero, the first element names the
+	// top-level structure ("trailer", "catalog" or "info").
+	Path []string
+
+	// Page is the 1-based page number for problems in the content stream
+	// of a page, and zero otherwise.
+	Page int
+
+	// Err describes the problem.
+	Err error
+}
+
+// Location returns a human-readable description of the location of the
+// problem, for example "page 2, 7 0 R /Resources/Font".
+func (p *Problem) Location() string {
+	var parts []string
+	if p.Page > 0 {
+		parts = append(parts, "page "+strconv.Itoa(p.Page)+",")
+	}
+	if p.Ref != 0 {
+		parts = append(parts, pdf.AsString(p.Ref))
+	}
+	if len(p.Path) > 0 {
+		parts = append(parts, strings.Join(p.Path, ""))
+	}
+	return strings.TrimSuffix(strings.Join(parts, " "), ",")
+}
+
+// Message returns the description of the problem, without the location.
+// For a [pdf.MalformedFileError], the entries of the Loc field are
+// included, outermost first.
+func (p *Problem) Message() string {
+	var e *pdf.MalformedFileError
+	if !errors.As(p.Err, &e) || e.Error() != p.Err.Error() {
+		return p.Err.Error()
+	}
+	var parts []string
+	for i := len(e.Loc) - 1; i >= 0; i-- {
+		parts = append(parts, e.Loc[i])
+	}
+	parts = append(parts, e.Err.Error())
+	return strings.Join(parts, ": ")
+}
+
+// String formats the problem as a single line of text.
+func (p *Problem) String() string {
+	loc := p.Location()
+	if loc == "" {
+		return p.Severity.String() + ": " + p.Message()
+	}
+	return p.Severity.String() + ": " + loc + ": " + p.Message()
+}
+
+// formatKey formats a path element yielded by [walker.Walker].
+func formatKey(key pdf.Object) string {
+	switch key := key.(type) {
+	case pdf.Integer:
+		return "[" + strconv.Itoa(int(key)) + "]"
+	default:
+		return pdf.AsString(key)
+	}
+}
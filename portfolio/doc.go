@@ -0,0 +1,35 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package portfolio writes and reads PDF portfolios.
+//
+// A PDF portfolio, called a portable collection in the PDF specification,
+// is a PDF document which serves as a container for other files.  The
+// files are stored in the EmbeddedFiles name tree of the document, and the
+// collection dictionary in the document catalog tells the viewer to present
+// the files instead of the pages of the document.  Files can be arranged
+// in a hierarchy of folders.
+//
+// [Write] creates a portfolio from a list of files, whose paths determine
+// the folder hierarchy.  The document itself consists of a single cover
+// page, which is shown by viewers without portfolio support.  [Read]
+// returns the files of an existing portfolio, together with their paths
+// in the folder hierarchy.
+//
+// [WriteWrapper] and [ReadWrapper] handle the related case of an
+// unencrypted wrapper document (PDF 2.0), which carries an encrypted payload
+// document as its only embedded file.
+package portfolio
@@ -0,0 +1,391 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package portfolio
+
+import (
+	"bytes"
+	"crypto/md5"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/collection"
+	"seehuhn.de/go/pdf/document"
+	"seehuhn.de/go/pdf/file"
+	"seehuhn.de/go/pdf/font/standard"
+	"seehuhn.de/go/pdf/nametree"
+)
+
+// Portfolio describes a PDF portfolio.
+type Portfolio struct {
+	// Collection (optional) describes how the portfolio is presented.
+	//
+	// When writing a portfolio, the folders given by Collection.Folders
+	// are kept, and missing folders for the file paths are added.  If
+	// Collection is nil, the files are shown in the details view.
+	Collection *collection.Collection
+
+	// Files lists the files in the portfolio.
+	Files []*File
+
+	// Initial (optional) is the path of the file which is presented when
+	// the portfolio is opened.  When writing a portfolio, this overrides
+	// Collection.InitialDocument.
+	Initial string
+}
+
+// File is a file in a portfolio.
+type File struct {
+	// Path is the location of the file in the folder hierarchy.  Path
+	// components are separated by slashes, the last component is the file
+	// name.
+	Path string
+
+	// Data is the contents of the file.
+	Data []byte
+
+	// MimeType (optional) is the MIME type of the file, for example
+	// "application/pdf".
+	MimeType string
+
+	// CreationDate (optional) is the time the file was created.
+	CreationDate time.Time
+
+	// ModDate (optional) is the time the file was last modified.
+	ModDate time.Time
+
+	// Description (optional) is a description of the file.
+	Description string
+
+	// Item (optional) holds the data for the collection schema fields.
+	Item *collection.ItemDict
+}
+
+// Write creates a portfolio containing the given files, and writes it to w.
+// The output uses PDF 1.7.
+func Write(w io.Writer, p *Portfolio) error {
+	coll := &collection.Collection{}
+	if p.Collection != nil {
+		*coll = *p.Collection
+	}
+	root := &collection.Folder{}
+	if coll.Folders != nil {
+		root = cloneFolder(coll.Folders)
+	}
+
+	// Find the folder for each file, creating missing folders on the way.
+	folderPath := map[*collection.Folder]string{}
+	byPath := map[string]*collection.Folder{}
+	var walk func(prefix string, f *collection.Folder)
+	walk = func(prefix string, f *collection.Folder) {
+		folderPath[f] = prefix
+		byPath[prefix] = f
+		for _, child := range f.Children {
+			walk(path.Join(prefix, child.Name), child)
+		}
+	}
+	walk("", root)
+	nextID := maxID(root) + 1
+
+	type entry struct {
+		file   *File
+		folder *collection.Folder
+		name   string
+	}
+	entries := make([]entry, len(p.Files))
+	for i, f := range p.Files {
+		dir, name, err := splitPath(f.Path)
+		if err != nil {
+			return err
+		}
+		folder := root
+		if dir != "" {
+			for _, comp := range strings.Split(dir, "/") {
+				childPath := path.Join(folderPath[folder], comp)
+				child := byPath[childPath]
+				if child == nil {
+					child = &collection.Folder{ID: nextID, Name: comp}
+					nextID++
+					folder.Children = append(folder.Children, child)
+					folderPath[child] = childPath
+					byPath[childPath] = child
+				}
+				folder = child
+			}
+		}
+		entries[i] = entry{file: f, folder: folder, name: name}
+	}
+
+	// Folder IDs are only added to the keys if the portfolio uses folders.
+	useFolders := coll.Folders != nil || len(root.Children) > 0
+	if useFolders {
+		coll.Folders = root
+	}
+	key := func(folder *collection.Folder, name string) pdf.Name {
+		if useFolders {
+			return collection.FileKey(folder.ID, name)
+		}
+		return pdf.Name(name)
+	}
+
+	specs := map[pdf.Name]*File{}
+	order := make([]pdf.Name, 0, len(entries))
+	for _, e := range entries {
+		k := key(e.folder, e.name)
+		if specs[k] != nil {
+			return fmt.Errorf("duplicate file %q", e.file.Path)
+		}
+		specs[k] = e.file
+		order = append(order, k)
+	}
+
+	if p.Initial != "" {
+		dir, name, err := splitPath(p.Initial)
+		if err != nil {
+			return err
+		}
+		folder := byPath[dir]
+		if folder == nil || specs[key(folder, name)] == nil {
+			return fmt.Errorf("initial document %q not found", p.Initial)
+		}
+		coll.InitialDocument = string(key(folder, name))
+	}
+
+	doc, err := document.WriteSinglePage(w, &pdf.Rectangle{URx: 595, URy: 842}, pdf.V1_7, nil)
+	if err != nil {
+		return err
+	}
+	err = drawCover(doc, []string{
+		"This document is a PDF portfolio.",
+		fmt.Sprintf("It contains %d embedded files.", len(p.Files)),
+		"Please use a PDF viewer with portfolio support to access the files.",
+	})
+	if err != nil {
+		return err
+	}
+
+	files := make(map[pdf.Name]pdf.Object, len(specs))
+	for _, k := range order {
+		spec, err := doc.RM.Embed(specification(specs[k]))
+		if err != nil {
+			return err
+		}
+		files[k] = spec
+	}
+	if len(files) > 0 {
+		treeRef, err := nametree.WriteMap(doc.Out, files)
+		if err != nil {
+			return err
+		}
+		doc.Out.GetMeta().Catalog.Names = pdf.Dict{"EmbeddedFiles": treeRef}
+	}
+
+	collObj, err := doc.RM.Embed(coll)
+	if err != nil {
+		return err
+	}
+	doc.Out.GetMeta().Catalog.Collection = collObj
+
+	return doc.Close()
+}
+
+// drawCover draws the cover page, which is shown by PDF viewers without
+// support for portfolios.
+func drawCover(doc *document.Page, lines []string) error {
+	F, err := standard.Helvetica.New()
+	if err != nil {
+		return err
+	}
+	doc.TextBegin()
+	doc.TextSetFont(F, 12)
+	doc.TextSetLeading(18)
+	doc.TextFirstLine(72, 760)
+	for i, line := range lines {
+		if i > 0 {
+			doc.TextNextLine()
+		}
+		doc.TextShow(line)
+	}
+	doc.TextEnd()
+	return nil
+}
+
+// specification returns the file specification for a file in the
+// portfolio.
+func specification(f *File) *file.Specification {
+	sum := md5.Sum(f.Data)
+	stm := &file.Stream{
+		MimeType:     f.MimeType,
+		Size:         int64(len(f.Data)),
+		CreationDate: f.CreationDate,
+		ModDate:      f.ModDate,
+		CheckSum:     sum[:],
+		WriteData: func(w io.Writer) error {
+			_, err := w.Write(f.Data)
+			return err
+		},
+	}
+	name := path.Base(f.Path)
+	return &file.Specification{
+		FileName:        name,
+		FileNameUnicode: name,
+		Description:     f.Description,
+		EmbeddedFiles: map[string]*file.Stream{
+			"F":  stm,
+			"UF": stm,
+		},
+		CollectionItem: f.Item,
+	}
+}
+
+// Read returns the portfolio stored in r.  The files are returned in the
+// order of their keys in the EmbeddedFiles name tree.  If the document has no collection dictionary, the
+// returned Collection is nil, and the files of the EmbeddedFiles name tree
+// are returned.
+func Read(r pdf.Getter) (*Portfolio, error) {
+	c := pdf.NewCursor(r)
+	cat := r.GetMeta().Catalog
+
+	p := &Portfolio{}
+	if cat.Collection != nil {
+		coll, err := pdf.Optional(pdf.Decode(c, cat.Collection, collection.ExtractCollection))
+		if err != nil {
+			return nil, err
+		}
+		p.Collection = coll
+	}
+
+	byID := map[int]string{}
+	if p.Collection != nil && p.Collection.Folders != nil {
+		var walk func(prefix string, f *collection.Folder)
+		walk = func(prefix string, f *collection.Folder) {
+			if _, seen := byID[f.ID]; seen {
+				return
+			}
+			byID[f.ID] = prefix
+			for _, child := range f.Children {
+				walk(path.Join(prefix, sanitize(child.Name)), child)
+			}
+		}
+		walk("", p.Collection.Folders)
+	}
+
+	names, err := c.Dict(cat.Names)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	if names == nil {
+		return p, nil
+	}
+	tree, err := nametree.ExtractInMemory(r, names["EmbeddedFiles"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	} else if tree == nil {
+		return p, nil
+	}
+
+	keys := make([]pdf.Name, 0, len(tree.Data))
+	for key := range tree.Data {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		spec, err := file.ExtractSpecification(c, tree.Data[key], false)
+		if pdf.IsReadError(err) {
+			return nil, err
+		} else if err != nil || spec == nil {
+			continue
+		}
+		stm := spec.EmbeddedFiles["UF"]
+		if stm == nil {
+			stm = spec.EmbeddedFiles["F"]
+		}
+		if stm == nil || stm.WriteData == nil {
+			continue
+		}
+
+		dir := ""
+		name := string(key)
+		if id, rest, ok := collection.SplitKey(key); ok {
+			if prefix, found := byID[id]; found {
+				dir, name = prefix, rest
+			}
+		}
+
+		buf := &bytes.Buffer{}
+		if err := stm.WriteData(buf); err != nil {
+			return nil, err
+		}
+		filePath := path.Join(dir, sanitize(name))
+		if p.Collection != nil && string(key) == p.Collection.InitialDocument {
+			p.Initial = filePath
+		}
+		p.Files = append(p.Files, &File{
+			Path:         filePath,
+			Data:         buf.Bytes(),
+			MimeType:     stm.MimeType,
+			CreationDate: stm.CreationDate,
+			ModDate:      stm.ModDate,
+			Description:  spec.Description,
+			Item:         spec.CollectionItem,
+		})
+	}
+	return p, nil
+}
+
+// sanitize makes a folder or file name usable as a path component.
+func sanitize(name string) string {
+	name = strings.ReplaceAll(name, "/", "_")
+	if name == "" || name == "." || name == ".." {
+		name = "_"
+	}
+	return name
+}
+
+// cloneFolder returns a copy of a folder hierarchy.  Only the tree structure
+// is copied, the remaining fields are shared.
+func cloneFolder(f *collection.Folder) *collection.Folder {
+	res := *f
+	res.Children = make([]*collection.Folder, len(f.Children))
+	for i, child := range f.Children {
+		res.Children[i] = cloneFolder(child)
+	}
+	return &res
+}
+
+func maxID(f *collection.Folder) int {
+	res := f.ID
+	for _, child := range f.Children {
+		res = max(res, maxID(child))
+	}
+	return res
+}
+
+// splitPath splits a slash-separated file path into the folder path and
+// the file name.
+func splitPath(p string) (dir, name string, err error) {
+	clean := path.Clean("/" + p)[1:]
+	if clean == "" {
+		return "", "", fmt.Errorf("invalid file path %q", p)
+	}
+	dir, name = path.Split(clean)
+	return strings.TrimSuffix(dir, "/"), name, nil
+}
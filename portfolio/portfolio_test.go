@@ -0,0 +1,204 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package portfolio
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+	"time"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/collection"
+	"seehuhn.de/go/pdf/file"
+	"seehuhn.de/go/pdf/optional"
+)
+
+func TestRoundTrip(t *testing.T) {
+	modTime := time.Date(2026, 5, 4, 12, 0, 0, 0, time.UTC)
+	in := &Portfolio{
+		Collection: &collection.Collection{
+			Schema: map[pdf.Name]*collection.Field{
+				"Name": {Type: collection.FieldFileName, Name: "Name", Order: optional.New(1)},
+				"Date": {Type: collection.FieldModDate, Name: "Modified", Order: optional.New(2)},
+			},
+			View: collection.ViewDetails,
+			Navigator: &collection.Navigator{
+				Layout: []collection.Layout{collection.LayoutTree},
+			},
+		},
+		Files: []*File{
+			{Path: "summary.pdf", Data: []byte("%PDF-1.7 summary"), MimeType: "application/pdf"},
+			{Path: "evidence/photos/scene.jpg", Data: []byte("JPEG"), MimeType: "image/jpeg"},
+			{Path: "evidence/costs.csv", Data: []byte("a,b\n1,2\n"), ModDate: modTime},
+			{Path: "letters/2026-05-01.txt", Data: []byte("Dear Sir"), Description: "first letter"},
+		},
+		Initial: "summary.pdf",
+	}
+
+	buf := &bytes.Buffer{}
+	err := Write(buf, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Read(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if out.Collection == nil || out.Collection.Folders == nil {
+		t.Fatal("folder hierarchy missing")
+	}
+	if len(out.Collection.Schema) != 2 || out.Collection.View != collection.ViewDetails {
+		t.Errorf("collection not preserved: %+v", out.Collection)
+	}
+	if out.Initial != "summary.pdf" {
+		t.Errorf("wrong initial document %q", out.Initial)
+	}
+
+	var folders []string
+	var walk func(prefix string, f *collection.Folder)
+	walk = func(prefix string, f *collection.Folder) {
+		for _, child := range f.Children {
+			name := prefix + "/" + child.Name
+			folders = append(folders, name)
+			walk(name, child)
+		}
+	}
+	walk("", out.Collection.Folders)
+	wantFolders := []string{"/evidence", "/evidence/photos", "/letters"}
+	if !slices.Equal(folders, wantFolders) {
+		t.Errorf("wrong folders: %v", folders)
+	}
+
+	got := map[string]*File{}
+	for _, f := range out.Files {
+		got[f.Path] = f
+	}
+	if len(got) != len(in.Files) {
+		t.Errorf("got %d files, want %d", len(got), len(in.Files))
+	}
+	for _, want := range in.Files {
+		f := got[want.Path]
+		if f == nil {
+			t.Errorf("missing file %q", want.Path)
+			continue
+		}
+		if !bytes.Equal(f.Data, want.Data) {
+			t.Errorf("%s: wrong data %q", want.Path, f.Data)
+		}
+		if f.MimeType != want.MimeType || f.Description != want.Description {
+			t.Errorf("%s: wrong metadata", want.Path)
+		}
+		if !f.ModDate.Equal(want.ModDate) {
+			t.Errorf("%s: wrong modification date %s", want.Path, f.ModDate)
+		}
+	}
+}
+
+func TestNoFolders(t *testing.T) {
+	in := &Portfolio{
+		Files: []*File{
+			{Path: "a.txt", Data: []byte("A")},
+			{Path: "b.txt", Data: []byte("B")},
+		},
+	}
+	buf := &bytes.Buffer{}
+	err := Write(buf, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := Read(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Collection == nil || out.Collection.Folders != nil {
+		t.Errorf("unexpected collection %+v", out.Collection)
+	}
+	if len(out.Files) != 2 || out.Files[0].Path != "a.txt" || out.Files[1].Path != "b.txt" {
+		t.Errorf("unexpected files %v", out.Files)
+	}
+}
+
+func TestDuplicate(t *testing.T) {
+	in := &Portfolio{
+		Files: []*File{
+			{Path: "docs/a.txt", Data: []byte("A")},
+			{Path: "/docs//a.txt", Data: []byte("B")},
+		},
+	}
+	err := Write(&bytes.Buffer{}, in)
+	if err == nil {
+		t.Error("expected error for duplicate file")
+	}
+}
+
+func TestWrapperRoundTrip(t *testing.T) {
+	in := &Wrapper{
+		Payload: &File{
+			Path:     "payload.pdf",
+			Data:     []byte("%PDF-2.0 encrypted payload"),
+			MimeType: "application/pdf",
+		},
+		EncryptedPayload: &file.EncryptedPayload{
+			FilterName: "MyCryptoFilter",
+			Version:    "1.0",
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	err := WriteWrapper(buf, in)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.GetMeta().Encryption != nil {
+		t.Error("wrapper document is encrypted")
+	}
+	out, err := ReadWrapper(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.Payload.Path != "payload.pdf" || !bytes.Equal(out.Payload.Data, in.Payload.Data) {
+		t.Errorf("wrong payload: %q %q", out.Payload.Path, out.Payload.Data)
+	}
+	if *out.EncryptedPayload != *in.EncryptedPayload {
+		t.Errorf("wrong encrypted payload dict: %v", out.EncryptedPayload)
+	}
+
+	p, err := Read(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Collection == nil || p.Collection.View != collection.ViewHidden || p.Initial != "payload.pdf" {
+		t.Errorf("wrong collection: %v %q", p.Collection, p.Initial)
+	}
+}
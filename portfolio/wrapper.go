@@ -0,0 +1,151 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package portfolio
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/collection"
+	"seehuhn.de/go/pdf/document"
+	"seehuhn.de/go/pdf/file"
+	"seehuhn.de/go/pdf/nametree"
+)
+
+// PDF 2.0 sections: 7.6.7
+
+// Wrapper describes an unencrypted wrapper document.  Such a document
+// contains an encrypted payload document as an embedded file.  PDF
+// processors which support the cryptographic filter of the payload open
+// the payload directly, all other processors show the cover page of the
+// wrapper.
+type Wrapper struct {
+	// Payload is the encrypted payload document.  The file name given by
+	// Payload.Path is used as the name of the embedded file.
+	Payload *File
+
+	// EncryptedPayload describes the cryptographic filter used to encrypt
+	// the payload document.
+	EncryptedPayload *file.EncryptedPayload
+}
+
+// WriteWrapper writes an unencrypted wrapper document around an encrypted
+// payload.  The output uses PDF 2.0.
+func WriteWrapper(w io.Writer, wr *Wrapper) error {
+	if wr.Payload == nil || wr.EncryptedPayload == nil {
+		return errors.New("missing encrypted payload")
+	}
+	_, name, err := splitPath(wr.Payload.Path)
+	if err != nil {
+		return err
+	}
+
+	doc, err := document.WriteSinglePage(w, &pdf.Rectangle{URx: 595, URy: 842}, pdf.V2_0, nil)
+	if err != nil {
+		return err
+	}
+	err = drawCover(doc, []string{
+		"This document contains an encrypted payload document.",
+		"Please use a PDF viewer which supports the " +
+			string(wr.EncryptedPayload.FilterName) + " cryptographic filter.",
+	})
+	if err != nil {
+		return err
+	}
+
+	spec := specification(wr.Payload)
+	spec.AFRelationship = file.RelationshipEncryptedPayload
+	spec.EncryptedPayload = wr.EncryptedPayload
+	specObj, err := doc.RM.Embed(spec)
+	if err != nil {
+		return err
+	}
+	treeRef, err := nametree.WriteMap(doc.Out, map[pdf.Name]pdf.Object{
+		pdf.Name(name): specObj,
+	})
+	if err != nil {
+		return err
+	}
+
+	coll := &collection.Collection{
+		View:            collection.ViewHidden,
+		InitialDocument: name,
+	}
+	collObj, err := doc.RM.Embed(coll)
+	if err != nil {
+		return err
+	}
+
+	cat := doc.Out.GetMeta().Catalog
+	cat.Names = pdf.Dict{"EmbeddedFiles": treeRef}
+	cat.AF = pdf.Array{specObj}
+	cat.Collection = collObj
+
+	return doc.Close()
+}
+
+// ReadWrapper returns the encrypted payload of an unencrypted wrapper
+// document.  The payload is located using the associated files of the
+// document catalog.
+func ReadWrapper(r pdf.Getter) (*Wrapper, error) {
+	c := pdf.NewCursor(r)
+	af, err := c.Array(r.GetMeta().Catalog.AF)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	for _, obj := range af {
+		spec, err := pdf.Optional(pdf.Decode(c, obj, file.ExtractSpecification))
+		if err != nil {
+			return nil, err
+		} else if spec == nil ||
+			spec.AFRelationship != file.RelationshipEncryptedPayload ||
+			spec.EncryptedPayload == nil {
+			continue
+		}
+		stm := spec.EmbeddedFiles["UF"]
+		if stm == nil {
+			stm = spec.EmbeddedFiles["F"]
+		}
+		if stm == nil || stm.WriteData == nil {
+			continue
+		}
+
+		buf := &bytes.Buffer{}
+		if err := stm.WriteData(buf); err != nil {
+			return nil, err
+		}
+		name := spec.FileNameUnicode
+		if name == "" {
+			name = spec.FileName
+		}
+		return &Wrapper{
+			Payload: &File{
+				Path:         sanitize(path.Base(name)),
+				Data:         buf.Bytes(),
+				MimeType:     stm.MimeType,
+				CreationDate: stm.CreationDate,
+				ModDate:      stm.ModDate,
+				Description:  spec.Description,
+			},
+			EncryptedPayload: spec.EncryptedPayload,
+		}, nil
+	}
+	return nil, errors.New("no encrypted payload found")
+}
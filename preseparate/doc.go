@@ -0,0 +1,47 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package preseparate splits the pages of a PDF document into colorant
+// plates.
+//
+// For every page of the source document, [Write] determines which process
+// colorants (Cyan, Magenta, Yellow and Black) and which spot colorants are
+// painted with a non-zero amount, and writes one output page per colorant.
+// Each of these pages shows the amount of ink of its colorant as a gray
+// level, from white for no ink to black for full coverage.  The pages carry a
+// separation dictionary (the SeparationInfo entry, see section 14.11.4 of ISO
+// 32000-2:2020), which lists all plates of the same source page and names the
+// colorant of the plate.  The result is a preseparated PDF file, as used for
+// inspecting individual printing plates.
+//
+// Colours are split into colorants as follows:
+//
+//   - Separation colours contribute their tint to the plate of their colorant.
+//     The colorant All contributes to every plate, and None to none.
+//   - DeviceN colours contribute each component to the plate of the
+//     corresponding colorant.
+//   - DeviceCMYK and four-component ICC-based colours contribute their
+//     components to the process plates, and DeviceGray colours contribute to
+//     the Black plate.
+//   - All other colours are converted to DeviceCMYK, using the CIE XYZ values
+//     of the colour.
+//
+// Overprinting is not simulated: an object painted on one plate knocks out
+// the objects below it on all other plates.  JPEG 2000 images are copied
+// unchanged.  Annotations are not included in the separated pages; to include
+// their appearance, flatten them into the page content first, for example
+// using [seehuhn.de/go/pdf/printprep].
+package preseparate
@@ -0,0 +1,118 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package preseparate
+
+import (
+	"seehuhn.de/go/icc"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/color"
+)
+
+// processColorants lists the colorants of DeviceCMYK, in the order of the
+// colour components.
+var processColorants = []pdf.Name{"Cyan", "Magenta", "Yellow", "Black"}
+
+// minAmount is the smallest amount of ink which marks a colorant as used.
+const minAmount = 1.0 / 512
+
+// inks splits colours into the amounts of the individual colorants.
+type inks struct {
+	ws   icc.Workspace
+	cmyk [4]float64
+}
+
+// amount returns the amount of the given colorant in a colour.  The result
+// is in the range [0, 1].
+func (k *inks) amount(space color.Space, values []float64, colorant pdf.Name) float64 {
+	switch s := space.(type) {
+	case *color.SpaceSeparation:
+		switch s.Colorant {
+		case colorant, "All":
+			return clip(values[0])
+		}
+		return 0
+
+	case *color.SpaceDeviceN:
+		for i, name := range s.Colorants {
+			if name == colorant {
+				return clip(values[i])
+			}
+		}
+		return 0
+	}
+
+	for i, name := range processColorants {
+		if name == colorant {
+			return k.process(space, values)[i]
+		}
+	}
+	return 0
+}
+
+// record adds the colorants which a colour uses to used.  The colorant All
+// is not recorded, since it is painted on whichever plates exist.
+func (k *inks) record(space color.Space, values []float64, used map[pdf.Name]bool) {
+	switch s := space.(type) {
+	case *color.SpaceSeparation:
+		if s.Colorant != "All" && s.Colorant != "None" && values[0] >= minAmount {
+			used[s.Colorant] = true
+		}
+		return
+
+	case *color.SpaceDeviceN:
+		for i, name := range s.Colorants {
+			if name != "All" && name != "None" && values[i] >= minAmount {
+				used[name] = true
+			}
+		}
+		return
+	}
+
+	for i, v := range k.process(space, values) {
+		if v >= minAmount {
+			used[processColorants[i]] = true
+		}
+	}
+}
+
+// process returns the process colorant amounts of a colour in a colour
+// space other than Separation and DeviceN.
+func (k *inks) process(space color.Space, values []float64) []float64 {
+	cmyk := k.cmyk[:]
+	switch {
+	case space.Family() == color.FamilyDeviceGray:
+		cmyk[0], cmyk[1], cmyk[2] = 0, 0, 0
+		cmyk[3] = 1 - clip(values[0])
+	case space.Family() == color.FamilyDeviceCMYK,
+		space.Family() == color.FamilyICCBased && space.Channels() == 4:
+		for i := range cmyk {
+			cmyk[i] = clip(values[i])
+		}
+	default:
+		X, Y, Z := space.ToXYZ(values, &k.ws)
+		color.SpaceDeviceCMYK.FromXYZ(X, Y, Z, cmyk, &k.ws)
+		for i, v := range cmyk {
+			cmyk[i] = clip(v)
+		}
+	}
+	return cmyk
+}
+
+func clip(x float64) float64 {
+	return max(0, min(1, x))
+}
@@ -0,0 +1,320 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package preseparate
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/function"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/internal/colorants"
+	"seehuhn.de/go/pdf/internal/recolor"
+	"seehuhn.de/go/pdf/internal/rewrite"
+	"seehuhn.de/go/pdf/page/separation"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// Options control which plates are written.  A nil *Options writes the
+// plates of all colorants used on each page.
+type Options struct {
+	// Colorants, if not empty, restricts the output to the plates of the
+	// listed colorants.  Pages which use none of these colorants are
+	// omitted.
+	Colorants []pdf.Name
+}
+
+// droppedKeys lists the page dictionary entries which are not copied to the
+// plates.  Annotations and article beads refer to document structure which
+// is not copied, and thumbnails show the composite page.
+var droppedKeys = []pdf.Name{
+	"Contents", "Resources", "Annots", "B", "Thumb", "StructParents",
+	"SeparationInfo",
+}
+
+// Write reads the document from r and writes a preseparated version, with
+// one page per colorant plate, to w.
+func Write(w io.Writer, r pdf.Getter, opts *Options) error {
+	var only map[pdf.Name]bool
+	if opts != nil && len(opts.Colorants) > 0 {
+		only = make(map[pdf.Name]bool)
+		for _, name := range opts.Colorants {
+			only[name] = true
+		}
+	}
+
+	refs, dicts, err := rewrite.ReadPages(r)
+	if err != nil {
+		return err
+	}
+
+	// soft masks for converted colour key masks need PDF 1.4
+	version := max(pdf.GetVersion(r), pdf.V1_4)
+	out, err := rewrite.NewWriter(w, r, version)
+	if err != nil {
+		return err
+	}
+	rm := pdf.NewResourceManager(out)
+
+	// the colour analysis converts the pages once, to a writer whose output
+	// is discarded
+	scratch, err := pdf.NewWriter(io.Discard, version, nil)
+	if err != nil {
+		return err
+	}
+
+	s := &separator{
+		x:        pdf.NewExtractor(r),
+		out:      out,
+		rm:       rm,
+		copy:     pdf.NewCopier(out, r),
+		scratch:  pdf.NewResourceManager(scratch),
+		scratchC: pdf.NewCopier(scratch, r),
+		plates:   make(map[pdf.Name]*recolor.Rewriter),
+		spaces:   make(map[pdf.Name]color.Space),
+	}
+
+	// references to pages without plates are replaced by null
+	var null pdf.Reference
+
+	tree := pagetree.NewWriter(out, rm)
+	for i, dict := range dicts {
+		names, err := s.colorants(dict)
+		if err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+		if only != nil {
+			names = slices.DeleteFunc(names, func(name pdf.Name) bool { return !only[name] })
+		}
+		if len(names) == 0 {
+			if null == 0 {
+				null = out.Alloc()
+			}
+			s.copy.Redirect(refs[i], null)
+			continue
+		}
+
+		plateRefs := make([]pdf.Reference, len(names))
+		for j := range names {
+			plateRefs[j] = out.Alloc()
+		}
+		// references to the source page, for example from destinations in
+		// the page content, go to the first plate
+		s.copy.Redirect(refs[i], plateRefs[0])
+
+		for j, name := range names {
+			plate, err := s.plate(dict, plateRefs, name)
+			if err != nil {
+				return fmt.Errorf("page %d, %s plate: %w", i+1, name, err)
+			}
+			if err := tree.AppendPageDict(plateRefs[j], plate); err != nil {
+				return err
+			}
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		return err
+	}
+
+	if null != 0 {
+		if err := out.Put(null, nil); err != nil {
+			return err
+		}
+	}
+
+	// The page labels and the document parts would not match the plates,
+	// and the interactive form, the structure tree and the article threads
+	// refer to page content which is not copied.
+	metaIn := r.GetMeta()
+	meta := out.GetMeta()
+	meta.Info = metaIn.Info
+	err = rewrite.CopyCatalog(out, s.copy, metaIn.Catalog,
+		"PageLabels", "DPartRoot", "AcroForm", "StructTreeRoot", "MarkInfo",
+		"Threads")
+	if err != nil {
+		return err
+	}
+	meta.Catalog.Pages = pagesRef
+
+	if err := rm.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+type separator struct {
+	x    *pdf.Extractor
+	out  *pdf.Writer
+	rm   *pdf.ResourceManager
+	copy *pdf.Copier
+
+	// scratch and scratchC write the output of the colour analysis.
+	scratch  *pdf.ResourceManager
+	scratchC *pdf.Copier
+
+	// plates holds one Rewriter per colorant.  These are shared between
+	// pages, so that objects used on several pages are converted only once.
+	plates map[pdf.Name]*recolor.Rewriter
+
+	// spaces holds the Separation colour spaces describing the colorants.
+	spaces map[pdf.Name]color.Space
+}
+
+// colorants returns the colorants used on a page, process colorants first
+// and spot colorants in alphabetical order.  A page without any ink gets a
+// single Black plate, so that the page is not lost.
+func (s *separator) colorants(dict pdf.Dict) ([]pdf.Name, error) {
+	spots, err := colorants.Collect(s.x, dict["Resources"])
+	if err != nil {
+		return nil, err
+	}
+	for name, space := range spots {
+		if _, seen := s.spaces[name]; !seen {
+			s.spaces[name] = space
+		}
+	}
+
+	u := &usage{used: make(map[pdf.Name]bool)}
+	rw := recolor.New(s.x, s.scratch, s.scratchC, u)
+	if _, _, err := rw.Page(dict["Contents"], dict["Resources"]); err != nil {
+		return nil, err
+	}
+
+	var names []pdf.Name
+	for _, name := range processColorants {
+		if u.used[name] {
+			names = append(names, name)
+		}
+	}
+	var spotNames []pdf.Name
+	for name := range u.used {
+		if !colorants.IsProcess(name) {
+			spotNames = append(spotNames, name)
+		}
+	}
+	slices.Sort(spotNames)
+	names = append(names, spotNames...)
+
+	if len(names) == 0 {
+		names = append(names, "Black")
+	}
+	return names, nil
+}
+
+// plate writes the plate of one colorant for a source page, and returns the
+// page dictionary.
+func (s *separator) plate(src pdf.Dict, plateRefs []pdf.Reference, colorant pdf.Name) (pdf.Dict, error) {
+	rw := s.plates[colorant]
+	if rw == nil {
+		rw = recolor.New(s.x, s.rm, s.copy, &plateMapper{colorant: colorant})
+		s.plates[colorant] = rw
+	}
+	contents, res, err := rw.Page(src["Contents"], src["Resources"])
+	if err != nil {
+		return nil, err
+	}
+
+	rest := maps.Clone(src)
+	for _, key := range droppedKeys {
+		delete(rest, key)
+	}
+	dict, err := s.copy.CopyDict(rest)
+	if err != nil {
+		return nil, err
+	}
+	dict["Contents"] = contents
+	if res != nil {
+		dict["Resources"] = res
+	} else {
+		dict["Resources"] = pdf.Dict{}
+	}
+
+	info := &separation.Dict{
+		Pages:          plateRefs,
+		DeviceColorant: colorant,
+		ColorSpace:     s.space(colorant),
+	}
+	sepInfo, err := info.Encode(s.rm)
+	if err != nil {
+		return nil, err
+	}
+	dict["SeparationInfo"] = sepInfo
+	return dict, nil
+}
+
+// space returns a Separation colour space for a colorant.  Spot colorants
+// use the colour space found in the source document; process colorants, and
+// spot colorants whose colour space is not known, use the corresponding
+// DeviceCMYK colour.
+func (s *separator) space(colorant pdf.Name) color.Space {
+	if space, ok := s.spaces[colorant]; ok && space != nil {
+		return space
+	}
+
+	full := []float64{0, 0, 0, 1}
+	if i := slices.Index(processColorants, colorant); i >= 0 {
+		full = []float64{0, 0, 0, 0}
+		full[i] = 1
+	}
+	space, err := color.Separation(colorant, color.SpaceDeviceCMYK, &function.Type2{
+		XMin: 0,
+		XMax: 1,
+		C0:   []float64{0, 0, 0, 0},
+		C1:   full,
+		N:    1,
+	})
+	if err != nil {
+		return nil
+	}
+	s.spaces[colorant] = space
+	return space
+}
+
+// usage is a [recolor.Mapper] which records the colorants of all converted
+// colours.
+type usage struct {
+	inks inks
+	used map[pdf.Name]bool
+}
+
+func (u *usage) Target() color.Space { return color.SpaceDeviceGray }
+
+func (u *usage) Keep(color.Space) bool { return false }
+
+func (u *usage) Convert(space color.Space, values []float64, dst []float64) {
+	u.inks.record(space, values, u.used)
+	dst[0] = 0
+}
+
+// plateMapper is a [recolor.Mapper] which shows the amount of one colorant
+// as a gray level.
+type plateMapper struct {
+	inks     inks
+	colorant pdf.Name
+}
+
+func (m *plateMapper) Target() color.Space { return color.SpaceDeviceGray }
+
+func (m *plateMapper) Keep(color.Space) bool { return false }
+
+func (m *plateMapper) Convert(space color.Space, values []float64, dst []float64) {
+	dst[0] = 1 - m.inks.amount(space, values, m.colorant)
+}
@@ -0,0 +1,288 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package preseparate
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+
+	"seehuhn.de/go/xmp"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/function"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+	"seehuhn.de/go/pdf/internal/rewrite/rewritetest"
+	"seehuhn.de/go/pdf/page/separation"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// makeSource writes a one-page document which paints a black square using
+// DeviceCMYK and a square in the spot colour Gold at half tint.
+func makeSource(t *testing.T) *pdf.Reader {
+	t.Helper()
+
+	w, buf := memfile.NewPDFWriter(pdf.V1_7, nil)
+	rm := pdf.NewResourceManager(w)
+
+	gold, err := color.Separation("Gold", color.SpaceDeviceCMYK, &function.Type2{
+		XMin: 0,
+		XMax: 1,
+		C0:   []float64{0, 0, 0, 0},
+		C1:   []float64{0, 0.2, 0.8, 0.1},
+		N:    1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	goldObj, err := rm.Embed(gold)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contentRef := w.Alloc()
+	stm, err := w.OpenStream(contentRef, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := "0 0 0 1 k 0 0 10 10 re f\n/CS0 cs 0.5 sc 20 0 10 10 re f\n"
+	if _, err := stm.Write([]byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	if err := stm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := pagetree.NewWriter(w, rm)
+	err = tree.AppendPageDict(w.Alloc(), pdf.Dict{
+		"Type":     pdf.Name("Page"),
+		"MediaBox": &pdf.Rectangle{URx: 100, URy: 100},
+		"Resources": pdf.Dict{
+			"ColorSpace": pdf.Dict{"CS0": goldObj},
+		},
+		"Contents": contentRef,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref, err := tree.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.GetMeta().Catalog.Pages = ref
+	if err := rm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(bytes.NewReader(buf.Data), int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// separate runs Write and returns the reader for the result, together with
+// the page references and dictionaries.
+func separate(t *testing.T, r *pdf.Reader, opts *Options) (*pdf.Reader, []pdf.Reference, []pdf.Dict) {
+	t.Helper()
+
+	var out bytes.Buffer
+	if err := Write(&out, r, opts); err != nil {
+		t.Fatal(err)
+	}
+	rr, err := pdf.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var refs []pdf.Reference
+	var pages []pdf.Dict
+	for ref, dict := range pagetree.NewIterator(rr).All() {
+		refs = append(refs, ref)
+		pages = append(pages, dict)
+	}
+	return rr, refs, pages
+}
+
+func TestWrite(t *testing.T) {
+	r := makeSource(t)
+	rr, refs, pages := separate(t, r, nil)
+	if len(pages) != 2 {
+		t.Fatalf("got %d plates, want 2", len(pages))
+	}
+
+	cur := pdf.NewCursor(rr)
+	wantColorants := []pdf.Name{"Black", "Gold"}
+	wantFills := [][]string{
+		{"0 g", "1 g"},   // the Black plate shows the black square only
+		{"1 g", "0.5 g"}, // the Gold plate shows the spot colour square
+	}
+	for i, dict := range pages {
+		info, err := pdf.Decode(cur, dict["SeparationInfo"], separation.Decode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.DeviceColorant != wantColorants[i] {
+			t.Errorf("plate %d: colorant %q, want %q", i, info.DeviceColorant, wantColorants[i])
+		}
+		if !slices.Equal(info.Pages, refs) {
+			t.Errorf("plate %d: Pages %v, want %v", i, info.Pages, refs)
+		}
+		sep, ok := info.ColorSpace.(*color.SpaceSeparation)
+		if !ok || sep.Colorant != wantColorants[i] {
+			t.Errorf("plate %d: unexpected colour space %v", i, info.ColorSpace)
+		}
+
+		if fills := fillColors(t, rr, dict["Contents"]); !slices.Equal(fills, wantFills[i]) {
+			t.Errorf("plate %d: fill colours %q, want %q", i, fills, wantFills[i])
+		}
+	}
+}
+
+func TestSelectColorants(t *testing.T) {
+	r := makeSource(t)
+	rr, _, pages := separate(t, r, &Options{Colorants: []pdf.Name{"Gold", "Cyan"}})
+	if len(pages) != 1 {
+		t.Fatalf("got %d plates, want 1", len(pages))
+	}
+	info, err := pdf.Decode(pdf.NewCursor(rr), pages[0]["SeparationInfo"], separation.Decode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.DeviceColorant != "Gold" {
+		t.Errorf("got colorant %q, want Gold", info.DeviceColorant)
+	}
+}
+
+// TestCatalog checks that the document-level data which does not depend on
+// the page content is carried over, and that references to a page go to
+// its first plate.
+func TestCatalog(t *testing.T) {
+	r := rewritetest.Source(t)
+	rr, refs, _ := separate(t, r, nil)
+	if len(refs) != 3 {
+		t.Fatalf("got %d plates, want 3", len(refs))
+	}
+	cat := rr.GetMeta().Catalog
+
+	dc := &xmp.DublinCore{}
+	if cat.Metadata == nil {
+		t.Error("XMP metadata missing")
+	} else if err := cat.Metadata.Data.Get(dc); err != nil || dc.Title.Default.V != rewritetest.Title {
+		t.Errorf("title = %q, %v", dc.Title.Default.V, err)
+	}
+	if cat.Lang != language.German || cat.AA == nil || cat.Extensions == nil || cat.AF == nil {
+		t.Errorf("catalog entries lost: %+v", cat)
+	}
+	if cat.PageLabels != nil {
+		t.Errorf("unexpected page labels %v", cat.PageLabels)
+	}
+
+	cur := pdf.NewCursor(rr)
+	outlines, err := cur.Dict(cat.Outlines)
+	if err != nil || outlines == nil {
+		t.Fatalf("Outlines = %v, %v", outlines, err)
+	}
+	item, err := cur.Dict(outlines["First"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest, err := cur.Array(item["Dest"])
+	if err != nil || len(dest) == 0 || dest[0] != refs[1] {
+		t.Errorf("outline destination = %v, %v, want page %v", dest, err, refs[1])
+	}
+}
+
+func TestAmount(t *testing.T) {
+	duo, err := color.DeviceN([]pdf.Name{"Cyan", "Silver"}, color.SpaceDeviceCMYK, &function.Type4{
+		Domain:  []float64{0, 1, 0, 1},
+		Range:   []float64{0, 1, 0, 1, 0, 1, 0, 1},
+		Program: "0 0 3 -1 roll 2 div",
+	}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := color.Separation("All", color.SpaceDeviceGray, &function.Type2{
+		XMin: 0,
+		XMax: 1,
+		C0:   []float64{1},
+		C1:   []float64{0},
+		N:    1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var k inks
+	for _, test := range []struct {
+		space    color.Space
+		values   []float64
+		colorant pdf.Name
+		want     float64
+	}{
+		{duo, []float64{0.25, 0.75}, "Cyan", 0.25},
+		{duo, []float64{0.25, 0.75}, "Silver", 0.75},
+		{duo, []float64{0.25, 0.75}, "Black", 0},
+		{all, []float64{0.5}, "Magenta", 0.5},
+		{color.SpaceDeviceGray, []float64{0.25}, "Black", 0.75},
+		{color.SpaceDeviceGray, []float64{0.25}, "Cyan", 0},
+		{color.SpaceDeviceCMYK, []float64{0.1, 0.2, 0.3, 0.4}, "Yellow", 0.3},
+	} {
+		if got := k.amount(test.space, test.values, test.colorant); got != test.want {
+			t.Errorf("%s %v: %s amount %g, want %g",
+				test.space.Family(), test.values, test.colorant, got, test.want)
+		}
+	}
+}
+
+// fillColors returns the fill colour operators used for painting, that is
+// excluding those which set the initial colour of the page.
+func fillColors(t *testing.T, r *pdf.Reader, contents pdf.Object) []string {
+	t.Helper()
+	stm, err := pdf.NewCursor(r).Stream(contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := pdf.ReadAll(r, nil, stm, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	open := func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(data)), nil }
+	it := content.NewScanner(open).NewIter()
+	var current string
+	var res []string
+	for name, args := range it.All() {
+		switch name {
+		case content.OpSetFillGray:
+			var buf bytes.Buffer
+			_ = content.Operator{Name: name, Args: args}.Format(&buf)
+			current = strings.TrimSpace(buf.String())
+		case content.OpFill:
+			res = append(res, current)
+		}
+	}
+	return res
+}
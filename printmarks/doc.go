@@ -0,0 +1,40 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package printmarks adds printer's marks to the pages of a PDF document,
+// for prepress output.
+//
+// For every page, the media box is enlarged to leave room for the marks
+// around the page's bleed box, and the following marks are added:
+//
+//   - trim marks at the corners of the trim box;
+//   - bleed marks at the corners of the bleed box, if this differs from
+//     the trim box;
+//   - registration targets at the middle of each side;
+//   - a colour bar with full and half tint patches for the four process
+//     colorants and for the spot colorants used on the page;
+//   - a slug line with the file name, page number and date.
+//
+// Each group of marks is a separate printer's mark annotation, with a /MN
+// entry naming the kind of mark.  This allows a RIP or viewer to show or
+// hide the individual kinds of marks.  Marks, except for the colour bar, are
+// drawn in the special /All separation, so that they appear on every plate.
+//
+// The trim box of a page defaults to the crop box, and then to the media
+// box.  The bleed box defaults to the trim box.  The output pages carry
+// explicit /TrimBox and /BleedBox entries, and the crop box is set to the
+// new media box so that viewers show the marks.
+package printmarks
@@ -0,0 +1,364 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package printmarks
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/annotation"
+	"seehuhn.de/go/pdf/annotation/appearance"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/font/standard"
+	"seehuhn.de/go/pdf/function"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/content/builder"
+	"seehuhn.de/go/pdf/graphics/form"
+	"seehuhn.de/go/pdf/graphics/printermark"
+	"seehuhn.de/go/pdf/internal/colorants"
+)
+
+// slugFontSize is the largest font size used for the slug line.
+const slugFontSize = 6
+
+// marker holds the state shared while adding marks to one document.
+type marker struct {
+	x       *pdf.Extractor
+	out     *pdf.Writer
+	rm      *pdf.ResourceManager
+	copy    *pdf.Copier
+	opts    *Options
+	version pdf.Version
+
+	// registration is the /All separation, which marks every plate.
+	registration *color.SpaceSeparation
+
+	// process holds Separation colour spaces for the four process colorants.
+	process []*color.SpaceSeparation
+
+	font font.Layouter
+}
+
+func newMarker(r pdf.Getter, out *pdf.Writer, rm *pdf.ResourceManager, opts *Options) (*marker, error) {
+	c := &marker{
+		x:       pdf.NewExtractor(r),
+		out:     out,
+		rm:      rm,
+		copy:    pdf.NewCopier(out, r),
+		opts:    opts,
+		version: pdf.GetVersion(out),
+	}
+
+	var err error
+	c.registration, err = cmykSeparation("All", 1, 1, 1, 1)
+	if err != nil {
+		return nil, err
+	}
+	for i, name := range []pdf.Name{"Cyan", "Magenta", "Yellow", "Black"} {
+		var cmyk [4]float64
+		cmyk[i] = 1
+		sep, err := cmykSeparation(name, cmyk[0], cmyk[1], cmyk[2], cmyk[3])
+		if err != nil {
+			return nil, err
+		}
+		c.process = append(c.process, sep)
+	}
+
+	if opts.Marks&PageInformation != 0 {
+		c.font, err = standard.Helvetica.New()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// cmykSeparation returns a Separation colour space with a DeviceCMYK
+// alternate, where tint 1 maps to the given CMYK colour.
+func cmykSeparation(name pdf.Name, c, m, y, k float64) (*color.SpaceSeparation, error) {
+	return color.Separation(name, color.SpaceDeviceCMYK, &function.Type2{
+		XMin: 0,
+		XMax: 1,
+		C0:   []float64{0, 0, 0, 0},
+		C1:   []float64{c, m, y, k},
+		N:    1,
+	})
+}
+
+// marks returns the printer's mark annotations for one page.  The page
+// number is zero-based.
+func (c *marker) marks(boxes *pageBoxes, resources pdf.Object, pageNo int) ([]*annotation.PrinterMark, error) {
+	var res []*annotation.PrinterMark
+	add := func(name pdf.Name, style string, a *annotation.PrinterMark, err error) error {
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		if a != nil {
+			a.MarkName = name
+			a.Appearance.Normal.PrinterMark.MarkStyle = style
+			res = append(res, a)
+		}
+		return nil
+	}
+
+	marks := c.opts.Marks
+	if marks&TrimMarks != 0 {
+		a, err := c.cornerMarks(boxes.trim, boxes.bleed, c.opts.Length)
+		if err := add("TrimMarks", "Trim marks", a, err); err != nil {
+			return nil, err
+		}
+	}
+	if marks&BleedMarks != 0 && boxes.bleed != boxes.trim {
+		a, err := c.cornerMarks(boxes.bleed, boxes.bleed, c.opts.Length/2)
+		if err := add("BleedMarks", "Bleed marks", a, err); err != nil {
+			return nil, err
+		}
+	}
+	if marks&RegistrationTargets != 0 {
+		for _, center := range c.targetCenters(boxes) {
+			a, err := c.registrationTarget(center[0], center[1])
+			if err := add("RegistrationTarget", "Registration target", a, err); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if marks&ColorBars != 0 {
+		spots, err := colorants.Collect(c.x, resources)
+		if err != nil {
+			return nil, err
+		}
+		a, err := c.colorBar(boxes, spots)
+		if err := add("ColorBar", "Colour bar", a, err); err != nil {
+			return nil, err
+		}
+	}
+	if marks&PageInformation != 0 {
+		a, err := c.slug(boxes, pageNo)
+		if err := add("PageInformation", "Page information", a, err); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// cornerMarks draws pairs of lines which extend the edges of box at each
+// corner.  The lines start at the given distance outside the bleed box.
+func (c *marker) cornerMarks(box, bleed pdf.Rectangle, length float64) (*annotation.PrinterMark, error) {
+	d := c.opts.Offset
+	left := bleed.LLx - d
+	right := bleed.URx + d
+	bottom := bleed.LLy - d
+	top := bleed.URy + d
+
+	b := c.newBuilder()
+	b.SetStrokeColor(c.registration.New(1))
+	for _, x := range []float64{box.LLx, box.URx} {
+		b.MoveTo(round(x), round(bottom))
+		b.LineTo(round(x), round(bottom-length))
+		b.MoveTo(round(x), round(top))
+		b.LineTo(round(x), round(top+length))
+	}
+	for _, y := range []float64{box.LLy, box.URy} {
+		b.MoveTo(round(left), round(y))
+		b.LineTo(round(left-length), round(y))
+		b.MoveTo(round(right), round(y))
+		b.LineTo(round(right+length), round(y))
+	}
+	b.Stroke()
+
+	bbox := pdf.Rectangle{
+		LLx: left - length,
+		LLy: bottom - length,
+		URx: right + length,
+		URy: top + length,
+	}
+	return c.annotation(b, bbox, nil)
+}
+
+// targetCenters returns the centres of the registration targets, in the
+// middle of each side of the page.
+func (c *marker) targetCenters(boxes *pageBoxes) [][2]float64 {
+	t, bl := boxes.trim, boxes.bleed
+	band := c.opts.Offset + c.opts.Length/2
+	midX := (t.LLx + t.URx) / 2
+	midY := (t.LLy + t.URy) / 2
+	return [][2]float64{
+		{midX, bl.URy + band},
+		{bl.URx + band, midY},
+		{midX, bl.LLy - band},
+		{bl.LLx - band, midY},
+	}
+}
+
+// registrationTarget draws a circle with cross hairs and a solid centre.
+func (c *marker) registrationTarget(x, y float64) (*annotation.PrinterMark, error) {
+	arm := c.opts.Length / 2
+	r := c.opts.Length / 3
+	x, y = round(x), round(y)
+
+	b := c.newBuilder()
+	b.SetStrokeColor(c.registration.New(1))
+	b.Circle(x, y, round(r))
+	b.MoveTo(round(x-arm), y)
+	b.LineTo(round(x+arm), y)
+	b.MoveTo(x, round(y-arm))
+	b.LineTo(x, round(y+arm))
+	b.Stroke()
+	b.SetFillColor(c.registration.New(1))
+	b.Circle(x, y, round(r/3))
+	b.Fill()
+
+	bbox := pdf.Rectangle{LLx: x - arm, LLy: y - arm, URx: x + arm, URy: y + arm}
+	return c.annotation(b, bbox, nil)
+}
+
+// colorBar draws patches at full and half tint for the process colorants,
+// followed by the given spot colorants.  The bar is placed above the page,
+// to the right of the registration target.  If there is not enough space,
+// nil is returned.
+func (c *marker) colorBar(boxes *pageBoxes, spots map[pdf.Name]*color.SpaceSeparation) (*annotation.PrinterMark, error) {
+	spaces := slices.Clone(c.process)
+	for _, name := range slices.Sorted(maps.Keys(spots)) {
+		spaces = append(spaces, spots[name])
+	}
+	tints := []float64{1, 0.5}
+
+	t, bl := boxes.trim, boxes.bleed
+	d, l := c.opts.Offset, c.opts.Length
+	x0 := (t.LLx+t.URx)/2 + l/2 + d
+	x1 := t.URx - d
+	size := min(l*2/3, (x1-x0)/float64(len(spaces)*len(tints)))
+	if size < 1 {
+		return nil, nil
+	}
+	size = round(size)
+	y := round(bl.URy + d + (l-size)/2)
+
+	b := c.newBuilder()
+	x := round(x0)
+	for _, space := range spaces {
+		for _, tint := range tints {
+			b.SetFillColor(space.New(tint))
+			b.Rectangle(x, y, size, size)
+			b.Fill()
+			x = round(x + size)
+		}
+	}
+
+	used := make(map[pdf.Name]*color.SpaceSeparation, len(spaces))
+	for _, space := range spaces {
+		used[space.Colorant] = space
+	}
+	bbox := pdf.Rectangle{LLx: round(x0), LLy: y, URx: x, URy: y + size}
+	return c.annotation(b, bbox, used)
+}
+
+// slug draws the slug line below the page, to the left of the registration
+// target.  The font size is reduced if the text does not fit.
+func (c *marker) slug(boxes *pageBoxes, pageNo int) (*annotation.PrinterMark, error) {
+	parts := []string{
+		fmt.Sprintf("Page %d", pageNo+1),
+		c.opts.Date.Format("2006-01-02 15:04"),
+	}
+	if c.opts.FileName != "" {
+		parts = slices.Insert(parts, 0, c.opts.FileName)
+	}
+	text := strings.Join(parts, "   ")
+
+	t, bl := boxes.trim, boxes.bleed
+	d, l := c.opts.Offset, c.opts.Length
+	x0 := t.LLx + d
+	avail := (t.LLx+t.URx)/2 - l/2 - d - x0
+
+	size := min(float64(slugFontSize), l/2)
+	width := c.font.Layout(nil, size, text).TotalWidth()
+	if width > avail {
+		size *= avail / width
+		width = avail
+	}
+	if size < 1 {
+		return nil, nil
+	}
+
+	// centre the text vertically in the band
+	yMid := bl.LLy - d - l/2
+	geom := c.font.GetGeometry()
+	y := yMid - size*(geom.Ascent+geom.Descent)/2
+
+	b := c.newBuilder()
+	b.SetFillColor(c.registration.New(1))
+	b.TextBegin()
+	b.TextSetFont(c.font, round(size))
+	b.TextFirstLine(round(x0), round(y))
+	b.TextShow(text)
+	b.TextEnd()
+
+	bbox := pdf.Rectangle{
+		LLx: x0,
+		LLy: yMid - size/2,
+		URx: x0 + width,
+		URy: yMid + size/2,
+	}
+	return c.annotation(b, bbox, nil)
+}
+
+// newBuilder returns a content stream builder for the appearance of a mark.
+func (c *marker) newBuilder() *builder.Builder {
+	b := builder.New(content.Form, nil, c.version)
+	b.SetLineWidth(c.opts.LineWidth)
+	return b
+}
+
+// annotation wraps the content drawn by b into a printer's mark annotation.
+// The form uses default user space coordinates, and the annotation rectangle
+// is the bounding box, enlarged to cover the line width.
+func (c *marker) annotation(b *builder.Builder, bbox pdf.Rectangle, used map[pdf.Name]*color.SpaceSeparation) (*annotation.PrinterMark, error) {
+	ops, err := b.Harvest()
+	if err != nil {
+		return nil, err
+	}
+
+	w := c.opts.LineWidth
+	bbox = pdf.Rectangle{
+		LLx: bbox.LLx - w,
+		LLy: bbox.LLy - w,
+		URx: bbox.URx + w,
+		URy: bbox.URy + w,
+	}
+	bbox.IRound(coordDigits)
+
+	f := &form.Form{
+		Content:     ops,
+		Res:         b.Resources,
+		BBox:        bbox,
+		PrinterMark: &printermark.Attributes{Colorants: used},
+	}
+	return &annotation.PrinterMark{
+		Common: annotation.Common{
+			Rect:       bbox,
+			Appearance: &appearance.Dict{Normal: f},
+		},
+	}, nil
+}
+
+func round(x float64) float64 {
+	return pdf.Round(x, coordDigits)
+}
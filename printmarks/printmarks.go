@@ -0,0 +1,296 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package printmarks
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"time"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/annotation"
+	"seehuhn.de/go/pdf/internal/rewrite"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// coordDigits is the number of decimal digits kept for the coordinates of
+// the marks and the page boundaries.
+const coordDigits = 4
+
+// Marks is a set of kinds of printer's marks.
+type Marks uint
+
+const (
+	// TrimMarks are short lines which show where the printed sheet is cut.
+	TrimMarks Marks = 1 << iota
+
+	// BleedMarks are short lines which show the extent of the bleed area.
+	BleedMarks
+
+	// RegistrationTargets are circles with cross hairs, used to align the
+	// printing plates.
+	RegistrationTargets
+
+	// ColorBars are patches of the process and spot colorants, used to check
+	// ink density.
+	ColorBars
+
+	// PageInformation is a slug line with the file name, page number and
+	// date.
+	PageInformation
+
+	// AllMarks selects every kind of printer's mark.
+	AllMarks = TrimMarks | BleedMarks | RegistrationTargets | ColorBars | PageInformation
+)
+
+// Default values for the [Options] fields.
+const (
+	defaultLength    = 18   // 1/4 inch
+	defaultOffset    = 6    // 1/12 inch
+	defaultLineWidth = 0.25 // hairline
+)
+
+// Options control which printer's marks are added, and how they are drawn.
+// A nil *Options adds all marks with the default sizes.
+type Options struct {
+	// Marks selects the kinds of marks to add.  If this is zero, all marks
+	// are added.
+	Marks Marks
+
+	// Length is the length of the trim marks, in PDF units.  The marks are
+	// placed in a band of this width around the bleed box.  If this is
+	// zero, 18 points (1/4 inch) is used.
+	Length float64
+
+	// Offset is the distance between the bleed box and the marks, in PDF
+	// units.  If this is zero, 6 points is used.
+	Offset float64
+
+	// LineWidth is the width of the lines used to draw the marks, in PDF
+	// units.  If this is zero, 0.25 points is used.
+	LineWidth float64
+
+	// FileName is the name shown in the slug line.  If this is empty, the
+	// slug line only shows the page number and date.
+	FileName string
+
+	// Date is the date shown in the slug line.  If this is the zero time,
+	// the current time is used.
+	Date time.Time
+}
+
+// Write reads the document from r, adds printer's marks to every page, and
+// writes the result to w.
+func Write(w io.Writer, r pdf.Getter, opts *Options) error {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if o.Length < 0 || o.Offset < 0 || o.LineWidth < 0 {
+		return errors.New("negative printer's mark dimensions")
+	}
+	if o.Marks == 0 {
+		o.Marks = AllMarks
+	}
+	if o.Length == 0 {
+		o.Length = defaultLength
+	}
+	if o.Offset == 0 {
+		o.Offset = defaultOffset
+	}
+	if o.LineWidth == 0 {
+		o.LineWidth = defaultLineWidth
+	}
+	if o.Date.IsZero() {
+		o.Date = time.Now()
+	}
+
+	refs, dicts, err := rewrite.ReadPages(r)
+	if err != nil {
+		return err
+	}
+
+	// printer's mark annotations need PDF 1.4
+	version := max(pdf.GetVersion(r), pdf.V1_4)
+	out, err := rewrite.NewWriter(w, r, version)
+	if err != nil {
+		return err
+	}
+	rm := pdf.NewResourceManager(out)
+
+	c, err := newMarker(r, out, rm, &o)
+	if err != nil {
+		return err
+	}
+
+	newRefs := rewrite.RedirectPages(out, c.copy, refs)
+
+	tree := pagetree.NewWriter(out, rm)
+	for i, dict := range dicts {
+		newDict, err := c.markPage(newRefs[i], dict, i)
+		if err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+		if err := tree.AppendPageDict(newRefs[i], newDict); err != nil {
+			return err
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		return err
+	}
+
+	metaIn := r.GetMeta()
+	meta := out.GetMeta()
+	meta.Info = metaIn.Info
+	if err := rewrite.CopyCatalog(out, c.copy, metaIn.Catalog); err != nil {
+		return err
+	}
+	meta.Catalog.Pages = pagesRef
+
+	if err := rm.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// boxKeys lists the page boundary entries which are replaced on the output
+// pages.
+var boxKeys = []pdf.Name{"MediaBox", "CropBox", "BleedBox", "TrimBox"}
+
+// markPage copies a page dictionary and adds the printer's marks.
+func (c *marker) markPage(ref pdf.Reference, src pdf.Dict, pageNo int) (pdf.Dict, error) {
+	boxes, err := c.pageBoxes(src)
+	if err != nil {
+		return nil, err
+	}
+
+	src = maps.Clone(src)
+	annotsIn := src["Annots"]
+	delete(src, "Annots")
+	for _, key := range boxKeys {
+		delete(src, key)
+	}
+	dict, err := c.copy.CopyDict(src)
+	if err != nil {
+		return nil, err
+	}
+
+	media := boxes.media
+	trim := boxes.trim
+	bleed := boxes.bleed
+	dict["MediaBox"] = &media
+	dict["CropBox"] = &media
+	dict["BleedBox"] = &bleed
+	dict["TrimBox"] = &trim
+
+	var annots pdf.Array
+	if a, err := pdf.CursorAt(c.x, nil).Array(annotsIn); err == nil && a != nil {
+		annots, err = c.copy.CopyArray(a)
+		if err != nil {
+			return nil, err
+		}
+	} else if pdf.IsReadError(err) {
+		return nil, err
+	}
+
+	marks, err := c.marks(boxes, src["Resources"], pageNo)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range marks {
+		m.Page = ref
+		m.Flags = annotation.FlagPrint | annotation.FlagReadOnly
+		annotRef, err := c.rm.Store(m)
+		if err != nil {
+			return nil, err
+		}
+		annots = append(annots, annotRef)
+	}
+	if len(annots) > 0 {
+		dict["Annots"] = annots
+	}
+
+	return dict, nil
+}
+
+// pageBoxes describes the page boundaries of one output page.
+type pageBoxes struct {
+	media, bleed, trim pdf.Rectangle
+}
+
+// pageBoxes determines the trim and bleed boxes of a source page, and the
+// enlarged media box which holds the marks.
+func (c *marker) pageBoxes(dict pdf.Dict) (*pageBoxes, error) {
+	cur := pdf.CursorAt(c.x, nil)
+	media, err := cur.Rectangle(dict["MediaBox"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	} else if err != nil || media == nil || media.IsZero() {
+		return nil, errors.New("missing or invalid MediaBox")
+	}
+
+	// box returns the given page boundary, clipped to the media box
+	box := func(key pdf.Name, fallback pdf.Rectangle) (pdf.Rectangle, error) {
+		r, err := cur.Rectangle(dict[key])
+		if pdf.IsReadError(err) {
+			return fallback, err
+		} else if err != nil || r == nil {
+			return fallback, nil
+		}
+		clipped := r.Intersect(media)
+		if clipped == nil || clipped.IsZero() {
+			return fallback, nil
+		}
+		return *clipped, nil
+	}
+
+	crop, err := box("CropBox", *media)
+	if err != nil {
+		return nil, err
+	}
+	trim, err := box("TrimBox", crop)
+	if err != nil {
+		return nil, err
+	}
+	bleed, err := box("BleedBox", trim)
+	if err != nil {
+		return nil, err
+	}
+	bleed = pdf.Rectangle{
+		LLx: min(bleed.LLx, trim.LLx),
+		LLy: min(bleed.LLy, trim.LLy),
+		URx: max(bleed.URx, trim.URx),
+		URy: max(bleed.URy, trim.URy),
+	}
+
+	m := c.opts.Offset + c.opts.Length
+	res := &pageBoxes{
+		media: pdf.Rectangle{
+			LLx: min(media.LLx, bleed.LLx-m),
+			LLy: min(media.LLy, bleed.LLy-m),
+			URx: max(media.URx, bleed.URx+m),
+			URy: max(media.URy, bleed.URy+m),
+		},
+		bleed: bleed,
+		trim:  trim,
+	}
+	res.media.IRound(coordDigits)
+	return res, nil
+}
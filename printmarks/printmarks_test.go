@@ -0,0 +1,275 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package printmarks
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+	"time"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/function"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+	"seehuhn.de/go/pdf/internal/rewrite/rewritetest"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// makeSource writes a one-page document with a 100x200 trim box, 3 points
+// of bleed, a text annotation and a spot colour, and returns a reader for
+// it.
+func makeSource(t *testing.T) *pdf.Reader {
+	t.Helper()
+
+	w, buf := memfile.NewPDFWriter(pdf.V1_7, nil)
+	rm := pdf.NewResourceManager(w)
+
+	spot, err := color.Separation("Gold", color.SpaceDeviceCMYK, &function.Type2{
+		XMin: 0,
+		XMax: 1,
+		C0:   []float64{0, 0, 0, 0},
+		C1:   []float64{0, 0.2, 0.8, 0.1},
+		N:    1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	spotObj, err := rm.Embed(spot)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	contentRef := w.Alloc()
+	stm, err := w.OpenStream(contentRef, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stm.Write([]byte("/CS0 cs 1 scn 0 0 106 206 re f")); err != nil {
+		t.Fatal(err)
+	}
+	if err := stm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := pagetree.NewWriter(w, rm)
+	pageRef := w.Alloc()
+	dict := pdf.Dict{
+		"Type":     pdf.Name("Page"),
+		"MediaBox": &pdf.Rectangle{URx: 106, URy: 206},
+		"BleedBox": &pdf.Rectangle{URx: 106, URy: 206},
+		"TrimBox":  &pdf.Rectangle{LLx: 3, LLy: 3, URx: 103, URy: 203},
+		"Resources": pdf.Dict{
+			"ColorSpace": pdf.Dict{"CS0": spotObj},
+		},
+		"Contents": contentRef,
+		"Annots": pdf.Array{
+			pdf.Dict{
+				"Type":     pdf.Name("Annot"),
+				"Subtype":  pdf.Name("Text"),
+				"Rect":     &pdf.Rectangle{LLx: 10, LLy: 20, URx: 30, URy: 40},
+				"Contents": pdf.TextString("note"),
+				"P":        pageRef,
+			},
+		},
+	}
+	if err := tree.AppendPageDict(pageRef, dict); err != nil {
+		t.Fatal(err)
+	}
+	ref, err := tree.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.GetMeta().Catalog.Pages = ref
+	if err := rm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(bytes.NewReader(buf.Data), int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// mark runs Write and returns a reader for the result, together with the
+// first page dictionary.  The page reference is stored in the dictionary
+// under the key "Ref", for use by markNames.
+func mark(t *testing.T, r *pdf.Reader, opts *Options) (*pdf.Reader, pdf.Dict) {
+	t.Helper()
+
+	var out bytes.Buffer
+	if err := Write(&out, r, opts); err != nil {
+		t.Fatal(err)
+	}
+	rr, err := pdf.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ref, dict := range pagetree.NewIterator(rr).All() {
+		dict["Ref"] = ref
+		return rr, dict
+	}
+	t.Fatal("no pages")
+	return nil, nil
+}
+
+// markNames returns the /MN entries of the printer's mark annotations on a
+// page, and the number of other annotations.
+func markNames(t *testing.T, rr *pdf.Reader, page pdf.Dict) ([]pdf.Name, int) {
+	t.Helper()
+
+	c := pdf.NewCursor(rr)
+	annots, err := c.Array(page["Annots"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []pdf.Name
+	other := 0
+	for _, obj := range annots {
+		dict, err := c.Dict(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if subtype, _ := c.Name(dict["Subtype"]); subtype != "PrinterMark" {
+			other++
+			if dict["P"] != page["Ref"] {
+				t.Errorf("annotation refers to page %v, want %v", dict["P"], page["Ref"])
+			}
+			continue
+		}
+		if flags, _ := c.Integer(dict["F"]); flags != 4|64 {
+			t.Errorf("printer's mark has flags %d", flags)
+		}
+		name, _ := c.Name(dict["MN"])
+		names = append(names, name)
+	}
+	return names, other
+}
+
+func TestWrite(t *testing.T) {
+	r := makeSource(t)
+	rr, page := mark(t, r, &Options{
+		FileName: "test.pdf",
+		Date:     time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	})
+
+	c := pdf.NewCursor(rr)
+	media, err := c.Rectangle(page["MediaBox"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &pdf.Rectangle{LLx: -24, LLy: -24, URx: 130, URy: 230}
+	if !media.NearlyEqual(want, 1e-6) {
+		t.Errorf("MediaBox %v, want %v", media, want)
+	}
+	crop, err := c.Rectangle(page["CropBox"])
+	if err != nil || !crop.NearlyEqual(want, 1e-6) {
+		t.Errorf("CropBox %v, want %v", crop, want)
+	}
+	trim, err := c.Rectangle(page["TrimBox"])
+	if err != nil || !trim.NearlyEqual(&pdf.Rectangle{LLx: 3, LLy: 3, URx: 103, URy: 203}, 1e-6) {
+		t.Errorf("unexpected TrimBox %v", trim)
+	}
+
+	names, other := markNames(t, rr, page)
+	if other != 1 {
+		t.Errorf("got %d other annotations, want 1", other)
+	}
+	slices.Sort(names)
+	wantNames := []pdf.Name{
+		"BleedMarks", "ColorBar", "PageInformation",
+		"RegistrationTarget", "RegistrationTarget", "RegistrationTarget", "RegistrationTarget",
+		"TrimMarks",
+	}
+	if !slices.Equal(names, wantNames) {
+		t.Errorf("got marks %v, want %v", names, wantNames)
+	}
+}
+
+func TestColorBar(t *testing.T) {
+	r := makeSource(t)
+	rr, page := mark(t, r, &Options{Marks: ColorBars})
+
+	c := pdf.NewCursor(rr)
+	annots, err := c.Array(page["Annots"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var colorants pdf.Dict
+	for _, obj := range annots {
+		dict, _ := c.Dict(obj)
+		if name, _ := c.Name(dict["MN"]); name != "ColorBar" {
+			continue
+		}
+		ap, _ := c.Dict(dict["AP"])
+		stm, err := c.Stream(ap["N"])
+		if err != nil || stm == nil {
+			t.Fatalf("missing appearance stream: %v", err)
+		}
+		colorants, _ = c.Dict(stm.Dict["Colorants"])
+	}
+
+	for _, name := range []pdf.Name{"Cyan", "Magenta", "Yellow", "Black", "Gold"} {
+		if _, ok := colorants[name]; !ok {
+			t.Errorf("colorant %q missing from the colour bar", name)
+		}
+	}
+	if len(colorants) != 5 {
+		t.Errorf("got %d colorants, want 5", len(colorants))
+	}
+}
+
+func TestSelectMarks(t *testing.T) {
+	r := makeSource(t)
+	rr, page := mark(t, r, &Options{Marks: TrimMarks | PageInformation, Length: 10, Offset: 2})
+
+	names, _ := markNames(t, rr, page)
+	slices.Sort(names)
+	if want := []pdf.Name{"PageInformation", "TrimMarks"}; !slices.Equal(names, want) {
+		t.Errorf("got marks %v, want %v", names, want)
+	}
+
+	media, err := pdf.NewCursor(rr).Rectangle(page["MediaBox"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &pdf.Rectangle{LLx: -12, LLy: -12, URx: 118, URy: 218}
+	if !media.NearlyEqual(want, 1e-6) {
+		t.Errorf("MediaBox %v, want %v", media, want)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	r := rewritetest.Source(t)
+	res := rewritetest.Rewrite(t, r, func(w io.Writer, r pdf.Getter) error {
+		return Write(w, r, &Options{FileName: "test.pdf"})
+	})
+	rewritetest.CheckCatalog(t, res)
+}
+
+func TestInvalidOptions(t *testing.T) {
+	r := makeSource(t)
+	var out bytes.Buffer
+	if err := Write(&out, r, &Options{Length: -1}); err == nil {
+		t.Error("no error for negative length")
+	}
+}
@@ -0,0 +1,168 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"errors"
+
+	"seehuhn.de/go/pdf"
+)
+
+// PDF 2.0 sections: 14.8.2.2.2
+
+// Artifact represents the property list of an Artifact marked-content
+// sequence (PDF 2.0 Table 363).  Artifacts are page content which is not
+// part of the document's real content, for example running heads, page
+// numbers or watermarks.
+type Artifact struct {
+	// Type (optional) is the type of the artifact.  The defined values are
+	// Pagination, Layout, Page and Background (PDF 1.7).
+	Type pdf.Name
+
+	// Subtype (optional, PDF 1.7) gives the kind of a pagination artifact.
+	// The defined values are Header, Footer, Watermark, PageNum, Bates,
+	// LineNum and Redaction.
+	Subtype pdf.Name
+
+	// BBox (optional) is the bounding box of the artifact's visible
+	// content, in default user space.
+	BBox *pdf.Rectangle
+
+	// Attached (optional) lists the page edges to which the artifact is
+	// logically attached.  The defined values are Top, Bottom, Left and
+	// Right.
+	Attached []pdf.Name
+
+	// SingleUse controls whether the property list is embedded directly
+	// in the content stream (true) or as an indirect object via the
+	// Properties resource dictionary (false).
+	SingleUse bool
+}
+
+var _ List = (*Artifact)(nil)
+
+// AsDirectDict returns the property list as a direct PDF dictionary
+// if SingleUse is true.  Returns nil otherwise.
+func (a *Artifact) AsDirectDict() pdf.Dict {
+	if !a.SingleUse {
+		return nil
+	}
+	return a.asDict()
+}
+
+func (a *Artifact) asDict() pdf.Dict {
+	dict := pdf.Dict{}
+	if a.Type != "" {
+		dict["Type"] = a.Type
+	}
+	if a.Subtype != "" {
+		dict["Subtype"] = a.Subtype
+	}
+	if a.BBox != nil {
+		dict["BBox"] = a.BBox.AsPDF(0)
+	}
+	if len(a.Attached) > 0 {
+		attached := make(pdf.Array, len(a.Attached))
+		for i, name := range a.Attached {
+			attached[i] = name
+		}
+		dict["Attached"] = attached
+	}
+	return dict
+}
+
+// Equal reports whether two property lists are semantically equal.
+func (a *Artifact) Equal(other List) bool {
+	b, ok := other.(*Artifact)
+	if !ok {
+		return false
+	}
+	if a.Type != b.Type || a.Subtype != b.Subtype || a.SingleUse != b.SingleUse {
+		return false
+	}
+	if (a.BBox == nil) != (b.BBox == nil) || a.BBox != nil && !a.BBox.Equal(b.BBox) {
+		return false
+	}
+	if len(a.Attached) != len(b.Attached) {
+		return false
+	}
+	for i := range a.Attached {
+		if a.Attached[i] != b.Attached[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// ExtractArtifact extracts an Artifact property list from a PDF object.
+// Unknown or malformed entries are ignored.
+func ExtractArtifact(c pdf.Cursor, obj pdf.Object, isDirect bool) (*Artifact, error) {
+	dict, err := c.Dict(obj)
+	if err != nil {
+		return nil, err
+	}
+	if dict == nil {
+		return nil, errNoArtifact
+	}
+
+	a := &Artifact{
+		SingleUse: isDirect,
+	}
+	if tp, err := c.Name(dict["Type"]); err == nil {
+		a.Type = tp
+	}
+	if subtype, err := c.Name(dict["Subtype"]); err == nil {
+		a.Subtype = subtype
+	}
+	if bbox, err := c.Rectangle(dict["BBox"]); err == nil && bbox != nil {
+		a.BBox = bbox
+	}
+	if attached, err := c.Array(dict["Attached"]); err == nil {
+		for _, obj := range attached {
+			if name, err := c.Name(obj); err == nil {
+				a.Attached = append(a.Attached, name)
+			}
+		}
+	}
+	return a, nil
+}
+
+var errNoArtifact = &pdf.MalformedFileError{
+	Err: errors.New("missing Artifact property list"),
+}
+
+// Embed writes the property list to the PDF file.
+// This implements the [pdf.Embedder] interface.
+func (a *Artifact) Embed(rm *pdf.EmbedHelper) (pdf.Native, error) {
+	if a.Subtype != "" {
+		if err := pdf.CheckVersion(rm.Out(), "Artifact Subtype", pdf.V1_7); err != nil {
+			return nil, err
+		}
+	}
+
+	dict := a.asDict()
+	if a.SingleUse {
+		return dict, nil
+	}
+
+	ref := rm.Alloc()
+	err := rm.Out().Put(ref, dict)
+	if err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
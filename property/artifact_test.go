@@ -0,0 +1,93 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package property
+
+import (
+	"testing"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+)
+
+func TestArtifactRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name string
+		a    *Artifact
+	}{
+		{
+			name: "empty",
+			a:    &Artifact{SingleUse: true},
+		},
+		{
+			name: "pagination",
+			a: &Artifact{
+				Type:      "Pagination",
+				Subtype:   "Footer",
+				BBox:      &pdf.Rectangle{LLx: 72, LLy: 20, URx: 120, URy: 32},
+				Attached:  []pdf.Name{"Bottom"},
+				SingleUse: true,
+			},
+		},
+		{
+			name: "indirect",
+			a: &Artifact{
+				Type:     "Pagination",
+				Subtype:  "Bates",
+				Attached: []pdf.Name{"Bottom", "Right"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			w, _ := memfile.NewPDFWriter(pdf.V2_0, nil)
+			rm := pdf.NewResourceManager(w)
+
+			embedded, err := rm.Embed(tc.a)
+			if err != nil {
+				t.Fatalf("embed failed: %v", err)
+			}
+			err = rm.Close()
+			if err != nil {
+				t.Fatalf("rm.Close failed: %v", err)
+			}
+
+			x := pdf.NewExtractor(w)
+			decoded, err := ExtractArtifact(pdf.CursorAt(x, nil), embedded, tc.a.SingleUse)
+			if err != nil {
+				t.Fatalf("extract failed: %v", err)
+			}
+			if !tc.a.Equal(decoded) {
+				t.Errorf("got %#v, want %#v", decoded, tc.a)
+			}
+		})
+	}
+}
+
+func TestArtifactSubtypeVersion(t *testing.T) {
+	w, _ := memfile.NewPDFWriter(pdf.V1_6, nil)
+	rm := pdf.NewResourceManager(w)
+
+	_, err := rm.Embed(&Artifact{Type: "Pagination", Subtype: "Header", SingleUse: true})
+	if err == nil {
+		t.Error("Subtype accepted in PDF 1.6")
+	}
+	_, err = rm.Embed(&Artifact{Type: "Pagination", SingleUse: true})
+	if err != nil {
+		t.Error(err)
+	}
+}
@@ -44,7 +44,7 @@
 //	dict := propList.AsDirectDict() // nil if indirect
 //
 // To create property lists for writing, use the specific types like
-// [ActualText] (defined in this package) or
+// [ActualText] and [Artifact] (defined in this package) or
 // [seehuhn.de/go/pdf/file.AF] (defined alongside file specifications):
 //
 //	actual := &property.ActualText{Text: "Hello"}
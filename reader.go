@@ -21,8 +21,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
+	"maps"
 	"math"
 	"os"
+	"slices"
 )
 
 // ReaderOptions provides additional information for opening a PDF file.
@@ -298,6 +301,37 @@ func (r *Reader) GetMeta() *MetaInfo {
 	return &r.meta
 }
 
+// ObjectLocation describes where an object is stored in a PDF file.
+type ObjectLocation struct {
+	// ObjStm is the object stream which contains the object, or 0 if the
+	// object is stored directly in the file.
+	ObjStm Reference
+
+	// Offset is the byte offset of the object in the file, if ObjStm is 0.
+	// Otherwise, Offset is the index of the object within the object
+	// stream.
+	Offset int64
+}
+
+// Objects iterates over the objects listed in the cross-reference table of
+// the file, in order of increasing object number.  Free entries are
+// skipped.
+func (r *Reader) Objects() iter.Seq2[Reference, ObjectLocation] {
+	return func(yield func(Reference, ObjectLocation) bool) {
+		for _, number := range slices.Sorted(maps.Keys(r.xref)) {
+			entry := r.xref[number]
+			if number == 0 || entry.IsFree() {
+				continue
+			}
+			ref := NewReference(number, entry.Generation)
+			loc := ObjectLocation{ObjStm: entry.InStream, Offset: entry.Pos}
+			if !yield(ref, loc) {
+				return
+			}
+		}
+	}
+}
+
 // Get reads an indirect object from the PDF file.  If the object is not
 // present, nil is returned without an error.
 //
@@ -0,0 +1,200 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdf
+
+import (
+	"errors"
+	"io"
+	"maps"
+)
+
+// ReencryptOptions specifies the encryption of the output of [Reencrypt].
+type ReencryptOptions struct {
+	// UserPassword and OwnerPassword are the new passwords.  If both are
+	// empty, the output is not encrypted.
+	UserPassword  string
+	OwnerPassword string
+
+	// UserPermissions are the operations permitted when the document is
+	// opened with the user password.
+	UserPermissions Perm
+
+	// Encryption (optional) selects the encryption algorithm.  If this is
+	// nil, the strongest algorithm available for the PDF version is used.
+	Encryption *Encryption
+
+	// PlaintextMetadata, if true, leaves the XMP metadata stream of the
+	// document catalog unencrypted and uncompressed.
+	PlaintextMetadata bool
+}
+
+// Reencrypt writes a copy of the document r to w, replacing the encryption
+// of r with the encryption given by opt.  The reader must have been opened
+// with the user or the owner password, if r is encrypted.
+//
+// All objects keep their object numbers, and the file identifiers and the
+// XMP metadata are preserved.  Object streams and cross-reference
+// streams of r are not copied; the objects from object streams are written
+// as ordinary objects.  The PDF version is increased if the selected
+// encryption algorithm requires this.
+func Reencrypt(w io.Writer, r *Reader, opt *ReencryptOptions) error {
+	if opt == nil {
+		opt = &ReencryptOptions{}
+	}
+	if r.enc != nil && r.enc.sec.key == nil {
+		return &AuthenticationError{r.enc.sec.ID}
+	}
+
+	c := NewCursor(r)
+	catDict, err := c.Dict(r.meta.Trailer["Root"])
+	if err != nil {
+		return err
+	}
+	rootRef, _ := r.meta.Trailer["Root"].(Reference)
+	infoRef, _ := r.meta.Trailer["Info"].(Reference)
+	encRef, _ := r.meta.Trailer["Encrypt"].(Reference)
+	metaRef, _ := catDict["Metadata"].(Reference)
+
+	encrypt := opt.UserPassword != "" || opt.OwnerPassword != ""
+	v := r.meta.Version
+	var md *MetadataStream
+	if src := r.meta.Catalog.Metadata; src != nil && metaRef != 0 {
+		md = &MetadataStream{Data: src.Data, Plaintext: opt.PlaintextMetadata}
+		if md.Plaintext && encrypt {
+			v = max(v, V1_6)
+		}
+	}
+	if encrypt {
+		_, _, err := chooseCipher(v, opt.Encryption)
+		var verErr *VersionError
+		if errors.As(err, &verErr) {
+			v = verErr.Earliest
+		} else if err != nil {
+			return err
+		}
+	}
+
+	out, err := newWriter(w, v, &WriterOptions{
+		ID:               r.meta.ID,
+		UserPassword:     opt.UserPassword,
+		OwnerPassword:    opt.OwnerPassword,
+		UserPermissions:  opt.UserPermissions,
+		Encryption:       opt.Encryption,
+		DocumentMetadata: md,
+	})
+	if err != nil {
+		return err
+	}
+	for number := range r.xref {
+		out.nextRef = max(out.nextRef, number+1)
+	}
+
+	// The catalog, the Info dictionary and the metadata stream are copied
+	// like all other objects.  Pre-seeding the resource manager makes
+	// Close refer to the copies instead of writing new objects.  Plaintext
+	// metadata is written uncompressed, so that it can be found by tools
+	// which scan the file.
+	if rootRef == 0 {
+		return errors.New("document catalog is not an indirect object")
+	}
+	out.rm.embedded[out.meta.Catalog] = rootRef
+	if md != nil && !md.Plaintext {
+		out.rm.embedded[md] = metaRef
+	}
+	out.meta.Info = r.meta.Info
+	if out.meta.Info != nil && infoRef != 0 {
+		out.rm.embedded[out.meta.Info] = infoRef
+	}
+
+	for ref := range r.Objects() {
+		if ref == encRef {
+			continue
+		} else if ref == metaRef && md != nil && md.Plaintext {
+			if err := out.commitMetadata(metaRef, md); err != nil {
+				return err
+			}
+			continue
+		}
+		obj, err := r.Get(ref, true)
+		if IsReadError(err) {
+			return err
+		} else if err != nil || obj == nil {
+			continue // skip malformed objects
+		}
+
+		x, isStream := obj.(*Stream)
+		if !isStream {
+			if err := out.Put(ref, obj); err != nil {
+				return err
+			}
+			continue
+		}
+		if tp, _ := x.Dict["Type"].(Name); tp == "XRef" || tp == "ObjStm" {
+			continue
+		}
+		if err := reencryptStream(out, r, ref, x); err != nil {
+			return err
+		}
+	}
+
+	return out.Close()
+}
+
+// reencryptStream copies the stream x from r to w, keeping the reference
+// and the filters of the stream.  The stream data is decrypted, and then
+// encrypted again by w.
+func reencryptStream(w *Writer, r *Reader, ref Reference, x *Stream) error {
+	recipe, _, err := streamCryptRecipe(r, x)
+	if err != nil {
+		return err
+	}
+
+	dict := maps.Clone(x.Dict)
+	delete(dict, "Length")
+	for _, key := range []Name{"Filter", "DecodeParms"} {
+		val, ok := dict[key]
+		if !ok {
+			continue
+		}
+		inlined, err := inlineFilterRefs(r, val)
+		if err != nil {
+			return err
+		}
+		dict[key] = inlined
+	}
+	// An explicit /Crypt entry names a crypt filter of r, which may not
+	// exist in the output.
+	if recipe == cryptExplicit {
+		if err := DropCryptFilter(r, dict); err != nil {
+			return err
+		}
+	}
+
+	src, err := RawStreamReader(r, x)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	stm, err := w.OpenStream(ref, dict)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(stm, src); err != nil {
+		return err
+	}
+	return stm.Close()
+}
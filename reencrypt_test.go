@@ -0,0 +1,261 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pdf
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/text/language"
+	"seehuhn.de/go/xmp"
+)
+
+// writeReencryptBase writes an encrypted test document with XMP metadata,
+// an Info dictionary and a content stream.  The reference of the content
+// stream is returned.
+func writeReencryptBase(t *testing.T) ([]byte, Reference) {
+	t.Helper()
+
+	packet := xmp.NewPacket()
+	dc := &xmp.DublinCore{}
+	dc.Title.Set(language.Und, "Reencrypt Test")
+	if err := packet.Set(dc); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, V1_7, &WriterOptions{
+		UserPassword:     "user",
+		OwnerPassword:    "owner",
+		UserPermissions:  PermPrint,
+		Encryption:       &Encryption{Cipher: "RC4", KeyLength: 128},
+		DocumentMetadata: &MetadataStream{Data: packet},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	contents := w.Alloc()
+	stm, err := w.OpenStream(contents, nil, FilterFlate{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stm.Write([]byte("page content stream")); err != nil {
+		t.Fatal(err)
+	}
+	if err := stm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := addPage(w, Name("Contents"), contents, Name("Label"), String("secret string")); err != nil {
+		t.Fatal(err)
+	}
+	w.GetMeta().Info = &Info{Title: "Reencrypt Test"}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes(), contents
+}
+
+func TestReencrypt(t *testing.T) {
+	data, contents := writeReencryptBase(t)
+	r, err := NewReader(bytes.NewReader(data), int64(len(data)),
+		&ReaderOptions{Password: "user"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.GetMeta().Encryption.String() != "RC4 (128-bit)" {
+		t.Fatalf("wrong input encryption %s", r.GetMeta().Encryption)
+	}
+
+	type testCase struct {
+		name     string
+		opt      *ReencryptOptions
+		password string
+		version  Version
+		cipher   string
+		rawXMP   bool
+	}
+	cases := []testCase{
+		{"AES-128", &ReencryptOptions{
+			UserPassword: "new",
+			Encryption:   &Encryption{Cipher: "AES", KeyLength: 128},
+		}, "new", V1_7, "AES-128", false},
+		{"AES-256", &ReencryptOptions{
+			OwnerPassword: "new",
+			Encryption:    &Encryption{Cipher: "AES", KeyLength: 256},
+		}, "", V2_0, "AES-256", false},
+		{"plaintext metadata", &ReencryptOptions{
+			UserPassword:      "new",
+			PlaintextMetadata: true,
+		}, "new", V1_7, "AES-128", true},
+		{"decrypt", &ReencryptOptions{}, "", V1_7, "None", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			err := Reencrypt(buf, r, tc.opt)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := buf.Bytes()
+
+			if got := bytes.Contains(out, []byte("Reencrypt Test</rdf:li>")); got != tc.rawXMP {
+				t.Errorf("raw XMP in file: got %t, want %t", got, tc.rawXMP)
+			}
+
+			r2, err := NewReader(bytes.NewReader(out), int64(len(out)),
+				&ReaderOptions{Password: tc.password})
+			if err != nil {
+				t.Fatal(err)
+			}
+			meta := r2.GetMeta()
+			if meta.Version != tc.version {
+				t.Errorf("version: got %s, want %s", meta.Version, tc.version)
+			}
+			if got := meta.Encryption.String(); got != tc.cipher {
+				t.Errorf("encryption: got %s, want %s", got, tc.cipher)
+			}
+			if !bytes.Equal(meta.ID[0], r.GetMeta().ID[0]) {
+				t.Error("file identifier changed")
+			}
+			if meta.Trailer["Root"] != r.GetMeta().Trailer["Root"] {
+				t.Error("catalog reference changed")
+			}
+			if meta.Info == nil || meta.Info.Title != "Reencrypt Test" {
+				t.Errorf("wrong Info dictionary: %v", meta.Info)
+			}
+			if !meta.Catalog.Metadata.Equal(r.GetMeta().Catalog.Metadata) {
+				t.Error("XMP metadata changed")
+			}
+
+			// the content stream keeps its object number
+			stm, err := NewCursor(r2).Stream(contents)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := ReadAll(r2, nil, stm, 1<<20)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != "page content stream" {
+				t.Errorf("wrong content stream: %q", body)
+			}
+
+			page, err := NewCursor(r2).Dict(meta.Catalog.Pages)
+			if err != nil {
+				t.Fatal(err)
+			}
+			kids, _ := page["Kids"].(Array)
+			if len(kids) != 1 {
+				t.Fatal("wrong page tree")
+			}
+			pageDict, err := NewCursor(r2).Dict(kids[0])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if label, _ := pageDict["Label"].(String); string(label) != "secret string" {
+				t.Errorf("wrong string: %v", pageDict["Label"])
+			}
+		})
+	}
+}
+
+// TestReencryptIndirectFilter checks that an explicit crypt filter is
+// removed from a stream whose /Filter and /DecodeParms arrays are
+// indirect objects.
+func TestReencryptIndirectFilter(t *testing.T) {
+	buf := &bytes.Buffer{}
+	w, err := NewWriter(buf, V1_7, &WriterOptions{
+		UserPassword:  "secret",
+		OwnerPassword: "owner",
+		CryptFilters: &CryptFilters{
+			Named: map[Name]*CryptMethod{"RC4CF": {Cipher: "RC4"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// OpenStream inlines indirect filter entries, so the indirect arrays
+	// are written separately and substituted after reading the file.
+	filterRef := w.Alloc()
+	if err := w.Put(filterRef, Array{Name("Crypt"), Name("FlateDecode")}); err != nil {
+		t.Fatal(err)
+	}
+	parmsRef := w.Alloc()
+	if err := w.Put(parmsRef, Array{Dict{"Name": Name("RC4CF")}, nil}); err != nil {
+		t.Fatal(err)
+	}
+	contents := w.Alloc()
+	stm, err := w.OpenStream(contents, nil, FilterCryptNamed{Name: "RC4CF"}, FilterFlate{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stm.Write([]byte("explicitly encrypted")); err != nil {
+		t.Fatal(err)
+	}
+	if err := stm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := addPage(w, Name("Contents"), contents); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()),
+		&ReaderOptions{Password: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := NewCursor(r).Stream(contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.Dict["Filter"] = filterRef
+	x.Dict["DecodeParms"] = parmsRef
+
+	out := &bytes.Buffer{}
+	w2, err := NewWriter(out, V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := reencryptStream(w2, r, contents, x); err != nil {
+		t.Fatal(err)
+	}
+	if err := addPage(w2, Name("Contents"), contents); err != nil {
+		t.Fatal(err)
+	}
+	if err := w2.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r2, err := NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x2, err := NewCursor(r2).Stream(contents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter, _ := x2.Dict["Filter"].(Array); len(filter) != 1 || filter[0] != Name("FlateDecode") {
+		t.Errorf("wrong filter: %v", x2.Dict["Filter"])
+	}
+	got, err := readStreamData(r2, contents)
+	if err != nil || got != "explicitly encrypted" {
+		t.Errorf("got %q, %v", got, err)
+	}
+}
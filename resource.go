@@ -383,7 +383,15 @@ func (p *CycleCheck) depth() int {
 //
 // The Extractor is safe for concurrent use from multiple goroutines.
 type Extractor struct {
-	R     Getter
+	R Getter
+
+	// Repaired, if not nil, is called for every malformed-file error which
+	// is skipped by [Optional] while decoding objects through this
+	// extractor.  Decoders use Optional where they tolerate invalid input,
+	// so this lists the violations of the PDF specification which the
+	// decoders otherwise repair silently.
+	Repaired func(err error)
+
 	mu    sync.Mutex
 	cache map[extractorKey]any
 	wip   map[extractorKey]*pending // decodes in progress (DecodeExclusive)
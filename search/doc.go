@@ -0,0 +1,34 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package search finds text in PDF documents.
+//
+// [Find] searches the text on the pages of a document and returns the
+// location of each match as a list of quadrilaterals in default user space,
+// suitable for the QuadPoints entry of a text markup annotation.
+// [Highlights] converts the matches into highlight annotations.
+//
+// The text of a page is read from the page content stream and from the form
+// XObjects it draws, in content stream order.  Gaps between glyphs and line
+// breaks are turned into single spaces, so that a query can match across
+// lines.  A hyphen at the end of a line is removed when it joins two
+// letters, so that hyphenated words are found.  Soft hyphens (U+00AD) are
+// always ignored.  Glyphs inside an ActualText span are searched using the
+// replacement text.
+//
+// Queries are either literal strings or regular expressions.  Optionally,
+// letter case and diacritics are ignored.
+package search
@@ -0,0 +1,47 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package search
+
+import (
+	"seehuhn.de/go/pdf/annotation"
+	"seehuhn.de/go/pdf/annotation/fallback"
+	"seehuhn.de/go/pdf/graphics/color"
+)
+
+// Highlights returns a highlight annotation for each hit, in the given
+// colour.  The appearance streams of the annotations are generated using g.
+// The caller is responsible for adding each annotation to the page given
+// by the Page field of the corresponding hit.
+func Highlights(hits []*Hit, g *fallback.Generator, col color.Color) ([]*annotation.TextMarkup, error) {
+	res := make([]*annotation.TextMarkup, 0, len(hits))
+	for _, hit := range hits {
+		a := &annotation.TextMarkup{
+			Common: annotation.Common{
+				Flags: annotation.FlagPrint,
+				Color: col,
+			},
+			Type:       annotation.TextMarkupTypeHighlight,
+			QuadPoints: hit.QuadPoints,
+		}
+		// The generator also sets a.Rect to the bounding box of the quads.
+		if err := g.AddAppearance(a); err != nil {
+			return nil, err
+		}
+		res = append(res, a)
+	}
+	return res, nil
+}
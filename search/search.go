@@ -0,0 +1,248 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package search
+
+import (
+	"errors"
+	"math"
+	"regexp"
+
+	"golang.org/x/text/cases"
+
+	"seehuhn.de/go/geom/vec"
+	"seehuhn.de/go/postscript/cid"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/page"
+	"seehuhn.de/go/pdf/pagetree"
+	"seehuhn.de/go/pdf/reader"
+)
+
+// Options control how [Find] matches text.  The zero value (or a nil
+// *Options) searches every page for the literal query, taking letter case
+// and diacritics into account.
+type Options struct {
+	// Regexp indicates that the query is a regular expression, using the
+	// syntax of the [regexp] package.  Otherwise, the query is a literal
+	// string.
+	Regexp bool
+
+	// IgnoreCase makes the search case-insensitive.
+	IgnoreCase bool
+
+	// IgnoreDiacritics makes the search ignore accents and other
+	// combining marks, so that for example "resume" matches "résumé".
+	IgnoreDiacritics bool
+
+	// Pages selects the pages to search, as zero-based page indices.
+	// A nil slice searches every page.
+	Pages []int
+}
+
+// Hit describes one occurrence of the query.
+type Hit struct {
+	// Page is the zero-based index of the page.
+	Page int
+
+	// Text is the matched text, as it appears on the page.  Line breaks are
+	// represented by newlines.
+	Text string
+
+	// QuadPoints gives the location of the match in default user space.
+	// There is one quadrilateral for each line of text covered by the
+	// match.  Each quadrilateral is represented by 4 points, giving the
+	// corners in counter-clockwise order, starting at the bottom-left.
+	QuadPoints []vec.Vec2
+}
+
+// Find returns the occurrences of query in the document, in page order.
+// Matches on a page do not overlap.  Pages which cannot be decoded are
+// skipped.
+func Find(r pdf.Getter, query string, opts *Options) ([]*Hit, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	n := &normalizer{
+		fold:       cases.Fold(),
+		foldCase:   opts.IgnoreCase && !opts.Regexp,
+		diacritics: opts.IgnoreDiacritics,
+	}
+	pattern := n.normalize(query)
+	if !opts.Regexp {
+		pattern = regexp.QuoteMeta(pattern)
+	} else if opts.IgnoreCase {
+		pattern = "(?i)" + pattern
+	}
+	if pattern == "" {
+		return nil, errors.New("empty query")
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var want map[int]bool
+	if opts.Pages != nil {
+		want = make(map[int]bool, len(opts.Pages))
+		for _, p := range opts.Pages {
+			want[p] = true
+		}
+	}
+
+	x := pdf.NewExtractor(r)
+	extraText := map[font.Instance]map[cid.CID]string{}
+	var hits []*Hit
+	it := pagetree.NewIterator(r)
+	pageNo := 0
+	for _, dict := range it.All() {
+		if want == nil || want[pageNo] {
+			p, err := readPage(x, dict, n, extraText)
+			if err != nil {
+				return nil, err
+			}
+			if p != nil {
+				hits = append(hits, p.find(pageNo, re)...)
+			}
+		}
+		pageNo++
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	return hits, nil
+}
+
+// readPage collects the text of a page.  The result is nil if the page
+// cannot be decoded.
+func readPage(x *pdf.Extractor, dict pdf.Dict, n *normalizer, extraText map[font.Instance]map[cid.CID]string) (*pageText, error) {
+	pg, err := pdf.Decode(pdf.CursorAt(x, nil), dict, page.Decode)
+	if pdf.IsMalformed(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	c := &collector{
+		x:         x,
+		n:         n,
+		p:         &pageText{},
+		extraText: extraText,
+	}
+	r := reader.New(x)
+	r.State = content.NewState(content.Page, pg.Resources)
+	if err := c.run(r, pg.NewIter(), 0); pdf.IsMalformed(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return c.p, nil
+}
+
+// find returns the matches of re on the page.
+func (p *pageText) find(pageNo int, re *regexp.Regexp) []*Hit {
+	var hits []*Hit
+	for _, m := range re.FindAllIndex(p.text, -1) {
+		first, last := -1, -1
+		for _, g := range p.owner[m[0]:m[1]] {
+			if g < 0 {
+				continue
+			}
+			if first < 0 {
+				first = g
+			}
+			last = g
+		}
+		if first < 0 {
+			continue
+		}
+
+		// include all glyphs of ActualText spans touched by the match
+		first = p.glyphs[first].group
+		for last+1 < len(p.glyphs) && p.glyphs[last+1].group == p.glyphs[last].group {
+			last++
+		}
+
+		raw := p.raw.String()
+		hits = append(hits, &Hit{
+			Page:       pageNo,
+			Text:       raw[p.glyphs[first].rawStart:p.glyphs[last].rawEnd],
+			QuadPoints: p.quads(first, last),
+		})
+	}
+	return hits
+}
+
+// quads returns one quadrilateral for each line of text in the glyph range
+// first to last, inclusive.
+func (p *pageText) quads(first, last int) []vec.Vec2 {
+	var res []vec.Vec2
+	start := first
+	for i := first + 1; i <= last+1; i++ {
+		if i <= last && p.glyphs[i].line == p.glyphs[start].line &&
+			sameDirection(&p.glyphs[i], &p.glyphs[start]) {
+			continue
+		}
+		res = append(res, p.runQuad(start, i)...)
+		start = i
+	}
+	return res
+}
+
+// runQuad returns the smallest quadrilateral, aligned with the first glyph,
+// which contains the boxes of glyphs start to end-1.
+func (p *pageText) runQuad(start, end int) []vec.Vec2 {
+	c := p.glyphs[start].corners
+	origin := c[0]
+	u := c[1].Sub(c[0])
+	if u.Length() == 0 {
+		u = vec.Vec2{X: 1}
+	}
+	u = u.Normalize()
+	v := u.Rot90()
+
+	uMin, uMax := math.Inf(1), math.Inf(-1)
+	vMin, vMax := math.Inf(1), math.Inf(-1)
+	for i := start; i < end; i++ {
+		for _, pt := range p.glyphs[i].corners {
+			d := pt.Sub(origin)
+			a, b := d.Dot(u), d.Dot(v)
+			uMin, uMax = min(uMin, a), max(uMax, a)
+			vMin, vMax = min(vMin, b), max(vMax, b)
+		}
+	}
+
+	return []vec.Vec2{
+		origin.Add(u.Mul(uMin)).Add(v.Mul(vMin)),
+		origin.Add(u.Mul(uMax)).Add(v.Mul(vMin)),
+		origin.Add(u.Mul(uMax)).Add(v.Mul(vMax)),
+		origin.Add(u.Mul(uMin)).Add(v.Mul(vMax)),
+	}
+}
+
+// sameDirection reports whether two glyphs have the same baseline direction.
+func sameDirection(a, b *glyphBox) bool {
+	da := a.corners[1].Sub(a.corners[0])
+	db := b.corners[1].Sub(b.corners[0])
+	la, lb := da.Length(), db.Length()
+	if la == 0 || lb == 0 {
+		return true
+	}
+	return math.Abs(da.Cross(db))/(la*lb) < 0.01 && da.Dot(db) > 0
+}
@@ -0,0 +1,182 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package search
+
+import (
+	"testing"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/annotation/fallback"
+	"seehuhn.de/go/pdf/document"
+	"seehuhn.de/go/pdf/font/gofont"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+)
+
+// makeSource writes a one-page document with two lines of text, at
+// baselines y=700 and y=680.
+func makeSource(t *testing.T) *pdf.Reader {
+	t.Helper()
+
+	F, err := gofont.Regular.NewSimple(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := memfile.New()
+	doc, err := document.WriteMultiPage(buf, document.A4, pdf.V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := doc.AddPage()
+	p.TextBegin()
+	p.TextSetFont(F, 12)
+	p.TextFirstLine(72, 700)
+	p.TextShow("The quick brown fox jumps over the hyphen-")
+	p.TextSecondLine(0, -20)
+	p.TextShow("ated Résumé and the LAZY dog.")
+	p.TextEnd()
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(buf, int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestFind(t *testing.T) {
+	r := makeSource(t)
+
+	for _, test := range []struct {
+		query string
+		opts  *Options
+		want  []string
+	}{
+		{"quick brown", nil, []string{"quick brown"}},
+		{"the", nil, []string{"the", "the"}},
+		{"lazy", nil, nil},
+		{"lazy", &Options{IgnoreCase: true}, []string{"LAZY"}},
+		{"resume", &Options{IgnoreCase: true}, nil},
+		{"resume", &Options{IgnoreCase: true, IgnoreDiacritics: true}, []string{"Résumé"}},
+		{"hyphenated", nil, []string{"hyphen-\nated"}},
+		{"the hyphenated résumé", &Options{IgnoreCase: true}, []string{"the hyphen-\nated Résumé"}},
+		{`b[a-z]+n`, &Options{Regexp: true}, []string{"brown"}},
+		{`^the`, &Options{Regexp: true, IgnoreCase: true}, []string{"The"}},
+		{"fox", &Options{Pages: []int{1}}, nil},
+	} {
+		hits, err := Find(r, test.query, test.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, hit := range hits {
+			got = append(got, hit.Text)
+		}
+		if len(got) != len(test.want) {
+			t.Errorf("%q: got %q, want %q", test.query, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%q: got %q, want %q", test.query, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestQuadPoints(t *testing.T) {
+	r := makeSource(t)
+
+	hits, err := Find(r, "quick", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Page != 0 {
+		t.Fatalf("unexpected hits: %v", hits)
+	}
+	q := hits[0].QuadPoints
+	if len(q) != 4 {
+		t.Fatalf("expected one quadrilateral, got %d points", len(q))
+	}
+	// the quadrilateral is axis-aligned, starts after "The " and covers the
+	// baseline
+	if q[0].Y != q[1].Y || q[2].Y != q[3].Y || q[0].X != q[3].X || q[1].X != q[2].X {
+		t.Errorf("quadrilateral is not axis-aligned: %v", q)
+	}
+	if q[0].X <= 80 || q[1].X <= q[0].X || q[1].X > 72+12*6 {
+		t.Errorf("unexpected horizontal extent: %v", q)
+	}
+	if q[0].Y >= 700 || q[2].Y <= 700 || q[2].Y-q[0].Y > 15 {
+		t.Errorf("unexpected vertical extent: %v", q)
+	}
+
+	// a match across a line break has one quadrilateral per line
+	hits, err = Find(r, "hyphenated", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || len(hits[0].QuadPoints) != 8 {
+		t.Fatalf("unexpected hits: %v", hits)
+	}
+	q = hits[0].QuadPoints
+	if q[0].Y <= q[4].Y || q[4].X != 72 {
+		t.Errorf("unexpected quadrilaterals: %v", q)
+	}
+}
+
+func TestHighlights(t *testing.T) {
+	r := makeSource(t)
+
+	hits, err := Find(r, "the", &Options{IgnoreCase: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g, err := fallback.NewStyle().New(pdf.V1_7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	annots, err := Highlights(hits, g, color.DeviceRGB{1, 1, 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(annots) != 3 {
+		t.Fatalf("expected 3 annotations, got %d", len(annots))
+	}
+	for i, a := range annots {
+		if a.Appearance == nil || a.Appearance.Normal == nil {
+			t.Errorf("annotation %d has no appearance", i)
+		}
+		// allow for the rounding of the rectangle to two decimal places
+		rect := a.Rect
+		rect.LLx -= 0.005
+		rect.LLy -= 0.005
+		rect.URx += 0.005
+		rect.URy += 0.005
+		for _, p := range a.QuadPoints {
+			if !rect.Contains(p) {
+				t.Errorf("annotation %d: point %v outside of %v", i, p, a.Rect)
+			}
+		}
+	}
+}
@@ -0,0 +1,343 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package search
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/vec"
+	"seehuhn.de/go/postscript/cid"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/font/dict"
+	"seehuhn.de/go/pdf/font/textextract"
+	"seehuhn.de/go/pdf/graphics"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/form"
+	"seehuhn.de/go/pdf/reader"
+)
+
+// maxDepth limits the nesting of form XObjects.
+const maxDepth = 32
+
+// separator describes the gap before the next glyph.
+type separator uint8
+
+const (
+	sepNone separator = iota
+	sepSpace
+	sepLine
+)
+
+// glyphBox describes a glyph on the page.
+type glyphBox struct {
+	// corners holds the corners of the glyph box in default user space,
+	// counter-clockwise starting at the bottom left of the glyph.
+	corners [4]vec.Vec2
+
+	// line numbers the lines of text on the page.
+	line int
+
+	// group is the index of the first glyph of the ActualText span the
+	// glyph belongs to, or the index of the glyph itself.
+	group int
+
+	// rawStart and rawEnd give the position of the glyph's text in
+	// pageText.raw.
+	rawStart, rawEnd int
+}
+
+// pageText holds the text of a page.
+type pageText struct {
+	glyphs []glyphBox
+
+	// text is the normalised text used for searching, and owner gives the
+	// glyph index for every byte of text, or -1 for separators.
+	text  []byte
+	owner []int
+
+	// raw is the text as it appears on the page, with line breaks
+	// represented by newlines.
+	raw strings.Builder
+}
+
+// normalizer converts text to the form used for searching.
+type normalizer struct {
+	fold       cases.Caser
+	foldCase   bool
+	diacritics bool
+}
+
+// normalize returns the searchable form of text.  Runs of white space are
+// replaced by a single space and soft hyphens are removed.
+func (n *normalizer) normalize(text string) string {
+	if n.diacritics {
+		var b strings.Builder
+		for _, r := range norm.NFD.String(text) {
+			if !unicode.Is(unicode.Mn, r) {
+				b.WriteRune(r)
+			}
+		}
+		text = norm.NFC.String(b.String())
+	}
+	if n.foldCase {
+		text = n.fold.String(text)
+	}
+
+	var b strings.Builder
+	space := false
+	for _, r := range text {
+		switch {
+		case r == '\u00ad': // soft hyphen
+			continue
+		case unicode.IsSpace(r):
+			if !space {
+				b.WriteByte(' ')
+			}
+			space = true
+		default:
+			b.WriteRune(r)
+			space = false
+		}
+	}
+	return b.String()
+}
+
+// collector gathers the text of a page.
+type collector struct {
+	x *pdf.Extractor
+	n *normalizer
+	p *pageText
+
+	line    int
+	pending separator
+
+	extraText map[font.Instance]map[cid.CID]string
+
+	// actual is the replacement text of the current ActualText span, and
+	// group is the index of the first glyph in the span, or -1 if no glyph
+	// has been seen yet.
+	actual   string
+	inActual bool
+	group    int
+}
+
+// run reads a content stream using the reader r.
+func (c *collector) run(r *reader.Reader, it content.Iter, depth int) error {
+	r.TextEvent = func(event reader.TextEvent, _ float64) {
+		switch event {
+		case reader.TextEventSpace:
+			c.space()
+		case reader.TextEventNL:
+			c.newLine()
+		}
+	}
+	r.ActualText = func(event reader.ActualTextEvent, text string) error {
+		switch event {
+		case reader.ActualTextBegin:
+			c.actual, c.inActual, c.group = text, true, -1
+		case reader.ActualTextEnd:
+			c.inActual = false
+		}
+		return nil
+	}
+	r.Character = func(code font.Code) error {
+		c.character(r.State.GState, code)
+		return nil
+	}
+	r.XObject = func(obj graphics.XObject, ctm matrix.Matrix) error {
+		f, ok := obj.(*form.Form)
+		if !ok || f.Content == nil || depth >= maxDepth {
+			return nil
+		}
+
+		inner := reader.New(c.x)
+		inner.State = content.NewState(content.Form, f.Res)
+		inner.State.GState.CTM = f.Matrix.Mul(ctm)
+		c.newLine()
+		err := c.run(inner, f.Content.NewIter(), depth+1)
+		c.newLine()
+		return err
+	}
+	return r.ProcessIter(it)
+}
+
+// space records a gap between words.
+func (c *collector) space() {
+	if c.pending == sepNone {
+		c.pending = sepSpace
+	}
+}
+
+// newLine records a line break.
+func (c *collector) newLine() {
+	if c.pending != sepLine {
+		c.pending = sepLine
+		c.line++
+	}
+}
+
+// character adds a glyph to the page text.
+func (c *collector) character(gs *graphics.State, code font.Code) {
+	p := c.p
+
+	text := code.Text
+	if text == "" && gs.TextFont != nil {
+		m, ok := c.extraText[gs.TextFont]
+		if !ok {
+			m = textextract.GlyphNameMapping(gs.TextFont)
+			c.extraText[gs.TextFont] = m
+		}
+		text = m[code.CID]
+	}
+
+	idx := len(p.glyphs)
+	group := idx
+	if c.inActual {
+		if c.group >= 0 {
+			group = c.group
+			text = ""
+		} else {
+			c.group = idx
+			text = c.actual
+		}
+	}
+
+	switch c.pending {
+	case sepLine:
+		if p.endsWithHyphen() && startsWithLetter(text) {
+			_, size := utf8.DecodeLastRune(p.text)
+			p.text = p.text[:len(p.text)-size]
+			p.owner = p.owner[:len(p.owner)-size]
+		} else {
+			p.appendSeparator()
+		}
+		p.raw.WriteByte('\n')
+	case sepSpace:
+		p.appendSeparator()
+		p.raw.WriteByte(' ')
+	}
+	c.pending = sepNone
+
+	normalized := c.n.normalize(text)
+	if len(p.text) == 0 || p.text[len(p.text)-1] == ' ' {
+		normalized = strings.TrimPrefix(normalized, " ")
+	}
+	p.text = append(p.text, normalized...)
+	for range len(normalized) {
+		p.owner = append(p.owner, idx)
+	}
+
+	box := glyphBox{
+		corners:  glyphCorners(gs, code),
+		line:     c.line,
+		group:    group,
+		rawStart: p.raw.Len(),
+	}
+	p.raw.WriteString(text)
+	box.rawEnd = p.raw.Len()
+	p.glyphs = append(p.glyphs, box)
+}
+
+// appendSeparator adds a space to the searchable text, unless the text is
+// empty or already ends in a space.
+func (p *pageText) appendSeparator() {
+	if len(p.text) == 0 || p.text[len(p.text)-1] == ' ' {
+		return
+	}
+	p.text = append(p.text, ' ')
+	p.owner = append(p.owner, -1)
+}
+
+// endsWithHyphen reports whether the searchable text ends in a hyphen
+// which follows a letter.
+func (p *pageText) endsWithHyphen() bool {
+	last, size := utf8.DecodeLastRune(p.text)
+	if last != '-' && last != '\u2010' {
+		return false
+	}
+	prev, _ := utf8.DecodeLastRune(p.text[:len(p.text)-size])
+	return unicode.IsLetter(prev)
+}
+
+// startsWithLetter reports whether text starts with a letter.
+func startsWithLetter(text string) bool {
+	r, _ := utf8.DecodeRuneInString(text)
+	return unicode.IsLetter(r)
+}
+
+// glyphCorners returns the corners of the box of a glyph, in default user
+// space.  The box covers the advance width of the glyph and the ascent and
+// descent of the font.
+func glyphCorners(gs *graphics.State, code font.Code) [4]vec.Vec2 {
+	ascent, descent := fontExtent(gs.TextFont)
+
+	var x0, x1, y0, y1 float64
+	if gs.TextFont != nil && gs.TextFont.WritingMode() == font.Vertical {
+		x0, x1 = -0.5, 0.5
+		y0, y1 = code.VerticalAdvance, 0
+		if y0 > y1 {
+			y0, y1 = y1, y0
+		}
+	} else {
+		x0, x1 = 0, code.Width
+		y0, y1 = descent, ascent
+	}
+
+	M := gs.TextRenderingMatrix()
+	return [4]vec.Vec2{
+		M.Apply(vec.Vec2{X: x0, Y: y0}),
+		M.Apply(vec.Vec2{X: x1, Y: y0}),
+		M.Apply(vec.Vec2{X: x1, Y: y1}),
+		M.Apply(vec.Vec2{X: x0, Y: y1}),
+	}
+}
+
+// fontExtent returns the ascent and descent of a font, in units of the font
+// size.
+func fontExtent(f font.Instance) (ascent, descent float64) {
+	if g, ok := f.(interface{ GetGeometry() *font.Geometry }); ok {
+		if geom := g.GetGeometry(); geom != nil && geom.Ascent > geom.Descent {
+			return geom.Ascent, geom.Descent
+		}
+	}
+
+	var desc *font.Descriptor
+	if fd, ok := f.(interface{ GetDict() dict.Dict }); ok {
+		switch d := fd.GetDict().(type) {
+		case *dict.Type1:
+			desc = d.Descriptor
+		case *dict.TrueType:
+			desc = d.Descriptor
+		case *dict.CIDFontType0:
+			desc = d.Descriptor
+		case *dict.CIDFontType2:
+			desc = d.Descriptor
+		}
+	}
+	if desc != nil && desc.Ascent > desc.Descent {
+		return desc.Ascent / 1000, desc.Descent / 1000
+	}
+	return 0.8, -0.2
+}
@@ -0,0 +1,32 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package signature implements long-term validation data and document
+// time-stamps for signed PDF documents.
+//
+// PAdES signatures (ETSI EN 319 142) can be kept verifiable after the
+// signing certificates have expired, by storing the data needed for
+// validation inside the document and by protecting this data with a
+// document time-stamp.  The validation data is stored in the document
+// security store (DSS), described by [DSS].  Document time-stamps are
+// signatures of type DocTimeStamp, whose contents is an RFC 3161
+// time-stamp token obtained from a [TimeStampAuthority].
+//
+// [AddValidationData] and [AddDocTimeStamp] modify documents using
+// incremental updates, so that existing signatures remain valid.
+// [Signatures] lists the signatures of a document, and [ReadDSS] reads
+// the document security store.
+package signature
@@ -0,0 +1,374 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package signature
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/internal/limits"
+)
+
+// PDF 2.0 sections: 12.8.4.3 12.8.4.4
+
+// DSS represents a document security store dictionary.  This contains
+// data needed to validate the signatures in the document.
+type DSS struct {
+	// Certs (optional) contains DER-encoded X.509 certificates used in
+	// the validation of signatures.
+	//
+	// This corresponds to the /Certs entry in the PDF dictionary.
+	Certs [][]byte
+
+	// OCSPs (optional) contains DER-encoded OCSP responses (RFC 6960).
+	//
+	// This corresponds to the /OCSPs entry in the PDF dictionary.
+	OCSPs [][]byte
+
+	// CRLs (optional) contains DER-encoded certificate revocation lists
+	// (RFC 5280).
+	//
+	// This corresponds to the /CRLs entry in the PDF dictionary.
+	CRLs [][]byte
+
+	// VRI (optional) maps signatures to the validation data used for
+	// them.  The keys are computed by [VRIKey].
+	//
+	// This corresponds to the /VRI entry in the PDF dictionary.
+	VRI map[string]*VRI
+}
+
+// VRI represents a validation-related information dictionary.  This
+// identifies the validation data used for a single signature.
+type VRI struct {
+	// Certs (optional) contains DER-encoded X.509 certificates.
+	//
+	// This corresponds to the /Cert entry in the PDF dictionary.
+	Certs [][]byte
+
+	// OCSPs (optional) contains DER-encoded OCSP responses.
+	//
+	// This corresponds to the /OCSP entry in the PDF dictionary.
+	OCSPs [][]byte
+
+	// CRLs (optional) contains DER-encoded certificate revocation lists.
+	//
+	// This corresponds to the /CRL entry in the PDF dictionary.
+	CRLs [][]byte
+
+	// TU (optional) is the time at which the validation data was
+	// collected.
+	//
+	// This corresponds to the /TU entry in the PDF dictionary.
+	TU time.Time
+
+	// TS (optional) is a DER-encoded RFC 3161 time-stamp token, which
+	// records the time at which the validation data was collected.
+	//
+	// This corresponds to the /TS entry in the PDF dictionary.
+	TS []byte
+}
+
+// VRIKey returns the key used in the VRI dictionary for the signature
+// with the given Contents value.  The key is the upper-case hexadecimal
+// representation of the SHA-1 hash of the Contents string.
+func VRIKey(contents []byte) string {
+	sum := sha1.Sum(contents)
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+var _ pdf.Embedder = (*DSS)(nil)
+
+// ReadDSS reads the document security store of a PDF document.
+// If the document has no DSS dictionary, nil is returned.
+func ReadDSS(r pdf.Getter) (*DSS, error) {
+	obj := r.GetMeta().Catalog.DSS
+	if obj == nil {
+		return nil, nil
+	}
+	return pdf.Decode(pdf.NewCursor(r), obj, ExtractDSS)
+}
+
+// ExtractDSS extracts a document security store from a PDF file.
+func ExtractDSS(c pdf.Cursor, obj pdf.Object, _ bool) (*DSS, error) {
+	dict, err := c.DictTyped(obj, "DSS")
+	if err != nil {
+		return nil, err
+	} else if dict == nil {
+		return nil, pdf.Error("missing DSS dictionary")
+	}
+
+	d := &DSS{}
+	if d.Certs, err = readDataArray(c, dict["Certs"]); err != nil {
+		return nil, err
+	}
+	if d.OCSPs, err = readDataArray(c, dict["OCSPs"]); err != nil {
+		return nil, err
+	}
+	if d.CRLs, err = readDataArray(c, dict["CRLs"]); err != nil {
+		return nil, err
+	}
+
+	vriDict, err := c.Dict(dict["VRI"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	for key, val := range vriDict {
+		vri, err := pdf.Optional(extractVRI(c, val))
+		if err != nil {
+			return nil, err
+		} else if vri == nil {
+			continue
+		}
+		if d.VRI == nil {
+			d.VRI = make(map[string]*VRI)
+		}
+		d.VRI[strings.ToUpper(string(key))] = vri
+	}
+
+	return d, nil
+}
+
+func extractVRI(c pdf.Cursor, obj pdf.Object) (*VRI, error) {
+	dict, err := c.DictTyped(obj, "VRI")
+	if err != nil {
+		return nil, err
+	} else if dict == nil {
+		return nil, pdf.Error("missing VRI dictionary")
+	}
+
+	vri := &VRI{}
+	if vri.Certs, err = readDataArray(c, dict["Cert"]); err != nil {
+		return nil, err
+	}
+	if vri.OCSPs, err = readDataArray(c, dict["OCSP"]); err != nil {
+		return nil, err
+	}
+	if vri.CRLs, err = readDataArray(c, dict["CRL"]); err != nil {
+		return nil, err
+	}
+	if tu, err := pdf.Optional(c.Date(dict["TU"])); err != nil {
+		return nil, err
+	} else {
+		vri.TU = time.Time(tu)
+	}
+	if dict["TS"] != nil {
+		ts, err := pdf.Optional(c.ReadAll(dict["TS"], limits.MaxValidationDataBytes))
+		if err != nil {
+			return nil, err
+		}
+		vri.TS = ts
+	}
+	return vri, nil
+}
+
+// readDataArray reads an array of streams.  Malformed entries are skipped.
+func readDataArray(c pdf.Cursor, obj pdf.Object) ([][]byte, error) {
+	a, err := c.Array(obj)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	var res [][]byte
+	for _, elem := range a {
+		data, err := c.ReadAll(elem, limits.MaxValidationDataBytes)
+		if pdf.IsReadError(err) {
+			return nil, err
+		} else if err != nil || len(data) == 0 {
+			continue
+		}
+		res = append(res, data)
+	}
+	return res, nil
+}
+
+// Embed adds the document security store to a PDF file.
+//
+// This implements the [pdf.Embedder] interface.
+func (d *DSS) Embed(rm *pdf.EmbedHelper) (pdf.Native, error) {
+	return d.embed(rm, nil)
+}
+
+// embed writes the DSS dictionary.  Data which is already present in the
+// file is taken from known, which maps the contents of data streams to
+// their references.
+func (d *DSS) embed(rm *pdf.EmbedHelper, known map[string]pdf.Reference) (pdf.Native, error) {
+	if err := pdf.CheckVersion(rm.Out(), "document security store", pdf.V1_7); err != nil {
+		return nil, err
+	}
+
+	dataArray := func(data [][]byte) (pdf.Array, error) {
+		var res pdf.Array
+		for _, item := range data {
+			if len(item) == 0 {
+				return nil, errors.New("empty validation data")
+			}
+			if ref, ok := known[string(item)]; ok {
+				res = append(res, ref)
+				continue
+			}
+			ref, err := rm.Embed(dataStream(item))
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, ref)
+		}
+		return res, nil
+	}
+
+	dict := pdf.Dict{}
+	if rm.Out().GetOptions().HasAny(pdf.OptDictTypes) {
+		dict["Type"] = pdf.Name("DSS")
+	}
+	for _, entry := range []struct {
+		key  pdf.Name
+		data [][]byte
+	}{{"Certs", d.Certs}, {"OCSPs", d.OCSPs}, {"CRLs", d.CRLs}} {
+		if len(entry.data) == 0 {
+			continue
+		}
+		a, err := dataArray(entry.data)
+		if err != nil {
+			return nil, err
+		}
+		dict[entry.key] = a
+	}
+
+	if len(d.VRI) > 0 {
+		vriDict := pdf.Dict{}
+		for _, key := range slices.Sorted(maps.Keys(d.VRI)) {
+			vri := d.VRI[key]
+			if vri == nil {
+				continue
+			}
+			if len(key) != 40 || strings.ToUpper(key) != key {
+				return nil, errors.New("invalid VRI key " + key)
+			}
+
+			entry := pdf.Dict{}
+			if rm.Out().GetOptions().HasAny(pdf.OptDictTypes) {
+				entry["Type"] = pdf.Name("VRI")
+			}
+			for _, e := range []struct {
+				key  pdf.Name
+				data [][]byte
+			}{{"Cert", vri.Certs}, {"OCSP", vri.OCSPs}, {"CRL", vri.CRLs}} {
+				if len(e.data) == 0 {
+					continue
+				}
+				a, err := dataArray(e.data)
+				if err != nil {
+					return nil, err
+				}
+				entry[e.key] = a
+			}
+			if !vri.TU.IsZero() {
+				entry["TU"] = pdf.Date(vri.TU)
+			}
+			if len(vri.TS) > 0 {
+				ts, err := rm.Embed(dataStream(vri.TS))
+				if err != nil {
+					return nil, err
+				}
+				entry["TS"] = ts
+			}
+			vriDict[pdf.Name(key)] = entry
+		}
+		dict["VRI"] = vriDict
+	}
+
+	ref := rm.Alloc()
+	err := rm.Out().Put(ref, dict)
+	if err != nil {
+		return nil, err
+	}
+	return ref, nil
+}
+
+// merge adds the validation data from other to d.  Duplicate entries are
+// omitted.
+func (d *DSS) merge(other *DSS) {
+	d.Certs = appendNew(d.Certs, other.Certs)
+	d.OCSPs = appendNew(d.OCSPs, other.OCSPs)
+	d.CRLs = appendNew(d.CRLs, other.CRLs)
+	for key, vri := range other.VRI {
+		if vri == nil {
+			continue
+		}
+		if d.VRI == nil {
+			d.VRI = make(map[string]*VRI)
+		}
+		old := d.VRI[key]
+		if old == nil {
+			d.VRI[key] = vri
+			continue
+		}
+		merged := &VRI{
+			Certs: appendNew(slices.Clone(old.Certs), vri.Certs),
+			OCSPs: appendNew(slices.Clone(old.OCSPs), vri.OCSPs),
+			CRLs:  appendNew(slices.Clone(old.CRLs), vri.CRLs),
+			TU:    old.TU,
+			TS:    old.TS,
+		}
+		if !vri.TU.IsZero() {
+			merged.TU = vri.TU
+		}
+		if len(vri.TS) > 0 {
+			merged.TS = vri.TS
+		}
+		d.VRI[key] = merged
+	}
+}
+
+// appendNew appends the elements of b which are not yet contained in a.
+func appendNew(a, b [][]byte) [][]byte {
+	for _, item := range b {
+		if !slices.ContainsFunc(a, func(x []byte) bool { return bytes.Equal(x, item) }) {
+			a = append(a, item)
+		}
+	}
+	return a
+}
+
+// dataStream is a stream containing validation data.  Using the contents
+// as the key in the resource manager ensures that every item is only
+// stored once.
+type dataStream string
+
+// Embed implements the [pdf.Embedder] interface.
+func (s dataStream) Embed(rm *pdf.EmbedHelper) (pdf.Native, error) {
+	ref := rm.Alloc()
+	w, err := rm.Out().OpenStream(ref, pdf.Dict{}, pdf.FilterCompress{})
+	if err != nil {
+		return nil, err
+	}
+	_, err = w.Write([]byte(s))
+	closeErr := w.Close()
+	if err != nil {
+		return nil, err
+	}
+	if closeErr != nil {
+		return nil, closeErr
+	}
+	return ref, nil
+}
@@ -0,0 +1,429 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/document"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+)
+
+// localTSA is an in-process time-stamp authority for testing.
+type localTSA struct {
+	key    *ecdsa.PrivateKey
+	cert   *x509.Certificate
+	serial int64
+	now    time.Time
+}
+
+func newLocalTSA(t *testing.T) *localTSA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Test TSA"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(24 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageTimeStamping},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &localTSA{key: key, cert: cert, now: now}
+}
+
+func (a *localTSA) TimeStamp(_ context.Context, h crypto.Hash, digest []byte) ([]byte, error) {
+	return a.token(h, digest, nil)
+}
+
+func (a *localTSA) token(h crypto.Hash, digest []byte, nonce *big.Int) ([]byte, error) {
+	oid, err := hashToOID(h)
+	if err != nil {
+		return nil, err
+	}
+	a.serial++
+	a.now = a.now.Add(time.Minute)
+
+	info, err := asn1.Marshal(tstInfo{
+		Version: 1,
+		Policy:  asn1.ObjectIdentifier{1, 2, 3, 4, 1},
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oid},
+			HashedMessage: digest,
+		},
+		SerialNumber: big.NewInt(a.serial),
+		GenTime: asn1.RawValue{
+			Tag:   asn1.TagGeneralizedTime,
+			Bytes: []byte(a.now.Format("20060102150405.000Z")),
+		},
+		Nonce: nonce,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	mustMarshal := func(val any) []byte {
+		data, err := asn1.Marshal(val)
+		if err != nil {
+			panic(err)
+		}
+		return data
+	}
+	contentDigest := sha256.Sum256(info)
+	attrs, err := asn1.MarshalWithParams([]attribute{
+		{Type: oidContentType, Values: []asn1.RawValue{{FullBytes: mustMarshal(oidTSTInfo)}}},
+		{Type: oidMessageDigest, Values: []asn1.RawValue{{FullBytes: mustMarshal(contentDigest[:])}}},
+	}, "set")
+	if err != nil {
+		return nil, err
+	}
+	attrsDigest := sha256.Sum256(attrs)
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, attrsDigest[:])
+	if err != nil {
+		return nil, err
+	}
+	signedAttrs := bytes.Clone(attrs)
+	signedAttrs[0] = 0xA0
+
+	sd := signedData{
+		Version:          3,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+		EncapContentInfo: encapContentInfo{EContentType: oidTSTInfo, EContent: info},
+		Certificates: asn1.RawValue{
+			Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true,
+			Bytes: a.cert.Raw,
+		},
+		SignerInfos: []signerInfo{{
+			Version: 1,
+			SID: asn1.RawValue{FullBytes: mustMarshal(issuerAndSerial{
+				Issuer:       asn1.RawValue{FullBytes: a.cert.RawIssuer},
+				SerialNumber: a.cert.SerialNumber,
+			})},
+			DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			SignedAttrs:        asn1.RawValue{FullBytes: signedAttrs},
+			SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+			Signature:          sig,
+		}},
+	}
+	return asn1.Marshal(contentInfo{
+		ContentType: oidSignedData,
+		Content: asn1.RawValue{
+			Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true,
+			Bytes: mustMarshal(sd),
+		},
+	})
+}
+
+// writeTestDocument returns a single-page PDF document.
+func writeTestDocument(t *testing.T, v pdf.Version) []byte {
+	t.Helper()
+
+	buf := &bytes.Buffer{}
+	page, err := document.WriteSinglePage(buf, &pdf.Rectangle{URx: 200, URy: 200}, v, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	page.MoveTo(10, 10)
+	page.LineTo(190, 190)
+	page.Stroke()
+	err = page.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func openPDF(t *testing.T, data []byte) *pdf.Reader {
+	t.Helper()
+	r, err := pdf.NewReader(bytes.NewReader(data), int64(len(data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestDSSRoundTrip(t *testing.T) {
+	cert := []byte("certificate data")
+	d1 := &DSS{
+		Certs: [][]byte{cert, []byte("another certificate")},
+		OCSPs: [][]byte{[]byte("OCSP response")},
+		CRLs:  [][]byte{[]byte("revocation list")},
+		VRI: map[string]*VRI{
+			VRIKey([]byte("signature")): {
+				Certs: [][]byte{cert},
+				TU:    time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+				TS:    []byte("time-stamp token"),
+			},
+		},
+	}
+
+	w, _ := memfile.NewPDFWriter(pdf.V2_0, nil)
+	rm := pdf.NewResourceManager(w)
+	obj, err := rm.Embed(d1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = rm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.GetMeta().Trailer["Quir:E"] = obj
+	err = w.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x := pdf.NewExtractor(w)
+	d2, err := pdf.Decode(pdf.CursorAt(x, nil), w.GetMeta().Trailer["Quir:E"], ExtractDSS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(d1, d2); diff != "" {
+		t.Errorf("round trip failed (-want +got):\n%s", diff)
+	}
+}
+
+func TestAddValidationData(t *testing.T) {
+	data := writeTestDocument(t, pdf.V1_4)
+
+	d1 := &DSS{
+		Certs: [][]byte{[]byte("cert A"), []byte("cert B")},
+		OCSPs: [][]byte{[]byte("OCSP A")},
+	}
+	buf := &bytes.Buffer{}
+	err := AddValidationData(buf, openPDF(t, data), d1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data1 := bytes.Clone(buf.Bytes())
+	if !bytes.HasPrefix(data1, data) {
+		t.Fatal("original file was modified")
+	}
+
+	key := VRIKey([]byte("signature"))
+	d2 := &DSS{
+		Certs: [][]byte{[]byte("cert B"), []byte("cert C")},
+		VRI: map[string]*VRI{
+			key: {Certs: [][]byte{[]byte("cert B")}},
+		},
+	}
+	buf.Reset()
+	err = AddValidationData(buf, openPDF(t, data1), d2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data2 := buf.Bytes()
+
+	r := openPDF(t, data2)
+	if r.GetMeta().Version < pdf.V1_7 {
+		t.Errorf("version not upgraded: %s", r.GetMeta().Version)
+	}
+	d, err := ReadDSS(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &DSS{
+		Certs: [][]byte{[]byte("cert A"), []byte("cert B"), []byte("cert C")},
+		OCSPs: [][]byte{[]byte("OCSP A")},
+		VRI: map[string]*VRI{
+			key: {Certs: [][]byte{[]byte("cert B")}},
+		},
+	}
+	if diff := cmp.Diff(want, d); diff != "" {
+		t.Errorf("wrong DSS (-want +got):\n%s", diff)
+	}
+
+	// Existing streams must be re-used by the second update.
+	dict, err := pdf.NewCursor(r).Dict(r.GetMeta().Catalog.DSS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certs := dict["Certs"].(pdf.Array)
+	r1 := openPDF(t, data1)
+	dict1, err := pdf.NewCursor(r1).Dict(r1.GetMeta().Catalog.DSS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certs1 := dict1["Certs"].(pdf.Array)
+	if certs[0] != certs1[0] || certs[1] != certs1[1] {
+		t.Errorf("existing certificate streams were not re-used")
+	}
+}
+
+func TestDocTimeStamp(t *testing.T) {
+	tsa := newLocalTSA(t)
+	ctx := context.Background()
+	data := writeTestDocument(t, pdf.V1_7)
+
+	buf := &bytes.Buffer{}
+	err := AddDocTimeStamp(ctx, buf, openPDF(t, data), tsa, &TimeStampOptions{ReservedSize: 4096})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data1 := bytes.Clone(buf.Bytes())
+	if !bytes.HasPrefix(data1, data) {
+		t.Fatal("original file was modified")
+	}
+
+	r1 := openPDF(t, data1)
+	sigs, err := Signatures(r1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 1 {
+		t.Fatalf("got %d signatures, want 1", len(sigs))
+	}
+	sig := sigs[0]
+	if sig.Type != "DocTimeStamp" || sig.SubFilter != "ETSI.RFC3161" || sig.FieldName != "Timestamp1" {
+		t.Errorf("wrong signature %+v", sig)
+	}
+	br := sig.ByteRange
+	if len(br) != 4 || br[0] != 0 || br[2]+br[3] != int64(len(data1)) {
+		t.Errorf("byte range %v does not cover the file", br)
+	}
+	if data1[br[1]] != '<' || data1[br[2]-1] != '>' {
+		t.Errorf("byte range gap does not match the Contents string")
+	}
+	info, err := sig.VerifyTimeStamp(bytes.NewReader(data1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Signer.Equal(tsa.cert) || info.SerialNumber.Int64() != 1 {
+		t.Errorf("wrong time-stamp info %+v", info)
+	}
+
+	// Add validation data for the time-stamp, followed by a second
+	// time-stamp covering the validation data.
+	buf.Reset()
+	err = AddValidationData(buf, r1, &DSS{
+		Certs: [][]byte{tsa.cert.Raw},
+		VRI: map[string]*VRI{
+			sig.VRIKey(): {Certs: [][]byte{tsa.cert.Raw}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data2 := bytes.Clone(buf.Bytes())
+	buf.Reset()
+	err = AddDocTimeStamp(ctx, buf, openPDF(t, data2), tsa, &TimeStampOptions{ReservedSize: 4096})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data3 := buf.Bytes()
+
+	sigs, err = Signatures(openPDF(t, data3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sigs) != 2 || sigs[1].FieldName != "Timestamp2" {
+		t.Fatalf("unexpected signatures %v", sigs)
+	}
+	for _, sig := range sigs {
+		if _, err := sig.VerifyTimeStamp(bytes.NewReader(data3)); err != nil {
+			t.Errorf("%s: %v", sig.FieldName, err)
+		}
+	}
+
+	// Modifying the covered data must invalidate the time-stamp.
+	tampered := bytes.Clone(data3)
+	tampered[len(tampered)-10] ^= 1
+	if _, err := sigs[1].VerifyTimeStamp(bytes.NewReader(tampered)); err == nil {
+		t.Error("modification was not detected")
+	}
+}
+
+func TestHTTPAuthority(t *testing.T) {
+	tsa := newLocalTSA(t)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/timestamp-query" {
+			http.Error(w, "wrong content type", http.StatusBadRequest)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		var req timeStampReq
+		if _, err := asn1.Unmarshal(body, &req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h, _ := oidToHash(req.MessageImprint.HashAlgorithm.Algorithm)
+		token, err := tsa.token(h, req.MessageImprint.HashedMessage, req.Nonce)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp, _ := asn1.Marshal(timeStampResp{
+			TimeStampToken: asn1.RawValue{FullBytes: token},
+		})
+		w.Header().Set("Content-Type", "application/timestamp-reply")
+		w.Write(resp)
+	}))
+	defer server.Close()
+
+	a := &HTTPAuthority{URL: server.URL}
+	digest := sha256.Sum256([]byte("hello"))
+	token, err := a.TimeStamp(context.Background(), crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	info, err := ParseTimeStampToken(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(info.HashedMessage, digest[:]) {
+		t.Error("wrong message imprint")
+	}
+}
+
+func TestTokenTooLarge(t *testing.T) {
+	tsa := newLocalTSA(t)
+	data := writeTestDocument(t, pdf.V1_7)
+	err := AddDocTimeStamp(context.Background(), &bytes.Buffer{}, openPDF(t, data), tsa, &TimeStampOptions{ReservedSize: 100})
+	if err == nil {
+		t.Error("expected error for small reserved size")
+	}
+}
@@ -0,0 +1,238 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package signature
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+
+	"seehuhn.de/go/pdf"
+)
+
+// Signature describes a signed signature field of a PDF document.
+type Signature struct {
+	// FieldName is the fully qualified name of the signature field.
+	FieldName string
+
+	// Type is "Sig" for ordinary signatures and "DocTimeStamp" for
+	// document time-stamps.
+	Type pdf.Name
+
+	// Filter is the name of the preferred signature handler.
+	Filter pdf.Name
+
+	// SubFilter describes the encoding of the signature value, for example
+	// "ETSI.CAdES.detached" or "ETSI.RFC3161".
+	SubFilter pdf.Name
+
+	// ByteRange lists pairs of byte offsets and lengths, which describe
+	// the parts of the file covered by the signature.
+	ByteRange []int64
+
+	// Contents is the signature value, including any zero padding.
+	Contents []byte
+
+	// Name (optional) is the name of the person or authority signing the
+	// document.
+	Name string
+
+	// Location (optional) is the physical location of the signing.
+	Location string
+
+	// Reason (optional) is the reason for the signing.
+	Reason string
+
+	// M (optional) is the time of signing, as claimed by the signer.
+	M time.Time
+}
+
+// maxFieldDepth limits the depth of the form field tree.
+const maxFieldDepth = 32
+
+// Signatures returns the signed signature fields of a PDF document, in the
+// order in which they appear in the field tree.
+func Signatures(r pdf.Getter) ([]*Signature, error) {
+	c := pdf.NewCursor(r)
+	form, err := c.Dict(r.GetMeta().Catalog.AcroForm)
+	if pdf.IsReadError(err) {
+		return nil, err
+	} else if form == nil {
+		return nil, nil
+	}
+	fields, err := c.Array(form["Fields"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+
+	var res []*Signature
+	seen := make(map[pdf.Reference]bool)
+	var walk func(obj pdf.Object, prefix string, ft pdf.Name, depth int) error
+	walk = func(obj pdf.Object, prefix string, ft pdf.Name, depth int) error {
+		if ref, ok := obj.(pdf.Reference); ok {
+			if seen[ref] {
+				return nil
+			}
+			seen[ref] = true
+		}
+		if depth > maxFieldDepth {
+			return nil
+		}
+		dict, err := c.Dict(obj)
+		if pdf.IsReadError(err) {
+			return err
+		} else if dict == nil {
+			return nil
+		}
+
+		name := prefix
+		if t, _ := c.TextString(dict["T"]); t != "" {
+			if name != "" {
+				name += "."
+			}
+			name += string(t)
+		}
+		if x, _ := c.Name(dict["FT"]); x != "" {
+			ft = x
+		}
+
+		if kids, _ := c.Array(dict["Kids"]); len(kids) > 0 {
+			for _, kid := range kids {
+				if err := walk(kid, name, ft, depth+1); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if ft != "Sig" || dict["V"] == nil {
+			return nil
+		}
+		sig, err := pdf.Optional(extractSignature(c, dict["V"]))
+		if err != nil {
+			return err
+		} else if sig != nil {
+			sig.FieldName = name
+			res = append(res, sig)
+		}
+		return nil
+	}
+	for _, field := range fields {
+		if err := walk(field, "", "", 0); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// extractSignature reads a signature dictionary.
+func extractSignature(c pdf.Cursor, obj pdf.Object) (*Signature, error) {
+	dict, err := c.Dict(obj)
+	if err != nil {
+		return nil, err
+	} else if dict == nil {
+		return nil, pdf.Error("missing signature dictionary")
+	}
+
+	sig := &Signature{Type: "Sig"}
+	if tp, _ := c.Name(dict["Type"]); tp != "" {
+		sig.Type = tp
+	}
+	sig.Filter, _ = c.Name(dict["Filter"])
+	sig.SubFilter, _ = c.Name(dict["SubFilter"])
+
+	contents, err := c.String(dict["Contents"])
+	if err != nil {
+		return nil, err
+	}
+	sig.Contents = []byte(contents)
+
+	br, err := c.Array(dict["ByteRange"])
+	if err != nil {
+		return nil, err
+	}
+	if len(br) == 0 || len(br)%2 != 0 {
+		return nil, pdf.Error("invalid signature ByteRange")
+	}
+	for _, elem := range br {
+		x, err := c.Integer(elem)
+		if err != nil {
+			return nil, err
+		} else if x < 0 {
+			return nil, pdf.Error("invalid signature ByteRange")
+		}
+		sig.ByteRange = append(sig.ByteRange, int64(x))
+	}
+
+	if s, _ := c.TextString(dict["Name"]); s != "" {
+		sig.Name = string(s)
+	}
+	if s, _ := c.TextString(dict["Location"]); s != "" {
+		sig.Location = string(s)
+	}
+	if s, _ := c.TextString(dict["Reason"]); s != "" {
+		sig.Reason = string(s)
+	}
+	if m, err := c.Date(dict["M"]); err == nil {
+		sig.M = time.Time(m)
+	}
+
+	return sig, nil
+}
+
+// SignedData returns the bytes of the PDF file which are covered by the
+// signature.  The argument ra must give access to the complete PDF file.
+func (s *Signature) SignedData(ra io.ReaderAt) io.Reader {
+	var parts []io.Reader
+	for i := 0; i+1 < len(s.ByteRange); i += 2 {
+		parts = append(parts, io.NewSectionReader(ra, s.ByteRange[i], s.ByteRange[i+1]))
+	}
+	return io.MultiReader(parts...)
+}
+
+// VRIKey returns the key for this signature in the VRI dictionary of the
+// document security store.
+func (s *Signature) VRIKey() string {
+	return VRIKey(s.Contents)
+}
+
+// VerifyTimeStamp checks a document time-stamp.  The time-stamp token is
+// parsed and its signature is verified, and the message imprint is compared
+// to the hash of the signed bytes of the file.  The argument ra must give
+// access to the complete PDF file.
+func (s *Signature) VerifyTimeStamp(ra io.ReaderAt) (*TimeStampInfo, error) {
+	if s.SubFilter != "ETSI.RFC3161" {
+		return nil, errors.New("not an RFC 3161 time-stamp")
+	}
+	info, err := ParseTimeStampToken(s.Contents)
+	if err != nil {
+		return nil, err
+	}
+	if !info.Hash.Available() {
+		return nil, errors.New("hash function not available")
+	}
+	h := info.Hash.New()
+	_, err = io.Copy(h, s.SignedData(ra))
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(h.Sum(nil), info.HashedMessage) {
+		return nil, errors.New("document time-stamp does not match the document")
+	}
+	return info, nil
+}
@@ -0,0 +1,179 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package signature
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+)
+
+// A TimeStampAuthority issues RFC 3161 time-stamp tokens.
+//
+// [HTTPAuthority] implements this interface for time-stamp servers which
+// are accessed over HTTP.  Tests can implement the interface using a local,
+// in-process time-stamp authority.
+type TimeStampAuthority interface {
+	// TimeStamp returns a DER-encoded time-stamp token for the given
+	// message digest, computed using the hash function h.  The token is a
+	// CMS ContentInfo of type SignedData, which encapsulates a TSTInfo
+	// structure (RFC 3161, section 2.4.2).
+	TimeStamp(ctx context.Context, h crypto.Hash, digest []byte) ([]byte, error)
+}
+
+// HTTPAuthority is a [TimeStampAuthority] which obtains time-stamp tokens
+// from a time-stamp server, using the HTTP transport described in RFC 3161,
+// section 3.4.
+type HTTPAuthority struct {
+	// URL is the address of the time-stamp server.
+	URL string
+
+	// Client (optional) is the HTTP client used for requests.
+	// If this is nil, [http.DefaultClient] is used.
+	Client *http.Client
+
+	// Policy (optional) requests a specific time-stamp policy.
+	Policy asn1.ObjectIdentifier
+
+	// Username and Password (optional) are used for HTTP basic
+	// authentication.
+	Username, Password string
+}
+
+// maxResponseSize limits the size of time-stamp server responses.
+const maxResponseSize = 1 << 20
+
+// TimeStamp implements the [TimeStampAuthority] interface.
+func (a *HTTPAuthority) TimeStamp(ctx context.Context, h crypto.Hash, digest []byte) ([]byte, error) {
+	oid, err := hashToOID(h)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+	req := timeStampReq{
+		Version: 1,
+		MessageImprint: messageImprint{
+			HashAlgorithm: pkix.AlgorithmIdentifier{Algorithm: oid, Parameters: asn1.NullRawValue},
+			HashedMessage: digest,
+		},
+		ReqPolicy: a.Policy,
+		Nonce:     nonce,
+		CertReq:   true,
+	}
+	body, err := asn1.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/timestamp-query")
+	httpReq.Header.Set("Accept", "application/timestamp-reply")
+	if a.Username != "" || a.Password != "" {
+		httpReq.SetBasicAuth(a.Username, a.Password)
+	}
+
+	client := a.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("time-stamp server: %s", resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxResponseSize {
+		return nil, errors.New("time-stamp server: response too large")
+	}
+
+	return parseTimeStampResp(data, h, digest, nonce)
+}
+
+// parseTimeStampResp extracts the time-stamp token from a time-stamp
+// server response, and checks that the token matches the request.
+func parseTimeStampResp(data []byte, h crypto.Hash, digest []byte, nonce *big.Int) ([]byte, error) {
+	var resp timeStampResp
+	_, err := asn1.Unmarshal(data, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("time-stamp response: %w", err)
+	}
+	// status 0 is "granted", status 1 is "grantedWithMods"
+	if resp.Status.Status > 1 {
+		msg := fmt.Sprintf("time-stamp request rejected (status %d)", resp.Status.Status)
+		if len(resp.Status.StatusString) > 0 {
+			msg += ": " + strings.Join(resp.Status.StatusString, "; ")
+		}
+		return nil, errors.New(msg)
+	}
+	token := resp.TimeStampToken.FullBytes
+	if len(token) == 0 {
+		return nil, errors.New("time-stamp response: missing token")
+	}
+
+	info, err := ParseTimeStampToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if info.Hash != h || !bytes.Equal(info.HashedMessage, digest) {
+		return nil, errors.New("time-stamp response: message imprint mismatch")
+	}
+	var tst tstInfo
+	if err := tstInfoOf(token, &tst); err != nil {
+		return nil, err
+	}
+	if nonce != nil && (tst.Nonce == nil || tst.Nonce.Cmp(nonce) != 0) {
+		return nil, errors.New("time-stamp response: nonce mismatch")
+	}
+	return token, nil
+}
+
+// tstInfoOf extracts the TSTInfo structure from a time-stamp token.
+func tstInfoOf(token []byte, info *tstInfo) error {
+	var ci contentInfo
+	_, err := asn1.Unmarshal(token, &ci)
+	if err != nil {
+		return err
+	}
+	var sd signedData
+	_, err = asn1.Unmarshal(ci.Content.Bytes, &sd)
+	if err != nil {
+		return err
+	}
+	_, err = asn1.Unmarshal(sd.EncapContentInfo.EContent, info)
+	return err
+}
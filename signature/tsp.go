@@ -0,0 +1,402 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package signature
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+)
+
+// This file implements the parts of RFC 3161 (time-stamp protocol) and
+// RFC 5652 (cryptographic message syntax) needed for document time-stamps.
+
+var (
+	oidSignedData    = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidTSTInfo       = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 1, 4}
+	oidContentType   = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+
+	oidSHA1   = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidSHA256 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidSHA384 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 2}
+	oidSHA512 = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 3}
+
+	oidRSAPSS = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 10}
+)
+
+var hashOIDs = []struct {
+	hash crypto.Hash
+	oid  asn1.ObjectIdentifier
+}{
+	{crypto.SHA1, oidSHA1},
+	{crypto.SHA256, oidSHA256},
+	{crypto.SHA384, oidSHA384},
+	{crypto.SHA512, oidSHA512},
+}
+
+func hashToOID(h crypto.Hash) (asn1.ObjectIdentifier, error) {
+	for _, entry := range hashOIDs {
+		if entry.hash == h {
+			return entry.oid, nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported hash function %s", h)
+}
+
+func oidToHash(oid asn1.ObjectIdentifier) (crypto.Hash, error) {
+	for _, entry := range hashOIDs {
+		if entry.oid.Equal(oid) {
+			return entry.hash, nil
+		}
+	}
+	return 0, fmt.Errorf("unsupported hash algorithm %s", oid)
+}
+
+type messageImprint struct {
+	HashAlgorithm pkix.AlgorithmIdentifier
+	HashedMessage []byte
+}
+
+// timeStampReq is defined in RFC 3161, section 2.4.1.
+type timeStampReq struct {
+	Version        int
+	MessageImprint messageImprint
+	ReqPolicy      asn1.ObjectIdentifier `asn1:"optional"`
+	Nonce          *big.Int              `asn1:"optional"`
+	CertReq        bool                  `asn1:"optional,default:false"`
+}
+
+type pkiStatusInfo struct {
+	Status       int
+	StatusString []string       `asn1:"optional,utf8"`
+	FailInfo     asn1.BitString `asn1:"optional"`
+}
+
+// timeStampResp is defined in RFC 3161, section 2.4.2.
+type timeStampResp struct {
+	Status         pkiStatusInfo
+	TimeStampToken asn1.RawValue `asn1:"optional"`
+}
+
+// tstInfo is defined in RFC 3161, section 2.4.2.  The GenTime field is
+// kept as a raw value, since encoding/asn1 does not accept fractional
+// seconds.
+type tstInfo struct {
+	Version        int
+	Policy         asn1.ObjectIdentifier
+	MessageImprint messageImprint
+	SerialNumber   *big.Int
+	GenTime        asn1.RawValue
+	Accuracy       accuracy      `asn1:"optional"`
+	Ordering       bool          `asn1:"optional,default:false"`
+	Nonce          *big.Int      `asn1:"optional"`
+	TSA            asn1.RawValue `asn1:"optional,tag:0"`
+	Extensions     asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type accuracy struct {
+	Seconds int `asn1:"optional"`
+	Millis  int `asn1:"optional,tag:0"`
+	Micros  int `asn1:"optional,tag:1"`
+}
+
+// contentInfo is defined in RFC 5652, section 3.
+type contentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue `asn1:"explicit,tag:0"`
+}
+
+// signedData is defined in RFC 5652, section 5.1.
+type signedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo encapContentInfo
+	Certificates     asn1.RawValue `asn1:"optional,tag:0"`
+	CRLs             asn1.RawValue `asn1:"optional,tag:1"`
+	SignerInfos      []signerInfo  `asn1:"set"`
+}
+
+type encapContentInfo struct {
+	EContentType asn1.ObjectIdentifier
+	EContent     []byte `asn1:"explicit,optional,tag:0"`
+}
+
+// signerInfo is defined in RFC 5652, section 5.3.
+type signerInfo struct {
+	Version            int
+	SID                asn1.RawValue
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue `asn1:"optional,tag:0"`
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+	UnsignedAttrs      asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+type issuerAndSerial struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type attribute struct {
+	Type   asn1.ObjectIdentifier
+	Values []asn1.RawValue `asn1:"set"`
+}
+
+// TimeStampInfo describes a verified RFC 3161 time-stamp token.
+type TimeStampInfo struct {
+	// Time is the time at which the time-stamp token was created.
+	Time time.Time
+
+	// Hash is the hash function used for the message imprint.
+	Hash crypto.Hash
+
+	// HashedMessage is the hash of the time-stamped data.
+	HashedMessage []byte
+
+	// SerialNumber is the serial number assigned by the time-stamp
+	// authority.
+	SerialNumber *big.Int
+
+	// Policy identifies the policy under which the token was issued.
+	Policy asn1.ObjectIdentifier
+
+	// Signer is the certificate of the time-stamp authority.
+	Signer *x509.Certificate
+
+	// Certificates contains all certificates included in the token.
+	Certificates []*x509.Certificate
+}
+
+// ParseTimeStampToken parses a DER-encoded RFC 3161 time-stamp token and
+// verifies the signature of the time-stamp authority.  Trailing zero bytes,
+// as found in the Contents string of signature dictionaries, are ignored.
+//
+// The token must contain the certificate of the time-stamp authority.  The
+// certificate itself is not validated; callers can use the returned
+// certificates to build and check a certificate chain.
+func ParseTimeStampToken(der []byte) (*TimeStampInfo, error) {
+	var ci contentInfo
+	rest, err := asn1.Unmarshal(der, &ci)
+	if err != nil {
+		return nil, fmt.Errorf("time-stamp token: %w", err)
+	}
+	if len(bytes.Trim(rest, "\x00")) > 0 {
+		return nil, errors.New("time-stamp token: trailing data")
+	}
+	if !ci.ContentType.Equal(oidSignedData) {
+		return nil, errors.New("time-stamp token: not a SignedData structure")
+	}
+	var sd signedData
+	_, err = asn1.Unmarshal(ci.Content.Bytes, &sd)
+	if err != nil {
+		return nil, fmt.Errorf("time-stamp token: %w", err)
+	}
+	if !sd.EncapContentInfo.EContentType.Equal(oidTSTInfo) {
+		return nil, errors.New("time-stamp token: missing TSTInfo")
+	}
+	if len(sd.SignerInfos) != 1 {
+		return nil, errors.New("time-stamp token: need exactly one signer")
+	}
+
+	var info tstInfo
+	_, err = asn1.Unmarshal(sd.EncapContentInfo.EContent, &info)
+	if err != nil {
+		return nil, fmt.Errorf("TSTInfo: %w", err)
+	}
+	if info.GenTime.Tag != asn1.TagGeneralizedTime {
+		return nil, errors.New("TSTInfo: invalid genTime")
+	}
+	genTime, err := time.Parse("20060102150405Z0700", string(info.GenTime.Bytes))
+	if err != nil {
+		return nil, fmt.Errorf("TSTInfo: %w", err)
+	}
+	h, err := oidToHash(info.MessageImprint.HashAlgorithm.Algorithm)
+	if err != nil {
+		return nil, fmt.Errorf("TSTInfo: %w", err)
+	}
+
+	var certs []*x509.Certificate
+	if len(sd.Certificates.Bytes) > 0 {
+		certs, err = x509.ParseCertificates(sd.Certificates.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("time-stamp token: %w", err)
+		}
+	}
+
+	signer, err := verifySignerInfo(&sd.SignerInfos[0], sd.EncapContentInfo.EContent, certs)
+	if err != nil {
+		return nil, fmt.Errorf("time-stamp token: %w", err)
+	}
+
+	return &TimeStampInfo{
+		Time:          genTime,
+		Hash:          h,
+		HashedMessage: info.MessageImprint.HashedMessage,
+		SerialNumber:  info.SerialNumber,
+		Policy:        info.Policy,
+		Signer:        signer,
+		Certificates:  certs,
+	}, nil
+}
+
+// verifySignerInfo checks the signature of a CMS signer over the given
+// content, and returns the certificate of the signer.
+func verifySignerInfo(si *signerInfo, content []byte, certs []*x509.Certificate) (*x509.Certificate, error) {
+	signer, err := findSigner(si.SID, certs)
+	if err != nil {
+		return nil, err
+	}
+
+	h, err := oidToHash(si.DigestAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if !h.Available() {
+		return nil, fmt.Errorf("hash function %s not available", h)
+	}
+	hh := h.New()
+	hh.Write(content)
+	digest := hh.Sum(nil)
+
+	if len(si.SignedAttrs.FullBytes) == 0 {
+		return nil, errors.New("missing signed attributes")
+	}
+	// The signature is computed over the DER encoding of the attributes
+	// as a SET, rather than the implicitly tagged form used in SignerInfo.
+	signedBytes := bytes.Clone(si.SignedAttrs.FullBytes)
+	signedBytes[0] = 0x31
+
+	var attrs []attribute
+	_, err = asn1.UnmarshalWithParams(signedBytes, &attrs, "set")
+	if err != nil {
+		return nil, err
+	}
+	var hasType, hasDigest bool
+	for _, attr := range attrs {
+		if len(attr.Values) != 1 {
+			continue
+		}
+		switch {
+		case attr.Type.Equal(oidContentType):
+			var ct asn1.ObjectIdentifier
+			_, err := asn1.Unmarshal(attr.Values[0].FullBytes, &ct)
+			if err != nil || !ct.Equal(oidTSTInfo) {
+				return nil, errors.New("content type mismatch")
+			}
+			hasType = true
+		case attr.Type.Equal(oidMessageDigest):
+			var md []byte
+			_, err := asn1.Unmarshal(attr.Values[0].FullBytes, &md)
+			if err != nil || !bytes.Equal(md, digest) {
+				return nil, errors.New("message digest mismatch")
+			}
+			hasDigest = true
+		}
+	}
+	if !hasType || !hasDigest {
+		return nil, errors.New("missing content type or message digest attribute")
+	}
+
+	algo, err := signatureAlgorithm(signer.PublicKey, h, si.SignatureAlgorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	err = signer.CheckSignature(algo, signedBytes, si.Signature)
+	if err != nil {
+		return nil, err
+	}
+	return signer, nil
+}
+
+// findSigner returns the certificate identified by a CMS SignerIdentifier.
+func findSigner(sid asn1.RawValue, certs []*x509.Certificate) (*x509.Certificate, error) {
+	if sid.Class == asn1.ClassContextSpecific && sid.Tag == 0 {
+		// subjectKeyIdentifier
+		for _, cert := range certs {
+			if len(cert.SubjectKeyId) > 0 && bytes.Equal(cert.SubjectKeyId, sid.Bytes) {
+				return cert, nil
+			}
+		}
+		return nil, errors.New("signer certificate not found")
+	}
+
+	var ias issuerAndSerial
+	_, err := asn1.Unmarshal(sid.FullBytes, &ias)
+	if err != nil {
+		return nil, err
+	}
+	for _, cert := range certs {
+		if cert.SerialNumber.Cmp(ias.SerialNumber) == 0 &&
+			bytes.Equal(cert.RawIssuer, ias.Issuer.FullBytes) {
+			return cert, nil
+		}
+	}
+	return nil, errors.New("signer certificate not found")
+}
+
+// signatureAlgorithm determines the X.509 signature algorithm for a CMS
+// signature.  CMS identifies the public key algorithm and the hash
+// function separately.
+func signatureAlgorithm(pub any, h crypto.Hash, sigAlg asn1.ObjectIdentifier) (x509.SignatureAlgorithm, error) {
+	switch pub.(type) {
+	case *rsa.PublicKey:
+		pss := sigAlg.Equal(oidRSAPSS)
+		switch {
+		case h == crypto.SHA1 && !pss:
+			return x509.SHA1WithRSA, nil
+		case h == crypto.SHA256:
+			if pss {
+				return x509.SHA256WithRSAPSS, nil
+			}
+			return x509.SHA256WithRSA, nil
+		case h == crypto.SHA384:
+			if pss {
+				return x509.SHA384WithRSAPSS, nil
+			}
+			return x509.SHA384WithRSA, nil
+		case h == crypto.SHA512:
+			if pss {
+				return x509.SHA512WithRSAPSS, nil
+			}
+			return x509.SHA512WithRSA, nil
+		}
+	case *ecdsa.PublicKey:
+		switch h {
+		case crypto.SHA1:
+			return x509.ECDSAWithSHA1, nil
+		case crypto.SHA256:
+			return x509.ECDSAWithSHA256, nil
+		case crypto.SHA384:
+			return x509.ECDSAWithSHA384, nil
+		case crypto.SHA512:
+			return x509.ECDSAWithSHA512, nil
+		}
+	case ed25519.PublicKey:
+		return x509.PureEd25519, nil
+	}
+	return 0, fmt.Errorf("unsupported signature algorithm %s with %s", sigAlg, h)
+}
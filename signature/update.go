@@ -0,0 +1,402 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package signature
+
+import (
+	"context"
+	"crypto"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/acroform"
+	"seehuhn.de/go/pdf/internal/limits"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// AddValidationData adds validation data to the document security store of
+// the PDF file read by r, and writes the result to w.  Data already present
+// in the store is kept, and duplicate entries are omitted.
+//
+// The output is written as an incremental update, so that existing
+// signatures remain valid.  Files using a PDF version earlier than 1.7 are
+// upgraded to PDF 1.7.
+func AddValidationData(w io.Writer, r *pdf.Reader, d *DSS) error {
+	out, err := pdf.NewIncrementalWriter(w, r)
+	if err != nil {
+		return err
+	}
+	requireVersion(out, pdf.V1_7)
+
+	merged := &DSS{}
+	var known map[string]pdf.Reference
+	if obj := r.GetMeta().Catalog.DSS; obj != nil {
+		old, err := pdf.Optional(ReadDSS(r))
+		if err != nil {
+			return err
+		}
+		if old != nil {
+			merged.merge(old)
+		}
+		known, err = knownStreams(r, obj)
+		if err != nil {
+			return err
+		}
+	}
+	merged.merge(d)
+
+	rm := pdf.NewResourceManager(out)
+	ref, err := pdf.ResourceManagerEmbedFunc(rm, func(e *pdf.EmbedHelper, d *DSS) (pdf.Native, error) {
+		return d.embed(e, known)
+	}, merged)
+	if err != nil {
+		return err
+	}
+	out.GetMeta().Catalog.DSS = ref
+
+	err = rm.Close()
+	if err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// knownStreams returns the data streams of an existing DSS dictionary, so
+// that they can be re-used when the dictionary is updated.
+func knownStreams(r pdf.Getter, obj pdf.Object) (map[string]pdf.Reference, error) {
+	c := pdf.NewCursor(r)
+	res := make(map[string]pdf.Reference)
+	add := func(obj pdf.Object) error {
+		ref, ok := obj.(pdf.Reference)
+		if !ok {
+			return nil
+		}
+		data, err := c.ReadAll(ref, limits.MaxValidationDataBytes)
+		if pdf.IsReadError(err) {
+			return err
+		} else if err == nil && len(data) > 0 {
+			res[string(data)] = ref
+		}
+		return nil
+	}
+	addArray := func(obj pdf.Object) error {
+		a, err := c.Array(obj)
+		if pdf.IsReadError(err) {
+			return err
+		}
+		for _, elem := range a {
+			if err := add(elem); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	dict, err := c.Dict(obj)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	for _, key := range []pdf.Name{"Certs", "OCSPs", "CRLs"} {
+		if err := addArray(dict[key]); err != nil {
+			return nil, err
+		}
+	}
+	vri, err := c.Dict(dict["VRI"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	for _, val := range vri {
+		entry, err := c.Dict(val)
+		if pdf.IsReadError(err) {
+			return nil, err
+		}
+		for _, key := range []pdf.Name{"Cert", "OCSP", "CRL"} {
+			if err := addArray(entry[key]); err != nil {
+				return nil, err
+			}
+		}
+		if err := add(entry["TS"]); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+// TimeStampOptions contains options for [AddDocTimeStamp].
+type TimeStampOptions struct {
+	// Hash (optional) is the hash function used for the message imprint.
+	// The default is SHA-256.
+	Hash crypto.Hash
+
+	// FieldName (optional) is the name of the new signature field.
+	// By default, a name of the form "Timestamp1" is chosen.
+	FieldName string
+
+	// ReservedSize (optional) is the number of bytes reserved for the
+	// time-stamp token.  The default is 16384.
+	ReservedSize int
+}
+
+// AddDocTimeStamp adds a document time-stamp to the PDF file read by r, and
+// writes the result to w.  The time-stamp token is obtained from tsa, and
+// covers the complete output file, with the exception of the token itself.
+//
+// The time-stamp is stored in a new, invisible signature field on the first
+// page.  The output is written as an incremental update, so that existing
+// signatures remain valid.  Files using a PDF version earlier than 1.7 are
+// upgraded to PDF 1.7.  Encrypted files are not supported.
+func AddDocTimeStamp(ctx context.Context, w io.Writer, r *pdf.Reader, tsa TimeStampAuthority, opt *TimeStampOptions) error {
+	if opt == nil {
+		opt = &TimeStampOptions{}
+	}
+	h := opt.Hash
+	if h == 0 {
+		h = crypto.SHA256
+	}
+	if _, err := hashToOID(h); err != nil {
+		return err
+	}
+	if !h.Available() {
+		return fmt.Errorf("hash function %s not available", h)
+	}
+	size := opt.ReservedSize
+	if size <= 0 {
+		size = 16384
+	}
+	if r.GetMeta().Encryption != nil {
+		return errors.New("time-stamping encrypted documents is not supported")
+	}
+
+	buf := &writeBuffer{}
+	out, err := pdf.NewIncrementalWriter(buf, r)
+	if err != nil {
+		return err
+	}
+	requireVersion(out, pdf.V1_7)
+
+	byteRange := pdf.NewPlaceholder(out, 64)
+	contents := pdf.NewPlaceholder(out, 2*size+2)
+	sigRef := out.Alloc()
+	err = out.Put(sigRef, pdf.Dict{
+		"Type":      pdf.Name("DocTimeStamp"),
+		"Filter":    pdf.Name("Adobe.PPKLite"),
+		"SubFilter": pdf.Name("ETSI.RFC3161"),
+		"ByteRange": byteRange,
+		"Contents":  contents,
+	})
+	if err != nil {
+		return err
+	}
+	err = addSignatureField(out, r, sigRef, opt.FieldName)
+	if err != nil {
+		return err
+	}
+	err = out.Close()
+	if err != nil {
+		return err
+	}
+
+	// Fill in the byte range, which covers everything except the
+	// Contents string.
+	pos := contents.Offsets()
+	if len(pos) != 1 {
+		return errors.New("cannot locate signature contents")
+	}
+	start := pos[0]
+	end := start + int64(2*size+2)
+	total := int64(len(buf.data))
+	err = byteRange.Set(pdf.Array{
+		pdf.Integer(0), pdf.Integer(start),
+		pdf.Integer(end), pdf.Integer(total - end),
+	})
+	if err != nil {
+		return err
+	}
+
+	hh := h.New()
+	hh.Write(buf.data[:start])
+	hh.Write(buf.data[end:])
+	digest := hh.Sum(nil)
+
+	token, err := tsa.TimeStamp(ctx, h, digest)
+	if err != nil {
+		return err
+	}
+	if len(token) > size {
+		return fmt.Errorf("time-stamp token too large (%d > %d bytes)", len(token), size)
+	}
+
+	hexToken := make([]byte, 2*size+2)
+	for i := range hexToken {
+		hexToken[i] = '0'
+	}
+	hexToken[0] = '<'
+	hex.Encode(hexToken[1:], token)
+	hexToken[len(hexToken)-1] = '>'
+	copy(buf.data[start:end], hexToken)
+
+	_, err = w.Write(buf.data)
+	return err
+}
+
+// addSignatureField adds an invisible signature field with the given
+// signature dictionary to the first page of the document.
+func addSignatureField(out *pdf.Writer, r pdf.Getter, sigRef pdf.Reference, name string) error {
+	c := pdf.NewCursor(r)
+	cat := out.GetMeta().Catalog
+
+	form, err := c.Dict(cat.AcroForm)
+	if pdf.IsReadError(err) {
+		return err
+	}
+	form = maps.Clone(form)
+	if form == nil {
+		form = pdf.Dict{}
+	}
+	fields, err := c.Array(form["Fields"])
+	if pdf.IsReadError(err) {
+		return err
+	}
+
+	used := make(map[string]bool)
+	for _, field := range fields {
+		dict, err := c.Dict(field)
+		if pdf.IsReadError(err) {
+			return err
+		}
+		if t, _ := c.TextString(dict["T"]); t != "" {
+			used[string(t)] = true
+		}
+	}
+	if name == "" {
+		for i := 1; ; i++ {
+			name = fmt.Sprintf("Timestamp%d", i)
+			if !used[name] {
+				break
+			}
+		}
+	} else if used[name] {
+		return fmt.Errorf("duplicate field name %q", name)
+	}
+
+	pageRef, _, err := pagetree.GetPage(r, 0)
+	if err != nil {
+		return err
+	}
+	pageDict, err := c.Dict(pageRef)
+	if err != nil {
+		return err
+	}
+
+	// merged field and widget annotation dictionary
+	fieldRef := out.Alloc()
+	err = out.Put(fieldRef, pdf.Dict{
+		"FT":      pdf.Name("Sig"),
+		"T":       pdf.TextString(name),
+		"V":       sigRef,
+		"Type":    pdf.Name("Annot"),
+		"Subtype": pdf.Name("Widget"),
+		"Rect":    pdf.Array{pdf.Integer(0), pdf.Integer(0), pdf.Integer(0), pdf.Integer(0)},
+		"F":       pdf.Integer(132), // Print, Locked
+		"P":       pageRef,
+	})
+	if err != nil {
+		return err
+	}
+
+	annots, err := c.Array(pageDict["Annots"])
+	if pdf.IsReadError(err) {
+		return err
+	}
+	annots = append(slices.Clone(annots), fieldRef)
+	if ref, ok := pageDict["Annots"].(pdf.Reference); ok {
+		err = out.Put(ref, annots)
+	} else {
+		pageDict = maps.Clone(pageDict)
+		pageDict["Annots"] = annots
+		err = out.Put(pageRef, pageDict)
+	}
+	if err != nil {
+		return err
+	}
+
+	fields = append(slices.Clone(fields), fieldRef)
+	if ref, ok := form["Fields"].(pdf.Reference); ok {
+		err = out.Put(ref, fields)
+		if err != nil {
+			return err
+		}
+	} else {
+		form["Fields"] = fields
+	}
+	flags, _ := c.Integer(form["SigFlags"])
+	form["SigFlags"] = pdf.Integer(acroform.SignatureFlags(flags) | acroform.SignaturesExist | acroform.AppendOnly)
+
+	if ref, ok := cat.AcroForm.(pdf.Reference); ok {
+		return out.Put(ref, form)
+	}
+	cat.AcroForm = form
+	return nil
+}
+
+// requireVersion raises the PDF version of an incremental update, if
+// needed.  The new version is recorded in the document catalog.
+func requireVersion(out *pdf.Writer, v pdf.Version) {
+	meta := out.GetMeta()
+	if meta.Version < v {
+		meta.Version = v
+		meta.Catalog.Version = v
+	}
+}
+
+// writeBuffer is an in-memory io.WriteSeeker.  Seeking allows the
+// [pdf.Writer] to fill in placeholders.
+type writeBuffer struct {
+	data []byte
+	pos  int64
+}
+
+func (b *writeBuffer) Write(p []byte) (int, error) {
+	end := b.pos + int64(len(p))
+	if end > int64(len(b.data)) {
+		b.data = slices.Grow(b.data, int(end)-len(b.data))[:end]
+	}
+	copy(b.data[b.pos:], p)
+	b.pos = end
+	return len(p), nil
+}
+
+func (b *writeBuffer) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += b.pos
+	case io.SeekEnd:
+		offset += int64(len(b.data))
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	b.pos = offset
+	return offset, nil
+}
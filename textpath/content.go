@@ -0,0 +1,714 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package textpath
+
+import (
+	"bytes"
+	"slices"
+	"strconv"
+	"strings"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/path"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/font/dict"
+	"seehuhn.de/go/pdf/graphics"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/extract"
+	"seehuhn.de/go/pdf/graphics/form"
+	"seehuhn.de/go/pdf/page"
+	"seehuhn.de/go/pdf/reader"
+)
+
+// coordDigits is the number of decimal digits kept for coordinates written
+// to content streams.
+const coordDigits = 4
+
+// matrixDigits is the number of decimal digits kept for the coefficients of
+// matrices.  The matrices of Type 3 glyphs often have small entries, which
+// need more digits than coordinates.
+const matrixDigits = 6
+
+// maxDepth bounds the recursion through nested form XObjects.
+const maxDepth = 40
+
+// streamKeys lists the stream dictionary entries which are rewritten when
+// the content of a stream is replaced.
+var streamKeys = []pdf.Name{"Length", "Filter", "DecodeParms", "F", "FFilter", "FDecodeParms"}
+
+type converter struct {
+	x          *pdf.Extractor
+	out        *pdf.Writer
+	rm         *pdf.ResourceManager
+	copy       *pdf.Copier
+	actualText bool
+
+	// fonts holds the glyphs of the fonts seen so far.  Fonts are keyed by
+	// the reference of the font dictionary where possible, and by the
+	// [font.Instance] otherwise.  Fonts which cannot be converted map to
+	// nil.
+	fonts map[any]*glyphSource
+
+	// forms maps source form XObjects to their converted copies.
+	forms map[pdf.Reference]pdf.Reference
+}
+
+// page converts the content of a page.  The function writes a new content
+// stream and returns a reference to it, together with the new resource
+// dictionary.
+func (c *converter) page(contents, resources pdf.Object) (pdf.Reference, pdf.Dict, error) {
+	cur := pdf.CursorAt(c.x, nil)
+
+	rawRes, err := cur.Dict(resources)
+	if pdf.IsReadError(err) {
+		return 0, nil, err
+	}
+	res, err := pdf.Decode(cur, resources, extract.Resources)
+	if pdf.IsReadError(err) {
+		return 0, nil, err
+	}
+
+	var stm content.Stream
+	if contents != nil {
+		resolved, err := cur.Resolve(contents)
+		if pdf.IsReadError(err) {
+			return 0, nil, err
+		}
+		segments, err := page.ExtractContents(cur, resolved)
+		if pdf.IsReadError(err) {
+			return 0, nil, err
+		}
+		stm = &page.Page{Contents: segments}
+	}
+
+	data, newRes, err := c.content(stm, content.Page, rawRes, res, 0)
+	if err != nil {
+		return 0, nil, err
+	}
+	ref := c.out.Alloc()
+	if err := c.writeStream(ref, nil, data); err != nil {
+		return 0, nil, err
+	}
+	return ref, newRes, nil
+}
+
+// xObject converts an XObject.  Form XObjects are converted; other XObjects
+// are copied.  The resources of the parent content stream are used for
+// forms which have no resource dictionary of their own.
+func (c *converter) xObject(obj pdf.Object, parentRes pdf.Dict, depth int) (pdf.Object, error) {
+	ref, isRef := obj.(pdf.Reference)
+	if v, ok := c.forms[ref]; isRef && ok {
+		return v, nil
+	}
+
+	stm, err := pdf.CursorAt(c.x, nil).Stream(obj)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	if !isRef || stm == nil || stm.Dict["Subtype"] != pdf.Name("Form") || depth >= maxDepth {
+		return c.copyNative(obj)
+	}
+
+	outRef := c.out.Alloc()
+	c.forms[ref] = outRef // publish before recursing, breaking cycles
+	if err := c.form(outRef, stm, parentRes, depth); err != nil {
+		return nil, err
+	}
+	return outRef, nil
+}
+
+// form writes a converted copy of a form XObject to outRef.
+func (c *converter) form(outRef pdf.Reference, stm *pdf.Stream, parentRes pdf.Dict, depth int) error {
+	cur := pdf.CursorAt(c.x, nil)
+
+	var resources pdf.Object = parentRes
+	if obj, ok := stm.Dict["Resources"]; ok {
+		resources = obj
+	}
+	rawRes, err := cur.Dict(resources)
+	if pdf.IsReadError(err) {
+		return err
+	}
+	res, err := pdf.Decode(cur, resources, extract.Resources)
+	if pdf.IsReadError(err) {
+		return err
+	}
+
+	segments, err := page.ExtractContents(cur, stm)
+	if pdf.IsReadError(err) {
+		return err
+	}
+	data, newRes, err := c.content(&page.Page{Contents: segments}, content.Form, rawRes, res, depth+1)
+	if err != nil {
+		return err
+	}
+
+	rest := pdf.Dict{}
+	for key, val := range stm.Dict {
+		if key != "Resources" && !slices.Contains(streamKeys, key) {
+			rest[key] = val
+		}
+	}
+	dict, err := c.copy.CopyDict(rest)
+	if err != nil {
+		return err
+	}
+	dict["Resources"] = newRes
+	return c.writeStream(outRef, dict, data)
+}
+
+// content converts a content stream and returns the new content, together
+// with the new resource dictionary.
+func (c *converter) content(stm content.Stream, typ content.Type, rawRes pdf.Dict, res *content.Resources, depth int) ([]byte, pdf.Dict, error) {
+	if res == nil {
+		res = &content.Resources{}
+	}
+	s := &streamConverter{
+		c:      c,
+		rawRes: rawRes,
+		depth:  depth,
+		fonts:  map[pdf.Name]bool{},
+		procs:  map[pdf.Native]pdf.Name{},
+	}
+	s.r = reader.New(c.x)
+	s.r.State = content.NewState(typ, res)
+	s.r.Character = s.character
+	s.r.EveryOp = s.op
+
+	if stm != nil {
+		if err := s.r.ProcessIter(stm.NewIter()); err != nil {
+			return nil, nil, err
+		}
+	}
+	newRes, err := s.resources()
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.buf.Bytes(), newRes, nil
+}
+
+// streamConverter holds the state while a single content stream is
+// converted.
+type streamConverter struct {
+	c      *converter
+	r      *reader.Reader
+	rawRes pdf.Dict
+	depth  int
+
+	buf bytes.Buffer
+
+	// fontName is the resource name of the current font, and fontStack
+	// holds the names saved by the q operator.
+	fontName  pdf.Name
+	fontStack []pdf.Name
+
+	// tm is the text matrix before the current operator.
+	tm matrix.Matrix
+
+	// glyphs collects the outlines of the glyphs shown by the current
+	// text-showing operator, and text collects their text.  If painted is
+	// set, glyphs holds complete painting operators for Type 3 glyphs
+	// instead of a path.  If keep is set, some glyph could not be converted
+	// and the operator is kept as text.
+	glyphs  bytes.Buffer
+	text    strings.Builder
+	painted bool
+	keep    bool
+
+	// clip collects the outlines of the glyphs in the current text object
+	// which are added to the clipping path.
+	clip bytes.Buffer
+
+	// fonts lists the font resources used by text which is kept.
+	fonts map[pdf.Name]bool
+
+	// procs maps the form XObjects drawing Type 3 glyphs to their resource
+	// names.
+	procs map[pdf.Native]pdf.Name
+}
+
+// op is called by the reader for every operator of the content stream.
+// The glyphs shown by text-showing operators have already been collected
+// by the Character callback at this point.
+func (s *streamConverter) op(name string, args []pdf.Object) error {
+	var err error
+	switch op := content.OpName(name); op {
+	case content.OpTextBegin:
+		s.clip.Reset()
+
+	case content.OpTextEnd:
+		if s.clip.Len() > 0 {
+			s.buf.Write(s.clip.Bytes())
+			err = s.write(content.OpClipNonZero)
+			if err == nil {
+				err = s.write(content.OpEndPath)
+			}
+			s.clip.Reset()
+		}
+
+	case content.OpTextSetFont:
+		if len(args) > 0 {
+			s.fontName, _ = args[0].(pdf.Name)
+		}
+
+	case content.OpTextSetCharacterSpacing, content.OpTextSetWordSpacing,
+		content.OpTextSetHorizontalScaling, content.OpTextSetLeading,
+		content.OpTextSetRenderingMode, content.OpTextSetRise,
+		content.OpTextMoveOffset, content.OpTextMoveOffsetSetLeading,
+		content.OpTextSetMatrix, content.OpTextNextLine:
+		// The text state is tracked by the reader and applied to the
+		// outlines.  Text which is kept gets the text state it needs.
+
+	case content.OpTextShow, content.OpTextShowArray,
+		content.OpTextShowMoveNextLine, content.OpTextShowMoveNextLineSetSpacing:
+		err = s.show(op, args)
+
+	case content.OpPushGraphicsState:
+		s.fontStack = append(s.fontStack, s.fontName)
+		err = s.write(op, args...)
+
+	case content.OpPopGraphicsState:
+		if n := len(s.fontStack); n > 0 {
+			s.fontName = s.fontStack[n-1]
+			s.fontStack = s.fontStack[:n-1]
+		}
+		err = s.write(op, args...)
+
+	default:
+		err = s.write(op, args...)
+	}
+	s.tm = s.r.State.GState.TextMatrix
+	return err
+}
+
+// character is called by the reader for every glyph shown, and adds the
+// glyph to s.glyphs.
+func (s *streamConverter) character(code font.Code) error {
+	gs := s.r.State.GState
+	src := s.glyphSource(gs.TextFont)
+	if src == nil || src.type3 != nil && gs.TextRenderingMode >= graphics.TextRenderingModeFillClip {
+		s.keep = true
+		return nil
+	}
+	s.text.WriteString(code.Text)
+
+	// T maps the glyph's position in text space to user space
+	T := matrix.Matrix{gs.TextFontSize * gs.TextHorizontalScaling, 0, 0, gs.TextFontSize, 0, gs.TextRise}
+	T = T.Mul(gs.TextMatrix)
+	if gs.TextFont.WritingMode() == font.Vertical {
+		T = matrix.Translate(-code.Width/2, -0.88).Mul(T)
+	}
+
+	if src.type3 != nil {
+		return s.type3Glyph(src, code, T)
+	}
+	p, G, ok := src.outline(code)
+	if !ok {
+		return nil
+	}
+	return s.writePath(&s.glyphs, p, G.Mul(T))
+}
+
+// type3Glyph adds the painting operators for a Type 3 glyph to s.glyphs.
+func (s *streamConverter) type3Glyph(src *glyphSource, code font.Code, T matrix.Matrix) error {
+	glyphName := pdf.Name(glyphName(src.type3.Encoding, nil, code.CID))
+	proc, seen := src.procs[glyphName]
+	if !seen {
+		var err error
+		proc, err = s.c.glyphProc(src.type3, glyphName, s.r.State.Resources)
+		if err != nil {
+			return err
+		}
+		src.procs[glyphName] = proc
+	}
+	if proc == nil {
+		return nil
+	}
+
+	name, ok := s.procs[proc]
+	if !ok {
+		name = s.newXObjectName()
+		s.procs[proc] = name
+	}
+	M := src.type3.FontMatrix.Mul(T)
+	s.painted = true
+	return writeOps(&s.glyphs,
+		content.Operator{Name: content.OpPushGraphicsState},
+		content.Operator{Name: content.OpTransform, Args: matrixArgs(M)},
+		content.Operator{Name: content.OpXObject, Args: []pdf.Object{name}},
+		content.Operator{Name: content.OpPopGraphicsState},
+	)
+}
+
+// show writes the replacement for a text-showing operator.
+func (s *streamConverter) show(op content.OpName, args []pdf.Object) error {
+	defer func() {
+		s.glyphs.Reset()
+		s.text.Reset()
+		s.painted = false
+		s.keep = false
+	}()
+
+	gs := s.r.State.GState
+	if s.keep || gs.TextFont == nil {
+		return s.keepText(op, args)
+	}
+
+	mode := gs.TextRenderingMode
+	if mode == graphics.TextRenderingModeInvisible || s.glyphs.Len() == 0 {
+		return nil
+	}
+
+	span := s.c.actualText && s.text.Len() > 0 && !s.r.InActualText()
+	if span {
+		text := pdf.TextString(s.text.String()).AsPDF(s.c.out.GetOptions())
+		err := s.write(content.OpBeginMarkedContentWithProperties,
+			pdf.Name("Span"), pdf.Dict{"ActualText": text})
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.painted {
+		s.buf.Write(s.glyphs.Bytes())
+	} else {
+		var paint content.OpName
+		switch mode {
+		case graphics.TextRenderingModeFill, graphics.TextRenderingModeFillClip:
+			paint = content.OpFill
+		case graphics.TextRenderingModeStroke, graphics.TextRenderingModeStrokeClip:
+			paint = content.OpStroke
+		case graphics.TextRenderingModeFillStroke, graphics.TextRenderingModeFillStrokeClip:
+			paint = content.OpFillAndStroke
+		}
+		if paint != "" {
+			s.buf.Write(s.glyphs.Bytes())
+			if err := s.write(paint); err != nil {
+				return err
+			}
+		}
+		if mode >= graphics.TextRenderingModeFillClip {
+			s.clip.Write(s.glyphs.Bytes())
+		}
+	}
+
+	if span {
+		return s.write(content.OpEndMarkedContent)
+	}
+	return nil
+}
+
+// keepText writes a text-showing operator which is not converted, in a text
+// object of its own.  The text state is set explicitly, since the text
+// state operators of the original content stream are removed.
+func (s *streamConverter) keepText(op content.OpName, args []pdf.Object) error {
+	gs := s.r.State.GState
+
+	// The operators ' and " move to the next line before showing the
+	// text, so that the text starts at the new line matrix.
+	tm := s.tm
+	var show content.Operator
+	switch op {
+	case content.OpTextShowMoveNextLine:
+		tm = gs.TextLineMatrix
+		show = content.Operator{Name: content.OpTextShow, Args: args}
+	case content.OpTextShowMoveNextLineSetSpacing:
+		tm = gs.TextLineMatrix
+		show = content.Operator{Name: content.OpTextShow, Args: args[2:]}
+	default:
+		show = content.Operator{Name: op, Args: args}
+	}
+
+	ops := []content.Operator{{Name: content.OpTextBegin}}
+	if s.fontName != "" && gs.TextFont != nil {
+		s.fonts[s.fontName] = true
+		ops = append(ops, content.Operator{
+			Name: content.OpTextSetFont,
+			Args: []pdf.Object{s.fontName, pdf.Number(gs.TextFontSize)},
+		})
+	}
+	ops = append(ops,
+		content.Operator{Name: content.OpTextSetCharacterSpacing, Args: numbers(gs.TextCharacterSpacing)},
+		content.Operator{Name: content.OpTextSetWordSpacing, Args: numbers(gs.TextWordSpacing)},
+		content.Operator{Name: content.OpTextSetHorizontalScaling, Args: numbers(100 * gs.TextHorizontalScaling)},
+		content.Operator{Name: content.OpTextSetRenderingMode, Args: []pdf.Object{pdf.Integer(gs.TextRenderingMode)}},
+		content.Operator{Name: content.OpTextSetRise, Args: numbers(gs.TextRise)},
+		content.Operator{Name: content.OpTextSetMatrix, Args: matrixArgs(tm)},
+		show,
+		content.Operator{Name: content.OpTextEnd},
+	)
+	return writeOps(&s.buf, ops...)
+}
+
+// glyphSource returns the glyphs of a font, or nil if the glyphs cannot be
+// converted.
+func (s *streamConverter) glyphSource(f font.Instance) *glyphSource {
+	if f == nil {
+		return nil
+	}
+	var key any = f
+	if fonts, err := pdf.CursorAt(s.c.x, nil).Dict(s.rawRes["Font"]); err == nil {
+		if ref, ok := fonts[s.fontName].(pdf.Reference); ok {
+			key = ref
+		}
+	}
+	src, ok := s.c.fonts[key]
+	if !ok {
+		src = newGlyphSource(f)
+		s.c.fonts[key] = src
+	}
+	return src
+}
+
+// glyphProc returns a form XObject which draws the glyph procedure of a
+// Type 3 font.  The result is nil if the font has no such glyph.  The
+// resources res are used if neither the glyph nor the font have resources
+// of their own.
+func (c *converter) glyphProc(fi *dict.FontInfoType3, name pdf.Name, res *content.Resources) (pdf.Native, error) {
+	cp := fi.CharProcs[name]
+	if cp == nil || cp.Content == nil {
+		return nil, nil
+	}
+
+	// The glyph metrics operators d0 and d1 are not allowed in form
+	// XObjects.  The bounding box given to d1 becomes the bounding box of
+	// the form.
+	var bbox *pdf.Rectangle
+	var ops []content.Operator
+	it := cp.Content.NewIter()
+	for op, args := range it.All() {
+		switch op {
+		case content.OpType3ColoredGlyph:
+			continue
+		case content.OpType3UncoloredGlyph:
+			if v := operands(args); len(v) == 6 {
+				bbox = &pdf.Rectangle{LLx: v[2], LLy: v[3], URx: v[4], URy: v[5]}
+			}
+			continue
+		}
+		ops = append(ops, content.Operator{Name: op, Args: slices.Clone(args)})
+	}
+	if err := it.Err(); pdf.IsReadError(err) {
+		return nil, err
+	}
+
+	if bbox == nil {
+		bbox = fi.FontBBox
+	}
+	if bbox == nil || bbox.IsZero() {
+		// Glyphs painted with d0 need not stay inside the font bounding
+		// box; use a box which does not clip glyphs of any sensible size.
+		bbox = &pdf.Rectangle{LLx: -1e5, LLy: -1e5, URx: 1e5, URy: 1e5}
+	}
+
+	glyphRes := cp.Resources
+	if glyphRes == nil {
+		glyphRes = fi.Resources
+	}
+	if glyphRes == nil {
+		glyphRes = res
+	}
+	return c.rm.Embed(&form.Form{
+		Content: &content.Operators{Ops: ops},
+		Res:     glyphRes,
+		BBox:    *bbox,
+	})
+}
+
+// resources returns the resource dictionary for the converted content
+// stream.  Font resources are only kept if they are used by text which is
+// kept, and form XObjects are converted.
+func (s *streamConverter) resources() (pdf.Dict, error) {
+	cur := pdf.CursorAt(s.c.x, nil)
+	res := pdf.Dict{}
+	for _, key := range s.rawRes.SortedKeys() {
+		val := s.rawRes[key]
+		switch key {
+		case "Font":
+			fonts, err := cur.Dict(val)
+			if pdf.IsReadError(err) {
+				return nil, err
+			}
+			out := pdf.Dict{}
+			for name := range s.fonts {
+				if obj, ok := fonts[name]; ok {
+					copied, err := s.c.copyNative(obj)
+					if err != nil {
+						return nil, err
+					}
+					out[name] = copied
+				}
+			}
+			if len(out) > 0 {
+				res[key] = out
+			}
+
+		case "XObject":
+			xObjects, err := cur.Dict(val)
+			if pdf.IsReadError(err) {
+				return nil, err
+			}
+			out := pdf.Dict{}
+			for _, name := range xObjects.SortedKeys() {
+				conv, err := s.c.xObject(xObjects[name], s.rawRes, s.depth)
+				if err != nil {
+					return nil, err
+				}
+				if conv != nil {
+					out[name] = conv
+				}
+			}
+			res[key] = out
+
+		default:
+			copied, err := s.c.copyNative(val)
+			if err != nil {
+				return nil, err
+			}
+			if copied != nil {
+				res[key] = copied
+			}
+		}
+	}
+
+	if len(s.procs) > 0 {
+		out, _ := res["XObject"].(pdf.Dict)
+		if out == nil {
+			out = pdf.Dict{}
+			res["XObject"] = out
+		}
+		for proc, name := range s.procs {
+			out[name] = proc
+		}
+	}
+	return res, nil
+}
+
+// newXObjectName returns a name for a new entry in the XObject resource
+// dictionary.
+func (s *streamConverter) newXObjectName() pdf.Name {
+	xObjects, _ := pdf.CursorAt(s.c.x, nil).Dict(s.rawRes["XObject"])
+	for i := len(s.procs) + 1; ; i++ {
+		name := pdf.Name("G" + strconv.Itoa(i))
+		if _, exists := xObjects[name]; !exists {
+			return name
+		}
+	}
+}
+
+// write appends an operator to the converted content stream.
+func (s *streamConverter) write(op content.OpName, args ...pdf.Object) error {
+	return content.Operator{Name: op, Args: args}.Format(&s.buf)
+}
+
+// writePath appends the path p, transformed by M, to buf.
+func (s *streamConverter) writePath(buf *bytes.Buffer, p path.Path, M matrix.Matrix) error {
+	for cmd, pts := range p.Transform(M).ToCubic() {
+		var op content.OpName
+		switch cmd {
+		case path.CmdMoveTo:
+			op = content.OpMoveTo
+		case path.CmdLineTo:
+			op = content.OpLineTo
+		case path.CmdCubeTo:
+			op = content.OpCurveTo
+		case path.CmdClose:
+			op = content.OpClosePath
+		default:
+			continue
+		}
+		args := make([]pdf.Object, 0, 2*len(pts))
+		for _, pt := range pts {
+			args = append(args, pdf.Number(pdf.Round(pt.X, coordDigits)), pdf.Number(pdf.Round(pt.Y, coordDigits)))
+		}
+		if err := (content.Operator{Name: op, Args: args}).Format(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeStream writes a compressed stream.
+func (c *converter) writeStream(ref pdf.Reference, dict pdf.Dict, data []byte) error {
+	stm, err := c.out.OpenStream(ref, dict, pdf.FilterCompress{})
+	if err != nil {
+		return err
+	}
+	if _, err := stm.Write(data); err != nil {
+		return err
+	}
+	return stm.Close()
+}
+
+// copyNative copies a value through the copier, skipping non-Native values.
+func (c *converter) copyNative(val pdf.Object) (pdf.Object, error) {
+	nv, ok := val.(pdf.Native)
+	if !ok {
+		return nil, nil
+	}
+	return c.copy.Copy(nv)
+}
+
+// writeOps appends operators to buf.
+func writeOps(buf *bytes.Buffer, ops ...content.Operator) error {
+	for _, op := range ops {
+		if err := op.Format(buf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// numbers converts numbers to PDF objects, rounded to coordDigits decimal
+// digits.
+func numbers(values ...float64) []pdf.Object {
+	res := make([]pdf.Object, len(values))
+	for i, v := range values {
+		res[i] = pdf.Number(pdf.Round(v, coordDigits))
+	}
+	return res
+}
+
+// matrixArgs returns the operands for the cm and Tm operators.
+func matrixArgs(M matrix.Matrix) []pdf.Object {
+	res := make([]pdf.Object, len(M))
+	for i, v := range M {
+		res[i] = pdf.Number(pdf.Round(v, matrixDigits))
+	}
+	return res
+}
+
+// operands returns the numeric operands of an operator.  The result is nil
+// if some operand is not a number.
+func operands(args []pdf.Object) []float64 {
+	res := make([]float64, len(args))
+	for i, arg := range args {
+		switch x := arg.(type) {
+		case pdf.Integer:
+			res[i] = float64(x)
+		case pdf.Real:
+			res[i] = float64(x)
+		case pdf.Number:
+			res[i] = float64(x)
+		default:
+			return nil
+		}
+	}
+	return res
+}
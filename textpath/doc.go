@@ -0,0 +1,46 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package textpath converts the text of a PDF document to vector outlines.
+//
+// [Write] copies a document and replaces every text-showing operator in the
+// page content streams, and in the form XObjects they use, with path
+// construction and painting operators which draw the same glyphs.  The glyph
+// outlines are taken from the embedded font programs: Type 1, CFF (simple
+// and CID-keyed), TrueType and OpenType fonts are supported.  The glyphs of
+// Type 3 fonts are drawn by turning their glyph procedures into form
+// XObjects.
+//
+// All text rendering modes are honoured: text is filled, stroked, filled
+// and stroked, or omitted, and glyphs shown in one of the clipping modes are
+// collected and added to the clipping path at the end of the text object.
+// The text state parameters (character and word spacing, horizontal scaling,
+// rise and the text matrix) are applied to the outlines.  Optionally, each
+// converted text-showing operator is wrapped in a marked-content sequence
+// with an ActualText entry, so that the text can still be searched and
+// copied.
+//
+// Text which cannot be converted, for example text in fonts which are not
+// embedded, is kept as text.  Such text is written in a text object of its
+// own, together with the text state it needs.  In vertical writing mode, the
+// default position vector is used for every glyph, since glyph-specific
+// position vectors are not available.
+//
+// Form XObjects are converted with the default text state, rather than the
+// text state of the content stream which draws them.  Text in patterns, in
+// annotation appearance streams and inside Type 3 glyph procedures is not
+// converted.
+package textpath
@@ -0,0 +1,256 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package textpath
+
+import (
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/path"
+	"seehuhn.de/go/postscript/cid"
+	"seehuhn.de/go/sfnt"
+	"seehuhn.de/go/sfnt/cff"
+	"seehuhn.de/go/sfnt/glyph"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/font/dict"
+	"seehuhn.de/go/pdf/font/encoding"
+	"seehuhn.de/go/pdf/font/glyphdata"
+	"seehuhn.de/go/pdf/font/glyphdata/cffglyphs"
+	"seehuhn.de/go/pdf/font/glyphdata/sfntglyphs"
+	"seehuhn.de/go/pdf/font/glyphdata/type1glyphs"
+)
+
+// glyphSource gives access to the glyphs of a font.
+type glyphSource struct {
+	// outline returns the outline of the glyph for a character code, in
+	// glyph space, together with the matrix which maps glyph space to text
+	// space.  The last return value is false if the font has no glyph for
+	// the code.
+	outline func(code font.Code) (path.Path, matrix.Matrix, bool)
+
+	// type3 is set for Type 3 fonts.  Their glyphs are drawn by the form
+	// XObjects in procs, instead of using outlines.
+	type3 *dict.FontInfoType3
+	procs map[pdf.Name]pdf.Native
+}
+
+// newGlyphSource returns the glyphs of f.  The result is nil if the font
+// is not embedded, or if the font program cannot be read.
+func newGlyphSource(f font.Instance) *glyphSource {
+	var outline func(font.Code) (path.Path, matrix.Matrix, bool)
+	switch fi := f.FontInfo().(type) {
+	case *dict.FontInfoSimple:
+		outline = simpleOutlines(fi)
+	case *dict.FontInfoCID:
+		outline = cidOutlines(fi)
+	case *dict.FontInfoGlyfEmbedded:
+		outline = glyfOutlines(fi)
+	case *dict.FontInfoType3:
+		return &glyphSource{type3: fi, procs: map[pdf.Name]pdf.Native{}}
+	}
+	if outline == nil {
+		return nil
+	}
+	return &glyphSource{outline: outline}
+}
+
+// simpleOutlines returns the outlines of a simple font.  Codes are mapped to
+// glyphs through the glyph names given by the encoding, falling back to the
+// built-in encoding of the font program; for TrueType fonts the methods
+// from section 9.6.5.4 of ISO 32000-2:2020 are used.
+func simpleOutlines(fi *dict.FontInfoSimple) func(font.Code) (path.Path, matrix.Matrix, bool) {
+	if fi.FontFile == nil {
+		return nil
+	}
+
+	switch fi.FontFile.Type {
+	case glyphdata.Type1:
+		t1, err := type1glyphs.FromStream(fi.FontFile)
+		if err != nil {
+			return nil
+		}
+		builtin := t1.Outlines.BuiltinEncoding()
+		return func(code font.Code) (path.Path, matrix.Matrix, bool) {
+			g := t1.Glyphs[glyphName(fi.Encoding, builtin, code.CID)]
+			if g == nil {
+				g = t1.Glyphs[".notdef"]
+			}
+			if g == nil {
+				return nil, matrix.Matrix{}, false
+			}
+			return g.Path(), t1.FontMatrix, true
+		}
+
+	case glyphdata.CFFSimple:
+		f, err := cffglyphs.FromStream(fi.FontFile)
+		if err != nil {
+			return nil
+		}
+		return namedCFFOutlines(f.Outlines, f.FontMatrix, fi.Encoding)
+
+	case glyphdata.OpenTypeCFFSimple:
+		f, err := sfntglyphs.FromStream(fi.FontFile)
+		if err != nil {
+			return nil
+		}
+		o, ok := f.Outlines.(*cff.Outlines)
+		if !ok {
+			return nil
+		}
+		return namedCFFOutlines(o, f.FontMatrix, fi.Encoding)
+
+	case glyphdata.TrueType, glyphdata.OpenTypeGlyf:
+		f, err := sfntglyphs.FromStream(fi.FontFile)
+		if err != nil {
+			return nil
+		}
+		sel := sfntglyphs.NewTrueTypeSelector(f, fi.IsSymbolic, fi.Encoding)
+		return func(code font.Code) (path.Path, matrix.Matrix, bool) {
+			gid, _ := sel(code.CID)
+			p, M := sfntOutline(f, gid)
+			return p, M, true
+		}
+	}
+	return nil
+}
+
+// namedCFFOutlines returns the outlines of a simple CFF font, selecting the
+// glyphs by name.
+func namedCFFOutlines(o *cff.Outlines, fm matrix.Matrix, enc encoding.Simple) func(font.Code) (path.Path, matrix.Matrix, bool) {
+	byName := make(map[string]glyph.ID, len(o.Glyphs))
+	for gid, g := range o.Glyphs {
+		if g != nil && g.Name != "" {
+			byName[g.Name] = glyph.ID(gid)
+		}
+	}
+	builtin := o.BuiltinEncoding()
+	return func(code font.Code) (path.Path, matrix.Matrix, bool) {
+		gid := byName[glyphName(enc, builtin, code.CID)]
+		return o.Path(gid), o.GlyphMatrix(fm, gid), true
+	}
+}
+
+// cidOutlines returns the outlines of a CIDFont with a CFF font program.
+// For CID-keyed font programs, the CIDs are mapped to glyphs through the
+// charset; otherwise CIDs are used as glyph IDs.
+func cidOutlines(fi *dict.FontInfoCID) func(font.Code) (path.Path, matrix.Matrix, bool) {
+	if fi.FontFile == nil {
+		return nil
+	}
+
+	var o *cff.Outlines
+	var fm matrix.Matrix
+	switch fi.FontFile.Type {
+	case glyphdata.CFF:
+		f, err := cffglyphs.FromStream(fi.FontFile)
+		if err != nil {
+			return nil
+		}
+		o, fm = f.Outlines, f.FontMatrix
+	case glyphdata.OpenTypeCFF:
+		f, err := sfntglyphs.FromStream(fi.FontFile)
+		if err != nil {
+			return nil
+		}
+		var ok bool
+		o, ok = f.Outlines.(*cff.Outlines)
+		if !ok {
+			return nil
+		}
+		fm = f.FontMatrix
+	default:
+		return nil
+	}
+
+	var toGID map[cid.CID]glyph.ID
+	if o.IsCIDKeyed() {
+		toGID = make(map[cid.CID]glyph.ID, len(o.GIDToCID))
+		for gid, c := range o.GIDToCID {
+			toGID[c] = glyph.ID(gid)
+		}
+	}
+	lookup := func(c cid.CID) (glyph.ID, bool) {
+		if toGID != nil {
+			gid, ok := toGID[c]
+			return gid, ok
+		}
+		return glyph.ID(c), int(c) < len(o.Glyphs)
+	}
+	return func(code font.Code) (path.Path, matrix.Matrix, bool) {
+		gid, ok := lookup(code.CID)
+		if !ok {
+			gid, _ = lookup(code.Notdef)
+		}
+		return o.Path(gid), o.GlyphMatrix(fm, gid), true
+	}
+}
+
+// glyfOutlines returns the outlines of a CIDFont with a TrueType font
+// program.
+func glyfOutlines(fi *dict.FontInfoGlyfEmbedded) func(font.Code) (path.Path, matrix.Matrix, bool) {
+	if fi.FontFile == nil {
+		return nil
+	}
+	f, err := sfntglyphs.FromStream(fi.FontFile)
+	if err != nil {
+		return nil
+	}
+	return func(code font.Code) (path.Path, matrix.Matrix, bool) {
+		gid := glyph.ID(code.CID)
+		if fi.CIDToGID != nil {
+			gid = 0
+			if int(code.CID) < len(fi.CIDToGID) {
+				gid = fi.CIDToGID[code.CID]
+			}
+		}
+		p, M := sfntOutline(f, gid)
+		return p, M, true
+	}
+}
+
+// sfntOutline returns the outline of a glyph in a TrueType or OpenType font,
+// together with the matrix which maps glyph space to text space.
+func sfntOutline(f *sfnt.Font, gid glyph.ID) (path.Path, matrix.Matrix) {
+	M := f.FontMatrix
+	if o, ok := f.Outlines.(*cff.Outlines); ok {
+		M = o.GlyphMatrix(M, gid)
+	}
+	return f.Outlines.Path(gid), M
+}
+
+// glyphName returns the name of the glyph selected by a CID of a simple
+// font.  The CID is the character code plus one.
+func glyphName(enc encoding.Simple, builtin []string, c cid.CID) string {
+	if c == 0 {
+		return ".notdef"
+	}
+	code := byte(c - 1)
+	var name string
+	if enc != nil {
+		name = enc(code)
+	}
+	if enc == nil || name == encoding.UseBuiltin {
+		name = ""
+		if int(code) < len(builtin) {
+			name = builtin[code]
+		}
+	}
+	if name == "" {
+		return ".notdef"
+	}
+	return name
+}
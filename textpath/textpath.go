@@ -0,0 +1,116 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package textpath
+
+import (
+	"fmt"
+	"io"
+	"maps"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/internal/rewrite"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// Options control the conversion.
+type Options struct {
+	// ActualText wraps the outlines of each converted text-showing operator
+	// in a marked-content sequence with an ActualText entry, so that the
+	// text remains searchable.
+	ActualText bool
+}
+
+// Write reads the document from r, converts the text on all pages to
+// outlines, and writes the result to w.  A nil *Options uses the default
+// options.
+func Write(w io.Writer, r pdf.Getter, opts *Options) error {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+
+	refs, dicts, err := rewrite.ReadPages(r)
+	if err != nil {
+		return err
+	}
+
+	out, err := rewrite.NewWriter(w, r, pdf.GetVersion(r))
+	if err != nil {
+		return err
+	}
+	rm := pdf.NewResourceManager(out)
+
+	c := &converter{
+		x:          pdf.NewExtractor(r),
+		out:        out,
+		rm:         rm,
+		copy:       pdf.NewCopier(out, r),
+		actualText: o.ActualText,
+		fonts:      map[any]*glyphSource{},
+		forms:      map[pdf.Reference]pdf.Reference{},
+	}
+
+	newRefs := rewrite.RedirectPages(out, c.copy, refs)
+
+	tree := pagetree.NewWriter(out, rm)
+	for i, dict := range dicts {
+		newDict, err := c.convertPage(dict)
+		if err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+		if err := tree.AppendPageDict(newRefs[i], newDict); err != nil {
+			return err
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		return err
+	}
+
+	metaIn := r.GetMeta()
+	meta := out.GetMeta()
+	meta.Info = metaIn.Info
+	if err := rewrite.CopyCatalog(out, c.copy, metaIn.Catalog); err != nil {
+		return err
+	}
+	meta.Catalog.Pages = pagesRef
+
+	if err := rm.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// convertPage converts the content of a page and returns the new page
+// dictionary.
+func (c *converter) convertPage(src pdf.Dict) (pdf.Dict, error) {
+	contents, res, err := c.page(src["Contents"], src["Resources"])
+	if err != nil {
+		return nil, err
+	}
+
+	rest := maps.Clone(src)
+	delete(rest, "Contents")
+	delete(rest, "Resources")
+	dict, err := c.copy.CopyDict(rest)
+	if err != nil {
+		return nil, err
+	}
+	dict["Contents"] = contents
+	dict["Resources"] = res
+	return dict, nil
+}
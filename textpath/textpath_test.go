@@ -0,0 +1,310 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package textpath
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/document"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/font/cff"
+	"seehuhn.de/go/pdf/font/gofont"
+	"seehuhn.de/go/pdf/font/standard"
+	"seehuhn.de/go/pdf/font/type1"
+	"seehuhn.de/go/pdf/graphics"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/internal/debug/makefont"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+	"seehuhn.de/go/pdf/internal/rewrite/rewritetest"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// makeSource writes a one-page document which shows the text "AB" at
+// (72, 700) in 20pt, using the given font and text rendering mode.
+func makeSource(t *testing.T, F font.Layouter, mode graphics.TextRenderingMode) *pdf.Reader {
+	t.Helper()
+
+	buf := memfile.New()
+	doc, err := document.WriteMultiPage(buf, document.A4, pdf.V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := doc.AddPage()
+	p.TextBegin()
+	p.TextSetFont(F, 20)
+	if mode != graphics.TextRenderingModeFill {
+		p.TextSetRenderingMode(mode)
+	}
+	p.TextFirstLine(72, 700)
+	p.TextShow("AB")
+	p.TextEnd()
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(buf, int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// convert runs Write and returns the operators of the first page of the
+// result, together with the page's resource dictionary.
+func convert(t *testing.T, r *pdf.Reader, opts *Options) ([]content.Operator, pdf.Dict) {
+	t.Helper()
+
+	var out bytes.Buffer
+	if err := Write(&out, r, opts); err != nil {
+		t.Fatal(err)
+	}
+	rr, err := pdf.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dict := range pagetree.NewIterator(rr).All() {
+		cur := pdf.NewCursor(rr)
+		stm, err := cur.Stream(dict["Contents"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := pdf.ReadAll(rr, nil, stm, 1<<20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := cur.Dict(dict["Resources"])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		open := func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(data)), nil }
+		it := content.NewScanner(open).NewIter()
+		var ops []content.Operator
+		for name, args := range it.All() {
+			ops = append(ops, content.Operator{Name: name, Args: append([]pdf.Object(nil), args...)})
+		}
+		if err := it.Err(); err != nil {
+			t.Fatal(err)
+		}
+		return ops, res
+	}
+	t.Fatal("no pages")
+	return nil, nil
+}
+
+// count returns how often each operator occurs.
+func count(ops []content.Operator) map[content.OpName]int {
+	res := map[content.OpName]int{}
+	for _, op := range ops {
+		res[op.Name]++
+	}
+	return res
+}
+
+// num returns the value of a numeric operand.
+func num(obj pdf.Object) float64 {
+	switch x := obj.(type) {
+	case pdf.Integer:
+		return float64(x)
+	case pdf.Real:
+		return float64(x)
+	case pdf.Number:
+		return float64(x)
+	}
+	return 0
+}
+
+// textOps lists the operators which must not remain after conversion.
+var textOps = []content.OpName{
+	content.OpTextBegin, content.OpTextEnd, content.OpTextSetFont,
+	content.OpTextShow, content.OpTextShowArray,
+}
+
+func TestFonts(t *testing.T) {
+	for _, test := range []struct {
+		name string
+		make func() (font.Layouter, error)
+	}{
+		{"TrueType", func() (font.Layouter, error) { return gofont.Regular.NewSimple(nil) }},
+		{"TrueTypeComposite", func() (font.Layouter, error) { return gofont.Regular.NewComposite(nil) }},
+		{"CFF", func() (font.Layouter, error) { return cff.NewSimple(makefont.OpenType(), nil) }},
+		{"CFFComposite", func() (font.Layouter, error) { return cff.NewComposite(makefont.OpenTypeCID(), nil) }},
+		{"Type1", func() (font.Layouter, error) { return type1.New(makefont.Type1(), makefont.AFM()) }},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			F, err := test.make()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ops, res := convert(t, makeSource(t, F, graphics.TextRenderingModeFill), nil)
+
+			n := count(ops)
+			for _, op := range textOps {
+				if n[op] > 0 {
+					t.Errorf("%d %q operators remain", n[op], op)
+				}
+			}
+			if n[content.OpFill] != 1 {
+				t.Errorf("got %d fill operators, want 1", n[content.OpFill])
+			}
+			if n[content.OpMoveTo] < 2 {
+				t.Errorf("got %d subpaths, want at least 2", n[content.OpMoveTo])
+			}
+			if res["Font"] != nil {
+				t.Error("unused font resources are kept")
+			}
+
+			// The glyphs "A" and "B" lie in the box spanned by the text
+			// position and the em square, at 20pt.
+			for _, op := range ops {
+				switch op.Name {
+				case content.OpMoveTo, content.OpLineTo, content.OpCurveTo:
+				default:
+					continue
+				}
+				for i := 0; i+1 < len(op.Args); i += 2 {
+					x, y := num(op.Args[i]), num(op.Args[i+1])
+					if x < 71 || x > 72+2*20 || y < 700-5 || y > 700+20 {
+						t.Errorf("point (%g, %g) outside of the text box", x, y)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRenderingModes(t *testing.T) {
+	F, err := gofont.Regular.NewSimple(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		mode  graphics.TextRenderingMode
+		paint content.OpName
+		clip  bool
+	}{
+		{graphics.TextRenderingModeStroke, content.OpStroke, false},
+		{graphics.TextRenderingModeFillStroke, content.OpFillAndStroke, false},
+		{graphics.TextRenderingModeInvisible, "", false},
+		{graphics.TextRenderingModeFillClip, content.OpFill, true},
+		{graphics.TextRenderingModeClip, "", true},
+	} {
+		ops, _ := convert(t, makeSource(t, F, test.mode), nil)
+		n := count(ops)
+		for _, op := range []content.OpName{content.OpFill, content.OpStroke, content.OpFillAndStroke} {
+			want := 0
+			if op == test.paint {
+				want = 1
+			}
+			if n[op] != want {
+				t.Errorf("mode %d: got %d %q operators, want %d", test.mode, n[op], op, want)
+			}
+		}
+		if got := n[content.OpClipNonZero] == 1 && n[content.OpEndPath] == 1; got != test.clip {
+			t.Errorf("mode %d: clip = %t, want %t", test.mode, got, test.clip)
+		}
+		if test.mode == graphics.TextRenderingModeInvisible && n[content.OpMoveTo] > 0 {
+			t.Errorf("mode %d: invisible text is drawn", test.mode)
+		}
+	}
+}
+
+func TestActualText(t *testing.T) {
+	F, err := gofont.Regular.NewSimple(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops, _ := convert(t, makeSource(t, F, graphics.TextRenderingModeFill), &Options{ActualText: true})
+
+	var found bool
+	for _, op := range ops {
+		if op.Name != content.OpBeginMarkedContentWithProperties {
+			continue
+		}
+		dict, _ := op.Args[1].(pdf.Dict)
+		if s, _ := dict["ActualText"].(pdf.String); string(s) == "AB" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("no ActualText span found")
+	}
+	if n := count(ops); n[content.OpEndMarkedContent] != 1 {
+		t.Errorf("got %d EMC operators, want 1", n[content.OpEndMarkedContent])
+	}
+}
+
+func TestType3(t *testing.T) {
+	F, err := makefont.Type3()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops, res := convert(t, makeSource(t, F, graphics.TextRenderingModeFill), nil)
+
+	n := count(ops)
+	for _, op := range textOps {
+		if n[op] > 0 {
+			t.Errorf("%d %q operators remain", n[op], op)
+		}
+	}
+	if n[content.OpXObject] != 2 {
+		t.Errorf("got %d Do operators, want 2", n[content.OpXObject])
+	}
+	xObjects, _ := res["XObject"].(pdf.Dict)
+	if len(xObjects) != 2 {
+		t.Errorf("got %d XObjects, want 2", len(xObjects))
+	}
+}
+
+func TestKeepText(t *testing.T) {
+	// the standard fonts are not embedded, so no outlines are available
+	F, err := standard.Helvetica.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ops, res := convert(t, makeSource(t, F, graphics.TextRenderingModeFill), nil)
+
+	n := count(ops)
+	if n[content.OpTextShow]+n[content.OpTextShowArray] != 1 || n[content.OpTextSetFont] != 1 {
+		t.Errorf("text is not kept: %v", n)
+	}
+	if fonts, _ := res["Font"].(pdf.Dict); len(fonts) != 1 {
+		t.Errorf("got %d font resources, want 1", len(fonts))
+	}
+	for _, op := range ops {
+		if op.Name == content.OpTextSetMatrix {
+			x, y := num(op.Args[4]), num(op.Args[5])
+			if x != 72 || y != 700 {
+				t.Errorf("text moved to (%g, %g)", x, y)
+			}
+		}
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	r := rewritetest.Source(t)
+	res := rewritetest.Rewrite(t, r, func(w io.Writer, r pdf.Getter) error {
+		return Write(w, r, nil)
+	})
+	rewritetest.CheckCatalog(t, res)
+}
@@ -0,0 +1,41 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package textreplace finds and replaces text in the pages of a PDF
+// document.
+//
+// [Write] copies a document and replaces every occurrence of the given
+// strings in the page content streams, for example to fill the
+// placeholders of a template.  The text of the pages is recovered from the
+// character codes of the text-showing operators, using the ToUnicode maps
+// and the encodings of the fonts, so that matches are found even if the
+// text is split across several Tj and TJ operators.
+//
+// The replacement text is shown in the font of the matched text, starting
+// at the position of the first matched glyph.  Text following the match is
+// not moved.  If the font lacks glyphs for the replacement text, the
+// replacement is laid out using a [font.Layouter] supplied by the caller:
+// either one for the same font, or a substitute font.  Such fonts are
+// embedded as new font resources; the original font is left unchanged.
+//
+// A match is only replaced if this can be done safely: all matched glyphs
+// must use the same font and font size, lie on one line of horizontal text,
+// and start and end at glyph boundaries.  Matches which are not replaced
+// are listed in the [Report], together with the reason.
+//
+// Only the page content streams are searched; text in form XObjects,
+// patterns and annotation appearance streams is left unchanged.
+package textreplace
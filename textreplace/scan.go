@@ -0,0 +1,309 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package textreplace
+
+import (
+	"slices"
+	"strings"
+
+	"seehuhn.de/go/geom/matrix"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/extract"
+	"seehuhn.de/go/pdf/page"
+	"seehuhn.de/go/pdf/reader"
+)
+
+// pageText holds the operators of a page content stream, together with the
+// glyphs shown by its text-showing operators.
+type pageText struct {
+	ops    []content.Operator
+	rawRes pdf.Dict
+
+	// glyphs lists the glyphs in content stream order, and text is the
+	// concatenation of their text.  The text of glyph i starts at byte
+	// offset offsets[i].
+	glyphs  []*glyph
+	text    string
+	offsets []int
+
+	// states holds the text state of each text-showing operator, indexed
+	// by operator.
+	states map[int]*textState
+}
+
+// textState describes the text state in effect for a text-showing operator.
+type textState struct {
+	font     font.Instance
+	fontKey  any
+	fontName pdf.Name
+	size     float64
+	tc, tw   float64
+	th       float64
+}
+
+// glyph describes a single character code shown by a text-showing
+// operator.
+type glyph struct {
+	// op is the index of the operator, and elem the index of the string
+	// among the operands of the operator.  The character code occupies
+	// bytes start to end of that string.
+	op         int
+	elem       int
+	start, end int
+
+	text    string
+	advance float64 // displacement in text space units
+	tm      matrix.Matrix
+	code    []byte
+	width   float64
+	useWS   bool
+
+	// removed is set for glyphs which are part of a match, and insert is
+	// set for the first glyph of a match.
+	removed bool
+	insert  *encoded
+}
+
+// codeInfo describes a character code which is known to be usable with a
+// font.
+type codeInfo struct {
+	code  []byte
+	width float64
+	useWS bool
+}
+
+// scanner collects the glyphs of a content stream.
+type scanner struct {
+	c *converter
+	r *reader.Reader
+	p *pageText
+
+	fontName  pdf.Name
+	fontStack []pdf.Name
+
+	// pending holds the glyphs reported for the current operator.
+	pending []*glyph
+}
+
+// scanPage reads the content stream of a page.
+func (c *converter) scanPage(contents, resources pdf.Object) (*pageText, error) {
+	cur := pdf.CursorAt(c.x, nil)
+
+	rawRes, err := cur.Dict(resources)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	res, err := pdf.Decode(cur, resources, extract.Resources)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	if res == nil {
+		res = &content.Resources{}
+	}
+
+	p := &pageText{
+		rawRes: rawRes,
+		states: map[int]*textState{},
+	}
+	if contents == nil {
+		return p, nil
+	}
+	resolved, err := cur.Resolve(contents)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	segments, err := page.ExtractContents(cur, resolved)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+
+	s := &scanner{c: c, p: p}
+	s.r = reader.New(c.x)
+	s.r.State = content.NewState(content.Page, res)
+	s.r.Character = s.character
+	s.r.EveryOp = s.op
+	if err := s.r.ProcessIter((&page.Page{Contents: segments}).NewIter()); err != nil {
+		return nil, err
+	}
+
+	var text strings.Builder
+	p.offsets = make([]int, len(p.glyphs))
+	for i, g := range p.glyphs {
+		p.offsets[i] = text.Len()
+		text.WriteString(g.text)
+	}
+	p.text = text.String()
+	return p, nil
+}
+
+// character is called by the reader for every glyph shown.
+func (s *scanner) character(code font.Code) error {
+	gs := s.r.State.GState
+	advance := code.Width*gs.TextFontSize + gs.TextCharacterSpacing
+	if code.UseWordSpacing {
+		advance += gs.TextWordSpacing
+	}
+	s.pending = append(s.pending, &glyph{
+		text:    code.Text,
+		advance: advance * gs.TextHorizontalScaling,
+		tm:      gs.TextMatrix,
+		width:   code.Width,
+		useWS:   code.UseWordSpacing,
+	})
+	return nil
+}
+
+// op is called by the reader for every operator.  For text-showing
+// operators, the glyphs reported by the Character callback are matched to
+// the character codes in the operands.
+func (s *scanner) op(name string, args []pdf.Object) error {
+	opName := content.OpName(name)
+	switch opName {
+	case content.OpTextSetFont:
+		if len(args) > 0 {
+			s.fontName, _ = args[0].(pdf.Name)
+		}
+	case content.OpPushGraphicsState:
+		s.fontStack = append(s.fontStack, s.fontName)
+	case content.OpPopGraphicsState:
+		if n := len(s.fontStack); n > 0 {
+			s.fontName = s.fontStack[n-1]
+			s.fontStack = s.fontStack[:n-1]
+		}
+	case content.OpTextShow, content.OpTextShowArray,
+		content.OpTextShowMoveNextLine, content.OpTextShowMoveNextLineSetSpacing:
+		s.show(args)
+	}
+	s.pending = s.pending[:0]
+
+	s.p.ops = append(s.p.ops, content.Operator{Name: opName, Args: cloneArgs(args)})
+	return nil
+}
+
+// show assigns the pending glyphs to the character codes of a text-showing
+// operator.  If the glyphs cannot be matched to the codes, for example
+// because the text is invisible, the glyphs are dropped.
+func (s *scanner) show(args []pdf.Object) {
+	gs := s.r.State.GState
+	f := gs.TextFont
+	if f == nil || len(s.pending) == 0 {
+		return
+	}
+
+	type codeRange struct{ elem, start, end int }
+	var codes []codeRange
+	codec := f.Codec()
+	for elem, str := range showStrings(args) {
+		for pos := 0; pos < len(str); {
+			_, k, _ := codec.Decode(str[pos:])
+			codes = append(codes, codeRange{elem, pos, pos + k})
+			pos += k
+		}
+	}
+	if len(codes) != len(s.pending) {
+		return
+	}
+
+	opIdx := len(s.p.ops)
+	st := &textState{
+		font:     f,
+		fontKey:  s.fontKey(f),
+		fontName: s.fontName,
+		size:     gs.TextFontSize,
+		tc:       gs.TextCharacterSpacing,
+		tw:       gs.TextWordSpacing,
+		th:       gs.TextHorizontalScaling,
+	}
+	s.p.states[opIdx] = st
+
+	strs := showStrings(args)
+	known := s.c.codes[st.fontKey]
+	if known == nil {
+		known = map[string]*codeInfo{}
+		s.c.codes[st.fontKey] = known
+	}
+	for i, g := range s.pending {
+		cr := codes[i]
+		g.op, g.elem, g.start, g.end = opIdx, cr.elem, cr.start, cr.end
+		g.code = slices.Clone(strs[cr.elem][cr.start:cr.end])
+		s.p.glyphs = append(s.p.glyphs, g)
+		if g.text != "" && known[g.text] == nil {
+			known[g.text] = &codeInfo{code: g.code, width: g.width, useWS: g.useWS}
+		}
+	}
+}
+
+// fontKey returns the key used to identify a font across pages: the
+// reference of the font dictionary if available, and the font instance
+// otherwise.
+func (s *scanner) fontKey(f font.Instance) any {
+	fonts, _ := pdf.CursorAt(s.c.x, nil).Dict(s.p.rawRes["Font"])
+	if ref, ok := fonts[s.fontName].(pdf.Reference); ok {
+		return ref
+	}
+	return f
+}
+
+// showStrings returns the strings shown by a text-showing operator, indexed
+// by their position among the operands.  For TJ, the index is the position
+// in the array; other elements of the array map to nil.
+func showStrings(args []pdf.Object) []pdf.String {
+	switch len(args) {
+	case 1:
+		switch a := args[0].(type) {
+		case pdf.String:
+			return []pdf.String{a}
+		case pdf.Array:
+			res := make([]pdf.String, len(a))
+			for i, obj := range a {
+				res[i], _ = obj.(pdf.String)
+			}
+			return res
+		}
+	case 3:
+		if s, ok := args[2].(pdf.String); ok {
+			return []pdf.String{s}
+		}
+	}
+	return nil
+}
+
+// cloneArgs returns a copy of the operands of an operator which does not
+// share memory with the content stream scanner.
+func cloneArgs(args []pdf.Object) []pdf.Object {
+	res := make([]pdf.Object, len(args))
+	for i, arg := range args {
+		switch a := arg.(type) {
+		case pdf.String:
+			res[i] = slices.Clone(a)
+		case pdf.Array:
+			res[i] = pdf.Array(cloneArgs(a))
+		case pdf.Dict:
+			d := make(pdf.Dict, len(a))
+			for key, val := range a {
+				d[key] = cloneArgs([]pdf.Object{val})[0]
+			}
+			res[i] = d
+		default:
+			res[i] = arg
+		}
+	}
+	return res
+}
@@ -0,0 +1,638 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package textreplace
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/font/subset"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/internal/rewrite"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// Replacement describes a string to be replaced.
+type Replacement struct {
+	// Old is the text to find.  This must not be empty.
+	Old string
+
+	// New is the text which replaces Old.  If this is empty, the matched
+	// text is removed.
+	New string
+}
+
+// Options control how replacement text is shown.
+type Options struct {
+	// Fonts (optional) maps PostScript font names to fonts which can show
+	// replacement text which the embedded font cannot show, for example
+	// because the embedded font is a subset.  The names may be given with
+	// or without subset tag.
+	Fonts map[string]font.Layouter
+
+	// Substitute (optional) is used for replacement text which can be shown
+	// neither in the original font nor in a font from Fonts.
+	Substitute font.Layouter
+}
+
+// Report summarises the result of [Write].
+type Report struct {
+	// Replaced is the number of matches which were replaced.
+	Replaced int
+
+	// Skipped lists the matches which were not replaced.
+	Skipped []Skipped
+}
+
+// Skipped describes a match which was not replaced.
+type Skipped struct {
+	// Page is the zero-based index of the page.
+	Page int
+
+	// Old is the text which was found.
+	Old string
+
+	// Reason describes why the match was not replaced.
+	Reason string
+}
+
+type converter struct {
+	x    *pdf.Extractor
+	out  *pdf.Writer
+	rm   *pdf.ResourceManager
+	copy *pdf.Copier
+	opts Options
+
+	// codes lists, for every font, the character codes found in the
+	// document, indexed by their text.  These codes are known to be
+	// present in the embedded font.
+	codes map[any]map[string]*codeInfo
+}
+
+// Write reads the document from r, replaces the given strings on all pages,
+// and writes the result to w.  The replacements are searched in the order
+// given; where matches overlap, the first one is used.  A nil *Options uses
+// the default options.
+func Write(w io.Writer, r pdf.Getter, replacements []Replacement, opts *Options) (*Report, error) {
+	for _, repl := range replacements {
+		if repl.Old == "" {
+			return nil, errors.New("empty search string")
+		}
+	}
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+
+	refs, dicts, err := rewrite.ReadPages(r)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := rewrite.NewWriter(w, r, pdf.GetVersion(r))
+	if err != nil {
+		return nil, err
+	}
+	rm := pdf.NewResourceManager(out)
+	c := &converter{
+		x:     pdf.NewExtractor(r),
+		out:   out,
+		rm:    rm,
+		copy:  pdf.NewCopier(out, r),
+		opts:  o,
+		codes: map[any]map[string]*codeInfo{},
+	}
+
+	newRefs := rewrite.RedirectPages(out, c.copy, refs)
+
+	// All pages are read before any replacement is made, so that the
+	// character codes used anywhere in the document can be used for the
+	// replacement text.
+	pages := make([]*pageText, len(dicts))
+	for i, dict := range dicts {
+		pages[i], err = c.scanPage(dict["Contents"], dict["Resources"])
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+	}
+
+	report := &Report{}
+	tree := pagetree.NewWriter(out, rm)
+	for i, dict := range dicts {
+		n := 0
+		for _, m := range pages[i].findMatches(replacements) {
+			reason := m.reason
+			if reason == "" {
+				reason = c.prepare(pages[i], m)
+			}
+			if reason != "" {
+				report.Skipped = append(report.Skipped, Skipped{Page: i, Old: m.repl.Old, Reason: reason})
+				continue
+			}
+			n++
+		}
+		report.Replaced += n
+
+		var newDict pdf.Dict
+		if n > 0 {
+			newDict, err = c.rewritePage(dict, pages[i])
+		} else {
+			newDict, err = c.copy.CopyDict(dict)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("page %d: %w", i+1, err)
+		}
+		if err := tree.AppendPageDict(newRefs[i], newDict); err != nil {
+			return nil, err
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	metaIn := r.GetMeta()
+	meta := out.GetMeta()
+	meta.Info = metaIn.Info
+	if err := rewrite.CopyCatalog(out, c.copy, metaIn.Catalog); err != nil {
+		return nil, err
+	}
+	meta.Catalog.Pages = pagesRef
+
+	if err := rm.Close(); err != nil {
+		return nil, err
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// match describes an occurrence of a search string.  The matched glyphs
+// are glyphs first to last-1.  If reason is set, the match cannot be
+// replaced.
+type match struct {
+	repl        *Replacement
+	first, last int
+	reason      string
+}
+
+// findMatches returns the non-overlapping matches of the replacements, in
+// the order in which they occur on the page.
+func (p *pageText) findMatches(replacements []Replacement) []*match {
+	type candidate struct {
+		start, end, idx int
+	}
+	var cands []candidate
+	for idx, repl := range replacements {
+		for pos := 0; ; {
+			i := strings.Index(p.text[pos:], repl.Old)
+			if i < 0 {
+				break
+			}
+			start := pos + i
+			pos = start + len(repl.Old)
+			cands = append(cands, candidate{start, pos, idx})
+		}
+	}
+	slices.SortFunc(cands, func(a, b candidate) int {
+		if a.start != b.start {
+			return a.start - b.start
+		}
+		return a.idx - b.idx
+	})
+
+	var res []*match
+	end := 0
+	for _, cand := range cands {
+		if cand.start < end {
+			continue
+		}
+		end = cand.end
+
+		m := &match{repl: &replacements[cand.idx]}
+		m.first = p.glyphAt(cand.start)
+		m.last = p.glyphAt(cand.end)
+		if m.first >= len(p.glyphs) || p.offsets[m.first] != cand.start ||
+			p.offsets[m.last-1]+len(p.glyphs[m.last-1].text) != cand.end {
+			m.reason = "the match does not start and end at glyph boundaries"
+		}
+		res = append(res, m)
+	}
+	return res
+}
+
+// glyphAt returns the index of the first glyph with text, whose text
+// starts at or after the byte offset pos.  If there is no such glyph, the
+// number of glyphs is returned.
+func (p *pageText) glyphAt(pos int) int {
+	i, _ := slices.BinarySearch(p.offsets, pos)
+	for i < len(p.glyphs) && p.glyphs[i].text == "" {
+		i++
+	}
+	return i
+}
+
+// prepare checks whether a match can be replaced and, if so, marks the
+// matched glyphs.  The return value is the reason why the match cannot be
+// replaced, or the empty string.
+func (c *converter) prepare(p *pageText, m *match) string {
+	glyphs := p.glyphs[m.first:m.last]
+	first := glyphs[0]
+	st := p.states[first.op]
+
+	if st.font.WritingMode() != font.Horizontal {
+		return "vertical text is not supported"
+	}
+	if st.size == 0 || st.th == 0 {
+		return "the text has zero size"
+	}
+
+	inv, ok := first.tm.Inv()
+	if !ok {
+		return "the text matrix is singular"
+	}
+	tol := 0.05 * math.Abs(st.size)
+	for _, g := range glyphs[1:] {
+		other := p.states[g.op]
+		if other.fontKey != st.fontKey || other.size != st.size || other.th != st.th {
+			return "the matched text uses more than one font"
+		}
+		for i := range 4 {
+			if math.Abs(g.tm[i]-first.tm[i]) > 1e-6 {
+				return "the matched text is not on a single line"
+			}
+		}
+		x := g.tm[4]*inv[0] + g.tm[5]*inv[2] + inv[4]
+		y := g.tm[4]*inv[1] + g.tm[5]*inv[3] + inv[5]
+		if math.Abs(y) > tol || x < -tol {
+			return "the matched text is not on a single line"
+		}
+	}
+
+	enc := c.encode(st, m.repl.New)
+	if enc == nil {
+		return "the replacement text cannot be shown in the available fonts"
+	}
+
+	for _, g := range glyphs {
+		g.removed = true
+	}
+	first.insert = enc
+	return ""
+}
+
+// encoded holds replacement text, encoded for a TJ operator.
+type encoded struct {
+	// items holds the operand of the TJ operator.
+	items pdf.Array
+
+	// advance is the displacement of the text, in text space units.
+	advance float64
+
+	// font is the font the text is encoded for, or nil if the text uses the
+	// font of the matched text.
+	font font.Layouter
+}
+
+// encode encodes the replacement text for the text state st.  The text is
+// encoded using the character codes of the original font if possible, and
+// using the fonts from the options otherwise.  The result is nil if the
+// text cannot be encoded.
+func (c *converter) encode(st *textState, text string) *encoded {
+	if enc := c.encodeKnown(st, text); enc != nil {
+		return enc
+	}
+	if st.fontName == "" {
+		return nil
+	}
+
+	name := st.font.PostScriptName()
+	_, base := subset.Split(name)
+	for _, F := range []font.Layouter{c.opts.Fonts[name], c.opts.Fonts[base], c.opts.Substitute} {
+		if F == nil {
+			continue
+		}
+		if enc := encodeLayout(F, st, text); enc != nil {
+			return enc
+		}
+	}
+	return nil
+}
+
+// encodeKnown encodes text using the character codes of the original font
+// which occur in the document.
+func (c *converter) encodeKnown(st *textState, text string) *encoded {
+	known := c.codes[st.fontKey]
+	maxLen := 0
+	for key := range known {
+		maxLen = max(maxLen, len(key))
+	}
+
+	var s pdf.String
+	advance := 0.0
+	for pos := 0; pos < len(text); {
+		var info *codeInfo
+		l := min(maxLen, len(text)-pos)
+		for ; l > 0; l-- {
+			if pos+l < len(text) && !utf8.RuneStart(text[pos+l]) {
+				continue
+			}
+			if info = known[text[pos:pos+l]]; info != nil {
+				break
+			}
+		}
+		if info == nil {
+			return nil
+		}
+		s = append(s, info.code...)
+		w := info.width*st.size + st.tc
+		if info.useWS {
+			w += st.tw
+		}
+		advance += w * st.th
+		pos += l
+	}
+	return &encoded{items: pdf.Array{s}, advance: advance}
+}
+
+// encodeLayout lays out text using the font F, with the text state st.
+// The result is nil if F has no glyphs for some of the text.
+func encodeLayout(F font.Layouter, st *textState, text string) *encoded {
+	if F.WritingMode() != font.Horizontal {
+		return nil
+	}
+
+	T := font.NewTypesetter(F, st.size)
+	T.SetCharacterSpacing(st.tc)
+	T.SetWordSpacing(st.tw)
+	T.SetHorizontalScaling(st.th)
+	seq := T.Layout(nil, text)
+
+	codec := F.Codec()
+	var items pdf.Array
+	var run pdf.String
+	xActual, xWanted := 0.0, seq.Skip
+	for _, g := range seq.Seq {
+		if g.GID == 0 {
+			return nil
+		}
+		if kern := kernValue(xWanted-xActual, st); kern != 0 && !F.IsBlank(g.GID) {
+			if len(run) > 0 {
+				items = append(items, run)
+				run = nil
+			}
+			items = append(items, kern)
+			xActual -= float64(kern) / 1000 * st.size * st.th
+		}
+		xWanted += g.Advance
+
+		code, ok := F.Encode(g.GID, g.Text)
+		if !ok {
+			return nil
+		}
+		prev := len(run)
+		run = codec.AppendCode(run, code)
+		for info := range F.Codes(run[prev:]) {
+			w := info.Width*st.size + st.tc
+			if info.UseWordSpacing {
+				w += st.tw
+			}
+			xActual += w * st.th
+		}
+	}
+	if len(run) > 0 {
+		items = append(items, run)
+	}
+	if kern := kernValue(xWanted-xActual, st); kern != 0 {
+		items = append(items, kern)
+		xActual -= float64(kern) / 1000 * st.size * st.th
+	}
+	return &encoded{items: items, advance: xActual, font: F}
+}
+
+// kernValue returns the TJ operand which moves the text position by dx
+// text space units.
+func kernValue(dx float64, st *textState) pdf.Number {
+	return pdf.Number(pdf.Round(-dx*1000/(st.size*st.th), 3))
+}
+
+// rewritePage writes the new content stream of a page and returns the new
+// page dictionary.
+func (c *converter) rewritePage(src pdf.Dict, p *pageText) (pdf.Dict, error) {
+	fonts, err := pdf.CursorAt(c.x, nil).Dict(p.rawRes["Font"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	newFonts := map[font.Layouter]pdf.Name{}
+	next := 1
+	fontName := func(F font.Layouter) pdf.Name {
+		if name, ok := newFonts[F]; ok {
+			return name
+		}
+		for {
+			name := pdf.Name(fmt.Sprintf("R%d", next))
+			next++
+			if _, exists := fonts[name]; !exists {
+				newFonts[F] = name
+				return name
+			}
+		}
+	}
+
+	byOp := map[int][]*glyph{}
+	for _, g := range p.glyphs {
+		byOp[g.op] = append(byOp[g.op], g)
+	}
+
+	var buf bytes.Buffer
+	for i, op := range p.ops {
+		ops := []content.Operator{op}
+		if glyphs := byOp[i]; slices.ContainsFunc(glyphs, func(g *glyph) bool { return g.removed }) {
+			ops = rewriteOp(op, p.states[i], glyphs, fontName)
+		}
+		for _, op := range ops {
+			if err := op.Format(&buf); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	ref := c.out.Alloc()
+	stm, err := c.out.OpenStream(ref, nil, pdf.FilterCompress{})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := stm.Write(buf.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := stm.Close(); err != nil {
+		return nil, err
+	}
+
+	res, err := c.copy.CopyDict(p.rawRes)
+	if err != nil {
+		return nil, err
+	}
+	if res == nil {
+		res = pdf.Dict{}
+	}
+	if len(newFonts) > 0 {
+		fontDict, err := c.copy.CopyDict(fonts)
+		if err != nil {
+			return nil, err
+		}
+		if fontDict == nil {
+			fontDict = pdf.Dict{}
+		}
+		for F, name := range newFonts {
+			obj, err := c.rm.Embed(F)
+			if err != nil {
+				return nil, err
+			}
+			fontDict[name] = obj
+		}
+		res["Font"] = fontDict
+	}
+
+	rest := maps.Clone(src)
+	delete(rest, "Contents")
+	delete(rest, "Resources")
+	dict, err := c.copy.CopyDict(rest)
+	if err != nil {
+		return nil, err
+	}
+	dict["Contents"] = ref
+	dict["Resources"] = res
+	return dict, nil
+}
+
+// rewriteOp returns the operators which replace a text-showing operator
+// with removed glyphs.  Removed glyphs are replaced by kerning, so that the
+// position of the remaining glyphs does not change, and the replacement
+// text is inserted in place of the first glyph of each match.
+func rewriteOp(op content.Operator, st *textState, glyphs []*glyph, fontName func(font.Layouter) pdf.Name) []content.Operator {
+	var res []content.Operator
+	switch op.Name {
+	case content.OpTextShowMoveNextLine:
+		res = append(res, content.Operator{Name: content.OpTextNextLine})
+	case content.OpTextShowMoveNextLineSetSpacing:
+		res = append(res,
+			content.Operator{Name: content.OpTextSetWordSpacing, Args: op.Args[:1]},
+			content.Operator{Name: content.OpTextSetCharacterSpacing, Args: op.Args[1:2]},
+			content.Operator{Name: content.OpTextNextLine})
+	}
+
+	type pos struct{ elem, start int }
+	at := make(map[pos]*glyph, len(glyphs))
+	for _, g := range glyphs {
+		at[pos{g.elem, g.start}] = g
+	}
+
+	var items pdf.Array
+	var run pdf.String
+	skip := 0.0 // pending displacement, in text space units
+	flushRun := func() {
+		if len(run) > 0 {
+			items = append(items, run)
+			run = nil
+		}
+	}
+	flushSkip := func() {
+		if kern := kernValue(skip, st); kern != 0 {
+			flushRun()
+			items = append(items, kern)
+		}
+		skip = 0
+	}
+	flushItems := func() {
+		flushRun()
+		if len(items) > 0 {
+			res = append(res, content.Operator{Name: content.OpTextShowArray, Args: []pdf.Object{items}})
+			items = nil
+		}
+	}
+
+	strs := showStrings(op.Args)
+	var elems pdf.Array
+	if op.Name == content.OpTextShowArray {
+		elems, _ = op.Args[0].(pdf.Array)
+	} else {
+		elems = pdf.Array{strs[0]}
+	}
+	for elem, obj := range elems {
+		s, isString := obj.(pdf.String)
+		if !isString {
+			if kern, ok := number(obj); ok {
+				skip -= kern / 1000 * st.size * st.th
+			}
+			continue
+		}
+		for start := 0; start < len(s); {
+			g := at[pos{elem, start}]
+			if g == nil { // cannot happen for operators with removed glyphs
+				break
+			}
+			if enc := g.insert; enc != nil {
+				flushSkip()
+				if enc.font == nil {
+					flushRun()
+					items = append(items, enc.items...)
+				} else {
+					flushItems()
+					res = append(res,
+						content.Operator{Name: content.OpTextSetFont, Args: []pdf.Object{fontName(enc.font), pdf.Number(st.size)}},
+						content.Operator{Name: content.OpTextShowArray, Args: []pdf.Object{enc.items}},
+						content.Operator{Name: content.OpTextSetFont, Args: []pdf.Object{st.fontName, pdf.Number(st.size)}})
+				}
+				skip -= enc.advance
+			}
+			if g.removed {
+				skip += g.advance
+			} else {
+				flushSkip()
+				run = append(run, s[g.start:g.end]...)
+			}
+			start = g.end
+		}
+	}
+	flushSkip()
+	flushItems()
+	return res
+}
+
+// number returns the value of a numeric object.
+func number(obj pdf.Object) (float64, bool) {
+	switch x := obj.(type) {
+	case pdf.Integer:
+		return float64(x), true
+	case pdf.Real:
+		return float64(x), true
+	case pdf.Number:
+		return float64(x), true
+	}
+	return 0, false
+}
@@ -0,0 +1,241 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package textreplace
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/document"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/font/gofont"
+	"seehuhn.de/go/pdf/font/standard"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+	"seehuhn.de/go/pdf/internal/rewrite/rewritetest"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// makeSource writes a one-page document with one line of text per
+// element of lines.  Each line is shown using one text-showing operator
+// per element.
+func makeSource(t *testing.T, F font.Layouter, lines ...[]string) *pdf.Reader {
+	t.Helper()
+
+	buf := memfile.New()
+	doc, err := document.WriteMultiPage(buf, document.A4, pdf.V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := doc.AddPage()
+	p.TextBegin()
+	p.TextSetFont(F, 12)
+	for i, line := range lines {
+		if i == 0 {
+			p.TextFirstLine(72, 700)
+		} else {
+			p.TextSecondLine(0, -20)
+		}
+		for _, s := range line {
+			p.TextShow(s)
+		}
+	}
+	p.TextEnd()
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(buf, int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// replace runs Write and returns the text of the first page of the
+// result, together with the report.
+func replace(t *testing.T, r pdf.Getter, repl []Replacement, opts *Options) (*pageText, pdf.Dict, *Report) {
+	t.Helper()
+
+	var out bytes.Buffer
+	report, err := Write(&out, r, repl, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err := pdf.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, res := scanFirst(t, rr)
+	return p, res, report
+}
+
+// scanFirst reads the text of the first page of a document.
+func scanFirst(t *testing.T, r pdf.Getter) (*pageText, pdf.Dict) {
+	t.Helper()
+
+	c := &converter{x: pdf.NewExtractor(r), codes: map[any]map[string]*codeInfo{}}
+	for _, dict := range pagetree.NewIterator(r).All() {
+		p, err := c.scanPage(dict["Contents"], dict["Resources"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p, p.rawRes
+	}
+	t.Fatal("no pages")
+	return nil, nil
+}
+
+// position returns the horizontal position of the glyph whose text starts
+// at the given byte offset.
+func position(t *testing.T, p *pageText, offset int) float64 {
+	t.Helper()
+	for i, g := range p.glyphs {
+		if p.offsets[i] == offset && g.text != "" {
+			return g.tm[4]
+		}
+	}
+	t.Fatalf("no glyph at offset %d in %q", offset, p.text)
+	return 0
+}
+
+// TestReplace checks that a placeholder which is split across
+// text-showing operators is replaced using the codes of the original font,
+// and that the text after the placeholder does not move.
+func TestReplace(t *testing.T) {
+	F, err := gofont.Regular.NewSimple(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := makeSource(t, F,
+		[]string{"Dear {{NA", "ME}}!"},
+		[]string{"Jane Doe"})
+	before, _ := scanFirst(t, r)
+	xBefore := position(t, before, strings.Index(before.text, "!"))
+
+	after, res, report := replace(t, r, []Replacement{{Old: "{{NAME}}", New: "Jane"}}, nil)
+	if report.Replaced != 1 || len(report.Skipped) != 0 {
+		t.Fatalf("unexpected report: %+v", report)
+	}
+	if after.text != "Dear Jane!Jane Doe" {
+		t.Errorf("wrong text %q", after.text)
+	}
+	if xAfter := position(t, after, strings.Index(after.text, "!")); math.Abs(xAfter-xBefore) > 0.01 {
+		t.Errorf("text after the match moved from %g to %g", xBefore, xAfter)
+	}
+	if fonts, _ := res["Font"].(pdf.Dict); len(fonts) != 1 {
+		t.Errorf("expected 1 font, got %d", len(fonts))
+	}
+
+	// The replacement text starts where the placeholder started.
+	if want, got := position(t, before, 5), position(t, after, 5); math.Abs(got-want) > 0.01 {
+		t.Errorf("replacement starts at %g instead of %g", got, want)
+	}
+}
+
+// TestSubstitute checks that text which cannot be shown in the original
+// font is shown using a font from the options.
+func TestSubstitute(t *testing.T) {
+	F, err := gofont.Regular.NewSimple(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := makeSource(t, F, []string{"Dear {{NAME}}!"})
+	G, err := gofont.Regular.NewSimple(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	H, err := standard.Helvetica.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, test := range []struct {
+		name string
+		opts *Options
+	}{
+		{"Fonts", &Options{Fonts: map[string]font.Layouter{
+			F.PostScriptName(): G,
+		}}},
+		{"Substitute", &Options{Substitute: H}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			after, res, report := replace(t, r, []Replacement{{Old: "{{NAME}}", New: "Zoë"}}, test.opts)
+			if report.Replaced != 1 {
+				t.Fatalf("unexpected report: %+v", report)
+			}
+			if after.text != "Dear Zoë!" {
+				t.Errorf("wrong text %q", after.text)
+			}
+			if fonts, _ := res["Font"].(pdf.Dict); len(fonts) != 2 {
+				t.Errorf("expected 2 fonts, got %d", len(fonts))
+			}
+		})
+	}
+}
+
+// TestSkipped checks that matches which cannot be replaced are left
+// unchanged and are reported.
+func TestSkipped(t *testing.T) {
+	F, err := gofont.Regular.NewSimple(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := makeSource(t, F,
+		[]string{"Dear {{NAME}}"},
+		[]string{"{{AB", "C}}"},
+		[]string{"{{XY"},
+		[]string{"Z}}"})
+
+	after, _, report := replace(t, r, []Replacement{
+		{Old: "{{NAME}}", New: "Zoë"},
+		{Old: "{{ABC}}", New: "Dear"},
+		{Old: "{{XYZ}}", New: "Dear"},
+	}, nil)
+
+	if report.Replaced != 1 {
+		t.Errorf("expected 1 replacement, got %d", report.Replaced)
+	}
+	reasons := map[string]string{}
+	for _, s := range report.Skipped {
+		if s.Page != 0 {
+			t.Errorf("wrong page %d", s.Page)
+		}
+		reasons[s.Old] = s.Reason
+	}
+	if len(reasons) != 2 || reasons["{{NAME}}"] == "" || reasons["{{XYZ}}"] == "" {
+		t.Errorf("unexpected skipped matches: %+v", report.Skipped)
+	}
+	if after.text != "Dear {{NAME}}Dear{{XYZ}}" {
+		t.Errorf("wrong text %q", after.text)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	r := rewritetest.Source(t)
+	res := rewritetest.Rewrite(t, r, func(w io.Writer, r pdf.Getter) error {
+		_, err := Write(w, r, []Replacement{{Old: "body", New: "main"}}, nil)
+		return err
+	})
+	rewritetest.CheckCatalog(t, res)
+}
@@ -0,0 +1,46 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package tounicode repairs missing or broken ToUnicode maps.
+//
+// Text extraction relies on the ToUnicode CMap of a font to map character
+// codes to text.  Many PDF producers omit this map, or write maps which
+// assign wrong or unusable text to some codes.  [Write] copies a document
+// and writes new ToUnicode CMaps for the fonts where the mapping can be
+// improved.
+//
+// The character codes considered are those used in the content streams of
+// the pages, in the form XObjects these refer to, and in annotation
+// appearance streams.  For each code, the text is taken from the first of
+// the following sources which gives a result:
+//
+//   - the overrides given in [Options],
+//   - the existing ToUnicode map, unless the entry is empty, contains
+//     control characters or the replacement character U+FFFD,
+//   - the glyph names in the embedded font program,
+//   - the glyph names of the font encoding, including the built-in
+//     encodings of the standard 14 fonts,
+//   - the Unicode cmap table of an embedded TrueType font,
+//   - for CIDFonts using one of the Adobe character collections, the
+//     corresponding predefined UCS-2 CMap.
+//
+// Entries of the existing ToUnicode map which map to the private use area
+// are only used if no other source gives a result.  Codes for which no text
+// could be found are listed in the [Report].
+//
+// Fonts which are given as direct objects in resource dictionaries are
+// left unchanged.
+package tounicode
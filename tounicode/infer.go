@@ -0,0 +1,263 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tounicode
+
+import (
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"seehuhn.de/go/postscript/cid"
+	"seehuhn.de/go/postscript/type1/names"
+	sfntcmap "seehuhn.de/go/sfnt/cmap"
+	"seehuhn.de/go/sfnt/glyph"
+
+	"seehuhn.de/go/pdf/font/cmap"
+	"seehuhn.de/go/pdf/font/dict"
+	"seehuhn.de/go/pdf/font/encoding"
+	"seehuhn.de/go/pdf/font/glyphdata/sfntglyphs"
+	"seehuhn.de/go/pdf/font/pdfenc"
+	"seehuhn.de/go/pdf/font/textextract"
+)
+
+// ucs2CMaps lists the predefined CMaps which map Unicode to the CIDs of
+// the Adobe character collections, indexed by ordering.
+var ucs2CMaps = map[string]string{
+	"CNS1":   "UniCNS-UCS2-H",
+	"GB1":    "UniGB-UCS2-H",
+	"Japan1": "UniJIS-UCS2-H",
+	"Korea1": "UniKS-UCS2-H",
+}
+
+// guesser infers the text for the character codes of a font, using
+// information other than the ToUnicode map.
+type guesser struct {
+	d dict.Dict
+
+	// glyphText maps CIDs to text, based on glyph names in the embedded
+	// font.  For simple fonts, the CID is the character code plus one.
+	glyphText map[cid.CID]string
+
+	// gidText maps glyph IDs of an embedded TrueType font to text, based
+	// on the Unicode cmap table of the font.
+	gidText map[glyph.ID]rune
+
+	// gid maps CIDs to glyph IDs of the embedded TrueType font.
+	gid func(cid.CID) (glyph.ID, bool)
+
+	// cidText maps CIDs to text, for CIDFonts using a known character
+	// collection.
+	cidText map[cid.CID]string
+}
+
+// newGuesser collects the information used to infer text for a font.
+// The cidText argument caches the inverted UCS-2 CMaps.
+func newGuesser(d dict.Dict, cidText map[string]map[cid.CID]string) *guesser {
+	g := &guesser{
+		d:         d,
+		glyphText: textextract.GlyphNameMapping(d.MakeFont()),
+	}
+
+	switch d := d.(type) {
+	case *dict.TrueType:
+		if d.FontFile != nil {
+			info, err := sfntglyphs.FromStream(d.FontFile)
+			if err == nil {
+				g.gidText = unicodeGlyphs(info.CMapTable)
+				symbolic := d.Descriptor != nil && d.Descriptor.IsSymbolic
+				g.gid = sfntglyphs.NewTrueTypeSelector(info, symbolic, d.Encoding)
+			}
+		}
+	case *dict.CIDFontType2:
+		if d.FontFile != nil {
+			info, err := sfntglyphs.FromStream(d.FontFile)
+			if err == nil {
+				g.gidText = unicodeGlyphs(info.CMapTable)
+				cidToGID := d.CIDToGID
+				g.gid = func(c cid.CID) (glyph.ID, bool) {
+					if cidToGID == nil {
+						return glyph.ID(c), true
+					}
+					if int(c) >= len(cidToGID) {
+						return 0, false
+					}
+					return cidToGID[c], true
+				}
+			}
+		}
+		g.cidText = collectionText(d.ROS, cidText)
+	case *dict.CIDFontType0:
+		g.cidText = collectionText(d.ROS, cidText)
+	}
+	return g
+}
+
+// text returns the text for a character code, or the empty string if no
+// text can be inferred.
+func (g *guesser) text(code []byte) string {
+	var c cid.CID
+	switch d := g.d.(type) {
+	case *dict.CIDFontType0:
+		c = d.CMap.LookupCID(code)
+	case *dict.CIDFontType2:
+		c = d.CMap.LookupCID(code)
+	default:
+		c = cid.CID(code[0]) + 1
+	}
+
+	if text := g.glyphText[c]; isValid(text) {
+		return text
+	}
+	if text := g.encodingText(code); isValid(text) {
+		return text
+	}
+	if g.gid != nil && g.gidText != nil {
+		if gid, ok := g.gid(c); ok && gid != 0 {
+			if r, ok := g.gidText[gid]; ok {
+				return string(r)
+			}
+		}
+	}
+	if text := g.cidText[c]; isValid(text) {
+		return text
+	}
+	return ""
+}
+
+// encodingText returns the text corresponding to the glyph name assigned
+// to a code by the encoding of a simple font.  For fonts which are not
+// embedded, the built-in encoding of the standard 14 fonts is used.
+func (g *guesser) encodingText(code []byte) string {
+	var enc encoding.Simple
+	var postScriptName string
+	var embedded bool
+	switch d := g.d.(type) {
+	case *dict.Type1:
+		enc, postScriptName, embedded = d.Encoding, d.PostScriptName, d.FontFile != nil
+	case *dict.TrueType:
+		enc, postScriptName, embedded = d.Encoding, d.PostScriptName, d.FontFile != nil
+	case *dict.Type3:
+		enc, embedded = d.Encoding, true
+	default:
+		return ""
+	}
+	if enc == nil {
+		return ""
+	}
+
+	name := enc(code[0])
+	if name == encoding.UseBuiltin && !embedded {
+		switch postScriptName {
+		case "Symbol":
+			name = pdfenc.Symbol.Encoding[code[0]]
+		case "ZapfDingbats":
+			name = pdfenc.ZapfDingbats.Encoding[code[0]]
+		default:
+			name = pdfenc.Standard.Encoding[code[0]]
+		}
+	}
+	if name == "" || name == encoding.UseBuiltin || name == ".notdef" {
+		return ""
+	}
+	return names.ToUnicode(name, postScriptName)
+}
+
+// unicodeGlyphs inverts the Unicode subtable of a TrueType cmap table.
+// Where several characters map to the same glyph, the smallest one is
+// used.
+func unicodeGlyphs(table sfntcmap.Table) map[glyph.ID]rune {
+	for _, key := range [][2]uint16{{3, 10}, {0, 4}, {3, 1}, {0, 3}} {
+		sub, err := table.GetNoLang(key[0], key[1])
+		if err != nil {
+			continue
+		}
+
+		res := map[glyph.ID]rune{}
+		low, high := sub.CodeRange()
+		for r := max(low, 0); r <= min(high, unicode.MaxRune); r++ {
+			if r >= 0xD800 && r < 0xE000 {
+				continue
+			}
+			gid := sub.Lookup(r)
+			if _, seen := res[gid]; gid != 0 && !seen {
+				res[gid] = r
+			}
+		}
+		return res
+	}
+	return nil
+}
+
+// collectionText returns a mapping from CIDs to text for one of the Adobe
+// character collections, or nil if the collection is not known.  The
+// mappings are cached in cache.
+func collectionText(ros *cid.SystemInfo, cache map[string]map[cid.CID]string) map[cid.CID]string {
+	if ros == nil || ros.Registry != "Adobe" {
+		return nil
+	}
+	name, ok := ucs2CMaps[ros.Ordering]
+	if !ok {
+		return nil
+	}
+	if res, ok := cache[name]; ok {
+		return res
+	}
+
+	var res map[cid.CID]string
+	if cm, err := cmap.Predefined(name); err == nil {
+		if codec, err := cm.Codec(); err == nil {
+			res = map[cid.CID]string{}
+			var buf []byte
+			for code, c := range cm.All(codec) {
+				buf = codec.AppendCode(buf[:0], code)
+				if len(buf) != 2 {
+					continue
+				}
+				u := utf16.Decode([]uint16{uint16(buf[0])<<8 | uint16(buf[1])})
+				if _, seen := res[c]; !seen && c != 0 {
+					res[c] = string(u)
+				}
+			}
+		}
+	}
+	cache[name] = res
+	return res
+}
+
+// isValid reports whether text is usable as the text of a character code.
+func isValid(text string) bool {
+	if text == "" || !utf8.ValidString(text) {
+		return false
+	}
+	for _, r := range text {
+		if r == utf8.RuneError || unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// isPrivateUse reports whether text contains characters from one of the
+// Unicode private use areas.
+func isPrivateUse(text string) bool {
+	for _, r := range text {
+		if unicode.Is(unicode.Co, r) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,266 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tounicode
+
+import (
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font/charcode"
+	"seehuhn.de/go/pdf/font/dict"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/extract"
+	"seehuhn.de/go/pdf/page"
+)
+
+// maxDepth limits the nesting of form XObjects.
+const maxDepth = 40
+
+// fontData describes a font which is used in the document.
+type fontData struct {
+	raw   pdf.Dict
+	dict  dict.Dict
+	codec *charcode.Codec
+
+	// used lists the character codes shown using the font.
+	used map[charcode.Code]bool
+}
+
+// collector finds the fonts used in a document, together with the
+// character codes shown using each font.
+type collector struct {
+	x *pdf.Extractor
+
+	fonts map[pdf.Reference]*fontData
+	bad   map[pdf.Reference]bool
+	forms map[pdf.Reference]bool
+}
+
+// page collects the codes used on a page, including annotation
+// appearance streams.
+func (c *collector) page(dict pdf.Dict) error {
+	cur := pdf.CursorAt(c.x, nil)
+
+	resources, err := cur.Dict(dict["Resources"])
+	if pdf.IsReadError(err) {
+		return err
+	}
+	if err := c.contents(dict["Contents"], resources, 0); err != nil {
+		return err
+	}
+
+	annots, err := cur.Array(dict["Annots"])
+	if pdf.IsReadError(err) {
+		return err
+	}
+	for _, obj := range annots {
+		annot, err := cur.Dict(obj)
+		if pdf.IsReadError(err) {
+			return err
+		}
+		ap, err := cur.Dict(annot["AP"])
+		if pdf.IsReadError(err) {
+			return err
+		}
+		for _, key := range []pdf.Name{"N", "R", "D"} {
+			val, err := cur.Resolve(ap[key])
+			if pdf.IsReadError(err) {
+				return err
+			}
+			switch val := val.(type) {
+			case *pdf.Stream:
+				err = c.xObject(ap[key], nil, 0)
+			case pdf.Dict:
+				for _, state := range val {
+					if err = c.xObject(state, nil, 0); err != nil {
+						break
+					}
+				}
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// xObject collects the codes used in a form XObject.  Other types of
+// XObjects are ignored.
+func (c *collector) xObject(obj pdf.Object, parentRes pdf.Dict, depth int) error {
+	ref, isRef := obj.(pdf.Reference)
+	if isRef {
+		if c.forms[ref] {
+			return nil
+		}
+		c.forms[ref] = true
+	}
+	if depth >= maxDepth {
+		return nil
+	}
+
+	cur := pdf.CursorAt(c.x, nil)
+	stm, err := cur.Stream(obj)
+	if pdf.IsReadError(err) {
+		return err
+	}
+	if stm == nil || stm.Dict["Subtype"] != pdf.Name("Form") {
+		return nil
+	}
+
+	resources := parentRes
+	if obj, ok := stm.Dict["Resources"]; ok {
+		resources, err = cur.Dict(obj)
+		if pdf.IsReadError(err) {
+			return err
+		}
+	}
+	return c.contents(stm, resources, depth+1)
+}
+
+// contents collects the codes used in a content stream.
+func (c *collector) contents(obj pdf.Object, resources pdf.Dict, depth int) error {
+	if obj == nil {
+		return nil
+	}
+	cur := pdf.CursorAt(c.x, nil)
+
+	resolved, err := cur.Resolve(obj)
+	if pdf.IsReadError(err) {
+		return err
+	}
+	segments, err := page.ExtractContents(cur, resolved)
+	if pdf.IsReadError(err) {
+		return err
+	}
+
+	fonts, err := cur.Dict(resources["Font"])
+	if pdf.IsReadError(err) {
+		return err
+	}
+	xObjects, err := cur.Dict(resources["XObject"])
+	if pdf.IsReadError(err) {
+		return err
+	}
+
+	var current *fontData
+	var stack []*fontData
+	it := (&page.Page{Contents: segments}).NewIter()
+	for name, args := range it.All() {
+		switch name {
+		case content.OpPushGraphicsState:
+			stack = append(stack, current)
+		case content.OpPopGraphicsState:
+			if n := len(stack); n > 0 {
+				current = stack[n-1]
+				stack = stack[:n-1]
+			}
+		case content.OpTextSetFont:
+			current = nil
+			if len(args) > 0 {
+				if key, ok := args[0].(pdf.Name); ok {
+					current, err = c.font(fonts[key])
+					if err != nil {
+						return err
+					}
+				}
+			}
+		case content.OpTextShow, content.OpTextShowArray,
+			content.OpTextShowMoveNextLine, content.OpTextShowMoveNextLineSetSpacing:
+			if current != nil {
+				current.addCodes(args)
+			}
+		case content.OpXObject:
+			if len(args) > 0 {
+				if key, ok := args[0].(pdf.Name); ok {
+					if err := c.xObject(xObjects[key], resources, depth); err != nil {
+						return err
+					}
+				}
+			}
+		}
+	}
+	if err := it.Err(); pdf.IsReadError(err) {
+		return err
+	}
+	return nil
+}
+
+// font returns the information for the font dictionary referenced by obj.
+// The result is nil if the font is not an indirect object, or if the font
+// dictionary cannot be read.
+func (c *collector) font(obj pdf.Object) (*fontData, error) {
+	ref, ok := obj.(pdf.Reference)
+	if !ok || c.bad[ref] {
+		return nil, nil
+	}
+	if f, ok := c.fonts[ref]; ok {
+		return f, nil
+	}
+
+	cur := pdf.CursorAt(c.x, nil)
+	raw, err := cur.Dict(ref)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	d, err := pdf.Decode(cur, ref, extract.Dict)
+	if pdf.IsReadError(err) {
+		return nil, err
+	} else if err != nil || d == nil {
+		c.bad[ref] = true
+		return nil, nil
+	}
+
+	f := &fontData{
+		raw:   raw,
+		dict:  d,
+		codec: d.Codec(),
+		used:  map[charcode.Code]bool{},
+	}
+	c.fonts[ref] = f
+	return f, nil
+}
+
+// addCodes records the character codes shown by a text-showing operator.
+func (f *fontData) addCodes(args []pdf.Object) {
+	var strs []pdf.String
+	switch len(args) {
+	case 1:
+		switch a := args[0].(type) {
+		case pdf.String:
+			strs = append(strs, a)
+		case pdf.Array:
+			for _, obj := range a {
+				if s, ok := obj.(pdf.String); ok {
+					strs = append(strs, s)
+				}
+			}
+		}
+	case 3:
+		if s, ok := args[2].(pdf.String); ok {
+			strs = append(strs, s)
+		}
+	}
+
+	for _, s := range strs {
+		for len(s) > 0 {
+			code, k, valid := f.codec.Decode(s)
+			if valid {
+				f.used[code] = true
+			}
+			s = s[k:]
+		}
+	}
+}
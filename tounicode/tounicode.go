@@ -0,0 +1,298 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tounicode
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"seehuhn.de/go/postscript/cid"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font/charcode"
+	"seehuhn.de/go/pdf/font/cmap"
+	"seehuhn.de/go/pdf/font/dict"
+	"seehuhn.de/go/pdf/font/subset"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// Options control how ToUnicode maps are repaired.
+type Options struct {
+	// Overrides (optional) specifies the text for character codes of
+	// individual fonts.  The outer map is indexed by font name: the
+	// PostScript name, with or without subset tag, or the /Name entry for
+	// Type 3 fonts.  The inner map is indexed by character code, given as
+	// a byte string.  Overrides take precedence over all other sources.
+	Overrides map[string]map[string]string
+
+	// IgnoreExisting, if set, discards the existing ToUnicode maps, so that
+	// the text for all codes is inferred from the fonts.
+	IgnoreExisting bool
+}
+
+// Report summarises the result of [Write].
+type Report struct {
+	// Fonts lists the fonts used in the document, ordered by their
+	// reference in the input file.
+	Fonts []FontReport
+}
+
+// FontReport describes the repair of a single font.
+type FontReport struct {
+	// Font is the reference of the font dictionary in the input file.
+	Font pdf.Reference
+
+	// Name is the PostScript name of the font, or the /Name entry for Type 3
+	// fonts.
+	Name string
+
+	// Changed is the number of character codes where the text was added,
+	// changed or removed.
+	Changed int
+
+	// Unmapped lists the character codes used in the document for which no
+	// text could be found.
+	Unmapped [][]byte
+}
+
+// Write reads the document from r and writes a copy to w, where the
+// ToUnicode maps of the fonts are repaired.  A nil *Options uses the
+// default options.
+func Write(w io.Writer, r pdf.Getter, opts *Options) (*Report, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	c := &collector{
+		x:     pdf.NewExtractor(r),
+		fonts: map[pdf.Reference]*fontData{},
+		bad:   map[pdf.Reference]bool{},
+		forms: map[pdf.Reference]bool{},
+	}
+	it := pagetree.NewIterator(r)
+	n := 0
+	for _, dict := range it.All() {
+		if err := c.page(dict); err != nil {
+			return nil, fmt.Errorf("page %d: %w", n+1, err)
+		}
+		n++
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	if n == 0 {
+		return nil, errors.New("document has no pages")
+	}
+
+	report := &Report{}
+	repaired := map[pdf.Reference]*cmap.ToUnicodeFile{}
+	cidText := map[string]map[cid.CID]string{}
+	for _, ref := range slices.Sorted(maps.Keys(c.fonts)) {
+		f := c.fonts[ref]
+		name := fontName(f.raw)
+		tu, fr, err := f.repair(opts, name, cidText)
+		if err != nil {
+			return nil, fmt.Errorf("font %q: %w", name, err)
+		}
+		fr.Font = ref
+		fr.Name = name
+		report.Fonts = append(report.Fonts, *fr)
+		if tu != nil {
+			repaired[ref] = tu
+		}
+	}
+
+	metaIn := r.GetMeta()
+	out, err := pdf.NewWriter(w, pdf.GetVersion(r), &pdf.WriterOptions{
+		DocumentMetadata: metaIn.Catalog.Metadata,
+	})
+	if err != nil {
+		return nil, err
+	}
+	rm := pdf.NewResourceManager(out)
+	copier := pdf.NewCopier(out, r)
+
+	// Fonts with a new ToUnicode map are written to new references, so that
+	// all references to the font pick up the new font dictionary.
+	newRefs := map[pdf.Reference]pdf.Reference{}
+	for ref := range repaired {
+		newRefs[ref] = out.Alloc()
+		copier.Redirect(ref, newRefs[ref])
+	}
+
+	// The catalog dictionary is copied directly, to preserve all entries.
+	// The metadata stream has already been written by pdf.NewWriter.
+	catDict, err := pdf.NewCursor(r).Dict(metaIn.Trailer["Root"])
+	if err != nil {
+		return nil, err
+	}
+	catDict = maps.Clone(catDict)
+	delete(catDict, "Metadata")
+	newCatDict, err := copier.CopyDict(catDict)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := pdf.Decode(pdf.NewCursor(out), newCatDict, pdf.DecodeCatalog)
+	if err != nil {
+		return nil, err
+	}
+	meta := out.GetMeta()
+	catalog.Metadata = meta.Catalog.Metadata
+	meta.Catalog = catalog
+	meta.Info = metaIn.Info
+
+	for _, ref := range slices.Sorted(maps.Keys(repaired)) {
+		fontDict := maps.Clone(c.fonts[ref].raw)
+		delete(fontDict, "ToUnicode")
+		fontDict, err = copier.CopyDict(fontDict)
+		if err != nil {
+			return nil, err
+		}
+		fontDict["ToUnicode"], err = rm.Embed(repaired[ref])
+		if err != nil {
+			return nil, err
+		}
+		if err := out.Put(newRefs[ref], fontDict); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := rm.Close(); err != nil {
+		return nil, err
+	}
+	if err := out.Close(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// repair computes the new ToUnicode map of a font.  The returned map is
+// nil if the existing map needs no change.
+func (f *fontData) repair(opts *Options, name string, cidText map[string]map[cid.CID]string) (*cmap.ToUnicodeFile, *FontReport, error) {
+	existing := map[charcode.Code]string{}
+	if tu := toUnicode(f.dict); tu != nil {
+		for code, text := range tu.All(f.codec) {
+			existing[code] = text
+		}
+	}
+
+	// Existing entries are kept, unless the code is used in the document
+	// and the entry is not usable.  For used codes, entries which map to the
+	// private use area are only kept as a last resort.
+	mapping := map[charcode.Code]string{}
+	fallback := map[charcode.Code]string{}
+	if !opts.IgnoreExisting {
+		for code, text := range existing {
+			switch {
+			case !f.used[code]:
+				mapping[code] = text
+			case !isValid(text):
+				// drop
+			case isPrivateUse(text):
+				fallback[code] = text
+			default:
+				mapping[code] = text
+			}
+		}
+	}
+
+	var g *guesser
+	var buf []byte
+	for _, code := range slices.Sorted(maps.Keys(f.used)) {
+		if _, ok := mapping[code]; ok {
+			continue
+		}
+		if g == nil {
+			g = newGuesser(f.dict, cidText)
+		}
+		buf = f.codec.AppendCode(buf[:0], code)
+		if text := g.text(buf); text != "" {
+			mapping[code] = text
+		} else if text, ok := fallback[code]; ok {
+			mapping[code] = text
+		}
+	}
+
+	_, base := subset.Split(name)
+	for _, key := range []string{base, name} {
+		for s, text := range opts.Overrides[key] {
+			code, k, valid := f.codec.Decode([]byte(s))
+			if !valid || k != len(s) {
+				return nil, nil, fmt.Errorf("invalid character code %x in overrides", s)
+			}
+			mapping[code] = text
+		}
+	}
+
+	fr := &FontReport{}
+	for code, text := range mapping {
+		if old, ok := existing[code]; !ok || old != text {
+			fr.Changed++
+		}
+	}
+	for code := range existing {
+		if _, ok := mapping[code]; !ok {
+			fr.Changed++
+		}
+	}
+	for _, code := range slices.Sorted(maps.Keys(f.used)) {
+		if _, ok := mapping[code]; !ok {
+			fr.Unmapped = append(fr.Unmapped, f.codec.AppendCode(nil, code))
+		}
+	}
+
+	if maps.Equal(mapping, existing) {
+		return nil, fr, nil
+	}
+	tu, err := cmap.NewToUnicodeFile(f.codec.CodeSpaceRange(), mapping)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tu, fr, nil
+}
+
+// toUnicode returns the ToUnicode map of a font, or nil if there is none.
+func toUnicode(d dict.Dict) *cmap.ToUnicodeFile {
+	switch d := d.(type) {
+	case *dict.Type1:
+		return d.ToUnicode
+	case *dict.TrueType:
+		return d.ToUnicode
+	case *dict.Type3:
+		return d.ToUnicode
+	case *dict.CIDFontType0:
+		return d.ToUnicode
+	case *dict.CIDFontType2:
+		return d.ToUnicode
+	}
+	return nil
+}
+
+// fontName returns the name used to identify a font in [Options] and
+// [Report].
+func fontName(raw pdf.Dict) string {
+	if name, ok := raw["BaseFont"].(pdf.Name); ok {
+		return string(name)
+	}
+	if name, ok := raw["Name"].(pdf.Name); ok {
+		return string(name)
+	}
+	return ""
+}
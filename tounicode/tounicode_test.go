@@ -0,0 +1,319 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package tounicode
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/document"
+	"seehuhn.de/go/pdf/font/charcode"
+	"seehuhn.de/go/pdf/font/cmap"
+	"seehuhn.de/go/pdf/font/gofont"
+	"seehuhn.de/go/pdf/graphics/extract"
+	"seehuhn.de/go/pdf/internal/debug/memfile"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// makeSource writes a one-page document which shows text using a font
+// with the given font dictionary.  The font dictionary is written as
+// returned by makeFont, which can use w to write additional objects.
+func makeSource(t *testing.T, text string, makeFont func(w *pdf.Writer) pdf.Dict) *pdf.Reader {
+	t.Helper()
+
+	buf := memfile.New()
+	w, err := pdf.NewWriter(buf, pdf.V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rm := pdf.NewResourceManager(w)
+
+	fontRef := w.Alloc()
+	if err := w.Put(fontRef, makeFont(w)); err != nil {
+		t.Fatal(err)
+	}
+
+	contentRef := w.Alloc()
+	stm, err := w.OpenStream(contentRef, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := "BT /F1 12 Tf 72 700 Td " + pdf.AsString(pdf.String(text)) + " Tj ET\n"
+	if _, err := stm.Write([]byte(body)); err != nil {
+		t.Fatal(err)
+	}
+	if err := stm.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	tree := pagetree.NewWriter(w, rm)
+	err = tree.AppendPageDict(w.Alloc(), pdf.Dict{
+		"Type":     pdf.Name("Page"),
+		"MediaBox": &pdf.Rectangle{URx: 595, URy: 842},
+		"Contents": contentRef,
+		"Resources": pdf.Dict{
+			"Font": pdf.Dict{"F1": fontRef},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.GetMeta().Catalog.Pages = pagesRef
+	if err := rm.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := pdf.NewReader(buf, int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+// repair runs Write and returns the ToUnicode map of the font on the
+// first page of the result, together with the report.
+func repair(t *testing.T, r pdf.Getter, opts *Options) (map[charcode.Code]string, *Report) {
+	t.Helper()
+
+	var out bytes.Buffer
+	report, err := Write(&out, r, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err := pdf.NewReader(bytes.NewReader(out.Bytes()), int64(out.Len()), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dict := range pagetree.NewIterator(rr).All() {
+		cur := pdf.NewCursor(rr)
+		res, err := cur.Dict(dict["Resources"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		fonts, err := cur.Dict(res["Font"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		d, err := pdf.Decode(cur, fonts["F1"], extract.Dict)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tu := toUnicode(d)
+		if tu == nil {
+			return nil, report
+		}
+		m, err := tu.GetMapping()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return m, report
+	}
+	t.Fatal("no pages")
+	return nil, nil
+}
+
+// makeHelvetica returns a font dictionary for a non-embedded Helvetica
+// font.  Code 1 is mapped to a glyph name without Unicode value, and the
+// ToUnicode map assigns a control character to "H".
+func makeHelvetica(w *pdf.Writer) pdf.Dict {
+	csr := charcode.Simple
+	tu, err := cmap.NewToUnicodeFile(csr, map[charcode.Code]string{
+		'H': "\x00",
+		'e': "e",
+	})
+	if err != nil {
+		panic(err)
+	}
+	rm := pdf.NewResourceManager(w)
+	tuRef, err := rm.Embed(tu)
+	if err != nil {
+		panic(err)
+	}
+	if err := rm.Close(); err != nil {
+		panic(err)
+	}
+
+	return pdf.Dict{
+		"Type":     pdf.Name("Font"),
+		"Subtype":  pdf.Name("Type1"),
+		"BaseFont": pdf.Name("Helvetica"),
+		"Encoding": pdf.Dict{
+			"BaseEncoding": pdf.Name("WinAnsiEncoding"),
+			"Differences":  pdf.Array{pdf.Integer(1), pdf.Name("g123")},
+		},
+		"ToUnicode": tuRef,
+	}
+}
+
+func TestSimple(t *testing.T) {
+	r := makeSource(t, "Hello\x01", makeHelvetica)
+
+	m, report := repair(t, r, nil)
+	want := map[charcode.Code]string{'H': "H", 'e': "e", 'l': "l", 'o': "o"}
+	for code, text := range want {
+		if m[code] != text {
+			t.Errorf("code %d: got %q, want %q", code, m[code], text)
+		}
+	}
+	if _, ok := m[1]; ok {
+		t.Errorf("unexpected mapping for code 1: %q", m[1])
+	}
+
+	if len(report.Fonts) != 1 {
+		t.Fatalf("expected 1 font, got %d", len(report.Fonts))
+	}
+	fr := report.Fonts[0]
+	if fr.Name != "Helvetica" || fr.Changed != 3 {
+		t.Errorf("unexpected report: %+v", fr)
+	}
+	if len(fr.Unmapped) != 1 || !bytes.Equal(fr.Unmapped[0], []byte{1}) {
+		t.Errorf("unexpected unmapped codes: %v", fr.Unmapped)
+	}
+}
+
+func TestOverrides(t *testing.T) {
+	r := makeSource(t, "Hello\x01", makeHelvetica)
+
+	opts := &Options{
+		Overrides: map[string]map[string]string{
+			"Helvetica": {"\x01": "✓", "o": "0"},
+		},
+	}
+	m, report := repair(t, r, opts)
+	if m[1] != "✓" || m['o'] != "0" || m['H'] != "H" {
+		t.Errorf("unexpected mapping: %q", m)
+	}
+	if len(report.Fonts[0].Unmapped) != 0 {
+		t.Errorf("unexpected unmapped codes: %v", report.Fonts[0].Unmapped)
+	}
+}
+
+// TestUnchanged checks that fonts with a complete ToUnicode map are
+// left alone.
+func TestUnchanged(t *testing.T) {
+	r := makeSource(t, "ee", makeHelvetica)
+
+	m, report := repair(t, r, nil)
+	if fr := report.Fonts[0]; fr.Changed != 0 || len(fr.Unmapped) != 0 {
+		t.Errorf("unexpected report: %+v", fr)
+	}
+	if len(m) != 2 || m['H'] != "\x00" {
+		t.Errorf("ToUnicode map was modified: %q", m)
+	}
+}
+
+// TestCollection checks that text for CID-keyed fonts is found using the
+// character collection.
+func TestCollection(t *testing.T) {
+	makeFont := func(w *pdf.Writer) pdf.Dict {
+		cidFont := pdf.Dict{
+			"Type":     pdf.Name("Font"),
+			"Subtype":  pdf.Name("CIDFontType0"),
+			"BaseFont": pdf.Name("KozMinPr6N-Regular"),
+			"CIDSystemInfo": pdf.Dict{
+				"Registry":   pdf.String("Adobe"),
+				"Ordering":   pdf.String("Japan1"),
+				"Supplement": pdf.Integer(6),
+			},
+			"FontDescriptor": pdf.Dict{
+				"Type":        pdf.Name("FontDescriptor"),
+				"FontName":    pdf.Name("KozMinPr6N-Regular"),
+				"Flags":       pdf.Integer(4),
+				"FontBBox":    &pdf.Rectangle{LLx: -437, LLy: -340, URx: 1147, URy: 1317},
+				"ItalicAngle": pdf.Integer(0),
+				"Ascent":      pdf.Integer(880),
+				"Descent":     pdf.Integer(-120),
+				"CapHeight":   pdf.Integer(742),
+				"StemV":       pdf.Integer(80),
+			},
+		}
+		return pdf.Dict{
+			"Type":            pdf.Name("Font"),
+			"Subtype":         pdf.Name("Type0"),
+			"BaseFont":        pdf.Name("KozMinPr6N-Regular-Identity-H"),
+			"Encoding":        pdf.Name("Identity-H"),
+			"DescendantFonts": pdf.Array{cidFont},
+		}
+	}
+	// CID 34 is "A", CID 843 is HIRAGANA LETTER A
+	r := makeSource(t, "\x00\x22\x03\x4b", makeFont)
+
+	// For multi-byte codes, the first byte is the least significant byte
+	// of the charcode.Code.
+	m, report := repair(t, r, nil)
+	if m[0x2200] != "A" || m[0x4b03] != "あ" {
+		t.Errorf("unexpected mapping: %q", m)
+	}
+	if fr := report.Fonts[0]; fr.Changed != 2 || len(fr.Unmapped) != 0 {
+		t.Errorf("unexpected report: %+v", fr)
+	}
+}
+
+// TestEmbedded checks that text for embedded TrueType fonts is recovered
+// from the font program.
+func TestEmbedded(t *testing.T) {
+	F, err := gofont.Regular.NewSimple(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf := memfile.New()
+	doc, err := document.WriteMultiPage(buf, document.A4, pdf.V1_7, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := doc.AddPage()
+	p.TextBegin()
+	p.TextSetFont(F, 12)
+	p.TextFirstLine(72, 700)
+	p.TextShow("Hi, ∫")
+	p.TextEnd()
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := pdf.NewReader(buf, int64(len(buf.Data)), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, report := repair(t, r, &Options{IgnoreExisting: true})
+	var text []string
+	for _, v := range m {
+		text = append(text, v)
+	}
+	slices.Sort(text)
+	if got := strings.Join(text, ""); got != " ,Hi∫" {
+		t.Errorf("unexpected text %q", got)
+	}
+	if fr := report.Fonts[0]; len(fr.Unmapped) != 0 {
+		t.Errorf("unexpected unmapped codes: %v", fr.Unmapped)
+	}
+}
@@ -1182,6 +1182,17 @@ func (x *Placeholder) Set(val Native) error {
 	return nil
 }
 
+// Offsets returns the byte offsets in the output file at which space for the
+// placeholder has been reserved and not yet been filled in.  Each reserved
+// space has the size given to [NewPlaceholder].
+//
+// This allows callers to fill in values which cannot be written using
+// [Placeholder.Set], for example the hexadecimal Contents string of a
+// signature dictionary, which must have a fixed width.
+func (x *Placeholder) Offsets() []int64 {
+	return slices.Clone(x.pos)
+}
+
 // AsString formats a PDF object as a string, in the same way as the
 // it would be written to a PDF file.
 func AsString(obj Object) string {
@@ -109,7 +109,10 @@ func (w *Walker) walk(yield func([]pdf.Object, pdf.Native) bool, preOrder bool)
 		return
 	}
 	d.path = append(d.path[:0], pdf.Name("info"))
-	d.walkObject(infoDict)
+	if !d.walkObject(infoDict) {
+		w.Err = d.err
+		return
+	}
 
 	catalogDict, err := pdf.NewCursor(w).Dict(meta.Trailer["Root"])
 	if err != nil {
@@ -117,7 +120,10 @@ func (w *Walker) walk(yield func([]pdf.Object, pdf.Native) bool, preOrder bool)
 		return
 	}
 	d.path = append(d.path[:0], pdf.Name("catalog"))
-	d.walkObject(catalogDict)
+	if !d.walkObject(catalogDict) {
+		w.Err = d.err
+		return
+	}
 
 	d.path = append(d.path[:0], pdf.Name("trailer"))
 	d.walkObject(meta.Trailer)
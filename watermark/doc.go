@@ -0,0 +1,37 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package watermark places a text, an image or a page of another PDF file
+// onto the pages of an existing PDF document, for example a diagonal
+// "CONFIDENTIAL" across every page or a logo in a corner.
+//
+// The watermark is written once, as a form XObject, and is then drawn on
+// every page.  Depending on [Options.Placement], it is drawn behind or in
+// front of the existing page content, or it is shown by a watermark
+// annotation with a fixed print dictionary.  Watermarks in the page
+// content are enclosed in an /Artifact marked-content sequence with
+// subtype /Watermark, so that text extraction tools can ignore them.
+//
+// Position, rotation and size are given relative to the page as it is
+// displayed: the watermark is placed inside the visible region given by
+// the crop box, and the /Rotate entry of each page is compensated for.
+// Since the size is by default relative to the page size, pages of
+// different sizes each get a suitably scaled watermark.
+//
+// If [Options.Layer] is set, the watermark is placed in an optional
+// content group of that name, so that it can be shown or hidden in a
+// viewer.
+package watermark
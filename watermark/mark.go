@@ -0,0 +1,433 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package watermark
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"seehuhn.de/go/geom/matrix"
+	"seehuhn.de/go/geom/vec"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/annotation"
+	"seehuhn.de/go/pdf/annotation/appearance"
+	"seehuhn.de/go/pdf/font/standard"
+	"seehuhn.de/go/pdf/graphics"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/content"
+	"seehuhn.de/go/pdf/graphics/content/builder"
+	"seehuhn.de/go/pdf/graphics/extgstate"
+	"seehuhn.de/go/pdf/graphics/form"
+	"seehuhn.de/go/pdf/graphics/group"
+	"seehuhn.de/go/pdf/oc"
+	"seehuhn.de/go/pdf/page"
+	"seehuhn.de/go/pdf/pagetree"
+	"seehuhn.de/go/pdf/property"
+)
+
+// nominalFontSize is the font size used for text watermarks which are
+// scaled to fit the page.
+const nominalFontSize = 100
+
+// coordDigits is the number of decimal digits kept for coordinates.
+const coordDigits = 4
+
+// stamper holds the state shared while adding a watermark to one document.
+type stamper struct {
+	x       *pdf.Extractor
+	out     *pdf.Writer
+	rm      *pdf.ResourceManager
+	copy    *pdf.Copier
+	opts    *Options
+	version pdf.Version
+
+	// group is the optional content group of the watermark, or nil.
+	group *oc.Group
+
+	// markForm draws the watermark.  The form is embedded once, and mark is
+	// the resulting reference.
+	markForm *form.Form
+	mark     pdf.Reference
+
+	// box is the bounding box of the watermark, in the coordinates of
+	// markForm.
+	box pdf.Rectangle
+
+	// fixedScale is the scale factor for the watermark, if Options.Size is
+	// set.
+	fixedScale float64
+
+	// open is the shared content stream which saves the graphics state
+	// before the original page content.
+	open pdf.Reference
+}
+
+// makeMark creates the form XObject which draws the watermark.
+//
+// The watermark itself is drawn by an inner form, which is a transparency
+// group if the watermark is transparent.  This way, overlapping parts of
+// the watermark do not show through each other.  The outer form sets the
+// transparency and adds the marked-content sequences.
+func (s *stamper) makeMark() error {
+	o := s.opts
+
+	b := builder.New(content.Form, nil, s.version)
+	switch g := o.Graphic.(type) {
+	case nil:
+		F := o.Font
+		if F == nil {
+			var err error
+			F, err = standard.HelveticaBold.New()
+			if err != nil {
+				return err
+			}
+		}
+		col := o.Color
+		if col == nil {
+			col = color.DeviceGray(defaultGray)
+		}
+		size := float64(nominalFontSize)
+		s.fixedScale = 0
+		if o.Size > 0 {
+			size = o.Size
+			s.fixedScale = 1
+		}
+		geom := F.GetGeometry()
+		width := F.Layout(nil, size, o.Text).TotalWidth()
+		s.box = pdf.Rectangle{
+			LLx: 0,
+			LLy: geom.Descent * size,
+			URx: width,
+			URy: geom.Ascent * size,
+		}
+		b.SetFillColor(col)
+		b.TextBegin()
+		b.TextSetFont(F, size)
+		b.TextShow(o.Text)
+		b.TextEnd()
+
+	case graphics.Image:
+		bounds := g.Bounds()
+		if bounds.Dx() <= 0 || bounds.Dy() <= 0 {
+			return errors.New("empty watermark image")
+		}
+		aspect := float64(bounds.Dx()) / float64(bounds.Dy())
+		s.box = pdf.Rectangle{URx: aspect, URy: 1}
+		if o.Size > 0 {
+			s.fixedScale = o.Size / aspect
+		}
+		b.Transform(matrix.Scale(aspect, 1))
+		b.DrawXObject(g)
+
+	case *form.Form:
+		m := g.Matrix
+		if m == matrix.Zero {
+			m = matrix.Identity
+		}
+		s.box = transformRect(m, g.BBox)
+		if s.box.Dx() <= 0 || s.box.Dy() <= 0 {
+			return errors.New("empty watermark form")
+		}
+		if o.Size > 0 {
+			s.fixedScale = o.Size / s.box.Dx()
+		}
+		b.DrawXObject(g)
+
+	default:
+		return fmt.Errorf("unsupported watermark graphic %T", o.Graphic)
+	}
+	ops, err := b.Harvest()
+	if err != nil {
+		return err
+	}
+	inner := &form.Form{
+		Content: ops,
+		Res:     b.Resources,
+		BBox:    s.box,
+	}
+	if o.Transparency > 0 {
+		inner.Group = &group.TransparencyAttributes{}
+	}
+
+	b = builder.New(content.Form, nil, s.version)
+	inContent := o.Placement != AsAnnotation
+	if inContent {
+		b.MarkedContentStart(&graphics.MarkedContent{
+			Tag: "Artifact",
+			Properties: &property.Artifact{
+				Type:      "Pagination",
+				Subtype:   "Watermark",
+				SingleUse: true,
+			},
+			Inline: true,
+		})
+		if s.group != nil {
+			b.MarkedContentStart(&graphics.MarkedContent{
+				Tag:        "OC",
+				Properties: s.group,
+			})
+		}
+	}
+	if o.Transparency > 0 {
+		alpha := pdf.Round(1-o.Transparency, 3)
+		b.SetExtGState(&extgstate.ExtGState{
+			Set:         graphics.StateStrokeAlpha | graphics.StateFillAlpha,
+			StrokeAlpha: alpha,
+			FillAlpha:   alpha,
+			SingleUse:   true,
+		})
+	}
+	b.DrawXObject(inner)
+	if inContent {
+		if s.group != nil {
+			b.MarkedContentEnd()
+		}
+		b.MarkedContentEnd()
+	}
+	ops, err = b.Harvest()
+	if err != nil {
+		return err
+	}
+	s.markForm = &form.Form{
+		Content: ops,
+		Res:     b.Resources,
+		BBox:    s.box,
+	}
+
+	obj, err := s.rm.Embed(s.markForm)
+	if err != nil {
+		return err
+	}
+	ref, ok := obj.(pdf.Reference)
+	if !ok {
+		return fmt.Errorf("unexpected form object %T", obj)
+	}
+	s.mark = ref
+	return nil
+}
+
+// frame describes the visible region of a page, as it is displayed.
+type frame struct {
+	// width and height are the dimensions of the displayed page.
+	width, height float64
+
+	// toUser maps display coordinates, with the origin in the bottom left
+	// corner of the displayed page, to default user space.
+	toUser matrix.Matrix
+}
+
+// pageFrame determines the visible region of a page from the crop box and
+// the page rotation.
+func (s *stamper) pageFrame(dict pdf.Dict) (*frame, error) {
+	cur := pdf.CursorAt(s.x, nil)
+	media, err := cur.Rectangle(dict["MediaBox"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	} else if err != nil || media == nil || media.IsZero() {
+		return nil, errors.New("missing or invalid MediaBox")
+	}
+	box := *media
+	crop, err := cur.Rectangle(dict["CropBox"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	} else if err == nil && crop != nil {
+		if clipped := crop.Intersect(media); clipped != nil && !clipped.IsZero() {
+			box = *clipped
+		}
+	}
+
+	var rotate page.Rotation
+	if deg, err := cur.Integer(dict["Rotate"]); err == nil {
+		rotate = page.RotationFromDegrees(int(deg))
+	} else if pdf.IsReadError(err) {
+		return nil, err
+	}
+	return newFrame(box, rotate), nil
+}
+
+func newFrame(box pdf.Rectangle, rotate page.Rotation) *frame {
+	w, h := box.Dx(), box.Dy()
+	f := &frame{width: w, height: h}
+	switch rotate {
+	case page.Rotate90:
+		f.width, f.height = h, w
+		f.toUser = matrix.Matrix{0, 1, -1, 0, box.URx, box.LLy}
+	case page.Rotate180:
+		f.toUser = matrix.Matrix{-1, 0, 0, -1, box.URx, box.URy}
+	case page.Rotate270:
+		f.width, f.height = h, w
+		f.toUser = matrix.Matrix{0, -1, 1, 0, box.LLx, box.URy}
+	default:
+		f.toUser = matrix.Translate(box.LLx, box.LLy)
+	}
+	return f
+}
+
+// placement returns the transformation which maps the watermark's form
+// coordinates to the display coordinates of a page.
+func (s *stamper) placement(f *frame) matrix.Matrix {
+	o := s.opts
+
+	angle := o.Angle
+	if o.Diagonal {
+		angle = math.Atan2(f.height, f.width) * 180 / math.Pi
+	}
+	rot := matrix.RotateDeg(angle)
+	bb := transformRect(rot, s.box)
+
+	scale := s.fixedScale
+	if scale == 0 {
+		scale = o.Scale * min(f.width/bb.Dx(), f.height/bb.Dy())
+	}
+
+	ax, ay := o.Position.anchor()
+	m := o.Margin
+	if o.Position == Center {
+		m = 0
+	}
+	px := m + ax*(f.width-2*m)
+	py := m + ay*(f.height-2*m)
+	tx := px - scale*(bb.LLx+ax*bb.Dx())
+	ty := py - scale*(bb.LLy+ay*bb.Dy())
+
+	return rot.Mul(matrix.Scale(scale, scale)).Mul(matrix.Translate(tx, ty))
+}
+
+// annotation creates a watermark annotation for a page, and returns its
+// reference.
+//
+// The appearance stream uses display coordinates, and its matrix compensates
+// for the page rotation.  The fixed print dictionary anchors the annotation
+// at the same relative position on the target media.
+func (s *stamper) annotation(pageRef pdf.Reference, f *frame, place matrix.Matrix) (pdf.Reference, error) {
+	o := s.opts
+
+	b := builder.New(content.Form, nil, s.version)
+	b.Transform(roundMatrix(place))
+	b.DrawXObject(s.markForm)
+	ops, err := b.Harvest()
+	if err != nil {
+		return 0, err
+	}
+	dispBox := transformRect(place, s.box)
+	dispBox.IRound(coordDigits)
+	rot := f.toUser
+	rot[4], rot[5] = 0, 0
+	ap := &form.Form{
+		Content: ops,
+		Res:     b.Resources,
+		BBox:    dispBox,
+		Matrix:  rot,
+	}
+
+	rect := transformRect(f.toUser, dispBox)
+	ax, ay := o.Position.anchor()
+	m := o.Margin
+	if o.Position == Center {
+		m = 0
+	}
+	dx := -ax*rect.Dx() + m*(1-2*ax)
+	dy := -ay*rect.Dy() + m*(1-2*ay)
+
+	a := &annotation.Watermark{
+		Common: annotation.Common{
+			Rect:       rect,
+			Appearance: &appearance.Dict{Normal: ap},
+			Flags:      annotation.FlagPrint | annotation.FlagReadOnly,
+			Page:       pageRef,
+		},
+		FixedPrint: &annotation.FixedPrint{
+			Matrix:    roundMatrix(matrix.Translate(dx, dy)),
+			H:         ax,
+			V:         ay,
+			SingleUse: true,
+		},
+	}
+	if s.group != nil {
+		a.OptionalContent = s.group
+	}
+	return s.rm.Store(a)
+}
+
+// FromPage returns a form XObject which draws the given page of a PDF
+// file, for use as [Options.Graphic].  The page number is zero-based.  The
+// form shows the visible region of the page, as given by the crop box, in
+// the orientation in which the page is displayed.
+func FromPage(r pdf.Getter, pageNo int) (*form.Form, error) {
+	_, dict, err := pagetree.GetPage(r, pageNo)
+	if err != nil {
+		return nil, err
+	}
+	pg, err := pdf.Decode(pdf.NewCursor(r), dict, page.Decode)
+	if err != nil {
+		return nil, err
+	}
+	if pg.MediaBox == nil {
+		return nil, errors.New("missing MediaBox")
+	}
+	box := *pg.MediaBox
+	if pg.CropBox != nil {
+		if clipped := pg.CropBox.Intersect(pg.MediaBox); clipped != nil && !clipped.IsZero() {
+			box = *clipped
+		}
+	}
+
+	f := newFrame(box, pg.Rotate)
+	toDisplay, ok := f.toUser.Inv()
+	if !ok {
+		return nil, errors.New("invalid page geometry")
+	}
+	return &form.Form{
+		Content: pg,
+		Res:     pg.Resources,
+		BBox:    box,
+		Matrix:  toDisplay,
+	}, nil
+}
+
+// transformRect returns the bounding box of the image of a rectangle under
+// a transformation.
+func transformRect(m matrix.Matrix, r pdf.Rectangle) pdf.Rectangle {
+	var res pdf.Rectangle
+	for i, p := range []vec.Vec2{
+		{X: r.LLx, Y: r.LLy},
+		{X: r.URx, Y: r.LLy},
+		{X: r.URx, Y: r.URy},
+		{X: r.LLx, Y: r.URy},
+	} {
+		q := m.Apply(p)
+		if i == 0 {
+			res = pdf.Rectangle{LLx: q.X, LLy: q.Y, URx: q.X, URy: q.Y}
+		} else {
+			res.ExtendVec(q)
+		}
+	}
+	return res
+}
+
+func roundMatrix(m matrix.Matrix) matrix.Matrix {
+	for i := range m {
+		m[i] = pdf.Round(m[i], coordDigits)
+	}
+	return m
+}
+
+func coord(x float64) string {
+	return pdf.AsString(pdf.Number(pdf.Round(x, coordDigits)))
+}
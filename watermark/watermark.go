@@ -0,0 +1,513 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package watermark
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/font"
+	"seehuhn.de/go/pdf/graphics"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/internal/rewrite"
+	"seehuhn.de/go/pdf/oc"
+	"seehuhn.de/go/pdf/pagetree"
+)
+
+// Placement selects how the watermark is added to a page.
+type Placement int
+
+const (
+	// Behind draws the watermark in the page content, before the existing
+	// content.
+	Behind Placement = iota
+
+	// InFront draws the watermark in the page content, after the existing
+	// content.
+	InFront
+
+	// AsAnnotation adds a watermark annotation with a fixed print
+	// dictionary to the page.
+	AsAnnotation
+)
+
+// Position gives the place of the watermark on the page.
+type Position int
+
+// These are the possible positions of a watermark.
+const (
+	Center Position = iota
+	Top
+	Bottom
+	Left
+	Right
+	TopLeft
+	TopRight
+	BottomLeft
+	BottomRight
+)
+
+// anchor returns the point of the watermark's bounding box which is aligned
+// with the corresponding point of the page, as fractions of the width and
+// height.
+func (p Position) anchor() (float64, float64) {
+	switch p {
+	case Top:
+		return 0.5, 1
+	case Bottom:
+		return 0.5, 0
+	case Left:
+		return 0, 0.5
+	case Right:
+		return 1, 0.5
+	case TopLeft:
+		return 0, 1
+	case TopRight:
+		return 1, 1
+	case BottomLeft:
+		return 0, 0
+	case BottomRight:
+		return 1, 0
+	default:
+		return 0.5, 0.5
+	}
+}
+
+// Default values for the [Options] fields.
+const (
+	defaultScale = 0.8
+	defaultGray  = 0.5
+)
+
+// Options describe the watermark and how it is placed on the pages.
+type Options struct {
+	// Text is the text of the watermark.  Exactly one of Text and Graphic
+	// must be set.
+	Text string
+
+	// Font is the font used for Text.  If this is nil, Helvetica-Bold is
+	// used.
+	Font font.Layouter
+
+	// Color is the colour used for Text.  If this is nil, a medium grey is
+	// used.
+	Color color.Color
+
+	// Graphic is an image or a form XObject to use as the watermark.  The
+	// function [FromPage] can be used to turn a page of a PDF file into a
+	// form XObject.
+	Graphic graphics.XObject
+
+	// Placement selects whether the watermark is drawn behind or in front
+	// of the page content, or as an annotation.
+	Placement Placement
+
+	// Position is the place of the watermark on the displayed page.
+	Position Position
+
+	// Angle is the rotation of the watermark, in degrees counterclockwise.
+	Angle float64
+
+	// Diagonal, if set, rotates the watermark to run along the diagonal
+	// from the bottom left to the top right corner of each page.  Angle is
+	// ignored in this case.
+	Diagonal bool
+
+	// Size, if non-zero, gives the size of the watermark in PDF units: the
+	// font size for a text watermark, or the width for a graphic.
+	// Otherwise, the watermark is scaled to fit the page, see Scale.
+	Size float64
+
+	// Scale is the fraction of the width and height of the page which the
+	// rotated watermark may cover, if Size is zero.  If this is zero, 0.8
+	// is used.
+	Scale float64
+
+	// Margin is the distance between the watermark and the page edge, for
+	// positions other than Center.
+	Margin float64
+
+	// Transparency is the transparency of the watermark, from 0 (opaque)
+	// to 1 (invisible).
+	Transparency float64
+
+	// Layer, if non-empty, is the name of an optional content group which
+	// contains the watermark.
+	Layer string
+
+	// Pages (optional) lists the zero-based page numbers to add the
+	// watermark to.  If this is empty, all pages are used.
+	Pages []int
+}
+
+// Write reads the document from r, adds the watermark to the selected
+// pages, and writes the result to w.
+func Write(w io.Writer, r pdf.Getter, opts *Options) error {
+	o := Options{}
+	if opts != nil {
+		o = *opts
+	}
+	if (o.Text == "") == (o.Graphic == nil) {
+		return errors.New("exactly one of Text and Graphic must be set")
+	}
+	if o.Placement < Behind || o.Placement > AsAnnotation {
+		return fmt.Errorf("invalid placement %d", o.Placement)
+	}
+	if o.Position < Center || o.Position > BottomRight {
+		return fmt.Errorf("invalid position %d", o.Position)
+	}
+	if o.Size < 0 || o.Scale < 0 || o.Margin < 0 {
+		return errors.New("negative watermark dimensions")
+	}
+	if o.Transparency < 0 || o.Transparency > 1 {
+		return errors.New("transparency must be between 0 and 1")
+	}
+	if o.Scale == 0 {
+		o.Scale = defaultScale
+	}
+
+	refs, dicts, err := rewrite.ReadPages(r)
+	if err != nil {
+		return err
+	}
+
+	for _, pageNo := range o.Pages {
+		if pageNo < 0 || pageNo >= len(dicts) {
+			return fmt.Errorf("page %d out of range", pageNo+1)
+		}
+	}
+
+	// extended graphics states with transparency need PDF 1.4, watermark
+	// annotations need PDF 1.6, and optional content needs PDF 1.5
+	minVersion := pdf.V1_4
+	if o.Placement == AsAnnotation {
+		minVersion = pdf.V1_6
+	} else if o.Layer != "" {
+		minVersion = pdf.V1_5
+	}
+	version := max(pdf.GetVersion(r), minVersion)
+	out, err := rewrite.NewWriter(w, r, version)
+	if err != nil {
+		return err
+	}
+	rm := pdf.NewResourceManager(out)
+	s := &stamper{
+		x:       pdf.NewExtractor(r),
+		out:     out,
+		rm:      rm,
+		copy:    pdf.NewCopier(out, r),
+		opts:    &o,
+		version: version,
+	}
+	if o.Layer != "" {
+		s.group = &oc.Group{Name: o.Layer}
+	}
+	if err := s.makeMark(); err != nil {
+		return err
+	}
+
+	newRefs := rewrite.RedirectPages(out, s.copy, refs)
+
+	tree := pagetree.NewWriter(out, rm)
+	for i, dict := range dicts {
+		var newDict pdf.Dict
+		if len(o.Pages) == 0 || slices.Contains(o.Pages, i) {
+			newDict, err = s.markPage(newRefs[i], dict)
+		} else {
+			newDict, err = s.copy.CopyDict(dict)
+		}
+		if err != nil {
+			return fmt.Errorf("page %d: %w", i+1, err)
+		}
+		if err := tree.AppendPageDict(newRefs[i], newDict); err != nil {
+			return err
+		}
+	}
+	pagesRef, err := tree.Close()
+	if err != nil {
+		return err
+	}
+
+	metaIn := r.GetMeta()
+	meta := out.GetMeta()
+	meta.Info = metaIn.Info
+	err = rewrite.CopyCatalog(out, s.copy, metaIn.Catalog, "OCProperties")
+	if err != nil {
+		return err
+	}
+	meta.Catalog.OCProperties, err = s.ocProperties(metaIn.Catalog.OCProperties)
+	if err != nil {
+		return err
+	}
+	meta.Catalog.Pages = pagesRef
+
+	if err := rm.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// markPage copies a page dictionary and adds the watermark.
+func (s *stamper) markPage(ref pdf.Reference, src pdf.Dict) (pdf.Dict, error) {
+	f, err := s.pageFrame(src)
+	if err != nil {
+		return nil, err
+	}
+	place := s.placement(f)
+
+	src = maps.Clone(src)
+	if s.opts.Placement == AsAnnotation {
+		annotsIn := src["Annots"]
+		delete(src, "Annots")
+		dict, err := s.copy.CopyDict(src)
+		if err != nil {
+			return nil, err
+		}
+
+		var annots pdf.Array
+		if a, err := pdf.CursorAt(s.x, nil).Array(annotsIn); err == nil && a != nil {
+			annots, err = s.copy.CopyArray(a)
+			if err != nil {
+				return nil, err
+			}
+		} else if pdf.IsReadError(err) {
+			return nil, err
+		}
+		annotRef, err := s.annotation(ref, f, place)
+		if err != nil {
+			return nil, err
+		}
+		dict["Annots"] = append(annots, annotRef)
+		return dict, nil
+	}
+
+	contentsIn := src["Contents"]
+	resourcesIn := src["Resources"]
+	delete(src, "Contents")
+	delete(src, "Resources")
+	dict, err := s.copy.CopyDict(src)
+	if err != nil {
+		return nil, err
+	}
+
+	res, name, err := s.resources(resourcesIn)
+	if err != nil {
+		return nil, err
+	}
+	dict["Resources"] = res
+
+	contents, err := s.contents(contentsIn, name, place.Mul(f.toUser))
+	if err != nil {
+		return nil, err
+	}
+	dict["Contents"] = contents
+
+	return dict, nil
+}
+
+// resources copies the resource dictionary of a page and adds the
+// watermark form XObject.  The name of the form is returned.
+func (s *stamper) resources(obj pdf.Object) (pdf.Dict, pdf.Name, error) {
+	cur := pdf.CursorAt(s.x, nil)
+	resIn, err := cur.Dict(obj)
+	if pdf.IsReadError(err) {
+		return nil, "", err
+	}
+	resIn = maps.Clone(resIn)
+	xobjIn, err := cur.Dict(resIn["XObject"])
+	if pdf.IsReadError(err) {
+		return nil, "", err
+	}
+	delete(resIn, "XObject")
+
+	res, err := s.copy.CopyDict(resIn)
+	if err != nil {
+		return nil, "", err
+	}
+	if res == nil {
+		res = pdf.Dict{}
+	}
+	xobj, err := s.copy.CopyDict(xobjIn)
+	if err != nil {
+		return nil, "", err
+	}
+	if xobj == nil {
+		xobj = pdf.Dict{}
+	}
+
+	var name pdf.Name
+	for k := 1; ; k++ {
+		name = pdf.Name(fmt.Sprintf("Wm%d", k))
+		if _, used := xobj[name]; !used {
+			break
+		}
+	}
+	xobj[name] = s.mark
+	res["XObject"] = xobj
+	return res, name, nil
+}
+
+// contents returns the new /Contents entry of a page.  A content stream
+// which draws the named form XObject, transformed by m, is added before or
+// after the original content streams.  When drawing in front, the original
+// content is enclosed in q/Q, so that changes to the graphics state cannot
+// affect the watermark.
+func (s *stamper) contents(obj pdf.Object, name pdf.Name, m [6]float64) (pdf.Array, error) {
+	cur := pdf.CursorAt(s.x, nil)
+	var streams pdf.Array
+	resolved, err := cur.Resolve(obj)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	switch x := resolved.(type) {
+	case pdf.Array:
+		streams = x
+	case *pdf.Stream:
+		streams = pdf.Array{obj}
+	}
+	var orig pdf.Array
+	for _, stm := range streams {
+		ref, ok := stm.(pdf.Reference)
+		if !ok {
+			continue
+		}
+		copied, err := s.copy.CopyReference(ref)
+		if err != nil {
+			return nil, err
+		}
+		orig = append(orig, copied)
+	}
+
+	body := fmt.Sprintf("q %s %s %s %s %s %s cm /%s Do Q\n",
+		coord(m[0]), coord(m[1]), coord(m[2]), coord(m[3]), coord(m[4]), coord(m[5]), name)
+
+	if s.opts.Placement == Behind || len(orig) == 0 {
+		ref, err := s.writeStream(body)
+		if err != nil {
+			return nil, err
+		}
+		return append(pdf.Array{ref}, orig...), nil
+	}
+
+	if s.open == 0 {
+		s.open, err = s.writeStream("q\n")
+		if err != nil {
+			return nil, err
+		}
+	}
+	ref, err := s.writeStream("Q\n" + body)
+	if err != nil {
+		return nil, err
+	}
+	res := append(pdf.Array{s.open}, orig...)
+	return append(res, ref), nil
+}
+
+// writeStream writes a content stream with the given body.
+func (s *stamper) writeStream(body string) (pdf.Reference, error) {
+	ref := s.out.Alloc()
+	stm, err := s.out.OpenStream(ref, nil)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := stm.Write([]byte(body)); err != nil {
+		return 0, err
+	}
+	if err := stm.Close(); err != nil {
+		return 0, err
+	}
+	return ref, nil
+}
+
+// ocProperties returns the optional content properties of the output
+// document.  If the watermark is in an optional content group, the group is
+// added to the existing properties, or new properties are created.
+func (s *stamper) ocProperties(obj pdf.Object) (pdf.Object, error) {
+	cur := pdf.CursorAt(s.x, nil)
+	dictIn, err := cur.Dict(obj)
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+
+	if s.group == nil {
+		if dictIn == nil {
+			return nil, nil
+		}
+		return s.copy.CopyDict(dictIn)
+	}
+
+	if dictIn == nil {
+		props := &oc.Properties{
+			OCGs: []*oc.Group{s.group},
+			D:    &oc.Configuration{Order: []oc.OrderItem{s.group}},
+		}
+		return s.rm.Embed(props)
+	}
+
+	groupRef, err := s.rm.Embed(s.group)
+	if err != nil {
+		return nil, err
+	}
+
+	dictIn = maps.Clone(dictIn)
+	ocgsIn, err := cur.Array(dictIn["OCGs"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	dIn, err := cur.Dict(dictIn["D"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	dIn = maps.Clone(dIn)
+	orderIn, err := cur.Array(dIn["Order"])
+	if pdf.IsReadError(err) {
+		return nil, err
+	}
+	delete(dictIn, "OCGs")
+	delete(dictIn, "D")
+	delete(dIn, "Order")
+
+	dict, err := s.copy.CopyDict(dictIn)
+	if err != nil {
+		return nil, err
+	}
+	ocgs, err := s.copy.CopyArray(ocgsIn)
+	if err != nil {
+		return nil, err
+	}
+	dict["OCGs"] = append(ocgs, groupRef)
+
+	d, err := s.copy.CopyDict(dIn)
+	if err != nil {
+		return nil, err
+	}
+	if d == nil {
+		d = pdf.Dict{}
+	}
+	order, err := s.copy.CopyArray(orderIn)
+	if err != nil {
+		return nil, err
+	}
+	d["Order"] = append(order, groupRef)
+	dict["D"] = d
+
+	return dict, nil
+}
@@ -0,0 +1,309 @@
+// seehuhn.de/go/pdf - a library for reading and writing PDF files
+// Copyright (C) 2026  Jochen Voss <voss@seehuhn.de>
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package watermark
+
+import (
+	"bytes"
+	goimage "image"
+	gocolor "image/color"
+	"io"
+	"math"
+	"strings"
+	"testing"
+
+	"seehuhn.de/go/geom/vec"
+
+	"seehuhn.de/go/pdf"
+	"seehuhn.de/go/pdf/graphics/color"
+	"seehuhn.de/go/pdf/graphics/image"
+	"seehuhn.de/go/pdf/internal/rewrite/rewritetest"
+	"seehuhn.de/go/pdf/pagetree"
+	"seehuhn.de/go/pdf/search"
+)
+
+func mark(t *testing.T, r pdf.Getter, opts *Options) *pdf.Reader {
+	t.Helper()
+	return rewritetest.Rewrite(t, r, func(w io.Writer, r pdf.Getter) error {
+		return Write(w, r, opts)
+	})
+}
+
+func TestPlacement(t *testing.T) {
+	r := rewritetest.Source(t)
+	res := mark(t, r, &Options{Text: "CONFIDENTIAL", Diagonal: true})
+
+	hits, err := search.Find(res, "CONFIDENTIAL", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 3 {
+		t.Fatalf("found %d watermarks, want 3", len(hits))
+	}
+
+	// centres of the visible page regions, in default user space
+	centers := []vec.Vec2{{X: 200, Y: 300}, {X: 300, Y: 200}, {X: 200, Y: 300}}
+	// the expected baseline directions: along the diagonal of the
+	// displayed page, which for the rotated page runs from the bottom
+	// right to the top left of the media box
+	dirs := []vec.Vec2{{X: 400, Y: 600}, {X: -600, Y: 400}, {X: 300, Y: 400}}
+	for _, hit := range hits {
+		q := hit.QuadPoints
+		mid := vec.Middle(q[0], q[2])
+		if d := mid.Sub(centers[hit.Page]).Length(); d > 10 {
+			t.Errorf("page %d: watermark centred at %v, want %v", hit.Page+1, mid, centers[hit.Page])
+		}
+		dir := q[1].Sub(q[0]).Normalize()
+		want := dirs[hit.Page].Normalize()
+		if math.Abs(dir.Dot(want)-1) > 1e-3 {
+			t.Errorf("page %d: baseline direction %v, want %v", hit.Page+1, dir, want)
+		}
+	}
+
+	// the body text is unaffected
+	hits, err = search.Find(res, "body text", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 3 {
+		t.Errorf("found %d copies of the body text, want 3", len(hits))
+	}
+}
+
+func TestInFront(t *testing.T) {
+	r := rewritetest.Source(t)
+	res := mark(t, r, &Options{
+		Text:         "DRAFT",
+		Size:         24,
+		Placement:    InFront,
+		Position:     BottomRight,
+		Margin:       10,
+		Transparency: 0.5,
+		Layer:        "Watermark",
+		Pages:        []int{1},
+	})
+
+	c := pdf.NewCursor(res)
+	for i := range 3 {
+		_, dict, err := pagetree.GetPage(res, i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i != 1 {
+			if _, isArray := dict["Contents"].(pdf.Array); isArray {
+				t.Errorf("page %d: unexpected watermark", i+1)
+			}
+			continue
+		}
+		contents, err := c.Array(dict["Contents"])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(contents) != 3 {
+			t.Fatalf("page %d: got %d content streams, want 3", i+1, len(contents))
+		}
+		stm, err := c.Stream(contents[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := pdf.ReadAll(res, nil, stm, 1<<20)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(body), "Q\nq ") || !strings.Contains(string(body), "/Wm1 Do") {
+			t.Errorf("page %d: unexpected content stream %q", i+1, body)
+		}
+	}
+
+	hits, err := search.Find(res, "DRAFT", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Page != 1 {
+		t.Fatalf("unexpected hits %v", hits)
+	}
+	// bottom right of the displayed page is the top right of the media box
+	q := hits[0].QuadPoints
+	var bbox pdf.Rectangle
+	for i, p := range q {
+		if i == 0 {
+			bbox = pdf.Rectangle{LLx: p.X, LLy: p.Y, URx: p.X, URy: p.Y}
+		}
+		bbox.ExtendVec(p)
+	}
+	if math.Abs(bbox.URx-590) > 1 || math.Abs(bbox.URy-390) > 1 || bbox.Dy() < 50 || bbox.Dy() > 100 {
+		t.Errorf("watermark outside the expected region: %v", bbox)
+	}
+
+	checkLayers(t, res, "Watermark")
+}
+
+func TestLayerMerge(t *testing.T) {
+	r := rewritetest.Source(t)
+	res := mark(t, r, &Options{Text: "ONE", Layer: "First"})
+	res = mark(t, res, &Options{Text: "TWO", Layer: "Second"})
+	checkLayers(t, res, "First", "Second")
+}
+
+// checkLayers checks that the document has optional content groups with
+// the given names, all of which are listed in the default configuration.
+func checkLayers(t *testing.T, r pdf.Getter, names ...string) {
+	t.Helper()
+
+	c := pdf.NewCursor(r)
+	props, err := c.Dict(r.GetMeta().Catalog.OCProperties)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ocgs, err := c.Array(props["OCGs"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ocgs) != len(names) {
+		t.Fatalf("got %d optional content groups, want %d", len(ocgs), len(names))
+	}
+	for i, obj := range ocgs {
+		dict, err := c.Dict(obj)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name, _ := c.TextString(dict["Name"])
+		if string(name) != names[i] {
+			t.Errorf("group %d: got name %q, want %q", i, name, names[i])
+		}
+	}
+	d, err := c.Dict(props["D"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	order, err := c.Array(d["Order"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != len(names) {
+		t.Errorf("got %d groups in /Order, want %d", len(order), len(names))
+	}
+}
+
+func TestAnnotation(t *testing.T) {
+	r := rewritetest.Source(t)
+	res := mark(t, r, &Options{Text: "COPY", Placement: AsAnnotation, Position: Top, Margin: 20})
+
+	c := pdf.NewCursor(res)
+	for ref, dict := range pagetree.NewIterator(res).All() {
+		annots, err := c.Array(dict["Annots"])
+		if err != nil || len(annots) != 1 {
+			t.Fatalf("page %v: unexpected annotations %v, %v", ref, annots, err)
+		}
+		a, err := c.Dict(annots[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a["Subtype"] != pdf.Name("Watermark") {
+			t.Errorf("page %v: wrong subtype %v", ref, a["Subtype"])
+		}
+		fp, err := c.Dict(a["FixedPrint"])
+		if err != nil || fp == nil {
+			t.Errorf("page %v: missing FixedPrint", ref)
+			continue
+		}
+		if v, _ := c.Number(fp["V"]); v != 1 {
+			t.Errorf("page %v: V = %v, want 1", ref, v)
+		}
+		if a["P"] != ref {
+			t.Errorf("page %v: annotation refers to page %v", ref, a["P"])
+		}
+	}
+
+	hits, err := search.Find(res, "COPY", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 0 {
+		t.Error("watermark annotation found in page content")
+	}
+}
+
+func TestGraphic(t *testing.T) {
+	r := rewritetest.Source(t)
+
+	// use the rotated page as a logo; in its displayed orientation it is
+	// 400 wide and 600 high
+	logo, err := FromPage(r, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := transformRect(logo.Matrix, logo.BBox); d.Dx() != 400 || d.Dy() != 600 {
+		t.Errorf("unexpected logo size %v", d)
+	}
+	res := mark(t, r, &Options{Graphic: logo, Size: 100, Position: TopLeft})
+
+	hits, err := search.Find(res, "body text", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 6 {
+		t.Fatalf("found %d copies of the body text, want 6", len(hits))
+	}
+
+	img := goimage.NewRGBA(goimage.Rect(0, 0, 4, 2))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	img.Set(1, 1, gocolor.RGBA{R: 0xff, A: 0xff})
+	res = mark(t, r, &Options{
+		Graphic:      image.FromImage(img, color.SpaceDeviceRGB, 8),
+		Transparency: 0.8,
+	})
+	if _, _, err := pagetree.GetPage(res, 2); err != nil {
+		t.Error(err)
+	}
+}
+
+// TestCatalog checks that the document-level data survives the watermark
+// pass, for each way of placing the watermark.
+func TestCatalog(t *testing.T) {
+	for _, opts := range []*Options{
+		{Text: "DRAFT"},
+		{Text: "DRAFT", Layer: "Watermark"},
+		{Text: "DRAFT", Placement: AsAnnotation},
+		{Text: "DRAFT", Pages: []int{1}},
+	} {
+		r := rewritetest.Source(t)
+		res := mark(t, r, opts)
+		rewritetest.CheckCatalog(t, res)
+
+		hasLayer := res.GetMeta().Catalog.OCProperties != nil
+		if hasLayer != (opts.Layer != "") {
+			t.Errorf("%v: OCProperties = %v", opts, res.GetMeta().Catalog.OCProperties)
+		}
+	}
+}
+
+func TestInvalid(t *testing.T) {
+	r := rewritetest.Source(t)
+	for _, opts := range []*Options{
+		nil,
+		{},
+		{Text: "x", Graphic: &image.Dict{}},
+		{Text: "x", Transparency: 2},
+		{Text: "x", Pages: []int{3}},
+	} {
+		if err := Write(&bytes.Buffer{}, r, opts); err == nil {
+			t.Errorf("missing error for %v", opts)
+		}
+	}
+}
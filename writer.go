@@ -43,11 +43,62 @@ type WriterOptions struct {
 	// has been called.
 	DocumentMetadata *MetadataStream
 
+	// Encryption (optional) selects the encryption algorithm, if a password
+	// is set.  The supported algorithms are RC4 with key lengths between
+	// 40 and 128 bits, AES-128 (PDF 1.6 or newer) and AES-256 (PDF 2.0).
+	// If Encryption is nil, the strongest algorithm available for the PDF
+	// version is used.
+	Encryption *Encryption
+
+	// CryptFilters (optional) selects which parts of an encrypted document
+	// are encrypted, and how.  This requires PDF 1.6 or newer and is only
+	// used if a password is set.  If CryptFilters is nil, strings, streams
+	// and embedded files are all encrypted using the standard crypt filter.
+	CryptFilters *CryptFilters
+
 	// If this flag is true, the writer tries to generate a PDF file which is
 	// more human-readable, at the expense of increased file size.
 	HumanReadable bool
 }
 
+// CryptFilters describes the crypt filters of an encrypted PDF file.
+//
+// A document where only the attachments are encrypted, while the pages can
+// be viewed without a password, uses /Identity for StmF and StrF together
+// with a crypt filter for EFF which has EmbeddedFileOpen set.
+type CryptFilters struct {
+	// Named lists the crypt filters of the document, in addition to the
+	// standard crypt filter /StdCF which uses the default cipher for the
+	// PDF version.  An entry for "StdCF" can be used to change the
+	// properties of the standard crypt filter.  The name "Identity" is
+	// reserved.
+	//
+	// Streams can select one of these filters explicitly, by using
+	// [FilterCryptNamed] or [FilterCryptStandard] as the first filter.
+	Named map[Name]*CryptMethod
+
+	// StmF, StrF and EFF name the crypt filters used for streams, strings
+	// and embedded file streams, respectively.  Each value is either
+	// "Identity" (no encryption), "StdCF", or a key of Named.  The empty
+	// name selects "StdCF".
+	StmF Name
+	StrF Name
+	EFF  Name
+}
+
+// CryptMethod describes a named crypt filter.
+type CryptMethod struct {
+	// Cipher is either "AES" or "RC4".  RC4 can only be used for PDF
+	// versions before 2.0.  If Cipher is empty, the default cipher for the
+	// PDF version is used.
+	Cipher string
+
+	// EmbeddedFileOpen indicates that PDF processors should only ask for
+	// the password when an embedded file encrypted with this filter is
+	// opened.
+	EmbeddedFileOpen bool
+}
+
 // Writer represents a PDF file open for writing.
 // Use [Create] or [NewWriter] to create a new Writer.
 type Writer struct {
@@ -90,6 +141,17 @@ type Writer struct {
 	// early.  Callers that need their own embedding scope still
 	// construct their own ResourceManager via NewResourceManager.
 	rm *ResourceManager
+
+	// base is the file being updated, if the Writer was created by
+	// [NewIncrementalWriter].  In this case, the xref map only contains the
+	// objects written as part of the update.
+	base *incrementalBase
+
+	// rootRef and infoRef, if non-zero, are used for the /Root and /Info
+	// entries of the trailer instead of writing meta.Catalog and meta.Info.
+	// See [Writer.SetTrailerRefs].
+	rootRef Reference
+	infoRef Reference
 }
 
 // isEncrypted reports whether the file being written has document-level
@@ -131,6 +193,29 @@ func NewWriter(w io.Writer, v Version, opt *WriterOptions) (*Writer, error) {
 		opt = &WriterOptions{}
 	}
 
+	pdf, err := newWriter(w, v, opt)
+	if err != nil {
+		return nil, err
+	}
+
+	// commit the document-level metadata stream now, while opt is still
+	// in scope and Plaintext is the value the user signed up for.  The
+	// reference is cached on pdf.rm and re-used in Close to wire the
+	// catalog dict.
+	if opt.DocumentMetadata != nil {
+		err := pdf.commitMetadata(pdf.Alloc(), opt.DocumentMetadata)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return pdf, nil
+}
+
+// newWriter implements [NewWriter], except for writing the document
+// metadata stream.
+func newWriter(w io.Writer, v Version, opt *WriterOptions) (*Writer, error) {
+
 	versionString, err := v.ToString() // check for valid version
 	if err != nil {
 		return nil, err
@@ -185,32 +270,9 @@ func NewWriter(w io.Writer, v Version, opt *WriterOptions) (*Writer, error) {
 
 	var enc *encryptInfo
 	if useEncryption {
-		var cf *cryptFilter
-		var V int
-		if v >= V2_0 {
-			cf = &cryptFilter{
-				Cipher: cipherAES,
-				Length: 256,
-			}
-			V = 5
-		} else if v >= V1_6 {
-			cf = &cryptFilter{
-				Cipher: cipherAES,
-				Length: 128,
-			}
-			V = 4
-		} else if v >= V1_4 {
-			cf = &cryptFilter{
-				Cipher: cipherRC4,
-				Length: 128,
-			}
-			V = 2
-		} else {
-			cf = &cryptFilter{
-				Cipher: cipherRC4,
-				Length: 40,
-			}
-			V = 1
+		cf, V, err := chooseCipher(v, opt.Encryption)
+		if err != nil {
+			return nil, err
 		}
 		sec, err := createStdSecHandler(ID[0], opt.UserPassword,
 			opt.OwnerPassword, opt.UserPermissions, cf.Length, V,
@@ -224,6 +286,20 @@ func NewWriter(w io.Writer, v Version, opt *WriterOptions) (*Writer, error) {
 			strF: cf,
 			efF:  cf,
 		}
+		if V >= 4 {
+			enc.cf = map[Name]*cryptFilter{"StdCF": cf}
+		}
+		if opt.CryptFilters != nil {
+			if v < V1_6 {
+				return nil, &VersionError{Operation: "crypt filters", Earliest: V1_6}
+			} else if V < 4 {
+				return nil, errors.New("crypt filters require AES encryption")
+			}
+			err := enc.setCryptFilters(opt.CryptFilters)
+			if err != nil {
+				return nil, err
+			}
+		}
 
 		encryptDict, err := enc.AsDict(v)
 		if err != nil {
@@ -290,24 +366,19 @@ func NewWriter(w io.Writer, v Version, opt *WriterOptions) (*Writer, error) {
 		}
 	}
 
-	// commit the document-level metadata stream now, while opt is still
-	// in scope and Plaintext is the value the user signed up for.  The
-	// reference is cached on pdf.rm and re-used in Close to wire the
-	// catalog dict.
-	if opt.DocumentMetadata != nil {
-		metaRef := pdf.Alloc()
-		if unencryptedMetadata {
-			pdf.refIsPlaintext[metaRef] = true
-		}
-		e := &EmbedHelper{rm: pdf.rm, copiers: map[*Extractor]*Copier{}}
-		if _, err := e.EmbedAt(metaRef, opt.DocumentMetadata); err != nil {
-			return nil, err
-		}
-	}
-
 	return pdf, nil
 }
 
+// commitMetadata writes the document metadata stream md at ref.
+func (w *Writer) commitMetadata(ref Reference, md *MetadataStream) error {
+	if md.Plaintext {
+		w.refIsPlaintext[ref] = true
+	}
+	e := &EmbedHelper{rm: w.rm, copiers: map[*Extractor]*Copier{}}
+	_, err := e.EmbedAt(ref, md)
+	return err
+}
+
 // Close closes the Writer, flushing any unwritten data to the underlying
 // io.Writer.
 func (w *Writer) Close() error {
@@ -325,13 +396,26 @@ func (w *Writer) Close() error {
 		return errors.New("Catalog.Metadata changed after NewWriter")
 	}
 
-	catRef, err := w.rm.Store(w.meta.Catalog)
-	if err != nil {
-		return fmt.Errorf("failed to write document catalog: %w", err)
+	var skipInfo bool
+	if w.base != nil {
+		skipInfo = w.closeIncremental(trailer)
 	}
-	trailer["Root"] = catRef
 
-	if w.meta.Info != nil {
+	if w.rootRef != 0 {
+		trailer["Root"] = w.rootRef
+	} else {
+		catRef, err := w.rm.Store(w.meta.Catalog)
+		if err != nil {
+			return fmt.Errorf("failed to write document catalog: %w", err)
+		}
+		trailer["Root"] = catRef
+	}
+
+	if w.infoRef != 0 {
+		trailer["Info"] = w.infoRef
+	} else if skipInfo {
+		// the Info dictionary of the original file is kept
+	} else if w.meta.Info != nil {
 		infoRef, err := w.rm.Embed(w.meta.Info)
 		if err != nil {
 			return err
@@ -345,7 +429,8 @@ func (w *Writer) Close() error {
 		delete(trailer, "Info")
 	}
 
-	if err := w.rm.Close(); err != nil {
+	err := w.rm.Close()
+	if err != nil {
 		return err
 	}
 
@@ -388,6 +473,17 @@ func (w *Writer) Close() error {
 	return nil
 }
 
+// SetTrailerRefs sets the /Root and /Info entries of the trailer to the
+// given references.  This is used when the document catalog and the document
+// information dictionary are written by the caller, for example when
+// copying all objects of a file.  In this case, Close does not write
+// the Catalog and Info fields of [Writer.GetMeta].  A zero info reference
+// keeps the default behaviour for the /Info entry.
+func (w *Writer) SetTrailerRefs(root, info Reference) {
+	w.rootRef = root
+	w.infoRef = info
+}
+
 // GetMeta returns the MetaInfo for the PDF file.
 func (w *Writer) GetMeta() *MetaInfo {
 	return &w.meta
@@ -409,6 +505,16 @@ func (w *Writer) Alloc() Reference {
 	return res
 }
 
+// Reserve makes sure that [Writer.Alloc] only returns object numbers
+// larger than the object number of ref.  This is used when objects are
+// written at object numbers chosen by the caller, for example when the
+// object numbers of an existing file are kept, so that the objects
+// allocated by the writer itself (stream lengths, object streams, the
+// cross-reference stream) do not clash with these.
+func (w *Writer) Reserve(ref Reference) {
+	w.nextRef = max(w.nextRef, ref.Number()+1)
+}
+
 // Get returns the object with the given reference from the PDF file.
 //
 // If the underlying io.Writer does not support seeking, Get will return an
@@ -429,6 +535,9 @@ func (w *Writer) get(ref Reference, canObjStm, scalarOnly bool) (obj Native, err
 	}
 
 	entry := w.xref[ref.Number()]
+	if entry == nil && w.base != nil {
+		return w.base.r.Get(ref, canObjStm)
+	}
 	if entry.IsFree() || entry.Generation != ref.Generation() {
 		return nil, nil
 	}
@@ -691,11 +800,10 @@ func (w *Writer) OpenStream(ref Reference, dict Dict, filters ...Filter) (io.Wri
 	}
 
 	// Per PDF spec §7.4.10, a Crypt filter must be the first entry in
-	// the /Filter array.  Reject API misuse and detect a leading Crypt
-	// filter so the document-level encryption wrap can be skipped (the
-	// CryptFilter is responsible for that stream's encryption recipe).
-	// Bail before setXRef so a not-yet-supported CryptFilter does not
-	// leave a dirty xref entry pointing at an uncreated stream.
+	// the /Filter array.  Reject API misuse and determine the crypt filter
+	// for the stream data.  Bail before setXRef so that an unknown crypt
+	// filter does not leave a dirty xref entry pointing at an uncreated
+	// stream.
 	var leadingCrypt CryptFilter
 	for i, f := range filters {
 		cf, isCrypt := f.(CryptFilter)
@@ -705,13 +813,14 @@ func (w *Writer) OpenStream(ref Reference, dict Dict, filters ...Filter) (io.Wri
 		if i != 0 {
 			return nil, errors.New("Crypt filter must be the first filter in OpenStream")
 		}
-		if _, ok := cf.(FilterCryptIdentity); !ok {
-			return nil, fmt.Errorf("OpenStream: %T encoding is not yet supported", cf)
-		}
 		leadingCrypt = cf
 	}
+	crypt, err := w.streamCrypt(ref, dict, leadingCrypt)
+	if err != nil {
+		return nil, fmt.Errorf("Writer.OpenStream: %w", err)
+	}
 
-	err := w.setXRef(ref, &xRefEntry{Pos: w.w.pos, Generation: ref.Generation()})
+	err = w.setXRef(ref, &xRefEntry{Pos: w.w.pos, Generation: ref.Generation()})
 	if err != nil {
 		return nil, fmt.Errorf("Writer.OpenStream: %w", err)
 	}
@@ -758,24 +867,8 @@ func (w *Writer) OpenStream(ref Reference, dict Dict, filters ...Filter) (io.Wri
 		length:     length,
 	}
 
-	// Skip the document-level encryption wrap when:
-	//   - the writer has explicitly marked this ref as plaintext (catalog
-	//     metadata stream when /EncryptMetadata false), or
-	//   - the stream's wire form declares a Crypt filter at position 0 of
-	//     /Filter — either passed in via filters... or already present in
-	//     dict["Filter"] (e.g. for a copied stream being written through
-	//     Writer.Put).  Per PDF spec §7.4.10 the explicit Crypt filter
-	//     overrides the default StmF.
-	skipDefaultEncrypt := w.refIsPlaintext[ref] || leadingCrypt != nil
-	if !skipDefaultEncrypt {
-		startsWithCrypt, err := filterChainStartsWithCrypt(w, streamDict["Filter"])
-		if err != nil {
-			return nil, err
-		}
-		skipDefaultEncrypt = startsWithCrypt
-	}
-	if w.w.enc != nil && !skipDefaultEncrypt {
-		enc, err := w.w.enc.EncryptStream(ref, streamBody)
+	if crypt != nil {
+		enc, err := w.w.enc.encryptStream(crypt, ref, streamBody)
 		if err != nil {
 			return nil, err
 		}
@@ -783,9 +876,13 @@ func (w *Writer) OpenStream(ref Reference, dict Dict, filters ...Filter) (io.Wri
 	}
 
 	for _, filter := range filters {
-		streamBody, err = filter.Encode(w.meta.Version, streamBody)
-		if err != nil {
-			return nil, err
+		// A leading Crypt filter only names the crypt filter, the
+		// encryption was set up above.
+		if _, isCrypt := filter.(CryptFilter); !isCrypt {
+			streamBody, err = filter.Encode(w.meta.Version, streamBody)
+			if err != nil {
+				return nil, err
+			}
 		}
 
 		name, parms, err := filter.Info(w.meta.Version)
@@ -799,6 +896,90 @@ func (w *Writer) OpenStream(ref Reference, dict Dict, filters ...Filter) (io.Wri
 	return streamBody, nil
 }
 
+// chooseCipher returns the crypt filter and the /V value of the encryption
+// dictionary for the given encryption algorithm.  If e is nil, the
+// strongest algorithm available for the PDF version is used.
+func chooseCipher(v Version, e *Encryption) (*cryptFilter, int, error) {
+	if e == nil {
+		switch {
+		case v >= V2_0:
+			return &cryptFilter{Cipher: cipherAES, Length: 256}, 5, nil
+		case v >= V1_6:
+			return &cryptFilter{Cipher: cipherAES, Length: 128}, 4, nil
+		case v >= V1_4:
+			return &cryptFilter{Cipher: cipherRC4, Length: 128}, 2, nil
+		default:
+			return &cryptFilter{Cipher: cipherRC4, Length: 40}, 1, nil
+		}
+	}
+
+	var V int
+	var earliest Version
+	switch {
+	case e.Cipher == "RC4" && e.KeyLength == 40:
+		V, earliest = 1, V1_1
+	case e.Cipher == "RC4" && e.KeyLength > 40 && e.KeyLength <= 128 && e.KeyLength%8 == 0:
+		V, earliest = 2, V1_4
+	case e.Cipher == "AES" && e.KeyLength == 128:
+		V, earliest = 4, V1_6
+	case e.Cipher == "AES" && e.KeyLength == 256:
+		V, earliest = 5, V2_0
+	default:
+		return nil, 0, fmt.Errorf("unsupported encryption algorithm %s", e)
+	}
+	if v < earliest {
+		return nil, 0, &VersionError{Operation: e.String() + " encryption", Earliest: earliest}
+	}
+	cipher := cipherRC4
+	if e.Cipher == "AES" {
+		cipher = cipherAES
+	}
+	return &cryptFilter{Cipher: cipher, Length: e.KeyLength}, V, nil
+}
+
+// streamCrypt returns the crypt filter used to encrypt the data of the
+// stream ref, or nil if the data is written unencrypted.
+//
+// The writer skips encryption for references marked as plaintext (the
+// catalog metadata stream when /EncryptMetadata is false).  Otherwise, a
+// Crypt filter at position 0 of the filter chain, either passed in as
+// explicit (from the filters argument of OpenStream) or already present in
+// dict["Filter"] (e.g. for a copied stream being written through
+// Writer.Put), overrides the document default.  Embedded file streams are
+// recognised by their /Type entry.
+func (w *Writer) streamCrypt(ref Reference, dict Dict, explicit CryptFilter) (*cryptFilter, error) {
+	if explicit == nil {
+		startsWithCrypt, err := filterChainStartsWithCrypt(w, dict["Filter"])
+		if err != nil {
+			return nil, err
+		}
+		if startsWithCrypt {
+			filters, err := GetFilters(w, nil, dict)
+			if err != nil {
+				return nil, err
+			}
+			explicit = filters[0].(CryptFilter)
+		}
+	}
+
+	enc := w.w.enc
+	if enc == nil {
+		if _, isIdentity := explicit.(FilterCryptIdentity); explicit != nil && !isIdentity {
+			return nil, errors.New("Crypt filter requires an encrypted document")
+		}
+		return nil, nil
+	}
+	if w.refIsPlaintext[ref] {
+		return nil, nil
+	}
+
+	tp, err := Resolve(w, dict["Type"])
+	if err != nil {
+		return nil, err
+	}
+	return enc.streamFilter(explicit, tp == Name("EmbeddedFile"))
+}
+
 type streamWriter struct {
 	parent     *Writer
 	streamDict Dict
@@ -22,6 +22,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"iter"
 	"math"
 	"math/bits"
 	"strconv"
@@ -545,25 +546,31 @@ func (w *Writer) setXRef(ref Reference, entry *xRefEntry) error {
 }
 
 func (w *Writer) writeXRefTable(xRefDict Dict) error {
-	_, err := fmt.Fprintf(w.w, "xref\n0 %d\n", w.nextRef)
+	_, err := w.w.Write([]byte("xref\n"))
 	if err != nil {
 		return err
 	}
-	for i := uint32(0); i < w.nextRef; i++ {
-		entry := w.xref[i]
-		if entry != nil && entry.InStream != 0 {
-			return errors.New("cannot use xref tables with object streams")
-		}
-		if entry != nil && entry.Pos >= 0 {
-			_, err = fmt.Fprintf(w.w, "%010d %05d n\r\n",
-				entry.Pos, entry.Generation)
-		} else {
-			// free object
-			_, err = w.w.Write([]byte("0000000000 65535 f\r\n"))
-		}
+	for _, sec := range w.xRefSections() {
+		_, err = fmt.Fprintf(w.w, "%d %d\n", sec[0], sec[1])
 		if err != nil {
 			return err
 		}
+		for i := sec[0]; i < sec[0]+sec[1]; i++ {
+			entry := w.xref[i]
+			if entry != nil && entry.InStream != 0 {
+				return errors.New("cannot use xref tables with object streams")
+			}
+			if entry != nil && entry.Pos >= 0 {
+				_, err = fmt.Fprintf(w.w, "%010d %05d n\r\n",
+					entry.Pos, entry.Generation)
+			} else {
+				// free object
+				_, err = w.w.Write([]byte("0000000000 65535 f\r\n"))
+			}
+			if err != nil {
+				return err
+			}
+		}
 	}
 
 	_, err = w.w.Write([]byte("trailer\n"))
@@ -630,7 +637,15 @@ func (w *Writer) writeXRefStream(xRefDict Dict) error {
 		return err
 	}
 	wx := bufio.NewWriter(wxRaw)
-	for i := uint32(0); i < w.nextRef; i++ {
+	sections := w.xRefSections()
+	if w.base != nil {
+		var index Array
+		for _, sec := range sections {
+			index = append(index, Integer(sec[0]), Integer(sec[1]))
+		}
+		xRefDict["Index"] = index
+	}
+	for i := range xRefNumbers(sections) {
 		entry := w.xref[i]
 		if entry == nil {
 			err := wx.WriteByte(0)
@@ -716,6 +731,20 @@ func (w *Writer) writeXRefStream(xRefDict Dict) error {
 	return err
 }
 
+// xRefNumbers iterates over the object numbers in the given xref
+// subsections.
+func xRefNumbers(sections [][2]uint32) iter.Seq[uint32] {
+	return func(yield func(uint32) bool) {
+		for _, sec := range sections {
+			for i := sec[0]; i < sec[0]+sec[1]; i++ {
+				if !yield(i) {
+					return
+				}
+			}
+		}
+	}
+}
+
 func encodeInt64(data io.ByteWriter, x uint64, w int) error {
 	for i := w - 1; i >= 0; i-- {
 		err := data.WriteByte(byte(x >> (i * 8)))